@@ -113,7 +113,7 @@ async def chunk_text(
 
     async with httpx.AsyncClient(timeout=TIMEOUT) as client:
         response = await client.post(
-            f"{CHUNKER_SERVICE_URL}/chunk",
+            f"{CHUNKER_SERVICE_URL}/v1/chunk",
             json=payload,
             headers=get_auth_headers(),
         )
@@ -161,7 +161,7 @@ def chunk_text(
     print(f"Plan: {json.dumps(plan, indent=2)}")
 
     response = httpx.post(
-        f"{chunker_url}/chunk",
+        f"{chunker_url}/v1/chunk",
         json={
             "text": markdown_text,
             "plan": plan,
@@ -0,0 +1,44 @@
+package main
+
+import (
+	"expvar"
+	"log/slog"
+	"net"
+	"net/http"
+	"net/http/pprof"
+	"time"
+)
+
+// adminMux serves the Go runtime's profiling (/debug/pprof/) and metrics
+// (/debug/vars) endpoints.
+func adminMux() *http.ServeMux {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/debug/pprof/", pprof.Index)
+	mux.HandleFunc("/debug/pprof/cmdline", pprof.Cmdline)
+	mux.HandleFunc("/debug/pprof/profile", pprof.Profile)
+	mux.HandleFunc("/debug/pprof/symbol", pprof.Symbol)
+	mux.HandleFunc("/debug/pprof/trace", pprof.Trace)
+	mux.Handle("/debug/vars", expvar.Handler())
+	return mux
+}
+
+// startAdmin serves adminMux on addr in the background. The admin
+// listener has no TLS or auth, and profiles expose internals, so addr
+// should only be reachable from the pod or through port-forwarding. It
+// has no write timeout because CPU profiles and traces stream for as long
+// as requested.
+func startAdmin(addr string) {
+	expvar.Publish("jobs_pending", expvar.Func(func() interface{} { return jobPending.Load() }))
+	expvar.Publish("jobs_running", expvar.Func(func() interface{} { return jobScheduler.Running() }))
+	lis, err := net.Listen("tcp", addr)
+	if err != nil {
+		fatal("admin listen failed", err)
+	}
+	srv := &http.Server{Handler: adminMux(), ReadHeaderTimeout: 10 * time.Second}
+	slog.Info("admin endpoints (pprof, expvar) listening", "addr", addr)
+	go func() {
+		if err := srv.Serve(lis); err != nil {
+			slog.Error("admin server failed", "error", err)
+		}
+	}()
+}
@@ -0,0 +1,136 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strings"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+
+	"chunker-service/pkg/jwtauth"
+)
+
+// Scopes a bearer token needs. Producing chunks for ingestion needs
+// chunk:write; the read-only endpoints need chunk:read.
+const (
+	scopeWrite = "chunk:write"
+	scopeRead  = "chunk:read"
+)
+
+// verifier checks bearer tokens when auth is configured; endpoints are
+// open when it is nil.
+var verifier *jwtauth.Verifier
+
+func loadAuth(cfg authConfig) {
+	if cfg.JWKSURL == "" && cfg.Issuer == "" {
+		return
+	}
+	verifier = &jwtauth.Verifier{JWKSURL: cfg.JWKSURL, Issuer: cfg.Issuer, Audience: cfg.Audience}
+	slog.Info("bearer token auth enabled", "issuer", cfg.Issuer, "audience", cfg.Audience)
+}
+
+// bearerToken extracts the token from an Authorization header value.
+func bearerToken(header string) (string, bool) {
+	scheme, token, ok := strings.Cut(header, " ")
+	if !ok || !strings.EqualFold(scheme, "Bearer") {
+		return "", false
+	}
+	token = strings.TrimSpace(token)
+	return token, token != ""
+}
+
+// authorize verifies the bearer token in header, checks it grants scope
+// and returns its claims. The returned error is a gRPC status so both
+// APIs can use it.
+func authorize(ctx context.Context, header, scope string) (*jwtauth.Claims, error) {
+	token, ok := bearerToken(header)
+	if !ok {
+		return nil, status.Error(codes.Unauthenticated, "missing bearer token")
+	}
+	claims, err := verifier.Verify(ctx, token)
+	if errors.Is(err, jwtauth.ErrInvalidToken) {
+		return nil, status.Error(codes.Unauthenticated, err.Error())
+	}
+	if err != nil {
+		slog.Error("token verification unavailable", "request_id", requestID(ctx), "error", err)
+		return nil, status.Error(codes.Unavailable, "token verification unavailable")
+	}
+	if !claims.HasScope(scope) {
+		return nil, status.Error(codes.PermissionDenied, fmt.Sprintf("token lacks scope %q", scope))
+	}
+	return claims, nil
+}
+
+type claimsKey struct{}
+
+// tokenSubject returns the subject of the bearer token a request was
+// authorized with, or "" when auth is disabled.
+func tokenSubject(ctx context.Context) string {
+	if claims, ok := ctx.Value(claimsKey{}).(*jwtauth.Claims); ok {
+		return claims.Subject
+	}
+	return ""
+}
+
+// requireScope wraps h so requests need a bearer token granting scope.
+// The token's claims are passed to h in the request context.
+func requireScope(scope string, h http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if verifier == nil {
+			h(w, r)
+			return
+		}
+		claims, err := authorize(r.Context(), r.Header.Get("Authorization"), scope)
+		switch status.Code(err) {
+		case codes.OK:
+			h(w, r.WithContext(context.WithValue(r.Context(), claimsKey{}, claims)))
+		case codes.Unauthenticated:
+			challenge := `Bearer error="invalid_token"`
+			if r.Header.Get("Authorization") == "" {
+				challenge = "Bearer"
+			}
+			w.Header().Set("WWW-Authenticate", challenge)
+			writeJSON(w, http.StatusUnauthorized, errorResponse{Error: status.Convert(err).Message(), Code: "invalid_token"})
+		case codes.PermissionDenied:
+			w.Header().Set("WWW-Authenticate", fmt.Sprintf(`Bearer error="insufficient_scope", scope=%q`, scope))
+			writeJSON(w, http.StatusForbidden, errorResponse{Error: status.Convert(err).Message(), Code: "insufficient_scope"})
+		default:
+			writeJSON(w, http.StatusServiceUnavailable, errorResponse{Error: status.Convert(err).Message(), Code: "auth_unavailable"})
+		}
+	}
+}
+
+// grpcAuth returns interceptors enforcing chunk:write on every gRPC
+// method, reading the token from the authorization metadata.
+func grpcAuth() []grpc.ServerOption {
+	check := func(ctx context.Context) error {
+		var header string
+		if md, ok := metadata.FromIncomingContext(ctx); ok {
+			if v := md.Get("authorization"); len(v) > 0 {
+				header = v[0]
+			}
+		}
+		_, err := authorize(ctx, header, scopeWrite)
+		return err
+	}
+	return []grpc.ServerOption{
+		grpc.ChainUnaryInterceptor(func(ctx context.Context, req interface{}, _ *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+			if err := check(ctx); err != nil {
+				return nil, err
+			}
+			return handler(ctx, req)
+		}),
+		grpc.ChainStreamInterceptor(func(srv interface{}, ss grpc.ServerStream, _ *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+			if err := check(ss.Context()); err != nil {
+				return err
+			}
+			return handler(srv, ss)
+		}),
+	}
+}
@@ -0,0 +1,160 @@
+package main
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"chunker-service/pkg/chunking"
+	"chunker-service/pkg/tokenizer"
+	"chunker-service/pkg/yamljson"
+)
+
+// catalogRoutingFiles are the names tried, in order, for the catalog's
+// routing file, which has the shape of the config file's routing section.
+var catalogRoutingFiles = []string{"routing.json", "routing.yaml", "routing.yml"}
+
+// catalog loads plan presets, routing and tokenizers from a directory and
+// reloads them when its files change, so plans can be tuned by editing a
+// ConfigMap instead of redeploying. A catalog without a routing file
+// leaves the config file's routing in effect. A catalog that fails to
+// load or validate is logged and the previous one kept.
+//
+// Tokenizers are registered as they load and a tokenizer removed from
+// the catalog stays registered until the server restarts.
+type catalog struct {
+	dir      string
+	fallback chunking.Routing
+	// version is that of the files last loaded and failed that of the
+	// files last rejected, which are not retried until they change.
+	version string
+	failed  string
+}
+
+// loadCatalog loads cfg.Dir, exiting if it is invalid, and polls it for
+// changes until the server shuts down.
+func loadCatalog(cfg catalogConfig, fallback chunking.Routing) {
+	c := &catalog{dir: cfg.Dir, fallback: fallback}
+	if err := c.reload(); err != nil {
+		fatal("failed to load catalog", err)
+	}
+	if cfg.ReloadSeconds > 0 {
+		go c.watch(seconds(cfg.ReloadSeconds))
+	}
+}
+
+func (c *catalog) watch(every time.Duration) {
+	t := time.NewTicker(every)
+	defer t.Stop()
+	for {
+		select {
+		case <-serverCtx.Done():
+			return
+		case <-t.C:
+			if err := c.reload(); err != nil {
+				slog.Error("catalog reload failed; keeping the previous catalog", "dir", c.dir, "error", err)
+			}
+		}
+	}
+}
+
+// reload loads the catalog if its files changed since the last attempt.
+func (c *catalog) reload() error {
+	version, err := catalogVersion(c.dir)
+	if err != nil {
+		return err
+	}
+	if version == c.version || version == c.failed {
+		return nil
+	}
+	r, err := c.routing()
+	if err != nil {
+		c.failed = version
+		return err
+	}
+	names, err := tokenizer.LoadDir(c.dir)
+	if err != nil {
+		c.failed = version
+		return fmt.Errorf("tokenizers: %w", err)
+	}
+	routing.Store(r)
+	c.version = version
+	slog.Info("catalog loaded", "dir", c.dir, "version", version, "presets", len(r.Presets), "routes", len(r.Routes), "tokenizers", names)
+	return nil
+}
+
+// routing reads and validates the catalog's routing file, or returns the
+// fallback when there is none.
+func (c *catalog) routing() (*chunking.Routing, error) {
+	for _, name := range catalogRoutingFiles {
+		path := filepath.Join(c.dir, name)
+		data, err := yamljson.ReadFile(path)
+		if errors.Is(err, fs.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		var r chunking.Routing
+		dec := json.NewDecoder(bytes.NewReader(data))
+		dec.DisallowUnknownFields()
+		if err := dec.Decode(&r); err != nil {
+			return nil, fmt.Errorf("invalid %s: %w", name, err)
+		}
+		if err := r.Validate(); err != nil {
+			return nil, fmt.Errorf("%s: %w", name, err)
+		}
+		return &r, nil
+	}
+	r := c.fallback
+	return &r, nil
+}
+
+// catalogVersion hashes the names, sizes and modification times of the
+// files in dir and its subdirectories, following symlinks and skipping
+// hidden entries. A ConfigMap update swaps every key's symlink to freshly
+// written files, so it changes the version like an edit in place does.
+func catalogVersion(dir string) (string, error) {
+	var lines []string
+	var walk func(path, rel string) error
+	walk = func(path, rel string) error {
+		entries, err := os.ReadDir(path)
+		if err != nil {
+			return err
+		}
+		for _, e := range entries {
+			if strings.HasPrefix(e.Name(), ".") {
+				continue
+			}
+			info, err := os.Stat(filepath.Join(path, e.Name()))
+			if err != nil {
+				return err
+			}
+			name := filepath.Join(rel, e.Name())
+			if info.IsDir() {
+				if err := walk(filepath.Join(path, e.Name()), name); err != nil {
+					return err
+				}
+				continue
+			}
+			lines = append(lines, fmt.Sprintf("%s %d %d", name, info.Size(), info.ModTime().UnixNano()))
+		}
+		return nil
+	}
+	if err := walk(dir, ""); err != nil {
+		return "", err
+	}
+	sort.Strings(lines)
+	sum := sha256.Sum256([]byte(strings.Join(lines, "\n")))
+	return hex.EncodeToString(sum[:6]), nil
+}
@@ -0,0 +1,71 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"chunker-service/pkg/chunking"
+)
+
+func TestCatalogReload(t *testing.T) {
+	old := routing.Load()
+	t.Cleanup(func() { routing.Store(old) })
+	captureLogs(t, logConfig{Format: logFormatText, Level: "info"})
+
+	dir := t.TempDir()
+	path := filepath.Join(dir, "routing.yaml")
+	mod := time.Now().Add(-time.Hour)
+	write := func(text string) {
+		t.Helper()
+		if err := os.WriteFile(path, []byte(text), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		mod = mod.Add(time.Second)
+		if err := os.Chtimes(path, mod, mod); err != nil {
+			t.Fatal(err)
+		}
+	}
+	route := func(ext string) string { return routing.Load().Routes[ext] }
+
+	fallback := chunking.Routing{Routes: map[string]string{".md": "markdown"}}
+	c := &catalog{dir: dir, fallback: fallback}
+	// Without a routing file the config's routing stays in effect.
+	if err := c.reload(); err != nil || route(".md") != "markdown" {
+		t.Fatalf("expected the fallback routing, got %v, %v", routing.Load(), err)
+	}
+
+	write("presets:\n  code: {mode: lines, window_size: 60}\nroutes:\n  .go: code\n")
+	if err := c.reload(); err != nil || route(".go") != "code" || route(".md") != "" {
+		t.Fatalf("expected the catalog's routing, got %v, %v", routing.Load(), err)
+	}
+	loaded := routing.Load()
+	if err := c.reload(); err != nil || routing.Load() != loaded {
+		t.Errorf("an unchanged catalog should not reload, got %v", err)
+	}
+
+	// Invalid catalogs are rejected and the last good one kept.
+	for _, bad := range []string{
+		"presets: [",
+		"presets:\n  code: {windw_size: 60}\nroutes:\n  .go: code\n",
+		"routes:\n  .go: missing\n",
+	} {
+		write(bad)
+		if err := c.reload(); err == nil {
+			t.Errorf("expected %q to be rejected", bad)
+		}
+		if routing.Load() != loaded {
+			t.Errorf("%q replaced the previous catalog", bad)
+		}
+		// The rejected files are not retried until they change.
+		if err := c.reload(); err != nil {
+			t.Errorf("a rejected catalog was retried: %v", err)
+		}
+	}
+
+	write("presets:\n  code: {mode: lines, window_size: 60}\nroutes:\n  .py: code\n")
+	if err := c.reload(); err != nil || route(".py") != "code" || route(".go") != "" {
+		t.Errorf("expected the fixed catalog loaded, got %v, %v", routing.Load(), err)
+	}
+}
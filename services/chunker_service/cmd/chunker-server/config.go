@@ -0,0 +1,575 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"sync/atomic"
+
+	"chunker-service/pkg/chunking"
+	"chunker-service/pkg/embedding"
+	"chunker-service/pkg/jobs"
+	"chunker-service/pkg/yamljson"
+)
+
+// serverConfig holds the settings of the HTTP and gRPC listeners. Each
+// setting comes from, in increasing precedence, its default, the config
+// file (-config or CHUNKER_CONFIG), its CHUNKER_* environment variable and
+// its command-line flag.
+type serverConfig struct {
+	Addr     string         `json:"addr"`
+	GRPCAddr string         `json:"grpc_addr,omitempty"`
+	TLS      tlsConfig      `json:"tls"`
+	Auth     authConfig     `json:"auth"`
+	Limits   limitsConfig   `json:"limits"`
+	Timeouts timeoutsConfig `json:"timeouts"`
+	Jobs     jobsConfig     `json:"jobs"`
+	Index    indexConfig    `json:"index"`
+	// DefaultPlan holds plan fields applied to every request that does not
+	// set them itself.
+	DefaultPlan json.RawMessage `json:"default_plan,omitempty"`
+
+	// AdminAddr, when set, serves pprof and expvar on a separate plain
+	// HTTP listener without auth.
+	AdminAddr string `json:"admin_addr,omitempty"`
+
+	// Routing applies plan presets by MIME type or file extension to
+	// requests whose plan names no strategy.
+	Routing chunking.Routing `json:"routing,omitempty"`
+
+	Log logConfig `json:"log"`
+
+	// Catalog, when its Dir is set, loads routing and tokenizers from a
+	// directory, such as a mounted ConfigMap, and reloads them when it
+	// changes.
+	Catalog catalogConfig `json:"catalog"`
+
+	Embedding embeddingConfig `json:"embedding"`
+	// MetaSchema is the metadata schema applied to every request on top
+	// of the plan's.
+	MetaSchema json.RawMessage `json:"meta_schema,omitempty"`
+	// TokenizerDir holds model tokenizers loaded at startup.
+	TokenizerDir string `json:"tokenizer_dir,omitempty"`
+	// AuditLog is the JSONL file successful chunk requests are recorded
+	// in for replay.
+	AuditLog string `json:"audit_log,omitempty"`
+}
+
+// tlsConfig enables TLS on both listeners when set. ClientCAFile turns on
+// client certificate verification (mTLS); ClientAuth is "require"
+// (default) or "optional".
+type tlsConfig struct {
+	CertFile     string `json:"cert_file,omitempty"`
+	KeyFile      string `json:"key_file,omitempty"`
+	ClientCAFile string `json:"client_ca_file,omitempty"`
+	ClientAuth   string `json:"client_auth,omitempty"`
+}
+
+// authConfig enables bearer token auth when JWKSURL or Issuer is set.
+// Without JWKSURL the key set is discovered from the issuer.
+type authConfig struct {
+	JWKSURL  string `json:"jwks_url,omitempty"`
+	Issuer   string `json:"issuer,omitempty"`
+	Audience string `json:"audience,omitempty"`
+}
+
+type limitsConfig struct {
+	MaxTotalChunks  int   `json:"max_total_chunks"`
+	MaxOutputBytes  int   `json:"max_output_bytes"`
+	MaxRequestBytes int64 `json:"max_request_bytes"`
+}
+
+// timeoutsConfig is in whole seconds; zero disables a timeout.
+type timeoutsConfig struct {
+	ReadSeconds          int `json:"read_seconds"`
+	WriteSeconds         int `json:"write_seconds"`
+	IdleSeconds          int `json:"idle_seconds"`
+	ShutdownGraceSeconds int `json:"shutdown_grace_seconds"`
+}
+
+// jobsConfig configures the asynchronous job API. Store is "memory" or
+// "redis"; only redis lets every replica answer for every job. Jobs are
+// forgotten TTLSeconds after their last update. CallbackSecret enables
+// completion callbacks, signed with it, to CallbackHosts (any host with
+// public addresses when empty). MaxPerTenant caps the jobs of one tenant
+// running at once and TenantWeights, by tenant, shares the workers
+// between tenants with jobs waiting (1 when unlisted).
+type jobsConfig struct {
+	Store          string         `json:"store"`
+	RedisURL       string         `json:"redis_url,omitempty"`
+	TTLSeconds     int            `json:"ttl_seconds"`
+	Workers        int            `json:"workers"`
+	MaxPending     int            `json:"max_pending"`
+	TimeoutSeconds int            `json:"timeout_seconds"`
+	CallbackSecret string         `json:"callback_secret,omitempty"`
+	CallbackHosts  []string       `json:"callback_hosts,omitempty"`
+	MaxPerTenant   int            `json:"max_per_tenant"`
+	TenantWeights  map[string]int `json:"tenant_weights,omitempty"`
+}
+
+// indexConfig enables the built-in vector index behind /index and
+// /search when Store is "memory". The index lives in the process and is
+// lost on restart, so it suits demos and laptop deployments rather than
+// production, which uses the vector gateway.
+type indexConfig struct {
+	Store string `json:"store,omitempty"`
+}
+
+// logConfig selects the log output: Format is "json" (default) or "text"
+// and Level one of debug, info (default), warn or error.
+type logConfig struct {
+	Format string `json:"format"`
+	Level  string `json:"level"`
+}
+
+// catalogConfig names the catalog directory, polled for changes every
+// ReloadSeconds; zero loads it once at startup.
+type catalogConfig struct {
+	Dir           string `json:"dir,omitempty"`
+	ReloadSeconds int    `json:"reload_seconds"`
+}
+
+// embeddingConfig enables semantic plans and real vectors in the built-in
+// index when URL is set. Token is a secret, so like the callback secret it
+// has no flag. MaxTokens and MaxItemTokens are the model's request and
+// text limits, and RequestsPerMinute and TokensPerMinute the provider's
+// rate limits, tokens counted as words; zero is unlimited. CacheURL keeps
+// vectors in Redis for CacheTTLSeconds instead of CacheSize of them in
+// memory.
+type embeddingConfig struct {
+	URL               string `json:"url,omitempty"`
+	Model             string `json:"model,omitempty"`
+	Token             string `json:"token,omitempty"`
+	BatchSize         int    `json:"batch_size"`
+	TimeoutSeconds    int    `json:"timeout_seconds"`
+	MaxTokens         int    `json:"max_tokens"`
+	MaxItemTokens     int    `json:"max_item_tokens"`
+	RequestsPerMinute int    `json:"requests_per_minute"`
+	TokensPerMinute   int    `json:"tokens_per_minute"`
+	CacheSize         int    `json:"cache_size"`
+	CacheURL          string `json:"cache_url,omitempty"`
+	CacheTTLSeconds   int    `json:"cache_ttl_seconds"`
+}
+
+func defaultConfig() serverConfig {
+	return serverConfig{
+		Addr:      ":8080",
+		Limits:    limitsConfig{MaxTotalChunks: 100000, MaxOutputBytes: 64 << 20, MaxRequestBytes: 32 << 20},
+		Timeouts:  timeoutsConfig{ReadSeconds: 60, WriteSeconds: 120, IdleSeconds: 120, ShutdownGraceSeconds: 30},
+		Jobs:      jobsConfig{Store: jobStoreMemory, TTLSeconds: 3600, Workers: 2, MaxPending: 100, TimeoutSeconds: 1800},
+		Log:       logConfig{Format: logFormatJSON, Level: "info"},
+		Catalog:   catalogConfig{ReloadSeconds: 10},
+		Embedding: embeddingConfig{BatchSize: embedding.DefaultBatchSize, TimeoutSeconds: 30},
+	}
+}
+
+// loadConfig builds and validates the configuration from args (without the
+// program name), the environment and the config file.
+func loadConfig(args []string) (serverConfig, error) {
+	// The config file sits below the environment and flags, so find it
+	// first and parse the flags again on top of the merged result.
+	var scratch serverConfig
+	path, err := parseFlags(args, &scratch)
+	if err != nil {
+		return scratch, err
+	}
+	cfg := defaultConfig()
+	if path != "" {
+		if err := cfg.loadFile(path); err != nil {
+			return cfg, err
+		}
+	}
+	cfg.applyEnv()
+	if _, err := parseFlags(args, &cfg); err != nil {
+		return cfg, err
+	}
+	return cfg, cfg.validate()
+}
+
+// parseFlags parses args into cfg, leaving settings without a flag on the
+// command line untouched, and returns the config file path.
+func parseFlags(args []string, cfg *serverConfig) (string, error) {
+	fs := flag.NewFlagSet("chunker-server", flag.ContinueOnError)
+	path := fs.String("config", os.Getenv("CHUNKER_CONFIG"), "JSON or YAML config file")
+	fs.StringVar(&cfg.Addr, "addr", cfg.Addr, "HTTP listen address")
+	fs.StringVar(&cfg.GRPCAddr, "grpc-addr", cfg.GRPCAddr, "gRPC listen address (disabled when empty)")
+	fs.StringVar(&cfg.AdminAddr, "admin-addr", cfg.AdminAddr, "pprof and expvar listen address, without auth (disabled when empty)")
+	fs.StringVar(&cfg.TLS.CertFile, "tls-cert", cfg.TLS.CertFile, "TLS certificate file (PEM)")
+	fs.StringVar(&cfg.TLS.KeyFile, "tls-key", cfg.TLS.KeyFile, "TLS private key file (PEM)")
+	fs.StringVar(&cfg.TLS.ClientCAFile, "tls-client-ca", cfg.TLS.ClientCAFile, "CA bundle (PEM) for verifying client certificates")
+	fs.StringVar(&cfg.TLS.ClientAuth, "tls-client-auth", cfg.TLS.ClientAuth, `client certificates: "require" or "optional"`)
+	fs.StringVar(&cfg.Auth.JWKSURL, "jwks-url", cfg.Auth.JWKSURL, "JWKS URL for bearer token verification")
+	fs.StringVar(&cfg.Auth.Issuer, "jwt-issuer", cfg.Auth.Issuer, "required token issuer; also used for OIDC discovery")
+	fs.StringVar(&cfg.Auth.Audience, "jwt-audience", cfg.Auth.Audience, "required token audience")
+	fs.IntVar(&cfg.Limits.MaxTotalChunks, "max-total-chunks", cfg.Limits.MaxTotalChunks, "maximum chunks per request (0 = unlimited)")
+	fs.IntVar(&cfg.Limits.MaxOutputBytes, "max-output-bytes", cfg.Limits.MaxOutputBytes, "maximum chunk text bytes per request (0 = unlimited)")
+	fs.Int64Var(&cfg.Limits.MaxRequestBytes, "max-request-bytes", cfg.Limits.MaxRequestBytes, "maximum request body bytes, HTTP and gRPC (0 = unlimited)")
+	fs.IntVar(&cfg.Timeouts.ReadSeconds, "read-timeout", cfg.Timeouts.ReadSeconds, "seconds allowed to read a request (0 = unlimited)")
+	fs.IntVar(&cfg.Timeouts.WriteSeconds, "write-timeout", cfg.Timeouts.WriteSeconds, "seconds allowed to handle a request and write the response (0 = unlimited)")
+	fs.IntVar(&cfg.Timeouts.IdleSeconds, "idle-timeout", cfg.Timeouts.IdleSeconds, "keep-alive idle timeout in seconds (0 = unlimited)")
+	fs.IntVar(&cfg.Timeouts.ShutdownGraceSeconds, "shutdown-grace", cfg.Timeouts.ShutdownGraceSeconds, "seconds in-flight requests may run after SIGTERM")
+	fs.StringVar(&cfg.Jobs.Store, "job-store", cfg.Jobs.Store, `job store: "memory" or "redis"`)
+	fs.StringVar(&cfg.Jobs.RedisURL, "job-redis-url", cfg.Jobs.RedisURL, "redis://[user:password@]host:port[/db] for the redis job store")
+	fs.IntVar(&cfg.Jobs.TTLSeconds, "job-ttl", cfg.Jobs.TTLSeconds, "seconds a job is kept after its last update (0 = forever)")
+	fs.IntVar(&cfg.Jobs.Workers, "job-workers", cfg.Jobs.Workers, "jobs chunked concurrently")
+	fs.IntVar(&cfg.Jobs.MaxPending, "job-max-pending", cfg.Jobs.MaxPending, "queued plus running jobs before submissions are refused (0 = unlimited)")
+	fs.IntVar(&cfg.Jobs.MaxPerTenant, "job-max-per-tenant", cfg.Jobs.MaxPerTenant, "jobs of one tenant chunked concurrently (0 = up to job-workers)")
+	fs.IntVar(&cfg.Jobs.TimeoutSeconds, "job-timeout", cfg.Jobs.TimeoutSeconds, "seconds a job may run (0 = unlimited)")
+	fs.StringVar(&cfg.Index.Store, "index-store", cfg.Index.Store, `built-in vector index: "memory" (disabled when empty)`)
+	fs.StringVar(&cfg.Log.Format, "log-format", cfg.Log.Format, `log format: "json" or "text"`)
+	fs.StringVar(&cfg.Log.Level, "log-level", cfg.Log.Level, "minimum log level: debug, info, warn or error")
+	fs.StringVar(&cfg.Catalog.Dir, "catalog-dir", cfg.Catalog.Dir, "directory of routing and tokenizer files, reloaded on change")
+	fs.IntVar(&cfg.Catalog.ReloadSeconds, "catalog-reload", cfg.Catalog.ReloadSeconds, "seconds between catalog change checks (0 = load once)")
+	fs.StringVar(&cfg.Embedding.URL, "embedding-url", cfg.Embedding.URL, "embedding service base URL; enables semantic plans")
+	fs.StringVar(&cfg.Embedding.Model, "embedding-model", cfg.Embedding.Model, "model sent to the embedding service (default: the service's own)")
+	fs.IntVar(&cfg.Embedding.BatchSize, "embedding-batch", cfg.Embedding.BatchSize, "texts per embedding request")
+	fs.IntVar(&cfg.Embedding.TimeoutSeconds, "embedding-timeout", cfg.Embedding.TimeoutSeconds, "seconds allowed for each embedding request (0 = unlimited)")
+	fs.IntVar(&cfg.Embedding.MaxTokens, "embedding-max-tokens", cfg.Embedding.MaxTokens, "embedding tokens per request, counted as words (0 = unlimited)")
+	fs.IntVar(&cfg.Embedding.MaxItemTokens, "embedding-max-item-tokens", cfg.Embedding.MaxItemTokens, "embedding tokens per text, counted as words (0 = unlimited)")
+	fs.IntVar(&cfg.Embedding.RequestsPerMinute, "embedding-rpm", cfg.Embedding.RequestsPerMinute, "embedding requests per minute (0 = unlimited)")
+	fs.IntVar(&cfg.Embedding.TokensPerMinute, "embedding-tpm", cfg.Embedding.TokensPerMinute, "embedding tokens per minute, counted as words (0 = unlimited)")
+	fs.IntVar(&cfg.Embedding.CacheSize, "embedding-cache-size", cfg.Embedding.CacheSize, "embedded texts kept in memory (0 = no cache)")
+	fs.StringVar(&cfg.Embedding.CacheURL, "embedding-cache-url", cfg.Embedding.CacheURL, "redis://[user:password@]host:port[/db] to cache embedded texts in instead of memory")
+	fs.IntVar(&cfg.Embedding.CacheTTLSeconds, "embedding-cache-ttl", cfg.Embedding.CacheTTLSeconds, "seconds vectors stay in the redis cache (0 = until evicted)")
+	fs.StringVar(&cfg.TokenizerDir, "tokenizer-dir", cfg.TokenizerDir, "directory of model tokenizers to load at startup")
+	fs.StringVar(&cfg.AuditLog, "audit-log", cfg.AuditLog, "JSONL file recording successful chunk requests for replay")
+	fs.Func("meta-schema", "JSON metadata schema applied to every request", func(s string) error {
+		cfg.MetaSchema = json.RawMessage(s)
+		return nil
+	})
+	fs.Func("job-callback-hosts", "comma-separated hosts job callbacks may be sent to, including private addresses (default: any public address)", func(s string) error {
+		cfg.Jobs.CallbackHosts = splitList(s)
+		return nil
+	})
+	fs.Func("job-tenant-weights", "comma-separated tenant=weight shares of the job workers (default weight 1)", func(s string) error {
+		weights, err := parseWeights(s)
+		cfg.Jobs.TenantWeights = weights
+		return err
+	})
+	fs.Func("default-plan", "JSON plan fields applied to requests that do not set them", func(s string) error {
+		cfg.DefaultPlan = json.RawMessage(s)
+		return nil
+	})
+	if err := fs.Parse(args); err != nil {
+		return "", err
+	}
+	if fs.NArg() > 0 {
+		return "", fmt.Errorf("unexpected arguments: %v", fs.Args())
+	}
+	return *path, nil
+}
+
+// loadFile merges a JSON or YAML config file into cfg. Like manifests,
+// YAML is detected by extension and uses the JSON field names.
+func (cfg *serverConfig) loadFile(path string) error {
+	data, err := yamljson.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(cfg); err != nil {
+		return fmt.Errorf("invalid config %s: %w", path, err)
+	}
+	return nil
+}
+
+func (cfg *serverConfig) applyEnv() {
+	if v := os.Getenv("CHUNKER_ADDR"); v != "" {
+		cfg.Addr = v
+	}
+	if v := os.Getenv("CHUNKER_GRPC_ADDR"); v != "" {
+		cfg.GRPCAddr = v
+	}
+	if v := os.Getenv("CHUNKER_ADMIN_ADDR"); v != "" {
+		cfg.AdminAddr = v
+	}
+	if v := os.Getenv("CHUNKER_TLS_CERT_FILE"); v != "" {
+		cfg.TLS.CertFile = v
+	}
+	if v := os.Getenv("CHUNKER_TLS_KEY_FILE"); v != "" {
+		cfg.TLS.KeyFile = v
+	}
+	if v := os.Getenv("CHUNKER_TLS_CLIENT_CA_FILE"); v != "" {
+		cfg.TLS.ClientCAFile = v
+	}
+	if v := os.Getenv("CHUNKER_TLS_CLIENT_AUTH"); v != "" {
+		cfg.TLS.ClientAuth = v
+	}
+	if v := os.Getenv("CHUNKER_JWKS_URL"); v != "" {
+		cfg.Auth.JWKSURL = v
+	}
+	if v := os.Getenv("CHUNKER_JWT_ISSUER"); v != "" {
+		cfg.Auth.Issuer = v
+	}
+	if v := os.Getenv("CHUNKER_JWT_AUDIENCE"); v != "" {
+		cfg.Auth.Audience = v
+	}
+	if v := os.Getenv("CHUNKER_JOB_STORE"); v != "" {
+		cfg.Jobs.Store = v
+	}
+	if v := os.Getenv("CHUNKER_JOB_REDIS_URL"); v != "" {
+		cfg.Jobs.RedisURL = v
+	}
+	if v := os.Getenv("CHUNKER_JOB_CALLBACK_SECRET"); v != "" {
+		cfg.Jobs.CallbackSecret = v
+	}
+	if v := os.Getenv("CHUNKER_JOB_CALLBACK_HOSTS"); v != "" {
+		cfg.Jobs.CallbackHosts = splitList(v)
+	}
+	if v := os.Getenv("CHUNKER_JOB_TENANT_WEIGHTS"); v != "" {
+		weights, err := parseWeights(v)
+		if err != nil {
+			fatal("invalid CHUNKER_JOB_TENANT_WEIGHTS", err)
+		}
+		cfg.Jobs.TenantWeights = weights
+	}
+	if v := os.Getenv("CHUNKER_INDEX_STORE"); v != "" {
+		cfg.Index.Store = v
+	}
+	if v := os.Getenv("CHUNKER_LOG_FORMAT"); v != "" {
+		cfg.Log.Format = v
+	}
+	if v := os.Getenv("CHUNKER_LOG_LEVEL"); v != "" {
+		cfg.Log.Level = v
+	}
+	if v := os.Getenv("CHUNKER_CATALOG_DIR"); v != "" {
+		cfg.Catalog.Dir = v
+	}
+	if v := os.Getenv("CHUNKER_DEFAULT_PLAN"); v != "" {
+		cfg.DefaultPlan = json.RawMessage(v)
+	}
+	if v := os.Getenv("CHUNKER_META_SCHEMA"); v != "" {
+		cfg.MetaSchema = json.RawMessage(v)
+	}
+	if v := os.Getenv("CHUNKER_TOKENIZER_DIR"); v != "" {
+		cfg.TokenizerDir = v
+	}
+	if v := os.Getenv("CHUNKER_AUDIT_LOG"); v != "" {
+		cfg.AuditLog = v
+	}
+	if v := os.Getenv("CHUNKER_EMBEDDING_URL"); v != "" {
+		cfg.Embedding.URL = v
+	}
+	if v := os.Getenv("CHUNKER_EMBEDDING_MODEL"); v != "" {
+		cfg.Embedding.Model = v
+	}
+	if v := os.Getenv("CHUNKER_EMBEDDING_TOKEN"); v != "" {
+		cfg.Embedding.Token = v
+	}
+	if v := os.Getenv("CHUNKER_EMBEDDING_CACHE_URL"); v != "" {
+		cfg.Embedding.CacheURL = v
+	}
+	cfg.Limits.MaxTotalChunks = envInt("CHUNKER_MAX_TOTAL_CHUNKS", cfg.Limits.MaxTotalChunks)
+	cfg.Limits.MaxOutputBytes = envInt("CHUNKER_MAX_OUTPUT_BYTES", cfg.Limits.MaxOutputBytes)
+	cfg.Limits.MaxRequestBytes = int64(envInt("CHUNKER_MAX_REQUEST_BYTES", int(cfg.Limits.MaxRequestBytes)))
+	cfg.Timeouts.ReadSeconds = envInt("CHUNKER_READ_TIMEOUT_SECONDS", cfg.Timeouts.ReadSeconds)
+	cfg.Timeouts.WriteSeconds = envInt("CHUNKER_WRITE_TIMEOUT_SECONDS", cfg.Timeouts.WriteSeconds)
+	cfg.Timeouts.IdleSeconds = envInt("CHUNKER_IDLE_TIMEOUT_SECONDS", cfg.Timeouts.IdleSeconds)
+	cfg.Timeouts.ShutdownGraceSeconds = envInt("CHUNKER_SHUTDOWN_GRACE_SECONDS", cfg.Timeouts.ShutdownGraceSeconds)
+	cfg.Jobs.TTLSeconds = envInt("CHUNKER_JOB_TTL_SECONDS", cfg.Jobs.TTLSeconds)
+	cfg.Jobs.Workers = envInt("CHUNKER_JOB_WORKERS", cfg.Jobs.Workers)
+	cfg.Jobs.MaxPending = envInt("CHUNKER_JOB_MAX_PENDING", cfg.Jobs.MaxPending)
+	cfg.Jobs.TimeoutSeconds = envInt("CHUNKER_JOB_TIMEOUT_SECONDS", cfg.Jobs.TimeoutSeconds)
+	cfg.Jobs.MaxPerTenant = envInt("CHUNKER_JOB_MAX_PER_TENANT", cfg.Jobs.MaxPerTenant)
+	cfg.Catalog.ReloadSeconds = envInt("CHUNKER_CATALOG_RELOAD_SECONDS", cfg.Catalog.ReloadSeconds)
+	e := &cfg.Embedding
+	e.BatchSize = envInt("CHUNKER_EMBEDDING_BATCH", e.BatchSize)
+	e.TimeoutSeconds = envInt("CHUNKER_EMBEDDING_TIMEOUT_SECONDS", e.TimeoutSeconds)
+	e.MaxTokens = envInt("CHUNKER_EMBEDDING_MAX_TOKENS", e.MaxTokens)
+	e.MaxItemTokens = envInt("CHUNKER_EMBEDDING_MAX_ITEM_TOKENS", e.MaxItemTokens)
+	e.RequestsPerMinute = envInt("CHUNKER_EMBEDDING_RPM", e.RequestsPerMinute)
+	e.TokensPerMinute = envInt("CHUNKER_EMBEDDING_TPM", e.TokensPerMinute)
+	e.CacheSize = envInt("CHUNKER_EMBEDDING_CACHE_SIZE", e.CacheSize)
+	e.CacheTTLSeconds = envInt("CHUNKER_EMBEDDING_CACHE_TTL_SECONDS", e.CacheTTLSeconds)
+}
+
+func (cfg *serverConfig) validate() error {
+	if cfg.Addr == "" {
+		return errors.New("addr is required")
+	}
+	if cfg.AdminAddr != "" && (cfg.AdminAddr == cfg.Addr || cfg.AdminAddr == cfg.GRPCAddr) {
+		return errors.New("admin_addr must differ from addr and grpc_addr")
+	}
+	if _, err := cfg.TLS.serverTLS(); err != nil {
+		return fmt.Errorf("tls: %w", err)
+	}
+	if cfg.Auth.Audience != "" && cfg.Auth.JWKSURL == "" && cfg.Auth.Issuer == "" {
+		return errors.New("auth: audience needs jwks_url or issuer")
+	}
+	if cfg.Limits.MaxTotalChunks < 0 || cfg.Limits.MaxOutputBytes < 0 || cfg.Limits.MaxRequestBytes < 0 {
+		return errors.New("limits must be >= 0")
+	}
+	t := cfg.Timeouts
+	if t.ReadSeconds < 0 || t.WriteSeconds < 0 || t.IdleSeconds < 0 || t.ShutdownGraceSeconds < 0 {
+		return errors.New("timeouts must be >= 0")
+	}
+	if err := cfg.Jobs.validate(); err != nil {
+		return fmt.Errorf("jobs: %w", err)
+	}
+	if cfg.Index.Store != "" && cfg.Index.Store != indexStoreMemory {
+		return fmt.Errorf("index: store must be %q or empty, got %q", indexStoreMemory, cfg.Index.Store)
+	}
+	if err := cfg.Log.validate(); err != nil {
+		return fmt.Errorf("log: %w", err)
+	}
+	if err := cfg.Routing.Validate(); err != nil {
+		return fmt.Errorf("routing: %w", err)
+	}
+	if cfg.Catalog.ReloadSeconds < 0 {
+		return errors.New("catalog: reload_seconds must be >= 0")
+	}
+	if len(cfg.DefaultPlan) > 0 {
+		if err := checkDefaultPlan(cfg.DefaultPlan); err != nil {
+			return fmt.Errorf("default_plan: %w", err)
+		}
+	}
+	if err := cfg.Embedding.validate(); err != nil {
+		return fmt.Errorf("embedding: %w", err)
+	}
+	if len(cfg.MetaSchema) > 0 {
+		// Check already names meta_schema.
+		if _, err := parseMetaSchema(cfg.MetaSchema); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (e embeddingConfig) validate() error {
+	if e.BatchSize < 1 {
+		return errors.New("batch_size must be >= 1")
+	}
+	if e.TimeoutSeconds < 0 || e.MaxTokens < 0 || e.MaxItemTokens < 0 || e.CacheSize < 0 || e.CacheTTLSeconds < 0 {
+		return errors.New("timeout_seconds, max_tokens, max_item_tokens, cache_size and cache_ttl_seconds must be >= 0")
+	}
+	if err := e.rateLimit().Validate(); err != nil {
+		return err
+	}
+	if e.CacheURL != "" {
+		if _, err := jobs.NewRedis(e.CacheURL, 0); err != nil {
+			return fmt.Errorf("cache_url: %w", err)
+		}
+	}
+	return nil
+}
+
+func (e embeddingConfig) rateLimit() embedding.RateLimit {
+	return embedding.RateLimit{RequestsPerMinute: e.RequestsPerMinute, TokensPerMinute: e.TokensPerMinute}
+}
+
+// parseMetaSchema decodes and checks a metadata schema.
+func parseMetaSchema(data json.RawMessage) (chunking.MetaSchema, error) {
+	var schema chunking.MetaSchema
+	if err := json.Unmarshal(data, &schema); err != nil {
+		return schema, fmt.Errorf("meta_schema: %w", err)
+	}
+	return schema, schema.Check()
+}
+
+func (j jobsConfig) validate() error {
+	switch j.Store {
+	case jobStoreMemory:
+	case jobStoreRedis:
+		if j.RedisURL == "" {
+			return errors.New("the redis store needs redis_url")
+		}
+	default:
+		return fmt.Errorf("store must be %q or %q, got %q", jobStoreMemory, jobStoreRedis, j.Store)
+	}
+	if j.Workers < 1 {
+		return errors.New("workers must be >= 1")
+	}
+	if j.TTLSeconds < 0 || j.MaxPending < 0 || j.TimeoutSeconds < 0 || j.MaxPerTenant < 0 {
+		return errors.New("ttl_seconds, max_pending, timeout_seconds and max_per_tenant must be >= 0")
+	}
+	for tenant, w := range j.TenantWeights {
+		if w < 1 {
+			return fmt.Errorf("tenant_weights: weight of %q must be >= 1", tenant)
+		}
+	}
+	if len(j.CallbackHosts) > 0 && j.CallbackSecret == "" {
+		return errors.New("callback_hosts needs callback_secret")
+	}
+	_, err := j.newJobStore()
+	return err
+}
+
+// checkDefaultPlan rejects unknown fields and settings no request could
+// use. Fields that only make sense together, such as overlap without
+// window_size, are checked once a request completes the plan.
+func checkDefaultPlan(data json.RawMessage) error {
+	var plan chunking.ChunkingPlan
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&plan); err != nil {
+		return err
+	}
+	switch {
+	case plan.WindowSize < 0:
+		return errors.New("window_size must be >= 0")
+	case plan.Overlap < 0:
+		return errors.New("overlap must be >= 0")
+	case plan.WindowSize > 0 && plan.Overlap >= plan.WindowSize:
+		return errors.New("overlap must be < window_size")
+	case plan.Strategy != "" && plan.Strategy != chunking.StrategySliding && plan.Strategy != chunking.StrategySemantic:
+		return fmt.Errorf("unknown strategy %q", plan.Strategy)
+	}
+	switch plan.Mode {
+	case "", chunking.ModeCharacters, chunking.ModeTokens, chunking.ModeLines, chunking.ModeGraphemes, chunking.ModeBytes:
+	default:
+		return fmt.Errorf("unsupported mode %q", plan.Mode)
+	}
+	_, _, err := chunking.ParseRetention(plan.Retention)
+	return err
+}
+
+// defaultPlanJSON is the configured default plan; requests start from a
+// fresh copy of it via newPlan.
+var defaultPlanJSON json.RawMessage
+
+// routing is the MIME type and extension routing in effect: the config
+// file's, or the catalog's once one is loaded. It is replaced, never
+// modified, on reload.
+var routing atomic.Pointer[chunking.Routing]
+
+// newPlan returns a plan holding the configured defaults. Each call
+// decodes a new copy so requests never share slices, maps or children.
+func newPlan() chunking.ChunkingPlan {
+	var plan chunking.ChunkingPlan
+	if len(defaultPlanJSON) > 0 {
+		// Validated at startup.
+		_ = json.Unmarshal(defaultPlanJSON, &plan)
+	}
+	return plan
+}
+
+// parseWeights parses a comma-separated list of tenant=weight pairs.
+func parseWeights(s string) (map[string]int, error) {
+	weights := map[string]int{}
+	for _, pair := range splitList(s) {
+		tenant, w, ok := strings.Cut(pair, "=")
+		n, err := strconv.Atoi(strings.TrimSpace(w))
+		if !ok || err != nil {
+			return nil, fmt.Errorf("invalid tenant weight %q, want tenant=weight", pair)
+		}
+		weights[strings.TrimSpace(tenant)] = n
+	}
+	return weights, nil
+}
+
+// splitList splits a comma-separated list, dropping blank entries.
+func splitList(s string) []string {
+	var out []string
+	for _, v := range strings.Split(s, ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			out = append(out, v)
+		}
+	}
+	return out
+}
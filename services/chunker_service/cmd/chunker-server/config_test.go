@@ -0,0 +1,116 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func writeConfig(t *testing.T, name, body string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(body), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestLoadConfigPrecedence(t *testing.T) {
+	path := writeConfig(t, "chunker.yaml", `
+addr: ":1000"
+grpc_addr: ":2000"
+admin_addr: "127.0.0.1:3000"
+jobs:
+  workers: 4
+  max_pending: 10
+log:
+  level: debug
+embedding:
+  url: http://file
+  batch_size: 16
+`)
+	t.Setenv("CHUNKER_CONFIG", path)
+	t.Setenv("CHUNKER_GRPC_ADDR", ":2001")
+	t.Setenv("CHUNKER_ADMIN_ADDR", "127.0.0.1:3001")
+	t.Setenv("CHUNKER_JOB_MAX_PENDING", "11")
+	t.Setenv("CHUNKER_EMBEDDING_BATCH", "32")
+
+	cfg, err := loadConfig([]string{"-admin-addr", "127.0.0.1:3002", "-embedding-batch", "48"})
+	if err != nil {
+		t.Fatalf("loadConfig failed: %v", err)
+	}
+	for _, c := range []struct {
+		name      string
+		got, want interface{}
+	}{
+		{"file over default", cfg.Addr, ":1000"},
+		{"file over default", cfg.Jobs.Workers, 4},
+		{"file over default", cfg.Log.Level, "debug"},
+		{"file over default", cfg.Embedding.URL, "http://file"},
+		{"env over file", cfg.GRPCAddr, ":2001"},
+		{"env over file", cfg.Jobs.MaxPending, 11},
+		{"flag over env", cfg.AdminAddr, "127.0.0.1:3002"},
+		{"flag over env", cfg.Embedding.BatchSize, 48},
+		{"default", cfg.Jobs.TTLSeconds, 3600},
+		{"default", cfg.Embedding.TimeoutSeconds, 30},
+	} {
+		if c.got != c.want {
+			t.Errorf("%s: got %v, want %v", c.name, c.got, c.want)
+		}
+	}
+
+	// -config wins over CHUNKER_CONFIG like any other flag.
+	other := writeConfig(t, "other.json", `{"addr": ":4000"}`)
+	if cfg, err := loadConfig([]string{"-config", other}); err != nil || cfg.Addr != ":4000" || cfg.Jobs.Workers != 2 {
+		t.Errorf("expected only %s to be loaded, got addr %q workers %d, %v", other, cfg.Addr, cfg.Jobs.Workers, err)
+	}
+}
+
+func TestLoadConfigRejectsInvalid(t *testing.T) {
+	t.Setenv("CHUNKER_CONFIG", "")
+	for _, tc := range []struct {
+		name string
+		file string
+		env  map[string]string
+		args []string
+		want string
+	}{
+		{name: "unknown file field", file: `{"adr": ":1"}`, want: "unknown field"},
+		{name: "empty addr", args: []string{"-addr", ""}, want: "addr is required"},
+		{name: "admin on addr", args: []string{"-admin-addr", ":8080"}, want: "admin_addr"},
+		{name: "cert without key", args: []string{"-tls-cert", "/tmp/cert.pem"}, want: "tls"},
+		{name: "audience without issuer", args: []string{"-jwt-audience", "chunker"}, want: "audience"},
+		{name: "negative limit", args: []string{"-max-total-chunks", "-1"}, want: "limits"},
+		{name: "negative timeout", env: map[string]string{"CHUNKER_READ_TIMEOUT_SECONDS": "-5"}, want: "timeouts"},
+		{name: "no workers", args: []string{"-job-workers", "0"}, want: "workers"},
+		{name: "unknown job store", env: map[string]string{"CHUNKER_JOB_STORE": "disk"}, want: "store"},
+		{name: "redis without url", args: []string{"-job-store", "redis"}, want: "redis_url"},
+		{name: "bad tenant weight", args: []string{"-job-tenant-weights", "a=x"}, want: "tenant weight"},
+		{name: "zero tenant weight", file: `{"jobs": {"tenant_weights": {"a": 0}}}`, want: "tenant_weights"},
+		{name: "hosts without secret", args: []string{"-job-callback-hosts", "hooks.internal"}, want: "callback_secret"},
+		{name: "unknown index store", args: []string{"-index-store", "disk"}, want: "index"},
+		{name: "log format", args: []string{"-log-format", "xml"}, want: "log"},
+		{name: "catalog reload", args: []string{"-catalog-reload", "-1"}, want: "reload_seconds"},
+		{name: "default plan field", args: []string{"-default-plan", `{"windw_size": 10}`}, want: "default_plan"},
+		{name: "default plan overlap", args: []string{"-default-plan", `{"window_size": 10, "overlap": 10}`}, want: "overlap"},
+		{name: "embedding batch", file: "{\"embedding\": {\"batch_size\": 0}}", want: "batch_size"},
+		{name: "embedding cache url", args: []string{"-embedding-cache-url", "http://cache"}, want: "cache_url"},
+		{name: "meta schema", args: []string{"-meta-schema", `{"a": "bogus"}`}, want: "meta_schema"},
+		{name: "stray argument", args: []string{"serve"}, want: "unexpected arguments"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			for k, v := range tc.env {
+				t.Setenv(k, v)
+			}
+			args := tc.args
+			if tc.file != "" {
+				args = append([]string{"-config", writeConfig(t, "chunker.json", tc.file)}, args...)
+			}
+			_, err := loadConfig(args)
+			if err == nil || !strings.Contains(err.Error(), tc.want) {
+				t.Errorf("got %v, want an error mentioning %q", err, tc.want)
+			}
+		})
+	}
+}
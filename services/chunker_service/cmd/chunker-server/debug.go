@@ -0,0 +1,50 @@
+package main
+
+import (
+	"crypto/subtle"
+	"net/http"
+	"os"
+	"strings"
+
+	"chunker-service/pkg/chunking"
+)
+
+// debugKeys are the API keys allowed to request debug traces, configured
+// as a comma-separated list in CHUNKER_DEBUG_KEYS. Debug traces are
+// disabled when it is empty.
+var debugKeys []string
+
+type debugChunkResponse struct {
+	SchemaVersion int              `json:"schema_version"`
+	Chunks        []chunking.Chunk `json:"chunks"`
+	Trace         *chunking.Trace  `json:"trace"`
+}
+
+func loadDebugKeys() {
+	for _, k := range strings.Split(os.Getenv("CHUNKER_DEBUG_KEYS"), ",") {
+		if k = strings.TrimSpace(k); k != "" {
+			debugKeys = append(debugKeys, k)
+		}
+	}
+}
+
+// wantsDebug reports whether the request asked for a debug trace with
+// X-Debug: true.
+func wantsDebug(r *http.Request) bool {
+	return strings.EqualFold(r.Header.Get("X-Debug"), "true")
+}
+
+// debugAuthorized reports whether the request's X-API-Key is one of the
+// configured debug keys.
+func debugAuthorized(r *http.Request) bool {
+	key := r.Header.Get("X-API-Key")
+	if key == "" {
+		return false
+	}
+	for _, k := range debugKeys {
+		if subtle.ConstantTimeCompare([]byte(k), []byte(key)) == 1 {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,113 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"regexp"
+	"runtime/debug"
+	"strings"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// errorCodes are the codes of error responses that do not set a more
+// specific one, so every error body has a stable code to match on.
+var errorCodes = map[int]string{
+	http.StatusBadRequest:            "invalid_request",
+	http.StatusUnauthorized:          "unauthorized",
+	http.StatusForbidden:             "forbidden",
+	http.StatusNotFound:              "not_found",
+	http.StatusMethodNotAllowed:      "method_not_allowed",
+	http.StatusRequestEntityTooLarge: "too_large",
+	http.StatusUnprocessableEntity:   "unprocessable",
+	http.StatusInternalServerError:   "internal",
+	http.StatusBadGateway:            "upstream_failed",
+	http.StatusServiceUnavailable:    "unavailable",
+}
+
+// leakPattern matches what must not reach a client: URLs, which may name
+// internal services or carry credentials, absolute file paths and stack
+// trace lines.
+var leakPattern = regexp.MustCompile(`[A-Za-z][A-Za-z0-9+.-]*://|(?:^|[\s"'(=:])(?:/[\w.@-]+){2,}|\b[A-Za-z]:\\|\.go:\d+|goroutine \d+`)
+
+// errInternal is the detail-free error clients see for failures that are
+// ours, such as panics.
+var errInternal = errors.New("internal error")
+
+// publicError completes an error body before it is written: it fills in
+// the default code for status and the request ID, which clients quote so
+// the full detail can be found in the logs, and withholds a message that
+// could leak internals, logging it instead.
+func publicError(w http.ResponseWriter, status int, e errorResponse) errorResponse {
+	if e.Code == "" {
+		e.Code = errorCodes[status]
+		if e.Code == "" {
+			e.Code = "error"
+		}
+	}
+	e.RequestID = w.Header().Get(requestIDHeader)
+	if leakPattern.MatchString(e.Error) {
+		slog.Warn("error detail withheld from response", "request_id", e.RequestID, "status", status, "code", e.Code, "error", e.Error)
+		e.Error = strings.ToLower(http.StatusText(status))
+	}
+	return e
+}
+
+// withheld logs err, whose detail clients must not see, and returns msg
+// for the response in its place.
+func withheld(ctx context.Context, msg string, err error) string {
+	slog.Error(msg, "request_id", requestID(ctx), "error", err)
+	return msg
+}
+
+// recoverPanics turns a panicking handler into a 500 with no detail; the
+// panic and its stack go to the log.
+func recoverPanics(h http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		defer func() {
+			v := recover()
+			if v == nil {
+				return
+			}
+			if v == http.ErrAbortHandler {
+				panic(v)
+			}
+			slog.Error("panic serving request", "request_id", requestID(r.Context()), "panic", fmt.Sprint(v), "stack", string(debug.Stack()))
+			writeJSON(w, http.StatusInternalServerError, errorResponse{Error: errInternal.Error(), Code: "internal"})
+		}()
+		h.ServeHTTP(w, r)
+	})
+}
+
+// grpcErrors returns interceptors doing for gRPC what recoverPanics and
+// publicError do for HTTP: panics become detail-free Internal errors and
+// status messages that could leak internals are withheld. The request ID
+// is in the x-request-id response header.
+func grpcErrors() []grpc.ServerOption {
+	public := func(ctx context.Context, err *error) {
+		if v := recover(); v != nil {
+			slog.Error("panic serving request", "request_id", requestID(ctx), "panic", fmt.Sprint(v), "stack", string(debug.Stack()))
+			*err = status.Error(codes.Internal, errInternal.Error())
+			return
+		}
+		if st, ok := status.FromError(*err); ok && leakPattern.MatchString(st.Message()) {
+			slog.Warn("error detail withheld from response", "request_id", requestID(ctx), "code", st.Code().String(), "error", st.Message())
+			*err = status.Error(st.Code(), strings.ToLower(st.Code().String()))
+		}
+	}
+	return []grpc.ServerOption{
+		grpc.ChainUnaryInterceptor(func(ctx context.Context, req interface{}, _ *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (resp interface{}, err error) {
+			defer public(ctx, &err)
+			return handler(ctx, req)
+		}),
+		grpc.ChainStreamInterceptor(func(srv interface{}, ss grpc.ServerStream, _ *grpc.StreamServerInfo, handler grpc.StreamHandler) (err error) {
+			defer public(ss.Context(), &err)
+			return handler(srv, ss)
+		}),
+	}
+}
@@ -0,0 +1,129 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"chunker-service/pkg/chunking"
+)
+
+// errorBody writes e with status the way handlers do and returns the
+// response.
+func errorBody(t *testing.T, status int, e errorResponse) (*httptest.ResponseRecorder, errorResponse) {
+	t.Helper()
+	w := httptest.NewRecorder()
+	w.Header().Set(requestIDHeader, "req-1")
+	writeJSON(w, status, e)
+	var got errorResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &got); err != nil {
+		t.Fatalf("invalid error body %q: %v", w.Body, err)
+	}
+	return w, got
+}
+
+func TestChunkErrorClasses(t *testing.T) {
+	captureLogs(t, logConfig{Format: logFormatText, Level: "info"})
+	ctx := context.Background()
+	for _, tc := range []struct {
+		name   string
+		err    error
+		status int
+		code   string
+		msg    string
+	}{
+		{"output too large", fmt.Errorf("%w: 120 chunks > 100", chunking.ErrOutputTooLarge), http.StatusRequestEntityTooLarge, "output_too_large", "chunk output exceeds limit: 120 chunks > 100"},
+		{"binary", chunking.ErrBinaryContent, http.StatusUnprocessableEntity, "binary_content", "input is not text"},
+		{"meta", fmt.Errorf("%w: doc_id is required", chunking.ErrInvalidMeta), http.StatusBadRequest, "invalid_meta", "invalid metadata: doc_id is required"},
+		// Upstream failures keep their detail, such as the service URL,
+		// in the log.
+		{"embedding", fmt.Errorf("%w: Post \"http://embed.internal:8000/embed\": connection refused", chunking.ErrEmbeddingFailed), http.StatusBadGateway, "embedding_failed", "embedding service failed"},
+		{"enricher", fmt.Errorf("%w: acronyms: timeout", chunking.ErrEnricherFailed), http.StatusBadGateway, "enricher_failed", "enricher failed"},
+		{"cancelled", context.Canceled, http.StatusServiceUnavailable, "cancelled", "request cancelled"},
+		{"internal", errInternal, http.StatusInternalServerError, "internal", "internal error"},
+		{"plan", errors.New("overlap must be >= 0 and < window_size"), http.StatusBadRequest, "invalid_request", "overlap must be >= 0 and < window_size"},
+	} {
+		status, resp := chunkError(ctx, tc.err)
+		w, got := errorBody(t, status, resp)
+		want := errorResponse{Error: tc.msg, Code: tc.code, RequestID: "req-1"}
+		if w.Code != tc.status || got != want {
+			t.Errorf("%s: got %d %+v, want %d %+v", tc.name, w.Code, got, tc.status, want)
+		}
+		if ct := w.Header().Get("Content-Type"); ct != "application/json" {
+			t.Errorf("%s: got content type %q", tc.name, ct)
+		}
+	}
+}
+
+func TestPublicErrorCodes(t *testing.T) {
+	for status, code := range errorCodes {
+		if _, got := errorBody(t, status, errorResponse{Error: "x"}); got.Code != code {
+			t.Errorf("%d: got code %q, want %q", status, got.Code, code)
+		}
+	}
+	if _, got := errorBody(t, http.StatusTeapot, errorResponse{Error: "x"}); got.Code != "error" {
+		t.Errorf("a status without a default code got %q, want error", got.Code)
+	}
+	if _, got := errorBody(t, http.StatusNotFound, errorResponse{Error: "x", Code: "job_not_found"}); got.Code != "job_not_found" {
+		t.Errorf("a specific code was replaced by %q", got.Code)
+	}
+}
+
+func TestPublicErrorWithholdsLeaks(t *testing.T) {
+	captureLogs(t, logConfig{Format: logFormatText, Level: "info"})
+	for _, msg := range []string{
+		`Post "https://user:pw@embed.internal/embed": EOF`,
+		"open /etc/chunker/tokenizers/cl100k.tiktoken: permission denied",
+		`read C:\chunker\catalog: access denied`,
+		"panic at main.go:123",
+		"goroutine 7 [running]",
+	} {
+		if _, got := errorBody(t, http.StatusBadRequest, errorResponse{Error: msg}); got.Error != "bad request" || got.Code != "invalid_request" {
+			t.Errorf("%q: got %+v, want the detail withheld", msg, got)
+		}
+	}
+	for _, msg := range []string{
+		"plan.window_size must be > 0",
+		"unsupported mode \"words\"",
+		"meta.file_path must be a string",
+		"ratio must be 1/2 or 2/3",
+	} {
+		if _, got := errorBody(t, http.StatusBadRequest, errorResponse{Error: msg}); got.Error != msg {
+			t.Errorf("%q was withheld as %q", msg, got.Error)
+		}
+	}
+}
+
+func TestRecoverPanics(t *testing.T) {
+	logs := captureLogs(t, logConfig{Format: logFormatText, Level: "info"})
+	h := logRequests(recoverPanics(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		panic("open /var/secret/key: boom")
+	})))
+	r := httptest.NewRequest(http.MethodPost, "/v1/chunk", nil)
+	r.Header.Set(requestIDHeader, "req-1")
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, r)
+	var got errorResponse
+	json.Unmarshal(w.Body.Bytes(), &got)
+	if want := (errorResponse{Error: "internal error", Code: "internal", RequestID: "req-1"}); w.Code != http.StatusInternalServerError || got != want {
+		t.Errorf("got %d %+v, want 500 %+v", w.Code, got, want)
+	}
+	if !strings.Contains(logs.String(), "/var/secret/key") || !strings.Contains(logs.String(), "stack=") {
+		t.Errorf("expected the panic and its stack logged, got %q", logs)
+	}
+
+	// An aborted handler still aborts the connection.
+	defer func() {
+		if v := recover(); v != http.ErrAbortHandler {
+			t.Errorf("expected ErrAbortHandler to propagate, got %v", v)
+		}
+	}()
+	recoverPanics(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		panic(http.ErrAbortHandler)
+	})).ServeHTTP(httptest.NewRecorder(), r)
+}
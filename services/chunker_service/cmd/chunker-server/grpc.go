@@ -0,0 +1,128 @@
+package main
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"log/slog"
+	"net"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/status"
+
+	"chunker-service/pkg/chunkerpb"
+	"chunker-service/pkg/chunking"
+)
+
+// grpcServer serves the chunker gRPC API with the same configuration as
+// the HTTP handlers.
+type grpcServer struct {
+	chunkerpb.UnimplementedChunkerServer
+}
+
+func (grpcServer) Chunk(ctx context.Context, req *chunkerpb.ChunkRequest) (*chunkerpb.ChunkResponse, error) {
+	chunks, err := grpcChunk(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	resp := &chunkerpb.ChunkResponse{Chunks: make([]*chunkerpb.Chunk, len(chunks))}
+	for i, ch := range chunks {
+		if resp.Chunks[i], err = chunkerpb.ChunkToProto(ch); err != nil {
+			return nil, status.Error(codes.Internal, withheld(ctx, "chunk encoding failed", err))
+		}
+	}
+	return resp, nil
+}
+
+func (grpcServer) ChunkStream(req *chunkerpb.ChunkRequest, stream chunkerpb.Chunker_ChunkStreamServer) error {
+	chunks, err := grpcChunk(stream.Context(), req)
+	if err != nil {
+		return err
+	}
+	for _, ch := range chunks {
+		msg, err := chunkerpb.ChunkToProto(ch)
+		if err != nil {
+			return status.Error(codes.Internal, withheld(stream.Context(), "chunk encoding failed", err))
+		}
+		if err := stream.Send(msg); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// grpcChunk runs a request through runChunk, so it is stamped, audited
+// and shadowed like /chunk, mapping errors to the status codes matching
+// the HTTP API's status codes.
+func grpcChunk(ctx context.Context, req *chunkerpb.ChunkRequest) ([]chunking.Chunk, error) {
+	base := newPlan()
+	if req.GetPreset() != "" {
+		var err error
+		if base, err = presetPlan(req.GetPreset()); err != nil {
+			return nil, status.Error(codes.InvalidArgument, err.Error())
+		}
+	} else if req.GetPlan().GetStrategy() == "" {
+		_, _ = routing.Load().Apply(&base, req.GetMeta().AsMap())
+	}
+	plan, err := chunkerpb.PlanFromProtoWithDefaults(req.GetPlan(), base)
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	if plan.WindowSize <= 0 {
+		return nil, status.Error(codes.InvalidArgument, "plan.window_size must be > 0")
+	}
+	creq := chunkRequest{Text: req.GetText(), Plan: plan, Meta: req.GetMeta().AsMap(), Preset: req.GetPreset()}
+	if req.GetCreatedAt() != nil {
+		createdAt := req.GetCreatedAt().AsTime()
+		creq.CreatedAt = &createdAt
+	}
+	chunks, err := runChunk(ctx, creq, nil)
+	switch {
+	case errors.Is(err, chunking.ErrOutputTooLarge):
+		return nil, status.Error(codes.ResourceExhausted, err.Error())
+	case errors.Is(err, chunking.ErrEmbeddingFailed):
+		return nil, status.Error(codes.Unavailable, withheld(ctx, "embedding service failed", err))
+	case errors.Is(err, chunking.ErrEnricherFailed):
+		return nil, status.Error(codes.Unavailable, withheld(ctx, "enricher failed", err))
+	case errors.Is(err, context.Canceled), errors.Is(err, context.DeadlineExceeded):
+		return nil, status.FromContextError(err).Err()
+	case errors.Is(err, errInternal):
+		return nil, status.Error(codes.Internal, errInternal.Error())
+	case err != nil:
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	logChunks(ctx, len(chunks))
+	return chunks, nil
+}
+
+// startGRPC listens on addr and serves the gRPC API in the background,
+// over TLS when tlsCfg is set.
+func startGRPC(addr string, tlsCfg *tls.Config) *grpc.Server {
+	var opts []grpc.ServerOption
+	if tlsCfg != nil {
+		opts = append(opts, grpc.Creds(credentials.NewTLS(tlsCfg)))
+	}
+	if maxRequestBytes > 0 {
+		opts = append(opts, grpc.MaxRecvMsgSize(int(maxRequestBytes)))
+	}
+	opts = append(opts, grpcLogging()...)
+	opts = append(opts, grpcErrors()...)
+	if verifier != nil {
+		opts = append(opts, grpcAuth()...)
+	}
+	lis, err := net.Listen("tcp", addr)
+	if err != nil {
+		fatal("grpc listen failed", err)
+	}
+	srv := grpc.NewServer(opts...)
+	chunkerpb.RegisterChunkerServer(srv, grpcServer{})
+	slog.Info("chunker gRPC service listening", "addr", addr)
+	go func() {
+		if err := srv.Serve(lis); err != nil {
+			fatal("grpc server failed", err)
+		}
+	}()
+	return srv
+}
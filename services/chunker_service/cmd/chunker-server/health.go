@@ -0,0 +1,154 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"sync"
+	"time"
+
+	"chunker-service/pkg/jobs"
+	"chunker-service/pkg/tokenizer"
+)
+
+// Readiness check statuses.
+const (
+	checkOK      = "ok"
+	checkFailed  = "failed"
+	checkSkipped = "skipped"
+)
+
+// readyTimeout bounds each readiness check, well inside the probe's own
+// timeout.
+const readyTimeout = 2 * time.Second
+
+// tokenizerDir is the configured tokenizer_dir and loadedTokenizers the names
+// loaded from it at startup.
+var (
+	tokenizerDir     string
+	loadedTokenizers []string
+)
+
+// readyCheck reports whether one dependency is usable. errSkipped marks a
+// dependency this server is not configured to use.
+type readyCheck func(ctx context.Context) error
+
+var errSkipped = errors.New("not configured")
+
+// readyChecks are run by /readyz, by name.
+var readyChecks = map[string]readyCheck{
+	"tokenizers": checkTokenizers,
+	"embedding":  checkEmbedding,
+	"job_store":  checkJobStore,
+}
+
+type checkResult struct {
+	Status    string  `json:"status"`
+	Error     string  `json:"error,omitempty"`
+	LatencyMS float64 `json:"latency_ms"`
+}
+
+type readyResponse struct {
+	Status string                 `json:"status"`
+	Checks map[string]checkResult `json:"checks"`
+}
+
+// checkTokenizers fails when the tokenizer directory yielded nothing or
+// the default plan names a tokenizer that is not registered.
+func checkTokenizers(context.Context) error {
+	name := newPlan().Tokenizer
+	if tokenizerDir == "" && name == "" {
+		return errSkipped
+	}
+	if tokenizerDir != "" && len(loadedTokenizers) == 0 {
+		return errors.New("no tokenizers found in CHUNKER_TOKENIZER_DIR")
+	}
+	for _, n := range loadedTokenizers {
+		if _, err := tokenizer.Get(n); err != nil {
+			return err
+		}
+	}
+	if name != "" {
+		if _, err := tokenizer.Get(name); err != nil {
+			return fmt.Errorf("default plan tokenizer: %w", err)
+		}
+	}
+	return nil
+}
+
+func checkEmbedding(ctx context.Context) error {
+	h, ok := embedder.(interface{ Health(context.Context) error })
+	if !ok {
+		return errSkipped
+	}
+	return h.Health(ctx)
+}
+
+// checkJobStore pings the redis store; the memory store is always ready.
+func checkJobStore(ctx context.Context) error {
+	r, ok := jobStore.(*jobs.Redis)
+	if !ok {
+		return errSkipped
+	}
+	return r.Ping(ctx)
+}
+
+// handleLive reports that the process is serving. It checks nothing else,
+// so a dependency outage never gets the pod restarted.
+func handleLive(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, map[string]string{"status": "ok"})
+}
+
+// handleReady runs every readiness check concurrently and answers 503
+// unless none failed. Failure details are logged; the response only names
+// the failing checks, as details can hold internal addresses.
+func handleReady(w http.ResponseWriter, r *http.Request) {
+	resp := runChecks(r.Context(), readyChecks, readyTimeout, false)
+	status := http.StatusOK
+	if resp.Status == checkFailed {
+		status = http.StatusServiceUnavailable
+	}
+	writeJSON(w, status, resp)
+}
+
+// runChecks runs checks concurrently, each bounded by timeout, and logs
+// every failure. With detail, a failure's message is kept in the result
+// unless it could leak internals.
+func runChecks(ctx context.Context, checks map[string]readyCheck, timeout time.Duration, detail bool) readyResponse {
+	resp := readyResponse{Status: checkOK, Checks: map[string]checkResult{}}
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	for name, check := range checks {
+		wg.Add(1)
+		go func(name string, check readyCheck) {
+			defer wg.Done()
+			ctx, cancel := context.WithTimeout(ctx, timeout)
+			defer cancel()
+			start := time.Now()
+			err := check(ctx)
+			res := checkResult{Status: checkOK, LatencyMS: float64(time.Since(start).Microseconds()) / 1000}
+			switch {
+			case errors.Is(err, errSkipped):
+				res.Status = checkSkipped
+			case err != nil:
+				res.Status, res.Error = checkFailed, "check failed"
+				if errors.Is(err, context.DeadlineExceeded) {
+					res.Error = "check timed out"
+				} else if detail && !leakPattern.MatchString(err.Error()) {
+					res.Error = err.Error()
+				}
+				slog.Warn("check failed", "request_id", requestID(ctx), "check", name, "error", err)
+			}
+			mu.Lock()
+			resp.Checks[name] = res
+			if res.Status == checkFailed {
+				resp.Status = checkFailed
+			}
+			mu.Unlock()
+		}(name, check)
+	}
+	wg.Wait()
+	return resp
+}
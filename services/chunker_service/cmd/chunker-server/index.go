@@ -0,0 +1,361 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"chunker-service/pkg/chunking"
+	"chunker-service/pkg/embedding"
+	"chunker-service/pkg/jobs"
+	"chunker-service/pkg/vectorstore"
+)
+
+// Vector stores for indexConfig.Store.
+const indexStoreMemory = "memory"
+
+// defaultSearchK is the number of matches /search returns when the
+// request sets no k.
+const defaultSearchK = 10
+
+// vectorIndex backs /index and /search; both are disabled when it is
+// nil. A reembed replaces it whole.
+var vectorIndex atomic.Pointer[indexState]
+
+// indexState is a vector index and the embedder that produced its
+// vectors, which must also embed its queries.
+type indexState struct {
+	store    vectorstore.VectorStore
+	embedder chunking.Embedder
+}
+
+var (
+	// indexWrites is held for reading by requests that modify the index
+	// and for writing by a reembed, so no write is lost while the chunks
+	// are copied to the new index.
+	indexWrites sync.RWMutex
+	// reembedState is the status of the running or last reembed.
+	reembedMu    sync.Mutex
+	reembedState *reembedStatus
+)
+
+type indexResponse struct {
+	SchemaVersion int      `json:"schema_version"`
+	Indexed       int      `json:"indexed"`
+	IDs           []string `json:"ids"`
+}
+
+type deleteDocumentResponse struct {
+	SchemaVersion int    `json:"schema_version"`
+	DocID         string `json:"doc_id"`
+	Deleted       int    `json:"deleted"`
+}
+
+type searchRequest struct {
+	Query  string             `json:"query"`
+	K      int                `json:"k,omitempty"`
+	Filter vectorstore.Filter `json:"filter"`
+}
+
+type searchResponse struct {
+	SchemaVersion int                 `json:"schema_version"`
+	Matches       []vectorstore.Match `json:"matches"`
+}
+
+// loadIndex sets up the built-in vector index. Chunks are embedded with
+// the CHUNKER_EMBEDDING_URL embedder, so it must be loaded first; without
+// one, a hashing embedder matches on shared words, which is enough for
+// demos and tests.
+func loadIndex(cfg indexConfig) {
+	if cfg.Store == "" {
+		return
+	}
+	idx := &indexState{store: vectorstore.NewMemory(), embedder: embedder}
+	if idx.embedder == nil {
+		idx.embedder = embedding.Hashing{}
+		slog.Warn("vector index uses the hashing embedder; set CHUNKER_EMBEDDING_URL for semantic search")
+	}
+	vectorIndex.Store(idx)
+	slog.Info("vector index enabled", "store", cfg.Store)
+}
+
+// checkIndex returns the index in effect, or writes an error and returns
+// nil when the index is disabled.
+func checkIndex(w http.ResponseWriter) *indexState {
+	idx := vectorIndex.Load()
+	if idx == nil {
+		writeJSON(w, http.StatusNotFound, errorResponse{Error: "the vector index is not enabled on this server", Code: "index_disabled"})
+	}
+	return idx
+}
+
+// lockIndexWrites takes indexWrites for a request that modifies the
+// index, or writes an error and returns false while a reembed runs.
+func lockIndexWrites(w http.ResponseWriter) bool {
+	if !indexWrites.TryRLock() {
+		w.Header().Set("Retry-After", "30")
+		writeJSON(w, http.StatusServiceUnavailable, errorResponse{Error: "the vector index is being re-embedded", Code: "reembed_in_progress"})
+		return false
+	}
+	return true
+}
+
+// handleIndex chunks a /chunk request body and stores the chunks,
+// embedded, in the vector index.
+func handleIndex(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeJSON(w, http.StatusMethodNotAllowed, errorResponse{Error: "use POST"})
+		return
+	}
+	if checkIndex(w) == nil || !lockIndexWrites(w) {
+		return
+	}
+	defer indexWrites.RUnlock()
+	// Load again: a reembed that finished since replaced the index.
+	idx := vectorIndex.Load()
+	var req chunkRequest
+	if !decodeChunkJSON(w, r, &req, &req) {
+		return
+	}
+	if req.Plan.WindowSize <= 0 {
+		writeJSON(w, http.StatusBadRequest, errorResponse{Error: "plan.window_size must be > 0"})
+		return
+	}
+	chunks, err := runChunk(r.Context(), req, nil)
+	if err != nil {
+		status, resp := chunkError(r.Context(), err)
+		writeJSON(w, status, resp)
+		return
+	}
+	if err := vectorstore.Index(r.Context(), idx.store, idx.embedder, chunks); err != nil {
+		status, resp := indexError(r.Context(), err)
+		writeJSON(w, status, resp)
+		return
+	}
+	logChunks(r.Context(), len(chunks))
+	ids := make([]string, len(chunks))
+	for i, ch := range chunks {
+		ids[i] = ch.ID
+	}
+	writeJSON(w, http.StatusOK, indexResponse{SchemaVersion: chunking.SchemaVersion, Indexed: len(chunks), IDs: ids})
+}
+
+// handleSearch returns the indexed chunks nearest to a query.
+func handleSearch(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeJSON(w, http.StatusMethodNotAllowed, errorResponse{Error: "use POST"})
+		return
+	}
+	idx := checkIndex(w)
+	if idx == nil {
+		return
+	}
+	var req searchRequest
+	if !decodeJSON(w, r, &req) {
+		return
+	}
+	if req.Query == "" {
+		writeJSON(w, http.StatusBadRequest, errorResponse{Error: "query is required"})
+		return
+	}
+	if req.K < 0 {
+		writeJSON(w, http.StatusBadRequest, errorResponse{Error: "k must be >= 0"})
+		return
+	}
+	if req.K == 0 {
+		req.K = defaultSearchK
+	}
+	vectors, err := idx.embedder.Embed(r.Context(), []string{req.Query})
+	if err == nil && len(vectors) != 1 {
+		err = fmt.Errorf("got %d vectors for the query", len(vectors))
+	}
+	if ctxErr := r.Context().Err(); ctxErr != nil {
+		err = ctxErr
+	} else if err != nil {
+		err = fmt.Errorf("%w: %v", chunking.ErrEmbeddingFailed, err)
+	}
+	if err != nil {
+		status, resp := indexError(r.Context(), err)
+		writeJSON(w, status, resp)
+		return
+	}
+	matches, err := idx.store.Search(r.Context(), vectors[0], req.K, req.Filter)
+	if err != nil {
+		status, resp := indexError(r.Context(), err)
+		writeJSON(w, status, resp)
+		return
+	}
+	if matches == nil {
+		matches = []vectorstore.Match{}
+	}
+	writeJSON(w, http.StatusOK, searchResponse{SchemaVersion: chunking.SchemaVersion, Matches: matches})
+}
+
+// handleDocument deletes every indexed chunk of a document, so it can be
+// re-indexed without leaving stale chunks or removed altogether.
+// Deleting a document with no chunks succeeds with nothing deleted.
+func handleDocument(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodDelete {
+		writeJSON(w, http.StatusMethodNotAllowed, errorResponse{Error: "use DELETE"})
+		return
+	}
+	if checkIndex(w) == nil || !lockIndexWrites(w) {
+		return
+	}
+	defer indexWrites.RUnlock()
+	id := strings.TrimPrefix(apiPath(r), "/documents/")
+	if id == "" || strings.Contains(id, "/") {
+		writeJSON(w, http.StatusNotFound, errorResponse{Error: "document not found", Code: "document_not_found"})
+		return
+	}
+	n, err := vectorIndex.Load().store.DeleteByDocument(r.Context(), id)
+	if err != nil {
+		status, resp := indexError(r.Context(), err)
+		writeJSON(w, status, resp)
+		return
+	}
+	slog.Info("document deleted", "request_id", requestID(r.Context()), "doc_id", id, "chunks", n)
+	writeJSON(w, http.StatusOK, deleteDocumentResponse{SchemaVersion: chunking.SchemaVersion, DocID: id, Deleted: n})
+}
+
+// indexError maps an embedding or vector store error to its HTTP status
+// and body. Embedding failures are reported like those of semantic
+// chunking; the store's own errors are internal.
+func indexError(ctx context.Context, err error) (int, errorResponse) {
+	if errors.Is(err, chunking.ErrEmbeddingFailed) || errors.Is(err, context.Canceled) {
+		return chunkError(ctx, err)
+	}
+	return http.StatusInternalServerError, errorResponse{Error: withheld(ctx, "vector index failed", err), Code: "index_failed"}
+}
+
+// reembedBatch is the number of chunks embedded per call during a
+// reembed.
+const reembedBatch = 256
+
+type reembedRequest struct {
+	// Model is the embedding model the index moves to.
+	Model string `json:"model"`
+}
+
+// reembedStatus reports a reembed. Status is one of the job states
+// running, succeeded or failed.
+type reembedStatus struct {
+	SchemaVersion int        `json:"schema_version"`
+	Status        string     `json:"status"`
+	Model         string     `json:"model"`
+	Chunks        int        `json:"chunks"`
+	Embedded      int        `json:"embedded"`
+	StartedAt     time.Time  `json:"started_at"`
+	FinishedAt    *time.Time `json:"finished_at,omitempty"`
+	Error         string     `json:"error,omitempty"`
+	Code          string     `json:"code,omitempty"`
+}
+
+// handleReembed starts re-embedding every indexed chunk with another
+// model (POST) or reports on the running or last reembed (GET). The
+// chunks are embedded in the background into a new index, which then
+// replaces the current one and its embedder at once (blue/green):
+// searches use the old index until the swap, and /index and document
+// deletes are refused until it is done.
+func handleReembed(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		if checkIndex(w) == nil {
+			return
+		}
+		reembedMu.Lock()
+		state := reembedState
+		if state != nil {
+			copied := *state
+			state = &copied
+		}
+		reembedMu.Unlock()
+		if state == nil {
+			writeJSON(w, http.StatusNotFound, errorResponse{Error: "the index has not been re-embedded", Code: "reembed_not_found"})
+			return
+		}
+		writeJSON(w, http.StatusOK, state)
+	case http.MethodPost:
+		startReembed(w, r)
+	default:
+		writeJSON(w, http.StatusMethodNotAllowed, errorResponse{Error: "use GET or POST"})
+	}
+}
+
+func startReembed(w http.ResponseWriter, r *http.Request) {
+	idx := checkIndex(w)
+	if idx == nil {
+		return
+	}
+	var req reembedRequest
+	if !decodeJSON(w, r, &req) {
+		return
+	}
+	if req.Model == "" {
+		writeJSON(w, http.StatusBadRequest, errorResponse{Error: "model is required"})
+		return
+	}
+	base, ok := embedder.(*embedding.Client)
+	if !ok {
+		writeJSON(w, http.StatusBadRequest, errorResponse{Error: "re-embedding needs an embedding service; set CHUNKER_EMBEDDING_URL", Code: "reembed_unavailable"})
+		return
+	}
+	reembedMu.Lock()
+	if reembedState != nil && reembedState.Status == jobs.StatusRunning {
+		reembedMu.Unlock()
+		writeJSON(w, http.StatusConflict, errorResponse{Error: "a reembed is already running", Code: "reembed_in_progress"})
+		return
+	}
+	state := &reembedStatus{SchemaVersion: chunking.SchemaVersion, Status: jobs.StatusRunning, Model: req.Model, StartedAt: clock.Now()}
+	reembedState = state
+	copied := *state
+	reembedMu.Unlock()
+
+	// The client shares the configured client's limiter, retrier and
+	// cache, whose keys include the model.
+	client := *base
+	client.Model = req.Model
+	go runReembed(withRequestLog(serverCtx, &requestLog{id: requestID(r.Context())}), &client)
+	w.Header().Set("Location", apiPrefix+"/index/reembed")
+	writeJSON(w, http.StatusAccepted, copied)
+}
+
+// runReembed copies the index into a new one embedded by client and
+// swaps it in. On failure the current index stays in effect.
+func runReembed(ctx context.Context, client *embedding.Client) {
+	indexWrites.Lock()
+	defer indexWrites.Unlock()
+	blue := vectorIndex.Load()
+	var chunks []chunking.Chunk
+	if lister, ok := blue.store.(interface{ Chunks() []chunking.Chunk }); ok {
+		chunks = lister.Chunks()
+	}
+	setReembed(func(s *reembedStatus) { s.Chunks = len(chunks) })
+
+	green := &indexState{store: vectorstore.NewMemory(), embedder: client}
+	n, err := vectorstore.Reembed(ctx, green.store, client, chunks, reembedBatch)
+	finished := clock.Now()
+	if err != nil {
+		_, resp := indexError(ctx, err)
+		setReembed(func(s *reembedStatus) {
+			s.Status, s.Embedded, s.FinishedAt, s.Error, s.Code = jobs.StatusFailed, n, &finished, resp.Error, resp.Code
+		})
+		slog.Error("index reembed failed", "request_id", requestID(ctx), "model", client.Model, "embedded", n, "chunks", len(chunks), "error", err)
+		return
+	}
+	vectorIndex.Store(green)
+	setReembed(func(s *reembedStatus) { s.Status, s.Embedded, s.FinishedAt = jobs.StatusSucceeded, n, &finished })
+	slog.Info("index reembedded", "request_id", requestID(ctx), "model", client.Model, "chunks", n)
+}
+
+func setReembed(update func(*reembedStatus)) {
+	reembedMu.Lock()
+	defer reembedMu.Unlock()
+	update(reembedState)
+}
@@ -0,0 +1,409 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/http"
+	"net/netip"
+	"net/url"
+	"runtime/debug"
+	"strings"
+	"sync/atomic"
+	"syscall"
+	"time"
+
+	"chunker-service/pkg/chunking"
+	"chunker-service/pkg/jobs"
+	"chunker-service/pkg/retry"
+)
+
+// Job stores for jobsConfig.Store.
+const (
+	jobStoreMemory = "memory"
+	jobStoreRedis  = "redis"
+)
+
+var (
+	jobStore jobs.Store
+	// jobScheduler bounds the jobs chunking at once, per tenant and in
+	// total; the rest wait in line.
+	jobScheduler *jobs.Scheduler
+	jobPending   atomic.Int64
+	jobMax       int64
+	jobTimeout   time.Duration
+
+	callbackSecret []byte
+	// callbackHosts, when not empty, lists the only hosts callbacks may
+	// be sent to. When it is empty, callbacks may only reach public
+	// addresses.
+	callbackHosts  map[string]bool
+	callbackClient = &http.Client{
+		Timeout: 10 * time.Second,
+		// Callbacks are dialed directly, not through a proxy, so the
+		// address checked is the one connected to, after DNS resolution.
+		Transport: requestIDTransport{base: &http.Transport{
+			DialContext:         (&net.Dialer{Timeout: 5 * time.Second, Control: checkCallbackDial}).DialContext,
+			TLSHandshakeTimeout: 5 * time.Second,
+			MaxIdleConns:        10,
+			IdleConnTimeout:     90 * time.Second,
+		}},
+		// A redirect could lead past callbackHosts.
+		CheckRedirect: func(*http.Request, []*http.Request) error { return http.ErrUseLastResponse },
+	}
+	// Receivers are independent, so one that is down must not trip a
+	// breaker for the rest.
+	callbackRetrier = retry.New("job-callback", retry.Policy{
+		MaxAttempts: 5, InitialBackoffMS: 1000, MaxBackoffMS: 30000, Multiplier: 2,
+	})
+)
+
+type jobResponse struct {
+	SchemaVersion int `json:"schema_version"`
+	jobs.Job
+}
+
+// jobRequest is a /chunk request plus an optional completion callback.
+type jobRequest struct {
+	chunkRequest
+	Callback *callbackRequest `json:"callback,omitempty"`
+}
+
+type callbackRequest struct {
+	URL           string `json:"url"`
+	IncludeResult bool   `json:"include_result,omitempty"`
+}
+
+// newJobStore builds the configured store without connecting to it.
+func (j jobsConfig) newJobStore() (jobs.Store, error) {
+	ttl := seconds(j.TTLSeconds)
+	if j.Store == jobStoreRedis {
+		return jobs.NewRedis(j.RedisURL, ttl)
+	}
+	return jobs.NewMemory(ttl), nil
+}
+
+func loadJobs(cfg jobsConfig) {
+	// Already validated by loadConfig.
+	store, err := cfg.newJobStore()
+	if err != nil {
+		fatal("job store unavailable", err)
+	}
+	if r, ok := store.(*jobs.Redis); ok {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		if err := r.Ping(ctx); err != nil {
+			fatal("job store unavailable", err)
+		}
+	}
+	jobStore = store
+	jobScheduler = jobs.NewScheduler(cfg.Workers, cfg.MaxPerTenant, cfg.TenantWeights)
+	jobMax = int64(cfg.MaxPending)
+	jobTimeout = seconds(cfg.TimeoutSeconds)
+	callbackSecret = []byte(cfg.CallbackSecret)
+	if len(cfg.CallbackHosts) > 0 {
+		callbackHosts = map[string]bool{}
+		for _, h := range cfg.CallbackHosts {
+			callbackHosts[strings.ToLower(h)] = true
+		}
+	}
+}
+
+// errCallbackAddress is returned for callbacks to a non-public address
+// while no callback hosts are configured.
+var errCallbackAddress = errors.New("callback address is not public")
+
+// nonPublicPrefixes are the ranges publicAddr refuses beyond those the
+// netip predicates cover.
+var nonPublicPrefixes = []netip.Prefix{
+	netip.MustParsePrefix("0.0.0.0/8"),
+	netip.MustParsePrefix("100.64.0.0/10"), // carrier-grade NAT
+}
+
+// publicAddr reports whether ip is a public unicast address: not
+// loopback, private, link-local (which holds cloud metadata endpoints),
+// unspecified or multicast.
+func publicAddr(ip netip.Addr) bool {
+	ip = ip.Unmap()
+	if !ip.IsGlobalUnicast() || ip.IsPrivate() {
+		return false
+	}
+	for _, p := range nonPublicPrefixes {
+		if p.Contains(ip) {
+			return false
+		}
+	}
+	return true
+}
+
+// checkCallbackDial refuses connections to non-public addresses while no
+// callback hosts are configured. It runs on the resolved address of every
+// connection, so a host that resolves differently after checkCallback
+// cannot reach the internal network.
+func checkCallbackDial(network, address string, _ syscall.RawConn) error {
+	if callbackHosts != nil {
+		return nil
+	}
+	ap, err := netip.ParseAddrPort(address)
+	if err != nil {
+		return err
+	}
+	if !publicAddr(ap.Addr()) {
+		return fmt.Errorf("%w: %s", errCallbackAddress, ap.Addr())
+	}
+	return nil
+}
+
+// checkCallback validates a callback URL against the configuration.
+// Without callback hosts, the URL's host must resolve to public addresses
+// only.
+func checkCallback(ctx context.Context, cb *callbackRequest) (string, error) {
+	if len(callbackSecret) == 0 {
+		return "callbacks_disabled", errors.New("job callbacks are not enabled on this server")
+	}
+	u, err := url.Parse(cb.URL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return "invalid_callback", errors.New("callback.url must be an absolute http or https URL")
+	}
+	if callbackHosts != nil {
+		if !callbackHosts[strings.ToLower(u.Hostname())] {
+			return "callback_not_allowed", fmt.Errorf("callback host %q is not allowed", u.Hostname())
+		}
+		return "", nil
+	}
+	addrs, err := net.DefaultResolver.LookupNetIP(ctx, "ip", u.Hostname())
+	if err != nil {
+		return "invalid_callback", fmt.Errorf("callback host %q does not resolve", u.Hostname())
+	}
+	for _, ip := range addrs {
+		if !publicAddr(ip) {
+			return "callback_not_allowed", fmt.Errorf("callback host %q is not a public address; set callback hosts to allow it", u.Hostname())
+		}
+	}
+	return "", nil
+}
+
+// handleJobs accepts a /chunk request body and chunks it in the
+// background. The job ID in the response, or the callback, is the only
+// way to learn the result.
+func handleJobs(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeJSON(w, http.StatusMethodNotAllowed, errorResponse{Error: "use POST"})
+		return
+	}
+	var req jobRequest
+	if !decodeChunkJSON(w, r, &req, &req.chunkRequest) {
+		return
+	}
+	if req.Plan.WindowSize <= 0 {
+		writeJSON(w, http.StatusBadRequest, errorResponse{Error: "plan.window_size must be > 0"})
+		return
+	}
+	if req.Callback != nil {
+		if code, err := checkCallback(r.Context(), req.Callback); err != nil {
+			writeJSON(w, http.StatusBadRequest, errorResponse{Error: err.Error(), Code: code})
+			return
+		}
+	}
+	if n := jobPending.Add(1); jobMax > 0 && n > jobMax {
+		jobPending.Add(-1)
+		w.Header().Set("Retry-After", "30")
+		writeJSON(w, http.StatusServiceUnavailable, errorResponse{Error: "too many pending jobs", Code: "queue_full"})
+		return
+	}
+	job := jobs.Job{ID: jobs.NewID(), Status: jobs.StatusQueued, CreatedAt: clock.Now(), Owner: tokenSubject(r.Context())}
+	job.Tenant = jobTenant(r.Context(), req.chunkRequest)
+	if req.Callback != nil {
+		job.Callback = &jobs.Callback{URL: req.Callback.URL, IncludeResult: req.Callback.IncludeResult, Status: jobs.CallbackPending}
+	}
+	if err := jobStore.Put(r.Context(), job); err != nil {
+		jobPending.Add(-1)
+		slog.Error("job store write failed", "request_id", requestID(r.Context()), "job_id", job.ID, "error", err)
+		writeJSON(w, http.StatusServiceUnavailable, errorResponse{Error: "job store unavailable", Code: "job_store_unavailable"})
+		return
+	}
+	// The job outlives the request but keeps its ID for logs and calls.
+	go runJob(withRequestLog(serverCtx, &requestLog{id: requestID(r.Context())}), job, req.chunkRequest)
+	w.Header().Set("Location", apiPrefix+"/jobs/"+job.ID)
+	writeJSON(w, http.StatusAccepted, jobResponse{SchemaVersion: chunking.SchemaVersion, Job: job})
+}
+
+// jobTenant is the tenant a job is scheduled under: the subject of the
+// bearer token that submitted it or, without auth, the request's "tenant"
+// metadata. Jobs without either share the "" tenant.
+func jobTenant(ctx context.Context, req chunkRequest) string {
+	if sub := tokenSubject(ctx); sub != "" {
+		return sub
+	}
+	tenant, _ := req.Meta["tenant"].(string)
+	return tenant
+}
+
+// runJob chunks req, records the outcome and then sends the callback,
+// if any. ctx derives from serverCtx, so jobs still queued or running at
+// shutdown fail as cancelled.
+func runJob(ctx context.Context, job jobs.Job, req chunkRequest) {
+	job = executeJob(ctx, job, req)
+	jobPending.Add(-1)
+	if job.Callback != nil {
+		deliverCallback(ctx, job)
+	}
+}
+
+// executeJob waits for the scheduler to grant a slot, chunks req and returns the finished
+// job. A panic fails the job instead of the server.
+func executeJob(ctx context.Context, job jobs.Job, req chunkRequest) (finished jobs.Job) {
+	defer func() {
+		if v := recover(); v != nil {
+			slog.Error("panic running job", "request_id", requestID(ctx), "job_id", job.ID, "panic", fmt.Sprint(v), "stack", string(debug.Stack()))
+			finished = finishJob(ctx, job, nil, errInternal)
+		}
+	}()
+	release, err := jobScheduler.Acquire(ctx, job.Tenant)
+	if err != nil {
+		return finishJob(ctx, job, nil, err)
+	}
+	defer release()
+	started := clock.Now()
+	job.Status, job.StartedAt = jobs.StatusRunning, &started
+	putJob(job)
+
+	runCtx, cancel := ctx, context.CancelFunc(func() {})
+	if jobTimeout > 0 {
+		runCtx, cancel = context.WithTimeout(ctx, jobTimeout)
+	}
+	defer cancel()
+	chunks, err := runChunk(runCtx, req, nil)
+	return finishJob(ctx, job, chunks, err)
+}
+
+// finishJob records and logs the outcome of a job.
+func finishJob(ctx context.Context, job jobs.Job, chunks []chunking.Chunk, err error) jobs.Job {
+	var result json.RawMessage
+	if err == nil {
+		result, err = json.Marshal(chunks)
+	}
+	finished := clock.Now()
+	job.FinishedAt = &finished
+	switch {
+	case errors.Is(err, context.DeadlineExceeded):
+		job.Status, job.Error, job.Code = jobs.StatusFailed, "job timed out", "timeout"
+	case err != nil:
+		_, resp := chunkError(ctx, err)
+		job.Status, job.Error, job.Code = jobs.StatusFailed, resp.Error, resp.Code
+	default:
+		job.Status, job.Result = jobs.StatusSucceeded, result
+	}
+	attrs := []interface{}{"request_id", requestID(ctx), "job_id", job.ID, "status", job.Status}
+	if job.StartedAt != nil {
+		attrs = append(attrs, "latency_ms", float64(finished.Sub(*job.StartedAt).Microseconds())/1000)
+	}
+	if job.Status == jobs.StatusSucceeded {
+		attrs = append(attrs, "chunks", len(chunks))
+	} else {
+		attrs = append(attrs, "code", job.Code, "error", err)
+	}
+	slog.Info("job finished", attrs...)
+	putJob(job)
+	return job
+}
+
+// deliverCallback POSTs the signed job event to the job's callback URL,
+// retrying network errors, 408, 429 and 5xx responses with backoff, and
+// records the outcome on the job. Shutdown abandons pending retries.
+func deliverCallback(ctx context.Context, job jobs.Job) {
+	body, err := json.Marshal(jobs.NewEvent(job, apiPrefix+"/jobs/"+job.ID))
+	if err != nil {
+		slog.Error("job callback encode failed", "request_id", requestID(ctx), "job_id", job.ID, "error", err)
+		return
+	}
+	attempts, err := callbackRetrier.Do(ctx, func(ctx context.Context) error {
+		return postCallback(ctx, job, body)
+	})
+	job.Callback.Attempts = attempts
+	if err != nil {
+		job.Callback.Status, job.Callback.Error = jobs.CallbackFailed, err.Error()
+		// Network errors name the addresses the URL resolved to.
+		var urlErr *url.Error
+		if errors.Is(err, errCallbackAddress) {
+			job.Callback.Error = errCallbackAddress.Error()
+		} else if errors.As(err, &urlErr) {
+			job.Callback.Error = "callback request failed"
+		}
+		slog.Warn("job callback failed", "request_id", requestID(ctx), "job_id", job.ID, "attempts", attempts, "error", err)
+	} else {
+		job.Callback.Status = jobs.CallbackDelivered
+	}
+	putJob(job)
+}
+
+func postCallback(ctx context.Context, job jobs.Job, body []byte) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, job.Callback.URL, bytes.NewReader(body))
+	if err != nil {
+		return retry.Permanent(err)
+	}
+	now := time.Now()
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "chunker-service")
+	req.Header.Set("X-Chunker-Job-Id", job.ID)
+	req.Header.Set(jobs.TimestampHeader, fmt.Sprint(now.Unix()))
+	req.Header.Set(jobs.SignatureHeader, jobs.Sign(callbackSecret, now, body))
+	resp, err := callbackClient.Do(req)
+	if errors.Is(err, errCallbackAddress) {
+		return retry.Permanent(err)
+	}
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	switch {
+	case resp.StatusCode >= 200 && resp.StatusCode < 300:
+		return nil
+	case resp.StatusCode == http.StatusRequestTimeout, resp.StatusCode == http.StatusTooManyRequests, resp.StatusCode >= 500:
+		return fmt.Errorf("callback returned %s", resp.Status)
+	}
+	return retry.Permanent(fmt.Errorf("callback returned %s", resp.Status))
+}
+
+// putJob records a state change from a background job. Its request is
+// gone, so a failed write can only be logged; pollers then see the last
+// state that was written.
+func putJob(job jobs.Job) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if err := jobStore.Put(ctx, job); err != nil {
+		slog.Error("job store write failed", "job_id", job.ID, "error", err)
+	}
+}
+
+func handleJob(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeJSON(w, http.StatusMethodNotAllowed, errorResponse{Error: "use GET"})
+		return
+	}
+	id := strings.TrimPrefix(apiPath(r), "/jobs/")
+	if id == "" || strings.Contains(id, "/") {
+		writeJSON(w, http.StatusNotFound, errorResponse{Error: "job not found", Code: "job_not_found"})
+		return
+	}
+	job, err := jobStore.Get(r.Context(), id)
+	// Another caller's job is reported as missing so its ID cannot be
+	// confirmed.
+	if err == nil && job.Owner != tokenSubject(r.Context()) {
+		err = jobs.ErrNotFound
+	}
+	if errors.Is(err, jobs.ErrNotFound) {
+		writeJSON(w, http.StatusNotFound, errorResponse{Error: "job not found", Code: "job_not_found"})
+		return
+	}
+	if err != nil {
+		slog.Error("job store read failed", "request_id", requestID(r.Context()), "job_id", id, "error", err)
+		writeJSON(w, http.StatusServiceUnavailable, errorResponse{Error: "job store unavailable", Code: "job_store_unavailable"})
+		return
+	}
+	writeJSON(w, http.StatusOK, jobResponse{SchemaVersion: chunking.SchemaVersion, Job: job})
+}
@@ -0,0 +1,200 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"strings"
+	"testing"
+
+	"chunker-service/pkg/jobs"
+	"chunker-service/pkg/jwtauth"
+)
+
+// withSubject returns r as authorized by a token for subject.
+func withSubject(r *http.Request, subject string) *http.Request {
+	return r.WithContext(context.WithValue(r.Context(), claimsKey{}, &jwtauth.Claims{Subject: subject}))
+}
+
+func TestHandleJobOwner(t *testing.T) {
+	old := jobStore
+	defer func() { jobStore = old }()
+	jobStore = jobs.NewMemory(0)
+	job := jobs.Job{ID: jobs.NewID(), Status: jobs.StatusSucceeded, Owner: "alice"}
+	if err := jobStore.Put(context.Background(), job); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, tc := range []struct {
+		subject string
+		status  int
+	}{
+		{"alice", http.StatusOK},
+		{"mallory", http.StatusNotFound},
+		// Without auth there is no subject, which owns only jobs
+		// submitted without auth.
+		{"", http.StatusNotFound},
+	} {
+		r := httptest.NewRequest(http.MethodGet, apiPrefix+"/jobs/"+job.ID, nil)
+		if tc.subject != "" {
+			r = withSubject(r, tc.subject)
+		}
+		w := httptest.NewRecorder()
+		handleJob(w, r)
+		if w.Code != tc.status {
+			t.Errorf("%q: got %d, want %d", tc.subject, w.Code, tc.status)
+			continue
+		}
+		if tc.status == http.StatusNotFound {
+			var resp errorResponse
+			json.Unmarshal(w.Body.Bytes(), &resp)
+			// Indistinguishable from a job that does not exist.
+			if resp.Code != "job_not_found" {
+				t.Errorf("%q: got code %q, want job_not_found", tc.subject, resp.Code)
+			}
+			continue
+		}
+		var resp jobResponse
+		if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil || resp.ID != job.ID {
+			t.Errorf("%q: expected the job, got %s", tc.subject, w.Body)
+		}
+	}
+}
+
+func TestPublicAddr(t *testing.T) {
+	for _, tc := range []struct {
+		addr   string
+		public bool
+	}{
+		{"8.8.8.8", true},
+		{"2001:4860:4860::8888", true},
+		{"127.0.0.1", false},
+		{"::1", false},
+		{"10.1.2.3", false},
+		{"172.16.0.1", false},
+		{"192.168.1.1", false},
+		{"fd00::1", false},
+		{"169.254.169.254", false}, // cloud metadata
+		{"fe80::1", false},
+		{"100.64.0.1", false}, // carrier-grade NAT
+		{"100.127.255.255", false},
+		{"100.128.0.1", true},
+		{"0.0.0.0", false},
+		{"0.1.2.3", false},
+		{"224.0.0.1", false},
+		{"::ffff:127.0.0.1", false},
+		{"::ffff:169.254.169.254", false},
+		{"::ffff:8.8.8.8", true},
+	} {
+		if got := publicAddr(netip.MustParseAddr(tc.addr)); got != tc.public {
+			t.Errorf("publicAddr(%s) = %v, want %v", tc.addr, got, tc.public)
+		}
+	}
+}
+
+// withCallbacks enables callbacks, to hosts only when hosts is not empty,
+// until the test ends.
+func withCallbacks(t *testing.T, hosts ...string) {
+	oldSecret, oldHosts := callbackSecret, callbackHosts
+	t.Cleanup(func() { callbackSecret, callbackHosts = oldSecret, oldHosts })
+	callbackSecret, callbackHosts = []byte("s3cret"), nil
+	if len(hosts) > 0 {
+		callbackHosts = map[string]bool{}
+		for _, h := range hosts {
+			callbackHosts[h] = true
+		}
+	}
+}
+
+func TestCheckCallback(t *testing.T) {
+	ctx := context.Background()
+	for _, tc := range []struct {
+		name  string
+		hosts []string
+		url   string
+		code  string
+	}{
+		{"public", nil, "https://8.8.8.8/hook", ""},
+		{"loopback", nil, "http://127.0.0.1:8080/hook", "callback_not_allowed"},
+		{"localhost", nil, "http://localhost/hook", "callback_not_allowed"},
+		{"rfc1918", nil, "http://10.0.0.5/hook", "callback_not_allowed"},
+		{"link-local", nil, "http://169.254.169.254/latest/meta-data", "callback_not_allowed"},
+		{"cgnat", nil, "http://100.100.100.200/hook", "callback_not_allowed"},
+		{"ipv4-mapped", nil, "http://[::ffff:10.0.0.5]/hook", "callback_not_allowed"},
+		{"ipv6 loopback", nil, "http://[::1]/hook", "callback_not_allowed"},
+		{"not http", nil, "file:///etc/passwd", "invalid_callback"},
+		{"relative", nil, "/hook", "invalid_callback"},
+		{"allowlisted private", []string{"10.0.0.5"}, "http://10.0.0.5/hook", ""},
+		{"allowlist is case-insensitive", []string{"hooks.internal"}, "http://HOOKS.internal/x", ""},
+		{"not allowlisted", []string{"hooks.internal"}, "https://8.8.8.8/hook", "callback_not_allowed"},
+	} {
+		withCallbacks(t, tc.hosts...)
+		code, err := checkCallback(ctx, &callbackRequest{URL: tc.url})
+		if code != tc.code || (err == nil) != (tc.code == "") {
+			t.Errorf("%s: got %q, %v, want %q", tc.name, code, err, tc.code)
+		}
+	}
+
+	callbackSecret = nil
+	if code, _ := checkCallback(ctx, &callbackRequest{URL: "https://8.8.8.8/hook"}); code != "callbacks_disabled" {
+		t.Errorf("expected callbacks_disabled without a secret, got %q", code)
+	}
+}
+
+func TestCheckCallbackDial(t *testing.T) {
+	for _, tc := range []struct {
+		hosts   []string
+		address string
+		refused bool
+	}{
+		{nil, "8.8.8.8:443", false},
+		{nil, "127.0.0.1:80", true},
+		{nil, "[::1]:80", true},
+		{nil, "192.168.0.10:80", true},
+		{nil, "169.254.169.254:80", true},
+		{nil, "100.64.1.1:80", true},
+		{nil, "[::ffff:127.0.0.1]:80", true},
+		// The allowlist is checked on the URL; any address it resolves
+		// to may be dialed.
+		{[]string{"hooks.internal"}, "10.0.0.5:80", false},
+	} {
+		withCallbacks(t, tc.hosts...)
+		err := checkCallbackDial("tcp", tc.address, nil)
+		if refused := errors.Is(err, errCallbackAddress); refused != tc.refused {
+			t.Errorf("%s (hosts %v): got %v, want refused %v", tc.address, tc.hosts, err, tc.refused)
+		}
+	}
+}
+
+func TestCallbackRefusesRedirects(t *testing.T) {
+	var followed bool
+	target := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		followed = true
+	}))
+	defer target.Close()
+	hook := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, target.URL, http.StatusTemporaryRedirect)
+	}))
+	defer hook.Close()
+
+	// Test servers listen on loopback, so allow it by name.
+	withCallbacks(t, "127.0.0.1")
+	job := jobs.Job{ID: jobs.NewID(), Callback: &jobs.Callback{URL: hook.URL}}
+	err := postCallback(context.Background(), job, []byte(`{}`))
+	if err == nil || !strings.Contains(err.Error(), "307") {
+		t.Errorf("expected the redirect to fail the callback, got %v", err)
+	}
+	if followed {
+		t.Error("the redirect was followed")
+	}
+
+	// Without the allowlist the dial itself is refused.
+	callbackClient.Transport.(requestIDTransport).base.(*http.Transport).CloseIdleConnections()
+	withCallbacks(t)
+	if err := postCallback(context.Background(), job, []byte(`{}`)); !errors.Is(err, errCallbackAddress) {
+		t.Errorf("expected the loopback dial to be refused, got %v", err)
+	}
+}
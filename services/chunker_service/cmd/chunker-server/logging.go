@@ -0,0 +1,308 @@
+package main
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+)
+
+// Log formats and levels for logConfig.
+const (
+	logFormatJSON = "json"
+	logFormatText = "text"
+)
+
+var logLevels = map[string]slog.Level{
+	"debug": slog.LevelDebug,
+	"info":  slog.LevelInfo,
+	"warn":  slog.LevelWarn,
+	"error": slog.LevelError,
+}
+
+// requestIDHeader carries a request's ID in both directions: a caller's
+// valid ID is kept, otherwise one is generated, and it is echoed in the
+// response and forwarded on calls the request makes.
+const requestIDHeader = "X-Request-ID"
+
+// probePaths are logged at debug level when they succeed, as kubelet
+// polls them every few seconds.
+var probePaths = map[string]bool{"/livez": true, "/readyz": true, "/healthz": true}
+
+// maxRequestIDLen bounds caller-supplied IDs so they stay log-friendly.
+const maxRequestIDLen = 128
+
+func (l logConfig) validate() error {
+	if l.Format != logFormatJSON && l.Format != logFormatText {
+		return fmt.Errorf("format must be %q or %q, got %q", logFormatJSON, logFormatText, l.Format)
+	}
+	if _, ok := logLevels[l.Level]; !ok {
+		return fmt.Errorf("level must be debug, info, warn or error, got %q", l.Level)
+	}
+	return nil
+}
+
+// setupLogging makes a slog logger writing to stderr the default, for
+// both slog and the log package.
+func setupLogging(l logConfig) {
+	slog.SetDefault(slog.New(newLogHandler(l, os.Stderr)))
+}
+
+// newLogHandler returns a handler writing l's format and level to w.
+func newLogHandler(l logConfig, w io.Writer) slog.Handler {
+	opts := &slog.HandlerOptions{Level: logLevels[l.Level]}
+	if l.Format == logFormatText {
+		return slog.NewTextHandler(w, opts)
+	}
+	return slog.NewJSONHandler(w, opts)
+}
+
+// fatal logs a startup error and exits.
+func fatal(msg string, err error) {
+	slog.Error(msg, "error", err)
+	os.Exit(1)
+}
+
+// requestLog collects what a request's log line reports beyond what the
+// middleware sees itself.
+type requestLog struct {
+	id     string
+	chunks int
+}
+
+type requestLogKey struct{}
+
+func withRequestLog(ctx context.Context, rl *requestLog) context.Context {
+	return context.WithValue(ctx, requestLogKey{}, rl)
+}
+
+// requestID returns the ID of the request ctx belongs to, or "".
+func requestID(ctx context.Context) string {
+	if rl, ok := ctx.Value(requestLogKey{}).(*requestLog); ok {
+		return rl.id
+	}
+	return ""
+}
+
+// logChunks records the number of chunks a request produced.
+func logChunks(ctx context.Context, n int) {
+	if rl, ok := ctx.Value(requestLogKey{}).(*requestLog); ok {
+		rl.chunks = n
+	}
+}
+
+// validRequestID accepts short IDs of letters, digits and "-_.:", which
+// covers UUIDs and trace IDs without letting callers inject log syntax.
+func validRequestID(id string) bool {
+	if id == "" || len(id) > maxRequestIDLen {
+		return false
+	}
+	for _, c := range id {
+		if !(c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || strings.ContainsRune("-_.:", c)) {
+			return false
+		}
+	}
+	return true
+}
+
+func newRequestID() string {
+	b := make([]byte, 16)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// logRequests assigns each request an ID and logs one line per request
+// with its method, path, status, latency, body sizes and, for chunking
+// requests, the chunk count.
+func logRequests(h http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		rl := &requestLog{id: r.Header.Get(requestIDHeader), chunks: -1}
+		if !validRequestID(rl.id) {
+			rl.id = newRequestID()
+		}
+		w.Header().Set(requestIDHeader, rl.id)
+		body := &countingReader{ReadCloser: r.Body}
+		r.Body = body
+		sw := &statusWriter{ResponseWriter: w, status: http.StatusOK}
+		r = r.WithContext(withRequestLog(r.Context(), rl))
+
+		h.ServeHTTP(sw, r)
+
+		attrs := []slog.Attr{
+			slog.String("request_id", rl.id),
+			slog.String("method", r.Method),
+			slog.String("path", r.URL.Path),
+			slog.Int("status", sw.status),
+			slog.Float64("latency_ms", float64(time.Since(start).Microseconds())/1000),
+			slog.Int64("bytes_in", body.n),
+			slog.Int64("bytes_out", sw.n),
+		}
+		if rl.chunks >= 0 {
+			attrs = append(attrs, slog.Int("chunks", rl.chunks))
+		}
+		level := slog.LevelInfo
+		switch {
+		case sw.status >= 500:
+			level = slog.LevelError
+		case probePaths[r.URL.Path]:
+			level = slog.LevelDebug
+		}
+		slog.LogAttrs(r.Context(), level, "request", attrs...)
+	})
+}
+
+type countingReader struct {
+	io.ReadCloser
+	n int64
+}
+
+func (c *countingReader) Read(p []byte) (int, error) {
+	n, err := c.ReadCloser.Read(p)
+	c.n += int64(n)
+	return n, err
+}
+
+type statusWriter struct {
+	http.ResponseWriter
+	status      int
+	wroteHeader bool
+	n           int64
+}
+
+func (w *statusWriter) WriteHeader(status int) {
+	if !w.wroteHeader {
+		w.status, w.wroteHeader = status, true
+	}
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *statusWriter) Write(p []byte) (int, error) {
+	w.wroteHeader = true
+	n, err := w.ResponseWriter.Write(p)
+	w.n += int64(n)
+	return n, err
+}
+
+// Unwrap lets http.ResponseController reach the underlying writer.
+func (w *statusWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// requestIDTransport forwards the ID of the request an outgoing call is
+// made for, so downstream logs can be joined with ours.
+type requestIDTransport struct {
+	base http.RoundTripper
+}
+
+func (t requestIDTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	base := t.base
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	if id := requestID(req.Context()); id != "" && req.Header.Get(requestIDHeader) == "" {
+		req = req.Clone(req.Context())
+		req.Header.Set(requestIDHeader, id)
+	}
+	return base.RoundTrip(req)
+}
+
+// grpcLogging returns interceptors doing for gRPC what logRequests does
+// for HTTP, with the ID in the x-request-id metadata.
+func grpcLogging() []grpc.ServerOption {
+	begin := func(ctx context.Context) (*requestLog, context.Context) {
+		rl := &requestLog{chunks: -1}
+		if md, ok := metadata.FromIncomingContext(ctx); ok {
+			if v := md.Get(requestIDHeader); len(v) > 0 {
+				rl.id = v[0]
+			}
+		}
+		if !validRequestID(rl.id) {
+			rl.id = newRequestID()
+		}
+		return rl, withRequestLog(ctx, rl)
+	}
+	end := func(ctx context.Context, rl *requestLog, method string, start time.Time, bytesIn int, err error) {
+		code := status.Code(err)
+		attrs := []slog.Attr{
+			slog.String("request_id", rl.id),
+			slog.String("method", method),
+			slog.String("code", code.String()),
+			slog.Float64("latency_ms", float64(time.Since(start).Microseconds())/1000),
+			slog.Int("bytes_in", bytesIn),
+		}
+		if rl.chunks >= 0 {
+			attrs = append(attrs, slog.Int("chunks", rl.chunks))
+		}
+		level := slog.LevelInfo
+		if grpcServerError(code) {
+			level = slog.LevelError
+		}
+		slog.LogAttrs(ctx, level, "grpc request", attrs...)
+	}
+	return []grpc.ServerOption{
+		grpc.ChainUnaryInterceptor(func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+			start := time.Now()
+			rl, ctx := begin(ctx)
+			_ = grpc.SetHeader(ctx, metadata.Pairs(requestIDHeader, rl.id))
+			size := 0
+			if m, ok := req.(proto.Message); ok {
+				size = proto.Size(m)
+			}
+			resp, err := handler(ctx, req)
+			end(ctx, rl, info.FullMethod, start, size, err)
+			return resp, err
+		}),
+		grpc.ChainStreamInterceptor(func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+			start := time.Now()
+			rl, ctx := begin(ss.Context())
+			_ = ss.SetHeader(metadata.Pairs(requestIDHeader, rl.id))
+			ls := &loggedStream{ServerStream: ss, ctx: ctx}
+			err := handler(srv, ls)
+			end(ctx, rl, info.FullMethod, start, ls.bytesIn, err)
+			return err
+		}),
+	}
+}
+
+// grpcServerError reports whether a status code means the server, not
+// the caller, is at fault.
+func grpcServerError(code codes.Code) bool {
+	switch code {
+	case codes.Internal, codes.Unavailable, codes.Unknown, codes.DataLoss, codes.Unimplemented:
+		return true
+	}
+	return false
+}
+
+// loggedStream gives a stream's handler the logging context and counts
+// the bytes it receives.
+type loggedStream struct {
+	grpc.ServerStream
+	ctx     context.Context
+	bytesIn int
+}
+
+func (s *loggedStream) Context() context.Context {
+	return s.ctx
+}
+
+func (s *loggedStream) RecvMsg(m interface{}) error {
+	err := s.ServerStream.RecvMsg(m)
+	if msg, ok := m.(proto.Message); ok && err == nil {
+		s.bytesIn += proto.Size(msg)
+	}
+	return err
+}
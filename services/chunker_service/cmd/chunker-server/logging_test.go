@@ -0,0 +1,128 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// captureLogs sends the default logger to a buffer until the test ends.
+func captureLogs(t *testing.T, l logConfig) *bytes.Buffer {
+	var buf bytes.Buffer
+	old := slog.Default()
+	t.Cleanup(func() { slog.SetDefault(old) })
+	slog.SetDefault(slog.New(newLogHandler(l, &buf)))
+	return &buf
+}
+
+func TestLogRequestsRequestID(t *testing.T) {
+	captureLogs(t, logConfig{Format: logFormatJSON, Level: "info"})
+	var seen string
+	h := logRequests(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		seen = requestID(r.Context())
+	}))
+	for _, tc := range []struct {
+		name, sent string
+		kept       bool
+	}{
+		{"uuid", "3f2b8c1e-7a4d-4e7b-9c55-0a1b2c3d4e5f", true},
+		{"trace id", "00-abc.def:1_2", true},
+		{"missing", "", false},
+		{"log injection", "abc\ninjected=1", false},
+		{"space", "a b", false},
+		{"too long", strings.Repeat("a", maxRequestIDLen+1), false},
+	} {
+		r := httptest.NewRequest(http.MethodGet, "/v1/health", nil)
+		if tc.sent != "" {
+			r.Header.Set(requestIDHeader, tc.sent)
+		}
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, r)
+		got := w.Header().Get(requestIDHeader)
+		if got != seen {
+			t.Errorf("%s: responded with %q but the handler saw %q", tc.name, got, seen)
+		}
+		if tc.kept && got != tc.sent {
+			t.Errorf("%s: expected %q kept, got %q", tc.name, tc.sent, got)
+		}
+		if !tc.kept && (got == tc.sent || !validRequestID(got) || len(got) != 32) {
+			t.Errorf("%s: expected a generated ID, got %q", tc.name, got)
+		}
+	}
+}
+
+func TestRequestIDTransportForwards(t *testing.T) {
+	var got []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = append(got, r.Header.Get(requestIDHeader))
+	}))
+	defer srv.Close()
+	client := &http.Client{Transport: requestIDTransport{}}
+
+	ctx := withRequestLog(context.Background(), &requestLog{id: "req-1"})
+	for _, preset := range []string{"", "callee-chosen"} {
+		req, _ := http.NewRequestWithContext(ctx, http.MethodGet, srv.URL, nil)
+		if preset != "" {
+			req.Header.Set(requestIDHeader, preset)
+		}
+		resp, err := client.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+	}
+	if strings.Join(got, ",") != "req-1,callee-chosen" {
+		t.Errorf("forwarded %q, want the request's ID unless one was set", got)
+	}
+}
+
+func TestLogRequestsFormat(t *testing.T) {
+	h := logRequests(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.Copy(io.Discard, r.Body)
+		logChunks(r.Context(), 3)
+		w.WriteHeader(http.StatusCreated)
+		w.Write([]byte("hello"))
+	}))
+	serve := func(path string) {
+		r := httptest.NewRequest(http.MethodPost, path, strings.NewReader("body"))
+		r.Header.Set(requestIDHeader, "req-1")
+		h.ServeHTTP(httptest.NewRecorder(), r)
+	}
+
+	buf := captureLogs(t, logConfig{Format: logFormatJSON, Level: "info"})
+	serve("/v1/chunk")
+	var line map[string]interface{}
+	if err := json.Unmarshal(buf.Bytes(), &line); err != nil {
+		t.Fatalf("expected one JSON line, got %q: %v", buf, err)
+	}
+	for k, want := range map[string]interface{}{
+		"msg": "request", "level": "INFO", "request_id": "req-1", "method": "POST",
+		"path": "/v1/chunk", "status": 201.0, "bytes_in": 4.0, "bytes_out": 5.0, "chunks": 3.0,
+	} {
+		if line[k] != want {
+			t.Errorf("%s = %v, want %v", k, line[k], want)
+		}
+	}
+	if _, ok := line["latency_ms"].(float64); !ok {
+		t.Errorf("expected latency_ms, got %v", line)
+	}
+
+	// Probes only log at debug level.
+	buf.Reset()
+	serve("/livez")
+	if buf.Len() != 0 {
+		t.Errorf("expected no probe line at info level, got %q", buf)
+	}
+
+	buf = captureLogs(t, logConfig{Format: logFormatText, Level: "debug"})
+	serve("/livez")
+	if out := buf.String(); !strings.Contains(out, "level=DEBUG msg=request request_id=req-1") || !strings.Contains(out, "path=/livez") {
+		t.Errorf("unexpected text line %q", out)
+	}
+}
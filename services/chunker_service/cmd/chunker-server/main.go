@@ -1,71 +1,627 @@
 package main
 
 import (
+	"context"
 	"encoding/json"
-	"log"
+	"errors"
+	"flag"
+	"fmt"
+	"log/slog"
+	"net"
 	"net/http"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
 	"time"
 
+	"google.golang.org/grpc"
+
+	"chunker-service/pkg/arrowipc"
+	"chunker-service/pkg/audit"
 	"chunker-service/pkg/chunking"
+	"chunker-service/pkg/embedding"
+	"chunker-service/pkg/jobs"
+	"chunker-service/pkg/tokenizer"
 )
 
 type chunkRequest struct {
 	Text string                 `json:"text"`
 	Plan chunking.ChunkingPlan  `json:"plan"`
 	Meta map[string]interface{} `json:"meta"`
+	// Preset names a plan to start from instead of the default plan: a
+	// routing preset of the server's config or catalog, or a built-in
+	// chunking preset. Plan fields are applied over it.
+	Preset string `json:"preset,omitempty"`
+	// CreatedAt optionally pins the timestamp of every returned chunk,
+	// so all chunks of a batch share one ingestion time.
+	CreatedAt *time.Time `json:"created_at,omitempty"`
+}
+
+type estimateRequest struct {
+	Text      string                 `json:"text"`
+	Documents []string               `json:"documents"`
+	Plan      chunking.ChunkingPlan  `json:"plan"`
+	Model     string                 `json:"model"`
+	Pricing   embedding.PricingTable `json:"pricing"`
+	Limits    embedding.Limits       `json:"limits"`
+}
+
+type analyzeRequest struct {
+	Text             string   `json:"text"`
+	HeadingLanguages []string `json:"heading_languages,omitempty"`
+}
+
+type suggestRequest struct {
+	Text string `json:"text"`
+}
+
+// estimateResponse, analyzeResponse and suggestResponse add the schema
+// version to their embedded results.
+type estimateResponse struct {
+	SchemaVersion int `json:"schema_version"`
+	embedding.Estimate
+}
+
+type analyzeResponse struct {
+	SchemaVersion int `json:"schema_version"`
+	chunking.Analysis
 }
 
+type suggestResponse struct {
+	SchemaVersion int `json:"schema_version"`
+	chunking.Suggestion
+}
+
+// errorResponse is the body of every error. Code is stable; RequestID
+// correlates the response with the server's logs.
 type errorResponse struct {
-	Error string `json:"error"`
+	Error     string `json:"error"`
+	Code      string `json:"code"`
+	RequestID string `json:"request_id,omitempty"`
+}
+
+// clock stamps CreatedAt on chunks that have no caller-supplied timestamp.
+var clock chunking.Clock = chunking.SystemClock{}
+
+// outputLimits are the request-level guardrails applied to every chunking
+// request, configured via the limits section of serverConfig.
+var outputLimits chunking.OutputLimits
+
+// maxRequestBytes caps request bodies on both APIs; 0 disables the cap.
+var maxRequestBytes int64
+
+// limitWarnRatio is the fraction of a limit at which responses carry a
+// Warning header so clients notice before requests start failing.
+const limitWarnRatio = 0.8
+
+// metaSchema is the server-wide metadata schema, configured via
+// meta_schema and applied to every request on top of the plan's.
+var metaSchema chunking.MetaSchema
+
+// auditLog, configured via audit_log, records every successful
+// /chunk request for later replay.
+var auditLog *audit.Log
+
+// embedder backs semantic plans and is configured via the embedding
+// section; semantic plans are rejected when it is nil.
+var embedder chunking.Embedder
+
+func newChunker() *chunking.SlidingWindowChunker {
+	return &chunking.SlidingWindowChunker{Limits: outputLimits, MetaSchema: metaSchema}
+}
+
+// chunkerFor returns the chunker executing plan's strategy, stopping when
+// ctx is done and recording its decisions in trace when set.
+func chunkerFor(ctx context.Context, plan chunking.ChunkingPlan, trace *chunking.Trace) (chunking.Chunker, error) {
+	if plan.Strategy != chunking.StrategySemantic {
+		chunker := newChunker()
+		chunker.Trace = trace
+		chunker.Context = ctx
+		return chunker, nil
+	}
+	if embedder == nil {
+		return nil, errors.New("semantic chunking is not configured (set CHUNKER_EMBEDDING_URL)")
+	}
+	return &chunking.SemanticChunker{Embedder: embedder, Limits: outputLimits, MetaSchema: metaSchema, Trace: trace, Context: ctx}, nil
+}
+
+// limitWarning returns a Warning header value when the output is close to
+// the configured limits, or "" otherwise.
+func limitWarning(chunks []chunking.Chunk) string {
+	total := 0
+	for _, ch := range chunks {
+		total += len(ch.Text)
+	}
+	switch {
+	case outputLimits.MaxChunks > 0 && float64(len(chunks)) >= limitWarnRatio*float64(outputLimits.MaxChunks):
+		return fmt.Sprintf(`199 chunker "%d chunks is close to the limit of %d"`, len(chunks), outputLimits.MaxChunks)
+	case outputLimits.MaxBytes > 0 && float64(total) >= limitWarnRatio*float64(outputLimits.MaxBytes):
+		return fmt.Sprintf(`199 chunker "%d bytes of chunk text is close to the limit of %d"`, total, outputLimits.MaxBytes)
+	}
+	return ""
+}
+
+// enricherWarning returns a Warning header value naming the enrichers
+// that failed under a "warn" policy, or "" when none did.
+func enricherWarning(chunks []chunking.Chunk) string {
+	if len(chunks) == 0 {
+		return ""
+	}
+	warnings, _ := chunks[0].Extra["enricher_warnings"].(map[string]string)
+	if len(warnings) == 0 {
+		return ""
+	}
+	names := make([]string, 0, len(warnings))
+	for name := range warnings {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return fmt.Sprintf(`199 chunker "enrichers failed: %s"`, strings.Join(names, ", "))
+}
+
+// concatWarning returns a Warning header value, the number of documents
+// and the number of chunks crossing between them when the request's text
+// looked like several concatenated documents under the "warn" policy, or
+// "" when it did not.
+func concatWarning(chunks []chunking.Chunk) (string, int, int) {
+	if len(chunks) == 0 {
+		return "", 0, 0
+	}
+	docs, _ := chunks[0].Extra["concatenated_documents"].(int)
+	if docs == 0 {
+		return "", 0, 0
+	}
+	crossing := 0
+	for _, ch := range chunks {
+		if ch.Extra["crosses_documents"] == true {
+			crossing++
+		}
+	}
+	return fmt.Sprintf(`199 chunker "input looks like %d concatenated documents; %d chunks cross between them, send one document per request or set plan.concatenated to split"`, docs, crossing), docs, crossing
+}
+
+func envInt(name string, def int) int {
+	v := os.Getenv(name)
+	if v == "" {
+		return def
+	}
+	n, err := strconv.Atoi(v)
+	if err != nil {
+		fatal("invalid "+name, err)
+	}
+	return n
+}
+
+func seconds(n int) time.Duration {
+	return time.Duration(n) * time.Second
 }
 
 func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	if e, ok := v.(errorResponse); ok {
+		v = publicError(w, status, e)
+	}
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(status)
 	_ = json.NewEncoder(w).Encode(v)
 }
 
+// limitBody rejects request bodies larger than maxRequestBytes: up front
+// when Content-Length declares it, otherwise once reading passes the limit.
+func limitBody(h http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if maxRequestBytes > 0 {
+			if r.ContentLength > maxRequestBytes {
+				writeTooLarge(w)
+				return
+			}
+			r.Body = http.MaxBytesReader(w, r.Body, maxRequestBytes)
+		}
+		h.ServeHTTP(w, r)
+	})
+}
+
+func writeTooLarge(w http.ResponseWriter) {
+	writeJSON(w, http.StatusRequestEntityTooLarge, errorResponse{
+		Error: fmt.Sprintf("request body exceeds the limit of %d bytes", maxRequestBytes),
+		Code:  "request_too_large",
+	})
+}
+
+// decodeJSON decodes the request body into v as it streams in. On failure
+// it writes the error response and returns false.
+func decodeJSON(w http.ResponseWriter, r *http.Request, v interface{}) bool {
+	err := json.NewDecoder(r.Body).Decode(v)
+	if err == nil {
+		return true
+	}
+	var tooLarge *http.MaxBytesError
+	if errors.As(err, &tooLarge) {
+		writeTooLarge(w)
+		return false
+	}
+	writeJSON(w, http.StatusBadRequest, errorResponse{Error: "invalid JSON body"})
+	return false
+}
+
+// decodeChunkJSON decodes a body holding a chunkRequest into v, which is
+// req or embeds it. req.Plan starts from the request's named preset, or
+// else from the default plan, overlaid by the preset routed to the
+// request's meta unless the request's plan names a strategy itself.
+func decodeChunkJSON(w http.ResponseWriter, r *http.Request, v interface{}, req *chunkRequest) bool {
+	var body json.RawMessage
+	if !decodeJSON(w, r, &body) {
+		return false
+	}
+	var head struct {
+		Plan   map[string]json.RawMessage `json:"plan"`
+		Meta   map[string]interface{}     `json:"meta"`
+		Preset string                     `json:"preset"`
+	}
+	if err := json.Unmarshal(body, &head); err != nil {
+		writeJSON(w, http.StatusBadRequest, errorResponse{Error: "invalid JSON body"})
+		return false
+	}
+	req.Plan = newPlan()
+	if head.Preset != "" {
+		plan, err := presetPlan(head.Preset)
+		if err != nil {
+			writeJSON(w, http.StatusBadRequest, errorResponse{Error: err.Error()})
+			return false
+		}
+		req.Plan = plan
+	} else if _, ok := head.Plan["strategy"]; !ok {
+		// Presets were validated when loaded.
+		_, _ = routing.Load().Apply(&req.Plan, head.Meta)
+	}
+	if err := json.Unmarshal(body, v); err != nil {
+		writeJSON(w, http.StatusBadRequest, errorResponse{Error: "invalid JSON body"})
+		return false
+	}
+	return true
+}
+
+// presetPlan returns the plan a request's preset names. Routing presets
+// from the config or catalog are applied over the default plan and take
+// precedence, so operators can override built-in presets by name.
+func presetPlan(name string) (chunking.ChunkingPlan, error) {
+	plan := newPlan()
+	r := routing.Load()
+	if _, ok := r.Presets[name]; ok {
+		err := r.ApplyPreset(&plan, name)
+		return plan, err
+	}
+	if plan, ok := chunking.Presets.Lookup(name); ok {
+		return plan, nil
+	}
+	names := chunking.Presets.Names()
+	for n := range r.Presets {
+		if _, ok := chunking.Presets.Lookup(n); !ok {
+			names = append(names, n)
+		}
+	}
+	sort.Strings(names)
+	return chunking.ChunkingPlan{}, fmt.Errorf("unknown preset %q (available: %s)", name, strings.Join(names, ", "))
+}
+
+// wantsArrow reports whether the client asked for an Arrow IPC stream.
+func wantsArrow(r *http.Request) bool {
+	return strings.Contains(r.Header.Get("Accept"), arrowipc.ContentType)
+}
+
+func writeArrow(w http.ResponseWriter, chunks []chunking.Chunk) {
+	w.Header().Set("Content-Type", arrowipc.ContentType)
+	w.WriteHeader(http.StatusOK)
+	if err := arrowipc.WriteChunks(w, chunks); err != nil {
+		slog.Error("arrow encode failed", "error", err)
+	}
+}
+
 func handleChunk(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodPost {
 		writeJSON(w, http.StatusMethodNotAllowed, errorResponse{Error: "use POST"})
 		return
 	}
 	var req chunkRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		writeJSON(w, http.StatusBadRequest, errorResponse{Error: "invalid JSON body"})
+	if !decodeChunkJSON(w, r, &req, &req) {
 		return
 	}
 	if req.Plan.WindowSize <= 0 {
 		writeJSON(w, http.StatusBadRequest, errorResponse{Error: "plan.window_size must be > 0"})
 		return
 	}
-	chunker := chunking.NewSlidingWindowChunker()
+	debug := wantsDebug(r)
+	if debug && !debugAuthorized(r) {
+		writeJSON(w, http.StatusForbidden, errorResponse{Error: "debug traces require an authorized X-API-Key"})
+		return
+	}
+	var trace *chunking.Trace
+	if debug {
+		trace = &chunking.Trace{}
+	}
+	chunks, err := runChunk(r.Context(), req, trace)
+	if err != nil {
+		status, resp := chunkError(r.Context(), err)
+		writeJSON(w, status, resp)
+		return
+	}
+	logChunks(r.Context(), len(chunks))
+	if warning := limitWarning(chunks); warning != "" {
+		w.Header().Set("Warning", warning)
+	}
+	if warning := enricherWarning(chunks); warning != "" {
+		w.Header().Add("Warning", warning)
+	}
+	if warning, docs, crossing := concatWarning(chunks); warning != "" {
+		slog.Warn("input looks concatenated", "request_id", requestID(r.Context()), "documents", docs, "crossing_chunks", crossing)
+		w.Header().Add("Warning", warning)
+	}
+	if debug {
+		writeJSON(w, http.StatusOK, debugChunkResponse{SchemaVersion: chunking.SchemaVersion, Chunks: chunks, Trace: trace})
+		return
+	}
+	if wantsArrow(r) {
+		writeArrow(w, chunks)
+		return
+	}
+	writeJSON(w, http.StatusOK, chunks)
+}
+
+// runChunk chunks req and stamps, audits and shadows the result, as
+// every successful /chunk request and job does.
+func runChunk(ctx context.Context, req chunkRequest, trace *chunking.Trace) ([]chunking.Chunk, error) {
+	chunker, err := chunkerFor(ctx, req.Plan, trace)
+	if err != nil {
+		return nil, err
+	}
 	chunks, err := chunker.Chunk(req.Text, req.Plan, req.Meta)
+	if err != nil {
+		return nil, err
+	}
+	stampClock := clock
+	if req.CreatedAt != nil {
+		stampClock = chunking.FixedClock(req.CreatedAt.UTC())
+	}
+	chunking.Stamp(chunks, stampClock)
+	if auditLog != nil {
+		rec := audit.Record{Time: clock.Now(), Text: req.Text, Plan: req.Plan, Meta: req.Meta, Chunks: chunking.Digest(chunks)}
+		if err := auditLog.Append(rec); err != nil {
+			slog.Error("audit log write failed", "request_id", requestID(ctx), "error", err)
+		}
+	}
+	maybeShadow(req, chunks)
+	return chunks, nil
+}
+
+// chunkError maps a runChunk error to its HTTP status and body. Errors
+// from the embedding service are logged rather than returned, as they
+// name its URL.
+func chunkError(ctx context.Context, err error) (int, errorResponse) {
+	switch {
+	case errors.Is(err, chunking.ErrOutputTooLarge):
+		return http.StatusRequestEntityTooLarge, errorResponse{Error: err.Error(), Code: "output_too_large"}
+	case errors.Is(err, chunking.ErrBinaryContent):
+		return http.StatusUnprocessableEntity, errorResponse{Error: err.Error(), Code: "binary_content"}
+	case errors.Is(err, chunking.ErrInvalidMeta):
+		return http.StatusBadRequest, errorResponse{Error: err.Error(), Code: "invalid_meta"}
+	case errors.Is(err, chunking.ErrEmbeddingFailed):
+		return http.StatusBadGateway, errorResponse{Error: withheld(ctx, "embedding service failed", err), Code: "embedding_failed"}
+	case errors.Is(err, chunking.ErrEnricherFailed):
+		return http.StatusBadGateway, errorResponse{Error: withheld(ctx, "enricher failed", err), Code: "enricher_failed"}
+	case errors.Is(err, context.Canceled):
+		return http.StatusServiceUnavailable, errorResponse{Error: "request cancelled", Code: "cancelled"}
+	case errors.Is(err, errInternal):
+		return http.StatusInternalServerError, errorResponse{Error: errInternal.Error(), Code: "internal"}
+	}
+	return http.StatusBadRequest, errorResponse{Error: err.Error()}
+}
+
+func handleEstimate(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeJSON(w, http.StatusMethodNotAllowed, errorResponse{Error: "use POST"})
+		return
+	}
+	req := estimateRequest{Plan: newPlan()}
+	if !decodeJSON(w, r, &req) {
+		return
+	}
+	if req.Plan.WindowSize <= 0 {
+		writeJSON(w, http.StatusBadRequest, errorResponse{Error: "plan.window_size must be > 0"})
+		return
+	}
+	docs := req.Documents
+	if req.Text != "" {
+		docs = append([]string{req.Text}, docs...)
+	}
+
+	// Count with the plan's tokenizer so estimates agree with the
+	// model-token windows used for chunking.
+	tok, err := chunking.PlanTokenizer(req.Plan)
 	if err != nil {
 		writeJSON(w, http.StatusBadRequest, errorResponse{Error: err.Error()})
 		return
 	}
-	now := time.Now().UTC()
-	for i := range chunks {
-		if chunks[i].CreatedAt.IsZero() {
-			chunks[i].CreatedAt = now
+
+	// Estimates carry no metadata, so skip metadata validation.
+	chunker := newChunker()
+	chunker.MetaSchema = nil
+	chunker.Context = r.Context()
+	req.Plan.MetaSchema = nil
+	var counts []int
+	for _, text := range docs {
+		chunks, err := chunker.Chunk(text, req.Plan, nil)
+		if errors.Is(err, chunking.ErrOutputTooLarge) {
+			writeJSON(w, http.StatusRequestEntityTooLarge, errorResponse{Error: err.Error(), Code: "output_too_large"})
+			return
+		}
+		if errors.Is(err, context.Canceled) {
+			writeJSON(w, http.StatusServiceUnavailable, errorResponse{Error: "request cancelled", Code: "cancelled"})
+			return
+		}
+		if err != nil {
+			writeJSON(w, http.StatusBadRequest, errorResponse{Error: err.Error()})
+			return
+		}
+		for _, ch := range chunks {
+			counts = append(counts, len(tok.Tokenize(ch.Text)))
 		}
 	}
-	writeJSON(w, http.StatusOK, chunks)
+
+	logChunks(r.Context(), len(counts))
+	est, err := embedding.EstimateCost(counts, req.Model, req.Pricing, req.Limits)
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, errorResponse{Error: err.Error()})
+		return
+	}
+	writeJSON(w, http.StatusOK, estimateResponse{SchemaVersion: chunking.SchemaVersion, Estimate: est})
+}
+
+func handleAnalyze(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeJSON(w, http.StatusMethodNotAllowed, errorResponse{Error: "use POST"})
+		return
+	}
+	var req analyzeRequest
+	if !decodeJSON(w, r, &req) {
+		return
+	}
+	analysis, err := chunking.AnalyzeLanguages(req.Text, req.HeadingLanguages)
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, errorResponse{Error: err.Error()})
+		return
+	}
+	writeJSON(w, http.StatusOK, analyzeResponse{SchemaVersion: chunking.SchemaVersion, Analysis: analysis})
+}
+
+func handleSuggestPlan(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeJSON(w, http.StatusMethodNotAllowed, errorResponse{Error: "use POST"})
+		return
+	}
+	var req suggestRequest
+	if !decodeJSON(w, r, &req) {
+		return
+	}
+	writeJSON(w, http.StatusOK, suggestResponse{SchemaVersion: chunking.SchemaVersion, Suggestion: chunking.SuggestPlan(req.Text)})
 }
 
-func handleHealth(w http.ResponseWriter, r *http.Request) {
-	writeJSON(w, http.StatusOK, map[string]string{"status": "ok"})
+// loadEmbedding sets embedder to a client of the configured embedding
+// service, if any.
+func loadEmbedding(cfg embeddingConfig) {
+	if cfg.URL == "" {
+		return
+	}
+	client := &embedding.Client{
+		URL:       cfg.URL,
+		Model:     cfg.Model,
+		Token:     cfg.Token,
+		BatchSize: cfg.BatchSize,
+		Limits:    embedding.Limits{MaxTokensPerRequest: cfg.MaxTokens, MaxTokensPerItem: cfg.MaxItemTokens},
+		HTTPClient: &http.Client{
+			Timeout:   seconds(cfg.TimeoutSeconds),
+			Transport: requestIDTransport{},
+		},
+	}
+	if rate := cfg.rateLimit(); rate != (embedding.RateLimit{}) {
+		client.Limiter = embedding.NewLimiter(rate)
+	}
+	if cfg.CacheURL != "" {
+		// Validated by loadConfig.
+		store, _ := jobs.NewRedis(cfg.CacheURL, seconds(cfg.CacheTTLSeconds))
+		store.Prefix = "chunker:embed:"
+		cache := embedding.NewRedisCache(store)
+		cache.OnError = func(err error) { slog.Warn("embedding cache failed", "error", err) }
+		client.Cache = cache
+	} else if cfg.CacheSize > 0 {
+		client.Cache = embedding.NewMemoryCache(cfg.CacheSize)
+	}
+	embedder = client
 }
 
 func main() {
+	cfg, err := loadConfig(os.Args[1:])
+	if errors.Is(err, flag.ErrHelp) {
+		return
+	}
+	if err != nil {
+		fatal("invalid configuration", err)
+	}
+	setupLogging(cfg.Log)
+	outputLimits = chunking.OutputLimits{MaxChunks: cfg.Limits.MaxTotalChunks, MaxBytes: cfg.Limits.MaxOutputBytes}
+	maxRequestBytes = cfg.Limits.MaxRequestBytes
+	defaultPlanJSON = cfg.DefaultPlan
+	routing.Store(&cfg.Routing)
+	loadAuth(cfg.Auth)
+	loadJobs(cfg.Jobs)
+	loadDebugKeys()
+	loadShadow()
+	if len(cfg.MetaSchema) > 0 {
+		// Validated by loadConfig.
+		metaSchema, _ = parseMetaSchema(cfg.MetaSchema)
+	}
+	if dir := cfg.TokenizerDir; dir != "" {
+		names, err := tokenizer.LoadDir(dir)
+		if err != nil {
+			fatal("failed to load tokenizers", err)
+		}
+		slog.Info("loaded tokenizers", "names", names)
+		tokenizerDir, loadedTokenizers = dir, names
+	}
+	if cfg.Catalog.Dir != "" {
+		loadCatalog(cfg.Catalog, cfg.Routing)
+	}
+	loadEmbedding(cfg.Embedding)
+	loadIndex(cfg.Index)
+	if cfg.AuditLog != "" {
+		var err error
+		if auditLog, err = audit.OpenLog(cfg.AuditLog); err != nil {
+			fatal("failed to open audit log", err)
+		}
+	}
+
+	// Already validated by loadConfig.
+	tlsCfg, err := cfg.TLS.serverTLS()
+	if err != nil {
+		fatal("invalid tls configuration", err)
+	}
+	var grpcSrv *grpc.Server
+	if cfg.GRPCAddr != "" {
+		grpcSrv = startGRPC(cfg.GRPCAddr, tlsCfg)
+	}
+	if cfg.AdminAddr != "" {
+		startAdmin(cfg.AdminAddr)
+	}
+
 	mux := http.NewServeMux()
-	mux.HandleFunc("/chunk", handleChunk)
-	mux.HandleFunc("/healthz", handleHealth)
+	handleAPI(mux, "/chunk", requireScope(scopeWrite, handleChunk))
+	handleAPI(mux, "/estimate", requireScope(scopeRead, handleEstimate))
+	handleAPI(mux, "/analyze", requireScope(scopeRead, handleAnalyze))
+	handleAPI(mux, "/plan/suggest", requireScope(scopeRead, handleSuggestPlan))
+	handleAPI(mux, "/pack", requireScope(scopeRead, handlePack))
+	handleAPI(mux, "/index", requireScope(scopeWrite, handleIndex))
+	handleAPI(mux, "/index/reembed", requireScope(scopeWrite, handleReembed))
+	handleAPI(mux, "/search", requireScope(scopeRead, handleSearch))
+	handleAPI(mux, "/documents/", requireScope(scopeWrite, handleDocument))
+	handleAPI(mux, "/jobs", requireScope(scopeWrite, handleJobs))
+	handleAPI(mux, "/jobs/", requireScope(scopeRead, handleJob))
+	handleAPI(mux, "/shadow", requireScope(scopeRead, handleShadow))
+	handleAPI(mux, "/selftest", requireScope(scopeWrite, handleSelftest))
+	mux.HandleFunc("/livez", handleLive)
+	mux.HandleFunc("/readyz", handleReady)
+	// /healthz predates the split and stays a liveness probe.
+	mux.HandleFunc("/healthz", handleLive)
+	mux.HandleFunc("/openapi.json", handleOpenAPI)
 
-	addr := ":8080"
-	log.Printf("chunker service listening on %s", addr)
-	if err := http.ListenAndServe(addr, mux); err != nil {
-		log.Fatalf("server error: %v", err)
+	srv := &http.Server{
+		Addr:              cfg.Addr,
+		Handler:           logRequests(recoverPanics(limitBody(mux))),
+		ReadHeaderTimeout: 10 * time.Second,
+		ReadTimeout:       seconds(cfg.Timeouts.ReadSeconds),
+		WriteTimeout:      seconds(cfg.Timeouts.WriteSeconds),
+		IdleTimeout:       seconds(cfg.Timeouts.IdleSeconds),
+		BaseContext:       func(net.Listener) context.Context { return serverCtx },
+		TLSConfig:         tlsCfg,
 	}
+	slog.Info("chunker service listening", "addr", srv.Addr, "tls", tlsCfg != nil, "mtls", tlsCfg != nil && tlsCfg.ClientCAs != nil)
+	serve(srv, grpcSrv, seconds(cfg.Timeouts.ShutdownGraceSeconds))
 }
@@ -0,0 +1,14 @@
+package main
+
+import (
+	"os"
+	"testing"
+
+	"chunker-service/pkg/chunking"
+)
+
+// TestMain sets up what main does before serving, as handlers expect.
+func TestMain(m *testing.M) {
+	routing.Store(&chunking.Routing{})
+	os.Exit(m.Run())
+}
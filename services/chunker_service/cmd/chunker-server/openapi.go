@@ -0,0 +1,65 @@
+package main
+
+import (
+	"net/http"
+	"sync"
+
+	"chunker-service/pkg/arrowipc"
+	"chunker-service/pkg/chunking"
+	"chunker-service/pkg/openapi"
+)
+
+// operations lists every HTTP endpoint with its request and response
+// types. Add new endpoints here so /openapi.json describes them. The
+// deprecated unversioned aliases are left out.
+var operations = []openapi.Operation{
+	{Method: http.MethodPost, Path: apiPrefix + "/chunk", Summary: "Chunk text according to a plan",
+		Request: chunkRequest{}, Response: []chunking.Chunk{}, Error: errorResponse{},
+		ResponseTypes: []string{arrowipc.ContentType}},
+	{Method: http.MethodPost, Path: apiPrefix + "/estimate", Summary: "Project embedding tokens, requests and cost",
+		Request: estimateRequest{}, Response: estimateResponse{}, Error: errorResponse{}},
+	{Method: http.MethodPost, Path: apiPrefix + "/analyze", Summary: "Document-level analysis without chunking",
+		Request: analyzeRequest{}, Response: analyzeResponse{}, Error: errorResponse{}},
+	{Method: http.MethodPost, Path: apiPrefix + "/plan/suggest", Summary: "Recommend a chunking plan from the document's structure",
+		Request: suggestRequest{}, Response: suggestResponse{}, Error: errorResponse{}},
+	{Method: http.MethodPost, Path: apiPrefix + "/pack", Summary: "Pack ranked chunks into a prompt context within a token budget",
+		Request: packRequest{}, Response: packResponse{}, Error: errorResponse{}},
+	{Method: http.MethodPost, Path: apiPrefix + "/index", Summary: "Chunk text and store the embedded chunks in the built-in vector index",
+		Request: chunkRequest{}, Response: indexResponse{}, Error: errorResponse{}},
+	{Method: http.MethodPost, Path: apiPrefix + "/index/reembed", Summary: "Re-embed the built-in vector index with another model and swap it in",
+		Request: reembedRequest{}, Response: reembedStatus{}, Error: errorResponse{}, Status: http.StatusAccepted},
+	{Method: http.MethodGet, Path: apiPrefix + "/index/reembed", Summary: "Status of the running or last reembed",
+		Response: reembedStatus{}, Error: errorResponse{}},
+	{Method: http.MethodPost, Path: apiPrefix + "/search", Summary: "Return the indexed chunks nearest to a query",
+		Request: searchRequest{}, Response: searchResponse{}, Error: errorResponse{}},
+	{Method: http.MethodDelete, Path: apiPrefix + "/documents/{id}", Summary: "Delete every indexed chunk of a document",
+		Response: deleteDocumentResponse{}, Error: errorResponse{}},
+	{Method: http.MethodPost, Path: apiPrefix + "/jobs", Summary: "Submit a /chunk request to run in the background",
+		Request: jobRequest{}, Response: jobResponse{}, Error: errorResponse{}, Status: http.StatusAccepted},
+	{Method: http.MethodGet, Path: apiPrefix + "/jobs/{id}", Summary: "Job status; result holds the chunks once it has succeeded",
+		Response: jobResponse{}, Error: errorResponse{}},
+	{Method: http.MethodGet, Path: apiPrefix + "/shadow", Summary: "Shadow mode comparison totals",
+		Response: shadowStats{}, Error: errorResponse{}},
+	{Method: http.MethodPost, Path: apiPrefix + "/selftest", Summary: "Run a synthetic document through every mode, strategy, enricher, tokenizer and preset; 503 when any fails",
+		Response: readyResponse{}, Error: errorResponse{}},
+	{Method: http.MethodGet, Path: "/livez", Summary: "Liveness: the process is serving",
+		Response: map[string]string{}},
+	{Method: http.MethodGet, Path: "/readyz", Summary: "Readiness: per-dependency checks; 503 when any fails",
+		Response: readyResponse{}},
+	{Method: http.MethodGet, Path: "/healthz", Summary: "Liveness (use /livez)",
+		Response: map[string]string{}},
+	{Method: http.MethodGet, Path: "/openapi.json", Summary: "This OpenAPI document",
+		Response: map[string]interface{}{}},
+}
+
+var (
+	specOnce sync.Once
+	spec     map[string]interface{}
+)
+
+func handleOpenAPI(w http.ResponseWriter, r *http.Request) {
+	specOnce.Do(func() {
+		spec = openapi.Generate("Chunker Service", "1.0.0", operations)
+	})
+	writeJSON(w, http.StatusOK, spec)
+}
@@ -0,0 +1,45 @@
+package main
+
+import (
+	"net/http"
+
+	"chunker-service/pkg/chunking"
+	"chunker-service/pkg/packer"
+	"chunker-service/pkg/tokenizer"
+)
+
+// packRequest carries retrieved chunks, best first, to be assembled into
+// a prompt context.
+type packResponse struct {
+	SchemaVersion int `json:"schema_version"`
+	packer.Result
+}
+
+type packRequest struct {
+	Chunks []chunking.Chunk `json:"chunks"`
+	Budget packer.Budget    `json:"budget"`
+	// Tokenizer counts the budget; it should match the generating model.
+	Tokenizer string `json:"tokenizer,omitempty"`
+}
+
+func handlePack(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeJSON(w, http.StatusMethodNotAllowed, errorResponse{Error: "use POST"})
+		return
+	}
+	var req packRequest
+	if !decodeJSON(w, r, &req) {
+		return
+	}
+	tok, err := tokenizer.Get(req.Tokenizer)
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, errorResponse{Error: err.Error()})
+		return
+	}
+	res, err := packer.Pack(req.Chunks, req.Budget, tok)
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, errorResponse{Error: err.Error()})
+		return
+	}
+	writeJSON(w, http.StatusOK, packResponse{SchemaVersion: chunking.SchemaVersion, Result: res})
+}
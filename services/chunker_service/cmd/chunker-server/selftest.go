@@ -0,0 +1,188 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"chunker-service/pkg/chunking"
+	"chunker-service/pkg/tokenizer"
+)
+
+// selftestTimeout bounds each self-test component; semantic chunking and
+// dependency checks make network calls.
+const selftestTimeout = 10 * time.Second
+
+// selftestDoc exercises every enricher: headings, a date header, an
+// acronym, a link, an image, a captioned table and a list.
+const selftestDoc = `# Release Notes
+
+## 2024-03-05
+
+Retrieval Augmented Generation (RAG) answers questions from a corpus.
+RAG needs well-sized chunks, as the [design notes](https://example.com/design) explain.
+
+![Pipeline diagram](pipeline.png "Ingestion")
+
+Table 1: Pipeline stages and owners.
+
+| Stage | Owner |
+|-------|-------|
+| Chunk | Go |
+| Embed | Python |
+
+The release adds:
+- directory ingestion
+- hot-reloaded presets
+`
+
+// selftestEnrichers enable one enricher each on a lines plan and check
+// that it left its mark on some chunk.
+var selftestEnrichers = map[string]struct {
+	enable func(*chunking.ChunkingPlan)
+	found  func(chunking.Chunk) bool
+}{
+	"headings": {
+		func(p *chunking.ChunkingPlan) { p.BreakOnHeadings, p.IncludeHeadings = true, true },
+		func(c chunking.Chunk) bool { return c.Extra["heading"] != nil },
+	},
+	"dates": {
+		func(p *chunking.ChunkingPlan) { p.BreakOnDates = true },
+		func(c chunking.Chunk) bool { return c.EffectiveAt != nil },
+	},
+	"captions": {
+		func(p *chunking.ChunkingPlan) { p.AttachCaptions = true },
+		func(c chunking.Chunk) bool { return c.Extra["captions"] != nil },
+	},
+	"images": {
+		func(p *chunking.ChunkingPlan) { p.Images = "keep" },
+		func(c chunking.Chunk) bool { return c.Extra["images"] != nil },
+	},
+	"links": {
+		func(p *chunking.ChunkingPlan) { p.Links = "footnote" },
+		func(c chunking.Chunk) bool { return c.Extra["links"] != nil },
+	},
+	"tables": {
+		func(p *chunking.ChunkingPlan) { p.ExtractTables = true },
+		func(c chunking.Chunk) bool { return c.Extra["table"] != nil },
+	},
+	"acronyms": {
+		func(p *chunking.ChunkingPlan) { p.ExpandAcronyms = true },
+		func(c chunking.Chunk) bool { return c.Extra["acronyms"] != nil },
+	},
+	"readability": {
+		func(p *chunking.ChunkingPlan) { p.Readability = true },
+		func(c chunking.Chunk) bool { return c.Extra["readability"] != nil },
+	},
+	"chunk_titles": {
+		func(p *chunking.ChunkingPlan) { p.ChunkTitles = true },
+		func(c chunking.Chunk) bool { return c.ChunkTitle != "" },
+	},
+	"children": {
+		func(p *chunking.ChunkingPlan) {
+			p.Children = &chunking.ChunkingPlan{WindowSize: 3, Mode: chunking.ModeLines}
+		},
+		func(c chunking.Chunk) bool { return c.ParentID != "" },
+	},
+}
+
+// handleSelftest runs selftestDoc through every chunking mode and
+// strategy, every enricher, every registered tokenizer and every routing
+// preset, and runs the readiness checks, so one call shows whether a
+// deployment works end to end. Like /readyz it answers 503 when any
+// component fails; unlike /readyz it reports why.
+func handleSelftest(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeJSON(w, http.StatusMethodNotAllowed, errorResponse{Error: "use POST"})
+		return
+	}
+	resp := runChecks(r.Context(), selftestChecks(), selftestTimeout, true)
+	status := http.StatusOK
+	if resp.Status == checkFailed {
+		status = http.StatusServiceUnavailable
+	}
+	writeJSON(w, status, resp)
+}
+
+// selftestChecks returns the self-test's components by name, prefixed by
+// kind: "mode/", "strategy/", "enricher/", "tokenizer/", "preset/" and
+// "dependency/".
+func selftestChecks() map[string]readyCheck {
+	checks := map[string]readyCheck{}
+	modes := []chunking.Mode{chunking.ModeCharacters, chunking.ModeTokens, chunking.ModeLines, chunking.ModeGraphemes, chunking.ModeBytes}
+	for _, mode := range modes {
+		plan := chunking.ChunkingPlan{WindowSize: 20, Overlap: 5, Mode: mode}
+		if mode == chunking.ModeLines {
+			plan.WindowSize, plan.Overlap = 4, 1
+		}
+		checks["mode/"+string(mode)] = selftestPlan(plan, nil)
+	}
+	checks["strategy/"+string(chunking.StrategySemantic)] = func(ctx context.Context) error {
+		if embedder == nil {
+			return errSkipped
+		}
+		return selftestPlan(chunking.ChunkingPlan{Strategy: chunking.StrategySemantic, WindowSize: 5}, nil)(ctx)
+	}
+	for name, e := range selftestEnrichers {
+		plan := chunking.ChunkingPlan{WindowSize: 40, Mode: chunking.ModeLines}
+		e.enable(&plan)
+		checks["enricher/"+name] = selftestPlan(plan, e.found)
+	}
+	for _, name := range tokenizer.Names() {
+		checks["tokenizer/"+name] = selftestPlan(chunking.ChunkingPlan{WindowSize: 20, Overlap: 5, Mode: chunking.ModeTokens, Tokenizer: name}, nil)
+	}
+	for name, fields := range routing.Load().Presets {
+		fields := fields
+		checks["preset/"+name] = func(ctx context.Context) error {
+			plan := newPlan()
+			if err := json.Unmarshal(fields, &plan); err != nil {
+				return err
+			}
+			// Presets may leave the window to the client.
+			if plan.WindowSize == 0 {
+				plan.WindowSize = 20
+			}
+			return selftestPlan(plan, nil)(ctx)
+		}
+	}
+	for name, check := range readyChecks {
+		checks["dependency/"+name] = check
+	}
+	return checks
+}
+
+// selftestPlan returns a check chunking selftestDoc with plan. It fails
+// on an error, on no chunks, on a chunk whose byte range does not hold
+// its text and, when found is set, when no chunk satisfies found.
+func selftestPlan(plan chunking.ChunkingPlan, found func(chunking.Chunk) bool) readyCheck {
+	return func(ctx context.Context) error {
+		chunker, err := chunkerFor(ctx, plan, nil)
+		if err != nil {
+			return err
+		}
+		chunks, err := chunker.Chunk(selftestDoc, plan, map[string]interface{}{"file_name": "selftest.md"})
+		if err != nil {
+			return err
+		}
+		if len(chunks) == 0 {
+			return errors.New("no chunks")
+		}
+		ok := found == nil
+		for i, c := range chunks {
+			if c.Text == "" {
+				return fmt.Errorf("chunk %d is empty", i)
+			}
+			if c.ByteStart < 0 || c.ByteEnd > len(selftestDoc) || c.ByteStart > c.ByteEnd {
+				return fmt.Errorf("chunk %d has byte range %d-%d outside the document", i, c.ByteStart, c.ByteEnd)
+			}
+			ok = ok || found(c)
+		}
+		if !ok {
+			return errors.New("no chunk shows the enricher's output")
+		}
+		return nil
+	}
+}
@@ -0,0 +1,70 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"chunker-service/pkg/chunking"
+)
+
+func runSelftest(t *testing.T) (int, readyResponse) {
+	t.Helper()
+	w := httptest.NewRecorder()
+	handleSelftest(w, httptest.NewRequest(http.MethodPost, apiPrefix+"/selftest", nil))
+	var resp readyResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("invalid response %s: %v", w.Body, err)
+	}
+	return w.Code, resp
+}
+
+func TestSelftestPasses(t *testing.T) {
+	oldEmbedder := embedder
+	t.Cleanup(func() { embedder = oldEmbedder })
+	embedder = nil
+
+	status, resp := runSelftest(t)
+	if status != http.StatusOK || resp.Status != checkOK {
+		t.Fatalf("expected the self-test to pass, got %d %+v", status, resp)
+	}
+	for _, name := range []string{"mode/" + string(chunking.ModeCharacters), "mode/lines", "mode/bytes", "enricher/headings", "enricher/dates", "enricher/tables", "enricher/children"} {
+		if got := resp.Checks[name].Status; got != checkOK {
+			t.Errorf("%s: got %q, want ok", name, got)
+		}
+	}
+	// Components the server is not configured for are skipped, not failed.
+	for _, name := range []string{"strategy/semantic", "dependency/embedding", "dependency/job_store"} {
+		if got := resp.Checks[name].Status; got != checkSkipped {
+			t.Errorf("%s: got %q, want skipped", name, got)
+		}
+	}
+}
+
+func TestSelftestFails(t *testing.T) {
+	old := routing.Load()
+	t.Cleanup(func() { routing.Store(old) })
+	routing.Store(&chunking.Routing{Presets: map[string]json.RawMessage{
+		"good":   json.RawMessage(`{"mode": "lines", "window_size": 10}`),
+		"broken": json.RawMessage(`{"window_size": 5, "overlap": 10}`),
+	}})
+	captureLogs(t, logConfig{Format: logFormatText, Level: "info"})
+
+	status, resp := runSelftest(t)
+	if status != http.StatusServiceUnavailable || resp.Status != checkFailed {
+		t.Fatalf("expected the self-test to fail, got %d %+v", status, resp)
+	}
+	broken := resp.Checks["preset/broken"]
+	// Unlike /readyz, the self-test says why.
+	if broken.Status != checkFailed || !strings.Contains(broken.Error, "overlap") {
+		t.Errorf("preset/broken: got %+v, want a failure naming the overlap", broken)
+	}
+	if got := resp.Checks["preset/good"].Status; got != checkOK {
+		t.Errorf("preset/good: got %q, want ok", got)
+	}
+	if got := resp.Checks["mode/tokens"].Status; got != checkOK {
+		t.Errorf("one failing component should not fail the rest, mode/tokens is %q", got)
+	}
+}
@@ -0,0 +1,77 @@
+package main
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"google.golang.org/grpc"
+)
+
+// serverCtx is the parent of every request context. It is cancelled when
+// the shutdown grace period runs out so chunking still in flight stops
+// instead of holding the process open.
+var serverCtx, cancelServer = context.WithCancel(context.Background())
+
+// serve runs srv, over TLS when srv.TLSConfig is set, until SIGTERM or
+// SIGINT. It then stops accepting connections and waits up to grace for
+// in-flight HTTP and gRPC requests to finish before cancelling whatever is
+// left, background shadow runs included.
+func serve(srv *http.Server, grpcSrv *grpc.Server, grace time.Duration) {
+	errc := make(chan error, 1)
+	go func() {
+		if srv.TLSConfig != nil {
+			errc <- srv.ListenAndServeTLS("", "")
+			return
+		}
+		errc <- srv.ListenAndServe()
+	}()
+
+	sigc := make(chan os.Signal, 1)
+	signal.Notify(sigc, syscall.SIGTERM, os.Interrupt)
+	select {
+	case err := <-errc:
+		fatal("server failed", err)
+	case sig := <-sigc:
+		slog.Info("draining requests", "signal", sig.String(), "grace", grace.String())
+	}
+	// A second signal falls back to the default and kills the process.
+	signal.Stop(sigc)
+
+	ctx, cancel := context.WithTimeout(context.Background(), grace)
+	defer cancel()
+	grpcDone := make(chan struct{})
+	go func() {
+		defer close(grpcDone)
+		if grpcSrv != nil {
+			stopGRPC(ctx, grpcSrv)
+		}
+	}()
+	if err := srv.Shutdown(ctx); err != nil {
+		slog.Warn("grace period expired, cancelling in-flight requests", "error", err)
+		cancelServer()
+		srv.Close()
+	}
+	<-grpcDone
+	cancelServer()
+	slog.Info("chunker service stopped")
+}
+
+// stopGRPC drains grpcSrv, closing its remaining streams once ctx is done.
+func stopGRPC(ctx context.Context, grpcSrv *grpc.Server) {
+	done := make(chan struct{})
+	go func() {
+		grpcSrv.GracefulStop()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-ctx.Done():
+		grpcSrv.Stop()
+		<-done
+	}
+}
@@ -0,0 +1,169 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"math/rand"
+	"net/http"
+	"os"
+	"strconv"
+	"sync"
+
+	"chunker-service/pkg/chunking"
+)
+
+// shadowOverrides, configured as a JSON object in CHUNKER_SHADOW_PLAN,
+// turns the server into a canary: a sample of /chunk requests is chunked
+// again with the request plan overlaid by these fields (for example
+// {"strategy": "semantic"} or {"window_size": 300}). Only the primary
+// result is returned; the difference is logged and aggregated at /shadow.
+var shadowOverrides map[string]json.RawMessage
+
+// shadowSample is the fraction of requests shadowed, configured via
+// CHUNKER_SHADOW_SAMPLE.
+var shadowSample = 1.0
+
+// shadowSlots bounds concurrent shadow runs; requests arriving while all
+// slots are busy are not shadowed, so a slow candidate cannot pile up work.
+var shadowSlots = make(chan struct{}, 4)
+
+// shadowStats aggregates comparisons between primary and candidate.
+type shadowStats struct {
+	mu              sync.Mutex
+	SchemaVersion   int     `json:"schema_version"`
+	Compared        int     `json:"compared"`
+	Identical       int     `json:"identical"`
+	Failed          int     `json:"failed"`
+	Dropped         int     `json:"dropped"`
+	PrimaryChunks   int     `json:"primary_chunks"`
+	CandidateChunks int     `json:"candidate_chunks"`
+	ChangedChunks   int     `json:"changed_chunks"`
+	MovedBoundaries int     `json:"moved_boundaries"`
+	MeanShift       float64 `json:"mean_shift"`
+	MaxShift        int     `json:"max_shift"`
+	shiftTotal      float64
+}
+
+var shadow = shadowStats{SchemaVersion: chunking.SchemaVersion}
+
+func loadShadow() {
+	v := os.Getenv("CHUNKER_SHADOW_PLAN")
+	if v == "" {
+		return
+	}
+	if err := json.Unmarshal([]byte(v), &shadowOverrides); err != nil {
+		fatal("invalid CHUNKER_SHADOW_PLAN", err)
+	}
+	if s := os.Getenv("CHUNKER_SHADOW_SAMPLE"); s != "" {
+		f, err := strconv.ParseFloat(s, 64)
+		if err != nil || f < 0 || f > 1 {
+			fatal("invalid CHUNKER_SHADOW_SAMPLE", errors.New("must be between 0 and 1"))
+		}
+		shadowSample = f
+	}
+	slog.Info("shadowing /chunk requests", "sample", shadowSample, "plan_overrides", v)
+}
+
+// candidatePlan overlays the shadow overrides on the request plan.
+func candidatePlan(plan chunking.ChunkingPlan) (chunking.ChunkingPlan, error) {
+	data, err := json.Marshal(plan)
+	if err != nil {
+		return plan, err
+	}
+	fields := map[string]json.RawMessage{}
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return plan, err
+	}
+	for k, v := range shadowOverrides {
+		fields[k] = v
+	}
+	if data, err = json.Marshal(fields); err != nil {
+		return plan, err
+	}
+	var out chunking.ChunkingPlan
+	if err := json.Unmarshal(data, &out); err != nil {
+		return plan, fmt.Errorf("shadow plan: %w", err)
+	}
+	return out, nil
+}
+
+// maybeShadow chunks req with the candidate plan in the background and
+// records how its chunks differ from primary.
+func maybeShadow(req chunkRequest, primary []chunking.Chunk) {
+	if shadowOverrides == nil || rand.Float64() >= shadowSample {
+		return
+	}
+	select {
+	case shadowSlots <- struct{}{}:
+	default:
+		shadow.mu.Lock()
+		shadow.Dropped++
+		shadow.mu.Unlock()
+		return
+	}
+	digests := chunking.Digest(primary)
+	go func() {
+		defer func() { <-shadowSlots }()
+		candidate, err := runShadow(req)
+		var cmp chunking.Comparison
+		if err == nil {
+			cmp = chunking.Compare(digests, candidate)
+		}
+		shadow.record(cmp, err)
+	}()
+}
+
+// runShadow returns the digests of the candidate chunks.
+func runShadow(req chunkRequest) ([]chunking.ChunkDigest, error) {
+	plan, err := candidatePlan(req.Plan)
+	if err != nil {
+		return nil, err
+	}
+	chunker, err := chunkerFor(serverCtx, plan, nil)
+	if err != nil {
+		return nil, err
+	}
+	chunks, err := chunker.Chunk(req.Text, plan, req.Meta)
+	if err != nil {
+		return nil, err
+	}
+	return chunking.Digest(chunks), nil
+}
+
+func (s *shadowStats) record(cmp chunking.Comparison, err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err != nil {
+		s.Failed++
+		slog.Warn("shadow chunking failed", "error", err)
+		return
+	}
+	s.Compared++
+	if cmp.Identical {
+		s.Identical++
+	}
+	s.PrimaryChunks += cmp.Before
+	s.CandidateChunks += cmp.After
+	s.ChangedChunks += cmp.Changed
+	s.MovedBoundaries += cmp.MovedBoundaries
+	s.shiftTotal += cmp.MeanShift * float64(cmp.MovedBoundaries)
+	if s.MovedBoundaries > 0 {
+		s.MeanShift = s.shiftTotal / float64(s.MovedBoundaries)
+	}
+	if cmp.MaxShift > s.MaxShift {
+		s.MaxShift = cmp.MaxShift
+	}
+	slog.Info("shadow comparison", "comparison", cmp)
+}
+
+func handleShadow(w http.ResponseWriter, r *http.Request) {
+	if shadowOverrides == nil {
+		writeJSON(w, http.StatusNotFound, errorResponse{Error: "shadow mode is not enabled"})
+		return
+	}
+	shadow.mu.Lock()
+	defer shadow.mu.Unlock()
+	writeJSON(w, http.StatusOK, &shadow)
+}
@@ -0,0 +1,120 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"chunker-service/pkg/chunking"
+)
+
+// withShadow enables shadow mode with overrides, a JSON object, until the
+// test ends, starting from empty stats.
+func withShadow(t *testing.T, overrides string, sample float64) {
+	t.Helper()
+	oldOverrides, oldSample := shadowOverrides, shadowSample
+	t.Cleanup(func() { shadowOverrides, shadowSample = oldOverrides, oldSample })
+	shadowOverrides = nil
+	if err := json.Unmarshal([]byte(overrides), &shadowOverrides); err != nil {
+		t.Fatal(err)
+	}
+	shadowSample = sample
+	shadow.mu.Lock()
+	defer shadow.mu.Unlock()
+	shadow.Compared, shadow.Identical, shadow.Failed, shadow.Dropped = 0, 0, 0, 0
+	shadow.PrimaryChunks, shadow.CandidateChunks, shadow.ChangedChunks = 0, 0, 0
+	shadow.MovedBoundaries, shadow.MeanShift, shadow.MaxShift, shadow.shiftTotal = 0, 0, 0, 0
+}
+
+// postChunk runs body through /chunk and returns the response.
+func postChunk(t *testing.T, body string) *httptest.ResponseRecorder {
+	t.Helper()
+	w := httptest.NewRecorder()
+	handleChunk(w, httptest.NewRequest(http.MethodPost, apiPrefix+"/chunk", strings.NewReader(body)))
+	return w
+}
+
+// shadowRuns waits for n shadow runs to finish and returns the stats.
+func shadowRuns(t *testing.T, n int) *shadowStats {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		shadow.mu.Lock()
+		s := &shadowStats{
+			Compared: shadow.Compared, Identical: shadow.Identical, Failed: shadow.Failed, Dropped: shadow.Dropped,
+			PrimaryChunks: shadow.PrimaryChunks, CandidateChunks: shadow.CandidateChunks,
+			ChangedChunks: shadow.ChangedChunks, MovedBoundaries: shadow.MovedBoundaries,
+		}
+		shadow.mu.Unlock()
+		if s.Compared+s.Failed+s.Dropped >= n {
+			return s
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("%d shadow runs never finished, have %+v", n, s)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+const shadowBody = `{"text": "one two three four five six seven eight nine ten eleven twelve", "plan": {"window_size": 6, "overlap": 0, "mode": "tokens"}}`
+
+func TestShadowRecordsDiff(t *testing.T) {
+	withShadow(t, `{"window_size": 4}`, 1)
+	if w := postChunk(t, shadowBody); w.Code != http.StatusOK {
+		t.Fatalf("primary failed: %d %s", w.Code, w.Body)
+	}
+	s := shadowRuns(t, 1)
+	if s.Compared != 1 || s.Identical != 0 || s.PrimaryChunks != 2 || s.CandidateChunks != 3 || s.ChangedChunks == 0 {
+		t.Errorf("unexpected comparison %+v", s)
+	}
+
+	// A candidate equal to the primary plan compares identical.
+	withShadow(t, `{"window_size": 6}`, 1)
+	postChunk(t, shadowBody)
+	if s := shadowRuns(t, 1); s.Compared != 1 || s.Identical != 1 || s.ChangedChunks != 0 || s.MovedBoundaries != 0 {
+		t.Errorf("expected an identical comparison, got %+v", s)
+	}
+}
+
+func TestShadowSampling(t *testing.T) {
+	withShadow(t, `{"window_size": 4}`, 0)
+	for i := 0; i < 20; i++ {
+		postChunk(t, shadowBody)
+	}
+	// Nothing was started, so there is nothing to wait for.
+	shadow.mu.Lock()
+	runs := shadow.Compared + shadow.Failed + shadow.Dropped
+	shadow.mu.Unlock()
+	if runs != 0 {
+		t.Errorf("sample 0 shadowed %d requests", runs)
+	}
+
+	withShadow(t, `{"window_size": 4}`, 1)
+	for i := 0; i < 3; i++ {
+		postChunk(t, shadowBody)
+	}
+	if s := shadowRuns(t, 3); s.Compared+s.Dropped != 3 {
+		t.Errorf("sample 1 should shadow every request, got %+v", s)
+	}
+}
+
+func TestShadowFailureLeavesPrimary(t *testing.T) {
+	oldEmbedder, oldClock := embedder, clock
+	t.Cleanup(func() { embedder, clock = oldEmbedder, oldClock })
+	embedder = nil
+	clock = chunking.FixedClock(time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC))
+	want := postChunk(t, shadowBody).Body.String()
+
+	// Semantic chunking is not configured, so every candidate fails.
+	withShadow(t, `{"strategy": "semantic"}`, 1)
+	w := postChunk(t, shadowBody)
+	if w.Code != http.StatusOK || w.Body.String() != want {
+		t.Errorf("the failing shadow changed the primary response: %d %s", w.Code, w.Body)
+	}
+	if s := shadowRuns(t, 1); s.Failed != 1 || s.Compared != 0 {
+		t.Errorf("expected one failed shadow run, got %+v", s)
+	}
+}
@@ -0,0 +1,60 @@
+package main
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"os"
+)
+
+// Client certificate modes for client_auth.
+const (
+	clientAuthRequire  = "require"
+	clientAuthOptional = "optional"
+)
+
+// serverTLS builds the TLS settings shared by the HTTP and gRPC
+// listeners, or returns nil when TLS is off. With a client CA, clients
+// must present a certificate it signed; in optional mode they may also
+// connect without one, but a certificate they do present must verify.
+func (t tlsConfig) serverTLS() (*tls.Config, error) {
+	if t.CertFile == "" && t.KeyFile == "" {
+		if t.ClientCAFile != "" || t.ClientAuth != "" {
+			return nil, errors.New("client_ca_file and client_auth need cert_file and key_file")
+		}
+		return nil, nil
+	}
+	if t.CertFile == "" || t.KeyFile == "" {
+		return nil, errors.New("cert_file and key_file must be set together")
+	}
+	cert, err := tls.LoadX509KeyPair(t.CertFile, t.KeyFile)
+	if err != nil {
+		return nil, err
+	}
+	cfg := &tls.Config{Certificates: []tls.Certificate{cert}, MinVersion: tls.VersionTLS12}
+	if t.ClientCAFile == "" {
+		if t.ClientAuth != "" {
+			return nil, errors.New("client_auth needs client_ca_file")
+		}
+		return cfg, nil
+	}
+	pem, err := os.ReadFile(t.ClientCAFile)
+	if err != nil {
+		return nil, err
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(pem) {
+		return nil, fmt.Errorf("no certificates in %s", t.ClientCAFile)
+	}
+	cfg.ClientCAs = pool
+	switch t.ClientAuth {
+	case "", clientAuthRequire:
+		cfg.ClientAuth = tls.RequireAndVerifyClientCert
+	case clientAuthOptional:
+		cfg.ClientAuth = tls.VerifyClientCertIfGiven
+	default:
+		return nil, fmt.Errorf("client_auth must be %q or %q, got %q", clientAuthRequire, clientAuthOptional, t.ClientAuth)
+	}
+	return cfg, nil
+}
@@ -0,0 +1,173 @@
+package main
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// testCA issues certificates for the mTLS tests.
+type testCA struct {
+	cert *x509.Certificate
+	key  *ecdsa.PrivateKey
+	pem  []byte
+}
+
+func newTestCA(t *testing.T, name string) *testCA {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: name},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		KeyUsage:              x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, _ := x509.ParseCertificate(der)
+	return &testCA{cert: cert, key: key, pem: pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})}
+}
+
+// issue returns the PEM certificate and key of a leaf for usage, valid
+// for 127.0.0.1.
+func (ca *testCA) issue(t *testing.T, name string, usage x509.ExtKeyUsage) (certPEM, keyPEM []byte) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(time.Now().UnixNano()),
+		Subject:      pkix.Name{CommonName: name},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{usage},
+		IPAddresses:  []net.IP{net.ParseIP("127.0.0.1")},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, ca.cert, &key.PublicKey, ca.key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER})
+}
+
+func writePEM(t *testing.T, dir, name string, data []byte) string {
+	t.Helper()
+	path := filepath.Join(dir, name)
+	if err := os.WriteFile(path, data, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestServerTLSClientCerts(t *testing.T) {
+	dir := t.TempDir()
+	ca, rogue := newTestCA(t, "chunker test CA"), newTestCA(t, "rogue CA")
+	serverCert, serverKey := ca.issue(t, "chunker", x509.ExtKeyUsageServerAuth)
+	base := tlsConfig{
+		CertFile:     writePEM(t, dir, "server.crt", serverCert),
+		KeyFile:      writePEM(t, dir, "server.key", serverKey),
+		ClientCAFile: writePEM(t, dir, "ca.crt", ca.pem),
+	}
+	clientCert, err := tls.X509KeyPair(ca.issue(t, "ingest", x509.ExtKeyUsageClientAuth))
+	if err != nil {
+		t.Fatal(err)
+	}
+	rogueCert, err := tls.X509KeyPair(rogue.issue(t, "ingest", x509.ExtKeyUsageClientAuth))
+	if err != nil {
+		t.Fatal(err)
+	}
+	roots := x509.NewCertPool()
+	roots.AddCert(ca.cert)
+
+	for _, tc := range []struct {
+		name       string
+		clientAuth string
+		cert       *tls.Certificate
+		ok         bool
+	}{
+		{"require, no certificate", "", nil, false},
+		{"require, valid certificate", clientAuthRequire, &clientCert, true},
+		{"require, certificate of another CA", "", &rogueCert, false},
+		{"optional, no certificate", clientAuthOptional, nil, true},
+		{"optional, valid certificate", clientAuthOptional, &clientCert, true},
+		{"optional, certificate of another CA", clientAuthOptional, &rogueCert, false},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			cfg := base
+			cfg.ClientAuth = tc.clientAuth
+			serverCfg, err := cfg.serverTLS()
+			if err != nil {
+				t.Fatalf("serverTLS failed: %v", err)
+			}
+			srv := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+			srv.TLS = serverCfg
+			srv.StartTLS()
+			defer srv.Close()
+
+			clientCfg := &tls.Config{RootCAs: roots}
+			if tc.cert != nil {
+				// Present the certificate even when the server's CA list
+				// does not name its issuer, which Go clients otherwise
+				// skip.
+				clientCfg.GetClientCertificate = func(*tls.CertificateRequestInfo) (*tls.Certificate, error) {
+					return tc.cert, nil
+				}
+			}
+			client := &http.Client{Transport: &http.Transport{TLSClientConfig: clientCfg}}
+			resp, err := client.Get(srv.URL)
+			if err == nil {
+				resp.Body.Close()
+			}
+			if (err == nil) != tc.ok {
+				t.Errorf("got %v, want success %v", err, tc.ok)
+			}
+		})
+	}
+}
+
+func TestServerTLSRejectsInvalid(t *testing.T) {
+	dir := t.TempDir()
+	ca := newTestCA(t, "chunker test CA")
+	cert, key := ca.issue(t, "chunker", x509.ExtKeyUsageServerAuth)
+	certFile, keyFile := writePEM(t, dir, "server.crt", cert), writePEM(t, dir, "server.key", key)
+	for _, tc := range []struct {
+		name string
+		cfg  tlsConfig
+	}{
+		{"cert without key", tlsConfig{CertFile: certFile}},
+		{"client CA without cert", tlsConfig{ClientCAFile: writePEM(t, dir, "ca.crt", ca.pem)}},
+		{"client auth without CA", tlsConfig{CertFile: certFile, KeyFile: keyFile, ClientAuth: clientAuthRequire}},
+		{"CA without certificates", tlsConfig{CertFile: certFile, KeyFile: keyFile, ClientCAFile: writePEM(t, dir, "empty.crt", []byte("nothing"))}},
+		{"unknown client auth", tlsConfig{CertFile: certFile, KeyFile: keyFile, ClientCAFile: writePEM(t, dir, "ca2.crt", ca.pem), ClientAuth: "sometimes"}},
+	} {
+		if _, err := tc.cfg.serverTLS(); err == nil {
+			t.Errorf("%s: expected an error", tc.name)
+		}
+	}
+}
@@ -0,0 +1,28 @@
+package main
+
+import (
+	"net/http"
+	"strings"
+)
+
+// apiPrefix versions the HTTP API. Routes move to a new prefix only for
+// breaking changes; chunking.SchemaVersion, reported in every response,
+// versions the Chunk and ChunkingPlan formats within it.
+const apiPrefix = "/v1"
+
+// handleAPI registers h under apiPrefix, and at the bare path for clients
+// written before routes were versioned. Bare-path responses carry
+// Deprecation and successor-version Link headers.
+func handleAPI(mux *http.ServeMux, path string, h http.HandlerFunc) {
+	mux.HandleFunc(apiPrefix+path, h)
+	mux.HandleFunc(path, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Deprecation", "true")
+		w.Header().Set("Link", "<"+apiPrefix+r.URL.Path+`>; rel="successor-version"`)
+		h(w, r)
+	})
+}
+
+// apiPath strips apiPrefix, so handlers see the same path on both routes.
+func apiPath(r *http.Request) string {
+	return strings.TrimPrefix(r.URL.Path, apiPrefix)
+}
@@ -0,0 +1,65 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"os"
+
+	"chunker-service/pkg/chunking"
+	"chunker-service/pkg/htmlview"
+	"chunker-service/pkg/pipeline"
+)
+
+// checkCheckpoint rejects flag combinations a --checkpoint run over files
+// cannot resume.
+func checkCheckpoint(cfg cliConfig, paths []string) {
+	switch {
+	case len(paths) == 0 && cfg.Dir == "":
+		log.Fatalf("--checkpoint needs file arguments, --dir or --manifest")
+	case cfg.OutputDir == "" && cfg.Format != "jsonl":
+		log.Fatalf("--checkpoint writes to stdout only with --format jsonl, which resumed runs can append to; use --output-dir for other formats")
+	case cfg.DryRun || cfg.Watch || cfg.RunManifest != "":
+		log.Fatalf("--checkpoint cannot be combined with --dry-run, --watch or --run-manifest")
+	}
+}
+
+// checkpointRun returns the fileRun of a --checkpoint run. Files cp
+// records with their current content are skipped. Every other file is
+// written, to its output path or stdout, as soon as it and the files
+// before it are chunked, and only then recorded, so an interrupted run
+// loses at most the files in flight.
+func checkpointRun(cfg cliConfig, plan chunking.ChunkingPlan, cp *pipeline.Checkpoint, files []cliFile, outputs []string, progress *pipeline.Progress) fileRun {
+	clock := chunking.FixedClock(cliClock(cfg).Now())
+	emit := func(i int, chunks []chunking.Chunk) {
+		chunking.Stamp(chunks, clock)
+		doc := htmlview.Document{Name: files[i].path, Text: files[i].text, Chunks: chunks}
+		var err error
+		if outputs != nil {
+			err = writeOutputFile(cfg, plan, outputs[i], doc)
+		} else {
+			err = writeDocuments(os.Stdout, cfg, plan, []htmlview.Document{doc})
+		}
+		if err != nil {
+			log.Fatalf("%v", err)
+		}
+		if err := cp.Record(files[i].path, files[i].hash); err != nil {
+			log.Fatalf("failed to record checkpoint: %v", err)
+		}
+	}
+	return fileRun{progress: progress, checkpoint: cp, emit: emit}
+}
+
+// printCheckpointReport writes how many files a --checkpoint run chunked
+// and skipped to stderr.
+func printCheckpointReport(cfg cliConfig, files []cliFile) {
+	skipped := 0
+	for _, f := range files {
+		if f.skipped {
+			skipped++
+		}
+	}
+	if cfg.OutputDir != "" {
+		fmt.Fprintf(os.Stderr, "wrote %d files to %s\n", len(files)-skipped, cfg.OutputDir)
+	}
+	fmt.Fprintf(os.Stderr, "skipped %d files recorded unchanged in %s\n", skipped, cfg.Checkpoint)
+}
@@ -0,0 +1,91 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+
+	"chunker-service/pkg/chunking"
+	"chunker-service/pkg/fieldzip"
+)
+
+// runCompress implements "chunker compress": it migrates chunks written
+// before a sink enabled compression, or back, by rewriting JSONL chunk
+// files to stdout with their text compressed, or expanded with
+// --decompress. Chunks already in the wanted form pass through unchanged,
+// so partly migrated files can be run through it again. Encrypted chunks
+// are rejected either way.
+func runCompress(args []string) {
+	fs := flag.NewFlagSet("compress", flag.ExitOnError)
+	var cfg fieldzip.Config
+	decompress := fs.Bool("decompress", false, "expand compressed chunks instead")
+	fs.IntVar(&cfg.Level, "level", 0, "zstd compression level, 1 (fastest) to 22 (smallest); 0 uses the default")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "usage: chunker compress [flags] chunks.jsonl... (- or no file reads stdin)")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+	zip, err := fieldzip.New(cfg)
+	if err != nil {
+		log.Fatalf("%v", err)
+	}
+	paths := fs.Args()
+	if len(paths) == 0 {
+		paths = []string{"-"}
+	}
+
+	w := bufio.NewWriter(os.Stdout)
+	enc := json.NewEncoder(w)
+	var total, changed int
+	for _, path := range paths {
+		var r io.Reader = os.Stdin
+		if path != "-" {
+			f, err := os.Open(path)
+			if err != nil {
+				log.Fatalf("failed to open chunks: %v", err)
+			}
+			defer f.Close()
+			r = f
+		}
+		dec := json.NewDecoder(r)
+		for {
+			var ch chunking.Chunk
+			if err := dec.Decode(&ch); err == io.EOF {
+				break
+			} else if err != nil {
+				log.Fatalf("%s: %v", path, err)
+			}
+			was := fieldzip.Compressed(ch)
+			if *decompress {
+				err = fieldzip.DecompressChunk(&ch)
+			} else {
+				var out []chunking.Chunk
+				if out, err = zip.CompressChunks([]chunking.Chunk{ch}); err == nil {
+					ch = out[0]
+				}
+			}
+			if err != nil {
+				log.Fatalf("%s: %v", path, err)
+			}
+			if fieldzip.Compressed(ch) != was {
+				changed++
+			}
+			total++
+			if err := enc.Encode(ch); err != nil {
+				log.Fatalf("failed to encode chunks: %v", err)
+			}
+		}
+	}
+	if err := w.Flush(); err != nil {
+		log.Fatalf("failed to write chunks: %v", err)
+	}
+	verb := "compressed"
+	if *decompress {
+		verb = "decompressed"
+	}
+	fmt.Fprintf(os.Stderr, "read %d chunks: %s %d\n", total, verb, changed)
+}
@@ -0,0 +1,76 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+
+	"chunker-service/pkg/corpusgen"
+)
+
+// runCorpus implements "chunker corpus": it generates the synthetic
+// corpus a spec file declares. Documents and their expected segmentation
+// are printed as JSON lines, or, with --output-dir, each document is
+// written to its own .txt file and the segmentation, without the text, to
+// expected.jsonl next to them, ready for --dir.
+func runCorpus(args []string) {
+	fs := flag.NewFlagSet("corpus", flag.ExitOnError)
+	outputDir := fs.String("output-dir", "", "write each document to a file in this directory and the expected segmentation to expected.jsonl")
+	seed := fs.Int64("seed", 0, "override the spec's seed")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "usage: chunker corpus [flags] spec.yaml")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+	if fs.NArg() != 1 {
+		fs.Usage()
+		os.Exit(2)
+	}
+	spec, err := corpusgen.LoadSpec(fs.Arg(0))
+	if err != nil {
+		log.Fatalf("failed to load corpus spec: %v", err)
+	}
+	fs.Visit(func(f *flag.Flag) {
+		if f.Name == "seed" {
+			spec.Seed = *seed
+		}
+	})
+	docs, err := corpusgen.Generate(spec)
+	if err != nil {
+		log.Fatalf("%v", err)
+	}
+
+	if *outputDir == "" {
+		enc := json.NewEncoder(os.Stdout)
+		for _, doc := range docs {
+			if err := enc.Encode(doc); err != nil {
+				log.Fatalf("failed to encode corpus: %v", err)
+			}
+		}
+		return
+	}
+	if err := os.MkdirAll(*outputDir, 0o755); err != nil {
+		log.Fatalf("failed to create output directory: %v", err)
+	}
+	expected, err := os.Create(filepath.Join(*outputDir, "expected.jsonl"))
+	if err != nil {
+		log.Fatalf("failed to create expected.jsonl: %v", err)
+	}
+	enc := json.NewEncoder(expected)
+	for _, doc := range docs {
+		if err := os.WriteFile(filepath.Join(*outputDir, doc.Name+".txt"), []byte(doc.Text), 0o644); err != nil {
+			log.Fatalf("failed to write document: %v", err)
+		}
+		doc.Text = ""
+		if err := enc.Encode(doc); err != nil {
+			log.Fatalf("failed to write expected.jsonl: %v", err)
+		}
+	}
+	if err := expected.Close(); err != nil {
+		log.Fatalf("failed to write expected.jsonl: %v", err)
+	}
+	fmt.Fprintf(os.Stderr, "wrote %d documents to %s\n", len(docs), *outputDir)
+}
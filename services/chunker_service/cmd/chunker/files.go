@@ -0,0 +1,181 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	"chunker-service/pkg/chunking"
+	"chunker-service/pkg/pipeline"
+)
+
+// expandPaths expands the glob arguments, for shells that pass them
+// through quoted, in argument order and lexical order within a glob. A
+// glob that matches nothing is an error, like a missing file.
+func expandPaths(args []string) ([]string, error) {
+	var paths []string
+	for _, arg := range args {
+		if !strings.ContainsAny(arg, "*?[") {
+			paths = append(paths, arg)
+			continue
+		}
+		matches, err := filepath.Glob(arg)
+		if err != nil {
+			return nil, fmt.Errorf("invalid glob %q: %w", arg, err)
+		}
+		if len(matches) == 0 {
+			return nil, fmt.Errorf("%q matched no files", arg)
+		}
+		sort.Strings(matches)
+		paths = append(paths, matches...)
+	}
+	return paths, nil
+}
+
+// fileMeta returns the file_name, file_path and mime_type of path with
+// --meta-json merged over them, as manifests do.
+func fileMeta(path string, base map[string]interface{}) map[string]interface{} {
+	meta := pipeline.FileMeta(path)
+	for k, v := range base {
+		meta[k] = v
+	}
+	return meta
+}
+
+// dirFiles returns the text files of --dir matching --include and
+// --exclude, reporting the binary files it skips on stderr.
+func dirFiles(cfg cliConfig) []pipeline.DirFile {
+	files, binary, err := pipeline.WalkDir(cfg.Dir, pipeline.WalkOptions{Include: cfg.Include, Exclude: cfg.Exclude})
+	if err != nil {
+		log.Fatalf("failed to read dir: %v", err)
+	}
+	for _, rel := range binary {
+		log.Printf("skipping binary file %s", rel)
+	}
+	if len(files) == 0 {
+		log.Fatalf("%s has no matching text files", cfg.Dir)
+	}
+	return files
+}
+
+// dirFileMeta is fileMeta for a file found under --dir: its file_path is
+// relative to the directory and its mime_type the detected one.
+func dirFileMeta(f pipeline.DirFile, base map[string]interface{}) map[string]interface{} {
+	meta := pipeline.FileMeta(f.RelPath)
+	meta["mime_type"] = f.MimeType
+	for k, v := range base {
+		meta[k] = v
+	}
+	return meta
+}
+
+// cliSampling reports whether any --sample-* flag is set, validating
+// them if so.
+func cliSampling(cfg cliConfig) bool {
+	if cfg.Sample == (pipeline.Sample{}) {
+		return false
+	}
+	if err := cfg.Sample.Validate(); err != nil {
+		log.Fatalf("invalid sample flags: %v", err)
+	}
+	return true
+}
+
+// sampleFiles returns the files in s and a report counting all of them.
+func sampleFiles(s pipeline.Sample, files []cliFile) ([]cliFile, *pipeline.SampleReport) {
+	report := &pipeline.SampleReport{}
+	var kept []cliFile
+	for i, f := range files {
+		info, err := os.Stat(f.path)
+		if err != nil {
+			log.Fatalf("failed to read %s: %v", f.path, err)
+		}
+		keep := s.Keep(i, f.path)
+		report.Add(info.Size(), keep)
+		if keep {
+			kept = append(kept, f)
+		}
+	}
+	return kept, report
+}
+
+// printSampleReport writes a sampled run's extrapolation to stderr.
+func printSampleReport(r pipeline.SampleReport) {
+	fmt.Fprintf(os.Stderr, "sampled %d of %d documents (%d of %d bytes): %d chunks, about %d for the whole corpus\n",
+		r.Sampled, r.Documents, r.SampledBytes, r.InputBytes, r.Chunks, r.EstimatedChunks)
+}
+
+// cliFile is an input file and the metadata its chunks carry. rel is
+// the slash-separated path --output-dir mirrors; text, hash (its
+// ContentHash) and skipped, set when a checkpoint already records it, are
+// filled in by chunkFiles.
+type cliFile struct {
+	path    string
+	rel     string
+	meta    map[string]interface{}
+	text    string
+	hash    string
+	skipped bool
+}
+
+// fileRun holds the optional parts of chunkFiles. Every file is counted
+// in progress as it finishes, files checkpoint records with their current
+// content are skipped, and emit receives the index of each file that was
+// chunked, in file order, as soon as it and every file before it are
+// done.
+type fileRun struct {
+	progress   *pipeline.Progress
+	checkpoint *pipeline.Checkpoint
+	emit       func(i int, chunks []chunking.Chunk)
+}
+
+// chunkFiles chunks files, up to workers at a time, and returns the
+// chunks of each file in file order whatever order they finish in, so the
+// output does not depend on --workers.
+func chunkFiles(chunker chunking.Chunker, routing *chunking.Routing, plan chunking.ChunkingPlan, files []cliFile, workers int, run fileRun) [][]chunking.Chunk {
+	results := make([][]chunking.Chunk, len(files))
+	finished := make([]bool, len(files))
+	var mu sync.Mutex
+	emitted := 0
+	next := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < workers && w < len(files); w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range next {
+				input, err := os.ReadFile(files[i].path)
+				if err != nil {
+					log.Fatalf("failed to read %s: %v", files[i].path, err)
+				}
+				files[i].hash = pipeline.ContentHash(input)
+				if run.checkpoint != nil && run.checkpoint.Done(files[i].path, files[i].hash) {
+					files[i].skipped = true
+				} else {
+					files[i].text = string(input)
+					results[i] = chunkDocument(chunker, routing, files[i].text, plan, files[i].meta)
+				}
+				run.progress.Add(len(input), len(results[i]))
+
+				mu.Lock()
+				finished[i] = true
+				for ; emitted < len(files) && finished[emitted]; emitted++ {
+					if run.emit != nil && !files[emitted].skipped {
+						run.emit(emitted, results[emitted])
+					}
+				}
+				mu.Unlock()
+			}
+		}()
+	}
+	for i := range files {
+		next <- i
+	}
+	close(next)
+	wg.Wait()
+	return results
+}
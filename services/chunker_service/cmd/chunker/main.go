@@ -2,76 +2,459 @@ package main
 
 import (
 	"encoding/json"
+	"errors"
 	"flag"
 	"fmt"
 	"io"
 	"log"
 	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
 	"time"
 
 	"chunker-service/pkg/chunking"
+	"chunker-service/pkg/csvout"
+	"chunker-service/pkg/embedding"
+	"chunker-service/pkg/htmlview"
+	"chunker-service/pkg/jobs"
+	"chunker-service/pkg/pipeline"
+	"chunker-service/pkg/tokenizer"
+	"chunker-service/pkg/yamljson"
 )
 
 // cliConfig holds flag values for the chunker CLI.
 type cliConfig struct {
-	PlanJSON string
-	MetaJSON string
+	PlanJSON    string
+	PlanFile    string
+	Preset      string
+	MetaJSON    string
+	Manifest    string
+	Checkpoint  string
+	DeadLetter  string
+	Repair      bool
+	Purge       bool
+	Tokenizers  string
+	CreatedAt   string
+	Format      string
+	SQLTable    string
+	CSVColumns  string
+	Embedding   string
+	Routing     string
+	Dir         string
+	Include     []string
+	Exclude     []string
+	Workers     int
+	Sample      pipeline.Sample
+	OutputDir   string
+	Watch       bool
+	RunManifest string
+	DryRun      bool
+	Quiet       bool
+	// Stream reads stdin as records instead of one document: "ndjson"
+	// or "records", split at RecordSeparator.
+	Stream          string
+	RecordSeparator string
+	// EmbeddingRate is the embedding provider's quota semantic plans
+	// stay within.
+	EmbeddingRate embedding.RateLimit
+	// EmbeddingCache is how many embedded texts semantic plans keep to
+	// avoid embedding them again; 0 disables the cache.
+	EmbeddingCache int
+	// EmbeddingCacheURL is a Redis URL to cache embedded texts in
+	// instead, shared with other runs and servers.
+	EmbeddingCacheURL string
+	// EmbeddingLimits are the embedding model's request limits, which
+	// size the batches sent to it.
+	EmbeddingLimits embedding.Limits
+	// WatchInterval is how often --watch checks the files for changes.
+	WatchInterval time.Duration
 }
 
 func parseFlags() cliConfig {
 	var cfg cliConfig
 	flag.StringVar(&cfg.PlanJSON, "plan-json", "", "JSON-encoded ChunkingPlan")
+	flag.StringVar(&cfg.PlanFile, "plan-file", "", "JSON or YAML (.yaml/.yml) file holding a ChunkingPlan (instead of --plan-json)")
+	flag.StringVar(&cfg.Preset, "preset", "", "built-in plan to start from: "+strings.Join(chunking.Presets.Names(), ", ")+"; --plan-json or --plan-file fields override it")
 	flag.StringVar(&cfg.MetaJSON, "meta-json", "{}", "JSON-encoded base metadata map")
+	flag.StringVar(&cfg.Manifest, "manifest", "", "JSON or YAML corpus manifest to execute instead of reading stdin")
+	flag.StringVar(&cfg.Checkpoint, "checkpoint", "", "progress file recording the content hash of every input done, so interrupted runs resume and re-runs skip unchanged files (overrides the manifest; needs --format jsonl or --output-dir for files)")
+	flag.StringVar(&cfg.DeadLetter, "dead-letter", "", "directory for failed manifest documents (overrides the manifest)")
+	flag.BoolVar(&cfg.Repair, "repair", false, "reconcile manifest sinks by writing documents missing from some of them")
+	flag.BoolVar(&cfg.Purge, "purge", false, "delete chunks past their retention date from the manifest sinks")
+	flag.StringVar(&cfg.Tokenizers, "tokenizer-dir", "", "directory of tiktoken rank files and HuggingFace BPE vocabularies to load")
+	flag.StringVar(&cfg.CreatedAt, "created-at", "", "RFC 3339 timestamp recorded as every chunk's created_at (overrides the manifest; default: now)")
+	flag.StringVar(&cfg.Format, "format", "json", "output format: json (one array), jsonl (one chunk per line), csv (one row per chunk), arrow (Arrow IPC stream), sql (INSERT), sql-copy (COPY) or html (chunk boundaries drawn over each document, for review)")
+	flag.StringVar(&cfg.Format, "output-format", "json", "alias of --format")
+	flag.StringVar(&cfg.OutputDir, "output-dir", "", "write each input file's chunks to its own file under this directory, mirroring the input paths, instead of to stdout")
+	flag.BoolVar(&cfg.Watch, "watch", false, "keep running and re-chunk files under --dir or given as arguments when they change (needs --format jsonl or --output-dir)")
+	flag.DurationVar(&cfg.WatchInterval, "watch-interval", time.Second, "how often --watch checks the files for changes")
+	flag.StringVar(&cfg.RunManifest, "run-manifest", "", "write a manifest of the run (input, plan, tokenizer and output hashes and the code version) to this file, for \"chunker reproduce\"")
+	flag.BoolVar(&cfg.DryRun, "dry-run", false, "chunk without writing chunks: print each document's chunk count, sizes in tokens and characters and overlap ratio as JSON lines")
+	flag.StringVar(&cfg.Stream, "stream", "", "read stdin as a stream of records, chunking and writing each before reading the next: ndjson ({\"text\": ..., \"meta\": {...}} per line) or records (text split at --record-separator); needs --format jsonl")
+	flag.StringVar(&cfg.RecordSeparator, "record-separator", `\x1e`, "separator between the records of --stream records, with Go escapes such as \\n (default: the ASCII record separator)")
+	flag.BoolVar(&cfg.Quiet, "quiet", false, "do not report progress on stderr while chunking files")
+	flag.StringVar(&cfg.SQLTable, "sql-table", "chunks", "table name for the sql formats")
+	flag.StringVar(&cfg.CSVColumns, "csv-columns", "", "comma-separated chunk fields for the csv format; \"offsets\" adds the character and byte offsets, \"extra.<key>\" an Extra entry (default: "+strings.Join(csvout.DefaultColumns, ",")+")")
+	flag.StringVar(&cfg.Embedding, "embedding-url", os.Getenv("CHUNKER_EMBEDDING_URL"), "embedding service URL used by semantic plans")
+	flag.IntVar(&cfg.EmbeddingRate.RequestsPerMinute, "embedding-rpm", envInt("CHUNKER_EMBEDDING_RPM"), "embedding requests per minute allowed across all workers; 429 responses are retried after a shared pause (default: no limit)")
+	flag.IntVar(&cfg.EmbeddingRate.TokensPerMinute, "embedding-tpm", envInt("CHUNKER_EMBEDDING_TPM"), "embedding tokens per minute allowed across all workers, counted as words (default: no limit)")
+	flag.IntVar(&cfg.EmbeddingLimits.MaxTokensPerRequest, "embedding-max-tokens", envInt("CHUNKER_EMBEDDING_MAX_TOKENS"), "embedding tokens per request, counted as words; batches are packed up to it (default: no limit)")
+	flag.IntVar(&cfg.EmbeddingLimits.MaxTokensPerItem, "embedding-max-item-tokens", envInt("CHUNKER_EMBEDDING_MAX_ITEM_TOKENS"), "embedding tokens per text, counted as words; longer texts fail (default: no limit)")
+	flag.IntVar(&cfg.EmbeddingCache, "embedding-cache-size", envInt("CHUNKER_EMBEDDING_CACHE_SIZE"), "embedded texts to keep in memory, by content hash and model, so repeated texts are not embedded again (default: no cache)")
+	flag.StringVar(&cfg.EmbeddingCacheURL, "embedding-cache-url", os.Getenv("CHUNKER_EMBEDDING_CACHE_URL"), "redis://[user:password@]host:port[/db] to cache embedded texts in, shared between runs and servers (overrides --embedding-cache-size)")
+	flag.StringVar(&cfg.Routing, "routing-json", "", "JSON-encoded presets and MIME type/extension routes applied when the plan names no strategy (overrides the manifest)")
+	flag.StringVar(&cfg.Dir, "dir", "", "directory whose text files are chunked, recursively, tagged with their relative paths")
+	flag.Func("include", "filepath.Match pattern of --dir files to chunk (repeatable; default: all)", func(s string) error {
+		cfg.Include = append(cfg.Include, s)
+		return nil
+	})
+	flag.Func("exclude", "filepath.Match pattern of --dir files and directories to skip (repeatable)", func(s string) error {
+		cfg.Exclude = append(cfg.Exclude, s)
+		return nil
+	})
+	flag.IntVar(&cfg.Workers, "workers", 1, "files chunked concurrently; output keeps file order")
+	flag.Float64Var(&cfg.Sample.Rate, "sample-rate", 0, "chunk only this fraction of the files, chosen by path, and extrapolate (overrides the manifest)")
+	flag.IntVar(&cfg.Sample.First, "sample-first", 0, "chunk only the first N files, plus every --sample-every'th after them (overrides the manifest)")
+	flag.IntVar(&cfg.Sample.Every, "sample-every", 0, "chunk only every Kth file, after the --sample-first files (overrides the manifest)")
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "usage: chunker [flags] [file or glob ...]\n       chunker replay [flags] audit.jsonl...\n       chunker reproduce [flags] run-manifest.json\n       chunker compress [flags] chunks.jsonl...\n       chunker corpus [flags] spec.yaml\n\nChunks the files and the --dir directory, or stdin when neither is given.\n\n")
+		flag.PrintDefaults()
+	}
 	flag.Parse()
 	return cfg
 }
 
 func main() {
+	if len(os.Args) > 1 && os.Args[1] == "replay" {
+		runReplay(os.Args[2:])
+		return
+	}
+	if len(os.Args) > 1 && os.Args[1] == "reproduce" {
+		runReproduce(os.Args[2:])
+		return
+	}
+	if len(os.Args) > 1 && os.Args[1] == "compress" {
+		runCompress(os.Args[2:])
+		return
+	}
+	if len(os.Args) > 1 && os.Args[1] == "corpus" {
+		runCorpus(os.Args[2:])
+		return
+	}
 	cfg := parseFlags()
 
-	if cfg.PlanJSON == "" {
-		log.Fatalf("missing required --plan-json argument")
+	if cfg.Tokenizers != "" {
+		if _, err := tokenizer.LoadDir(cfg.Tokenizers); err != nil {
+			log.Fatalf("failed to load tokenizers: %v", err)
+		}
 	}
 
-	plan := chunking.ChunkingPlan{}
-	if err := json.Unmarshal([]byte(cfg.PlanJSON), &plan); err != nil {
-		log.Fatalf("invalid plan-json: %v", err)
+	if cfg.Manifest != "" {
+		runManifest(cfg)
+		return
 	}
 
+	plan := cliPlan(cfg)
+
 	baseMeta := map[string]interface{}{}
 	if err := json.Unmarshal([]byte(cfg.MetaJSON), &baseMeta); err != nil {
 		log.Fatalf("invalid meta-json: %v", err)
 	}
 
-	input, err := io.ReadAll(os.Stdin)
+	paths, err := expandPaths(flag.Args())
 	if err != nil {
-		log.Fatalf("failed to read stdin: %v", err)
+		log.Fatalf("%v", err)
+	}
+	if _, ok := outputExtensions[cfg.Format]; !ok {
+		log.Fatalf("unsupported format %q", cfg.Format)
+	}
+	if cfg.OutputDir != "" && len(paths) == 0 && cfg.Dir == "" {
+		log.Fatalf("--output-dir needs file arguments or --dir")
+	}
+	if cfg.DryRun && (cfg.OutputDir != "" || cfg.Watch || cfg.RunManifest != "") {
+		log.Fatalf("--dry-run writes no chunks and cannot be combined with --output-dir, --watch or --run-manifest")
+	}
+	if cfg.Checkpoint != "" {
+		checkCheckpoint(cfg, paths)
+	}
+	if cfg.Watch {
+		checkWatch(cfg, paths)
+		if cfg.RunManifest != "" {
+			log.Fatalf("--run-manifest cannot be combined with --watch")
+		}
+	}
+	if cfg.Stream != "" {
+		checkStream(cfg, paths)
+	}
+	chunker := cliChunker(cfg)
+	routing := cliRouting(cfg)
+	if cfg.Stream != "" {
+		streamStdin(cfg, chunker, routing, plan, baseMeta)
+		fmt.Fprintln(os.Stderr, "chunking completed")
+		return
+	}
+	var chunks []chunking.Chunk
+	var stdin []byte
+	if len(paths) == 0 && cfg.Dir == "" {
+		if stdin, err = io.ReadAll(os.Stdin); err != nil {
+			log.Fatalf("failed to read stdin: %v", err)
+		}
+		chunks = chunkDocument(chunker, routing, string(stdin), plan, baseMeta)
+	}
+	var files []cliFile
+	for _, path := range paths {
+		files = append(files, cliFile{path: path, rel: filepath.Clean(path), meta: fileMeta(path, baseMeta)})
+	}
+	if cfg.Dir != "" {
+		for _, f := range dirFiles(cfg) {
+			files = append(files, cliFile{path: f.Path, rel: f.RelPath, meta: dirFileMeta(f, baseMeta)})
+		}
+	}
+	if cfg.Workers < 1 {
+		log.Fatalf("--workers must be >= 1")
+	}
+	if cfg.Watch {
+		if cfg.OutputDir != "" {
+			outputPaths(cfg, files)
+		}
+		w := &watcher{cfg: cfg, chunker: chunker, routing: routing, plan: plan, baseMeta: baseMeta, paths: paths}
+		w.watch()
+		return
+	}
+	var sample *pipeline.SampleReport
+	if cliSampling(cfg) {
+		files, sample = sampleFiles(cfg.Sample, files)
+	}
+	var outputs []string
+	if cfg.OutputDir != "" {
+		outputs = outputPaths(cfg, files)
+	}
+	progress := pipeline.NewProgress(len(files), time.Now())
+	run := fileRun{progress: progress}
+	if cfg.Checkpoint != "" {
+		cp, err := pipeline.OpenCheckpoint(cfg.Checkpoint)
+		if err != nil {
+			log.Fatalf("failed to open checkpoint: %v", err)
+		}
+		defer cp.Close()
+		run = checkpointRun(cfg, plan, cp, files, outputs, progress)
+	}
+	stopProgress := startProgress(cfg, progress)
+	fileChunks := chunkFiles(chunker, routing, plan, files, cfg.Workers, run)
+	stopProgress()
+	if sample != nil {
+		for _, fc := range fileChunks {
+			sample.Chunks += len(fc)
+		}
+		sample.Extrapolate()
+		printSampleReport(*sample)
+	}
+	if cfg.Checkpoint != "" {
+		printCheckpointReport(cfg, files)
+		fmt.Fprintln(os.Stderr, "chunking completed")
+		return
+	}
+
+	if cfg.DryRun {
+		var paths []string
+		var docs [][]chunking.Chunk
+		if stdin != nil {
+			paths, docs = append(paths, ""), append(docs, chunks)
+		}
+		for i, f := range files {
+			paths, docs = append(paths, f.path), append(docs, fileChunks[i])
+		}
+		printDryRun(plan, paths, docs)
+		return
+	}
+
+	// Ensure all chunks have basic metadata fields populated where
+	// possible, with one timestamp for the whole invocation.
+	createdAt := cliClock(cfg).Now()
+	clock := chunking.FixedClock(createdAt)
+	if cfg.RunManifest != "" {
+		chunking.Stamp(chunks, clock)
+		for _, fc := range fileChunks {
+			chunking.Stamp(fc, clock)
+		}
+		m := newRunManifest(cfg, plan, routing, createdAt)
+		saveRunManifest(cfg, m, stdin, chunks, files, fileChunks, baseMeta)
+	}
+	if cfg.OutputDir != "" {
+		for _, fc := range fileChunks {
+			chunking.Stamp(fc, clock)
+		}
+		writeOutputDir(cfg, plan, outputs, files, fileChunks)
+		fmt.Fprintln(os.Stderr, "chunking completed")
+		return
+	}
+	var docs []htmlview.Document
+	if stdin != nil {
+		docs = append(docs, htmlview.Document{Name: "stdin", Text: string(stdin), Chunks: chunks})
+	}
+	for i, f := range files {
+		docs = append(docs, htmlview.Document{Name: f.path, Text: f.text, Chunks: fileChunks[i]})
+	}
+	for _, doc := range docs {
+		chunking.Stamp(doc.Chunks, clock)
+	}
+
+	if err := writeDocuments(os.Stdout, cfg, plan, docs); err != nil {
+		log.Fatalf("failed to encode chunks: %v", err)
 	}
 
-	text := string(input)
+	fmt.Fprintln(os.Stderr, "chunking completed")
+}
 
-	chunker := chunking.NewSlidingWindowChunker()
-	chunks, err := chunker.Chunk(text, plan, baseMeta)
+// cliPlan returns the plan given by --preset, --plan-json or --plan-file.
+// A plan given with --preset is applied over the preset.
+func cliPlan(cfg cliConfig) chunking.ChunkingPlan {
+	plan := chunking.ChunkingPlan{}
+	if cfg.Preset != "" {
+		preset, ok := chunking.Presets.Lookup(cfg.Preset)
+		if !ok {
+			log.Fatalf("unknown preset %q (available: %s)", cfg.Preset, strings.Join(chunking.Presets.Names(), ", "))
+		}
+		plan = preset
+	}
+	data := []byte(cfg.PlanJSON)
+	switch {
+	case cfg.PlanJSON != "" && cfg.PlanFile != "":
+		log.Fatalf("--plan-json and --plan-file are mutually exclusive")
+	case cfg.PlanFile != "":
+		var err error
+		if data, err = yamljson.ReadFile(cfg.PlanFile); err != nil {
+			log.Fatalf("failed to read plan-file: %v", err)
+		}
+	case cfg.PlanJSON == "":
+		if cfg.Preset == "" {
+			log.Fatalf("missing required --plan-json, --plan-file or --preset argument")
+		}
+		return plan
+	}
+	if err := json.Unmarshal(data, &plan); err != nil {
+		log.Fatalf("invalid plan: %v", err)
+	}
+	return plan
+}
+
+// chunkDocument chunks one document, applying the preset routed to meta
+// when the plan names no strategy.
+func chunkDocument(chunker chunking.Chunker, routing *chunking.Routing, text string, plan chunking.ChunkingPlan, meta map[string]interface{}) []chunking.Chunk {
+	chunks, err := chunkText(chunker, routing, text, plan, meta)
 	if err != nil {
 		// While the actual chunking is not implemented, make the error
 		// explicit to callers.
-		if err == chunking.ErrNotImplemented {
+		if errors.Is(err, chunking.ErrNotImplemented) {
 			log.Fatalf("chunker not implemented: %v", err)
 		}
-		log.Fatalf("chunker error: %v", err)
+		log.Fatalf("%v", err)
+	}
+	return chunks
+}
+
+// chunkText is chunkDocument returning its error, for callers that
+// carry on after a failed document. Input that looks like concatenated
+// documents is reported on stderr.
+func chunkText(chunker chunking.Chunker, routing *chunking.Routing, text string, plan chunking.ChunkingPlan, meta map[string]interface{}) ([]chunking.Chunk, error) {
+	if routing != nil && plan.Strategy == "" {
+		if _, err := routing.Apply(&plan, meta); err != nil {
+			return nil, fmt.Errorf("invalid routing-json: %w", err)
+		}
+	}
+	chunks, err := chunker.Chunk(text, plan, meta)
+	if err != nil {
+		if path, ok := meta["file_path"].(string); ok {
+			return nil, fmt.Errorf("chunker error: %s: %w", path, err)
+		}
+		return nil, fmt.Errorf("chunker error: %w", err)
+	}
+	if len(chunks) > 0 {
+		if docs, ok := chunks[0].Extra["concatenated_documents"].(int); ok {
+			name, ok := meta["file_path"].(string)
+			if !ok {
+				name = "stdin"
+			}
+			log.Printf("warning: %s looks like %d concatenated documents whose chunks mix; chunk them separately or set \"concatenated\": \"split\" in the plan", name, docs)
+		}
 	}
+	return chunks, nil
+}
 
-	// Ensure all chunks have basic metadata fields populated where possible.
-	for i := range chunks {
-		if chunks[i].CreatedAt.IsZero() {
-			chunks[i].CreatedAt = time.Now().UTC()
+// cliRouting parses --routing-json, or returns nil when it is not set.
+func cliRouting(cfg cliConfig) *chunking.Routing {
+	if cfg.Routing == "" {
+		return nil
+	}
+	var routing chunking.Routing
+	if err := json.Unmarshal([]byte(cfg.Routing), &routing); err != nil {
+		log.Fatalf("invalid routing-json: %v", err)
+	}
+	if err := routing.Validate(); err != nil {
+		log.Fatalf("invalid routing-json: %v", err)
+	}
+	return &routing
+}
+
+// cliChunker returns the chunker for all plan strategies; semantic plans
+// need --embedding-url and send CHUNKER_EMBEDDING_TOKEN as a bearer token.
+// Every worker shares the one client, and so its rate limiter.
+func cliChunker(cfg cliConfig) chunking.Chunker {
+	strategies := chunking.Strategies{chunking.StrategySliding: chunking.NewSlidingWindowChunker()}
+	if cfg.Embedding != "" {
+		client := &embedding.Client{
+			URL:    cfg.Embedding,
+			Model:  os.Getenv("CHUNKER_EMBEDDING_MODEL"),
+			Token:  os.Getenv("CHUNKER_EMBEDDING_TOKEN"),
+			Limits: cfg.EmbeddingLimits,
+		}
+		if err := cfg.EmbeddingRate.Validate(); err != nil {
+			log.Fatalf("%v", err)
+		}
+		if cfg.EmbeddingRate != (embedding.RateLimit{}) {
+			client.Limiter = embedding.NewLimiter(cfg.EmbeddingRate)
 		}
+		if cfg.EmbeddingCacheURL != "" {
+			store, err := jobs.NewRedis(cfg.EmbeddingCacheURL, 0)
+			if err != nil {
+				log.Fatalf("%v", err)
+			}
+			store.Prefix = "chunker:embed:"
+			cache := embedding.NewRedisCache(store)
+			cache.OnError = func(err error) { log.Printf("embedding cache failed: %v", err) }
+			client.Cache = cache
+		} else if cfg.EmbeddingCache > 0 {
+			client.Cache = embedding.NewMemoryCache(cfg.EmbeddingCache)
+		}
+		strategies[chunking.StrategySemantic] = &chunking.SemanticChunker{Embedder: client}
 	}
+	return strategies
+}
 
-	enc := json.NewEncoder(os.Stdout)
-	if err := enc.Encode(chunks); err != nil {
-		log.Fatalf("failed to encode chunks: %v", err)
+// envInt returns the integer in the environment variable name, or 0 when
+// it is unset.
+func envInt(name string) int {
+	v := os.Getenv(name)
+	if v == "" {
+		return 0
+	}
+	n, err := strconv.Atoi(v)
+	if err != nil {
+		log.Fatalf("invalid %s: %v", name, err)
 	}
+	return n
+}
 
-	fmt.Fprintln(os.Stderr, "chunking completed")
+// cliClock returns the clock used for chunk timestamps: fixed when
+// --created-at is given, the system clock otherwise.
+func cliClock(cfg cliConfig) chunking.Clock {
+	if cfg.CreatedAt == "" {
+		return chunking.SystemClock{}
+	}
+	t, err := time.Parse(time.RFC3339, cfg.CreatedAt)
+	if err != nil {
+		log.Fatalf("invalid created-at: %v", err)
+	}
+	return chunking.FixedClock(t.UTC())
 }
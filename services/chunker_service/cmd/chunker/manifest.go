@@ -0,0 +1,127 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"sort"
+	"time"
+
+	"chunker-service/pkg/pipeline"
+	"chunker-service/pkg/retry"
+)
+
+// runManifest executes a corpus manifest end-to-end, writing chunks to the
+// sinks it declares and a summary to stderr.
+func runManifest(cfg cliConfig) {
+	m, err := pipeline.LoadManifest(cfg.Manifest)
+	if err != nil {
+		log.Fatalf("invalid manifest: %v", err)
+	}
+
+	if cfg.CreatedAt != "" {
+		createdAt := cliClock(cfg).Now()
+		m.CreatedAt = &createdAt
+	}
+
+	if routing := cliRouting(cfg); routing != nil {
+		m.Routing = routing
+	}
+
+	if cliSampling(cfg) {
+		m.Sample = &cfg.Sample
+	}
+
+	runner := pipeline.NewRunner()
+	runner.Chunker = cliChunker(cfg)
+	checkpoint := cfg.Checkpoint
+	if checkpoint == "" {
+		checkpoint = m.CheckpointPath()
+	}
+	if checkpoint != "" {
+		cp, err := pipeline.OpenCheckpoint(checkpoint)
+		if err != nil {
+			log.Fatalf("failed to open checkpoint: %v", err)
+		}
+		defer cp.Close()
+		runner.Checkpoint = cp
+	}
+	deadLetter := cfg.DeadLetter
+	if deadLetter == "" {
+		deadLetter = m.DeadLetterPath()
+	}
+	if deadLetter != "" {
+		dl, err := pipeline.NewDirDeadLetter(deadLetter)
+		if err != nil {
+			log.Fatalf("failed to open dead-letter directory: %v", err)
+		}
+		runner.DeadLetter = dl
+	}
+
+	if cfg.Repair {
+		report, err := runner.Repair(m)
+		if err != nil {
+			log.Fatalf("manifest repair failed after %d documents: %v", report.Documents, err)
+		}
+		fmt.Fprintf(os.Stderr, "manifest %s repaired: %d documents\n", m.Name, report.Documents)
+		printSinkReports(report)
+		return
+	}
+
+	if cfg.Purge {
+		report, err := runner.Purge(m)
+		for _, sr := range report.Sinks {
+			fmt.Fprintf(os.Stderr, "sink %s: %d chunks deleted, %d failed\n", sr.Name, sr.Chunks, sr.Failed)
+		}
+		if err != nil {
+			log.Fatalf("manifest purge failed: %v", err)
+		}
+		fmt.Fprintf(os.Stderr, "manifest %s purged: %d expired chunks\n", m.Name, report.Expired)
+		return
+	}
+
+	runner.Progress = pipeline.NewProgress(0, time.Now())
+	stopProgress := startProgress(cfg, runner.Progress)
+	report, err := runner.Run(m)
+	stopProgress()
+	if err != nil {
+		printSinkReports(report)
+		log.Fatalf("manifest run failed after %d documents: %v", report.Documents, err)
+	}
+	fmt.Fprintf(os.Stderr, "manifest %s completed: %d documents (%d unchanged, skipped), %d chunks\n",
+		m.Name, report.Documents, report.Skipped, report.Chunks)
+	if report.Sample != nil {
+		printSampleReport(*report.Sample)
+	}
+	printSinkReports(report)
+	if q := report.Queue; q != nil {
+		fmt.Fprintf(os.Stderr, "queue: depth %.2f avg, %d max of %d; %d batches of %.2f documents avg; chunking blocked %.2fs\n",
+			q.AvgDepth, q.MaxDepth, q.Capacity, q.Batches, q.AvgBatch, q.BlockedSeconds)
+	}
+	stats := retry.Snapshot()
+	names := make([]string, 0, len(stats))
+	for name := range stats {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		st := stats[name]
+		if st.Retries > 0 || st.Failures > 0 {
+			fmt.Fprintf(os.Stderr, "%s: %d retries, %d failed calls, %d breaker opens\n",
+				name, st.Retries, st.Failures, st.BreakerOpens)
+		}
+	}
+	if report.Failed > 0 {
+		fmt.Fprintf(os.Stderr, "%d documents failed, see %s\n", report.Failed, deadLetter)
+		os.Exit(1)
+	}
+}
+
+// printSinkReports writes one line per sink so divergence between fan-out
+// sinks is visible at the end of a run.
+func printSinkReports(report pipeline.Report) {
+	for _, sr := range report.Sinks {
+		fmt.Fprintf(os.Stderr, "sink %s: %d documents, %d chunks, %d failed\n",
+			sr.Name, sr.Documents, sr.Chunks, sr.Failed)
+	}
+}
@@ -0,0 +1,159 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+
+	"chunker-service/pkg/arrowipc"
+	"chunker-service/pkg/chunking"
+	"chunker-service/pkg/csvout"
+	"chunker-service/pkg/htmlview"
+	"chunker-service/pkg/sqlout"
+)
+
+// outputExtensions maps each --format to the extension of its
+// --output-dir files.
+var outputExtensions = map[string]string{
+	"json":     ".json",
+	"":         ".json",
+	"jsonl":    ".jsonl",
+	"csv":      ".csv",
+	"arrow":    ".arrow",
+	"sql":      ".sql",
+	"sql-copy": ".sql",
+	"html":     ".html",
+}
+
+// writeChunks encodes chunks to w in the --format format.
+func writeChunks(w io.Writer, cfg cliConfig, chunks []chunking.Chunk) error {
+	switch cfg.Format {
+	case "arrow":
+		return arrowipc.WriteChunks(w, chunks)
+	case "sql", "sql-copy":
+		table := sqlout.Table{Name: cfg.SQLTable}
+		if cfg.Format == "sql-copy" {
+			table.Mode = sqlout.ModeCopy
+		}
+		return table.Write(w, chunks)
+	case "csv":
+		return csvout.Write(w, chunks, csvout.ParseColumns(cfg.CSVColumns))
+	case "json", "":
+		return json.NewEncoder(w).Encode(chunks)
+	case "jsonl":
+		bw := bufio.NewWriter(w)
+		enc := json.NewEncoder(bw)
+		for _, ch := range chunks {
+			if err := enc.Encode(ch); err != nil {
+				return err
+			}
+		}
+		return bw.Flush()
+	}
+	return fmt.Errorf("unsupported format %q", cfg.Format)
+}
+
+// writeDocuments writes the chunks of docs to w in the --format format.
+// html draws them over the text of each document, on one page, with the
+// headings plan detects marked; the other formats list them.
+func writeDocuments(w io.Writer, cfg cliConfig, plan chunking.ChunkingPlan, docs []htmlview.Document) error {
+	if cfg.Format != "html" {
+		var chunks []chunking.Chunk
+		for _, doc := range docs {
+			chunks = append(chunks, doc.Chunks...)
+		}
+		return writeChunks(w, cfg, chunks)
+	}
+	for i := range docs {
+		headings, err := chunking.DetectHeadings(docs[i].Text, plan.HeadingLanguages)
+		if err != nil {
+			return err
+		}
+		docs[i].Headings = headings
+	}
+	return htmlview.Write(w, docs)
+}
+
+// outputPaths returns where --output-dir puts the chunks of each file: its
+// path relative to --dir, or as given for file arguments, with the
+// format's extension appended. Absolute and parent-relative arguments
+// cannot be mirrored, and two files may not map to the same output.
+func outputPaths(cfg cliConfig, files []cliFile) []string {
+	out := make([]string, len(files))
+	seen := map[string]string{}
+	for i, f := range files {
+		rel := filepath.FromSlash(f.rel)
+		if !filepath.IsLocal(rel) {
+			log.Fatalf("cannot mirror %s under --output-dir: use a path inside the working directory, or --dir", f.path)
+		}
+		out[i] = filepath.Join(cfg.OutputDir, rel+outputExtensions[cfg.Format])
+		if prev, ok := seen[out[i]]; ok {
+			log.Fatalf("%s and %s would both be written to %s", prev, f.path, out[i])
+		}
+		seen[out[i]] = f.path
+	}
+	return out
+}
+
+// writeOutputDir writes each file's chunks to its output path.
+func writeOutputDir(cfg cliConfig, plan chunking.ChunkingPlan, paths []string, files []cliFile, chunks [][]chunking.Chunk) {
+	for i, path := range paths {
+		doc := htmlview.Document{Name: files[i].path, Text: files[i].text, Chunks: chunks[i]}
+		if err := writeOutputFile(cfg, plan, path, doc); err != nil {
+			log.Fatalf("%v", err)
+		}
+	}
+	fmt.Fprintf(os.Stderr, "wrote %d files to %s\n", len(paths), cfg.OutputDir)
+}
+
+// writeOutputFile replaces the file at path with the chunks of doc,
+// creating its directory if needed.
+func writeOutputFile(cfg cliConfig, plan chunking.ChunkingPlan, path string, doc htmlview.Document) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return fmt.Errorf("failed to create output directory: %w", err)
+	}
+	f, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("failed to create output file: %w", err)
+	}
+	err = writeDocuments(f, cfg, plan, []htmlview.Document{doc})
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	return nil
+}
+
+// dryRunLine is the --dry-run report of one document; Path is empty for
+// stdin.
+type dryRunLine struct {
+	Path string `json:"path,omitempty"`
+	chunking.ChunkStats
+}
+
+// printDryRun writes a JSON line of chunk statistics per document to
+// stdout and their totals to stderr, counting tokens with the plan's
+// tokenizer.
+func printDryRun(plan chunking.ChunkingPlan, paths []string, chunks [][]chunking.Chunk) {
+	tok, err := chunking.PlanTokenizer(plan)
+	if err != nil {
+		log.Fatalf("%v", err)
+	}
+	enc := json.NewEncoder(os.Stdout)
+	stats := make([]chunking.ChunkStats, len(chunks))
+	for i := range chunks {
+		stats[i] = chunking.Stats(chunks[i], tok)
+		if err := enc.Encode(dryRunLine{Path: paths[i], ChunkStats: stats[i]}); err != nil {
+			log.Fatalf("failed to write statistics: %v", err)
+		}
+	}
+	t := chunking.MergeStats(stats)
+	fmt.Fprintf(os.Stderr, "dry run: %d documents, %d chunks; %d-%d tokens (avg %.2f), %d-%d characters (avg %.2f); overlap ratio %.2f\n",
+		len(chunks), t.Chunks, t.MinTokens, t.MaxTokens, t.AvgTokens, t.MinChars, t.MaxChars, t.AvgChars, t.OverlapRatio)
+}
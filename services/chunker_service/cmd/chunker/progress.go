@@ -0,0 +1,71 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"chunker-service/pkg/pipeline"
+)
+
+// Progress is redrawn in place as a bar this often when stderr is a
+// terminal, and written as a new line every progressLineInterval
+// otherwise, so logs stay short.
+const (
+	progressBarInterval  = 200 * time.Millisecond
+	progressLineInterval = 10 * time.Second
+	progressBarWidth     = 30
+)
+
+// startProgress reports p on stderr until the returned function is
+// called, which writes the final state. Runs that finish before the first
+// report print nothing, and --quiet turns reporting off.
+func startProgress(cfg cliConfig, p *pipeline.Progress) (stop func()) {
+	if cfg.Quiet {
+		return func() {}
+	}
+	info, err := os.Stderr.Stat()
+	tty := err == nil && info.Mode()&os.ModeCharDevice != 0
+	interval := progressLineInterval
+	if tty {
+		interval = progressBarInterval
+	}
+
+	done := make(chan struct{})
+	finished := make(chan struct{})
+	go func() {
+		defer close(finished)
+		t := time.NewTicker(interval)
+		defer t.Stop()
+		reported := false
+		report := func() {
+			reported = true
+			if !tty {
+				fmt.Fprintf(os.Stderr, "progress: %s\n", p.Line(time.Now()))
+				return
+			}
+			filled := int(p.Fraction() * progressBarWidth)
+			bar := strings.Repeat("=", filled) + strings.Repeat(" ", progressBarWidth-filled)
+			fmt.Fprintf(os.Stderr, "\r\033[K[%s] %s", bar, p.Line(time.Now()))
+		}
+		for {
+			select {
+			case <-t.C:
+				report()
+			case <-done:
+				if reported {
+					report()
+					if tty {
+						fmt.Fprintln(os.Stderr)
+					}
+				}
+				return
+			}
+		}
+	}()
+	return func() {
+		close(done)
+		<-finished
+	}
+}
@@ -0,0 +1,76 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+
+	"chunker-service/pkg/audit"
+	"chunker-service/pkg/tokenizer"
+)
+
+// runReplay implements "chunker replay": it re-executes the operations in
+// one or more audit logs with the current code, prints a JSON line for
+// every operation whose chunks changed or that now fails, and exits
+// non-zero if there were any.
+func runReplay(args []string) {
+	fs := flag.NewFlagSet("replay", flag.ExitOnError)
+	var cfg cliConfig
+	all := fs.Bool("all", false, "print identical operations too")
+	fs.StringVar(&cfg.Tokenizers, "tokenizer-dir", "", "directory of tiktoken rank files and HuggingFace BPE vocabularies to load")
+	fs.StringVar(&cfg.Embedding, "embedding-url", os.Getenv("CHUNKER_EMBEDDING_URL"), "embedding service URL used by semantic plans")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "usage: chunker replay [flags] audit.jsonl... (- reads stdin)")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+	if fs.NArg() == 0 {
+		fs.Usage()
+		os.Exit(2)
+	}
+	if cfg.Tokenizers != "" {
+		if _, err := tokenizer.LoadDir(cfg.Tokenizers); err != nil {
+			log.Fatalf("failed to load tokenizers: %v", err)
+		}
+	}
+
+	chunker := cliChunker(cfg)
+	enc := json.NewEncoder(os.Stdout)
+	var total audit.Summary
+	for _, path := range fs.Args() {
+		var r io.Reader = os.Stdin
+		if path != "-" {
+			f, err := os.Open(path)
+			if err != nil {
+				log.Fatalf("failed to open audit log: %v", err)
+			}
+			defer f.Close()
+			r = f
+		}
+		sum, err := audit.Replay(r, chunker, func(res audit.Result) error {
+			if !*all && res.Error == "" && res.Comparison.Identical {
+				return nil
+			}
+			return enc.Encode(struct {
+				Log string `json:"log"`
+				audit.Result
+			}{path, res})
+		})
+		if err != nil {
+			log.Fatalf("%s: %v", path, err)
+		}
+		total.Replayed += sum.Replayed
+		total.Identical += sum.Identical
+		total.Changed += sum.Changed
+		total.Failed += sum.Failed
+	}
+
+	fmt.Fprintf(os.Stderr, "replayed %d operations: %d identical, %d changed, %d failed\n",
+		total.Replayed, total.Identical, total.Changed, total.Failed)
+	if total.Changed > 0 || total.Failed > 0 {
+		os.Exit(1)
+	}
+}
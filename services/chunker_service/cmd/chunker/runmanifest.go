@@ -0,0 +1,168 @@
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"time"
+
+	"chunker-service/pkg/chunking"
+	"chunker-service/pkg/htmlview"
+	"chunker-service/pkg/pipeline"
+	"chunker-service/pkg/tokenizer"
+)
+
+// newRunManifest starts the run manifest of a run with the given plan and
+// created_at; its inputs are added with runInput.
+func newRunManifest(cfg cliConfig, plan chunking.ChunkingPlan, routing *chunking.Routing, createdAt time.Time) *pipeline.RunManifest {
+	m := &pipeline.RunManifest{
+		Version:      pipeline.RunManifestVersion,
+		CodeVersion:  pipeline.CodeVersion(),
+		CreatedAt:    createdAt,
+		Plan:         plan,
+		PlanHash:     pipeline.PlanHash(plan),
+		Routing:      routing,
+		Tokenizers:   map[string]string{},
+		TokenizerDir: cfg.Tokenizers,
+		Format:       cfg.Format,
+	}
+	for _, name := range tokenizer.Names() {
+		m.Tokenizers[name] = tokenizer.Digest(name)
+	}
+	if cliSampling(cfg) {
+		sample := cfg.Sample
+		m.Sample = &sample
+	}
+	switch cfg.Format {
+	case "sql", "sql-copy":
+		m.FormatOptions = map[string]string{"sql_table": cfg.SQLTable}
+	case "csv":
+		m.FormatOptions = map[string]string{"csv_columns": cfg.CSVColumns}
+	}
+	return m
+}
+
+// runInput records one document of m: the hash of its text, the plan
+// routing gave it and the hash of its chunks written in the run's format.
+func runInput(cfg cliConfig, m *pipeline.RunManifest, path, text string, meta map[string]interface{}, chunks []chunking.Chunk) (pipeline.RunInput, error) {
+	in := pipeline.RunInput{Path: path, Hash: pipeline.ContentHash([]byte(text)), Meta: meta, Chunks: len(chunks)}
+	if m.Routing != nil && m.Plan.Strategy == "" {
+		plan := m.Plan
+		if _, err := m.Routing.Apply(&plan, meta); err != nil {
+			return in, err
+		}
+		if h := pipeline.PlanHash(plan); h != m.PlanHash {
+			in.PlanHash = h
+		}
+	}
+	var out bytes.Buffer
+	doc := htmlview.Document{Name: path, Text: text, Chunks: chunks}
+	if err := writeDocuments(&out, cfg, m.Plan, []htmlview.Document{doc}); err != nil {
+		return in, err
+	}
+	in.OutputHash = pipeline.ContentHash(out.Bytes())
+	return in, nil
+}
+
+// saveRunManifest adds the run's documents to m and writes it to
+// --run-manifest.
+func saveRunManifest(cfg cliConfig, m *pipeline.RunManifest, stdin []byte, stdinChunks []chunking.Chunk, files []cliFile, fileChunks [][]chunking.Chunk, baseMeta map[string]interface{}) {
+	add := func(path, text string, meta map[string]interface{}, chunks []chunking.Chunk) {
+		in, err := runInput(cfg, m, path, text, meta, chunks)
+		if err != nil {
+			log.Fatalf("failed to record run manifest: %v", err)
+		}
+		m.Inputs = append(m.Inputs, in)
+	}
+	if stdin != nil {
+		add("", string(stdin), baseMeta, stdinChunks)
+	}
+	for i, f := range files {
+		add(f.path, f.text, f.meta, fileChunks[i])
+	}
+	if err := m.Save(cfg.RunManifest); err != nil {
+		log.Fatalf("failed to write run manifest: %v", err)
+	}
+}
+
+// runReproduce implements "chunker reproduce": it repeats the run a run
+// manifest records with the current code and inputs, reports every
+// document that changed or now chunks differently, and exits non-zero if
+// there were any.
+func runReproduce(args []string) {
+	fs := flag.NewFlagSet("reproduce", flag.ExitOnError)
+	var cfg cliConfig
+	fs.StringVar(&cfg.Tokenizers, "tokenizer-dir", "", "directory of tiktoken rank files and HuggingFace BPE vocabularies to load (default: the manifest's tokenizer_dir)")
+	fs.StringVar(&cfg.Embedding, "embedding-url", os.Getenv("CHUNKER_EMBEDDING_URL"), "embedding service URL used by semantic plans")
+	fs.IntVar(&cfg.EmbeddingRate.RequestsPerMinute, "embedding-rpm", envInt("CHUNKER_EMBEDDING_RPM"), "embedding requests per minute (default: no limit)")
+	fs.IntVar(&cfg.EmbeddingRate.TokensPerMinute, "embedding-tpm", envInt("CHUNKER_EMBEDDING_TPM"), "embedding tokens per minute, counted as words (default: no limit)")
+	fs.IntVar(&cfg.EmbeddingLimits.MaxTokensPerRequest, "embedding-max-tokens", envInt("CHUNKER_EMBEDDING_MAX_TOKENS"), "embedding tokens per request, counted as words (default: no limit)")
+	fs.IntVar(&cfg.EmbeddingLimits.MaxTokensPerItem, "embedding-max-item-tokens", envInt("CHUNKER_EMBEDDING_MAX_ITEM_TOKENS"), "embedding tokens per text, counted as words (default: no limit)")
+	fs.IntVar(&cfg.EmbeddingCache, "embedding-cache-size", envInt("CHUNKER_EMBEDDING_CACHE_SIZE"), "embedded texts to keep in memory so repeated texts are not embedded again (default: no cache)")
+	fs.StringVar(&cfg.EmbeddingCacheURL, "embedding-cache-url", os.Getenv("CHUNKER_EMBEDDING_CACHE_URL"), "redis URL to cache embedded texts in (overrides --embedding-cache-size)")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "usage: chunker reproduce [flags] run-manifest.json")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+	if fs.NArg() != 1 {
+		fs.Usage()
+		os.Exit(2)
+	}
+	want, err := pipeline.LoadRunManifest(fs.Arg(0))
+	if err != nil {
+		log.Fatalf("%v", err)
+	}
+	if cfg.Tokenizers == "" {
+		cfg.Tokenizers = want.TokenizerDir
+	}
+	if cfg.Tokenizers != "" {
+		if _, err := tokenizer.LoadDir(cfg.Tokenizers); err != nil {
+			log.Fatalf("failed to load tokenizers: %v", err)
+		}
+	}
+	cfg.Format = want.Format
+	cfg.SQLTable = want.FormatOptions["sql_table"]
+	cfg.CSVColumns = want.FormatOptions["csv_columns"]
+
+	chunker := cliChunker(cfg)
+	got := newRunManifest(cfg, want.Plan, want.Routing, want.CreatedAt)
+	clock := chunking.FixedClock(want.CreatedAt)
+	for _, in := range want.Inputs {
+		var data []byte
+		if in.Path == "" {
+			data, err = io.ReadAll(os.Stdin)
+		} else {
+			data, err = os.ReadFile(in.Path)
+		}
+		if err != nil {
+			log.Fatalf("failed to read input: %v", err)
+		}
+		chunks, err := chunkText(chunker, want.Routing, string(data), want.Plan, in.Meta)
+		if err != nil {
+			log.Fatalf("%v", err)
+		}
+		chunking.Stamp(chunks, clock)
+		rec, err := runInput(cfg, got, in.Path, string(data), in.Meta, chunks)
+		if err != nil {
+			log.Fatalf("%v", err)
+		}
+		got.Inputs = append(got.Inputs, rec)
+	}
+
+	warnings, differences := want.Compare(got)
+	for _, w := range warnings {
+		fmt.Fprintf(os.Stderr, "warning: %s\n", w)
+	}
+	for _, d := range differences {
+		fmt.Println(d)
+	}
+	if len(differences) > 0 {
+		fmt.Fprintf(os.Stderr, "not reproduced: %d differences\n", len(differences))
+		os.Exit(1)
+	}
+	fmt.Fprintf(os.Stderr, "reproduced %d inputs\n", len(want.Inputs))
+}
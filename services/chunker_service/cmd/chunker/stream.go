@@ -0,0 +1,131 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"strconv"
+	"strings"
+
+	"chunker-service/pkg/chunking"
+	"chunker-service/pkg/htmlview"
+)
+
+// maxStreamRecord caps the size of one --stream record.
+const maxStreamRecord = 64 << 20
+
+// streamRecord is one line of --stream ndjson input. Meta overlays
+// --meta-json for the record's chunks.
+type streamRecord struct {
+	Text string                 `json:"text"`
+	Meta map[string]interface{} `json:"meta"`
+}
+
+// checkStream rejects flag combinations --stream cannot serve: it reads
+// only stdin and writes each record's chunks as soon as they are cut.
+func checkStream(cfg cliConfig, paths []string) {
+	switch {
+	case cfg.Stream != "ndjson" && cfg.Stream != "records":
+		log.Fatalf("unsupported stream %q: use ndjson or records", cfg.Stream)
+	case len(paths) > 0 || cfg.Dir != "":
+		log.Fatalf("--stream reads stdin and cannot be combined with file arguments or --dir")
+	case cfg.Format != "jsonl":
+		log.Fatalf("--stream writes chunks as they are cut and needs --format jsonl")
+	case cfg.DryRun || cfg.RunManifest != "" || cliSampling(cfg):
+		log.Fatalf("--stream cannot be combined with --dry-run, --run-manifest or --sample-*")
+	}
+}
+
+// recordSeparator decodes --record-separator, which may use Go string
+// escapes such as \n and \x1e.
+func recordSeparator(cfg cliConfig) []byte {
+	sep, err := strconv.Unquote(`"` + strings.ReplaceAll(cfg.RecordSeparator, `"`, `\"`) + `"`)
+	if err != nil || sep == "" {
+		log.Fatalf("invalid --record-separator %q", cfg.RecordSeparator)
+	}
+	return []byte(sep)
+}
+
+// splitRecords is a bufio.SplitFunc returning the records between
+// occurrences of sep.
+func splitRecords(sep []byte) bufio.SplitFunc {
+	return func(data []byte, atEOF bool) (int, []byte, error) {
+		if i := bytes.Index(data, sep); i >= 0 {
+			return i + len(sep), data[:i], nil
+		}
+		if atEOF && len(data) > 0 {
+			return len(data), data, nil
+		}
+		return 0, nil, nil
+	}
+}
+
+// streamStdin implements --stream: it reads stdin one record at a time,
+// as NDJSON documents or text records split at --record-separator,
+// chunks each record as its own document and writes its chunks before
+// reading the next one.
+func streamStdin(cfg cliConfig, chunker chunking.Chunker, routing *chunking.Routing, plan chunking.ChunkingPlan, baseMeta map[string]interface{}) {
+	records, total, err := streamRecords(os.Stdin, os.Stdout, cfg, chunker, routing, plan, baseMeta)
+	if err != nil {
+		log.Fatalf("%v", err)
+	}
+	fmt.Fprintf(os.Stderr, "streamed %d records: %d chunks\n", records, total)
+}
+
+// streamRecords chunks the records of r to w in order and returns how
+// many records and chunks it wrote. Blank records are skipped. Each chunk
+// records its record's position in the input, counting from 0, in
+// Extra["record"]. It stops at the first record that fails, once the
+// chunks of the records before it are written.
+func streamRecords(r io.Reader, w io.Writer, cfg cliConfig, chunker chunking.Chunker, routing *chunking.Routing, plan chunking.ChunkingPlan, baseMeta map[string]interface{}) (records, total int, err error) {
+	sc := bufio.NewScanner(r)
+	sc.Buffer(make([]byte, 0, 64<<10), maxStreamRecord)
+	if cfg.Stream == "records" {
+		sc.Split(splitRecords(recordSeparator(cfg)))
+	}
+	clock := cliClock(cfg)
+	for n := 0; sc.Scan(); n++ {
+		text := sc.Text()
+		var recordMeta map[string]interface{}
+		if cfg.Stream == "ndjson" {
+			if strings.TrimSpace(text) == "" {
+				continue
+			}
+			var rec streamRecord
+			if err := json.Unmarshal(sc.Bytes(), &rec); err != nil {
+				return records, total, fmt.Errorf("record %d: invalid JSON: %w", n, err)
+			}
+			text, recordMeta = rec.Text, rec.Meta
+		}
+		if strings.TrimSpace(text) == "" {
+			continue
+		}
+		meta := make(map[string]interface{}, len(baseMeta)+len(recordMeta)+1)
+		for k, v := range baseMeta {
+			meta[k] = v
+		}
+		for k, v := range recordMeta {
+			meta[k] = v
+		}
+		meta["record"] = n
+
+		chunks, err := chunkText(chunker, routing, text, plan, meta)
+		if err != nil {
+			return records, total, fmt.Errorf("record %d: %w", n, err)
+		}
+		chunking.Stamp(chunks, clock)
+		if err := writeDocuments(w, cfg, plan, []htmlview.Document{{Text: text, Chunks: chunks}}); err != nil {
+			return records, total, fmt.Errorf("failed to encode chunks: %w", err)
+		}
+		records++
+		total += len(chunks)
+	}
+	if err := sc.Err(); err != nil {
+		return records, total, fmt.Errorf("failed to read stdin: %w", err)
+	}
+	return records, total, nil
+}
@@ -0,0 +1,84 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+
+	"chunker-service/pkg/chunking"
+)
+
+// streamedChunks decodes --stream output into "record:text" lines.
+func streamedChunks(t *testing.T, out *bytes.Buffer) []string {
+	t.Helper()
+	var got []string
+	sc := bufio.NewScanner(out)
+	for sc.Scan() {
+		var c chunking.Chunk
+		if err := json.Unmarshal(sc.Bytes(), &c); err != nil {
+			t.Fatalf("invalid chunk line %q: %v", sc.Text(), err)
+		}
+		got = append(got, fmt.Sprintf("%v:%s", c.Extra["record"], c.Text))
+	}
+	return got
+}
+
+func TestStreamRecordsOrder(t *testing.T) {
+	plan := chunking.ChunkingPlan{WindowSize: 2, Mode: chunking.ModeTokens}
+	for _, tc := range []struct {
+		name  string
+		cfg   cliConfig
+		input string
+		want  string
+	}{
+		{
+			name:  "ndjson",
+			cfg:   cliConfig{Stream: "ndjson", Format: "jsonl"},
+			input: `{"text": "a b c"}` + "\n\n" + `{"text": "d e"}` + "\n" + `{"text": "  "}` + "\n" + `{"text": "f"}`,
+			want:  "0:a b|0:c|2:d e|4:f",
+		},
+		{
+			name:  "records",
+			cfg:   cliConfig{Stream: "records", RecordSeparator: `\x1e`, Format: "jsonl"},
+			input: "a b c\x1e\x1ed e\x1ef\x1e",
+			want:  "0:a b|0:c|2:d e|3:f",
+		},
+	} {
+		var out bytes.Buffer
+		records, total, err := streamRecords(strings.NewReader(tc.input), &out, tc.cfg, &chunking.SlidingWindowChunker{}, nil, plan, nil)
+		if err != nil {
+			t.Fatalf("%s: %v", tc.name, err)
+		}
+		if got := strings.Join(streamedChunks(t, &out), "|"); got != tc.want {
+			t.Errorf("%s: streamed %q, want %q", tc.name, got, tc.want)
+		}
+		if records != 3 || total != 4 {
+			t.Errorf("%s: counted %d records and %d chunks, want 3 and 4", tc.name, records, total)
+		}
+	}
+}
+
+func TestStreamRecordsMidStreamError(t *testing.T) {
+	plan := chunking.ChunkingPlan{WindowSize: 2, Mode: chunking.ModeTokens}
+	cfg := cliConfig{Stream: "ndjson", Format: "jsonl"}
+	chunker := &chunking.SlidingWindowChunker{Limits: chunking.OutputLimits{MaxChunks: 2}}
+	for _, tc := range []struct {
+		name, input, err string
+	}{
+		{"invalid json", `{"text": "a b"}` + "\n" + `{"text": "c"}` + "\n" + `{"text": ` + "\n" + `{"text": "d"}`, "record 2: invalid JSON"},
+		{"chunking fails", `{"text": "a b"}` + "\n" + `{"text": "c"}` + "\n" + `{"text": "1 2 3 4 5 6"}` + "\n" + `{"text": "d"}`, "record 2: chunker error"},
+	} {
+		var out bytes.Buffer
+		records, total, err := streamRecords(strings.NewReader(tc.input), &out, cfg, chunker, nil, plan, nil)
+		if err == nil || !strings.HasPrefix(err.Error(), tc.err) {
+			t.Errorf("%s: got %v, want %q", tc.name, err, tc.err)
+		}
+		// The records before the failure are already out; none after it.
+		if got := strings.Join(streamedChunks(t, &out), "|"); got != "0:a b|1:c" || records != 2 || total != 2 {
+			t.Errorf("%s: streamed %q (%d records, %d chunks) before failing", tc.name, got, records, total)
+		}
+	}
+}
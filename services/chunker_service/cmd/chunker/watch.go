@@ -0,0 +1,164 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"log"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"sort"
+	"syscall"
+	"time"
+
+	"chunker-service/pkg/chunking"
+	"chunker-service/pkg/htmlview"
+	"chunker-service/pkg/pipeline"
+)
+
+// watcher keeps the output of --dir and the file arguments up to date,
+// re-chunking a file whenever its size or modification time changes. It
+// polls, like the server's catalog reload, so it needs no platform file
+// notification support and works on network and container mounts.
+type watcher struct {
+	cfg      cliConfig
+	chunker  chunking.Chunker
+	routing  *chunking.Routing
+	plan     chunking.ChunkingPlan
+	baseMeta map[string]interface{}
+	paths    []string
+
+	// seen holds the last state of every file by its relative path.
+	seen map[string]watchedFile
+}
+
+type watchedFile struct {
+	size int64
+	mod  time.Time
+	hash string
+	path string
+}
+
+// checkWatch rejects flag combinations --watch cannot keep up to date.
+func checkWatch(cfg cliConfig, paths []string) {
+	switch {
+	case len(paths) == 0 && cfg.Dir == "":
+		log.Fatalf("--watch needs file arguments or --dir")
+	case cfg.OutputDir == "" && cfg.Format != "jsonl":
+		log.Fatalf("--watch writes to stdout only with --format jsonl; use --output-dir for other formats")
+	case cliSampling(cfg):
+		log.Fatalf("--watch cannot be combined with --sample-*")
+	case cfg.WatchInterval <= 0:
+		log.Fatalf("--watch-interval must be > 0")
+	}
+}
+
+// watch chunks every file, then every --watch-interval the files that
+// changed, until interrupted.
+func (w *watcher) watch() {
+	w.seen = map[string]watchedFile{}
+	w.poll()
+	fmt.Fprintf(os.Stderr, "watching for changes every %s\n", w.cfg.WatchInterval)
+
+	stop := make(chan os.Signal, 1)
+	signal.Notify(stop, os.Interrupt, syscall.SIGTERM)
+	t := time.NewTicker(w.cfg.WatchInterval)
+	defer t.Stop()
+	for {
+		select {
+		case <-stop:
+			fmt.Fprintln(os.Stderr, "watch stopped")
+			return
+		case <-t.C:
+			w.poll()
+		}
+	}
+}
+
+// files lists the file arguments that exist and the current text files
+// of --dir.
+func (w *watcher) files() []cliFile {
+	var files []cliFile
+	for _, path := range w.paths {
+		if _, err := os.Stat(path); err != nil {
+			if !errors.Is(err, fs.ErrNotExist) {
+				log.Printf("%s: %v", path, err)
+			}
+			continue
+		}
+		files = append(files, cliFile{path: path, rel: filepath.Clean(path), meta: fileMeta(path, w.baseMeta)})
+	}
+	if w.cfg.Dir != "" {
+		found, _, err := pipeline.WalkDir(w.cfg.Dir, pipeline.WalkOptions{Include: w.cfg.Include, Exclude: w.cfg.Exclude})
+		if err != nil {
+			log.Printf("failed to read dir: %v", err)
+		}
+		for _, f := range found {
+			files = append(files, cliFile{path: f.Path, rel: f.RelPath, meta: dirFileMeta(f, w.baseMeta)})
+		}
+	}
+	return files
+}
+
+// poll re-chunks new and changed files and drops the output of removed
+// ones. A file that fails is reported and retried once it changes again.
+func (w *watcher) poll() {
+	current := map[string]bool{}
+	for _, f := range w.files() {
+		current[f.rel] = true
+		info, err := os.Stat(f.path)
+		if err != nil {
+			continue
+		}
+		prev, ok := w.seen[f.rel]
+		if ok && prev.size == info.Size() && prev.mod.Equal(info.ModTime()) {
+			continue
+		}
+		data, err := os.ReadFile(f.path)
+		if err != nil {
+			log.Printf("failed to read %s: %v", f.path, err)
+			continue
+		}
+		state := watchedFile{size: info.Size(), mod: info.ModTime(), hash: pipeline.ContentHash(data), path: prev.path}
+		if w.cfg.OutputDir != "" {
+			state.path = outputPaths(w.cfg, []cliFile{f})[0]
+		}
+		w.seen[f.rel] = state
+		if ok && prev.hash == state.hash {
+			continue
+		}
+		chunks, err := chunkText(w.chunker, w.routing, string(data), w.plan, f.meta)
+		if err != nil {
+			log.Printf("%v", err)
+			continue
+		}
+		chunking.Stamp(chunks, cliClock(w.cfg))
+		if w.cfg.OutputDir != "" {
+			err = writeOutputFile(w.cfg, w.plan, state.path, htmlview.Document{Name: f.path, Text: string(data), Chunks: chunks})
+		} else {
+			err = writeChunks(os.Stdout, w.cfg, chunks)
+		}
+		if err != nil {
+			log.Fatalf("%v", err)
+		}
+		fmt.Fprintf(os.Stderr, "chunked %s: %d chunks\n", f.rel, len(chunks))
+	}
+
+	var removed []string
+	for rel := range w.seen {
+		if !current[rel] {
+			removed = append(removed, rel)
+		}
+	}
+	sort.Strings(removed)
+	for _, rel := range removed {
+		if path := w.seen[rel].path; path != "" {
+			if err := os.Remove(path); err != nil && !errors.Is(err, fs.ErrNotExist) {
+				log.Printf("failed to remove %s: %v", path, err)
+			}
+		}
+		delete(w.seen, rel)
+		fmt.Fprintf(os.Stderr, "removed %s\n", rel)
+	}
+}
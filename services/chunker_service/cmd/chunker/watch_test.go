@@ -0,0 +1,89 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"chunker-service/pkg/chunking"
+)
+
+func TestWatchPoll(t *testing.T) {
+	in, out := t.TempDir(), t.TempDir()
+	cfg := cliConfig{Dir: in, OutputDir: out, Format: "jsonl", CreatedAt: "2026-01-01T00:00:00Z"}
+	w := &watcher{
+		cfg:     cfg,
+		chunker: &chunking.SlidingWindowChunker{Limits: chunking.OutputLimits{MaxChunks: 3}},
+		plan:    chunking.ChunkingPlan{WindowSize: 2, Mode: chunking.ModeTokens},
+		seen:    map[string]watchedFile{},
+	}
+	src, dst := filepath.Join(in, "a.txt"), filepath.Join(out, "a.txt.jsonl")
+	mod := time.Now().Add(-time.Hour)
+	// write changes src and moves its modification time on, as an edit
+	// would, however fast the test runs.
+	write := func(text string) {
+		t.Helper()
+		if err := os.WriteFile(src, []byte(text), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		mod = mod.Add(time.Second)
+		if err := os.Chtimes(src, mod, mod); err != nil {
+			t.Fatal(err)
+		}
+	}
+	output := func() string {
+		t.Helper()
+		data, err := os.ReadFile(dst)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return string(data)
+	}
+
+	write("one two three four")
+	w.poll()
+	if got := strings.Count(output(), "\n"); got != 2 {
+		t.Fatalf("expected 2 chunks, got %d:\n%s", got, output())
+	}
+
+	write("five six")
+	w.poll()
+	if got := output(); !strings.Contains(got, "five six") || strings.Contains(got, "one two") {
+		t.Fatalf("expected the output rechunked, got:\n%s", got)
+	}
+
+	// A touch without an edit is not rechunked.
+	os.Remove(dst)
+	mod = mod.Add(time.Second)
+	os.Chtimes(src, mod, mod)
+	w.poll()
+	if _, err := os.Stat(dst); !os.IsNotExist(err) {
+		t.Fatalf("expected an unchanged file to be skipped, stat gave %v", err)
+	}
+	write("seven eight")
+	w.poll()
+
+	// A change that fails to chunk, here by passing the chunk limit,
+	// keeps the previous output until the file changes again.
+	write("a b c d e f g h")
+	w.poll()
+	if got := output(); !strings.Contains(got, "seven eight") {
+		t.Fatalf("expected the previous output kept, got:\n%s", got)
+	}
+	write("nine ten")
+	w.poll()
+	if got := output(); !strings.Contains(got, "nine ten") {
+		t.Fatalf("expected the fixed file chunked, got:\n%s", got)
+	}
+
+	os.Remove(src)
+	w.poll()
+	if _, err := os.Stat(dst); !os.IsNotExist(err) {
+		t.Errorf("expected the output of a removed file to be removed, stat gave %v", err)
+	}
+	if len(w.seen) != 0 {
+		t.Errorf("expected no files tracked, have %v", w.seen)
+	}
+}
@@ -0,0 +1,165 @@
+package arrowipc
+
+import (
+	"encoding/binary"
+	"sort"
+)
+
+// A minimal FlatBuffers encoder covering what Arrow IPC metadata needs:
+// tables, strings, vectors of tables and vectors of 16-byte structs.
+// Objects are laid out front to back: every table is preceded by its
+// vtable and followed by the objects it references, so all uoffsets point
+// forward as the format requires.
+
+type fbObject interface {
+	write(b *fbBuilder) int
+}
+
+type fbBuilder struct {
+	buf []byte
+}
+
+// finish serializes root and returns the finished buffer.
+func finish(root fbObject) []byte {
+	b := &fbBuilder{buf: make([]byte, 4, 256)}
+	pos := root.write(b)
+	binary.LittleEndian.PutUint32(b.buf[0:], uint32(pos))
+	return b.buf
+}
+
+// pad appends zero bytes until (len(buf)+extra) is a multiple of align.
+func (b *fbBuilder) pad(align, extra int) {
+	for (len(b.buf)+extra)%align != 0 {
+		b.buf = append(b.buf, 0)
+	}
+}
+
+func (b *fbBuilder) patch(slot, target int) {
+	binary.LittleEndian.PutUint32(b.buf[slot:], uint32(target-slot))
+}
+
+// fbField is one table field: inline little-endian scalar bytes, or a
+// reference to another object.
+type fbField struct {
+	scalar []byte
+	ref    fbObject
+}
+
+func fbInt8(v uint8) *fbField { return &fbField{scalar: []byte{v}} }
+func fbInt16(v int16) *fbField {
+	return &fbField{scalar: binary.LittleEndian.AppendUint16(nil, uint16(v))}
+}
+func fbInt32(v int32) *fbField {
+	return &fbField{scalar: binary.LittleEndian.AppendUint32(nil, uint32(v))}
+}
+func fbInt64(v int64) *fbField {
+	return &fbField{scalar: binary.LittleEndian.AppendUint64(nil, uint64(v))}
+}
+func fbRef(o fbObject) *fbField { return &fbField{ref: o} }
+
+func fbBool(v bool) *fbField {
+	if v {
+		return fbInt8(1)
+	}
+	return fbInt8(0)
+}
+
+// fbTable lists fields by field id; nil entries are absent.
+type fbTable []*fbField
+
+func (t fbTable) write(b *fbBuilder) int {
+	// Lay fields out largest first after the 4-byte vtable offset so each
+	// is naturally aligned within the 8-aligned table.
+	type placed struct {
+		id, size int
+	}
+	var order []placed
+	for id, f := range t {
+		if f == nil {
+			continue
+		}
+		size := 4
+		if f.ref == nil {
+			size = len(f.scalar)
+		}
+		order = append(order, placed{id, size})
+	}
+	sort.SliceStable(order, func(i, j int) bool { return order[i].size > order[j].size })
+
+	offsets := make([]int, len(t))
+	inline := 4
+	for _, p := range order {
+		for inline%p.size != 0 {
+			inline++
+		}
+		offsets[p.id] = inline
+		inline += p.size
+	}
+
+	vtableSize := 4 + 2*len(t)
+	b.pad(8, vtableSize)
+	vtable := len(b.buf)
+	b.buf = binary.LittleEndian.AppendUint16(b.buf, uint16(vtableSize))
+	b.buf = binary.LittleEndian.AppendUint16(b.buf, uint16(inline))
+	for _, off := range offsets {
+		b.buf = binary.LittleEndian.AppendUint16(b.buf, uint16(off))
+	}
+
+	table := len(b.buf)
+	b.buf = append(b.buf, make([]byte, inline)...)
+	binary.LittleEndian.PutUint32(b.buf[table:], uint32(table-vtable))
+	for id, f := range t {
+		if f != nil && f.ref == nil {
+			copy(b.buf[table+offsets[id]:], f.scalar)
+		}
+	}
+	for id, f := range t {
+		if f != nil && f.ref != nil {
+			slot := table + offsets[id]
+			b.patch(slot, f.ref.write(b))
+		}
+	}
+	return table
+}
+
+type fbString string
+
+func (s fbString) write(b *fbBuilder) int {
+	b.pad(4, 0)
+	pos := len(b.buf)
+	b.buf = binary.LittleEndian.AppendUint32(b.buf, uint32(len(s)))
+	b.buf = append(b.buf, s...)
+	b.buf = append(b.buf, 0)
+	return pos
+}
+
+// fbTables is a vector of tables.
+type fbTables []fbObject
+
+func (v fbTables) write(b *fbBuilder) int {
+	b.pad(4, 0)
+	pos := len(b.buf)
+	b.buf = binary.LittleEndian.AppendUint32(b.buf, uint32(len(v)))
+	slots := len(b.buf)
+	b.buf = append(b.buf, make([]byte, 4*len(v))...)
+	for i, o := range v {
+		b.patch(slots+4*i, o.write(b))
+	}
+	return pos
+}
+
+// fbPairs is a vector of structs made of two int64s (Arrow's FieldNode
+// and Buffer).
+type fbPairs [][2]int64
+
+func (v fbPairs) write(b *fbBuilder) int {
+	// Elements must be 8-aligned; the length prefix sits just before them.
+	b.pad(8, 4)
+	pos := len(b.buf)
+	b.buf = binary.LittleEndian.AppendUint32(b.buf, uint32(len(v)))
+	for _, p := range v {
+		b.buf = binary.LittleEndian.AppendUint64(b.buf, uint64(p[0]))
+		b.buf = binary.LittleEndian.AppendUint64(b.buf, uint64(p[1]))
+	}
+	return pos
+}
@@ -0,0 +1,313 @@
+// Package arrowipc encodes chunks as Apache Arrow record batches in the IPC
+// streaming format, so analytics engines (Spark, DuckDB, pandas) and bulk
+// embedding jobs can read chunker output without parsing JSON.
+package arrowipc
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"io"
+
+	"chunker-service/pkg/chunking"
+)
+
+// ContentType is the media type of an Arrow IPC stream.
+const ContentType = "application/vnd.apache.arrow.stream"
+
+// DefaultBatchSize is the number of chunks per record batch when a Writer
+// is created with a non-positive batch size.
+const DefaultBatchSize = 1024
+
+// Arrow metadata enums (see Arrow's Schema.fbs and Message.fbs).
+const (
+	metadataV5 = 4
+
+	headerSchema      = 1
+	headerRecordBatch = 3
+
+	typeInt       = 2
+	typeUtf8      = 5
+	typeTimestamp = 10
+	typeList      = 12
+
+	unitMicrosecond = 2
+)
+
+// column describes one field of the chunk schema and how to encode it.
+type column struct {
+	name     string
+	kind     int // typeUtf8, typeInt (int64), typeTimestamp or typeList (of utf8)
+	nullable bool
+	str      func(c *chunking.Chunk) string
+	num      func(c *chunking.Chunk) (int64, bool)
+	list     func(c *chunking.Chunk) []string
+}
+
+func strCol(name string, f func(c *chunking.Chunk) string) column {
+	return column{name: name, kind: typeUtf8, str: f}
+}
+
+func intCol(name string, f func(c *chunking.Chunk) int) column {
+	return column{name: name, kind: typeInt, num: func(c *chunking.Chunk) (int64, bool) { return int64(f(c)), true }}
+}
+
+// columns mirrors the Chunk JSON field names. Extra is carried as a JSON
+// string because its shape varies between chunks.
+var columns = []column{
+	strCol("id", func(c *chunking.Chunk) string { return c.ID }),
+	strCol("parent_id", func(c *chunking.Chunk) string { return c.ParentID }),
+	{name: "child_ids", kind: typeList, list: func(c *chunking.Chunk) []string { return c.ChildIDs }},
+	intCol("chunk_index", func(c *chunking.Chunk) int { return c.ChunkIndex }),
+	strCol("prev_id", func(c *chunking.Chunk) string { return c.PrevID }),
+	strCol("next_id", func(c *chunking.Chunk) string { return c.NextID }),
+	strCol("text", func(c *chunking.Chunk) string { return c.Text }),
+	strCol("overlap_text", func(c *chunking.Chunk) string { return c.OverlapText }),
+	intCol("start_index", func(c *chunking.Chunk) int { return c.StartIndex }),
+	intCol("end_index", func(c *chunking.Chunk) int { return c.EndIndex }),
+	intCol("byte_start", func(c *chunking.Chunk) int { return c.ByteStart }),
+	intCol("byte_end", func(c *chunking.Chunk) int { return c.ByteEnd }),
+	intCol("rune_start", func(c *chunking.Chunk) int { return c.RuneStart }),
+	intCol("rune_end", func(c *chunking.Chunk) int { return c.RuneEnd }),
+	{name: "page", kind: typeInt, nullable: true, num: func(c *chunking.Chunk) (int64, bool) {
+		if c.Page == nil {
+			return 0, false
+		}
+		return int64(*c.Page), true
+	}},
+	strCol("section", func(c *chunking.Chunk) string { return c.Section }),
+	strCol("chunk_title", func(c *chunking.Chunk) string { return c.ChunkTitle }),
+	strCol("file_name", func(c *chunking.Chunk) string { return c.FileName }),
+	strCol("file_path", func(c *chunking.Chunk) string { return c.FilePath }),
+	strCol("mime_type", func(c *chunking.Chunk) string { return c.MimeType }),
+	strCol("doc_id", func(c *chunking.Chunk) string { return c.DocID }),
+	strCol("title", func(c *chunking.Chunk) string { return c.Title }),
+	strCol("url", func(c *chunking.Chunk) string { return c.URL }),
+	strCol("author", func(c *chunking.Chunk) string { return c.Author }),
+	{name: "tags", kind: typeList, list: func(c *chunking.Chunk) []string { return c.Tags }},
+	strCol("tenant", func(c *chunking.Chunk) string { return c.Tenant }),
+	strCol("license", func(c *chunking.Chunk) string { return c.License }),
+	strCol("retention", func(c *chunking.Chunk) string { return c.Retention }),
+	{name: "created_at", kind: typeTimestamp, nullable: true, num: func(c *chunking.Chunk) (int64, bool) {
+		return c.CreatedAt.UnixMicro(), !c.CreatedAt.IsZero()
+	}},
+	{name: "expires_at", kind: typeTimestamp, nullable: true, num: func(c *chunking.Chunk) (int64, bool) {
+		if c.ExpiresAt == nil {
+			return 0, false
+		}
+		return c.ExpiresAt.UnixMicro(), true
+	}},
+	{name: "effective_at", kind: typeTimestamp, nullable: true, num: func(c *chunking.Chunk) (int64, bool) {
+		if c.EffectiveAt == nil {
+			return 0, false
+		}
+		return c.EffectiveAt.UnixMicro(), true
+	}},
+	intCol("schema_version", func(c *chunking.Chunk) int { return c.Schema }),
+	strCol("extra", func(c *chunking.Chunk) string {
+		if len(c.Extra) == 0 {
+			return ""
+		}
+		data, err := json.Marshal(c.Extra)
+		if err != nil {
+			return ""
+		}
+		return string(data)
+	}),
+}
+
+// Writer streams chunks as Arrow record batches. The schema message is
+// written before the first batch and Close writes the end-of-stream
+// marker.
+type Writer struct {
+	w         io.Writer
+	batchSize int
+	started   bool
+}
+
+// NewWriter returns a Writer emitting batches of at most batchSize chunks.
+func NewWriter(w io.Writer, batchSize int) *Writer {
+	if batchSize <= 0 {
+		batchSize = DefaultBatchSize
+	}
+	return &Writer{w: w, batchSize: batchSize}
+}
+
+// Write encodes chunks as one or more record batches.
+func (w *Writer) Write(chunks []chunking.Chunk) error {
+	if err := w.start(); err != nil {
+		return err
+	}
+	for len(chunks) > 0 {
+		n := w.batchSize
+		if n > len(chunks) {
+			n = len(chunks)
+		}
+		if err := w.writeBatch(chunks[:n]); err != nil {
+			return err
+		}
+		chunks = chunks[n:]
+	}
+	return nil
+}
+
+// Close ends the stream. It writes the schema first if no chunks were
+// written, so an empty result is still a valid stream.
+func (w *Writer) Close() error {
+	if err := w.start(); err != nil {
+		return err
+	}
+	_, err := w.w.Write([]byte{0xff, 0xff, 0xff, 0xff, 0, 0, 0, 0})
+	return err
+}
+
+// WriteChunks writes chunks as a complete Arrow IPC stream.
+func WriteChunks(w io.Writer, chunks []chunking.Chunk) error {
+	aw := NewWriter(w, DefaultBatchSize)
+	if err := aw.Write(chunks); err != nil {
+		return err
+	}
+	return aw.Close()
+}
+
+func (w *Writer) start() error {
+	if w.started {
+		return nil
+	}
+	w.started = true
+	return w.writeMessage(headerSchema, schemaTable(), 0, nil)
+}
+
+func schemaTable() fbTable {
+	fields := make(fbTables, 0, len(columns))
+	for _, col := range columns {
+		fields = append(fields, fieldTable(col.name, col.kind, col.nullable))
+	}
+	// endianness (Little = 0) is the default and omitted.
+	return fbTable{nil, fbRef(fields)}
+}
+
+func fieldTable(name string, kind int, nullable bool) fbTable {
+	var typ fbTable
+	var children fbTables
+	switch kind {
+	case typeInt:
+		typ = fbTable{fbInt32(64), fbBool(true)}
+	case typeTimestamp:
+		typ = fbTable{fbInt16(unitMicrosecond), fbRef(fbString("UTC"))}
+	case typeList:
+		typ = fbTable{}
+		children = fbTables{fieldTable("item", typeUtf8, false)}
+	default:
+		typ = fbTable{}
+	}
+	return fbTable{
+		fbRef(fbString(name)),
+		fbBool(nullable),
+		fbInt8(uint8(kind)),
+		fbRef(typ),
+		nil,
+		fbRef(children),
+	}
+}
+
+// body accumulates the buffers of a record batch, each padded to 8 bytes.
+type body struct {
+	data    []byte
+	nodes   fbPairs
+	buffers fbPairs
+}
+
+func (b *body) buffer(p []byte) {
+	b.buffers = append(b.buffers, [2]int64{int64(len(b.data)), int64(len(p))})
+	b.data = append(b.data, p...)
+	for len(b.data)%8 != 0 {
+		b.data = append(b.data, 0)
+	}
+}
+
+// strings appends offsets and data buffers for a utf8 array.
+func (b *body) strings(values []string) {
+	offsets := make([]byte, 0, 4*(len(values)+1))
+	var data []byte
+	offsets = binary.LittleEndian.AppendUint32(offsets, 0)
+	for _, s := range values {
+		data = append(data, s...)
+		offsets = binary.LittleEndian.AppendUint32(offsets, uint32(len(data)))
+	}
+	b.buffer(offsets)
+	b.buffer(data)
+}
+
+func (w *Writer) writeBatch(chunks []chunking.Chunk) error {
+	var b body
+	n := int64(len(chunks))
+	for _, col := range columns {
+		switch col.kind {
+		case typeUtf8:
+			values := make([]string, len(chunks))
+			for i := range chunks {
+				values[i] = col.str(&chunks[i])
+			}
+			b.nodes = append(b.nodes, [2]int64{n, 0})
+			b.buffer(nil)
+			b.strings(values)
+		case typeInt, typeTimestamp:
+			validity := make([]byte, (len(chunks)+7)/8)
+			data := make([]byte, 0, 8*len(chunks))
+			nulls := int64(0)
+			for i := range chunks {
+				v, ok := col.num(&chunks[i])
+				if ok {
+					validity[i/8] |= 1 << (i % 8)
+				} else {
+					nulls++
+				}
+				data = binary.LittleEndian.AppendUint64(data, uint64(v))
+			}
+			b.nodes = append(b.nodes, [2]int64{n, nulls})
+			if nulls == 0 {
+				validity = nil
+			}
+			b.buffer(validity)
+			b.buffer(data)
+		case typeList:
+			offsets := binary.LittleEndian.AppendUint32(nil, 0)
+			var items []string
+			for i := range chunks {
+				items = append(items, col.list(&chunks[i])...)
+				offsets = binary.LittleEndian.AppendUint32(offsets, uint32(len(items)))
+			}
+			b.nodes = append(b.nodes, [2]int64{n, 0})
+			b.buffer(nil)
+			b.buffer(offsets)
+			b.nodes = append(b.nodes, [2]int64{int64(len(items)), 0})
+			b.buffer(nil)
+			b.strings(items)
+		}
+	}
+	batch := fbTable{fbInt64(n), fbRef(b.nodes), fbRef(b.buffers)}
+	return w.writeMessage(headerRecordBatch, batch, int64(len(b.data)), b.data)
+}
+
+// writeMessage frames a metadata message and its body: continuation
+// marker, metadata length, metadata padded to 8 bytes, then the body.
+func (w *Writer) writeMessage(headerType uint8, header fbTable, bodyLen int64, data []byte) error {
+	msg := finish(fbTable{
+		fbInt16(metadataV5),
+		fbInt8(headerType),
+		fbRef(header),
+		fbInt64(bodyLen),
+	})
+	for len(msg)%8 != 0 {
+		msg = append(msg, 0)
+	}
+	prefix := make([]byte, 8)
+	binary.LittleEndian.PutUint32(prefix, 0xffffffff)
+	binary.LittleEndian.PutUint32(prefix[4:], uint32(len(msg)))
+	for _, p := range [][]byte{prefix, msg, data} {
+		if _, err := w.w.Write(p); err != nil {
+			return err
+		}
+	}
+	return nil
+}
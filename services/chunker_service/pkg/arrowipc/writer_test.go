@@ -0,0 +1,117 @@
+package arrowipc
+
+import (
+	"bytes"
+	"encoding/binary"
+	"testing"
+	"time"
+
+	"chunker-service/pkg/chunking"
+)
+
+// fbFieldPos reads the position of field id of the table at pos, or -1.
+func fbFieldPos(buf []byte, table, id int) int {
+	vtable := table - int(int32(binary.LittleEndian.Uint32(buf[table:])))
+	size := int(binary.LittleEndian.Uint16(buf[vtable:]))
+	if 4+2*id >= size {
+		return -1
+	}
+	off := int(binary.LittleEndian.Uint16(buf[vtable+4+2*id:]))
+	if off == 0 {
+		return -1
+	}
+	return table + off
+}
+
+type message struct {
+	headerType uint8
+	header     int // table position within meta
+	bodyLen    int64
+	meta       []byte
+}
+
+func readMessages(t *testing.T, stream []byte) []message {
+	t.Helper()
+	var msgs []message
+	for {
+		if len(stream) < 8 || binary.LittleEndian.Uint32(stream) != 0xffffffff {
+			t.Fatalf("missing continuation marker")
+		}
+		n := int(binary.LittleEndian.Uint32(stream[4:]))
+		if n == 0 {
+			if len(stream) != 8 {
+				t.Fatalf("%d bytes after end-of-stream marker", len(stream)-8)
+			}
+			return msgs
+		}
+		if n%8 != 0 {
+			t.Fatalf("metadata length %d is not 8-byte aligned", n)
+		}
+		meta := stream[8 : 8+n]
+		root := int(binary.LittleEndian.Uint32(meta))
+		m := message{meta: meta}
+		if pos := fbFieldPos(meta, root, 0); pos < 0 || binary.LittleEndian.Uint16(meta[pos:]) != metadataV5 {
+			t.Fatalf("message is not metadata version V5")
+		}
+		m.headerType = meta[fbFieldPos(meta, root, 1)]
+		slot := fbFieldPos(meta, root, 2)
+		m.header = slot + int(binary.LittleEndian.Uint32(meta[slot:]))
+		if pos := fbFieldPos(meta, root, 3); pos >= 0 {
+			m.bodyLen = int64(binary.LittleEndian.Uint64(meta[pos:]))
+		}
+		msgs = append(msgs, m)
+		stream = stream[8+n+int(m.bodyLen):]
+	}
+}
+
+func TestWriterFramesSchemaAndBatches(t *testing.T) {
+	page := 2
+	chunks := []chunking.Chunk{
+		{ID: "a", Text: "one", Page: &page, Tags: []string{"x"}, CreatedAt: time.Unix(0, 0)},
+		{ID: "b", Text: "two", Extra: map[string]interface{}{"k": "v"}},
+		{ID: "c", Text: "three"},
+	}
+	var buf bytes.Buffer
+	w := NewWriter(&buf, 2)
+	if err := w.Write(chunks); err != nil {
+		t.Fatalf("write failed: %v", err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatalf("close failed: %v", err)
+	}
+
+	msgs := readMessages(t, buf.Bytes())
+	if len(msgs) != 3 {
+		t.Fatalf("expected schema plus 2 batches, got %d messages", len(msgs))
+	}
+	if msgs[0].headerType != headerSchema || msgs[0].bodyLen != 0 {
+		t.Errorf("first message should be a bodiless schema: %+v", msgs[0])
+	}
+	for i, want := range []int64{2, 1} {
+		m := msgs[i+1]
+		if m.headerType != headerRecordBatch {
+			t.Fatalf("message %d is not a record batch", i+1)
+		}
+		if m.bodyLen%8 != 0 {
+			t.Errorf("batch %d body length %d is not 8-byte aligned", i, m.bodyLen)
+		}
+		if rows := int64(binary.LittleEndian.Uint64(m.meta[fbFieldPos(m.meta, m.header, 0):])); rows != want {
+			t.Errorf("batch %d has %d rows, want %d", i, rows, want)
+		}
+	}
+	for _, s := range []string{"one", "two", "three", `{"k":"v"}`, "created_at"} {
+		if !bytes.Contains(buf.Bytes(), []byte(s)) {
+			t.Errorf("stream does not contain %q", s)
+		}
+	}
+}
+
+func TestWriteChunksEmpty(t *testing.T) {
+	var buf bytes.Buffer
+	if err := WriteChunks(&buf, nil); err != nil {
+		t.Fatalf("write failed: %v", err)
+	}
+	if msgs := readMessages(t, buf.Bytes()); len(msgs) != 1 || msgs[0].headerType != headerSchema {
+		t.Fatalf("empty stream should hold only the schema, got %+v", msgs)
+	}
+}
@@ -0,0 +1,75 @@
+// Package audit records chunking operations to an append-only JSONL log
+// and replays them against the current chunker, so upgrades can be
+// checked against real traffic before they ship.
+package audit
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"time"
+
+	"chunker-service/pkg/chunking"
+)
+
+// Record is one chunking operation: everything needed to run it again and
+// a digest of the chunks it produced.
+type Record struct {
+	Time   time.Time              `json:"time"`
+	Text   string                 `json:"text"`
+	Plan   chunking.ChunkingPlan  `json:"plan"`
+	Meta   map[string]interface{} `json:"meta,omitempty"`
+	Chunks []chunking.ChunkDigest `json:"chunks"`
+}
+
+// Log appends records to a file. It is safe for concurrent use.
+type Log struct {
+	mu  sync.Mutex
+	f   *os.File
+	enc *json.Encoder
+}
+
+// OpenLog opens path for appending, creating it if needed.
+func OpenLog(path string) (*Log, error) {
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return nil, err
+	}
+	return &Log{f: f, enc: json.NewEncoder(f)}, nil
+}
+
+// Append writes rec as one line.
+func (l *Log) Append(rec Record) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.enc.Encode(rec)
+}
+
+// Close closes the underlying file.
+func (l *Log) Close() error {
+	return l.f.Close()
+}
+
+// Read calls fn for every record in r, in order. Line numbers start at 1.
+func Read(r io.Reader, fn func(line int, rec Record) error) error {
+	sc := bufio.NewScanner(r)
+	sc.Buffer(make([]byte, 0, 64<<10), 256<<20)
+	line := 0
+	for sc.Scan() {
+		line++
+		if len(sc.Bytes()) == 0 {
+			continue
+		}
+		var rec Record
+		if err := json.Unmarshal(sc.Bytes(), &rec); err != nil {
+			return fmt.Errorf("line %d: %w", line, err)
+		}
+		if err := fn(line, rec); err != nil {
+			return err
+		}
+	}
+	return sc.Err()
+}
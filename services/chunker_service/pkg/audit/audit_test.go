@@ -0,0 +1,65 @@
+package audit
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"chunker-service/pkg/chunking"
+)
+
+func TestReplayReportsDifferences(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit.jsonl")
+	l, err := OpenLog(path)
+	if err != nil {
+		t.Fatalf("open log: %v", err)
+	}
+	chunker := chunking.NewSlidingWindowChunker()
+	plans := []chunking.ChunkingPlan{
+		{WindowSize: 2, Mode: chunking.ModeTokens},
+		{WindowSize: 3, Mode: chunking.ModeTokens},
+	}
+	for _, plan := range plans {
+		chunks, err := chunker.Chunk("a b c d e f", plan, nil)
+		if err != nil {
+			t.Fatalf("chunking failed: %v", err)
+		}
+		if err := l.Append(Record{Text: "a b c d e f", Plan: plan, Chunks: chunking.Digest(chunks)}); err != nil {
+			t.Fatalf("append: %v", err)
+		}
+	}
+	// A recording whose chunks no longer match, and one that now fails.
+	if err := l.Append(Record{Text: "a b c", Plan: plans[0], Chunks: []chunking.ChunkDigest{{ByteEnd: 5}}}); err != nil {
+		t.Fatalf("append: %v", err)
+	}
+	if err := l.Append(Record{Text: "a", Plan: chunking.ChunkingPlan{Mode: chunking.ModeTokens}}); err != nil {
+		t.Fatalf("append: %v", err)
+	}
+	if err := l.Close(); err != nil {
+		t.Fatalf("close: %v", err)
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatalf("open: %v", err)
+	}
+	defer f.Close()
+	var results []Result
+	sum, err := Replay(f, chunker, func(res Result) error {
+		results = append(results, res)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("replay failed: %v", err)
+	}
+	if sum != (Summary{Replayed: 4, Identical: 2, Changed: 1, Failed: 1}) {
+		t.Fatalf("unexpected summary: %+v", sum)
+	}
+	cmp := results[2].Comparison
+	if cmp.Before != 1 || cmp.After != 2 || cmp.Changed != 2 || cmp.MovedBoundaries != 1 || cmp.MaxShift != 2 {
+		t.Errorf("unexpected comparison: %+v", cmp)
+	}
+	if results[3].Line != 4 || results[3].Error == "" {
+		t.Errorf("expected failure on line 4, got %+v", results[3])
+	}
+}
@@ -0,0 +1,54 @@
+package audit
+
+import (
+	"io"
+	"time"
+
+	"chunker-service/pkg/chunking"
+)
+
+// Result is the outcome of replaying one record.
+type Result struct {
+	Line       int                 `json:"line"`
+	Time       string              `json:"time,omitempty"`
+	Comparison chunking.Comparison `json:"comparison"`
+	Error      string              `json:"error,omitempty"`
+}
+
+// Summary counts replay outcomes.
+type Summary struct {
+	Replayed  int `json:"replayed"`
+	Identical int `json:"identical"`
+	Changed   int `json:"changed"`
+	Failed    int `json:"failed"`
+}
+
+// Replay re-executes every record in r with chunker and compares the new
+// chunks with the recorded digests. fn receives the result of every
+// record; a record that now fails to chunk counts as failed rather than
+// stopping the replay.
+func Replay(r io.Reader, chunker chunking.Chunker, fn func(Result) error) (Summary, error) {
+	var sum Summary
+	err := Read(r, func(line int, rec Record) error {
+		res := Result{Line: line}
+		if !rec.Time.IsZero() {
+			res.Time = rec.Time.Format(time.RFC3339)
+		}
+		sum.Replayed++
+		chunks, err := chunker.Chunk(rec.Text, rec.Plan, rec.Meta)
+		switch {
+		case err != nil:
+			res.Error = err.Error()
+			sum.Failed++
+		default:
+			res.Comparison = chunking.Compare(rec.Chunks, chunking.Digest(chunks))
+			if res.Comparison.Identical {
+				sum.Identical++
+			} else {
+				sum.Changed++
+			}
+		}
+		return fn(res)
+	})
+	return sum, err
+}
@@ -0,0 +1,163 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.5.1
+// - protoc             (unknown)
+// source: chunker/v1/chunker.proto
+
+package chunkerpb
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.64.0 or later.
+const _ = grpc.SupportPackageIsVersion9
+
+const (
+	Chunker_Chunk_FullMethodName       = "/chunker.v1.Chunker/Chunk"
+	Chunker_ChunkStream_FullMethodName = "/chunker.v1.Chunker/ChunkStream"
+)
+
+// ChunkerClient is the client API for Chunker service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+type ChunkerClient interface {
+	Chunk(ctx context.Context, in *ChunkRequest, opts ...grpc.CallOption) (*ChunkResponse, error)
+	ChunkStream(ctx context.Context, in *ChunkRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[Chunk], error)
+}
+
+type chunkerClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewChunkerClient(cc grpc.ClientConnInterface) ChunkerClient {
+	return &chunkerClient{cc}
+}
+
+func (c *chunkerClient) Chunk(ctx context.Context, in *ChunkRequest, opts ...grpc.CallOption) (*ChunkResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ChunkResponse)
+	err := c.cc.Invoke(ctx, Chunker_Chunk_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *chunkerClient) ChunkStream(ctx context.Context, in *ChunkRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[Chunk], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &Chunker_ServiceDesc.Streams[0], Chunker_ChunkStream_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[ChunkRequest, Chunk]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type Chunker_ChunkStreamClient = grpc.ServerStreamingClient[Chunk]
+
+// ChunkerServer is the server API for Chunker service.
+// All implementations must embed UnimplementedChunkerServer
+// for forward compatibility.
+type ChunkerServer interface {
+	Chunk(context.Context, *ChunkRequest) (*ChunkResponse, error)
+	ChunkStream(*ChunkRequest, grpc.ServerStreamingServer[Chunk]) error
+	mustEmbedUnimplementedChunkerServer()
+}
+
+// UnimplementedChunkerServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedChunkerServer struct{}
+
+func (UnimplementedChunkerServer) Chunk(context.Context, *ChunkRequest) (*ChunkResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Chunk not implemented")
+}
+func (UnimplementedChunkerServer) ChunkStream(*ChunkRequest, grpc.ServerStreamingServer[Chunk]) error {
+	return status.Errorf(codes.Unimplemented, "method ChunkStream not implemented")
+}
+func (UnimplementedChunkerServer) mustEmbedUnimplementedChunkerServer() {}
+func (UnimplementedChunkerServer) testEmbeddedByValue()                 {}
+
+// UnsafeChunkerServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to ChunkerServer will
+// result in compilation errors.
+type UnsafeChunkerServer interface {
+	mustEmbedUnimplementedChunkerServer()
+}
+
+func RegisterChunkerServer(s grpc.ServiceRegistrar, srv ChunkerServer) {
+	// If the following call pancis, it indicates UnimplementedChunkerServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&Chunker_ServiceDesc, srv)
+}
+
+func _Chunker_Chunk_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ChunkRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ChunkerServer).Chunk(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Chunker_Chunk_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ChunkerServer).Chunk(ctx, req.(*ChunkRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Chunker_ChunkStream_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(ChunkRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(ChunkerServer).ChunkStream(m, &grpc.GenericServerStream[ChunkRequest, Chunk]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type Chunker_ChunkStreamServer = grpc.ServerStreamingServer[Chunk]
+
+// Chunker_ServiceDesc is the grpc.ServiceDesc for Chunker service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var Chunker_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "chunker.v1.Chunker",
+	HandlerType: (*ChunkerServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "Chunk",
+			Handler:    _Chunker_Chunk_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "ChunkStream",
+			Handler:       _Chunker_ChunkStream_Handler,
+			ServerStreams: true,
+		},
+	},
+	Metadata: "chunker/v1/chunker.proto",
+}
@@ -0,0 +1,70 @@
+package chunkerpb
+
+import (
+	"encoding/json"
+
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+
+	"chunker-service/pkg/chunking"
+)
+
+// The messages use the JSON API's field names, so conversions go through
+// JSON. Unknown fields are discarded, so a plan or chunk field missing
+// from the .proto is lost; TestProtoDeclaresEveryField catches that.
+var (
+	marshalJSON   = protojson.MarshalOptions{UseProtoNames: true}
+	unmarshalJSON = protojson.UnmarshalOptions{DiscardUnknown: true}
+)
+
+// PlanFromProto converts a protobuf plan to a chunking.ChunkingPlan.
+func PlanFromProto(p *ChunkingPlan) (chunking.ChunkingPlan, error) {
+	return PlanFromProtoWithDefaults(p, chunking.ChunkingPlan{})
+}
+
+// PlanFromProtoWithDefaults converts p on top of base: fields p leaves at
+// their zero value keep base's value, since proto3 does not distinguish
+// them from unset fields. base must not share slices, maps or pointers
+// with a plan that is still in use.
+func PlanFromProtoWithDefaults(p *ChunkingPlan, base chunking.ChunkingPlan) (chunking.ChunkingPlan, error) {
+	if p == nil {
+		return base, nil
+	}
+	data, err := marshalJSON.Marshal(p)
+	if err != nil {
+		return base, err
+	}
+	err = json.Unmarshal(data, &base)
+	return base, err
+}
+
+// PlanToProto converts a chunking.ChunkingPlan to its protobuf form.
+func PlanToProto(plan chunking.ChunkingPlan) (*ChunkingPlan, error) {
+	p := &ChunkingPlan{}
+	return p, fromJSON(plan, p)
+}
+
+// ChunkToProto converts a chunk to its protobuf form.
+func ChunkToProto(c chunking.Chunk) (*Chunk, error) {
+	p := &Chunk{}
+	return p, fromJSON(c, p)
+}
+
+// ChunkFromProto converts a protobuf chunk to a chunking.Chunk.
+func ChunkFromProto(p *Chunk) (chunking.Chunk, error) {
+	var c chunking.Chunk
+	data, err := marshalJSON.Marshal(p)
+	if err != nil {
+		return c, err
+	}
+	err = json.Unmarshal(data, &c)
+	return c, err
+}
+
+func fromJSON(v interface{}, m proto.Message) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	return unmarshalJSON.Unmarshal(data, m)
+}
@@ -0,0 +1,129 @@
+package chunkerpb
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+
+	"chunker-service/pkg/chunking"
+)
+
+func TestPlanRoundTrip(t *testing.T) {
+	expires := time.Date(2027, 1, 1, 0, 0, 0, 0, time.UTC)
+	plan := chunking.ChunkingPlan{
+		WindowSize:          200,
+		Overlap:             20,
+		Mode:                chunking.ModeTokens,
+		BreakOnHeadings:     true,
+		IncludeHeadings:     true,
+		MaxChunks:           9,
+		Notes:               "n",
+		GroupLists:          true,
+		AttachCaptions:      true,
+		ExpandAcronyms:      true,
+		Readability:         true,
+		TrimOverlap:         true,
+		AllowBinary:         true,
+		Tokenizer:           "cl100k_base",
+		MetaSchema:          chunking.MetaSchema{"doc_id": "string required"},
+		MetaFields:          map[string]string{"src": "url", "title": ""},
+		Children:            &chunking.ChunkingPlan{WindowSize: 50, Mode: chunking.ModeTokens},
+		License:             "CC-BY-4.0",
+		Retention:           "90d",
+		Strategy:            chunking.StrategySemantic,
+		SimilarityThreshold: 0.4,
+		HeadingLanguages:    []string{"zh"},
+		BreakOnDates:        true,
+		Images:              "strip",
+		ImageChunks:         true,
+		Links:               "footnote",
+		ExtractTables:       true,
+		ChunkTitles:         true,
+		ExpiresAt:           &expires,
+		Locale:              "de",
+		Enrich:              []string{"summary"},
+		EnricherPolicies:    map[string]chunking.EnricherPolicy{"*": "warn"},
+		Concatenated:        chunking.ConcatSplit,
+	}
+	p, err := PlanToProto(plan)
+	if err != nil {
+		t.Fatalf("to proto: %v", err)
+	}
+	got, err := PlanFromProto(p)
+	if err != nil {
+		t.Fatalf("from proto: %v", err)
+	}
+	if !reflect.DeepEqual(got, plan) {
+		t.Errorf("round trip changed the plan:\n got %+v\nwant %+v", got, plan)
+	}
+}
+
+func TestPlanFromProtoWithDefaults(t *testing.T) {
+	base := chunking.ChunkingPlan{WindowSize: 200, Overlap: 20, Mode: chunking.ModeTokens, License: "internal"}
+	got, err := PlanFromProtoWithDefaults(&ChunkingPlan{WindowSize: 50, Mode: "lines"}, base)
+	if err != nil {
+		t.Fatalf("from proto: %v", err)
+	}
+	want := chunking.ChunkingPlan{WindowSize: 50, Overlap: 20, Mode: chunking.ModeLines, License: "internal"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %+v, want %+v", got, want)
+	}
+}
+
+func TestChunkRoundTrip(t *testing.T) {
+	page := 3
+	expires := time.Date(2027, 1, 1, 0, 0, 0, 0, time.UTC)
+	effective := time.Date(2024, 3, 5, 0, 0, 0, 0, time.UTC)
+	chunk := chunking.Chunk{
+		Schema: chunking.SchemaVersion, ChunkTitle: "Install (1/2)", EffectiveAt: &effective,
+		ID: "d#1", ParentID: "d#0", ChildIDs: []string{"d#1.0"}, ChunkIndex: 1,
+		PrevID: "d#0", NextID: "d#2", Text: "text", OverlapText: "o",
+		StartIndex: 1, EndIndex: 2, ByteStart: 3, ByteEnd: 4, RuneStart: 5, RuneEnd: 6,
+		Page: &page, Section: "s", FileName: "f", FilePath: "p", MimeType: "text/plain",
+		DocID: "d", Title: "t", URL: "u", Author: "a", Tags: []string{"x"}, Tenant: "acme",
+		License: "l", Retention: "1y",
+		CreatedAt: time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC),
+		ExpiresAt: &expires,
+		Extra:     map[string]interface{}{"heading": "h", "heading_level": float64(2)},
+	}
+	p, err := ChunkToProto(chunk)
+	if err != nil {
+		t.Fatalf("to proto: %v", err)
+	}
+	got, err := ChunkFromProto(p)
+	if err != nil {
+		t.Fatalf("from proto: %v", err)
+	}
+	if !reflect.DeepEqual(got, chunk) {
+		t.Errorf("round trip changed the chunk:\n got %+v\nwant %+v", got, chunk)
+	}
+}
+
+// TestProtoDeclaresEveryField fails when a JSON field of the Go types has
+// no proto field of the same name, which the conversions would drop.
+func TestProtoDeclaresEveryField(t *testing.T) {
+	cases := []struct {
+		v interface{}
+		m proto.Message
+	}{
+		{chunking.ChunkingPlan{}, &ChunkingPlan{}},
+		{chunking.Chunk{}, &Chunk{}},
+	}
+	for _, tc := range cases {
+		fields := tc.m.ProtoReflect().Descriptor().Fields()
+		typ := reflect.TypeOf(tc.v)
+		for i := 0; i < typ.NumField(); i++ {
+			name, _, _ := strings.Cut(typ.Field(i).Tag.Get("json"), ",")
+			if name == "" || name == "-" {
+				continue
+			}
+			if fields.ByName(protoreflect.Name(name)) == nil {
+				t.Errorf("%s.%s (%q) has no field in the proto", typ.Name(), typ.Field(i).Name, name)
+			}
+		}
+	}
+}
@@ -0,0 +1,33 @@
+package chunking
+
+// Analysis describes document-level structure found by Analyze.
+type Analysis struct {
+	Glossary map[string]string `json:"glossary"`
+	// Headings explains each detected heading so detection settings can
+	// be tuned with evidence.
+	Headings []HeadingDecision `json:"headings"`
+	// Structure measures the document's shape, as SuggestPlan uses it.
+	Structure Structure `json:"structure"`
+}
+
+// Analyze inspects a whole document without chunking it, using every
+// registered heading language pack.
+func Analyze(text string) Analysis {
+	// Resolving all packs cannot fail.
+	a, _ := AnalyzeLanguages(text, nil)
+	return a
+}
+
+// AnalyzeLanguages is Analyze restricted to the named heading language
+// packs, mirroring ChunkingPlan.HeadingLanguages.
+func AnalyzeLanguages(text string, languages []string) (Analysis, error) {
+	headings, err := DetectHeadings(text, languages)
+	if err != nil {
+		return Analysis{}, err
+	}
+	return Analysis{
+		Glossary:  BuildGlossary(text),
+		Headings:  headings,
+		Structure: AnalyzeStructure(text),
+	}, nil
+}
@@ -0,0 +1,50 @@
+package chunking
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+const (
+	// garbageRatioLimit is the share of suspicious runes above which text
+	// is treated as binary or extraction garbage.
+	garbageRatioLimit = 0.1
+	// nulSniffLen is how far into the text a NUL byte marks it as binary,
+	// mirroring the heuristic git uses.
+	nulSniffLen = 8000
+)
+
+// checkBinary returns ErrBinaryContent when text looks like binary data or
+// failed text extraction: a NUL byte near the start, or a high ratio of
+// invalid UTF-8, replacement characters and non-whitespace control
+// characters.
+func checkBinary(text string) error {
+	head := text
+	if len(head) > nulSniffLen {
+		head = head[:nulSniffLen]
+	}
+	if strings.IndexByte(head, 0) >= 0 {
+		return fmt.Errorf("%w: NUL byte in input", ErrBinaryContent)
+	}
+
+	total, bad := 0, 0
+	for i := 0; i < len(text); {
+		r, size := utf8.DecodeRuneInString(text[i:])
+		i += size
+		total++
+		switch {
+		case r == utf8.RuneError:
+			// Covers both invalid UTF-8 and literal U+FFFD from upstream
+			// decoders.
+			bad++
+		case unicode.IsControl(r) && r != '\n' && r != '\r' && r != '\t' && r != '\f':
+			bad++
+		}
+	}
+	if total > 0 && float64(bad)/float64(total) > garbageRatioLimit {
+		return fmt.Errorf("%w: %.0f%% of characters are non-printable or invalid", ErrBinaryContent, 100*float64(bad)/float64(total))
+	}
+	return nil
+}
@@ -0,0 +1,82 @@
+package chunking
+
+import (
+	"regexp"
+	"strings"
+)
+
+// Caption is a figure or table caption found in the source document.
+type Caption struct {
+	Kind  string `json:"kind"`
+	Label string `json:"label"`
+	Text  string `json:"text"`
+}
+
+var (
+	captionPattern = regexp.MustCompile(
+		`(?i)^\s*(figure|fig\.|table|tab\.|chart|diagram|exhibit)\s*([0-9]+(?:[.\-][0-9]+)*[a-z]?)\s*[:.\-–—]\s*(\S.*)$`)
+	captionRefPattern = regexp.MustCompile(
+		`(?i)\b(figure|fig\.|table|tab\.|chart|diagram|exhibit)\s*([0-9]+(?:[.\-][0-9]+)*[a-z]?)`)
+)
+
+// captionKinds normalizes caption prefixes so "Fig. 3" and "Figure 3" refer
+// to the same caption.
+var captionKinds = map[string]string{
+	"figure":  "figure",
+	"fig.":    "figure",
+	"table":   "table",
+	"tab.":    "table",
+	"chart":   "chart",
+	"diagram": "diagram",
+	"exhibit": "exhibit",
+}
+
+// findCaptions returns the captions in text keyed by their normalized
+// reference ("figure 3"), keeping the first caption for each label.
+func findCaptions(text string) map[string]Caption {
+	captions := map[string]Caption{}
+	for _, line := range strings.Split(text, "\n") {
+		m := captionPattern.FindStringSubmatch(line)
+		if m == nil {
+			continue
+		}
+		kind := captionKinds[strings.ToLower(m[1])]
+		key := kind + " " + strings.ToLower(m[2])
+		if _, ok := captions[key]; ok {
+			continue
+		}
+		captions[key] = Caption{
+			Kind:  kind,
+			Label: strings.TrimSpace(m[1] + " " + m[2]),
+			Text:  strings.TrimSpace(m[3]),
+		}
+	}
+	return captions
+}
+
+// attachCaptions records in Extra["captions"] every caption whose label a
+// chunk mentions. Chunks containing the caption line itself therefore get
+// it too, as do chunks referring to it ("see Figure 3"), so retrieval on
+// either has the caption text available.
+func attachCaptions(text string, chunks []Chunk) {
+	captions := findCaptions(text)
+	if len(captions) == 0 {
+		return
+	}
+	for i := range chunks {
+		var attached []Caption
+		seen := map[string]bool{}
+		for _, m := range captionRefPattern.FindAllStringSubmatch(chunks[i].Text, -1) {
+			key := captionKinds[strings.ToLower(m[1])] + " " + strings.ToLower(m[2])
+			c, ok := captions[key]
+			if !ok || seen[key] {
+				continue
+			}
+			seen[key] = true
+			attached = append(attached, c)
+		}
+		if len(attached) > 0 {
+			chunks[i].Extra["captions"] = attached
+		}
+	}
+}
@@ -2,18 +2,67 @@ package chunking
 
 import "time"
 
+// SchemaVersion is the version of the Chunk and ChunkingPlan JSON formats.
+// It changes only when a field is removed or changes meaning; new
+// optional fields do not bump it.
+const SchemaVersion = 1
+
 // Chunk represents a single chunk of text along with useful metadata
 // for retrieval and debugging. It is designed to be serializable as JSON.
+//
+// StartIndex and EndIndex count units of the plan's mode; ByteStart/ByteEnd
+// and RuneStart/RuneEnd locate the same window in the original text so
+// highlighters can map a chunk back to its source span.
+//
+// ID is "<doc>#<n>", where doc is the doc_id metadata or a hash of the
+// document text. ChunkIndex, PrevID and NextID place a chunk among its
+// neighbours. Hierarchical plans link small child chunks to the large
+// parent chunk they were cut from through ParentID and ChildIDs.
+//
+// License and Retention tag every chunk of a document; ExpiresAt is when a
+// retention period ends and the chunk becomes eligible for purging.
+//
+// EffectiveAt, set by plans with break_on_dates, is the date (midnight
+// UTC) of the dated section, such as a changelog entry or meeting, a chunk
+// belongs to.
+//
+// Title is the document's title from metadata; ChunkTitle, set by plans
+// with chunk_titles, is a short label for the chunk itself.
+//
+// Schema is the SchemaVersion the chunk was produced under; chunks
+// serialized before versioning have none.
 type Chunk struct {
-	ID         string                 `json:"id"`
-	Text       string                 `json:"text"`
-	StartIndex int                    `json:"start_index"`
-	EndIndex   int                    `json:"end_index"`
-	Page       *int                   `json:"page,omitempty"`
-	Section    string                 `json:"section,omitempty"`
-	FileName   string                 `json:"file_name"`
-	FilePath   string                 `json:"file_path"`
-	MimeType   string                 `json:"mime_type"`
-	CreatedAt  time.Time              `json:"created_at"`
-	Extra      map[string]interface{} `json:"extra,omitempty"`
+	Schema      int                    `json:"schema_version,omitempty"`
+	ID          string                 `json:"id"`
+	ParentID    string                 `json:"parent_id,omitempty"`
+	ChildIDs    []string               `json:"child_ids,omitempty"`
+	ChunkIndex  int                    `json:"chunk_index"`
+	PrevID      string                 `json:"prev_id,omitempty"`
+	NextID      string                 `json:"next_id,omitempty"`
+	Text        string                 `json:"text"`
+	OverlapText string                 `json:"overlap_text,omitempty"`
+	StartIndex  int                    `json:"start_index"`
+	EndIndex    int                    `json:"end_index"`
+	ByteStart   int                    `json:"byte_start"`
+	ByteEnd     int                    `json:"byte_end"`
+	RuneStart   int                    `json:"rune_start"`
+	RuneEnd     int                    `json:"rune_end"`
+	Page        *int                   `json:"page,omitempty"`
+	Section     string                 `json:"section,omitempty"`
+	ChunkTitle  string                 `json:"chunk_title,omitempty"`
+	FileName    string                 `json:"file_name"`
+	FilePath    string                 `json:"file_path"`
+	MimeType    string                 `json:"mime_type"`
+	DocID       string                 `json:"doc_id,omitempty"`
+	Title       string                 `json:"title,omitempty"`
+	URL         string                 `json:"url,omitempty"`
+	Author      string                 `json:"author,omitempty"`
+	Tags        []string               `json:"tags,omitempty"`
+	Tenant      string                 `json:"tenant,omitempty"`
+	License     string                 `json:"license,omitempty"`
+	Retention   string                 `json:"retention,omitempty"`
+	CreatedAt   time.Time              `json:"created_at"`
+	ExpiresAt   *time.Time             `json:"expires_at,omitempty"`
+	EffectiveAt *time.Time             `json:"effective_at,omitempty"`
+	Extra       map[string]interface{} `json:"extra,omitempty"`
 }
@@ -1,10 +1,14 @@
 package chunking
 
 import (
+	"context"
 	"errors"
+	"fmt"
 	"regexp"
 	"strings"
-	"unicode"
+	"unicode/utf8"
+
+	"chunker-service/pkg/tokenizer"
 )
 
 // Chunker defines the deterministic interface for turning text plus a
@@ -13,11 +17,75 @@ type Chunker interface {
 	Chunk(text string, plan ChunkingPlan, baseMeta map[string]interface{}) ([]Chunk, error)
 }
 
+// Strategies dispatches each plan to the chunker registered for its
+// Strategy; plans without one use StrategySliding.
+type Strategies map[Strategy]Chunker
+
+// Chunk implements Chunker.
+func (s Strategies) Chunk(text string, plan ChunkingPlan, baseMeta map[string]interface{}) ([]Chunk, error) {
+	strategy := plan.Strategy
+	if strategy == "" {
+		strategy = StrategySliding
+	}
+	c, ok := s[strategy]
+	if !ok {
+		return nil, fmt.Errorf("strategy %q is not configured", strategy)
+	}
+	return c.Chunk(text, plan, baseMeta)
+}
+
 // SlidingWindowChunker performs simple sliding-window chunking over
-// characters, whitespace-delimited tokens, or lines depending on the
+// characters, tokens, or lines depending on the
 // ChunkingPlan. It is intentionally minimal and stateless so it can be
 // used from other processes.
-type SlidingWindowChunker struct{}
+type SlidingWindowChunker struct {
+	// Limits guards against accidentally huge outputs. Unlike
+	// ChunkingPlan.MaxChunks, which silently truncates, exceeding a limit
+	// fails the whole request with ErrOutputTooLarge.
+	Limits OutputLimits
+
+	// MetaSchema applies to every plan in addition to the plan's own
+	// meta_schema, which wins for keys declared in both.
+	MetaSchema MetaSchema
+
+	// Trace, when set, receives a step-by-step account of the chunking
+	// decisions. It is meant for a single debug request.
+	Trace *Trace
+
+	// Context, when set, cancels chunking: once it is done, Chunk stops
+	// between windows and returns its error. Like Trace it is meant for a
+	// single request.
+	Context context.Context
+
+	// Enrichers are the external enrichers plans may run by name.
+	Enrichers map[string]Enricher
+}
+
+// OutputLimits caps the total output of a single Chunk call. Zero values
+// disable the corresponding limit.
+type OutputLimits struct {
+	MaxChunks int
+	MaxBytes  int
+}
+
+func (l OutputLimits) check(chunks, bytes int) error {
+	if l.MaxChunks > 0 && chunks > l.MaxChunks {
+		return fmt.Errorf("%w: more than %d chunks", ErrOutputTooLarge, l.MaxChunks)
+	}
+	if l.MaxBytes > 0 && bytes > l.MaxBytes {
+		return fmt.Errorf("%w: more than %d bytes of chunk text", ErrOutputTooLarge, l.MaxBytes)
+	}
+	return nil
+}
+
+// checkContext returns ctx's error once it is done; a nil ctx is never
+// done.
+func checkContext(ctx context.Context) error {
+	if ctx == nil {
+		return nil
+	}
+	return ctx.Err()
+}
 
 // NewSlidingWindowChunker constructs a new SlidingWindowChunker.
 func NewSlidingWindowChunker() *SlidingWindowChunker {
@@ -26,7 +94,7 @@ func NewSlidingWindowChunker() *SlidingWindowChunker {
 
 // Chunk applies a sliding window over the provided text according to
 // the plan. StartIndex and EndIndex are expressed in unit indices
-// (characters, tokens, or lines depending on Mode).
+// (characters, graphemes, bytes, tokens, or lines depending on Mode).
 func (c *SlidingWindowChunker) Chunk(
 	text string,
 	plan ChunkingPlan,
@@ -38,16 +106,45 @@ func (c *SlidingWindowChunker) Chunk(
 	if plan.Overlap < 0 || plan.Overlap >= plan.WindowSize {
 		return nil, errors.New("overlap must be >= 0 and < window_size")
 	}
+	if plan.Strategy != "" && plan.Strategy != StrategySliding {
+		return nil, fmt.Errorf("strategy %q is not supported by the sliding window chunker", plan.Strategy)
+	}
+	if err := checkInput(text, plan, c.MetaSchema, baseMeta); err != nil {
+		return nil, err
+	}
+	if err := checkEnrichers(plan, c.Enrichers); err != nil {
+		return nil, err
+	}
+	if err := checkContext(c.Context); err != nil {
+		return nil, err
+	}
+	if chunks, ok, err := chunkConcatenated(text, plan, baseMeta, c.Limits, c.Trace, c.Chunk); ok {
+		return chunks, err
+	}
+
+	tok, err := PlanTokenizer(plan)
+	if err != nil {
+		return nil, err
+	}
 
 	var units []string
 	switch plan.Mode {
 	case ModeTokens:
-		units = strings.Fields(text)
+		units = tok.Tokenize(text)
 	case ModeLines:
 		units = strings.Split(text, "\n")
 	case ModeCharacters, "":
-		// Default to characters (bytes for now). Runes can be added later
-		// if needed, but for many test cases this is sufficient.
+		// Characters are runes, so windows never split a multi-byte
+		// UTF-8 sequence and indices count code points.
+		units = make([]string, 0, utf8.RuneCountInString(text))
+		for i := 0; i < len(text); {
+			_, size := utf8.DecodeRuneInString(text[i:])
+			units = append(units, text[i:i+size])
+			i += size
+		}
+	case ModeGraphemes:
+		units = splitGraphemes(text)
+	case ModeBytes:
 		units = make([]string, 0, len(text))
 		for i := 0; i < len(text); i++ {
 			units = append(units, text[i:i+1])
@@ -56,6 +153,21 @@ func (c *SlidingWindowChunker) Chunk(
 		return nil, errors.New("unsupported mode")
 	}
 
+	offsets := locateUnits(text, plan.Mode, units)
+	join := func(from, to int) string { return joinUnits(plan.Mode, tok, units[from:to]) }
+	if _, ok := tok.(wordTokenizer); ok && plan.Mode == ModeTokens {
+		// Words of scripts written without spaces keep the text's own
+		// spacing instead of gaining a space between every pair.
+		join = func(from, to int) string {
+			if from >= to {
+				return ""
+			}
+			return text[offsets.byteStart[from]:offsets.byteEnd[to-1]]
+		}
+	}
+
+	c.Trace.add("units", map[string]interface{}{"mode": plan.Mode, "count": len(units)},
+		"split %d bytes of input into %d units", len(text), len(units))
 	if len(units) == 0 {
 		return nil, nil
 	}
@@ -66,39 +178,81 @@ func (c *SlidingWindowChunker) Chunk(
 		return nil, errors.New("invalid step size computed from window_size and overlap")
 	}
 
+	var packs []LanguagePack
+	if plan.BreakOnHeadings || plan.BreakOnDates || plan.ChunkTitles {
+		if packs, err = resolveLanguagePacks(plan.HeadingLanguages); err != nil {
+			return nil, err
+		}
+	}
 	segments := []segment{{start: 0, end: len(units), heading: "", level: 0}}
 	if plan.BreakOnHeadings && plan.Mode == ModeLines {
-		segments = headingSegments(units)
+		segments = headingSegments(units, packs)
+		for _, seg := range segments {
+			if seg.heading == "" {
+				c.Trace.add("segment", map[string]interface{}{"start": seg.start, "end": seg.end},
+					"lines %d-%d have no heading", seg.start, seg.end)
+				continue
+			}
+			c.Trace.add("segment", map[string]interface{}{"start": seg.start, "end": seg.end, "heading": seg.heading, "level": seg.level},
+				"line %d is a level %d heading %q; segment covers lines %d-%d", seg.start, seg.level, seg.heading, seg.start, seg.end)
+		}
+	}
+
+	if plan.BreakOnDates && plan.Mode == ModeLines {
+		segments = splitAtDates(segments, units, packs)
+		c.Trace.add("dates", map[string]interface{}{"segments": len(segments)},
+			"split at date headers into %d segments", len(segments))
+	}
+
+	var blocks []span
+	if plan.GroupLists && plan.Mode == ModeLines {
+		blocks = listBlocks(units)
+		for _, b := range blocks {
+			c.Trace.add("list", map[string]interface{}{"start": b.start, "end": b.end},
+				"list block with introduction covers lines %d-%d", b.start, b.end)
+		}
 	}
 
 	var chunks []Chunk
+	totalBytes := 0
+outer:
 	for _, seg := range segments {
-		for start := seg.start; start < seg.end; start += step {
+		prevEnd := seg.start
+		for start := seg.start; start < seg.end; {
 			end := start + plan.WindowSize
 			if end > seg.end {
 				end = seg.end
 			}
-
-			window := units[start:end]
-			textChunk := ""
-			switch plan.Mode {
-			case ModeTokens:
-				textChunk = strings.Join(window, " ")
-			case ModeLines:
-				windowLines := window
-				if plan.IncludeHeadings && seg.heading != "" && start == seg.start && len(windowLines) > 0 {
-					windowLines = windowLines[1:]
+			pulled := false
+			if blocks != nil {
+				if adjusted := keepBlocksWhole(start, end, plan.WindowSize, blocks); adjusted != end {
+					c.Trace.add("boundary", map[string]interface{}{"from": end, "to": adjusted},
+						"moved window end from %d to %d to keep a list together", end, adjusted)
+					end, pulled = adjusted, true
 				}
-				textChunk = strings.Join(windowLines, "\n")
-			default:
-				textChunk = strings.Join(window, "")
+			}
+
+			from := start
+			overlapText := ""
+			if plan.TrimOverlap && prevEnd > start {
+				overlapText = join(start, prevEnd)
+				from = prevEnd
+			}
+			if plan.Mode == ModeLines && plan.IncludeHeadings && seg.heading != "" && !seg.continued && start == seg.start && from < end {
+				from++
 			}
 
 			chunk := Chunk{
-				Text:       textChunk,
-				StartIndex: start,
-				EndIndex:   end,
-				Extra:      map[string]interface{}{},
+				Schema:      SchemaVersion,
+				Text:        join(from, end),
+				OverlapText: overlapText,
+				StartIndex:  start,
+				EndIndex:    end,
+				ByteStart:   offsets.byteStart[start],
+				ByteEnd:     offsets.byteEnd[end-1],
+				RuneStart:   offsets.runeStart[start],
+				RuneEnd:     offsets.runeEnd[end-1],
+				Extra:       map[string]interface{}{},
 			}
 
 			if plan.Mode == ModeLines && seg.heading != "" {
@@ -111,28 +265,34 @@ func (c *SlidingWindowChunker) Chunk(
 				}
 			}
 
-			if v, ok := baseMeta["file_name"].(string); ok {
-				chunk.FileName = v
-			}
-			if v, ok := baseMeta["file_path"].(string); ok {
-				chunk.FilePath = v
+			promoteMeta(&chunk, baseMeta, plan.MetaFields)
+
+			chunks = append(chunks, chunk)
+			totalBytes += len(chunk.Text)
+			if err := c.Limits.check(len(chunks), totalBytes); err != nil {
+				return nil, err
 			}
-			if v, ok := baseMeta["mime_type"].(string); ok {
-				chunk.MimeType = v
+			if err := checkContext(c.Context); err != nil {
+				return nil, err
 			}
-
-			for k, v := range baseMeta {
-				if k == "file_name" || k == "file_path" || k == "mime_type" {
-					continue
-				}
-				chunk.Extra[k] = v
+			if plan.MaxChunks > 0 && len(chunks) >= plan.MaxChunks {
+				c.Trace.add("truncate", map[string]interface{}{"max_chunks": plan.MaxChunks},
+					"stopped after max_chunks=%d at unit %d of %d", plan.MaxChunks, end, len(units))
+				break outer
 			}
 
-			chunks = append(chunks, chunk)
-
 			if end == seg.end {
 				break
 			}
+			// Windows normally advance by step. When a list block pulled
+			// the end back, the next window starts at the block: one
+			// overlapping it would be pulled back to the same end and lie
+			// inside this chunk.
+			next := end - plan.Overlap
+			if pulled || next <= start {
+				next = end
+			}
+			start, prevEnd = next, end
 		}
 	}
 
@@ -140,32 +300,108 @@ func (c *SlidingWindowChunker) Chunk(
 		chunks = chunks[:plan.MaxChunks]
 	}
 
+	c.Trace.add("chunks", map[string]interface{}{"count": len(chunks), "bytes": totalBytes},
+		"produced %d chunks with %d bytes of text", len(chunks), totalBytes)
+
+	chunks, images, err := enrich(c.Context, text, chunks, plan, packs, c.Enrichers, c.Trace)
+	if err != nil {
+		return nil, err
+	}
+
+	doc := documentKey(text, baseMeta)
+	assignIDs(chunks, doc)
+	sequence := make([]*Chunk, len(chunks))
+	for i := range chunks {
+		sequence[i] = &chunks[i]
+	}
+	linkSequence(sequence)
+
+	if plan.Children != nil {
+		if chunks, err = c.addChildren(text, chunks, plan, baseMeta); err != nil {
+			return nil, err
+		}
+	}
+	if plan.ImageChunks {
+		if chunks, err = c.addImageChunks(text, chunks, images, doc, plan, baseMeta); err != nil {
+			return nil, err
+		}
+	}
+	markConcatenated(text, chunks, plan, c.Trace)
+	applyPolicy(chunks, plan)
 	return chunks, nil
 }
 
+// checkInput runs the plan and input checks shared by every chunker.
+func checkInput(text string, plan ChunkingPlan, schema MetaSchema, baseMeta map[string]interface{}) error {
+	if err := checkChildPlan(plan); err != nil {
+		return err
+	}
+	if _, _, err := ParseRetention(plan.Retention); err != nil {
+		return err
+	}
+	if err := checkMetaFields(plan.MetaFields); err != nil {
+		return err
+	}
+	if err := checkImages(plan.Images); err != nil {
+		return err
+	}
+	if err := checkLinks(plan.Links); err != nil {
+		return err
+	}
+	if err := checkLocale(plan.Locale); err != nil {
+		return err
+	}
+	if err := schema.Merge(plan.MetaSchema).Validate(baseMeta); err != nil {
+		return err
+	}
+	if err := checkConcatenated(plan.Concatenated); err != nil {
+		return err
+	}
+	if !plan.AllowBinary {
+		return checkBinary(text)
+	}
+	return nil
+}
+
+// joinUnits reassembles units into text using the separator implied by
+// the mode, deferring to the tokenizer in tokens mode.
+func joinUnits(mode Mode, tok tokenizer.Tokenizer, units []string) string {
+	switch mode {
+	case ModeTokens:
+		return tok.Join(units)
+	case ModeLines:
+		return strings.Join(units, "\n")
+	default:
+		return strings.Join(units, "")
+	}
+}
+
 var headingNumberPattern = regexp.MustCompile(`^[0-9]+(\.[0-9]+)*[.)]?\s+`)
 
 // headingSegments returns contiguous line ranges that begin at likely headings.
 // This keeps sliding windows from crossing major sections when requested.
+// A continued segment carries the heading of the section it was split
+// from but does not start with the heading line.
 type segment struct {
-	start   int
-	end     int
-	heading string
-	level   int
+	start     int
+	end       int
+	heading   string
+	level     int
+	continued bool
 }
 
-func headingSegments(lines []string) []segment {
+func headingSegments(lines []string, packs []LanguagePack) []segment {
 	var segments []segment
 
 	start := 0
-	headingText, headingLevel := headingInfo(lines[0])
+	headingText, headingLevel := headingInfo(lines[0], packs)
 	for i, line := range lines {
 		if i == 0 {
 			continue
 		}
-		if isHeading(line) {
+		if isHeading(line, packs) {
 			segments = append(segments, segment{start: start, end: i, heading: headingText, level: headingLevel})
-			headingText, headingLevel = headingInfo(line)
+			headingText, headingLevel = headingInfo(line, packs)
 			start = i
 		}
 	}
@@ -174,52 +410,3 @@ func headingSegments(lines []string) []segment {
 	}
 	return segments
 }
-
-func isHeading(line string) bool {
-	trimmed := strings.TrimSpace(line)
-	if trimmed == "" {
-		return false
-	}
-	if strings.HasPrefix(trimmed, "#") {
-		return true
-	}
-	if headingNumberPattern.MatchString(trimmed) {
-		return true
-	}
-	// Treat short, mostly-uppercase lines as headings (common in PDFs/MD).
-	if len([]rune(trimmed)) <= 80 {
-		totalLetters := 0
-		upperLetters := 0
-		for _, r := range trimmed {
-			if unicode.IsLetter(r) {
-				totalLetters++
-				if unicode.IsUpper(r) {
-					upperLetters++
-				}
-			}
-		}
-		if totalLetters > 0 && float64(upperLetters) >= 0.6*float64(totalLetters) {
-			return true
-		}
-	}
-	return false
-}
-
-func headingInfo(line string) (string, int) {
-	if !isHeading(line) {
-		return "", 0
-	}
-	trimmed := strings.TrimSpace(line)
-	if strings.HasPrefix(trimmed, "#") {
-		level := 0
-		for i := 0; i < len(trimmed) && trimmed[i] == '#'; i++ {
-			level++
-		}
-		return strings.TrimSpace(trimmed[level:]), level
-	}
-	if headingNumberPattern.MatchString(trimmed) {
-		return trimmed, 1
-	}
-	// Uppercase short heading
-	return trimmed, 1
-}
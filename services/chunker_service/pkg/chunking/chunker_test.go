@@ -1,8 +1,15 @@
 package chunking
 
 import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
 	"strings"
 	"testing"
+	"unicode/utf8"
+
+	"chunker-service/pkg/tokenizer"
 )
 
 func TestChunkCharactersSlidingWindow(t *testing.T) {
@@ -203,3 +210,339 @@ func TestChunkIncludeHeadings(t *testing.T) {
 		t.Fatalf("expected heading level 2, got %+v", chunks[0].Extra)
 	}
 }
+
+func TestChunkOutputLimits(t *testing.T) {
+	plan := ChunkingPlan{WindowSize: 2, Overlap: 0, Mode: ModeCharacters}
+
+	chunker := &SlidingWindowChunker{Limits: OutputLimits{MaxChunks: 2}}
+	if _, err := chunker.Chunk("abcdef", plan, nil); !errors.Is(err, ErrOutputTooLarge) {
+		t.Fatalf("expected ErrOutputTooLarge for chunk limit, got %v", err)
+	}
+
+	chunker = &SlidingWindowChunker{Limits: OutputLimits{MaxBytes: 5}}
+	if _, err := chunker.Chunk("abcdef", plan, nil); !errors.Is(err, ErrOutputTooLarge) {
+		t.Fatalf("expected ErrOutputTooLarge for byte limit, got %v", err)
+	}
+
+	// MaxChunks truncates before the guardrail is reached.
+	plan.MaxChunks = 2
+	chunks, err := chunker.Chunk("abcdef", plan, nil)
+	if err != nil || len(chunks) != 2 {
+		t.Fatalf("expected MaxChunks to truncate within limits, got %d chunks, err %v", len(chunks), err)
+	}
+}
+
+func TestChunkBreakOnNonLatinHeadings(t *testing.T) {
+	chunker := NewSlidingWindowChunker()
+	plan := ChunkingPlan{
+		WindowSize:      10,
+		Overlap:         0,
+		Mode:            ModeLines,
+		BreakOnHeadings: true,
+	}
+
+	text := "第1章 概要\n本書について説明します。\n第一节 安装\n安装步骤如下。\n제2장 설정\n설정 방법입니다."
+	chunks, err := chunker.Chunk(text, plan, map[string]interface{}{})
+	if err != nil {
+		t.Fatalf("chunking failed: %v", err)
+	}
+	if got, want := len(chunks), 3; got != want {
+		t.Fatalf("expected %d chunks split on headings, got %d: %+v", want, got, chunks)
+	}
+	wantLevels := []int{1, 2, 1}
+	for i, ch := range chunks {
+		if lvl, _ := ch.Extra["heading_level"].(int); lvl != wantLevels[i] {
+			t.Errorf("chunk %d heading level = %v, want %d", i, ch.Extra["heading_level"], wantLevels[i])
+		}
+	}
+
+	plan.HeadingLanguages = []string{"ko"}
+	chunks, err = chunker.Chunk(text, plan, map[string]interface{}{})
+	if err != nil {
+		t.Fatalf("chunking failed: %v", err)
+	}
+	if len(chunks) != 2 {
+		t.Fatalf("expected only the Korean heading to split with the ko pack, got %d chunks", len(chunks))
+	}
+
+	plan.HeadingLanguages = []string{"tlh"}
+	if _, err := chunker.Chunk(text, plan, nil); err == nil {
+		t.Fatalf("expected error for unknown heading language")
+	}
+}
+
+func TestChunkGroupLists(t *testing.T) {
+	chunker := NewSlidingWindowChunker()
+	plan := ChunkingPlan{
+		WindowSize: 4,
+		Overlap:    0,
+		Mode:       ModeLines,
+	}
+	text := "Para one\nPara two\nTo install:\n- download\n  the archive\n- run setup\nDone"
+
+	chunks, err := chunker.Chunk(text, plan, nil)
+	if err != nil {
+		t.Fatalf("chunking failed: %v", err)
+	}
+	if !strings.Contains(chunks[0].Text, "- download") || strings.Contains(chunks[0].Text, "- run setup") {
+		t.Fatalf("expected plain windows to split the list, got %q", chunks[0].Text)
+	}
+
+	plan.GroupLists = true
+	chunks, err = chunker.Chunk(text, plan, nil)
+	if err != nil {
+		t.Fatalf("chunking failed: %v", err)
+	}
+	want := []string{"Para one\nPara two", "To install:\n- download\n  the archive\n- run setup", "Done"}
+	if len(chunks) != len(want) {
+		t.Fatalf("expected %d chunks, got %d: %+v", len(want), len(chunks), chunks)
+	}
+	for i, ch := range chunks {
+		if ch.Text != want[i] {
+			t.Errorf("chunk %d text = %q, want %q", i, ch.Text, want[i])
+		}
+	}
+	if chunks[1].StartIndex != 2 || chunks[1].EndIndex != 6 {
+		t.Errorf("list chunk indices = (%d,%d), want (2,6)", chunks[1].StartIndex, chunks[1].EndIndex)
+	}
+
+	// With overlap, the window after a pulled-back end must not fall
+	// inside the chunk before it.
+	plan = ChunkingPlan{WindowSize: 10, Overlap: 5, Mode: ModeLines, GroupLists: true, TrimOverlap: true}
+	text = "one\ntwo\nthree\nfour\nfive\nsix\nSteps:\n- a\n- b\n- c\n- d\n- e\n- f\n- g\nafter\nmore\nend\nlast"
+	chunks, err = chunker.Chunk(text, plan, nil)
+	if err != nil {
+		t.Fatalf("chunking failed: %v", err)
+	}
+	prevEnd := 0
+	for i, ch := range chunks {
+		if ch.EndIndex <= prevEnd || strings.TrimSpace(ch.Text) == "" {
+			t.Fatalf("chunk %d (%d-%d, %q) adds nothing after line %d", i, ch.StartIndex, ch.EndIndex, ch.Text, prevEnd)
+		}
+		prevEnd = ch.EndIndex
+	}
+	if chunks[0].EndIndex != 6 || chunks[1].StartIndex != 6 || !strings.HasPrefix(chunks[1].Text, "Steps:") {
+		t.Errorf("expected the list to start the second chunk, got %+v", chunks[:2])
+	}
+}
+
+func TestChunkAttachCaptions(t *testing.T) {
+	chunker := NewSlidingWindowChunker()
+	plan := ChunkingPlan{
+		WindowSize:     2,
+		Overlap:        0,
+		Mode:           ModeLines,
+		AttachCaptions: true,
+	}
+	text := "The pipeline has three stages.\nFigure 3: Ingestion pipeline overview\nLatency is shown in Table 1.\nAs Fig. 3 shows, chunking is cheap."
+
+	chunks, err := chunker.Chunk(text, plan, nil)
+	if err != nil {
+		t.Fatalf("chunking failed: %v", err)
+	}
+	if len(chunks) != 2 {
+		t.Fatalf("expected 2 chunks, got %d", len(chunks))
+	}
+	for i, ch := range chunks {
+		captions, ok := ch.Extra["captions"].([]Caption)
+		if !ok || len(captions) != 1 {
+			t.Fatalf("chunk %d captions = %+v, want the Figure 3 caption", i, ch.Extra["captions"])
+		}
+		if captions[0].Kind != "figure" || captions[0].Text != "Ingestion pipeline overview" {
+			t.Errorf("chunk %d caption = %+v", i, captions[0])
+		}
+	}
+}
+
+func TestChunkTrimOverlap(t *testing.T) {
+	chunker := NewSlidingWindowChunker()
+	plan := ChunkingPlan{
+		WindowSize:  3,
+		Overlap:     1,
+		Mode:        ModeTokens,
+		TrimOverlap: true,
+	}
+
+	chunks, err := chunker.Chunk("a b c d e", plan, nil)
+	if err != nil {
+		t.Fatalf("chunking failed: %v", err)
+	}
+	if len(chunks) != 2 {
+		t.Fatalf("expected 2 chunks, got %d", len(chunks))
+	}
+	if chunks[0].Text != "a b c" || chunks[0].OverlapText != "" {
+		t.Errorf("first chunk = %q / %q", chunks[0].Text, chunks[0].OverlapText)
+	}
+	if chunks[1].Text != "d e" || chunks[1].OverlapText != "c" {
+		t.Errorf("second chunk = %q / overlap %q, want \"d e\" / \"c\"", chunks[1].Text, chunks[1].OverlapText)
+	}
+	if chunks[1].StartIndex != 2 || chunks[1].EndIndex != 5 {
+		t.Errorf("second chunk indices = (%d,%d), want (2,5)", chunks[1].StartIndex, chunks[1].EndIndex)
+	}
+}
+
+func TestChunkRejectsBinaryContent(t *testing.T) {
+	chunker := NewSlidingWindowChunker()
+	plan := ChunkingPlan{WindowSize: 10, Mode: ModeCharacters}
+
+	for name, text := range map[string]string{
+		"nul":         "PK\x03\x04\x00\x00payload",
+		"invalid":     "\xff\xfe\xfa\xfb text \xc0\xc1",
+		"replacement": "���� garbled ����",
+	} {
+		if _, err := chunker.Chunk(text, plan, nil); !errors.Is(err, ErrBinaryContent) {
+			t.Errorf("%s: expected ErrBinaryContent, got %v", name, err)
+		}
+	}
+
+	if _, err := chunker.Chunk("tab\tseparated\r\nlines with a stray \x01", plan, nil); err != nil {
+		t.Errorf("ordinary text rejected: %v", err)
+	}
+
+	plan.AllowBinary = true
+	if _, err := chunker.Chunk("PK\x03\x04\x00\x00payload", plan, nil); err != nil {
+		t.Errorf("allow_binary should skip the check: %v", err)
+	}
+}
+
+func TestChunkTrace(t *testing.T) {
+	trace := &Trace{}
+	chunker := &SlidingWindowChunker{Trace: trace}
+	plan := ChunkingPlan{
+		WindowSize:      3,
+		Overlap:         0,
+		Mode:            ModeLines,
+		BreakOnHeadings: true,
+		GroupLists:      true,
+		MaxChunks:       2,
+	}
+	text := "# Intro\nSome text\nSteps:\n- one\n- two\n# Next\nmore"
+
+	if _, err := chunker.Chunk(text, plan, nil); err != nil {
+		t.Fatalf("chunking failed: %v", err)
+	}
+	steps := map[string]int{}
+	for _, ev := range trace.Events {
+		steps[ev.Step]++
+	}
+	for step, want := range map[string]int{"units": 1, "segment": 2, "list": 1, "boundary": 1, "truncate": 1, "chunks": 1} {
+		if steps[step] != want {
+			t.Errorf("trace has %d %q events, want %d: %+v", steps[step], step, want, trace.Events)
+		}
+	}
+}
+
+func TestChunkCharactersAreRunes(t *testing.T) {
+	chunker := NewSlidingWindowChunker()
+	text := "héllo wörld"
+
+	chunks, err := chunker.Chunk(text, ChunkingPlan{WindowSize: 4, Mode: ModeCharacters}, nil)
+	if err != nil {
+		t.Fatalf("chunking failed: %v", err)
+	}
+	wantTexts := []string{"héll", "o wö", "rld"}
+	if len(chunks) != len(wantTexts) {
+		t.Fatalf("expected %d chunks, got %+v", len(wantTexts), chunks)
+	}
+	for i, ch := range chunks {
+		if ch.Text != wantTexts[i] {
+			t.Errorf("chunk %d: expected %q, got %q", i, wantTexts[i], ch.Text)
+		}
+		if !utf8.ValidString(ch.Text) {
+			t.Errorf("chunk %d is not valid UTF-8: %q", i, ch.Text)
+		}
+	}
+	if chunks[2].StartIndex != 8 || chunks[2].EndIndex != 11 {
+		t.Errorf("expected rune indices 8-11, got %d-%d", chunks[2].StartIndex, chunks[2].EndIndex)
+	}
+
+	chunks, err = chunker.Chunk(text, ChunkingPlan{WindowSize: 2, Mode: ModeBytes}, nil)
+	if err != nil {
+		t.Fatalf("chunking failed: %v", err)
+	}
+	if len(chunks) != 7 || chunks[0].Text != "h\xc3" {
+		t.Errorf("bytes mode should split on bytes, got %+v", chunks)
+	}
+}
+
+func TestChunkLinksNeighbours(t *testing.T) {
+	chunker := NewSlidingWindowChunker()
+	chunks, err := chunker.Chunk("a b c d e", ChunkingPlan{WindowSize: 2, Mode: ModeTokens}, nil)
+	if err != nil {
+		t.Fatalf("chunking failed: %v", err)
+	}
+	doc := documentKey("a b c d e", nil)
+	for i, ch := range chunks {
+		if want := fmt.Sprintf("%s#%d", doc, i); ch.ID != want || ch.ChunkIndex != i {
+			t.Errorf("chunk %d: expected id %s, got %s (index %d)", i, want, ch.ID, ch.ChunkIndex)
+		}
+		if i > 0 && ch.PrevID != chunks[i-1].ID {
+			t.Errorf("chunk %d: prev_id %q, want %q", i, ch.PrevID, chunks[i-1].ID)
+		}
+		if i+1 < len(chunks) && ch.NextID != chunks[i+1].ID {
+			t.Errorf("chunk %d: next_id %q, want %q", i, ch.NextID, chunks[i+1].ID)
+		}
+	}
+	if chunks[0].PrevID != "" || chunks[len(chunks)-1].NextID != "" {
+		t.Errorf("first and last chunks should have no prev/next")
+	}
+}
+
+// charTokenizer treats every byte as a token and joins losslessly, which is
+// enough to tell it apart from the whitespace default.
+type charTokenizer struct{}
+
+func (charTokenizer) Tokenize(text string) []string { return strings.Split(text, "") }
+func (charTokenizer) Join(tokens []string) string   { return strings.Join(tokens, "") }
+
+func TestChunkTokenizer(t *testing.T) {
+	tokenizer.Register("test-chars", charTokenizer{})
+	chunker := NewSlidingWindowChunker()
+	plan := ChunkingPlan{WindowSize: 4, Mode: ModeTokens, Tokenizer: "test-chars"}
+
+	chunks, err := chunker.Chunk("ab cdef", plan, nil)
+	if err != nil {
+		t.Fatalf("chunking failed: %v", err)
+	}
+	if len(chunks) != 2 || chunks[0].Text != "ab c" || chunks[1].Text != "def" {
+		t.Fatalf("unexpected chunks: %+v", chunks)
+	}
+
+	plan.Tokenizer = "missing"
+	if _, err := chunker.Chunk("ab", plan, nil); err == nil {
+		t.Fatalf("expected error for unknown tokenizer")
+	}
+}
+
+func TestChunkStopsWhenContextDone(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	chunker := &SlidingWindowChunker{Context: ctx}
+	_, err := chunker.Chunk("abcdef", ChunkingPlan{WindowSize: 2, Mode: ModeCharacters}, nil)
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected context.Canceled, got %v", err)
+	}
+
+	semantic := &SemanticChunker{Embedder: &topicEmbedder{}, Context: ctx}
+	_, err = semantic.Chunk("Cats purr. Dogs bark.", ChunkingPlan{WindowSize: 4, Strategy: StrategySemantic}, nil)
+	if !errors.Is(err, context.Canceled) || errors.Is(err, ErrEmbeddingFailed) {
+		t.Fatalf("expected context.Canceled from the semantic chunker, got %v", err)
+	}
+}
+
+func TestChunksCarrySchemaVersion(t *testing.T) {
+	chunks, err := NewSlidingWindowChunker().Chunk("alpha beta gamma delta",
+		ChunkingPlan{WindowSize: 2, Mode: ModeTokens, Children: &ChunkingPlan{WindowSize: 1, Mode: ModeTokens}}, nil)
+	if err != nil {
+		t.Fatalf("chunking failed: %v", err)
+	}
+	for _, c := range chunks {
+		if c.Schema != SchemaVersion {
+			t.Errorf("chunk %s: expected schema version %d, got %d", c.ID, SchemaVersion, c.Schema)
+		}
+	}
+	data, _ := json.Marshal(chunks[0])
+	if !strings.Contains(string(data), `"schema_version":1`) {
+		t.Errorf("schema_version missing from %s", data)
+	}
+}
@@ -0,0 +1,35 @@
+package chunking
+
+import "time"
+
+// Clock supplies the timestamps recorded in Chunk.CreatedAt. Injecting a
+// clock instead of calling time.Now keeps exports and tests reproducible.
+type Clock interface {
+	Now() time.Time
+}
+
+// SystemClock reads the wall clock in UTC.
+type SystemClock struct{}
+
+// Now returns the current UTC time.
+func (SystemClock) Now() time.Time { return time.Now().UTC() }
+
+// FixedClock always returns the same instant, e.g. a caller-supplied
+// ingestion timestamp shared by every chunk of a batch.
+type FixedClock time.Time
+
+// Now returns the fixed instant.
+func (c FixedClock) Now() time.Time { return time.Time(c) }
+
+// Stamp sets CreatedAt on chunks that lack one and derives ExpiresAt from
+// their retention period. The clock is read once so all chunks of the
+// call share the same timestamp.
+func Stamp(chunks []Chunk, clock Clock) {
+	now := clock.Now()
+	for i := range chunks {
+		if chunks[i].CreatedAt.IsZero() {
+			chunks[i].CreatedAt = now
+		}
+		expireAt(&chunks[i])
+	}
+}
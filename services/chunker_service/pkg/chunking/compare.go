@@ -0,0 +1,109 @@
+package chunking
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"sort"
+	"time"
+)
+
+// ChunkDigest summarizes a chunk compactly enough to keep for every
+// request: its ID, source span and a hash of everything else.
+type ChunkDigest struct {
+	ID        string `json:"id"`
+	ByteStart int    `json:"byte_start"`
+	ByteEnd   int    `json:"byte_end"`
+	Hash      string `json:"hash"`
+}
+
+// Digest summarizes chunks. CreatedAt and the ExpiresAt derived from it
+// are left out of the hash so re-running a plan later compares equal.
+func Digest(chunks []Chunk) []ChunkDigest {
+	out := make([]ChunkDigest, len(chunks))
+	for i, ch := range chunks {
+		ch.CreatedAt, ch.ExpiresAt = time.Time{}, nil
+		data, _ := json.Marshal(ch)
+		sum := sha256.Sum256(data)
+		out[i] = ChunkDigest{
+			ID:        ch.ID,
+			ByteStart: ch.ByteStart,
+			ByteEnd:   ch.ByteEnd,
+			Hash:      hex.EncodeToString(sum[:8]),
+		}
+	}
+	return out
+}
+
+// Comparison describes how two chunkings of the same text differ.
+// Boundaries are chunk end offsets; each boundary of the second chunking
+// is matched to the nearest boundary of the first, and shifts are in
+// bytes.
+type Comparison struct {
+	Identical bool `json:"identical"`
+	Before    int  `json:"before"`
+	After     int  `json:"after"`
+	// Changed counts chunks of the second chunking with no identical
+	// chunk in the first.
+	Changed         int     `json:"changed"`
+	MovedBoundaries int     `json:"moved_boundaries"`
+	MeanShift       float64 `json:"mean_shift"`
+	MaxShift        int     `json:"max_shift"`
+}
+
+// Compare compares chunking a (e.g. recorded or primary) with b (e.g.
+// replayed or candidate).
+func Compare(a, b []ChunkDigest) Comparison {
+	c := Comparison{Before: len(a), After: len(b), Identical: len(a) == len(b)}
+	have := make(map[ChunkDigest]bool, len(a))
+	ends := make([]int, len(a))
+	for i, d := range a {
+		have[d] = true
+		ends[i] = d.ByteEnd
+		if c.Identical && b[i] != d {
+			c.Identical = false
+		}
+	}
+	sort.Ints(ends)
+	total := 0
+	for _, d := range b {
+		if !have[d] {
+			c.Changed++
+		}
+		shift := nearest(ends, d.ByteEnd)
+		if shift > 0 {
+			c.MovedBoundaries++
+			total += shift
+			if shift > c.MaxShift {
+				c.MaxShift = shift
+			}
+		}
+	}
+	if c.MovedBoundaries > 0 {
+		c.MeanShift = float64(total) / float64(c.MovedBoundaries)
+	}
+	return c
+}
+
+// nearest returns the distance from x to the closest value in sorted, or
+// x itself when sorted is empty.
+func nearest(sorted []int, x int) int {
+	if len(sorted) == 0 {
+		return x
+	}
+	i := sort.SearchInts(sorted, x)
+	best := -1
+	for _, j := range []int{i - 1, i} {
+		if j < 0 || j >= len(sorted) {
+			continue
+		}
+		d := sorted[j] - x
+		if d < 0 {
+			d = -d
+		}
+		if best < 0 || d < best {
+			best = d
+		}
+	}
+	return best
+}
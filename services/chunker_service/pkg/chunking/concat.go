@@ -0,0 +1,269 @@
+package chunking
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode/utf8"
+)
+
+// ConcatPolicy is what a chunker does with text that looks like several
+// documents concatenated into one, whose chunks would mix unrelated
+// documents and poison retrieval.
+type ConcatPolicy string
+
+// Concatenation policies. ConcatWarn, the default, chunks the text whole
+// but records the number of documents found in every chunk's
+// Extra["concatenated_documents"] and marks chunks that straddle two of
+// them with Extra["crosses_documents"]. ConcatSplit chunks each document
+// on its own, and ConcatIgnore skips detection.
+const (
+	ConcatWarn   ConcatPolicy = "warn"
+	ConcatSplit  ConcatPolicy = "split"
+	ConcatIgnore ConcatPolicy = "ignore"
+)
+
+// DocumentPart is one of the documents found in a concatenated text: the
+// byte range it covers, the signal that started it and the name its file
+// marker gave it, if any.
+type DocumentPart struct {
+	Start  int    `json:"start"`
+	End    int    `json:"end"`
+	Signal string `json:"signal,omitempty"`
+	Name   string `json:"name,omitempty"`
+}
+
+// Signals starting a DocumentPart.
+const (
+	SignalFormFeed      = "form_feed"
+	SignalFileMarker    = "file_marker"
+	SignalRepeatedTitle = "repeated_title"
+)
+
+// fileMarkers match lines tools and people put between concatenated
+// files: "--- file: a.md ---", "=== document: b ===" and head/tail's
+// "==> c.txt <==". The first group is the file name.
+var fileMarkers = []*regexp.Regexp{
+	regexp.MustCompile(`(?im)^[ \t]*-{3,}[ \t]*(?:file|document|source)[ \t]*:[ \t]*(\S.*?)[ \t]*-*[ \t]*$`),
+	regexp.MustCompile(`(?im)^[ \t]*={3,}[ \t]*(?:file|document|source)[ \t]*:[ \t]*(\S.*?)[ \t]*=*[ \t]*$`),
+	regexp.MustCompile(`(?m)^==> (\S.*?) <==[ \t]*$`),
+}
+
+// maxTitleLength bounds the lines that can form a title page, so
+// repeated boilerplate paragraphs are not taken for one.
+const maxTitleLength = 120
+
+// SplitDocuments finds probable document boundaries in text and returns
+// the documents between them in order, or a single part covering the
+// whole text when there are none. A document starts after a form feed,
+// at a file marker line, or where the text's title page (its first two
+// non-blank lines) appears again after a blank line.
+func SplitDocuments(text string) []DocumentPart {
+	starts := map[int]DocumentPart{}
+	add := func(at int, signal, name string) {
+		if strings.TrimSpace(text[at:]) == "" {
+			return
+		}
+		if prev, ok := starts[at]; ok && prev.Name != "" {
+			return
+		}
+		starts[at] = DocumentPart{Start: at, Signal: signal, Name: name}
+	}
+
+	for i := strings.IndexByte(text, '\f'); i >= 0; {
+		add(i+1, SignalFormFeed, "")
+		next := strings.IndexByte(text[i+1:], '\f')
+		if next < 0 {
+			break
+		}
+		i += 1 + next
+	}
+	for _, re := range fileMarkers {
+		for _, m := range re.FindAllStringSubmatchIndex(text, -1) {
+			add(m[0], SignalFileMarker, text[m[2]:m[3]])
+		}
+	}
+	for _, at := range repeatedTitles(text) {
+		add(at, SignalRepeatedTitle, "")
+	}
+
+	first := DocumentPart{Start: 0}
+	if p, ok := starts[0]; ok {
+		first = p
+		delete(starts, 0)
+	}
+	parts := []DocumentPart{first}
+	for _, p := range starts {
+		parts = append(parts, p)
+	}
+	sort.Slice(parts, func(i, j int) bool { return parts[i].Start < parts[j].Start })
+	// Whitespace-only stretches do not count as a document: a marker
+	// right after a form feed starts one part, not two.
+	kept := parts[:1]
+	for _, p := range parts[1:] {
+		last := &kept[len(kept)-1]
+		if strings.TrimSpace(text[last.Start:p.Start]) == "" {
+			if p.Name != "" || last.Name == "" {
+				p.Start = last.Start
+				*last = p
+			}
+			continue
+		}
+		kept = append(kept, p)
+	}
+	for i := range kept {
+		kept[i].End = len(text)
+		if i+1 < len(kept) {
+			kept[i].End = kept[i+1].Start
+		}
+	}
+	return kept
+}
+
+// repeatedTitles returns the offsets where the text's first two non-blank
+// lines appear again, in order and after a blank line or form feed.
+func repeatedTitles(text string) []int {
+	lines := strings.SplitAfter(text, "\n")
+	var title []string
+	for _, l := range lines {
+		l = strings.TrimSpace(l)
+		if l == "" {
+			continue
+		}
+		if len(l) > maxTitleLength {
+			return nil
+		}
+		if title = append(title, l); len(title) == 2 {
+			break
+		}
+	}
+	if len(title) < 2 {
+		return nil
+	}
+
+	var out []int
+	offset, blank := 0, true
+	for i, l := range lines {
+		at := offset
+		offset += len(l)
+		if i == 0 {
+			blank = false
+			continue
+		}
+		trimmed := strings.TrimSpace(l)
+		if blank && trimmed == title[0] {
+			for j := i + 1; j < len(lines); j++ {
+				next := strings.TrimSpace(lines[j])
+				if next == "" {
+					continue
+				}
+				if next == title[1] {
+					out = append(out, at+len(l)-len(strings.TrimLeft(l, " \t\f")))
+				}
+				break
+			}
+		}
+		blank = trimmed == "" || strings.HasSuffix(strings.TrimRight(l, " \t\r\n"), "\f")
+	}
+	return out
+}
+
+// checkConcatenated rejects unknown concatenation policies.
+func checkConcatenated(policy ConcatPolicy) error {
+	switch policy {
+	case "", ConcatWarn, ConcatSplit, ConcatIgnore:
+		return nil
+	}
+	return fmt.Errorf("unknown concatenated policy %q", policy)
+}
+
+// chunkConcatenated chunks each document of a concatenated text on its
+// own with chunk under the split policy, reporting false when the plan
+// does not split or the text is a single document. Each part is chunked
+// as the document "<doc>/<n>", numbered from 1, so IDs, ChunkIndex and
+// unit indices start over in every part; byte and rune offsets stay
+// absolute in text. Chunks record their part in Extra["document_part"]
+// and the name a file marker gave it in Extra["document_name"].
+func chunkConcatenated(text string, plan ChunkingPlan, baseMeta map[string]interface{}, limits OutputLimits, trace *Trace,
+	chunk func(string, ChunkingPlan, map[string]interface{}) ([]Chunk, error)) ([]Chunk, bool, error) {
+	if plan.Concatenated != ConcatSplit {
+		return nil, false, nil
+	}
+	parts := SplitDocuments(text)
+	if len(parts) < 2 {
+		return nil, false, nil
+	}
+	trace.add("concatenated", map[string]interface{}{"parts": len(parts)},
+		"split concatenated input into %d documents", len(parts))
+
+	doc := documentKey(text, baseMeta)
+	partPlan := plan
+	partPlan.Concatenated = ConcatIgnore
+	var out []Chunk
+	totalBytes := 0
+	for n, part := range parts {
+		meta := make(map[string]interface{}, len(baseMeta)+1)
+		for k, v := range baseMeta {
+			meta[k] = v
+		}
+		meta["doc_id"] = fmt.Sprintf("%s/%d", doc, n+1)
+		chunks, err := chunk(text[part.Start:part.End], partPlan, meta)
+		if err != nil {
+			return nil, true, fmt.Errorf("document %d of concatenated input: %w", n+1, err)
+		}
+		runes := utf8.RuneCountInString(text[:part.Start])
+		for i := range chunks {
+			ch := &chunks[i]
+			ch.ByteStart += part.Start
+			ch.ByteEnd += part.Start
+			ch.RuneStart += runes
+			ch.RuneEnd += runes
+			if ch.Extra == nil {
+				ch.Extra = map[string]interface{}{}
+			}
+			ch.Extra["document_part"] = n + 1
+			if part.Name != "" {
+				ch.Extra["document_name"] = part.Name
+			}
+			totalBytes += len(ch.Text)
+		}
+		out = append(out, chunks...)
+		if err := limits.check(len(out), totalBytes); err != nil {
+			return nil, true, err
+		}
+	}
+	return out, true, nil
+}
+
+// markConcatenated applies the warn policy to the chunks of text: when
+// text looks like several documents, every chunk records how many in
+// Extra["concatenated_documents"], and chunks whose source span crosses
+// from one into the next are marked with Extra["crosses_documents"].
+func markConcatenated(text string, chunks []Chunk, plan ChunkingPlan, trace *Trace) {
+	if plan.Concatenated != "" && plan.Concatenated != ConcatWarn {
+		return
+	}
+	parts := SplitDocuments(text)
+	if len(parts) < 2 {
+		return
+	}
+	crossing := 0
+	for i := range chunks {
+		ch := &chunks[i]
+		if ch.Extra == nil {
+			ch.Extra = map[string]interface{}{}
+		}
+		ch.Extra["concatenated_documents"] = len(parts)
+		for _, p := range parts[1:] {
+			if ch.ByteStart < p.Start && ch.ByteEnd > p.Start &&
+				strings.TrimSpace(text[ch.ByteStart:p.Start]) != "" && strings.TrimSpace(text[p.Start:ch.ByteEnd]) != "" {
+				ch.Extra["crosses_documents"] = true
+				crossing++
+				break
+			}
+		}
+	}
+	trace.add("concatenated", map[string]interface{}{"parts": len(parts), "crossing": crossing},
+		"input looks like %d concatenated documents; %d chunks cross from one into the next", len(parts), crossing)
+}
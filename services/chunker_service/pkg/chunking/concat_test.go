@@ -0,0 +1,96 @@
+package chunking
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSplitDocuments(t *testing.T) {
+	cases := []struct {
+		name string
+		text string
+		want []DocumentPart
+	}{
+		{"single", "---\ntitle: x\n---\n# A\n\nbody\n", []DocumentPart{{Start: 0, End: 27}}},
+		{"form feeds", "one\fTwo\f\n", []DocumentPart{{Start: 0, End: 4}, {Start: 4, End: 9, Signal: SignalFormFeed}}},
+		{"file markers", "--- file: a.md ---\nalpha\n==> b.txt <==\nbeta\n", []DocumentPart{
+			{Start: 0, End: 25, Signal: SignalFileMarker, Name: "a.md"},
+			{Start: 25, End: 44, Signal: SignalFileMarker, Name: "b.txt"},
+		}},
+		{"marker after form feed", "alpha\f\n=== Document: b ===\nbeta", []DocumentPart{
+			{Start: 0, End: 6},
+			{Start: 6, End: 31, Signal: SignalFileMarker, Name: "b"},
+		}},
+		{"repeated title", "Annual Report\nAcme Corp\n\nbody one\n\nAnnual Report\nAcme Corp\n\nbody two\n", []DocumentPart{
+			{Start: 0, End: 35},
+			{Start: 35, End: 69, Signal: SignalRepeatedTitle},
+		}},
+		{"title line alone", "Summary\nfirst\n\nSummary\nsecond\n", []DocumentPart{{Start: 0, End: 30}}},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := SplitDocuments(tc.text); !reflect.DeepEqual(got, tc.want) {
+				t.Fatalf("expected %+v, got %+v", tc.want, got)
+			}
+		})
+	}
+}
+
+func TestConcatenatedPolicies(t *testing.T) {
+	text := "--- file: a.md ---\nalpha beta\n--- file: b.md ---\ngamma delta\n"
+	plan := ChunkingPlan{WindowSize: 4, Mode: ModeTokens}
+
+	chunks, err := NewSlidingWindowChunker().Chunk(text, plan, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	crossing := 0
+	for _, ch := range chunks {
+		if ch.Extra["concatenated_documents"] != 2 {
+			t.Fatalf("expected every chunk to count 2 documents: %+v", ch)
+		}
+		if ch.Extra["crosses_documents"] == true {
+			crossing++
+		}
+	}
+	if crossing == 0 {
+		t.Fatalf("expected chunks crossing documents: %+v", chunks)
+	}
+
+	plan.Concatenated = ConcatSplit
+	chunks, err = NewSlidingWindowChunker().Chunk(text, plan, map[string]interface{}{"doc_id": "d"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	var ids, names []string
+	for _, ch := range chunks {
+		ids = append(ids, ch.ID)
+		names = append(names, ch.Extra["document_name"].(string))
+		if text[ch.ByteStart:ch.ByteEnd] == "" || ch.Extra["crosses_documents"] != nil {
+			t.Fatalf("unexpected chunk %+v", ch)
+		}
+	}
+	if want := []string{"d/1#0", "d/1#1", "d/2#0", "d/2#1"}; !reflect.DeepEqual(ids, want) {
+		t.Fatalf("expected IDs %v, got %v", want, ids)
+	}
+	if want := []string{"a.md", "a.md", "b.md", "b.md"}; !reflect.DeepEqual(names, want) {
+		t.Fatalf("expected names %v, got %v", want, names)
+	}
+	if last := chunks[3]; text[last.ByteStart:last.ByteEnd] != "gamma delta" || last.DocID != "d/2" {
+		t.Fatalf("expected offsets in the whole text: %+v", last)
+	}
+
+	plan.Concatenated = ConcatIgnore
+	chunks, err = NewSlidingWindowChunker().Chunk(text, plan, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if chunks[0].Extra["concatenated_documents"] != nil {
+		t.Fatalf("ignore should not mark chunks: %+v", chunks[0])
+	}
+
+	plan.Concatenated = "merge"
+	if _, err := NewSlidingWindowChunker().Chunk(text, plan, nil); err == nil {
+		t.Fatal("expected an unknown policy to be rejected")
+	}
+}
@@ -1,25 +1,182 @@
 package chunking
 
+import "time"
+
 // Mode defines the unit type used for sliding window chunking.
 // It is intentionally simple so this package has minimal dependencies
 // and can be called from other languages or processes.
 type Mode string
 
 const (
+	// ModeCharacters counts Unicode code points.
 	ModeCharacters Mode = "chars"
 	ModeTokens     Mode = "tokens"
 	ModeLines      Mode = "lines"
+	// ModeGraphemes counts user-perceived characters (extended grapheme
+	// clusters), so windows never split an emoji sequence or separate a
+	// letter from its combining marks.
+	ModeGraphemes Mode = "graphemes"
+	// ModeBytes counts raw bytes and may split multi-byte characters;
+	// it preserves the original behavior of chars mode.
+	ModeBytes Mode = "bytes"
+)
+
+// Strategy selects the chunking algorithm a plan is executed with.
+type Strategy string
+
+const (
+	StrategySliding  Strategy = "sliding"
+	StrategySemantic Strategy = "semantic"
 )
 
 // ChunkingPlan describes how a piece of text should be chunked.
 // The plan is produced by an LLM (or other heuristic) and then
 // executed deterministically by the chunker implementation.
 type ChunkingPlan struct {
-	WindowSize       int    `json:"window_size"`
-	Overlap          int    `json:"overlap"`
-	Mode             Mode   `json:"mode"`
-	BreakOnHeadings  bool   `json:"break_on_headings"`
-	IncludeHeadings  bool   `json:"include_headings,omitempty"`
-	MaxChunks        int    `json:"max_chunks,omitempty"`
-	Notes            string `json:"notes,omitempty"`
+	WindowSize      int    `json:"window_size"`
+	Overlap         int    `json:"overlap"`
+	Mode            Mode   `json:"mode"`
+	BreakOnHeadings bool   `json:"break_on_headings"`
+	IncludeHeadings bool   `json:"include_headings,omitempty"`
+	MaxChunks       int    `json:"max_chunks,omitempty"`
+	Notes           string `json:"notes,omitempty"`
+
+	// BreakOnDates suits changelogs, release notes and meeting minutes:
+	// in lines mode windows do not cross a date header ("## 2024-03-05",
+	// "Minutes of 5 March 2024"), and in every mode each chunk's
+	// EffectiveAt is the date of the last header before it.
+	BreakOnDates bool `json:"break_on_dates,omitempty"`
+
+	// GroupLists keeps bulleted and numbered lists together with their
+	// introductory sentence in lines mode, moving a window boundary to
+	// the start of a list that would otherwise be split but fits in one
+	// window.
+	GroupLists bool `json:"group_lists,omitempty"`
+
+	// AttachCaptions detects figure and table captions ("Figure 3: ...")
+	// and records them in Extra["captions"] on every chunk that contains
+	// or refers to them.
+	AttachCaptions bool `json:"attach_captions,omitempty"`
+
+	// Images handles Markdown and HTML image references: "keep" leaves
+	// them in the text and "strip" removes them from chunk text. Either
+	// way each chunk lists the images it contains, with alt text, title
+	// and caption, in Extra["images"]. ImageChunks additionally emits an
+	// "image context" chunk per described image, after the text chunks.
+	Images      string `json:"images,omitempty"`
+	ImageChunks bool   `json:"image_chunks,omitempty"`
+
+	// Links handles Markdown links, whose URLs inflate token counts:
+	// "keep" leaves them, "strip" keeps only the link text and "footnote"
+	// moves URLs to numbered footnotes at the end of the chunk. Either way
+	// each chunk lists its links in Extra["links"] for citation.
+	Links string `json:"links,omitempty"`
+
+	// ExtractTables detects Markdown pipe tables, HTML tables and CSV
+	// blocks and records, alongside the flattened text, the rows each
+	// chunk contains as JSON objects keyed by column in Extra["table"].
+	ExtractTables bool `json:"extract_tables,omitempty"`
+
+	// ExpandAcronyms finds acronyms defined anywhere in the document
+	// ("Retrieval Augmented Generation (RAG)") and records the expansions
+	// of those a chunk uses in Extra["acronyms"].
+	ExpandAcronyms bool `json:"expand_acronyms,omitempty"`
+
+	// Readability records per-chunk readability metrics (Flesch scores,
+	// average sentence and word length) in Extra["readability"].
+	Readability bool `json:"readability,omitempty"`
+
+	// ChunkTitles sets ChunkTitle on every chunk: the nearest heading
+	// before it, numbered when several chunks share one ("Install (2/3)"),
+	// or its first sentence when there is no heading.
+	ChunkTitles bool `json:"chunk_titles,omitempty"`
+
+	// TrimOverlap removes the region shared with the previous chunk from
+	// Text and stores it in OverlapText instead, so sinks billing by
+	// stored bytes don't pay for the overlap twice. StartIndex still
+	// covers the full window.
+	TrimOverlap bool `json:"trim_overlap,omitempty"`
+
+	// AllowBinary disables the check that rejects binary or garbled input
+	// with ErrBinaryContent.
+	AllowBinary bool `json:"allow_binary,omitempty"`
+
+	// Tokenizer names the tokenizer used in tokens mode, so window_size
+	// can match an embedding model's token budget (e.g. "cl100k_base"
+	// once its rank file is loaded). Defaults to whitespace splitting.
+	Tokenizer string `json:"tokenizer,omitempty"`
+
+	// MetaSchema declares required and typed base metadata keys, checked
+	// before chunking so a missing doc_id fails the request instead of a
+	// later upsert.
+	MetaSchema MetaSchema `json:"meta_schema,omitempty"`
+
+	// MetaFields maps base-meta keys onto typed Chunk fields (doc_id,
+	// title, url, author, tags, tenant, file_name, file_path, mime_type,
+	// license, retention, expires_at),
+	// e.g. {"source_url": "url"}. Keys already named like a field are
+	// promoted without a mapping; map a key to "" to keep it in Extra.
+	MetaFields map[string]string `json:"meta_fields,omitempty"`
+
+	// Children enables hierarchical (small-to-big) chunking: every chunk
+	// produced by this plan becomes a parent that is split again with the
+	// nested plan. Parents and children are returned together, linked by
+	// ParentID and ChildIDs. Only one level of children is supported.
+	Children *ChunkingPlan `json:"children,omitempty"`
+
+	// License and Retention tag every chunk, e.g. "CC-BY-4.0" and "90d".
+	// Retention is a period (see ParseRetention) that sets each chunk's
+	// expires_at, or a named policy such as "legal-hold". Metadata keys of
+	// the same name take precedence.
+	License   string `json:"license,omitempty"`
+	Retention string `json:"retention,omitempty"`
+
+	// ExpiresAt is a fixed expiry for every chunk, such as the end of a
+	// price list's validity. It wins over Retention's period but not over
+	// expires_at or retention metadata.
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+
+	// Strategy selects how boundaries are chosen: "sliding" (the default)
+	// windows over units, "semantic" breaks between sentences whose
+	// embeddings are dissimilar and needs a SemanticChunker. In semantic
+	// plans WindowSize caps the sentences per chunk and Mode and Overlap
+	// are ignored.
+	Strategy Strategy `json:"strategy,omitempty"`
+
+	// SimilarityThreshold breaks a semantic chunk wherever the cosine
+	// similarity of adjacent sentences falls below it. SimilarityPercentile
+	// instead derives the threshold from the document's own similarity
+	// distribution, e.g. 10 breaks at its lowest tenth. At most one may be
+	// set; the default is the 10th percentile.
+	SimilarityThreshold  float64 `json:"similarity_threshold,omitempty"`
+	SimilarityPercentile float64 `json:"similarity_percentile,omitempty"`
+
+	// HeadingLanguages selects the language packs used to recognize
+	// headings in non-Latin scripts (e.g. "zh", "ja", "ko", "ru"). All
+	// registered packs are used when empty.
+	HeadingLanguages []string `json:"heading_languages,omitempty"`
+
+	// Locale is the BCP 47 locale of the text, e.g. "de", "ja" or
+	// "th-TH". It selects the language's sentence and word segmentation
+	// rules for semantic chunking, chunk titles and readability, and in
+	// tokens mode without a Tokenizer splits Japanese, Chinese and Thai
+	// into words. Language-neutral rules are used when empty.
+	Locale string `json:"locale,omitempty"`
+
+	// Enrich names external enrichers registered on the chunker, such as
+	// a summarizer, to run after the built-in ones in the order given.
+	Enrich []string `json:"enrich,omitempty"`
+
+	// EnricherPolicies sets what a failing enricher does, keyed by
+	// enricher name ("images", "links", "tables", "captions", "acronyms",
+	// "readability", "chunk_titles", "dates" or an Enrich name) or "*"
+	// for the rest: "fail" (the default), "skip" or "warn". Every chunk
+	// lists the enrichers that ran in Extra["enrichers"].
+	EnricherPolicies map[string]EnricherPolicy `json:"enricher_policies,omitempty"`
+
+	// Concatenated sets what happens to input that looks like several
+	// documents joined into one, found by SplitDocuments: "warn" (the
+	// default) marks the chunks, "split" chunks each document on its own
+	// and "ignore" skips the check.
+	Concatenated ConcatPolicy `json:"concatenated,omitempty"`
 }
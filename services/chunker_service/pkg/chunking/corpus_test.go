@@ -0,0 +1,113 @@
+package chunking
+
+import (
+	"testing"
+
+	"chunker-service/pkg/corpusgen"
+)
+
+// generated returns a corpus covering every corpusgen language and
+// heading style, with noise.
+func generated(t testing.TB) []corpusgen.Document {
+	var docs []corpusgen.Document
+	for _, style := range []string{corpusgen.StyleMarkdown, corpusgen.StyleNative} {
+		depth := 3
+		batch, err := corpusgen.Generate(corpusgen.Spec{
+			Seed: 42, Documents: len(corpusgen.Languages()), Depth: &depth,
+			Languages: corpusgen.Languages(), HeadingStyle: style,
+			Noise: corpusgen.Noise{PageMarkers: 0.3, Whitespace: 0.2, Hyphenation: 0.1},
+		})
+		if err != nil {
+			t.Fatalf("generating corpus: %v", err)
+		}
+		docs = append(docs, batch...)
+	}
+	return docs
+}
+
+func TestGeneratedCorpusSegmentation(t *testing.T) {
+	for _, doc := range generated(t) {
+		headings, err := DetectHeadings(doc.Text, nil)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if len(headings) != len(doc.Headings) {
+			t.Fatalf("%s (%s): expected %d headings, detected %d: %+v", doc.Name, doc.Language, len(doc.Headings), len(headings), headings)
+		}
+		for i, want := range doc.Headings {
+			if got := headings[i]; got.Line != want.Line || got.Level != want.Level || got.Text != want.Text {
+				t.Fatalf("%s (%s): expected heading %+v, detected %+v", doc.Name, doc.Language, want, got)
+			}
+		}
+
+		found := map[textSpan]bool{}
+		for _, s := range segmenterFor(doc.Language).sentences(doc.Text) {
+			found[s] = true
+		}
+		for _, s := range doc.Sentences {
+			if !found[textSpan{start: s.Start, end: s.End}] {
+				t.Fatalf("%s (%s): sentence %q was not segmented", doc.Name, doc.Language, doc.Text[s.Start:s.End])
+			}
+		}
+	}
+}
+
+func TestGeneratedCorpusBreakOnHeadings(t *testing.T) {
+	plan := ChunkingPlan{WindowSize: 4, Overlap: 1, Mode: ModeLines, BreakOnHeadings: true}
+	for _, doc := range generated(t) {
+		chunks, err := NewSlidingWindowChunker().Chunk(doc.Text, plan, nil)
+		if err != nil {
+			t.Fatalf("%s: chunking failed: %v", doc.Name, err)
+		}
+		for _, ch := range chunks {
+			for _, h := range doc.Headings {
+				if ch.ByteStart < h.Start && h.Start < ch.ByteEnd {
+					t.Fatalf("%s (%s): chunk %s crosses heading %q", doc.Name, doc.Language, ch.ID, h.Text)
+				}
+			}
+		}
+	}
+}
+
+func FuzzChunkOffsets(f *testing.F) {
+	for _, doc := range generated(f) {
+		f.Add(doc.Text, 20, 5)
+	}
+	f.Fuzz(func(t *testing.T, text string, window, overlap int) {
+		if window < 1 || window > 200 || overlap < 0 || overlap >= window {
+			t.Skip()
+		}
+		plan := ChunkingPlan{WindowSize: window, Overlap: overlap, Mode: ModeTokens}
+		chunks, err := NewSlidingWindowChunker().Chunk(text, plan, nil)
+		if err != nil {
+			return
+		}
+		runes := []rune(text)
+		for i, ch := range chunks {
+			if ch.ByteStart < 0 || ch.ByteEnd > len(text) || ch.ByteStart > ch.ByteEnd {
+				t.Fatalf("chunk %d: offsets %d-%d outside the text", i, ch.ByteStart, ch.ByteEnd)
+			}
+			if string(runes[ch.RuneStart:ch.RuneEnd]) != text[ch.ByteStart:ch.ByteEnd] {
+				t.Fatalf("chunk %d: rune and byte offsets disagree", i)
+			}
+		}
+	})
+}
+
+func BenchmarkSlidingWindowGenerated(b *testing.B) {
+	docs := generated(b)
+	plan := ChunkingPlan{WindowSize: 200, Overlap: 40, Mode: ModeTokens}
+	var size int64
+	for _, doc := range docs {
+		size += int64(len(doc.Text))
+	}
+	b.SetBytes(size)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		for _, doc := range docs {
+			if _, err := NewSlidingWindowChunker().Chunk(doc.Text, plan, nil); err != nil {
+				b.Fatal(err)
+			}
+		}
+	}
+}
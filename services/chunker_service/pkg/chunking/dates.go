@@ -0,0 +1,160 @@
+package chunking
+
+import (
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+)
+
+const monthNames = `jan(?:uary)?|feb(?:ruary)?|mar(?:ch)?|apr(?:il)?|may|june?|july?|aug(?:ust)?|sep(?:t(?:ember)?)?|oct(?:ober)?|nov(?:ember)?|dec(?:ember)?`
+
+var (
+	isoDatePattern  = regexp.MustCompile(`\b(\d{4})[-/.](\d{1,2})[-/.](\d{1,2})\b`)
+	dayMonthPattern = regexp.MustCompile(`(?i)\b(\d{1,2})(?:st|nd|rd|th)?\s+(` + monthNames + `)\.?,?\s+(\d{4})\b`)
+	monthDayPattern = regexp.MustCompile(`(?i)\b(` + monthNames + `)\.?\s+(\d{1,2})(?:st|nd|rd|th)?,?\s+(\d{4})\b`)
+)
+
+// maxDateHeaderWords bounds plain (non-heading) lines accepted as date
+// headers, so a dated sentence in running text does not start a section.
+const maxDateHeaderWords = 8
+
+// findDate returns the first calendar date written in s as 2024-03-05,
+// 2024/3/5, "5 March 2024" or "March 5th, 2024".
+func findDate(s string) (time.Time, bool) {
+	type match struct {
+		pos     int
+		y, m, d string
+	}
+	var found []match
+	if m := isoDatePattern.FindStringSubmatchIndex(s); m != nil {
+		found = append(found, match{m[0], s[m[2]:m[3]], s[m[4]:m[5]], s[m[6]:m[7]]})
+	}
+	if m := dayMonthPattern.FindStringSubmatchIndex(s); m != nil {
+		found = append(found, match{m[0], s[m[6]:m[7]], s[m[4]:m[5]], s[m[2]:m[3]]})
+	}
+	if m := monthDayPattern.FindStringSubmatchIndex(s); m != nil {
+		found = append(found, match{m[0], s[m[6]:m[7]], s[m[2]:m[3]], s[m[4]:m[5]]})
+	}
+	sort.Slice(found, func(i, j int) bool { return found[i].pos < found[j].pos })
+	for _, f := range found {
+		year, _ := strconv.Atoi(f.y)
+		day, _ := strconv.Atoi(f.d)
+		month, err := strconv.Atoi(f.m)
+		if err != nil {
+			month = monthNumber(f.m)
+		}
+		if month < 1 || month > 12 || day < 1 {
+			continue
+		}
+		t := time.Date(year, time.Month(month), day, 0, 0, 0, 0, time.UTC)
+		if t.Day() != day {
+			continue // e.g. February 30th
+		}
+		return t, true
+	}
+	return time.Time{}, false
+}
+
+func monthNumber(name string) int {
+	prefix := strings.ToLower(name)
+	if len(prefix) > 3 {
+		prefix = prefix[:3]
+	}
+	i := strings.Index("janfebmaraprmayjunjulaugsepoctnovdec", prefix)
+	if i < 0 {
+		return 0
+	}
+	return i/3 + 1
+}
+
+// dateHeader reports whether line opens a dated section, such as a
+// changelog entry "## [1.4.0] - 2024-03-05" or a minutes header
+// "Meeting of 5 March 2024", and returns its date. Headings qualify when
+// they contain a date; other lines must be short and not end a sentence.
+func dateHeader(line string, packs []LanguagePack) (time.Time, bool) {
+	trimmed := strings.TrimSpace(line)
+	if trimmed == "" || utf8.RuneCountInString(trimmed) > 100 {
+		return time.Time{}, false
+	}
+	date, ok := findDate(trimmed)
+	if !ok {
+		return time.Time{}, false
+	}
+	if isHeading(trimmed, packs) {
+		return date, true
+	}
+	if len(strings.Fields(trimmed)) > maxDateHeaderWords {
+		return time.Time{}, false
+	}
+	last, _ := utf8.DecodeLastRuneInString(trimmed)
+	return date, last != '.' && last != '!' && last != '?'
+}
+
+// splitAtDates cuts segments at date header lines. A cut is skipped while
+// the part before it holds nothing but headings and blank lines, so a
+// heading directly followed by its date stays one section. Parts split
+// off a heading's section keep its heading.
+func splitAtDates(segments []segment, lines []string, packs []LanguagePack) []segment {
+	var out []segment
+	for _, seg := range segments {
+		part := seg
+		for i := seg.start + 1; i < seg.end; i++ {
+			if _, ok := dateHeader(lines[i], packs); !ok || !hasBody(lines[part.start:i], packs) {
+				continue
+			}
+			part.end = i
+			out = append(out, part)
+			part = segment{start: i, end: seg.end, heading: seg.heading, level: seg.level, continued: seg.heading != ""}
+		}
+		out = append(out, part)
+	}
+	return out
+}
+
+func hasBody(lines []string, packs []LanguagePack) bool {
+	for _, line := range lines {
+		if strings.TrimSpace(line) != "" && !isHeading(line, packs) {
+			return true
+		}
+	}
+	return false
+}
+
+// attachDates sets EffectiveAt on every chunk to the date of the last
+// date header at or before the chunk's start. A chunk that opens with
+// headings followed by a date header, such as a document title and the
+// first entry, takes that header's date instead.
+func attachDates(text string, chunks []Chunk, packs []LanguagePack) {
+	type dated struct {
+		offset int
+		date   time.Time
+	}
+	var headers []dated
+	offset := 0
+	for _, line := range strings.SplitAfter(text, "\n") {
+		if d, ok := dateHeader(line, packs); ok {
+			headers = append(headers, dated{offset, d})
+		}
+		offset += len(line)
+	}
+	if len(headers) == 0 {
+		return
+	}
+	for i := range chunks {
+		lead := len(chunks[i].Text) - len(strings.TrimLeftFunc(chunks[i].Text, unicode.IsSpace))
+		start := chunks[i].ByteStart + lead
+		n := sort.Search(len(headers), func(j int) bool { return headers[j].offset > start })
+		if n < len(headers) && headers[n].offset < chunks[i].ByteEnd &&
+			!hasBody(strings.Split(text[start:headers[n].offset], "\n"), packs) {
+			n++
+		}
+		if n > 0 {
+			d := headers[n-1].date
+			chunks[i].EffectiveAt = &d
+		}
+	}
+}
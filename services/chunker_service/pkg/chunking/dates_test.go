@@ -0,0 +1,68 @@
+package chunking
+
+import (
+	"testing"
+	"time"
+)
+
+func TestFindDate(t *testing.T) {
+	cases := map[string]string{
+		"## [1.4.0] - 2024-03-05":         "2024-03-05",
+		"Minutes of 5th March 2024":       "2024-03-05",
+		"Sept. 30, 2023 release":          "2023-09-30",
+		"Released 2024/2/29":              "2024-02-29",
+		"February 30, 2024 then 2024-1-2": "2024-01-02",
+	}
+	for in, want := range cases {
+		got, ok := findDate(in)
+		if !ok || got.Format("2006-01-02") != want {
+			t.Errorf("%q: expected %s, got %v (%t)", in, want, got, ok)
+		}
+	}
+	for _, in := range []string{"version 1.2.3", "2024-13-01", "in March"} {
+		if got, ok := findDate(in); ok {
+			t.Errorf("%q: expected no date, got %v", in, got)
+		}
+	}
+}
+
+func TestBreakOnDates(t *testing.T) {
+	text := "# Changelog\n" +
+		"## 2024-03-05\nFixed login.\nFaster search.\n" +
+		"## 2024-02-01\nInitial release.\n" +
+		"Shipped on 2024-02-01 after a long beta that everyone enjoyed a lot.\n" +
+		"Meeting of 9 January 2024\nAgreed on scope."
+	chunks, err := NewSlidingWindowChunker().Chunk(text,
+		ChunkingPlan{WindowSize: 10, Mode: ModeLines, BreakOnDates: true}, nil)
+	if err != nil {
+		t.Fatalf("chunking failed: %v", err)
+	}
+	want := []struct {
+		first string
+		date  string
+	}{
+		{"# Changelog\n## 2024-03-05", "2024-03-05"},
+		{"## 2024-02-01", "2024-02-01"},
+		{"Meeting of 9 January 2024", "2024-01-09"},
+	}
+	if len(chunks) != len(want) {
+		t.Fatalf("expected %d chunks, got %d: %+v", len(want), len(chunks), chunks)
+	}
+	for i, w := range want {
+		if len(chunks[i].Text) < len(w.first) || chunks[i].Text[:len(w.first)] != w.first {
+			t.Errorf("chunk %d should start with %q, got %q", i, w.first, chunks[i].Text)
+		}
+		if chunks[i].EffectiveAt == nil || chunks[i].EffectiveAt.Format("2006-01-02") != w.date {
+			t.Errorf("chunk %d: expected effective date %s, got %v", i, w.date, chunks[i].EffectiveAt)
+		}
+	}
+
+	chars, err := NewSlidingWindowChunker().Chunk(text, ChunkingPlan{WindowSize: 30, BreakOnDates: true}, nil)
+	if err != nil {
+		t.Fatalf("chunking failed: %v", err)
+	}
+	last := chars[len(chars)-1]
+	if last.EffectiveAt == nil || !last.EffectiveAt.Equal(time.Date(2024, 1, 9, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("characters mode should still stamp dates, got %v", last.EffectiveAt)
+	}
+}
@@ -0,0 +1,195 @@
+package chunking
+
+import (
+	"context"
+	"fmt"
+)
+
+// EnricherPolicy says what a failing enricher does to the request.
+type EnricherPolicy string
+
+// Enricher policies. EnricherFail, the default, fails the request.
+// EnricherSkip returns the chunks without the enricher's output, and
+// EnricherWarn does the same but records the failure in every chunk's
+// Extra["enricher_warnings"].
+const (
+	EnricherFail EnricherPolicy = "fail"
+	EnricherSkip EnricherPolicy = "skip"
+	EnricherWarn EnricherPolicy = "warn"
+)
+
+// Enricher adds metadata to chunks from outside the chunker, such as an
+// LLM summarizer or a named-entity tagger. Chunkers run the registered
+// enrichers a plan names in Enrich after the built-in ones. Enrich may
+// change chunks in place; its changes are discarded when it fails under
+// a skip or warn policy.
+type Enricher interface {
+	Enrich(ctx context.Context, text string, chunks []Chunk) error
+}
+
+// builtinEnrichers are the plan options that enrich chunks once they are
+// cut, in the order they run.
+var builtinEnrichers = []string{"images", "links", "tables", "captions", "acronyms", "readability", "chunk_titles", "dates"}
+
+// checkEnrichers rejects enrichers that are not registered and policies
+// for enrichers the plan cannot run. "*" sets the default policy.
+func checkEnrichers(plan ChunkingPlan, enrichers map[string]Enricher) error {
+	known := map[string]bool{"*": true}
+	for _, name := range builtinEnrichers {
+		known[name] = true
+	}
+	for _, name := range plan.Enrich {
+		if enrichers[name] == nil {
+			return fmt.Errorf("enricher %q is not configured", name)
+		}
+		known[name] = true
+	}
+	for name, policy := range plan.EnricherPolicies {
+		if !known[name] {
+			return fmt.Errorf("enricher_policies: unknown enricher %q", name)
+		}
+		switch policy {
+		case EnricherFail, EnricherSkip, EnricherWarn:
+		default:
+			return fmt.Errorf("enricher_policies: %s: policy must be fail, skip or warn", name)
+		}
+	}
+	return nil
+}
+
+// enrichment runs one document's enrichers under the plan's policies and
+// records which of them ran.
+type enrichment struct {
+	plan     ChunkingPlan
+	trace    *Trace
+	ran      []string
+	warnings map[string]string
+}
+
+func (e *enrichment) policy(name string) EnricherPolicy {
+	if p, ok := e.plan.EnricherPolicies[name]; ok {
+		return p
+	}
+	if p, ok := e.plan.EnricherPolicies["*"]; ok {
+		return p
+	}
+	return EnricherFail
+}
+
+// run applies fn to chunks as the enricher name. Under a skip or warn
+// policy fn works on a copy, so a failure leaves chunks as they were.
+func (e *enrichment) run(name string, chunks []Chunk, fn func([]Chunk) error) ([]Chunk, error) {
+	policy := e.policy(name)
+	work := chunks
+	if policy != EnricherFail {
+		work = cloneChunks(chunks)
+	}
+	if err := guard(fn, work); err != nil {
+		if policy == EnricherFail {
+			return nil, fmt.Errorf("%w: %s: %v", ErrEnricherFailed, name, err)
+		}
+		e.trace.add("enricher", map[string]interface{}{"enricher": name, "policy": policy, "error": err.Error()},
+			"enricher %s failed and was skipped: %v", name, err)
+		if policy == EnricherWarn {
+			if e.warnings == nil {
+				e.warnings = map[string]string{}
+			}
+			e.warnings[name] = err.Error()
+		}
+		return chunks, nil
+	}
+	e.ran = append(e.ran, name)
+	return work, nil
+}
+
+// guard runs fn, turning a panic into an error so a bug in one enricher
+// is subject to its policy like any other failure.
+func guard(fn func([]Chunk) error, chunks []Chunk) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("panic: %v", r)
+		}
+	}()
+	return fn(chunks)
+}
+
+// record lists the enrichers that ran, and the failures a warn policy
+// kept, on every chunk.
+func (e *enrichment) record(chunks []Chunk) {
+	for i := range chunks {
+		if len(e.ran) > 0 {
+			chunks[i].Extra["enrichers"] = append([]string(nil), e.ran...)
+		}
+		if len(e.warnings) > 0 {
+			warnings := make(map[string]string, len(e.warnings))
+			for name, msg := range e.warnings {
+				warnings[name] = msg
+			}
+			chunks[i].Extra["enricher_warnings"] = warnings
+		}
+	}
+}
+
+// enrich runs the plan's built-in enrichers and then the registered ones
+// it names. It returns the enriched chunks and the document's image
+// references for image chunks.
+func enrich(ctx context.Context, text string, chunks []Chunk, plan ChunkingPlan, packs []LanguagePack, enrichers map[string]Enricher, trace *Trace) ([]Chunk, []imageRef, error) {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	e := &enrichment{plan: plan, trace: trace}
+	var images []imageRef
+	steps := []struct {
+		name    string
+		enabled bool
+		fn      func([]Chunk)
+	}{
+		{"images", plan.Images != "" || plan.ImageChunks, func(c []Chunk) { images = applyImages(text, c, plan) }},
+		{"links", plan.Links != "", func(c []Chunk) { applyLinks(c, plan.Links) }},
+		{"tables", plan.ExtractTables, func(c []Chunk) { attachTables(text, c) }},
+		{"captions", plan.AttachCaptions, func(c []Chunk) { attachCaptions(text, c) }},
+		{"acronyms", plan.ExpandAcronyms, func(c []Chunk) { attachGlossary(BuildGlossary(text), c) }},
+		{"readability", plan.Readability, func(c []Chunk) {
+			for i := range c {
+				c[i].Extra["readability"] = ComputeLocaleReadability(c[i].Text, plan.Locale)
+			}
+		}},
+		{"chunk_titles", plan.ChunkTitles, func(c []Chunk) { attachTitles(text, c, packs, segmenterFor(plan.Locale)) }},
+		{"dates", plan.BreakOnDates, func(c []Chunk) { attachDates(text, c, packs) }},
+	}
+	var err error
+	for _, step := range steps {
+		if !step.enabled {
+			continue
+		}
+		fn := step.fn
+		if chunks, err = e.run(step.name, chunks, func(c []Chunk) error { fn(c); return nil }); err != nil {
+			return nil, nil, err
+		}
+	}
+	for _, name := range plan.Enrich {
+		enricher := enrichers[name]
+		if chunks, err = e.run(name, chunks, func(c []Chunk) error { return enricher.Enrich(ctx, text, c) }); err != nil {
+			return nil, nil, err
+		}
+		if err := checkContext(ctx); err != nil {
+			return nil, nil, err
+		}
+	}
+	e.record(chunks)
+	return chunks, images, nil
+}
+
+// cloneChunks copies chunks deeply enough for an enricher to change their
+// fields and Extra without touching the originals.
+func cloneChunks(chunks []Chunk) []Chunk {
+	out := make([]Chunk, len(chunks))
+	for i, ch := range chunks {
+		out[i] = ch
+		out[i].Extra = make(map[string]interface{}, len(ch.Extra))
+		for k, v := range ch.Extra {
+			out[i].Extra[k] = v
+		}
+	}
+	return out
+}
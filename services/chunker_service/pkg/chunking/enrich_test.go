@@ -0,0 +1,79 @@
+package chunking
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+// failingEnricher tags every chunk, then fails or panics.
+type failingEnricher struct{ panics bool }
+
+func (e failingEnricher) Enrich(_ context.Context, _ string, chunks []Chunk) error {
+	for i := range chunks {
+		chunks[i].Extra["entities"] = []string{"half-done"}
+	}
+	if e.panics {
+		panic("index out of range")
+	}
+	return errors.New("ner endpoint unavailable")
+}
+
+func TestEnricherPolicies(t *testing.T) {
+	text := "The cat sat. The dog ran.\nA bird flew. The sun set."
+	chunker := &SlidingWindowChunker{Enrichers: map[string]Enricher{
+		"ner":     failingEnricher{},
+		"summary": failingEnricher{panics: true},
+	}}
+	plan := ChunkingPlan{WindowSize: 1, Mode: ModeLines, Readability: true, Enrich: []string{"ner"}}
+
+	if _, err := chunker.Chunk(text, plan, nil); !errors.Is(err, ErrEnricherFailed) {
+		t.Fatalf("expected the default policy to fail the request, got %v", err)
+	}
+
+	plan.EnricherPolicies = map[string]EnricherPolicy{"ner": EnricherSkip}
+	chunks, err := chunker.Chunk(text, plan, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	for _, ch := range chunks {
+		if _, ok := ch.Extra["entities"]; ok {
+			t.Fatalf("a skipped enricher's partial output should be discarded: %+v", ch.Extra)
+		}
+		if _, ok := ch.Extra["enricher_warnings"]; ok {
+			t.Fatalf("skip should not record a warning: %+v", ch.Extra)
+		}
+		if got := ch.Extra["enrichers"]; !reflect.DeepEqual(got, []string{"readability"}) {
+			t.Fatalf("expected only readability to have run, got %v", got)
+		}
+	}
+
+	plan.Enrich = []string{"summary", "ner"}
+	plan.EnricherPolicies = map[string]EnricherPolicy{"*": EnricherWarn}
+	chunks, err = chunker.Chunk(text, plan, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := map[string]string{"summary": "panic: index out of range", "ner": "ner endpoint unavailable"}
+	if got := chunks[1].Extra["enricher_warnings"]; !reflect.DeepEqual(got, want) {
+		t.Fatalf("expected both failures as warnings, got %v", got)
+	}
+}
+
+func TestCheckEnrichers(t *testing.T) {
+	enrichers := map[string]Enricher{"ner": failingEnricher{}}
+	for _, plan := range []ChunkingPlan{
+		{Enrich: []string{"summary"}},
+		{EnricherPolicies: map[string]EnricherPolicy{"summary": EnricherSkip}},
+		{EnricherPolicies: map[string]EnricherPolicy{"tables": "ignore"}},
+	} {
+		if err := checkEnrichers(plan, enrichers); err == nil {
+			t.Errorf("expected %+v to be rejected", plan)
+		}
+	}
+	ok := ChunkingPlan{Enrich: []string{"ner"}, EnricherPolicies: map[string]EnricherPolicy{"ner": EnricherWarn, "*": EnricherSkip}}
+	if err := checkEnrichers(ok, enrichers); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
@@ -5,3 +5,23 @@ import "errors"
 // ErrNotImplemented is returned by placeholder functions where the
 // underlying logic has not yet been implemented.
 var ErrNotImplemented = errors.New("chunking not implemented")
+
+// ErrOutputTooLarge is returned when chunking a request would exceed the
+// chunker's OutputLimits.
+var ErrOutputTooLarge = errors.New("chunk output exceeds limit")
+
+// ErrBinaryContent is returned when the input looks like binary data or
+// extraction garbage rather than text.
+var ErrBinaryContent = errors.New("input is not text")
+
+// ErrInvalidMeta is returned when base metadata does not satisfy the
+// plan's or chunker's metadata schema.
+var ErrInvalidMeta = errors.New("invalid metadata")
+
+// ErrEmbeddingFailed is returned when the semantic chunker's embedder
+// fails, so callers can tell upstream outages from bad requests.
+var ErrEmbeddingFailed = errors.New("embedding failed")
+
+// ErrEnricherFailed is returned when an enricher fails under the default
+// "fail" policy.
+var ErrEnricherFailed = errors.New("enricher failed")
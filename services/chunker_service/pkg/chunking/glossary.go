@@ -0,0 +1,126 @@
+package chunking
+
+import (
+	"regexp"
+	"strings"
+	"unicode"
+)
+
+var (
+	// "Retrieval Augmented Generation (RAG)"
+	acronymAfterPattern = regexp.MustCompile(`\(([A-Z][A-Za-z0-9&]*[A-Z][A-Za-z0-9&]*s?)\)`)
+	// "RAG (Retrieval Augmented Generation)"
+	acronymBeforePattern = regexp.MustCompile(`\b([A-Z][A-Za-z0-9&]*[A-Z][A-Za-z0-9&]*s?)\s+\(([^()]+)\)`)
+	acronymUsePattern    = regexp.MustCompile(`\b[A-Z][A-Za-z0-9&]*[A-Z][A-Za-z0-9&]*s?\b`)
+)
+
+// glossaryStopWords may appear inside an expansion without contributing a
+// letter to the acronym ("Bureau of Labor Statistics (BLS)").
+var glossaryStopWords = map[string]bool{
+	"a": true, "an": true, "and": true, "as": true, "by": true, "for": true,
+	"in": true, "of": true, "on": true, "or": true, "the": true, "to": true,
+	"with": true, "&": true,
+}
+
+// BuildGlossary scans text for defined acronyms in either the
+// "Long Form (LF)" or "LF (Long Form)" style and returns the expansions
+// keyed by acronym. The first definition of each acronym wins.
+func BuildGlossary(text string) map[string]string {
+	glossary := map[string]string{}
+
+	for _, loc := range acronymAfterPattern.FindAllStringSubmatchIndex(text, -1) {
+		acronym := text[loc[2]:loc[3]]
+		if _, ok := glossary[acronym]; ok {
+			continue
+		}
+		if long, ok := expandBackwards(acronym, strings.Fields(text[:loc[0]])); ok {
+			glossary[acronym] = long
+		}
+	}
+	for _, m := range acronymBeforePattern.FindAllStringSubmatch(text, -1) {
+		acronym := m[1]
+		if _, ok := glossary[acronym]; ok {
+			continue
+		}
+		words := strings.Fields(m[2])
+		if long, ok := expandBackwards(acronym, words); ok && long == strings.Join(words, " ") {
+			glossary[acronym] = long
+		}
+	}
+	return glossary
+}
+
+// expandBackwards matches the letters of acronym, last to first, against
+// the initials of the words preceding it (and the parts of hyphenated
+// words). It returns the shortest run of words that spells the acronym.
+func expandBackwards(acronym string, words []string) (string, bool) {
+	var letters []rune
+	for _, r := range strings.TrimSuffix(acronym, "s") {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			letters = append(letters, unicode.ToLower(r))
+		}
+	}
+	if len(letters) < 2 {
+		return "", false
+	}
+
+	limit := len(words) - (len(letters) + 5)
+	if limit < 0 {
+		limit = 0
+	}
+	j := len(letters) - 1
+	for i := len(words) - 1; i >= limit && j >= 0; i-- {
+		word := strings.Trim(words[i], `"'“”,;:`)
+		parts := strings.Split(word, "-")
+		matched := false
+		for p := len(parts) - 1; p >= 0 && j >= 0; p-- {
+			r := firstRune(parts[p])
+			if r == letters[j] {
+				j--
+				matched = true
+			} else if p == 0 || matched {
+				break
+			}
+		}
+		if !matched {
+			if glossaryStopWords[strings.ToLower(word)] {
+				continue
+			}
+			return "", false
+		}
+		if j < 0 {
+			long := make([]string, 0, len(words)-i)
+			for _, w := range words[i:] {
+				long = append(long, strings.Trim(w, `"'“”,;:`))
+			}
+			return strings.Join(long, " "), true
+		}
+	}
+	return "", false
+}
+
+func firstRune(s string) rune {
+	for _, r := range s {
+		return unicode.ToLower(r)
+	}
+	return 0
+}
+
+// attachGlossary records in Extra["acronyms"] the expansions of every
+// glossary acronym a chunk uses.
+func attachGlossary(glossary map[string]string, chunks []Chunk) {
+	if len(glossary) == 0 {
+		return
+	}
+	for i := range chunks {
+		used := map[string]string{}
+		for _, word := range acronymUsePattern.FindAllString(chunks[i].Text, -1) {
+			if long, ok := glossary[word]; ok {
+				used[word] = long
+			}
+		}
+		if len(used) > 0 {
+			chunks[i].Extra["acronyms"] = used
+		}
+	}
+}
@@ -0,0 +1,44 @@
+package chunking
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestBuildGlossary(t *testing.T) {
+	text := `We use Retrieval Augmented Generation (RAG) throughout.
+The Department of Energy (DOE) funds the work, and the
+LLM (large language model) answers questions.
+A Retrieval-Augmented Pipeline (RAP) is different. Nothing matches (XYZ) here.`
+
+	got := BuildGlossary(text)
+	want := map[string]string{
+		"RAG": "Retrieval Augmented Generation",
+		"DOE": "Department of Energy",
+		"LLM": "large language model",
+		"RAP": "Retrieval-Augmented Pipeline",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("glossary = %v, want %v", got, want)
+	}
+}
+
+func TestChunkExpandAcronyms(t *testing.T) {
+	chunker := NewSlidingWindowChunker()
+	plan := ChunkingPlan{WindowSize: 1, Overlap: 0, Mode: ModeLines, ExpandAcronyms: true}
+	text := "Retrieval Augmented Generation (RAG) grounds answers.\nRAG needs a good chunker.\nNo acronyms here."
+
+	chunks, err := chunker.Chunk(text, plan, nil)
+	if err != nil {
+		t.Fatalf("chunking failed: %v", err)
+	}
+	for i, want := range []bool{true, true, false} {
+		acronyms, ok := chunks[i].Extra["acronyms"].(map[string]string)
+		if ok != want {
+			t.Fatalf("chunk %d acronyms = %v, want present=%v", i, chunks[i].Extra["acronyms"], want)
+		}
+		if ok && acronyms["RAG"] != "Retrieval Augmented Generation" {
+			t.Errorf("chunk %d RAG expansion = %q", i, acronyms["RAG"])
+		}
+	}
+}
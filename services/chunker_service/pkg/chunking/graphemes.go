@@ -0,0 +1,138 @@
+package chunking
+
+import (
+	"unicode"
+	"unicode/utf8"
+)
+
+// splitGraphemes splits text into extended grapheme clusters, applying the
+// UAX #29 rules that matter for real-world text: CR LF, controls,
+// combining marks and other extenders, spacing marks, Hangul syllable
+// sequences, ZWJ emoji sequences and regional-indicator flag pairs.
+func splitGraphemes(text string) []string {
+	var out []string
+	start := 0
+	prev := graphemeProperty(-1)
+	prevPictographic := false // cluster so far is an emoji plus extenders
+	regional := 0             // regional indicators in the current cluster
+	for i := 0; i < len(text); {
+		r, size := utf8.DecodeRuneInString(text[i:])
+		prop := graphemeProp(r)
+		if i > start && graphemeBreak(prev, prop, prevPictographic && isPictographic(r), regional) {
+			out = append(out, text[start:i])
+			start = i
+			prevPictographic = false
+			regional = 0
+		}
+		switch {
+		case isPictographic(r):
+			prevPictographic = true
+		case prop != gpExtend && prop != gpZWJ:
+			prevPictographic = false
+		}
+		if prop == gpRegional {
+			regional++
+		}
+		prev = prop
+		i += size
+	}
+	if start < len(text) {
+		out = append(out, text[start:])
+	}
+	return out
+}
+
+type graphemeProperty int
+
+const (
+	gpOther graphemeProperty = iota
+	gpCR
+	gpLF
+	gpControl
+	gpExtend
+	gpZWJ
+	gpSpacingMark
+	gpRegional
+	gpL
+	gpV
+	gpT
+	gpLV
+	gpLVT
+)
+
+// graphemeBreak reports whether a cluster boundary falls between a rune
+// with property prev and the next rune with property next. emojiJoin is
+// set when the cluster is an emoji followed by extenders and the next rune
+// is an emoji too; regional counts the cluster's regional indicators.
+func graphemeBreak(prev, next graphemeProperty, emojiJoin bool, regional int) bool {
+	switch {
+	case prev == gpCR && next == gpLF: // GB3
+		return false
+	case prev == gpCR || prev == gpLF || prev == gpControl: // GB4
+		return true
+	case next == gpCR || next == gpLF || next == gpControl: // GB5
+		return true
+	case prev == gpL && (next == gpL || next == gpV || next == gpLV || next == gpLVT): // GB6
+		return false
+	case (prev == gpLV || prev == gpV) && (next == gpV || next == gpT): // GB7
+		return false
+	case (prev == gpLVT || prev == gpT) && next == gpT: // GB8
+		return false
+	case next == gpExtend || next == gpZWJ || next == gpSpacingMark: // GB9, GB9a
+		return false
+	case prev == gpZWJ && emojiJoin: // GB11
+		return false
+	case prev == gpRegional && next == gpRegional: // GB12, GB13
+		return regional%2 == 0
+	}
+	return true
+}
+
+func graphemeProp(r rune) graphemeProperty {
+	switch {
+	case r == '\r':
+		return gpCR
+	case r == '\n':
+		return gpLF
+	case r == 0x200D:
+		return gpZWJ
+	case r == 0x200C, r >= 0xE0020 && r <= 0xE007F, r >= 0x1F3FB && r <= 0x1F3FF:
+		// ZWNJ, emoji tag characters and skin-tone modifiers extend.
+		return gpExtend
+	case unicode.In(r, unicode.Mn, unicode.Me):
+		return gpExtend
+	case unicode.Is(unicode.Mc, r):
+		return gpSpacingMark
+	case unicode.In(r, unicode.Cc, unicode.Cf, unicode.Zl, unicode.Zp):
+		return gpControl
+	case r >= 0x1F1E6 && r <= 0x1F1FF:
+		return gpRegional
+	case r >= 0x1100 && r <= 0x115F, r >= 0xA960 && r <= 0xA97C:
+		return gpL
+	case r >= 0x1160 && r <= 0x11A7, r >= 0xD7B0 && r <= 0xD7C6:
+		return gpV
+	case r >= 0x11A8 && r <= 0x11FF, r >= 0xD7CB && r <= 0xD7FB:
+		return gpT
+	case r >= 0xAC00 && r <= 0xD7A3:
+		if (r-0xAC00)%28 == 0 {
+			return gpLV
+		}
+		return gpLVT
+	}
+	return gpOther
+}
+
+// isPictographic approximates Extended_Pictographic with the blocks that
+// hold emoji.
+func isPictographic(r rune) bool {
+	switch {
+	case r >= 0x1F000 && r <= 0x1FAFF && !(r >= 0x1F1E6 && r <= 0x1F1FF) && !(r >= 0x1F3FB && r <= 0x1F3FF):
+		return true
+	case r >= 0x2600 && r <= 0x27BF, r >= 0x2300 && r <= 0x23FF, r >= 0x2B00 && r <= 0x2BFF:
+		return true
+	case r == 0x00A9, r == 0x00AE, r == 0x203C, r == 0x2049, r == 0x2122, r == 0x2139,
+		r >= 0x2194 && r <= 0x21AA, r == 0x3030, r == 0x303D, r == 0x3297, r == 0x3299:
+		return true
+	}
+	return false
+}
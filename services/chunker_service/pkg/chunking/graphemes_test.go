@@ -0,0 +1,46 @@
+package chunking
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSplitGraphemes(t *testing.T) {
+	cases := []struct {
+		name string
+		text string
+		want []string
+	}{
+		{"combining mark", "e\u0301a", []string{"e\u0301", "a"}},
+		{"crlf", "a\r\nb", []string{"a", "\r\n", "b"}},
+		{"skin tone", "\U0001F44D\U0001F3FD!", []string{"\U0001F44D\U0001F3FD", "!"}},
+		{"zwj family", "\U0001F468\u200D\U0001F469\u200D\U0001F467x", []string{"\U0001F468\u200D\U0001F469\u200D\U0001F467", "x"}},
+		{"flags", "\U0001F1EF\U0001F1F5\U0001F1EB\U0001F1F7", []string{"\U0001F1EF\U0001F1F5", "\U0001F1EB\U0001F1F7"}},
+		{"variation selector", "\u2764\uFE0F", []string{"\u2764\uFE0F"}},
+		{"hangul jamo", "\u1100\u1161\u11A8\uAC00", []string{"\u1100\u1161\u11A8", "\uAC00"}},
+		{"spacing mark", "\u0915\u093F", []string{"\u0915\u093F"}},
+		{"zwj between letters", "a\u200Db", []string{"a\u200D", "b"}},
+	}
+	for _, tc := range cases {
+		if got := splitGraphemes(tc.text); !reflect.DeepEqual(got, tc.want) {
+			t.Errorf("%s: expected %q, got %q", tc.name, tc.want, got)
+		}
+	}
+}
+
+func TestChunkGraphemes(t *testing.T) {
+	chunker := NewSlidingWindowChunker()
+	chunks, err := chunker.Chunk("ok \U0001F44D\U0001F3FD cafe\u0301", ChunkingPlan{WindowSize: 4, Mode: ModeGraphemes}, nil)
+	if err != nil {
+		t.Fatalf("chunking failed: %v", err)
+	}
+	wantTexts := []string{"ok \U0001F44D\U0001F3FD", " caf", "e\u0301"}
+	if len(chunks) != len(wantTexts) {
+		t.Fatalf("expected %d chunks, got %+v", len(wantTexts), chunks)
+	}
+	for i, ch := range chunks {
+		if ch.Text != wantTexts[i] {
+			t.Errorf("chunk %d: expected %q, got %q", i, wantTexts[i], ch.Text)
+		}
+	}
+}
@@ -0,0 +1,100 @@
+package chunking
+
+import (
+	"strings"
+	"unicode"
+)
+
+// Heading heuristics reported in HeadingDecision.Heuristic.
+const (
+	HeuristicMarkdown     = "markdown"
+	HeuristicNumbered     = "numbered"
+	HeuristicLanguagePack = "language_pack"
+	HeuristicUppercase    = "uppercase"
+)
+
+// HeadingDecision explains why a line was treated as a heading.
+type HeadingDecision struct {
+	Line      int    `json:"line"`
+	Text      string `json:"text"`
+	Level     int    `json:"level"`
+	Heuristic string `json:"heuristic"`
+	// Pack names the language pack whose pattern matched, for the
+	// language_pack heuristic.
+	Pack string `json:"pack,omitempty"`
+	// Confidence is 1 for explicit markup and lower for inferred
+	// headings; uppercase lines score by their share of capital letters.
+	Confidence float64 `json:"confidence"`
+}
+
+// classifyHeading applies the heading heuristics in priority order and
+// reports the first one that fires.
+func classifyHeading(line string, packs []LanguagePack) (HeadingDecision, bool) {
+	trimmed := strings.TrimSpace(line)
+	if trimmed == "" {
+		return HeadingDecision{}, false
+	}
+	if strings.HasPrefix(trimmed, "#") {
+		level := 0
+		for i := 0; i < len(trimmed) && trimmed[i] == '#'; i++ {
+			level++
+		}
+		return HeadingDecision{
+			Text:       strings.TrimSpace(trimmed[level:]),
+			Level:      level,
+			Heuristic:  HeuristicMarkdown,
+			Confidence: 1,
+		}, true
+	}
+	if headingNumberPattern.MatchString(trimmed) {
+		return HeadingDecision{Text: trimmed, Level: 1, Heuristic: HeuristicNumbered, Confidence: 0.8}, true
+	}
+	if pack, level, ok := matchLanguagePacks(trimmed, packs); ok {
+		return HeadingDecision{Text: trimmed, Level: level, Heuristic: HeuristicLanguagePack, Pack: pack, Confidence: 0.8}, true
+	}
+	// Treat short, mostly-uppercase lines as headings (common in PDFs/MD).
+	if len([]rune(trimmed)) <= 80 {
+		totalLetters := 0
+		upperLetters := 0
+		for _, r := range trimmed {
+			if unicode.IsLetter(r) {
+				totalLetters++
+				if unicode.IsUpper(r) {
+					upperLetters++
+				}
+			}
+		}
+		if totalLetters > 0 && float64(upperLetters) >= 0.6*float64(totalLetters) {
+			ratio := float64(upperLetters) / float64(totalLetters)
+			return HeadingDecision{Text: trimmed, Level: 1, Heuristic: HeuristicUppercase, Confidence: round2(0.6 * ratio)}, true
+		}
+	}
+	return HeadingDecision{}, false
+}
+
+func isHeading(line string, packs []LanguagePack) bool {
+	_, ok := classifyHeading(line, packs)
+	return ok
+}
+
+func headingInfo(line string, packs []LanguagePack) (string, int) {
+	d, _ := classifyHeading(line, packs)
+	return d.Text, d.Level
+}
+
+// DetectHeadings lists every line the heading heuristics accept, with the
+// heuristic that fired, using the named language packs (all when empty).
+func DetectHeadings(text string, languages []string) ([]HeadingDecision, error) {
+	packs, err := resolveLanguagePacks(languages)
+	if err != nil {
+		return nil, err
+	}
+	var out []HeadingDecision
+	for i, line := range strings.Split(text, "\n") {
+		if d, ok := classifyHeading(line, packs); ok {
+			d.Line = i
+			out = append(out, d)
+		}
+	}
+	return out, nil
+}
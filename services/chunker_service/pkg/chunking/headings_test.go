@@ -0,0 +1,31 @@
+package chunking
+
+import "testing"
+
+func TestDetectHeadingsExplainsHeuristics(t *testing.T) {
+	text := "## Setup\nplain text here\n2.1 Install\nINSTALL NOTES\n第1章 概要\nlowercase line"
+	got, err := DetectHeadings(text, []string{"zh"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := []HeadingDecision{
+		{Line: 0, Text: "Setup", Level: 2, Heuristic: HeuristicMarkdown, Confidence: 1},
+		{Line: 2, Text: "2.1 Install", Level: 1, Heuristic: HeuristicNumbered, Confidence: 0.8},
+		{Line: 3, Text: "INSTALL NOTES", Level: 1, Heuristic: HeuristicUppercase, Confidence: 0.6},
+		{Line: 4, Text: "第1章 概要", Level: 1, Heuristic: HeuristicLanguagePack, Pack: "zh", Confidence: 0.8},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("expected %d headings, got %+v", len(want), got)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("heading %d: expected %+v, got %+v", i, want[i], got[i])
+		}
+	}
+}
+
+func TestAnalyzeLanguagesRejectsUnknownPack(t *testing.T) {
+	if _, err := AnalyzeLanguages("text", []string{"xx"}); err == nil {
+		t.Fatalf("expected error for unknown language pack")
+	}
+}
@@ -0,0 +1,85 @@
+package chunking
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+)
+
+// documentKey identifies the document in chunk IDs: its doc_id metadata
+// when present, otherwise a hash of its text.
+func documentKey(text string, baseMeta map[string]interface{}) string {
+	if id, ok := baseMeta["doc_id"].(string); ok && id != "" {
+		return id
+	}
+	sum := sha256.Sum256([]byte(text))
+	return "sha256:" + hex.EncodeToString(sum[:6])
+}
+
+// checkChildPlan validates the nested window config of a hierarchical plan.
+func checkChildPlan(plan ChunkingPlan) error {
+	if plan.Children == nil {
+		return nil
+	}
+	if plan.Children.Children != nil {
+		return errors.New("children: only one level of child chunks is supported")
+	}
+	if plan.Children.WindowSize <= 0 {
+		return errors.New("children: window_size must be > 0")
+	}
+	return nil
+}
+
+// addChildren splits every parent's source span with the child plan and
+// returns each parent followed by its children, linked by ParentID and
+// ChildIDs. Children are "<parent id>.<m>" and are numbered and linked to
+// their neighbours across the whole document. Child StartIndex/EndIndex
+// count child units within the parent; byte and rune offsets are absolute
+// in the document.
+func (c *SlidingWindowChunker) addChildren(text string, parents []Chunk, plan ChunkingPlan, baseMeta map[string]interface{}) ([]Chunk, error) {
+	child := &SlidingWindowChunker{MetaSchema: c.MetaSchema, Context: c.Context, Enrichers: c.Enrichers}
+	// The document as a whole is checked for concatenation, not each
+	// parent on its own.
+	childPlan := *plan.Children
+	childPlan.Concatenated = ConcatIgnore
+	out := make([]Chunk, 0, 2*len(parents))
+	totalBytes := 0
+	for i := range parents {
+		parent := parents[i]
+		children, err := child.Chunk(text[parent.ByteStart:parent.ByteEnd], childPlan, baseMeta)
+		if err != nil {
+			return nil, fmt.Errorf("children of chunk %d: %w", i, err)
+		}
+		for j := range children {
+			ch := &children[j]
+			ch.ID = fmt.Sprintf("%s.%d", parent.ID, j)
+			ch.ParentID = parent.ID
+			ch.ByteStart += parent.ByteStart
+			ch.ByteEnd += parent.ByteStart
+			ch.RuneStart += parent.RuneStart
+			ch.RuneEnd += parent.RuneStart
+			parent.ChildIDs = append(parent.ChildIDs, ch.ID)
+		}
+		out = append(out, parent)
+		out = append(out, children...)
+		totalBytes += len(parent.Text)
+		for _, ch := range children {
+			totalBytes += len(ch.Text)
+		}
+		if err := c.Limits.check(len(out), totalBytes); err != nil {
+			return nil, err
+		}
+	}
+	var siblings []*Chunk
+	for i := range out {
+		if out[i].ParentID != "" {
+			siblings = append(siblings, &out[i])
+		}
+	}
+	linkSequence(siblings)
+
+	c.Trace.add("children", map[string]interface{}{"parents": len(parents), "chunks": len(out)},
+		"split %d parent chunks into %d parent and child chunks", len(parents), len(out))
+	return out, nil
+}
@@ -0,0 +1,53 @@
+package chunking
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestChunkHierarchical(t *testing.T) {
+	chunker := NewSlidingWindowChunker()
+	text := "one two three four\nfive six seven eight"
+	plan := ChunkingPlan{
+		WindowSize: 1,
+		Mode:       ModeLines,
+		Children:   &ChunkingPlan{WindowSize: 2, Mode: ModeTokens},
+	}
+
+	chunks, err := chunker.Chunk(text, plan, map[string]interface{}{"doc_id": "d"})
+	if err != nil {
+		t.Fatalf("chunking failed: %v", err)
+	}
+	wantIDs := []string{"d#0", "d#0.0", "d#0.1", "d#1", "d#1.0", "d#1.1"}
+	var ids []string
+	for _, ch := range chunks {
+		ids = append(ids, ch.ID)
+	}
+	if !reflect.DeepEqual(ids, wantIDs) {
+		t.Fatalf("expected ids %v, got %v", wantIDs, ids)
+	}
+	if !reflect.DeepEqual(chunks[3].ChildIDs, []string{"d#1.0", "d#1.1"}) {
+		t.Errorf("unexpected child ids: %v", chunks[3].ChildIDs)
+	}
+	child := chunks[5]
+	if child.ParentID != "d#1" || child.Text != "seven eight" {
+		t.Errorf("unexpected child: %+v", child)
+	}
+	if got := text[child.ByteStart:child.ByteEnd]; got != "seven eight" {
+		t.Errorf("child byte offsets should be absolute, got span %q", got)
+	}
+	if child.ChunkIndex != 3 || child.PrevID != "d#1.0" || child.NextID != "" {
+		t.Errorf("children should be linked across parents: %+v", child)
+	}
+	if chunks[1].PrevID != "" || chunks[2].NextID != "d#1.0" || chunks[3].PrevID != "d#0" {
+		t.Errorf("unexpected links: %+v", chunks)
+	}
+	if child.RuneStart != child.ByteStart {
+		t.Errorf("ASCII rune offsets should match bytes: %d vs %d", child.RuneStart, child.ByteStart)
+	}
+
+	plan.Children.Children = &ChunkingPlan{WindowSize: 1}
+	if _, err := chunker.Chunk(text, plan, nil); err == nil {
+		t.Errorf("expected error for nested children")
+	}
+}
@@ -0,0 +1,198 @@
+package chunking
+
+import (
+	"fmt"
+	"html"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode/utf8"
+)
+
+// Image handling modes for ChunkingPlan.Images.
+const (
+	ImagesKeep  = "keep"
+	ImagesStrip = "strip"
+)
+
+// Image is an image referenced by the source document.
+type Image struct {
+	Src     string `json:"src"`
+	Alt     string `json:"alt,omitempty"`
+	Title   string `json:"title,omitempty"`
+	Caption string `json:"caption,omitempty"`
+}
+
+// imageRef is an Image and the byte span of its markup.
+type imageRef struct {
+	Image
+	start, end int
+}
+
+var (
+	markdownImagePattern = regexp.MustCompile(`!\[([^\]\n]*)\]\(\s*<?([^\s<>()]+)>?(?:\s+["']([^"'\n]*)["'])?\s*\)`)
+	htmlImagePattern     = regexp.MustCompile(`(?is)<img\b[^>]*>`)
+	imageAttrPattern     = regexp.MustCompile(`(?is)\b(src|alt|title)\s*=\s*(?:"([^"]*)"|'([^']*)'|([^\s"'>]+))`)
+	figcaptionPattern    = regexp.MustCompile(`(?is)^\s*(?:</?(?:a|picture|source)\b[^>]*>\s*)*<figcaption\b[^>]*>(.*?)</figcaption>`)
+	tagPattern           = regexp.MustCompile(`<[^>]*>`)
+)
+
+func checkImages(mode string) error {
+	switch mode {
+	case "", ImagesKeep, ImagesStrip:
+		return nil
+	}
+	return fmt.Errorf("images must be %q or %q, got %q", ImagesKeep, ImagesStrip, mode)
+}
+
+// findImages returns the Markdown ("![alt](src "title")") and HTML
+// ("<img src alt title>") image references in text, in order. An image's
+// caption is a following <figcaption>, or the next line when it reads
+// like a figure caption ("Figure 3: ...").
+func findImages(text string) []imageRef {
+	var refs []imageRef
+	for _, m := range markdownImagePattern.FindAllStringSubmatchIndex(text, -1) {
+		ref := imageRef{start: m[0], end: m[1]}
+		ref.Alt = strings.TrimSpace(text[m[2]:m[3]])
+		ref.Src = text[m[4]:m[5]]
+		if m[6] >= 0 {
+			ref.Title = strings.TrimSpace(text[m[6]:m[7]])
+		}
+		refs = append(refs, ref)
+	}
+	for _, m := range htmlImagePattern.FindAllStringIndex(text, -1) {
+		ref := imageRef{start: m[0], end: m[1]}
+		for _, a := range imageAttrPattern.FindAllStringSubmatch(text[m[0]:m[1]], -1) {
+			value := strings.TrimSpace(html.UnescapeString(a[2] + a[3] + a[4]))
+			switch strings.ToLower(a[1]) {
+			case "src":
+				ref.Src = value
+			case "alt":
+				ref.Alt = value
+			case "title":
+				ref.Title = value
+			}
+		}
+		if ref.Src != "" {
+			refs = append(refs, ref)
+		}
+	}
+	sort.Slice(refs, func(i, j int) bool { return refs[i].start < refs[j].start })
+	for i := range refs {
+		refs[i].Caption = imageCaption(text[refs[i].end:])
+	}
+	return refs
+}
+
+func imageCaption(after string) string {
+	if m := figcaptionPattern.FindStringSubmatch(after); m != nil {
+		return strings.Join(strings.Fields(html.UnescapeString(tagPattern.ReplaceAllString(m[1], " "))), " ")
+	}
+	rest := strings.TrimLeft(after, " \t")
+	if !strings.HasPrefix(rest, "\n") {
+		return "" // more text follows on the image's own line
+	}
+	for _, line := range strings.Split(rest, "\n")[1:] {
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		if captionPattern.MatchString(line) {
+			return strings.TrimSpace(line)
+		}
+		break
+	}
+	return ""
+}
+
+// applyImages records in Extra["images"] the images whose markup starts
+// inside each chunk and, in strip mode, removes image markup from chunk
+// text. Markup cut by a window boundary is left as it is.
+func applyImages(text string, chunks []Chunk, plan ChunkingPlan) []imageRef {
+	refs := findImages(text)
+	for i := range chunks {
+		var images []Image
+		for _, ref := range refs {
+			if ref.start >= chunks[i].ByteStart && ref.start < chunks[i].ByteEnd {
+				images = append(images, ref.Image)
+			}
+		}
+		if len(images) > 0 {
+			chunks[i].Extra["images"] = images
+		}
+		if plan.Images == ImagesStrip {
+			chunks[i].Text = stripImages(chunks[i].Text)
+			chunks[i].OverlapText = stripImages(chunks[i].OverlapText)
+		}
+	}
+	return refs
+}
+
+func stripImages(s string) string {
+	s = markdownImagePattern.ReplaceAllString(s, "")
+	return htmlImagePattern.ReplaceAllString(s, "")
+}
+
+// addImageChunks appends one "image context" chunk per image with alt
+// text, a title or a caption, so multimodal experiments can retrieve
+// images by their description. Their IDs are "<doc>#img<n>", ChunkIndex
+// counts images, Extra["kind"] is "image_context" and Extra["source_id"]
+// names the text chunk holding the image's markup, whose unit indices
+// they share.
+func (c *SlidingWindowChunker) addImageChunks(text string, chunks []Chunk, refs []imageRef, doc string, plan ChunkingPlan, baseMeta map[string]interface{}) ([]Chunk, error) {
+	var out []Chunk
+	runes, prev := 0, 0
+	for _, ref := range refs {
+		context := imageContext(ref.Image)
+		if context == "" {
+			continue
+		}
+		runes += utf8.RuneCountInString(text[prev:ref.start])
+		prev = ref.start
+		chunk := Chunk{
+			Schema:     SchemaVersion,
+			ID:         fmt.Sprintf("%s#img%d", doc, len(out)),
+			ChunkIndex: len(out),
+			Text:       context,
+			ByteStart:  ref.start,
+			ByteEnd:    ref.end,
+			RuneStart:  runes,
+			RuneEnd:    runes + utf8.RuneCountInString(text[ref.start:ref.end]),
+			Extra:      map[string]interface{}{"kind": "image_context", "image": ref.Image},
+		}
+		for _, src := range chunks {
+			if src.ParentID == "" && ref.start >= src.ByteStart && ref.start < src.ByteEnd {
+				chunk.Extra["source_id"] = src.ID
+				chunk.StartIndex, chunk.EndIndex = src.StartIndex, src.EndIndex
+				break
+			}
+		}
+		promoteMeta(&chunk, baseMeta, plan.MetaFields)
+		out = append(out, chunk)
+	}
+	all := append(chunks, out...)
+	totalBytes := 0
+	for _, ch := range all {
+		totalBytes += len(ch.Text)
+	}
+	if err := c.Limits.check(len(all), totalBytes); err != nil {
+		return nil, err
+	}
+	c.Trace.add("images", map[string]interface{}{"images": len(refs), "chunks": len(out)},
+		"added %d image context chunks for %d images", len(out), len(refs))
+	return all, nil
+}
+
+// imageContext joins an image's distinct descriptions, one per line.
+func imageContext(img Image) string {
+	var parts []string
+	for _, s := range []string{img.Alt, img.Title, img.Caption} {
+		dup := false
+		for _, p := range parts {
+			dup = dup || strings.EqualFold(p, s)
+		}
+		if s != "" && !dup {
+			parts = append(parts, s)
+		}
+	}
+	return strings.Join(parts, "\n")
+}
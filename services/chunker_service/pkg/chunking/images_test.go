@@ -0,0 +1,68 @@
+package chunking
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestFindImages(t *testing.T) {
+	text := "Intro ![Login screen](img/login.png \"Sign-in\") text.\n" +
+		"![](diagram.svg)\n" +
+		"Figure 2: Request flow through the gateway\n" +
+		"<figure><img alt='Cat &amp; dog' src=\"pets.jpg\"><figcaption>Our <b>pets</b></figcaption></figure>\n" +
+		"<img alt=\"no source\">"
+	refs := findImages(text)
+	want := []Image{
+		{Src: "img/login.png", Alt: "Login screen", Title: "Sign-in"},
+		{Src: "diagram.svg", Caption: "Figure 2: Request flow through the gateway"},
+		{Src: "pets.jpg", Alt: "Cat & dog", Caption: "Our pets"},
+	}
+	if len(refs) != len(want) {
+		t.Fatalf("expected %d images, got %+v", len(want), refs)
+	}
+	for i, w := range want {
+		if refs[i].Image != w {
+			t.Errorf("image %d: expected %+v, got %+v", i, w, refs[i].Image)
+		}
+		if !strings.Contains(text[refs[i].start:refs[i].end], w.Src) {
+			t.Errorf("image %d: span %q does not cover its markup", i, text[refs[i].start:refs[i].end])
+		}
+	}
+}
+
+func TestImagesStripAndContextChunks(t *testing.T) {
+	text := "Setup\n![Wiring diagram](wiring.png)\nConnect the red lead.\n" +
+		"Done\n<img src=\"spacer.gif\">\nAll set."
+	plan := ChunkingPlan{WindowSize: 3, Mode: ModeLines, Images: ImagesStrip, ImageChunks: true}
+	chunks, err := NewSlidingWindowChunker().Chunk(text, plan, map[string]interface{}{"doc_id": "manual"})
+	if err != nil {
+		t.Fatalf("chunking failed: %v", err)
+	}
+	// Two text chunks, then one context chunk: the spacer has no description.
+	if len(chunks) != 3 {
+		t.Fatalf("expected 3 chunks, got %d: %+v", len(chunks), chunks)
+	}
+	if chunks[0].Text != "Setup\n\nConnect the red lead." || chunks[1].Text != "Done\n\nAll set." {
+		t.Errorf("image markup should be stripped, got %q and %q", chunks[0].Text, chunks[1].Text)
+	}
+	images, _ := chunks[0].Extra["images"].([]Image)
+	if len(images) != 1 || images[0].Alt != "Wiring diagram" {
+		t.Errorf("expected the diagram in Extra[images], got %v", chunks[0].Extra["images"])
+	}
+	img := chunks[2]
+	if img.ID != "manual#img0" || img.Text != "Wiring diagram" || img.Extra["kind"] != "image_context" ||
+		img.Extra["source_id"] != "manual#0" || img.DocID != "manual" {
+		t.Errorf("unexpected image context chunk: %+v", img)
+	}
+	if text[img.ByteStart:img.ByteEnd] != "![Wiring diagram](wiring.png)" {
+		t.Errorf("image chunk should locate its markup, got %q", text[img.ByteStart:img.ByteEnd])
+	}
+
+	if _, err := NewSlidingWindowChunker().Chunk(text, ChunkingPlan{WindowSize: 3, Images: "drop"}, nil); err == nil {
+		t.Error("expected an error for an unknown images mode")
+	}
+	plain, _ := NewSlidingWindowChunker().Chunk(text, ChunkingPlan{WindowSize: 3, Mode: ModeLines}, nil)
+	if _, ok := plain[0].Extra["images"]; ok || !strings.Contains(plain[0].Text, "wiring.png") {
+		t.Errorf("image handling should be opt-in, got %+v", plain[0])
+	}
+}
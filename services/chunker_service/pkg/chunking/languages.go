@@ -0,0 +1,115 @@
+package chunking
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"sync"
+)
+
+// HeadingPattern matches a script- or language-specific heading convention
+// against a trimmed line and assigns the heading level it implies.
+type HeadingPattern struct {
+	Pattern *regexp.Regexp
+	Level   int
+}
+
+// LanguagePack groups the heading conventions of a language or script so
+// break_on_headings works for documents that have no Markdown markers or
+// Latin capitals to key on.
+type LanguagePack struct {
+	Name     string
+	Headings []HeadingPattern
+}
+
+const cjkNumerals = `0-9０-９一二三四五六七八九十百千零〇`
+
+var (
+	languagePacksMu sync.RWMutex
+	languagePacks   = map[string]LanguagePack{
+		"zh": {Name: "zh", Headings: []HeadingPattern{
+			{regexp.MustCompile(`^第[` + cjkNumerals + `]+[部篇卷编編]`), 1},
+			{regexp.MustCompile(`^第[` + cjkNumerals + `]+章`), 1},
+			{regexp.MustCompile(`^第[` + cjkNumerals + `]+[节節]`), 2},
+			{regexp.MustCompile(`^第[` + cjkNumerals + `]+[条條款]`), 3},
+			{regexp.MustCompile(`^[一二三四五六七八九十]+[、．.]\S`), 2},
+			{regexp.MustCompile(`^[（(][一二三四五六七八九十]+[）)]\S`), 3},
+		}},
+		"ja": {Name: "ja", Headings: []HeadingPattern{
+			{regexp.MustCompile(`^第[` + cjkNumerals + `]+[部編]`), 1},
+			{regexp.MustCompile(`^第[` + cjkNumerals + `]+章`), 1},
+			{regexp.MustCompile(`^第[` + cjkNumerals + `]+[節节]`), 2},
+			{regexp.MustCompile(`^第[` + cjkNumerals + `]+条`), 3},
+			{regexp.MustCompile(`^【[^】]+】$`), 2},
+			{regexp.MustCompile(`^[■◆□◇]\s*\S`), 2},
+			{regexp.MustCompile(`^[０-９]+[．.]\s*\S`), 2},
+		}},
+		"ko": {Name: "ko", Headings: []HeadingPattern{
+			{regexp.MustCompile(`^제\s*[0-9]+\s*[편부]`), 1},
+			{regexp.MustCompile(`^제\s*[0-9]+\s*장`), 1},
+			{regexp.MustCompile(`^제\s*[0-9]+\s*절`), 2},
+			{regexp.MustCompile(`^제\s*[0-9]+\s*조`), 3},
+		}},
+		"ru": {Name: "ru", Headings: []HeadingPattern{
+			{regexp.MustCompile(`^(?i:часть|раздел)\s+[0-9IVXLC]+`), 1},
+			{regexp.MustCompile(`^(?i:глава)\s+[0-9IVXLC]+`), 1},
+			{regexp.MustCompile(`^(?i:параграф|статья)\s+[0-9]+`), 2},
+			{regexp.MustCompile(`^§\s*[0-9]+`), 2},
+		}},
+	}
+)
+
+// RegisterLanguagePack adds or replaces a language pack.
+func RegisterLanguagePack(pack LanguagePack) {
+	languagePacksMu.Lock()
+	defer languagePacksMu.Unlock()
+	languagePacks[pack.Name] = pack
+}
+
+// LanguagePackNames returns the names of all registered language packs.
+func LanguagePackNames() []string {
+	languagePacksMu.RLock()
+	defer languagePacksMu.RUnlock()
+	names := make([]string, 0, len(languagePacks))
+	for name := range languagePacks {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// resolveLanguagePacks returns the packs named in the plan, or every
+// registered pack when none are named.
+func resolveLanguagePacks(names []string) ([]LanguagePack, error) {
+	languagePacksMu.RLock()
+	defer languagePacksMu.RUnlock()
+	if len(names) == 0 {
+		names = make([]string, 0, len(languagePacks))
+		for name := range languagePacks {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+	}
+	packs := make([]LanguagePack, 0, len(names))
+	for _, name := range names {
+		pack, ok := languagePacks[name]
+		if !ok {
+			return nil, fmt.Errorf("unknown heading language %q", name)
+		}
+		packs = append(packs, pack)
+	}
+	return packs, nil
+}
+
+// matchLanguagePacks returns the pack name and level of the first pack
+// pattern matching the trimmed line.
+func matchLanguagePacks(trimmed string, packs []LanguagePack) (string, int, bool) {
+	for _, pack := range packs {
+		for _, h := range pack.Headings {
+			if h.Pattern.MatchString(trimmed) {
+				return pack.Name, h.Level, true
+			}
+		}
+	}
+	return "", 0, false
+}
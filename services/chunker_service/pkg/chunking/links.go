@@ -0,0 +1,26 @@
+package chunking
+
+import "fmt"
+
+// assignIDs gives chunks without an ID the stable ID "<doc>#<n>".
+func assignIDs(chunks []Chunk, doc string) {
+	for i := range chunks {
+		if chunks[i].ID == "" {
+			chunks[i].ID = fmt.Sprintf("%s#%d", doc, i)
+		}
+	}
+}
+
+// linkSequence numbers chunks in order and links each to its neighbours,
+// so consumers can fetch surrounding context without re-chunking.
+func linkSequence(chunks []*Chunk) {
+	for i, ch := range chunks {
+		ch.ChunkIndex, ch.PrevID, ch.NextID = i, "", ""
+		if i > 0 {
+			ch.PrevID = chunks[i-1].ID
+		}
+		if i+1 < len(chunks) {
+			ch.NextID = chunks[i+1].ID
+		}
+	}
+}
@@ -0,0 +1,86 @@
+package chunking
+
+import (
+	"regexp"
+	"strings"
+)
+
+var listItemPattern = regexp.MustCompile(`^\s*([-*+•]|[0-9]+[.)]|[a-zA-Z][.)])\s+\S`)
+
+// span is a half-open range of unit indices.
+type span struct {
+	start int
+	end   int
+}
+
+// listBlocks returns the line ranges covered by bulleted or numbered lists,
+// each extended backwards to include the sentence introducing the list.
+// Indented lines following an item are treated as its continuation, and a
+// single blank line between items does not end the list.
+func listBlocks(lines []string) []span {
+	var blocks []span
+	for i := 0; i < len(lines); i++ {
+		if !listItemPattern.MatchString(lines[i]) {
+			continue
+		}
+		start := i
+		if intro := introLine(lines, i); intro >= 0 {
+			start = intro
+		}
+		end := listEnd(lines, i)
+		blocks = append(blocks, span{start: start, end: end})
+		i = end - 1
+	}
+	return blocks
+}
+
+// listEnd returns the index just past the list whose first item is at
+// index first.
+func listEnd(lines []string, first int) int {
+	end := first + 1
+	for end < len(lines) {
+		line := lines[end]
+		switch {
+		case listItemPattern.MatchString(line):
+			end++
+		case strings.TrimSpace(line) != "" && (line[0] == ' ' || line[0] == '\t'):
+			end++
+		case strings.TrimSpace(line) == "" && end+1 < len(lines) && listItemPattern.MatchString(lines[end+1]):
+			end += 2
+		default:
+			return end
+		}
+	}
+	return end
+}
+
+// introLine returns the index of the line introducing the list that starts
+// at item, skipping at most one blank line, or -1 if there is none.
+func introLine(lines []string, item int) int {
+	j := item - 1
+	if j >= 0 && strings.TrimSpace(lines[j]) == "" {
+		j--
+	}
+	if j < 0 || strings.TrimSpace(lines[j]) == "" || listItemPattern.MatchString(lines[j]) {
+		return -1
+	}
+	if strings.HasPrefix(strings.TrimSpace(lines[j]), "#") {
+		return -1
+	}
+	return j
+}
+
+// keepBlocksWhole pulls a window's end back to the start of a list block
+// it would otherwise cut, provided the block fits in a single window, so
+// the list starts the next window intact.
+func keepBlocksWhole(start, end, windowSize int, blocks []span) int {
+	for _, b := range blocks {
+		if b.start >= end {
+			break
+		}
+		if b.start > start && b.start < end && end < b.end && b.end-b.start <= windowSize {
+			return b.start
+		}
+	}
+	return end
+}
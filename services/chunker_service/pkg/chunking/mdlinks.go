@@ -0,0 +1,119 @@
+package chunking
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// Link handling modes for ChunkingPlan.Links.
+const (
+	LinksKeep     = "keep"
+	LinksStrip    = "strip"
+	LinksFootnote = "footnote"
+)
+
+// Link is a Markdown link found in a chunk's text.
+type Link struct {
+	Text  string `json:"text,omitempty"`
+	URL   string `json:"url"`
+	Title string `json:"title,omitempty"`
+}
+
+var (
+	markdownLinkPattern = regexp.MustCompile(`\[([^\[\]\n]*)\]\(\s*<?([^\s<>()]+)>?(?:\s+["']([^"'\n]*)["'])?\s*\)`)
+	autolinkPattern     = regexp.MustCompile(`<((?:https?|ftp|mailto):[^\s<>]+)>`)
+)
+
+func checkLinks(mode string) error {
+	switch mode {
+	case "", LinksKeep, LinksStrip, LinksFootnote:
+		return nil
+	}
+	return fmt.Errorf("links must be %q, %q or %q, got %q", LinksKeep, LinksStrip, LinksFootnote, mode)
+}
+
+// applyLinks records every inline ("[text](url "title")") and autolink
+// ("<https://...>") Markdown link in a chunk in Extra["links"], so
+// citations can be resolved whatever happens to the text. "strip" then
+// replaces each link with its text, and "footnote" with its text and a
+// marker ("text[1]") whose URL is listed once at the end of the chunk.
+// Image references ("![alt](src)") are not links. OverlapText is
+// rewritten too, but its links belong to the previous chunk.
+func applyLinks(chunks []Chunk, mode string) {
+	for i := range chunks {
+		chunks[i].OverlapText, _ = rewriteLinks(chunks[i].OverlapText, mode)
+		text, links := rewriteLinks(chunks[i].Text, mode)
+		if len(links) > 0 {
+			chunks[i].Text = text
+			chunks[i].Extra["links"] = links
+		}
+	}
+}
+
+func rewriteLinks(text, mode string) (string, []Link) {
+	var (
+		links     []Link
+		b         strings.Builder
+		footnotes []string
+		numbers   = map[string]int{}
+	)
+	last := 0
+	emit := func(start, end int, link Link) {
+		links = append(links, link)
+		b.WriteString(text[last:start])
+		last = end
+		switch mode {
+		case LinksStrip:
+			b.WriteString(link.Text)
+		case LinksFootnote:
+			n, ok := numbers[link.URL]
+			if !ok {
+				footnotes = append(footnotes, link.URL)
+				n = len(footnotes)
+				numbers[link.URL] = n
+			}
+			if link.Text != link.URL {
+				b.WriteString(link.Text)
+			}
+			fmt.Fprintf(&b, "[%d]", n)
+		default:
+			b.WriteString(text[start:end])
+		}
+	}
+	inline := markdownLinkPattern.FindAllStringSubmatchIndex(text, -1)
+	auto := autolinkPattern.FindAllStringSubmatchIndex(text, -1)
+	for len(inline) > 0 || len(auto) > 0 {
+		if len(auto) == 0 || (len(inline) > 0 && inline[0][0] < auto[0][0]) {
+			m := inline[0]
+			inline = inline[1:]
+			if m[0] > 0 && text[m[0]-1] == '!' {
+				continue
+			}
+			link := Link{Text: strings.TrimSpace(text[m[2]:m[3]]), URL: text[m[4]:m[5]]}
+			if m[6] >= 0 {
+				link.Title = strings.TrimSpace(text[m[6]:m[7]])
+			}
+			emit(m[0], m[1], link)
+			continue
+		}
+		m := auto[0]
+		auto = auto[1:]
+		if m[0] < last {
+			continue // inside a link already handled
+		}
+		url := text[m[2]:m[3]]
+		emit(m[0], m[1], Link{Text: url, URL: url})
+	}
+	if len(links) == 0 {
+		return text, nil
+	}
+	b.WriteString(text[last:])
+	if len(footnotes) > 0 {
+		b.WriteString("\n")
+		for i, url := range footnotes {
+			fmt.Fprintf(&b, "\n[%d]: %s", i+1, url)
+		}
+	}
+	return b.String(), links
+}
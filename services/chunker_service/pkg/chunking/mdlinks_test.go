@@ -0,0 +1,41 @@
+package chunking
+
+import "testing"
+
+func TestRewriteLinks(t *testing.T) {
+	text := "See [the docs](https://example.com/docs \"Docs\") and [API](https://example.com/api), " +
+		"again [docs](https://example.com/docs) or <https://example.com/faq>. ![logo](logo.png)"
+	cases := map[string]string{
+		LinksKeep:  text,
+		LinksStrip: "See the docs and API, again docs or https://example.com/faq. ![logo](logo.png)",
+		LinksFootnote: "See the docs[1] and API[2], again docs[1] or [3]. ![logo](logo.png)\n\n" +
+			"[1]: https://example.com/docs\n[2]: https://example.com/api\n[3]: https://example.com/faq",
+	}
+	for mode, want := range cases {
+		got, links := rewriteLinks(text, mode)
+		if got != want {
+			t.Errorf("%s: expected\n%q\ngot\n%q", mode, want, got)
+		}
+		if len(links) != 4 || links[0] != (Link{Text: "the docs", URL: "https://example.com/docs", Title: "Docs"}) ||
+			links[3].URL != "https://example.com/faq" {
+			t.Errorf("%s: unexpected links %+v", mode, links)
+		}
+	}
+}
+
+func TestLinksPlanOption(t *testing.T) {
+	text := "Read [the guide](https://example.com/guide) first."
+	chunks, err := NewSlidingWindowChunker().Chunk(text, ChunkingPlan{WindowSize: 100, Links: LinksStrip}, nil)
+	if err != nil {
+		t.Fatalf("chunking failed: %v", err)
+	}
+	if chunks[0].Text != "Read the guide first." {
+		t.Errorf("unexpected text %q", chunks[0].Text)
+	}
+	if links, _ := chunks[0].Extra["links"].([]Link); len(links) != 1 || links[0].URL != "https://example.com/guide" {
+		t.Errorf("the URL should be kept in Extra[links], got %v", chunks[0].Extra["links"])
+	}
+	if _, err := NewSlidingWindowChunker().Chunk(text, ChunkingPlan{WindowSize: 100, Links: "inline"}, nil); err == nil {
+		t.Error("expected an error for an unknown links mode")
+	}
+}
@@ -0,0 +1,135 @@
+package chunking
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+)
+
+// MetaSchema declares the metadata keys a request must or may carry. Each
+// value is a type, optionally followed by "required", e.g.
+//
+//	{"doc_id": "string required", "tags": "[]string", "page_count": "int"}
+//
+// Supported types are string, int, number, bool, []string, object and any.
+type MetaSchema map[string]string
+
+var metaTypes = map[string]func(v interface{}) bool{
+	"string": func(v interface{}) bool { _, ok := v.(string); return ok },
+	"int":    isInt,
+	"number": isNumber,
+	"bool":   func(v interface{}) bool { _, ok := v.(bool); return ok },
+	"[]string": func(v interface{}) bool {
+		switch list := v.(type) {
+		case []string:
+			return true
+		case []interface{}:
+			for _, item := range list {
+				if _, ok := item.(string); !ok {
+					return false
+				}
+			}
+			return true
+		}
+		return false
+	},
+	"object": func(v interface{}) bool { _, ok := v.(map[string]interface{}); return ok },
+	"any":    func(interface{}) bool { return true },
+}
+
+// Check reports the first malformed declaration in the schema.
+func (s MetaSchema) Check() error {
+	for _, key := range s.keys() {
+		if _, _, err := parseMetaField(s[key]); err != nil {
+			return fmt.Errorf("meta_schema %s: %w", key, err)
+		}
+	}
+	return nil
+}
+
+// Validate checks meta against the schema and reports every violation in
+// one error wrapping ErrInvalidMeta.
+func (s MetaSchema) Validate(meta map[string]interface{}) error {
+	var problems []string
+	for _, key := range s.keys() {
+		typ, required, err := parseMetaField(s[key])
+		if err != nil {
+			return fmt.Errorf("meta_schema %s: %w", key, err)
+		}
+		v, ok := meta[key]
+		switch {
+		case !ok || v == nil:
+			if required {
+				problems = append(problems, key+" is required")
+			}
+		case !metaTypes[typ](v):
+			problems = append(problems, fmt.Sprintf("%s must be %s, got %T", key, typ, v))
+		}
+	}
+	if len(problems) > 0 {
+		return fmt.Errorf("%w: %s", ErrInvalidMeta, strings.Join(problems, "; "))
+	}
+	return nil
+}
+
+// Merge returns a schema with the fields of both; other's declarations
+// win for keys present in both.
+func (s MetaSchema) Merge(other MetaSchema) MetaSchema {
+	if len(s) == 0 {
+		return other
+	}
+	if len(other) == 0 {
+		return s
+	}
+	merged := make(MetaSchema, len(s)+len(other))
+	for k, v := range s {
+		merged[k] = v
+	}
+	for k, v := range other {
+		merged[k] = v
+	}
+	return merged
+}
+
+func (s MetaSchema) keys() []string {
+	keys := make([]string, 0, len(s))
+	for k := range s {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func parseMetaField(decl string) (typ string, required bool, err error) {
+	fields := strings.Fields(decl)
+	switch {
+	case len(fields) == 2 && fields[1] == "required":
+		required = true
+	case len(fields) != 1:
+		return "", false, fmt.Errorf("expected \"<type> [required]\", got %q", decl)
+	}
+	typ = fields[0]
+	if _, ok := metaTypes[typ]; !ok {
+		return "", false, fmt.Errorf("unknown type %q", typ)
+	}
+	return typ, required, nil
+}
+
+func isNumber(v interface{}) bool {
+	switch v.(type) {
+	case float64, float32, int, int32, int64:
+		return true
+	}
+	return false
+}
+
+func isInt(v interface{}) bool {
+	switch n := v.(type) {
+	case int, int32, int64:
+		return true
+	case float64:
+		return n == math.Trunc(n) && !math.IsInf(n, 0)
+	}
+	return false
+}
@@ -0,0 +1,51 @@
+package chunking
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestMetaSchemaValidate(t *testing.T) {
+	schema := MetaSchema{
+		"doc_id": "string required",
+		"tags":   "[]string",
+		"pages":  "int",
+	}
+	ok := map[string]interface{}{
+		"doc_id": "d1",
+		"tags":   []interface{}{"a", "b"},
+		"pages":  float64(3),
+	}
+	if err := schema.Validate(ok); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	err := schema.Validate(map[string]interface{}{"tags": []interface{}{"a", 1}, "pages": 2.5})
+	if !errors.Is(err, ErrInvalidMeta) {
+		t.Fatalf("expected ErrInvalidMeta, got %v", err)
+	}
+	for _, want := range []string{"doc_id is required", "pages must be int", "tags must be []string"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("error %q should mention %q", err, want)
+		}
+	}
+
+	if err := (MetaSchema{"x": "uuid"}).Check(); err == nil {
+		t.Errorf("expected error for unknown type")
+	}
+}
+
+func TestChunkValidatesMeta(t *testing.T) {
+	chunker := &SlidingWindowChunker{MetaSchema: MetaSchema{"doc_id": "string required"}}
+	plan := ChunkingPlan{WindowSize: 2, Mode: ModeTokens, MetaSchema: MetaSchema{"tenant": "string required"}}
+
+	_, err := chunker.Chunk("a b c", plan, map[string]interface{}{"doc_id": "d1"})
+	if !errors.Is(err, ErrInvalidMeta) || !strings.Contains(err.Error(), "tenant is required") {
+		t.Fatalf("expected missing tenant error, got %v", err)
+	}
+	meta := map[string]interface{}{"doc_id": "d1", "tenant": "acme"}
+	if _, err := chunker.Chunk("a b c", plan, meta); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
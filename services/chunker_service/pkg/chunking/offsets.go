@@ -0,0 +1,67 @@
+package chunking
+
+import (
+	"strings"
+	"unicode/utf8"
+)
+
+// unitOffsets records where each unit starts and ends in the original
+// text, in bytes and in runes. Rune offsets of units that split a
+// multi-byte character (bytes mode) are widened to whole runes.
+type unitOffsets struct {
+	byteStart, byteEnd []int
+	runeStart, runeEnd []int
+}
+
+// locateUnits maps units back to the text they were split from. Lines are
+// separated by exactly one newline; every other mode's units appear in
+// order, possibly separated by skipped text such as whitespace between
+// tokens. A unit a tokenizer normalized beyond recognition is placed at
+// the current position.
+func locateUnits(text string, mode Mode, units []string) unitOffsets {
+	off := unitOffsets{
+		byteStart: make([]int, len(units)),
+		byteEnd:   make([]int, len(units)),
+		runeStart: make([]int, len(units)),
+		runeEnd:   make([]int, len(units)),
+	}
+	pos, runes := 0, 0 // runes counts rune starts in text[:pos]
+	for i, u := range units {
+		start := pos
+		if mode != ModeLines {
+			if idx := strings.Index(text[pos:], u); idx >= 0 {
+				start = pos + idx
+			}
+		}
+		runes += countRuneStarts(text[pos:start])
+		off.byteStart[i], off.runeStart[i] = start, runes
+		if start < len(text) && !utf8.RuneStart(text[start]) {
+			// Bytes mode can start mid-rune; round down to its rune.
+			off.runeStart[i]--
+		}
+		end := start + len(u)
+		if end > len(text) {
+			end = len(text)
+		}
+		// Counting rune starts rounds a mid-rune end up.
+		runes += countRuneStarts(text[start:end])
+		off.byteEnd[i], off.runeEnd[i] = end, runes
+		pos = end
+		if mode == ModeLines && pos < len(text) {
+			// Skip the newline separating this line from the next.
+			pos++
+			runes++
+		}
+	}
+	return off
+}
+
+func countRuneStarts(s string) int {
+	n := 0
+	for i := 0; i < len(s); i++ {
+		if utf8.RuneStart(s[i]) {
+			n++
+		}
+	}
+	return n
+}
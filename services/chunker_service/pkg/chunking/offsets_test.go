@@ -0,0 +1,45 @@
+package chunking
+
+import "testing"
+
+func TestChunkSourceOffsets(t *testing.T) {
+	chunker := NewSlidingWindowChunker()
+	cases := []struct {
+		name string
+		text string
+		plan ChunkingPlan
+	}{
+		{"chars", "héllo wörld", ChunkingPlan{WindowSize: 4, Mode: ModeCharacters}},
+		{"tokens", "  naïve  tokens\tare\nsplit ", ChunkingPlan{WindowSize: 2, Overlap: 1, Mode: ModeTokens}},
+		{"lines", "ä\n\nb\nc\n", ChunkingPlan{WindowSize: 2, Mode: ModeLines}},
+	}
+	for _, tc := range cases {
+		chunks, err := chunker.Chunk(tc.text, tc.plan, nil)
+		if err != nil {
+			t.Fatalf("%s: chunking failed: %v", tc.name, err)
+		}
+		runes := []rune(tc.text)
+		for i, ch := range chunks {
+			byteSpan := tc.text[ch.ByteStart:ch.ByteEnd]
+			if runeSpan := string(runes[ch.RuneStart:ch.RuneEnd]); runeSpan != byteSpan {
+				t.Errorf("%s chunk %d: rune span %q differs from byte span %q", tc.name, i, runeSpan, byteSpan)
+			}
+			if tc.plan.Mode != ModeTokens && byteSpan != ch.Text {
+				t.Errorf("%s chunk %d: span %q does not match text %q", tc.name, i, byteSpan, ch.Text)
+			}
+		}
+	}
+
+	chunks, _ := chunker.Chunk("  naïve  tokens", ChunkingPlan{WindowSize: 1, Mode: ModeTokens}, nil)
+	if got := chunks[1]; got.ByteStart != 10 || got.ByteEnd != 16 || got.RuneStart != 9 || got.RuneEnd != 15 {
+		t.Errorf("unexpected offsets for %q: %+v", got.Text, got)
+	}
+
+	// Bytes mode may split "é"; rune offsets widen to the whole rune.
+	chunks, _ = chunker.Chunk("héllo", ChunkingPlan{WindowSize: 2, Mode: ModeBytes}, nil)
+	for i, want := range [][2]int{{0, 2}, {1, 3}, {3, 5}} {
+		if got := chunks[i]; got.RuneStart != want[0] || got.RuneEnd != want[1] {
+			t.Errorf("bytes chunk %d: expected runes %v, got %d-%d", i, want, got.RuneStart, got.RuneEnd)
+		}
+	}
+}
@@ -0,0 +1,131 @@
+package chunking
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// PresetRegistry holds named plans vetted for a kind of document, so
+// clients can ask for "markdown-default" instead of writing a full plan.
+// It is safe for concurrent use.
+type PresetRegistry struct {
+	mu      sync.RWMutex
+	presets map[string]ChunkingPlan
+}
+
+// Presets is the registry the CLI's --preset and the server's "preset"
+// request field look names up in. It starts with the built-in presets;
+// Register adds more or overrides them.
+var Presets = NewPresetRegistry(map[string]ChunkingPlan{
+	// markdown-default keeps sections, lists and tables together and
+	// labels every chunk with its heading.
+	"markdown-default": {
+		Mode:            ModeLines,
+		WindowSize:      40,
+		Overlap:         5,
+		BreakOnHeadings: true,
+		IncludeHeadings: true,
+		GroupLists:      true,
+		ExtractTables:   true,
+		AttachCaptions:  true,
+		ChunkTitles:     true,
+	},
+	// code-default windows over source lines with enough overlap to keep
+	// a function's signature near its body.
+	"code-default": {
+		Mode:       ModeLines,
+		WindowSize: 60,
+		Overlap:    10,
+	},
+	// transcript windows over speaker turns, one per line, with overlap
+	// for context across the cut.
+	"transcript": {
+		Mode:       ModeLines,
+		WindowSize: 20,
+		Overlap:    4,
+	},
+	// scanned-pdf windows over tokens, since OCR line breaks follow the
+	// page layout rather than the text, and titles chunks by their first
+	// sentence, since scans rarely keep heading markup. Form feeds
+	// between pages are not taken for concatenated documents.
+	"scanned-pdf": {
+		Mode:         ModeTokens,
+		WindowSize:   300,
+		Overlap:      50,
+		ChunkTitles:  true,
+		Concatenated: ConcatIgnore,
+	},
+})
+
+// NewPresetRegistry returns a registry holding presets.
+func NewPresetRegistry(presets map[string]ChunkingPlan) *PresetRegistry {
+	r := &PresetRegistry{presets: make(map[string]ChunkingPlan, len(presets))}
+	for name, plan := range presets {
+		r.presets[name] = plan
+	}
+	return r
+}
+
+// Register adds or replaces a preset.
+func (r *PresetRegistry) Register(name string, plan ChunkingPlan) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.presets[name] = plan
+}
+
+// Names returns the names of all presets, sorted.
+func (r *PresetRegistry) Names() []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	names := make([]string, 0, len(r.presets))
+	for name := range r.presets {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Lookup returns a copy of the named preset, which the caller may change
+// without affecting the registry.
+func (r *PresetRegistry) Lookup(name string) (ChunkingPlan, bool) {
+	r.mu.RLock()
+	plan, ok := r.presets[name]
+	r.mu.RUnlock()
+	if !ok {
+		return ChunkingPlan{}, false
+	}
+	return copyPlan(plan), true
+}
+
+// Plan returns the named preset with the JSON plan fields in overrides,
+// which may be empty, applied over it. Unknown preset names and plan
+// fields are errors.
+func (r *PresetRegistry) Plan(name string, overrides []byte) (ChunkingPlan, error) {
+	plan, ok := r.Lookup(name)
+	if !ok {
+		return ChunkingPlan{}, fmt.Errorf("unknown preset %q (available: %s)", name, strings.Join(r.Names(), ", "))
+	}
+	if len(overrides) > 0 {
+		if err := decodePlanFields(overrides, &plan); err != nil {
+			return ChunkingPlan{}, fmt.Errorf("preset %q: %w", name, err)
+		}
+	}
+	return plan, nil
+}
+
+// copyPlan returns a deep copy of plan, so its slices, maps and children
+// can be changed without affecting the original.
+func copyPlan(plan ChunkingPlan) ChunkingPlan {
+	data, err := json.Marshal(plan)
+	if err != nil {
+		return plan
+	}
+	var out ChunkingPlan
+	if err := json.Unmarshal(data, &out); err != nil {
+		return plan
+	}
+	return out
+}
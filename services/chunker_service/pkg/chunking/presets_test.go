@@ -0,0 +1,46 @@
+package chunking
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestPresetRegistry(t *testing.T) {
+	want := []string{"code-default", "markdown-default", "scanned-pdf", "transcript"}
+	if got := Presets.Names(); !reflect.DeepEqual(got, want) {
+		t.Fatalf("expected built-in presets %v, got %v", want, got)
+	}
+	for _, name := range want {
+		plan, _ := Presets.Lookup(name)
+		plan.MaxChunks = 3
+		if _, err := NewSlidingWindowChunker().Chunk("# Title\nalpha beta gamma\n", plan, nil); err != nil {
+			t.Errorf("preset %s does not chunk: %v", name, err)
+		}
+	}
+
+	r := NewPresetRegistry(map[string]ChunkingPlan{"small": {Mode: ModeLines, WindowSize: 2, MetaFields: map[string]string{"a": "url"}}})
+	plan, ok := r.Lookup("small")
+	if !ok {
+		t.Fatal("expected the small preset")
+	}
+	plan.MetaFields["a"] = "title"
+	if again, _ := r.Lookup("small"); again.MetaFields["a"] != "url" {
+		t.Fatal("Lookup must return a copy")
+	}
+
+	plan, err := r.Plan("small", []byte(`{"overlap": 1}`))
+	if err != nil || plan.WindowSize != 2 || plan.Overlap != 1 || plan.Mode != ModeLines {
+		t.Fatalf("expected overrides over the preset, got %+v (%v)", plan, err)
+	}
+	if _, err := r.Plan("small", []byte(`{"windw_size": 1}`)); err == nil {
+		t.Error("expected an unknown plan field to be rejected")
+	}
+	if _, err := r.Plan("large", nil); err == nil {
+		t.Error("expected an unknown preset to be rejected")
+	}
+
+	r.Register("small", ChunkingPlan{Mode: ModeTokens, WindowSize: 5})
+	if plan, _ := r.Lookup("small"); plan.Mode != ModeTokens {
+		t.Fatalf("expected Register to override the preset, got %+v", plan)
+	}
+}
@@ -0,0 +1,105 @@
+package chunking
+
+import (
+	"fmt"
+	"time"
+)
+
+// metaSetters assign a base-meta value to a typed Chunk field, reporting
+// whether the value had the field's type.
+var metaSetters = map[string]func(c *Chunk, v interface{}) bool{
+	"file_name": stringSetter(func(c *Chunk) *string { return &c.FileName }),
+	"file_path": stringSetter(func(c *Chunk) *string { return &c.FilePath }),
+	"mime_type": stringSetter(func(c *Chunk) *string { return &c.MimeType }),
+	"doc_id":    stringSetter(func(c *Chunk) *string { return &c.DocID }),
+	"title":     stringSetter(func(c *Chunk) *string { return &c.Title }),
+	"url":       stringSetter(func(c *Chunk) *string { return &c.URL }),
+	"author":    stringSetter(func(c *Chunk) *string { return &c.Author }),
+	"tenant":    stringSetter(func(c *Chunk) *string { return &c.Tenant }),
+	"license":   stringSetter(func(c *Chunk) *string { return &c.License }),
+	"retention": func(c *Chunk, v interface{}) bool {
+		s, ok := v.(string)
+		if !ok {
+			return false
+		}
+		if _, _, err := ParseRetention(s); err != nil {
+			return false
+		}
+		c.Retention = s
+		return true
+	},
+	"expires_at": func(c *Chunk, v interface{}) bool {
+		var t time.Time
+		switch v := v.(type) {
+		case time.Time:
+			t = v
+		case string:
+			var err error
+			if t, err = time.Parse(time.RFC3339, v); err != nil {
+				return false
+			}
+		default:
+			return false
+		}
+		t = t.UTC()
+		c.ExpiresAt = &t
+		return true
+	},
+	"tags": func(c *Chunk, v interface{}) bool {
+		switch list := v.(type) {
+		case []string:
+			c.Tags = append([]string(nil), list...)
+			return true
+		case []interface{}:
+			tags := make([]string, 0, len(list))
+			for _, item := range list {
+				s, ok := item.(string)
+				if !ok {
+					return false
+				}
+				tags = append(tags, s)
+			}
+			c.Tags = tags
+			return true
+		}
+		return false
+	},
+}
+
+func stringSetter(field func(c *Chunk) *string) func(c *Chunk, v interface{}) bool {
+	return func(c *Chunk, v interface{}) bool {
+		s, ok := v.(string)
+		if ok {
+			*field(c) = s
+		}
+		return ok
+	}
+}
+
+// promoteMeta copies base metadata onto the chunk. Keys naming a typed
+// field (file_name, file_path, mime_type, doc_id, title, url, author,
+// tags, tenant, license, retention, expires_at), or mapped onto one by the plan's meta_fields, fill that
+// field; everything else, including values of the wrong type, lands in
+// Extra.
+func promoteMeta(c *Chunk, baseMeta map[string]interface{}, mapping map[string]string) {
+	for k, v := range baseMeta {
+		field, mapped := mapping[k]
+		if !mapped {
+			field = k
+		}
+		if set, ok := metaSetters[field]; ok && set(c, v) {
+			continue
+		}
+		c.Extra[k] = v
+	}
+}
+
+// checkMetaFields rejects mappings onto fields that don't exist.
+func checkMetaFields(mapping map[string]string) error {
+	for key, field := range mapping {
+		if _, ok := metaSetters[field]; !ok && field != "" {
+			return fmt.Errorf("meta_fields: %s maps to unknown chunk field %q", key, field)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,50 @@
+package chunking
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestChunkPromotesWellKnownMeta(t *testing.T) {
+	chunker := NewSlidingWindowChunker()
+	plan := ChunkingPlan{
+		WindowSize: 10,
+		Mode:       ModeTokens,
+		MetaFields: map[string]string{"source_url": "url", "title": ""},
+	}
+	meta := map[string]interface{}{
+		"doc_id":     "d1",
+		"author":     "A. Writer",
+		"tags":       []interface{}{"x", "y"},
+		"tenant":     7,
+		"source_url": "https://example.com/d1",
+		"title":      "Kept in extra",
+		"team":       "docs",
+	}
+
+	chunks, err := chunker.Chunk("a b c", plan, meta)
+	if err != nil {
+		t.Fatalf("chunking failed: %v", err)
+	}
+	ch := chunks[0]
+	if ch.DocID != "d1" || ch.Author != "A. Writer" || ch.URL != "https://example.com/d1" {
+		t.Errorf("typed fields not promoted: %+v", ch)
+	}
+	if !reflect.DeepEqual(ch.Tags, []string{"x", "y"}) {
+		t.Errorf("expected tags [x y], got %v", ch.Tags)
+	}
+	if ch.Title != "" || ch.Extra["title"] != "Kept in extra" {
+		t.Errorf("title mapped to \"\" should stay in extra: %+v", ch)
+	}
+	if ch.Tenant != "" || ch.Extra["tenant"] != 7 {
+		t.Errorf("mistyped tenant should stay in extra: %+v", ch)
+	}
+	if _, ok := ch.Extra["source_url"]; ok || ch.Extra["team"] != "docs" {
+		t.Errorf("unexpected extra: %v", ch.Extra)
+	}
+
+	plan.MetaFields = map[string]string{"x": "nope"}
+	if _, err := chunker.Chunk("a", plan, nil); err == nil {
+		t.Errorf("expected error for unknown chunk field")
+	}
+}
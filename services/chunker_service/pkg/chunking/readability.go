@@ -0,0 +1,148 @@
+package chunking
+
+import (
+	"math"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Readability holds simple readability and complexity metrics for a piece
+// of text. Scores use the standard Flesch formulas with a heuristic
+// English syllable counter, so they are approximate for other languages;
+// ComputeLocaleReadability uses German formulas for German and leaves
+// them zero for Japanese, Chinese and Thai.
+type Readability struct {
+	Sentences          int     `json:"sentences"`
+	Words              int     `json:"words"`
+	AvgSentenceLength  float64 `json:"avg_sentence_length"`
+	AvgWordLength      float64 `json:"avg_word_length"`
+	FleschReadingEase  float64 `json:"flesch_reading_ease"`
+	FleschKincaidGrade float64 `json:"flesch_kincaid_grade"`
+}
+
+// ComputeReadability returns readability metrics for text.
+func ComputeReadability(text string) Readability {
+	var r Readability
+	letters, syllables := 0, 0
+	for _, field := range strings.Fields(text) {
+		word := strings.TrimFunc(field, func(r rune) bool { return !unicode.IsLetter(r) && !unicode.IsDigit(r) })
+		if word == "" {
+			continue
+		}
+		r.Words++
+		letters += len([]rune(word))
+		syllables += countSyllables(word)
+		if strings.ContainsAny(field[len(field)-1:], ".!?") {
+			r.Sentences++
+		}
+	}
+	if r.Words == 0 {
+		return r
+	}
+	// Trailing text without terminal punctuation is still a sentence.
+	trimmed := strings.TrimRightFunc(text, unicode.IsSpace)
+	if !strings.ContainsAny(trimmed[len(trimmed)-1:], ".!?") {
+		r.Sentences++
+	}
+
+	words := float64(r.Words)
+	r.AvgSentenceLength = round2(words / float64(r.Sentences))
+	r.AvgWordLength = round2(float64(letters) / words)
+	r.FleschReadingEase = round2(206.835 - 1.015*(words/float64(r.Sentences)) - 84.6*(float64(syllables)/words))
+	r.FleschKincaidGrade = round2(0.39*(words/float64(r.Sentences)) + 11.8*(float64(syllables)/words) - 15.59)
+	return r
+}
+
+// ComputeLocaleReadability returns readability metrics for text in a BCP
+// 47 locale, counting sentences and words with the language's
+// segmentation rules. German scores use Amstad's Flesch reading ease and
+// the fourth Wiener Sachtextformel as the grade. Japanese, Chinese and
+// Thai have no syllable-based scores, so those stay zero and word length
+// counts characters. Without a locale it is ComputeReadability.
+func ComputeLocaleReadability(text, locale string) Readability {
+	if locale == "" {
+		return ComputeReadability(text)
+	}
+	seg := segmenterFor(locale)
+	var r Readability
+	letters, syllables, long := 0, 0, 0
+	for _, word := range seg.words(text) {
+		r.Words++
+		letters += utf8.RuneCountInString(word)
+		n := 0
+		switch seg.lang {
+		case "de":
+			n = countGermanSyllables(word)
+		default:
+			n = countSyllables(word)
+		}
+		syllables += n
+		if n >= 3 {
+			long++
+		}
+	}
+	if r.Words == 0 {
+		return r
+	}
+	r.Sentences = len(seg.sentences(text))
+
+	words, sentences := float64(r.Words), float64(r.Sentences)
+	r.AvgSentenceLength = round2(words / sentences)
+	r.AvgWordLength = round2(float64(letters) / words)
+	switch {
+	case seg.splitsWords():
+	case seg.lang == "de":
+		r.FleschReadingEase = round2(180 - words/sentences - 58.5*(float64(syllables)/words))
+		r.FleschKincaidGrade = round2(0.2656*(words/sentences) + 0.2744*(100*float64(long)/words) - 1.693)
+	default:
+		r.FleschReadingEase = round2(206.835 - 1.015*(words/sentences) - 84.6*(float64(syllables)/words))
+		r.FleschKincaidGrade = round2(0.39*(words/sentences) + 11.8*(float64(syllables)/words) - 15.59)
+	}
+	return r
+}
+
+// countGermanSyllables counts groups of vowels, including umlauts, so
+// diphthongs such as "ei" and "äu" count once and long compounds count
+// every part.
+func countGermanSyllables(word string) int {
+	count := 0
+	prevVowel := false
+	for _, r := range strings.ToLower(word) {
+		vowel := strings.ContainsRune("aeiouyäöü", r)
+		if vowel && !prevVowel {
+			count++
+		}
+		prevVowel = vowel
+	}
+	if count == 0 {
+		count = 1
+	}
+	return count
+}
+
+// countSyllables approximates English syllables as groups of vowels,
+// discounting a silent trailing "e".
+func countSyllables(word string) int {
+	word = strings.ToLower(word)
+	count := 0
+	prevVowel := false
+	for _, r := range word {
+		vowel := strings.ContainsRune("aeiouy", r)
+		if vowel && !prevVowel {
+			count++
+		}
+		prevVowel = vowel
+	}
+	if strings.HasSuffix(word, "e") && !strings.HasSuffix(word, "le") && count > 1 {
+		count--
+	}
+	if count == 0 {
+		count = 1
+	}
+	return count
+}
+
+func round2(f float64) float64 {
+	return math.Round(f*100) / 100
+}
@@ -0,0 +1,42 @@
+package chunking
+
+import "testing"
+
+func TestComputeReadability(t *testing.T) {
+	simple := ComputeReadability("The cat sat. The dog ran.")
+	if simple.Sentences != 2 || simple.Words != 6 || simple.AvgSentenceLength != 3 {
+		t.Fatalf("unexpected counts: %+v", simple)
+	}
+
+	dense := ComputeReadability("Notwithstanding aforementioned considerations, institutional interoperability necessitates comprehensive standardization initiatives")
+	if dense.Sentences != 1 {
+		t.Fatalf("unterminated text should count as one sentence: %+v", dense)
+	}
+	if dense.FleschReadingEase >= simple.FleschReadingEase || dense.FleschKincaidGrade <= simple.FleschKincaidGrade {
+		t.Fatalf("dense text should score harder than simple text: simple %+v, dense %+v", simple, dense)
+	}
+
+	if empty := ComputeReadability("  \n "); empty.Words != 0 || empty.Sentences != 0 {
+		t.Fatalf("expected zero metrics for blank text, got %+v", empty)
+	}
+}
+
+func TestComputeLocaleReadability(t *testing.T) {
+	text := "Der Kunde kam am 3. Oktober. Die Donaudampfschifffahrtsgesellschaft antwortete nicht."
+	if got, want := ComputeLocaleReadability(text, ""), ComputeReadability(text); got != want {
+		t.Fatalf("expected the default metrics without a locale, got %+v", got)
+	}
+	de := ComputeLocaleReadability(text, "de-DE")
+	if de.Sentences != 2 || de.Words != 10 {
+		t.Fatalf("unexpected German counts: %+v", de)
+	}
+	// Amstad: 180 - 10/2 - 58.5 * 23/10, with one syllable per vowel group.
+	if de.FleschReadingEase != 40.45 {
+		t.Errorf("expected Amstad's reading ease 40.45, got %+v", de)
+	}
+
+	ja := ComputeLocaleReadability("東京に行きました。猫が好きです。", "ja")
+	if ja.Sentences != 2 || ja.Words != 4 || ja.FleschReadingEase != 0 || ja.FleschKincaidGrade != 0 {
+		t.Errorf("unexpected Japanese metrics: %+v", ja)
+	}
+}
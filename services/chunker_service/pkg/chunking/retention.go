@@ -0,0 +1,68 @@
+package chunking
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+	"unicode"
+)
+
+// ParseRetention parses a retention policy. Periods are a Go duration
+// ("720h") or a whole number of days, weeks or years ("90d", "2w", "7y";
+// a year is 365 days) and report ok. Any other policy starting with a
+// letter is a named policy such as "legal-hold" that never expires on its
+// own; anything else must be a valid period.
+func ParseRetention(policy string) (period time.Duration, ok bool, err error) {
+	if policy == "" || unicode.IsLetter(rune(policy[0])) {
+		return 0, false, nil
+	}
+	day := 24 * time.Hour
+	units := map[byte]time.Duration{'d': day, 'w': 7 * day, 'y': 365 * day}
+	if unit, known := units[policy[len(policy)-1]]; known {
+		n, err := strconv.Atoi(policy[:len(policy)-1])
+		if err != nil || n <= 0 {
+			return 0, false, fmt.Errorf("invalid retention period %q", policy)
+		}
+		return time.Duration(n) * unit, true, nil
+	}
+	d, err := time.ParseDuration(policy)
+	if err != nil || d <= 0 {
+		return 0, false, fmt.Errorf("invalid retention period %q", policy)
+	}
+	return d, true, nil
+}
+
+// Expired reports whether the chunk's retention ended before now.
+func (c Chunk) Expired(now time.Time) bool {
+	return c.ExpiresAt != nil && c.ExpiresAt.Before(now)
+}
+
+// applyPolicy tags chunks with the plan's license, retention policy and
+// expiry unless their metadata already set one. Retention metadata
+// overrides the plan's expiry as well as its retention.
+func applyPolicy(chunks []Chunk, plan ChunkingPlan) {
+	for i := range chunks {
+		if chunks[i].License == "" {
+			chunks[i].License = plan.License
+		}
+		if chunks[i].Retention == "" {
+			chunks[i].Retention = plan.Retention
+			if chunks[i].ExpiresAt == nil && plan.ExpiresAt != nil {
+				t := plan.ExpiresAt.UTC()
+				chunks[i].ExpiresAt = &t
+			}
+		}
+	}
+}
+
+// expireAt sets ExpiresAt from the chunk's retention period, counted from
+// its CreatedAt, unless an explicit expiry was given.
+func expireAt(c *Chunk) {
+	if c.ExpiresAt != nil || c.CreatedAt.IsZero() {
+		return
+	}
+	if period, ok, _ := ParseRetention(c.Retention); ok {
+		t := c.CreatedAt.Add(period)
+		c.ExpiresAt = &t
+	}
+}
@@ -0,0 +1,95 @@
+package chunking
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseRetention(t *testing.T) {
+	cases := []struct {
+		policy string
+		want   time.Duration
+		ok     bool
+	}{
+		{"", 0, false},
+		{"legal-hold", 0, false},
+		{"90d", 90 * 24 * time.Hour, true},
+		{"2w", 14 * 24 * time.Hour, true},
+		{"1y", 365 * 24 * time.Hour, true},
+		{"36h", 36 * time.Hour, true},
+	}
+	for _, c := range cases {
+		got, ok, err := ParseRetention(c.policy)
+		if err != nil || got != c.want || ok != c.ok {
+			t.Errorf("ParseRetention(%q) = %v, %v, %v; want %v, %v", c.policy, got, ok, err, c.want, c.ok)
+		}
+	}
+	for _, bad := range []string{"90 days", "0d", "-1d", "3x"} {
+		if _, _, err := ParseRetention(bad); err == nil {
+			t.Errorf("ParseRetention(%q): expected error", bad)
+		}
+	}
+}
+
+func TestChunkLicenseAndRetention(t *testing.T) {
+	chunker := NewSlidingWindowChunker()
+	plan := ChunkingPlan{
+		WindowSize: 1,
+		Mode:       ModeLines,
+		License:    "CC-BY-4.0",
+		Retention:  "30d",
+		Children:   &ChunkingPlan{WindowSize: 1, Mode: ModeTokens},
+	}
+	chunks, err := chunker.Chunk("a b", plan, map[string]interface{}{"license": "proprietary"})
+	if err != nil {
+		t.Fatalf("chunking failed: %v", err)
+	}
+	created := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	Stamp(chunks, FixedClock(created))
+	for _, ch := range chunks {
+		if ch.License != "proprietary" || ch.Retention != "30d" {
+			t.Errorf("chunk %s: metadata license should win over the plan: %+v", ch.ID, ch)
+		}
+		if ch.ExpiresAt == nil || !ch.ExpiresAt.Equal(created.AddDate(0, 0, 30)) {
+			t.Errorf("chunk %s: unexpected expires_at %v", ch.ID, ch.ExpiresAt)
+		}
+	}
+	if !chunks[0].Expired(created.AddDate(0, 0, 31)) || chunks[0].Expired(created.AddDate(0, 0, 29)) {
+		t.Errorf("unexpected expiry check around %v", chunks[0].ExpiresAt)
+	}
+
+	chunks, err = chunker.Chunk("a", ChunkingPlan{WindowSize: 1, Retention: "legal-hold"},
+		map[string]interface{}{"expires_at": "2030-01-01T00:00:00Z"})
+	if err != nil {
+		t.Fatalf("chunking failed: %v", err)
+	}
+	Stamp(chunks, FixedClock(created))
+	if want := time.Date(2030, 1, 1, 0, 0, 0, 0, time.UTC); chunks[0].ExpiresAt == nil || !chunks[0].ExpiresAt.Equal(want) {
+		t.Errorf("explicit expires_at should be kept, got %v", chunks[0].ExpiresAt)
+	}
+
+	// A plan's fixed expiry beats its retention period, and retention
+	// metadata beats both.
+	validUntil := time.Date(2026, 6, 30, 0, 0, 0, 0, time.UTC)
+	plan = ChunkingPlan{WindowSize: 1, Retention: "30d", ExpiresAt: &validUntil}
+	chunks, err = chunker.Chunk("a", plan, nil)
+	if err != nil {
+		t.Fatalf("chunking failed: %v", err)
+	}
+	Stamp(chunks, FixedClock(created))
+	if chunks[0].ExpiresAt == nil || !chunks[0].ExpiresAt.Equal(validUntil) {
+		t.Errorf("plan expires_at should win over its retention, got %v", chunks[0].ExpiresAt)
+	}
+	chunks, err = chunker.Chunk("a", plan, map[string]interface{}{"retention": "7d"})
+	if err != nil {
+		t.Fatalf("chunking failed: %v", err)
+	}
+	Stamp(chunks, FixedClock(created))
+	if chunks[0].ExpiresAt == nil || !chunks[0].ExpiresAt.Equal(created.AddDate(0, 0, 7)) {
+		t.Errorf("retention metadata should win over the plan's expiry, got %v", chunks[0].ExpiresAt)
+	}
+
+	if _, err := chunker.Chunk("a", ChunkingPlan{WindowSize: 1, Retention: "90 days"}, nil); err == nil {
+		t.Errorf("expected error for malformed retention period")
+	}
+}
@@ -0,0 +1,102 @@
+package chunking
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"strings"
+)
+
+// Routing picks a plan preset for a document from its mime_type or
+// file_name metadata, so Markdown can be chunked by headings and code or
+// logs by lines without every client knowing the right plan. Presets
+// hold plan fields by name; Routes maps MIME types ("text/markdown", or
+// "text/*" for a whole type) and file extensions (".go") to a preset.
+//
+// Callers apply a route only when the client's plan leaves strategy
+// unset; a client naming a strategy has chosen its plan.
+type Routing struct {
+	Presets map[string]json.RawMessage `json:"presets,omitempty"`
+	Routes  map[string]string          `json:"routes,omitempty"`
+}
+
+// Validate rejects presets with unknown plan fields and routes that are
+// malformed or name a missing preset.
+func (r Routing) Validate() error {
+	for name, fields := range r.Presets {
+		var plan ChunkingPlan
+		if err := decodePlanFields(fields, &plan); err != nil {
+			return fmt.Errorf("preset %q: %w", name, err)
+		}
+	}
+	for key, preset := range r.Routes {
+		if !strings.HasPrefix(key, ".") && !strings.Contains(key, "/") {
+			return fmt.Errorf("route %q: expected a MIME type or an extension such as \".md\"", key)
+		}
+		if _, ok := r.Presets[preset]; !ok {
+			return fmt.Errorf("route %q: unknown preset %q", key, preset)
+		}
+	}
+	return nil
+}
+
+// Match returns the name of the preset routed to a document, or "". The
+// file extension wins over an exact MIME type, which is often a generic
+// text/plain, and an exact MIME type wins over a "type/*" route.
+func (r Routing) Match(meta map[string]interface{}) string {
+	if len(r.Routes) == 0 {
+		return ""
+	}
+	mime, _ := meta["mime_type"].(string)
+	mime, _, _ = strings.Cut(strings.ToLower(mime), ";")
+	mime = strings.TrimSpace(mime)
+	name, _ := meta["file_name"].(string)
+	if name == "" {
+		name, _ = meta["file_path"].(string)
+	}
+	keys := []string{strings.ToLower(filepath.Ext(name)), mime}
+	if major, _, ok := strings.Cut(mime, "/"); ok {
+		keys = append(keys, major+"/*")
+	}
+	for _, key := range keys {
+		if preset, ok := r.Routes[key]; ok && key != "" {
+			return preset
+		}
+	}
+	return ""
+}
+
+// Apply overlays the preset routed to meta onto plan and returns its
+// name, or "" when no route matches. plan's slices, maps and children
+// are copied first, so a plan shared between documents is not changed.
+func (r Routing) Apply(plan *ChunkingPlan, meta map[string]interface{}) (string, error) {
+	name := r.Match(meta)
+	if name == "" {
+		return "", nil
+	}
+	return name, r.ApplyPreset(plan, name)
+}
+
+// ApplyPreset overlays the named preset onto plan, copying plan first
+// like Apply. It fails for presets Routing does not hold.
+func (r Routing) ApplyPreset(plan *ChunkingPlan, name string) error {
+	fields, ok := r.Presets[name]
+	if !ok {
+		return fmt.Errorf("unknown preset %q", name)
+	}
+	out := copyPlan(*plan)
+	if err := json.Unmarshal(fields, &out); err != nil {
+		return fmt.Errorf("preset %q: %w", name, err)
+	}
+	*plan = out
+	return nil
+}
+
+// decodePlanFields applies the JSON plan fields in data over plan,
+// rejecting unknown fields.
+func decodePlanFields(data []byte, plan *ChunkingPlan) error {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	return dec.Decode(plan)
+}
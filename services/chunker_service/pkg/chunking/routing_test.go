@@ -0,0 +1,80 @@
+package chunking
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func testRouting() Routing {
+	return Routing{
+		Presets: map[string]json.RawMessage{
+			"markdown": json.RawMessage(`{"strategy":"sliding","break_on_headings":true}`),
+			"code":     json.RawMessage(`{"mode":"lines","window_size":40}`),
+			"text":     json.RawMessage(`{"window_size":200}`),
+		},
+		Routes: map[string]string{
+			"text/markdown": "markdown",
+			".go":           "code",
+			".log":          "code",
+			"text/*":        "text",
+		},
+	}
+}
+
+func TestRoutingMatch(t *testing.T) {
+	r := testRouting()
+	cases := []struct {
+		meta map[string]interface{}
+		want string
+	}{
+		{map[string]interface{}{"mime_type": "text/markdown; charset=utf-8"}, "markdown"},
+		{map[string]interface{}{"mime_type": "text/plain", "file_name": "main.GO"}, "code"},
+		{map[string]interface{}{"file_path": "/var/log/app.log"}, "code"},
+		{map[string]interface{}{"mime_type": "text/csv"}, "text"},
+		{map[string]interface{}{"mime_type": "application/pdf", "file_name": "a.pdf"}, ""},
+		{nil, ""},
+	}
+	for _, c := range cases {
+		if got := r.Match(c.meta); got != c.want {
+			t.Errorf("Match(%v) = %q, want %q", c.meta, got, c.want)
+		}
+	}
+}
+
+func TestRoutingApply(t *testing.T) {
+	r := testRouting()
+	shared := ChunkingPlan{WindowSize: 100, Overlap: 10, MetaFields: map[string]string{"src": "source_url"}}
+	plan := shared
+	name, err := r.Apply(&plan, map[string]interface{}{"file_name": "main.go"})
+	if err != nil {
+		t.Fatalf("apply failed: %v", err)
+	}
+	if name != "code" || plan.Mode != ModeLines || plan.WindowSize != 40 || plan.Overlap != 10 {
+		t.Errorf("expected the code preset over the plan, got %q %+v", name, plan)
+	}
+	plan.MetaFields["src"] = "changed"
+	if shared.MetaFields["src"] != "source_url" || shared.WindowSize != 100 {
+		t.Errorf("the shared plan should not change, got %+v", shared)
+	}
+
+	plan = shared
+	if name, _ := r.Apply(&plan, map[string]interface{}{"mime_type": "image/png"}); name != "" || plan.WindowSize != 100 {
+		t.Errorf("unrouted documents keep their plan, got %q %+v", name, plan)
+	}
+}
+
+func TestRoutingValidate(t *testing.T) {
+	if err := testRouting().Validate(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	bad := []Routing{
+		{Presets: map[string]json.RawMessage{"x": json.RawMessage(`{"window":3}`)}},
+		{Presets: map[string]json.RawMessage{"x": json.RawMessage(`{}`)}, Routes: map[string]string{"md": "x"}},
+		{Routes: map[string]string{".md": "missing"}},
+	}
+	for i, r := range bad {
+		if err := r.Validate(); err == nil {
+			t.Errorf("case %d: expected an error", i)
+		}
+	}
+}
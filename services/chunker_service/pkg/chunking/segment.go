@@ -0,0 +1,432 @@
+package chunking
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"chunker-service/pkg/tokenizer"
+)
+
+// localePattern accepts BCP 47 style tags such as "de", "ja-JP" or
+// "zh_Hant_TW".
+var localePattern = regexp.MustCompile(`^[A-Za-z]{2,3}([-_][A-Za-z0-9]{1,8})*$`)
+
+func checkLocale(locale string) error {
+	if locale != "" && !localePattern.MatchString(locale) {
+		return fmt.Errorf("invalid locale %q", locale)
+	}
+	return nil
+}
+
+// localeRules are the sentence conventions of a language written with
+// spaces between words.
+type localeRules struct {
+	// abbreviations end in a period without ending the sentence. They
+	// are lowercased and stored without the final period.
+	abbreviations map[string]bool
+	// initials lets a single capital letter and a period ("J. Smith")
+	// continue the sentence.
+	initials bool
+	// ordinals lets a number of one or two digits and a period
+	// ("am 3. Oktober") continue the sentence.
+	ordinals bool
+}
+
+func wordSet(words ...string) map[string]bool {
+	set := make(map[string]bool, len(words))
+	for _, w := range words {
+		set[w] = true
+	}
+	return set
+}
+
+var languageRules = map[string]localeRules{
+	"en": {
+		abbreviations: wordSet("mr", "mrs", "ms", "dr", "prof", "sr", "jr", "st", "vs", "etc",
+			"e.g", "i.e", "cf", "fig", "no", "approx", "inc", "ltd", "co", "corp", "dept", "est"),
+		initials: true,
+	},
+	"de": {
+		abbreviations: wordSet("z.b", "d.h", "u.a", "o.ä", "usw", "bzw", "vgl", "ca", "nr", "dr",
+			"prof", "hr", "fr", "s", "evtl", "ggf", "inkl", "bspw", "etc", "abs", "str", "tel", "u.s.w"),
+		initials: true,
+		ordinals: true,
+	},
+}
+
+// segmenter splits text into sentences and words following the
+// conventions of a locale's language. Japanese, Chinese and Thai, which
+// are written without spaces between words, get word boundaries inside
+// runs of their scripts; other languages split words at whitespace. The
+// zero value applies the language-neutral rules used without a locale.
+type segmenter struct {
+	lang  string
+	rules localeRules
+}
+
+// segmenterFor returns the segmenter for a BCP 47 locale. Only the
+// language subtag matters; languages without specific rules use the
+// language-neutral ones.
+func segmenterFor(locale string) segmenter {
+	lang, _, _ := strings.Cut(strings.ToLower(strings.ReplaceAll(locale, "_", "-")), "-")
+	return segmenter{lang: lang, rules: languageRules[lang]}
+}
+
+// splitsWords reports whether the language's words are not delimited by
+// spaces, so whitespace splitting would make whole sentences one word.
+func (s segmenter) splitsWords() bool {
+	return s.lang == "ja" || s.lang == "zh" || s.lang == "th"
+}
+
+// textSpan is the byte range of one sentence or word, without
+// surrounding whitespace.
+type textSpan struct {
+	start, end int
+}
+
+// splitSentences splits text with the language-neutral rules.
+func splitSentences(text string) []textSpan {
+	return segmenter{}.sentences(text)
+}
+
+// sentences splits text after sentence-ending punctuation followed by
+// whitespace (plus any closing quotes or brackets), after CJK full stops,
+// and at blank lines, so a heading or paragraph without final punctuation
+// does not run into the next one. Abbreviations of the language do not
+// end a sentence. In Japanese and Chinese, punctuation ends a sentence
+// without a following space; in Thai, which marks sentence ends with a
+// space rather than punctuation, so does a space between Thai letters.
+func (s segmenter) sentences(text string) []textSpan {
+	var out []textSpan
+	start := -1
+	newlines := 0
+	flush := func(end int) {
+		if start >= 0 {
+			out = append(out, textSpan{start: start, end: end})
+			start = -1
+		}
+	}
+	cjk := s.lang == "ja" || s.lang == "zh"
+	lastEnd := 0 // end of the last non-space rune
+	var last rune
+	spaced := false // whitespace follows last
+	for i := 0; i < len(text); {
+		r, size := utf8.DecodeRuneInString(text[i:])
+		if unicode.IsSpace(r) {
+			if r == '\n' {
+				newlines++
+				if newlines == 2 {
+					flush(lastEnd)
+				}
+			}
+			spaced = true
+			i += size
+			continue
+		}
+		if s.lang == "th" && spaced && isThaiLetter(last) && isThaiLetter(r) {
+			flush(lastEnd)
+		}
+		newlines = 0
+		spaced = false
+		if start < 0 {
+			start = i
+		}
+		i += size
+		lastEnd, last = i, r
+		switch {
+		case r == '。' || r == '！' || r == '？' || cjk && (r == '．' || r == '｡'):
+			i = skipClosers(text, i)
+			lastEnd = i
+			flush(i)
+		case r == '.' || r == '!' || r == '?':
+			j := skipClosers(text, i)
+			next, _ := utf8.DecodeRuneInString(text[j:])
+			ends := j == len(text) || unicode.IsSpace(next) || cjk && isCJK(next)
+			if ends && r == '.' && s.continues(text[start:i-1]) {
+				ends = false
+			}
+			if ends {
+				i, lastEnd = j, j
+				flush(j)
+			}
+		}
+	}
+	flush(lastEnd)
+	return out
+}
+
+// continues reports whether a period after sentence, the text of the
+// sentence so far, marks an abbreviation, an initial or an ordinal rather
+// than the end of the sentence.
+func (s segmenter) continues(sentence string) bool {
+	word := sentence[strings.LastIndexFunc(sentence, unicode.IsSpace)+1:]
+	word = strings.TrimLeftFunc(word, unicode.IsPunct)
+	if word == "" {
+		return false
+	}
+	if s.rules.abbreviations[strings.ToLower(word)] {
+		return true
+	}
+	if r, size := utf8.DecodeRuneInString(word); s.rules.initials && size == len(word) && unicode.IsUpper(r) {
+		return true
+	}
+	if s.rules.ordinals && len(word) <= 2 && strings.Trim(word, "0123456789") == "" {
+		return true
+	}
+	return false
+}
+
+// skipClosers advances past closing quotes and brackets after a sentence
+// terminator.
+func skipClosers(text string, i int) int {
+	for i < len(text) {
+		r, size := utf8.DecodeRuneInString(text[i:])
+		switch r {
+		case '"', '\'', ')', ']', '”', '’', '」', '』', '）':
+			i += size
+		default:
+			return i
+		}
+	}
+	return i
+}
+
+// wordSpans returns the byte ranges of the words of text, covering all
+// of its non-space text. Punctuation stays with the word before it, or
+// with the word after it when it opens a quote or bracket.
+func (s segmenter) wordSpans(text string) []textSpan {
+	var out []textSpan
+	start := -1
+	var state wordState
+	for i := 0; i < len(text); {
+		r, size := utf8.DecodeRuneInString(text[i:])
+		if unicode.IsSpace(r) {
+			if start >= 0 {
+				out = append(out, textSpan{start: start, end: i})
+				start = -1
+			}
+			i += size
+			continue
+		}
+		if start < 0 {
+			start, state = i, wordState{}
+		} else if s.splitsWords() && state.breaks(s.lang, r, text[i+size:]) {
+			out = append(out, textSpan{start: start, end: i})
+			start, state = i, wordState{}
+		}
+		state.add(s.lang, r)
+		i += size
+	}
+	if start >= 0 {
+		out = append(out, textSpan{start: start, end: len(text)})
+	}
+	return out
+}
+
+// words returns the words of text with surrounding punctuation removed,
+// keeping combining marks such as Thai vowel and tone marks, and skipping
+// words that are only punctuation.
+func (s segmenter) words(text string) []string {
+	var out []string
+	for _, sp := range s.wordSpans(text) {
+		word := strings.TrimFunc(text[sp.start:sp.end], func(r rune) bool {
+			return !unicode.IsLetter(r) && !unicode.IsDigit(r) && !unicode.IsMark(r)
+		})
+		if word != "" {
+			out = append(out, word)
+		}
+	}
+	return out
+}
+
+// Script classes of runes within words.
+const (
+	classPunct = iota
+	classOther
+	classHan
+	classHiragana
+	classKatakana
+	classThai
+)
+
+func scriptClass(r rune) int {
+	switch {
+	case unicode.Is(unicode.Han, r):
+		return classHan
+	case unicode.Is(unicode.Hiragana, r):
+		return classHiragana
+	case unicode.Is(unicode.Katakana, r) || r == 'ー':
+		return classKatakana
+	case isThaiLetter(r):
+		return classThai
+	case unicode.IsLetter(r) || unicode.IsDigit(r) || unicode.IsMark(r):
+		return classOther
+	}
+	return classPunct
+}
+
+func isCJK(r rune) bool {
+	c := scriptClass(r)
+	return c == classHan || c == classHiragana || c == classKatakana
+}
+
+func isThaiLetter(r rune) bool {
+	return r >= 0x0E01 && r <= 0x0E4E
+}
+
+// wordState tracks the word being built by wordSpans.
+type wordState struct {
+	class  int  // script class of the last letter, classPunct before any
+	closed bool // punctuation followed the last letter
+	thai   thaiCluster
+}
+
+// breaks reports whether a word boundary falls before r, followed by
+// rest.
+func (w *wordState) breaks(lang string, r rune, rest string) bool {
+	c := scriptClass(r)
+	if c == classPunct {
+		// Opening punctuation starts the next word.
+		return w.class != classPunct && (unicode.Is(unicode.Ps, r) || unicode.Is(unicode.Pi, r))
+	}
+	switch {
+	case w.class == classPunct:
+		return false
+	case w.closed || c != w.class:
+		// Kanji followed by kana endings (okurigana) form one word.
+		return !(lang == "ja" && w.class == classHan && c == classHiragana && !w.closed)
+	case c == classHan:
+		// Chinese has no spaces and no kana, so each character counts
+		// as a word.
+		return lang == "zh"
+	case c == classThai:
+		return w.thai.breaks(r, rest)
+	}
+	return false
+}
+
+func (w *wordState) add(lang string, r rune) {
+	c := scriptClass(r)
+	if c == classPunct {
+		w.closed = w.class != classPunct
+		return
+	}
+	if c != w.class || w.closed {
+		w.thai = thaiCluster{}
+	}
+	w.class, w.closed = c, false
+	if c == classThai {
+		w.thai.add(r)
+	}
+}
+
+// thaiCluster approximates Thai syllables without a dictionary: a
+// syllable is an optional leading vowel, one or two consonants, the vowel
+// and tone marks around them and an optional final consonant. Words of
+// several syllables are split, so Thai word counts are syllable counts.
+type thaiCluster struct {
+	consonants int
+	vowel      bool
+	closed     bool
+}
+
+func thaiLeadingVowel(r rune) bool { return r >= 0x0E40 && r <= 0x0E44 }
+func thaiConsonant(r rune) bool    { return r >= 0x0E01 && r <= 0x0E2E }
+
+// thaiVowel covers following vowels and vowel marks written above or
+// below the consonant.
+func thaiVowel(r rune) bool {
+	return r == 0x0E30 || r == 0x0E31 || r >= 0x0E32 && r <= 0x0E39 || r == 0x0E45 || r == 0x0E47
+}
+
+// thaiFinalVowel ends the syllable: sara a and sara am.
+func thaiFinalVowel(r rune) bool { return r == 0x0E30 || r == 0x0E33 }
+
+func (t *thaiCluster) breaks(r rune, rest string) bool {
+	switch {
+	case thaiLeadingVowel(r):
+		return true
+	case !thaiConsonant(r):
+		return false
+	case t.closed:
+		return true
+	case t.vowel && t.consonants > 0:
+		// A consonant carrying a vowel, alone or as the first of a
+		// cluster with ro, lo or wo, starts the next syllable; otherwise
+		// it is this syllable's final consonant.
+		next, size := utf8.DecodeRuneInString(rest)
+		if next == 'ร' || next == 'ล' || next == 'ว' {
+			next, _ = utf8.DecodeRuneInString(rest[size:])
+		}
+		return thaiVowel(next)
+	}
+	return t.consonants >= 2
+}
+
+func (t *thaiCluster) add(r rune) {
+	switch {
+	case thaiLeadingVowel(r):
+		t.vowel = true
+	case thaiConsonant(r):
+		if t.vowel && t.consonants > 0 {
+			t.closed = true
+		}
+		t.consonants++
+	case thaiVowel(r):
+		t.vowel = true
+		t.closed = t.closed || thaiFinalVowel(r)
+	case r == 0x0E2F || r == 0x0E46: // paiyannoi and mai yamok
+		t.closed = true
+	}
+}
+
+// PlanTokenizer returns the tokenizer used in tokens mode: the one the
+// plan names or, when it names none and its locale is written without
+// spaces between words, one splitting words with the locale's rules.
+func PlanTokenizer(plan ChunkingPlan) (tokenizer.Tokenizer, error) {
+	if seg := segmenterFor(plan.Locale); plan.Tokenizer == "" && seg.splitsWords() {
+		return wordTokenizer{seg}, nil
+	}
+	return tokenizer.Get(plan.Tokenizer)
+}
+
+// wordTokenizer treats every word found by a segmenter as one token.
+type wordTokenizer struct {
+	seg segmenter
+}
+
+func (t wordTokenizer) Tokenize(text string) []string {
+	spans := t.seg.wordSpans(text)
+	tokens := make([]string, len(spans))
+	for i, sp := range spans {
+		tokens[i] = text[sp.start:sp.end]
+	}
+	return tokens
+}
+
+// Join joins tokens with single spaces, except between two tokens of
+// scripts written without spaces.
+func (t wordTokenizer) Join(tokens []string) string {
+	var b strings.Builder
+	for i, tok := range tokens {
+		if i > 0 {
+			prev, _ := utf8.DecodeLastRuneInString(tokens[i-1])
+			next, _ := utf8.DecodeRuneInString(tok)
+			if !(spaceless(prev) && spaceless(next)) {
+				b.WriteByte(' ')
+			}
+		}
+		b.WriteString(tok)
+	}
+	return b.String()
+}
+
+// spaceless reports whether r belongs to a script written without spaces,
+// or is punctuation, which attaches to such scripts too.
+func spaceless(r rune) bool {
+	c := scriptClass(r)
+	return c != classOther
+}
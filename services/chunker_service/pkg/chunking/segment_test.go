@@ -0,0 +1,76 @@
+package chunking
+
+import (
+	"reflect"
+	"testing"
+)
+
+func spanTexts(text string, spans []textSpan) []string {
+	var out []string
+	for _, s := range spans {
+		out = append(out, text[s.start:s.end])
+	}
+	return out
+}
+
+func TestLocaleSentences(t *testing.T) {
+	cases := []struct {
+		locale, text string
+		want         []string
+	}{
+		{"", "Dr. Smith left. Bye", []string{"Dr.", "Smith left.", "Bye"}},
+		{"en-US", "Dr. Smith met J. Doe, e.g. at noon. They left!", []string{"Dr. Smith met J. Doe, e.g. at noon.", "They left!"}},
+		{"de", "Am 3. Oktober kam z.B. Herr Müller. Er blieb bis 2024. Dann ging er.", []string{"Am 3. Oktober kam z.B. Herr Müller.", "Er blieb bis 2024.", "Dann ging er."}},
+		{"ja", "猫が好きです!犬も好き?はい．", []string{"猫が好きです!", "犬も好き?", "はい．"}},
+		{"th", "ภาษาไทยสวยงาม สวัสดีครับ", []string{"ภาษาไทยสวยงาม", "สวัสดีครับ"}},
+	}
+	for _, c := range cases {
+		if got := spanTexts(c.text, segmenterFor(c.locale).sentences(c.text)); !reflect.DeepEqual(got, c.want) {
+			t.Errorf("%q: expected %q, got %q", c.locale, c.want, got)
+		}
+	}
+}
+
+func TestLocaleWords(t *testing.T) {
+	cases := []struct {
+		locale, text string
+		want         []string
+	}{
+		{"de", "Die Donaudampfschifffahrtsgesellschaft fuhr.", []string{"Die", "Donaudampfschifffahrtsgesellschaft", "fuhr."}},
+		{"ja", "東京に行きました。「カメラ」をAPIで使う", []string{"東京に", "行きました。", "「カメラ」", "を", "API", "で", "使う"}},
+		{"zh", "我们学习中文。", []string{"我", "们", "学", "习", "中", "文。"}},
+		{"th", "ประเทศไทยสวัสดีครับ", []string{"ประ", "เทศ", "ไทย", "สวัส", "ดี", "ครับ"}},
+	}
+	for _, c := range cases {
+		if got := spanTexts(c.text, segmenterFor(c.locale).wordSpans(c.text)); !reflect.DeepEqual(got, c.want) {
+			t.Errorf("%q: expected %q, got %q", c.locale, c.want, got)
+		}
+	}
+}
+
+func TestLocaleTokensMode(t *testing.T) {
+	text := "東京に行きました。 猫が好きです。"
+	chunks, err := NewSlidingWindowChunker().Chunk(text, ChunkingPlan{WindowSize: 3, Overlap: 1, Mode: ModeTokens, Locale: "ja"}, nil)
+	if err != nil {
+		t.Fatalf("chunking failed: %v", err)
+	}
+	want := []string{"東京に行きました。 猫が", "猫が好きです。"}
+	if len(chunks) != len(want) {
+		t.Fatalf("expected %d chunks, got %+v", len(want), chunks)
+	}
+	for i, ch := range chunks {
+		if ch.Text != want[i] || text[ch.ByteStart:ch.ByteEnd] != ch.Text {
+			t.Errorf("chunk %d: expected %q at its byte range, got %q at %d-%d", i, want[i], ch.Text, ch.ByteStart, ch.ByteEnd)
+		}
+	}
+
+	// Without a locale the whole text is two whitespace-delimited tokens.
+	plain, _ := NewSlidingWindowChunker().Chunk(text, ChunkingPlan{WindowSize: 3, Mode: ModeTokens}, nil)
+	if len(plain) != 1 {
+		t.Errorf("expected one chunk without a locale, got %d", len(plain))
+	}
+
+	if _, err := NewSlidingWindowChunker().Chunk(text, ChunkingPlan{WindowSize: 3, Locale: "ja JP"}, nil); err == nil {
+		t.Error("expected an error for a malformed locale")
+	}
+}
@@ -0,0 +1,253 @@
+package chunking
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"unicode/utf8"
+)
+
+// DefaultSimilarityPercentile is used when a semantic plan sets neither
+// a similarity threshold nor a percentile.
+const DefaultSimilarityPercentile = 10
+
+// Embedder turns texts into vectors, one per text and in input order.
+// embedding.Client implements it against the embedding service.
+type Embedder interface {
+	Embed(ctx context.Context, texts []string) ([][]float64, error)
+}
+
+// SemanticChunker splits text into sentences, embeds them and starts a
+// new chunk wherever adjacent sentences are dissimilar, so chunks follow
+// topic shifts instead of a fixed window. Plans must use StrategySemantic.
+type SemanticChunker struct {
+	Embedder Embedder
+
+	// Limits, MetaSchema, Trace, Context and Enrichers behave as on
+	// SlidingWindowChunker; Context also bounds the embedding calls.
+	Limits     OutputLimits
+	MetaSchema MetaSchema
+	Trace      *Trace
+	Context    context.Context
+	Enrichers  map[string]Enricher
+}
+
+// Chunk breaks text at similarity valleys: boundaries between sentences
+// whose cosine similarity is below the plan's threshold. A chunk is also
+// closed once it holds WindowSize sentences. StartIndex and EndIndex
+// count sentences.
+func (c *SemanticChunker) Chunk(
+	text string,
+	plan ChunkingPlan,
+	baseMeta map[string]interface{},
+) ([]Chunk, error) {
+	if plan.Strategy != StrategySemantic {
+		return nil, fmt.Errorf("strategy %q is not supported by the semantic chunker", plan.Strategy)
+	}
+	if c.Embedder == nil {
+		return nil, errors.New("semantic chunking requires an embedder")
+	}
+	if plan.WindowSize <= 0 {
+		return nil, errors.New("window_size must be > 0")
+	}
+	if plan.SimilarityThreshold != 0 && plan.SimilarityPercentile != 0 {
+		return nil, errors.New("set at most one of similarity_threshold and similarity_percentile")
+	}
+	if plan.SimilarityThreshold < -1 || plan.SimilarityThreshold > 1 {
+		return nil, errors.New("similarity_threshold must be between -1 and 1")
+	}
+	if plan.SimilarityPercentile < 0 || plan.SimilarityPercentile >= 100 {
+		return nil, errors.New("similarity_percentile must be >= 0 and < 100")
+	}
+	if err := checkInput(text, plan, c.MetaSchema, baseMeta); err != nil {
+		return nil, err
+	}
+	if err := checkEnrichers(plan, c.Enrichers); err != nil {
+		return nil, err
+	}
+	if chunks, ok, err := chunkConcatenated(text, plan, baseMeta, c.Limits, c.Trace, c.Chunk); ok {
+		return chunks, err
+	}
+
+	sentences := segmenterFor(plan.Locale).sentences(text)
+	c.Trace.add("units", map[string]interface{}{"mode": "sentences", "count": len(sentences)},
+		"split %d bytes of input into %d sentences", len(text), len(sentences))
+	if len(sentences) == 0 {
+		return nil, nil
+	}
+
+	sims, err := c.similarities(text, sentences)
+	if err != nil {
+		return nil, err
+	}
+	cutoff := plan.SimilarityThreshold
+	if cutoff == 0 {
+		p := plan.SimilarityPercentile
+		if p == 0 {
+			p = DefaultSimilarityPercentile
+		}
+		cutoff = percentile(sims, p)
+	}
+	c.Trace.add("threshold", map[string]interface{}{"cutoff": cutoff},
+		"breaking where adjacent sentence similarity is below %.4f", cutoff)
+
+	var chunks []Chunk
+	totalBytes := 0
+	runes, runePos := 0, 0 // runes counts runes in text[:runePos]
+	runesTo := func(pos int) int {
+		runes += utf8.RuneCountInString(text[runePos:pos])
+		runePos = pos
+		return runes
+	}
+	for start := 0; start < len(sentences); {
+		end := start + 1
+		for end < len(sentences) && end-start < plan.WindowSize && sims[end-1] >= cutoff {
+			end++
+		}
+		if end < len(sentences) {
+			if sims[end-1] < cutoff {
+				c.Trace.add("boundary", map[string]interface{}{"sentence": end, "similarity": sims[end-1]},
+					"similarity %.4f before sentence %d is a valley", sims[end-1], end)
+			} else {
+				c.Trace.add("boundary", map[string]interface{}{"sentence": end, "similarity": sims[end-1]},
+					"closed chunk at window_size=%d sentences before sentence %d", plan.WindowSize, end)
+			}
+		}
+
+		byteStart, byteEnd := sentences[start].start, sentences[end-1].end
+		chunk := Chunk{
+			Schema:     SchemaVersion,
+			Text:       text[byteStart:byteEnd],
+			StartIndex: start,
+			EndIndex:   end,
+			ByteStart:  byteStart,
+			ByteEnd:    byteEnd,
+			RuneStart:  runesTo(byteStart),
+			RuneEnd:    runesTo(byteEnd),
+			Extra:      map[string]interface{}{},
+		}
+		if end < len(sentences) {
+			chunk.Extra["break_similarity"] = sims[end-1]
+		}
+		promoteMeta(&chunk, baseMeta, plan.MetaFields)
+
+		chunks = append(chunks, chunk)
+		totalBytes += len(chunk.Text)
+		if err := c.Limits.check(len(chunks), totalBytes); err != nil {
+			return nil, err
+		}
+		if err := checkContext(c.Context); err != nil {
+			return nil, err
+		}
+		if plan.MaxChunks > 0 && len(chunks) >= plan.MaxChunks {
+			c.Trace.add("truncate", map[string]interface{}{"max_chunks": plan.MaxChunks},
+				"stopped after max_chunks=%d at sentence %d of %d", plan.MaxChunks, end, len(sentences))
+			break
+		}
+		start = end
+	}
+
+	c.Trace.add("chunks", map[string]interface{}{"count": len(chunks), "bytes": totalBytes},
+		"produced %d chunks with %d bytes of text", len(chunks), totalBytes)
+
+	var packs []LanguagePack
+	if plan.ChunkTitles || plan.BreakOnDates {
+		if packs, err = resolveLanguagePacks(plan.HeadingLanguages); err != nil {
+			return nil, err
+		}
+	}
+	chunks, images, err := enrich(c.Context, text, chunks, plan, packs, c.Enrichers, c.Trace)
+	if err != nil {
+		return nil, err
+	}
+
+	doc := documentKey(text, baseMeta)
+	assignIDs(chunks, doc)
+	sequence := make([]*Chunk, len(chunks))
+	for i := range chunks {
+		sequence[i] = &chunks[i]
+	}
+	linkSequence(sequence)
+
+	parent := &SlidingWindowChunker{Limits: c.Limits, MetaSchema: c.MetaSchema, Trace: c.Trace, Context: c.Context, Enrichers: c.Enrichers}
+	if plan.Children != nil {
+		if chunks, err = parent.addChildren(text, chunks, plan, baseMeta); err != nil {
+			return nil, err
+		}
+	}
+	if plan.ImageChunks {
+		if chunks, err = parent.addImageChunks(text, chunks, images, doc, plan, baseMeta); err != nil {
+			return nil, err
+		}
+	}
+	markConcatenated(text, chunks, plan, c.Trace)
+	applyPolicy(chunks, plan)
+	return chunks, nil
+}
+
+// similarities embeds the sentences and returns the cosine similarity of
+// each adjacent pair: sims[i] compares sentence i with sentence i+1.
+func (c *SemanticChunker) similarities(text string, sentences []textSpan) ([]float64, error) {
+	if len(sentences) < 2 {
+		return nil, nil
+	}
+	texts := make([]string, len(sentences))
+	for i, s := range sentences {
+		texts[i] = text[s.start:s.end]
+	}
+	ctx := c.Context
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	vectors, err := c.Embedder.Embed(ctx, texts)
+	if err := checkContext(ctx); err != nil {
+		return nil, err
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrEmbeddingFailed, err)
+	}
+	if len(vectors) != len(texts) {
+		return nil, fmt.Errorf("%w: got %d vectors for %d sentences", ErrEmbeddingFailed, len(vectors), len(texts))
+	}
+	sims := make([]float64, len(vectors)-1)
+	for i := range sims {
+		sims[i] = cosine(vectors[i], vectors[i+1])
+	}
+	return sims, nil
+}
+
+// cosine returns the cosine similarity of a and b, or 0 when either is a
+// zero vector or their dimensions differ.
+func cosine(a, b []float64) float64 {
+	if len(a) != len(b) {
+		return 0
+	}
+	var dot, na, nb float64
+	for i := range a {
+		dot += a[i] * b[i]
+		na += a[i] * a[i]
+		nb += b[i] * b[i]
+	}
+	if na == 0 || nb == 0 {
+		return 0
+	}
+	return dot / (math.Sqrt(na) * math.Sqrt(nb))
+}
+
+// percentile returns the p-th percentile of values using linear
+// interpolation between closest ranks.
+func percentile(values []float64, p float64) float64 {
+	if len(values) == 0 {
+		return 0
+	}
+	sorted := append([]float64(nil), values...)
+	sort.Float64s(sorted)
+	rank := p / 100 * float64(len(sorted)-1)
+	lo := int(rank)
+	if lo+1 >= len(sorted) {
+		return sorted[lo]
+	}
+	return sorted[lo] + (rank-float64(lo))*(sorted[lo+1]-sorted[lo])
+}
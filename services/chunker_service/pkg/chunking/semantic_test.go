@@ -0,0 +1,136 @@
+package chunking
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// topicEmbedder embeds a sentence as a one-hot vector of the topic word it
+// mentions, so sentences on the same topic are identical.
+type topicEmbedder struct{ calls int }
+
+func (e *topicEmbedder) Embed(_ context.Context, texts []string) ([][]float64, error) {
+	e.calls++
+	topics := []string{"cat", "car", "tax"}
+	out := make([][]float64, len(texts))
+	for i, t := range texts {
+		v := make([]float64, len(topics))
+		for j, topic := range topics {
+			if strings.Contains(t, topic) {
+				v[j] = 1
+			}
+		}
+		out[i] = v
+	}
+	return out, nil
+}
+
+func TestSemanticChunkBreaksAtValleys(t *testing.T) {
+	text := "The cat sat. A cat slept.\n\nMy car broke! The car stalled. File the tax form."
+	chunker := &SemanticChunker{Embedder: &topicEmbedder{}}
+	plan := ChunkingPlan{Strategy: StrategySemantic, WindowSize: 10, SimilarityThreshold: 0.5}
+
+	chunks, err := chunker.Chunk(text, plan, map[string]interface{}{"doc_id": "d"})
+	if err != nil {
+		t.Fatalf("chunking failed: %v", err)
+	}
+	var texts []string
+	for _, ch := range chunks {
+		texts = append(texts, ch.Text)
+		if got := text[ch.ByteStart:ch.ByteEnd]; got != ch.Text {
+			t.Errorf("offsets %d-%d cover %q, want %q", ch.ByteStart, ch.ByteEnd, got, ch.Text)
+		}
+	}
+	want := []string{"The cat sat. A cat slept.", "My car broke! The car stalled.", "File the tax form."}
+	if !reflect.DeepEqual(texts, want) {
+		t.Fatalf("expected %q, got %q", want, texts)
+	}
+	if chunks[1].StartIndex != 2 || chunks[1].EndIndex != 4 {
+		t.Errorf("expected sentence indices 2-4, got %d-%d", chunks[1].StartIndex, chunks[1].EndIndex)
+	}
+	if chunks[1].ID != "d#1" || chunks[1].PrevID != "d#0" || chunks[1].NextID != "d#2" {
+		t.Errorf("unexpected links: %+v", chunks[1])
+	}
+	if _, ok := chunks[0].Extra["break_similarity"]; !ok {
+		t.Errorf("expected break_similarity on chunk 0")
+	}
+
+	plan.WindowSize = 1
+	chunks, err = chunker.Chunk(text, plan, nil)
+	if err != nil {
+		t.Fatalf("chunking failed: %v", err)
+	}
+	if len(chunks) != 5 {
+		t.Errorf("window_size=1 should yield one chunk per sentence, got %d", len(chunks))
+	}
+}
+
+func TestSemanticChunkPercentile(t *testing.T) {
+	text := "The cat sat. A cat slept. My car broke. The car stalled."
+	chunker := &SemanticChunker{Embedder: &topicEmbedder{}}
+	plan := ChunkingPlan{Strategy: StrategySemantic, WindowSize: 10}
+
+	chunks, err := chunker.Chunk(text, plan, nil)
+	if err != nil {
+		t.Fatalf("chunking failed: %v", err)
+	}
+	if len(chunks) != 2 || chunks[1].Text != "My car broke. The car stalled." {
+		t.Errorf("expected a single break at the topic shift, got %+v", chunks)
+	}
+}
+
+func TestSemanticChunkValidation(t *testing.T) {
+	chunker := &SemanticChunker{Embedder: &topicEmbedder{}}
+	bad := []ChunkingPlan{
+		{WindowSize: 5},
+		{Strategy: StrategySemantic},
+		{Strategy: StrategySemantic, WindowSize: 5, SimilarityThreshold: 0.5, SimilarityPercentile: 10},
+		{Strategy: StrategySemantic, WindowSize: 5, SimilarityPercentile: 100},
+	}
+	for i, plan := range bad {
+		if _, err := chunker.Chunk("a. b.", plan, nil); err == nil {
+			t.Errorf("plan %d: expected error", i)
+		}
+	}
+	sliding := NewSlidingWindowChunker()
+	if _, err := sliding.Chunk("a. b.", ChunkingPlan{Strategy: StrategySemantic, WindowSize: 5}, nil); err == nil {
+		t.Errorf("sliding window chunker should reject semantic plans")
+	}
+}
+
+func TestSplitSentences(t *testing.T) {
+	text := "Smith said \"hi.\" Then left!\n\nHeading\nBody text\u3002\u6b21\u3002"
+	var got []string
+	for _, s := range splitSentences(text) {
+		got = append(got, text[s.start:s.end])
+	}
+	want := []string{"Smith said \"hi.\"", "Then left!", "Heading\nBody text\u3002", "\u6b21\u3002"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("expected %q, got %q", want, got)
+	}
+}
+
+func TestStrategiesDispatch(t *testing.T) {
+	embedder := &topicEmbedder{}
+	chunker := Strategies{
+		StrategySliding:  NewSlidingWindowChunker(),
+		StrategySemantic: &SemanticChunker{Embedder: embedder},
+	}
+	if _, err := chunker.Chunk("The cat sat. My car broke.", ChunkingPlan{WindowSize: 3}, nil); err != nil {
+		t.Fatalf("sliding plan failed: %v", err)
+	}
+	if embedder.calls != 0 {
+		t.Errorf("sliding plan should not embed")
+	}
+	if _, err := chunker.Chunk("The cat sat. My car broke.", ChunkingPlan{Strategy: StrategySemantic, WindowSize: 3}, nil); err != nil {
+		t.Fatalf("semantic plan failed: %v", err)
+	}
+	if embedder.calls != 1 {
+		t.Errorf("semantic plan should embed once, got %d calls", embedder.calls)
+	}
+	if _, err := (Strategies{}).Chunk("x", ChunkingPlan{WindowSize: 1}, nil); err == nil {
+		t.Errorf("expected error for unconfigured strategy")
+	}
+}
@@ -0,0 +1,98 @@
+package chunking
+
+import (
+	"unicode/utf8"
+
+	"chunker-service/pkg/tokenizer"
+)
+
+// ChunkStats summarizes the chunks of a document, or of several merged
+// with MergeStats, so a plan can be judged without reading the chunks.
+// Sizes count the chunk text in the plan's tokens and in characters.
+// OverlapBytes is the text consecutive chunks share and OverlapRatio
+// its share of all chunk text; child and image chunks, which repeat
+// their parent's text by design, do not count as overlap.
+type ChunkStats struct {
+	Chunks       int     `json:"chunks"`
+	MinTokens    int     `json:"min_tokens"`
+	MaxTokens    int     `json:"max_tokens"`
+	AvgTokens    float64 `json:"avg_tokens"`
+	MinChars     int     `json:"min_chars"`
+	MaxChars     int     `json:"max_chars"`
+	AvgChars     float64 `json:"avg_chars"`
+	Bytes        int     `json:"bytes"`
+	OverlapBytes int     `json:"overlap_bytes"`
+	OverlapRatio float64 `json:"overlap_ratio"`
+}
+
+// Stats computes the ChunkStats of one document's chunks, counting
+// tokens with tok.
+func Stats(chunks []Chunk, tok tokenizer.Tokenizer) ChunkStats {
+	var s ChunkStats
+	var tokens, chars int
+	prevEnd := -1
+	for i, ch := range chunks {
+		nt := len(tok.Tokenize(ch.Text))
+		nc := utf8.RuneCountInString(ch.Text)
+		if i == 0 || nt < s.MinTokens {
+			s.MinTokens = nt
+		}
+		if i == 0 || nc < s.MinChars {
+			s.MinChars = nc
+		}
+		s.MaxTokens = max(s.MaxTokens, nt)
+		s.MaxChars = max(s.MaxChars, nc)
+		tokens += nt
+		chars += nc
+		s.Bytes += ch.ByteEnd - ch.ByteStart
+
+		if ch.ParentID != "" || ch.Extra["kind"] == "image_context" {
+			continue
+		}
+		if prevEnd > ch.ByteStart {
+			s.OverlapBytes += min(prevEnd, ch.ByteEnd) - ch.ByteStart
+		}
+		prevEnd = ch.ByteEnd
+	}
+	s.Chunks = len(chunks)
+	if s.Chunks > 0 {
+		s.AvgTokens = round2(float64(tokens) / float64(s.Chunks))
+		s.AvgChars = round2(float64(chars) / float64(s.Chunks))
+	}
+	if s.Bytes > 0 {
+		s.OverlapRatio = round2(float64(s.OverlapBytes) / float64(s.Bytes))
+	}
+	return s
+}
+
+// MergeStats combines the ChunkStats of several documents.
+func MergeStats(stats []ChunkStats) ChunkStats {
+	var out ChunkStats
+	var tokens, chars float64
+	for _, s := range stats {
+		if s.Chunks == 0 {
+			continue
+		}
+		if out.Chunks == 0 || s.MinTokens < out.MinTokens {
+			out.MinTokens = s.MinTokens
+		}
+		if out.Chunks == 0 || s.MinChars < out.MinChars {
+			out.MinChars = s.MinChars
+		}
+		out.MaxTokens = max(out.MaxTokens, s.MaxTokens)
+		out.MaxChars = max(out.MaxChars, s.MaxChars)
+		tokens += s.AvgTokens * float64(s.Chunks)
+		chars += s.AvgChars * float64(s.Chunks)
+		out.Chunks += s.Chunks
+		out.Bytes += s.Bytes
+		out.OverlapBytes += s.OverlapBytes
+	}
+	if out.Chunks > 0 {
+		out.AvgTokens = round2(tokens / float64(out.Chunks))
+		out.AvgChars = round2(chars / float64(out.Chunks))
+	}
+	if out.Bytes > 0 {
+		out.OverlapRatio = round2(float64(out.OverlapBytes) / float64(out.Bytes))
+	}
+	return out
+}
@@ -0,0 +1,36 @@
+package chunking
+
+import (
+	"testing"
+
+	"chunker-service/pkg/tokenizer"
+)
+
+func TestStats(t *testing.T) {
+	text := "aa bb cc dd ee ff"
+	chunks, err := NewSlidingWindowChunker().Chunk(text, ChunkingPlan{WindowSize: 4, Overlap: 2, Mode: ModeTokens}, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	// "aa bb cc dd" and "cc dd ee ff" share "cc dd".
+	s := Stats(chunks, tokenizer.Whitespace{})
+	want := ChunkStats{Chunks: 2, MinTokens: 4, MaxTokens: 4, AvgTokens: 4, MinChars: 11, MaxChars: 11, AvgChars: 11,
+		Bytes: 22, OverlapBytes: 5, OverlapRatio: 0.23}
+	if s != want {
+		t.Fatalf("expected %+v, got %+v", want, s)
+	}
+
+	children, err := NewSlidingWindowChunker().Chunk(text, ChunkingPlan{WindowSize: 6, Mode: ModeTokens,
+		Children: &ChunkingPlan{WindowSize: 2, Mode: ModeTokens}}, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if s := Stats(children, tokenizer.Whitespace{}); s.Chunks != 4 || s.OverlapBytes != 0 || s.MinTokens != 2 || s.MaxTokens != 6 {
+		t.Fatalf("children should not count as overlap: %+v", s)
+	}
+
+	merged := MergeStats([]ChunkStats{want, {}, {Chunks: 1, MinTokens: 1, MaxTokens: 1, AvgTokens: 1, MinChars: 2, MaxChars: 2, AvgChars: 2, Bytes: 3}})
+	if merged.Chunks != 3 || merged.MinTokens != 1 || merged.MaxChars != 11 || merged.AvgTokens != 3 || merged.OverlapRatio != 0.2 {
+		t.Fatalf("unexpected merged stats: %+v", merged)
+	}
+}
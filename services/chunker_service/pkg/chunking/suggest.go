@@ -0,0 +1,242 @@
+package chunking
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Structure summarizes the shape of a document: the measurements
+// SuggestPlan picks a plan from. Ratios are shares of the non-blank
+// lines.
+type Structure struct {
+	Lines    int `json:"lines"`
+	Headings int `json:"headings"`
+	// HeadingDensity is the number of headings per 100 lines.
+	HeadingDensity float64 `json:"heading_density"`
+	// AvgLineLength is the average line length in characters.
+	AvgLineLength float64 `json:"avg_line_length"`
+	// CodeRatio counts lines in fenced code blocks or that look like
+	// source code.
+	CodeRatio float64 `json:"code_ratio"`
+	// TurnRatio counts lines that start a speaker turn ("Alice: ...",
+	// "[10:02] Bob: ...", "<bob> ...").
+	TurnRatio float64 `json:"turn_ratio"`
+	// PageBreaks counts form feeds, which PDF text extraction puts
+	// between pages.
+	PageBreaks int `json:"page_breaks,omitempty"`
+	// Language is the locale guessed from the document's script, or for
+	// Latin script its most common words; empty when unsure.
+	Language string `json:"language,omitempty"`
+}
+
+// Suggestion is a plan recommended for a document, with the evidence.
+type Suggestion struct {
+	Plan ChunkingPlan `json:"plan"`
+	// Preset names the preset the plan was derived from; it is empty
+	// for the plain prose plan.
+	Preset string `json:"preset,omitempty"`
+	// Confidence, from 0 to 1, is how clearly the document matched the
+	// preset. Callers may fall back to another way of choosing a plan,
+	// such as asking an LLM, when it is low.
+	Confidence float64   `json:"confidence"`
+	Reasons    []string  `json:"reasons"`
+	Structure  Structure `json:"structure"`
+}
+
+// Thresholds of SuggestPlan.
+const (
+	suggestCodeRatio      = 0.5
+	suggestTurnRatio      = 0.5
+	suggestHeadingDensity = 1.0 // headings per 100 lines
+	// suggestWindowChars is the characters a lines-mode window should
+	// hold; windows of long lines get fewer of them.
+	suggestWindowChars = 2400
+)
+
+var (
+	codeKeyword = regexp.MustCompile(`^(func|def|class|import|package|return|const|let|var|public|private|protected|static|#include|fn|impl|use|from \S+ import|if \(|for \(|while \(|} else)\b`)
+	speakerTurn = regexp.MustCompile(`^(\[?[0-9]{1,2}:[0-9]{2}(:[0-9]{2})?\]?\s+)?(<[^>\s]{1,32}>|\p{Lu}[\p{L}\p{N} ._'-]{0,31}:)\s+\S`)
+)
+
+// AnalyzeStructure measures the structure of text.
+func AnalyzeStructure(text string) Structure {
+	// Resolving all packs cannot fail.
+	packs, _ := resolveLanguagePacks(nil)
+	var s Structure
+	var chars, code, turns int
+	fenced := false
+	for _, line := range strings.Split(text, "\n") {
+		s.PageBreaks += strings.Count(line, "\f")
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "" {
+			continue
+		}
+		s.Lines++
+		chars += utf8.RuneCountInString(trimmed)
+		switch {
+		case strings.HasPrefix(trimmed, "```") || strings.HasPrefix(trimmed, "~~~"):
+			fenced = !fenced
+			code++
+		case fenced || codeLine(trimmed):
+			code++
+		case speakerTurn.MatchString(trimmed):
+			turns++
+		case isHeading(line, packs):
+			s.Headings++
+		}
+	}
+	if s.Lines > 0 {
+		n := float64(s.Lines)
+		s.HeadingDensity = round2(100 * float64(s.Headings) / n)
+		s.AvgLineLength = round2(float64(chars) / n)
+		s.CodeRatio = round2(float64(code) / n)
+		s.TurnRatio = round2(float64(turns) / n)
+	}
+	s.Language = guessLanguage(text)
+	return s
+}
+
+// codeLine reports whether a trimmed line outside a code fence looks like
+// source code: it starts with a keyword or ends like a statement or block.
+func codeLine(trimmed string) bool {
+	if codeKeyword.MatchString(trimmed) {
+		return true
+	}
+	for _, end := range []string{"{", "}", ";", "};", "})", ");"} {
+		if strings.HasSuffix(trimmed, end) {
+			return true
+		}
+	}
+	return false
+}
+
+// latinStopwords are frequent words of the Latin-script languages with
+// segmentation rules.
+var latinStopwords = map[string]map[string]bool{
+	"en": wordSet("the", "and", "of", "to", "is", "in", "that", "it", "with", "for", "are", "this"),
+	"de": wordSet("der", "die", "und", "das", "ist", "nicht", "ein", "eine", "zu", "mit", "den", "auf"),
+}
+
+// guessLanguage guesses the locale of text from the scripts of its
+// letters: kana means Japanese, otherwise Han means Chinese; Hangul,
+// Thai and Cyrillic mean Korean, Thai and Russian. Latin text is told
+// apart by its most frequent words.
+func guessLanguage(text string) string {
+	var letters, han, kana, hangul, thai, cyrillic int
+	for _, r := range text {
+		if !unicode.IsLetter(r) {
+			continue
+		}
+		letters++
+		switch {
+		case unicode.Is(unicode.Hiragana, r) || unicode.Is(unicode.Katakana, r):
+			kana++
+		case unicode.Is(unicode.Han, r):
+			han++
+		case unicode.Is(unicode.Hangul, r):
+			hangul++
+		case isThaiLetter(r):
+			thai++
+		case unicode.Is(unicode.Cyrillic, r):
+			cyrillic++
+		}
+	}
+	if letters == 0 {
+		return ""
+	}
+	share := func(n int) float64 { return float64(n) / float64(letters) }
+	switch {
+	case share(kana) > 0.1:
+		return "ja"
+	case share(han) > 0.3:
+		return "zh"
+	case share(hangul) > 0.3:
+		return "ko"
+	case share(thai) > 0.3:
+		return "th"
+	case share(cyrillic) > 0.3:
+		return "ru"
+	}
+	counts := map[string]int{}
+	for _, word := range strings.FieldsFunc(strings.ToLower(text), func(r rune) bool { return !unicode.IsLetter(r) }) {
+		for lang, stop := range latinStopwords {
+			if stop[word] {
+				counts[lang]++
+			}
+		}
+	}
+	best, total := "", 0
+	for lang, n := range counts {
+		total += n
+		if n > counts[best] || n == counts[best] && lang < best {
+			best = lang
+		}
+	}
+	// Require a clear majority of a meaningful sample.
+	if total < 5 || float64(counts[best]) < 0.7*float64(total) {
+		return ""
+	}
+	return best
+}
+
+// prosePlan is suggested for documents no preset fits.
+var prosePlan = ChunkingPlan{Mode: ModeTokens, WindowSize: 300, Overlap: 50, ChunkTitles: true}
+
+// SuggestPlan recommends a plan for text from its structure, for simple
+// cases that need no model to choose one. The plan starts from a preset
+// in Presets: code-default for source code, transcript for speaker turns,
+// markdown-default for documents with headings and scanned-pdf for
+// extracted PDF text. Other prose gets windows of tokens titled by their
+// first sentence. The plan's Locale is set to the guessed language, and
+// lines-mode windows shrink for long lines.
+func SuggestPlan(text string) Suggestion {
+	s := AnalyzeStructure(text)
+	sug := Suggestion{Structure: s}
+	switch {
+	case s.Lines == 0:
+		sug.Reasons = append(sug.Reasons, "empty document")
+	case s.CodeRatio >= suggestCodeRatio:
+		sug.Preset, sug.Confidence = "code-default", s.CodeRatio
+		sug.Reasons = append(sug.Reasons, fmt.Sprintf("%.0f%% of lines are code", 100*s.CodeRatio))
+	case s.TurnRatio >= suggestTurnRatio:
+		sug.Preset, sug.Confidence = "transcript", s.TurnRatio
+		sug.Reasons = append(sug.Reasons, fmt.Sprintf("%.0f%% of lines are speaker turns", 100*s.TurnRatio))
+	case s.Headings >= 2 && s.HeadingDensity >= suggestHeadingDensity:
+		sug.Preset, sug.Confidence = "markdown-default", math.Min(0.9, 0.5+s.HeadingDensity/20)
+		sug.Reasons = append(sug.Reasons, fmt.Sprintf("%d headings, %.1f per 100 lines", s.Headings, s.HeadingDensity))
+	case s.PageBreaks > 0:
+		sug.Preset, sug.Confidence = "scanned-pdf", 0.7
+		sug.Reasons = append(sug.Reasons, fmt.Sprintf("%d page breaks (form feeds)", s.PageBreaks))
+	default:
+		sug.Confidence = 0.4
+		sug.Reasons = append(sug.Reasons, "prose without headings")
+	}
+	plan, ok := Presets.Lookup(sug.Preset)
+	if !ok {
+		// Prose, or a preset that was removed from the registry.
+		sug.Preset, plan = "", copyPlan(prosePlan)
+	}
+
+	if plan.Mode == ModeLines && s.AvgLineLength > 0 {
+		if lines := int(math.Round(suggestWindowChars / s.AvgLineLength)); lines < plan.WindowSize {
+			lines = max(lines, 5)
+			plan.Overlap = min(plan.Overlap, lines/4)
+			plan.WindowSize = lines
+			sug.Reasons = append(sug.Reasons, fmt.Sprintf("average line of %.0f characters: %d lines per window", s.AvgLineLength, lines))
+		}
+	}
+	if s.Language != "" {
+		plan.Locale = s.Language
+		sug.Reasons = append(sug.Reasons, "language looks like "+s.Language)
+		if _, err := resolveLanguagePacks([]string{s.Language}); err == nil && plan.BreakOnHeadings {
+			plan.HeadingLanguages = []string{s.Language}
+		}
+	}
+	sug.Plan = plan
+	sug.Confidence = round2(sug.Confidence)
+	return sug
+}
@@ -0,0 +1,70 @@
+package chunking
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestAnalyzeStructure(t *testing.T) {
+	text := "# Title\n\nSome text here.\n\n```go\nx := 1\n```\n\n## Next\nAlice: hello there\n"
+	got := AnalyzeStructure(text)
+	want := Structure{Lines: 7, Headings: 2, HeadingDensity: 28.57, AvgLineLength: 8.71, CodeRatio: 0.43, TurnRatio: 0.14}
+	if got != want {
+		t.Fatalf("expected %+v, got %+v", want, got)
+	}
+
+	languages := map[string]string{
+		"The report is ready and the data is in the archive with the rest of it.":        "en",
+		"Der Bericht ist nicht fertig und die Daten sind mit dem Archiv auf dem Server.": "de",
+		"システムはすべての記録を保存します。":                                                             "ja",
+		"系统保存每条记录。":                                                                      "zh",
+		"Система хранит каждую запись.":                                                  "ru",
+		"Ok.": "",
+	}
+	for text, want := range languages {
+		if got := guessLanguage(text); got != want {
+			t.Errorf("guessLanguage(%q) = %q, want %q", text, got, want)
+		}
+	}
+}
+
+func TestSuggestPlan(t *testing.T) {
+	long := strings.Repeat("word ", 40)
+	cases := []struct {
+		name   string
+		text   string
+		preset string
+		check  func(ChunkingPlan) bool
+	}{
+		{"code", "package main\n\nimport \"fmt\"\n\nfunc main() {\n\tfmt.Println(1)\n}\n", "code-default", nil},
+		{"transcript", "[10:01] Alice: hi\n[10:02] Bob: hello\nAlice: how are you?\n", "transcript", nil},
+		{"markdown", "# One\n\nThe text is here and it is long enough.\n\n## Two\n\nMore of the text and the rest.\n", "markdown-default",
+			func(p ChunkingPlan) bool { return p.BreakOnHeadings && p.Locale == "en" }},
+		{"long lines", "# One\n" + strings.Repeat(long+"\n", 10) + "# Two\n" + long + "\n", "markdown-default",
+			func(p ChunkingPlan) bool { return p.WindowSize == 14 && p.Overlap == 3 }},
+		{"native headings", "第1章 总则\n\n本法保护数据。\n\n第2章 范围\n\n适用于系统。\n", "markdown-default",
+			func(p ChunkingPlan) bool { return reflect.DeepEqual(p.HeadingLanguages, []string{"zh"}) }},
+		{"scan", "page one of the scan\fpage two", "scanned-pdf",
+			func(p ChunkingPlan) bool { return p.Concatenated == ConcatIgnore }},
+		{"prose", "Just a paragraph of plain prose without any structure.", "",
+			func(p ChunkingPlan) bool { return p.Mode == ModeTokens && p.ChunkTitles }},
+	}
+	for _, tc := range cases {
+		sug := SuggestPlan(tc.text)
+		if sug.Preset != tc.preset || len(sug.Reasons) == 0 || sug.Confidence <= 0 || sug.Confidence > 1 {
+			t.Errorf("%s: expected preset %q, got %+v", tc.name, tc.preset, sug)
+			continue
+		}
+		if tc.check != nil && !tc.check(sug.Plan) {
+			t.Errorf("%s: unexpected plan %+v", tc.name, sug.Plan)
+		}
+		if _, err := NewSlidingWindowChunker().Chunk(tc.text, sug.Plan, nil); err != nil {
+			t.Errorf("%s: suggested plan does not chunk: %v", tc.name, err)
+		}
+	}
+
+	if sug := SuggestPlan(""); sug.Confidence != 0 || sug.Plan.WindowSize == 0 {
+		t.Errorf("expected a usable plan with no confidence for empty text, got %+v", sug)
+	}
+}
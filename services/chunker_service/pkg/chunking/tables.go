@@ -0,0 +1,263 @@
+package chunking
+
+import (
+	"encoding/csv"
+	"fmt"
+	"html"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode/utf8"
+)
+
+// Table formats recognized by findTables.
+const (
+	TableMarkdown = "markdown"
+	TableHTML     = "html"
+	TableCSV      = "csv"
+)
+
+// Table is the structured form of the rows of a table that a chunk
+// contains. Rows map column names to cell text; FirstRow is the index of
+// Rows[0] among the table's TotalRows data rows, so a table split across
+// chunks can be reassembled.
+type Table struct {
+	Format    string              `json:"format"`
+	Columns   []string            `json:"columns"`
+	Rows      []map[string]string `json:"rows"`
+	FirstRow  int                 `json:"first_row"`
+	TotalRows int                 `json:"total_rows"`
+}
+
+// tableRef is a table found in the source text. starts[0] is the byte
+// offset where the table begins and starts[r+1] where data row r begins.
+type tableRef struct {
+	format  string
+	columns []string
+	rows    [][]string
+	starts  []int
+}
+
+var (
+	markdownDelimiterPattern = regexp.MustCompile(`^\s*\|?\s*:?-+:?\s*(?:\|\s*:?-+:?\s*)*\|?\s*$`)
+	htmlTablePattern         = regexp.MustCompile(`(?is)<table\b.*?</table>`)
+	htmlRowPattern           = regexp.MustCompile(`(?is)<tr\b[^>]*>(.*?)</tr>`)
+	htmlCellPattern          = regexp.MustCompile(`(?is)<(th|td)\b[^>]*>(.*?)</t[hd]>`)
+)
+
+// minCSVLines is the shortest run of lines, header included, accepted as
+// a CSV table; maxCSVField rejects prose that happens to contain commas.
+const (
+	minCSVLines = 3
+	maxCSVField = 100
+)
+
+// findTables returns the Markdown pipe tables, HTML tables and CSV blocks
+// in text, in order.
+func findTables(text string) []tableRef {
+	var tables []tableRef
+	htmlSpans := htmlTablePattern.FindAllStringIndex(text, -1)
+	for _, m := range htmlSpans {
+		if t, ok := parseHTMLTable(text[m[0]:m[1]], m[0]); ok {
+			tables = append(tables, t)
+		}
+	}
+	insideHTML := func(offset int) bool {
+		for _, m := range htmlSpans {
+			if offset >= m[0] && offset < m[1] {
+				return true
+			}
+		}
+		return false
+	}
+
+	var offsets []int
+	lines := strings.SplitAfter(text, "\n")
+	offset := 0
+	for _, line := range lines {
+		offsets = append(offsets, offset)
+		offset += len(line)
+	}
+	for i := 0; i < len(lines); i++ {
+		if insideHTML(offsets[i]) {
+			continue
+		}
+		if t, end, ok := parseMarkdownTable(lines, offsets, i); ok {
+			tables = append(tables, t)
+			i = end - 1
+			continue
+		}
+		if t, end, ok := parseCSVTable(lines, offsets, i); ok {
+			tables = append(tables, t)
+			i = end - 1
+		}
+	}
+	sort.Slice(tables, func(i, j int) bool { return tables[i].starts[0] < tables[j].starts[0] })
+	return tables
+}
+
+// parseMarkdownTable parses a pipe table whose header is lines[i] and
+// returns the index just past its last row.
+func parseMarkdownTable(lines []string, offsets []int, i int) (tableRef, int, bool) {
+	if i+1 >= len(lines) || !strings.Contains(lines[i], "|") || !strings.Contains(lines[i+1], "|") ||
+		!markdownDelimiterPattern.MatchString(lines[i+1]) {
+		return tableRef{}, 0, false
+	}
+	t := tableRef{format: TableMarkdown, columns: pipeCells(lines[i]), starts: []int{offsets[i]}}
+	end := i + 2
+	for ; end < len(lines) && strings.Contains(lines[end], "|") && strings.TrimSpace(lines[end]) != ""; end++ {
+		t.rows = append(t.rows, pipeCells(lines[end]))
+		t.starts = append(t.starts, offsets[end])
+	}
+	return t, end, true
+}
+
+// pipeCells splits a pipe table row, honouring escaped pipes.
+func pipeCells(line string) []string {
+	line = strings.TrimSpace(line)
+	line = strings.TrimPrefix(line, "|")
+	if strings.HasSuffix(line, "|") && !strings.HasSuffix(line, `\|`) {
+		line = line[:len(line)-1]
+	}
+	var cells []string
+	var b strings.Builder
+	for i := 0; i < len(line); i++ {
+		switch {
+		case line[i] == '\\' && i+1 < len(line) && line[i+1] == '|':
+			b.WriteByte('|')
+			i++
+		case line[i] == '|':
+			cells = append(cells, strings.TrimSpace(b.String()))
+			b.Reset()
+		default:
+			b.WriteByte(line[i])
+		}
+	}
+	return append(cells, strings.TrimSpace(b.String()))
+}
+
+// parseCSVTable parses a block of at least minCSVLines comma-separated
+// lines with the same number of short fields, starting at lines[i].
+func parseCSVTable(lines []string, offsets []int, i int) (tableRef, int, bool) {
+	header, ok := csvFields(lines[i])
+	if !ok {
+		return tableRef{}, 0, false
+	}
+	t := tableRef{format: TableCSV, columns: header, starts: []int{offsets[i]}}
+	end := i + 1
+	for ; end < len(lines); end++ {
+		fields, ok := csvFields(lines[end])
+		if !ok || len(fields) != len(header) {
+			break
+		}
+		t.rows = append(t.rows, fields)
+		t.starts = append(t.starts, offsets[end])
+	}
+	return t, end, end-i >= minCSVLines
+}
+
+func csvFields(line string) ([]string, bool) {
+	line = strings.TrimRight(line, "\r\n")
+	if !strings.Contains(line, ",") {
+		return nil, false
+	}
+	r := csv.NewReader(strings.NewReader(line))
+	fields, err := r.Read()
+	if err != nil || len(fields) < 2 {
+		return nil, false
+	}
+	for i, f := range fields {
+		fields[i] = strings.TrimSpace(f)
+		if utf8.RuneCountInString(f) > maxCSVField {
+			return nil, false
+		}
+	}
+	return fields, true
+}
+
+// parseHTMLTable parses a <table> element found at offset. A first row of
+// <th> cells names the columns; otherwise they are named by position.
+func parseHTMLTable(s string, offset int) (tableRef, bool) {
+	t := tableRef{format: TableHTML}
+	for _, m := range htmlRowPattern.FindAllStringSubmatchIndex(s, -1) {
+		var cells []string
+		header := true
+		for _, c := range htmlCellPattern.FindAllStringSubmatch(s[m[2]:m[3]], -1) {
+			header = header && strings.EqualFold(c[1], "th")
+			cells = append(cells, strings.Join(strings.Fields(html.UnescapeString(tagPattern.ReplaceAllString(c[2], " "))), " "))
+		}
+		if len(cells) == 0 {
+			continue
+		}
+		if t.columns == nil && header && len(t.rows) == 0 {
+			t.columns = cells
+			t.starts = append(t.starts, offset+m[0])
+			continue
+		}
+		if t.starts == nil {
+			t.starts = append(t.starts, offset+m[0])
+		}
+		t.rows = append(t.rows, cells)
+		t.starts = append(t.starts, offset+m[0])
+	}
+	return t, len(t.rows) > 0
+}
+
+// columnNames makes the table's column names unique and non-empty,
+// naming unnamed columns "column_<n>" and extending them to width.
+func columnNames(columns []string, width int) []string {
+	if width < len(columns) {
+		width = len(columns)
+	}
+	names := make([]string, width)
+	seen := map[string]bool{}
+	for i := range names {
+		name := ""
+		if i < len(columns) {
+			name = columns[i]
+		}
+		if name == "" || seen[name] {
+			name = fmt.Sprintf("column_%d", i+1)
+		}
+		seen[name] = true
+		names[i] = name
+	}
+	return names
+}
+
+// attachTables records in Extra["table"] the structured rows of every
+// table a chunk holds rows of, one entry per table. A row belongs to the
+// chunks its first byte falls in.
+func attachTables(text string, chunks []Chunk) {
+	tables := findTables(text)
+	for _, t := range tables {
+		width := len(t.columns)
+		for _, row := range t.rows {
+			if len(row) > width {
+				width = len(row)
+			}
+		}
+		columns := columnNames(t.columns, width)
+		rowStarts := t.starts[1:]
+		for i := range chunks {
+			var table *Table
+			for r, start := range rowStarts {
+				if start < chunks[i].ByteStart || start >= chunks[i].ByteEnd {
+					continue
+				}
+				if table == nil {
+					table = &Table{Format: t.format, Columns: columns, FirstRow: r, TotalRows: len(t.rows)}
+				}
+				row := map[string]string{}
+				for c, cell := range t.rows[r] {
+					row[columns[c]] = cell
+				}
+				table.Rows = append(table.Rows, row)
+			}
+			if table != nil {
+				existing, _ := chunks[i].Extra["table"].([]Table)
+				chunks[i].Extra["table"] = append(existing, *table)
+			}
+		}
+	}
+}
@@ -0,0 +1,66 @@
+package chunking
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestFindTables(t *testing.T) {
+	text := "Prices:\n" +
+		"| Plan | Price \\| USD |\n|---|--:|\n| Basic | 10 |\n| Pro | 25 |\n\n" +
+		"<table><tr><th>City</th><th>Pop.</th></tr><tr><td>Oslo</td><td>0.7&nbsp;M</td></tr></table>\n" +
+		"name,qty,unit\nbolt,40,pcs\n\"nut, small\",15,pcs\n" +
+		"Hello, world.\nOne, two, three, and more, words here.\n"
+	tables := findTables(text)
+	want := []struct {
+		format  string
+		columns []string
+		rows    [][]string
+	}{
+		{TableMarkdown, []string{"Plan", "Price | USD"}, [][]string{{"Basic", "10"}, {"Pro", "25"}}},
+		{TableHTML, []string{"City", "Pop."}, [][]string{{"Oslo", "0.7 M"}}},
+		{TableCSV, []string{"name", "qty", "unit"}, [][]string{{"bolt", "40", "pcs"}, {"nut, small", "15", "pcs"}}},
+	}
+	if len(tables) != len(want) {
+		t.Fatalf("expected %d tables, got %d: %+v", len(want), len(tables), tables)
+	}
+	for i, w := range want {
+		got := tables[i]
+		if got.format != w.format || !reflect.DeepEqual(got.columns, w.columns) || !reflect.DeepEqual(got.rows, w.rows) {
+			t.Errorf("table %d: expected %s %q %q, got %s %q %q", i, w.format, w.columns, w.rows, got.format, got.columns, got.rows)
+		}
+		if len(got.starts) != len(got.rows)+1 {
+			t.Errorf("table %d: expected a start per row plus the table's, got %v", i, got.starts)
+		}
+	}
+}
+
+func TestExtractTablesSplitsRowsAcrossChunks(t *testing.T) {
+	text := "| id | score |\n|----|-------|\n| a | 1 |\n| b | 2 |\n| c | 3 |\nAfter the table."
+	chunks, err := NewSlidingWindowChunker().Chunk(text, ChunkingPlan{WindowSize: 4, Mode: ModeLines, ExtractTables: true}, nil)
+	if err != nil {
+		t.Fatalf("chunking failed: %v", err)
+	}
+	if len(chunks) != 2 {
+		t.Fatalf("expected 2 chunks, got %d", len(chunks))
+	}
+	first, _ := chunks[0].Extra["table"].([]Table)
+	second, _ := chunks[1].Extra["table"].([]Table)
+	if len(first) != 1 || len(first[0].Rows) != 2 || first[0].FirstRow != 0 || first[0].TotalRows != 3 {
+		t.Fatalf("unexpected first chunk table %+v", first)
+	}
+	if len(second) != 1 || second[0].FirstRow != 2 || second[0].Rows[0]["score"] != "3" ||
+		!reflect.DeepEqual(second[0].Columns, []string{"id", "score"}) {
+		t.Errorf("the continuation should keep the header's columns, got %+v", second)
+	}
+	if chunks[0].Text[:4] != "| id" {
+		t.Errorf("the flattened text should be kept, got %q", chunks[0].Text)
+	}
+}
+
+func TestColumnNames(t *testing.T) {
+	got := columnNames([]string{"a", "", "a"}, 4)
+	if want := []string{"a", "column_2", "column_3", "column_4"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("expected %v, got %v", want, got)
+	}
+}
@@ -0,0 +1,104 @@
+package chunking
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// maxTitleRunes caps generated chunk titles; longer headings and
+// sentences are cut at a word boundary and end in an ellipsis.
+const maxTitleRunes = 80
+
+// headingAt is a heading line and the byte offset where it starts.
+type headingAt struct {
+	offset int
+	text   string
+}
+
+// documentHeadings lists the headings of text in order.
+func documentHeadings(text string, packs []LanguagePack) []headingAt {
+	var out []headingAt
+	offset := 0
+	for _, line := range strings.SplitAfter(text, "\n") {
+		if d, ok := classifyHeading(line, packs); ok && d.Text != "" {
+			out = append(out, headingAt{offset: offset, text: d.Text})
+		}
+		offset += len(line)
+	}
+	return out
+}
+
+// attachTitles sets ChunkTitle on every chunk to the heading in effect
+// where the chunk starts, numbered "(2/3)" when consecutive chunks share
+// it, or else to the chunk's first sentence.
+func attachTitles(text string, chunks []Chunk, packs []LanguagePack, seg segmenter) {
+	headings := documentHeadings(text, packs)
+	current := make([]string, len(chunks))
+	for i := range chunks {
+		if h, ok := chunks[i].Extra["heading"].(string); ok && h != "" {
+			current[i] = h
+			continue
+		}
+		// A chunk that opens with a heading line belongs to that heading.
+		lead := len(chunks[i].Text) - len(strings.TrimLeftFunc(chunks[i].Text, unicode.IsSpace))
+		start := chunks[i].ByteStart + lead
+		n := sort.Search(len(headings), func(j int) bool { return headings[j].offset > start })
+		if n > 0 {
+			current[i] = headings[n-1].text
+		}
+	}
+	for i := 0; i < len(chunks); {
+		j := i + 1
+		for j < len(chunks) && current[j] == current[i] {
+			j++
+		}
+		for k := i; k < j; k++ {
+			switch {
+			case current[k] == "":
+				chunks[k].ChunkTitle = shorten(firstSentence(chunks[k].Text, seg), maxTitleRunes, seg)
+			case j-i == 1:
+				chunks[k].ChunkTitle = shorten(current[k], maxTitleRunes, seg)
+			default:
+				suffix := fmt.Sprintf(" (%d/%d)", k-i+1, j-i)
+				chunks[k].ChunkTitle = shorten(current[k], maxTitleRunes-len(suffix), seg) + suffix
+			}
+		}
+		i = j
+	}
+}
+
+func firstSentence(text string, seg segmenter) string {
+	spans := seg.sentences(text)
+	if len(spans) == 0 {
+		return ""
+	}
+	return text[spans[0].start:spans[0].end]
+}
+
+// shorten collapses whitespace and cuts s to at most max runes, at the
+// last word boundary when there is one. Text of languages written
+// without spaces is cut at the last word seg finds.
+func shorten(s string, max int, seg segmenter) string {
+	s = strings.Join(strings.Fields(s), " ")
+	runes := []rune(s)
+	if len(runes) <= max {
+		return s
+	}
+	cut := string(runes[:max-1])
+	if seg.splitsWords() {
+		end := 0
+		for _, w := range seg.wordSpans(s) {
+			if w.end <= len(cut) {
+				end = w.end
+			}
+		}
+		if end > 0 {
+			cut = cut[:end]
+		}
+	} else if i := strings.LastIndexByte(cut, ' '); i > 0 {
+		cut = cut[:i]
+	}
+	return strings.TrimRightFunc(cut, func(r rune) bool { return unicode.IsSpace(r) || unicode.IsPunct(r) }) + "…"
+}
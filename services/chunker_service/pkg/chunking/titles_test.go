@@ -0,0 +1,52 @@
+package chunking
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestChunkTitles(t *testing.T) {
+	text := "Welcome to the guide. It covers setup.\n" +
+		"## Install\nDownload the archive.\nUnpack it.\nRun the installer.\nReboot.\n" +
+		"## Usage\nStart the service."
+	chunks, err := NewSlidingWindowChunker().Chunk(text,
+		ChunkingPlan{WindowSize: 2, Mode: ModeLines, ChunkTitles: true},
+		map[string]interface{}{"doc_id": "guide", "title": "User Guide"})
+	if err != nil {
+		t.Fatalf("chunking failed: %v", err)
+	}
+	// The first chunk ends with the Install heading but starts before it.
+	want := []string{
+		"Welcome to the guide.",
+		"Install (1/2)",
+		"Install (2/2)",
+		"Usage",
+	}
+	if len(chunks) != len(want) {
+		t.Fatalf("expected %d chunks, got %d: %+v", len(want), len(chunks), chunks)
+	}
+	for i, w := range want {
+		if chunks[i].ChunkTitle != w {
+			t.Errorf("chunk %d %q: expected title %q, got %q", i, chunks[i].Text, w, chunks[i].ChunkTitle)
+		}
+		if chunks[i].Title != "User Guide" {
+			t.Errorf("chunk %d: document title should be kept, got %q", i, chunks[i].Title)
+		}
+	}
+
+	plain, _ := NewSlidingWindowChunker().Chunk(text, ChunkingPlan{WindowSize: 2, Mode: ModeLines}, nil)
+	if plain[0].ChunkTitle != "" {
+		t.Errorf("titles should be opt-in, got %q", plain[0].ChunkTitle)
+	}
+}
+
+func TestShortenCutsAtWordBoundary(t *testing.T) {
+	long := strings.Repeat("configuration ", 10)
+	got := shorten(long, 40, segmenter{})
+	if got != "configuration configuration…" {
+		t.Errorf("unexpected title %q", got)
+	}
+	if got := shorten("  Short\n title ", 40, segmenter{}); got != "Short title" {
+		t.Errorf("expected collapsed whitespace, got %q", got)
+	}
+}
@@ -0,0 +1,24 @@
+package chunking
+
+import "fmt"
+
+// Trace records the decisions made while chunking a single request so
+// users can see why a document was chunked the way it was. A nil *Trace
+// records nothing.
+type Trace struct {
+	Events []TraceEvent `json:"events"`
+}
+
+// TraceEvent is a single step in a Trace.
+type TraceEvent struct {
+	Step   string                 `json:"step"`
+	Detail string                 `json:"detail"`
+	Data   map[string]interface{} `json:"data,omitempty"`
+}
+
+func (t *Trace) add(step string, data map[string]interface{}, format string, args ...interface{}) {
+	if t == nil {
+		return
+	}
+	t.Events = append(t.Events, TraceEvent{Step: step, Detail: fmt.Sprintf(format, args...), Data: data})
+}
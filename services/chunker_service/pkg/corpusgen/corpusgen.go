@@ -0,0 +1,355 @@
+// Package corpusgen generates synthetic documents of known structure,
+// together with the segmentation a correct chunker should find in them,
+// so tests, fuzzing seeds and benchmarks can cover document shapes no
+// real sample exists for. A Spec declares the shape; the same Spec and
+// seed always generate the same corpus.
+package corpusgen
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/rand"
+	"strconv"
+	"strings"
+
+	"chunker-service/pkg/yamljson"
+)
+
+// Heading styles of Spec.HeadingStyle.
+const (
+	// StyleMarkdown writes "#" headings, one "#" per level.
+	StyleMarkdown = "markdown"
+	// StyleNative writes headings the way the document's language
+	// numbers them, such as "第2章" in Chinese and Japanese or "Глава 2"
+	// in Russian, and falls back to Markdown for languages and levels
+	// without such a convention.
+	StyleNative = "native"
+)
+
+// Spec declares the shape of a generated corpus. Zero values select the
+// defaults noted on each field.
+type Spec struct {
+	// Seed makes the corpus reproducible; document i uses Seed+i.
+	Seed      int64 `json:"seed,omitempty"`
+	Documents int   `json:"documents,omitempty"` // default 1
+	// Depth is the number of heading levels, 0 to 6; 0 generates plain
+	// paragraphs without headings. The default is 2.
+	Depth *int `json:"depth,omitempty"`
+	// Sections is the number of sections per level, under each heading
+	// of the level above (default 2).
+	Sections int `json:"sections,omitempty"`
+	// Paragraphs is the number of paragraphs per section (default 2).
+	Paragraphs int `json:"paragraphs,omitempty"`
+	// Sentences is the number of sentences per paragraph (default 3).
+	Sentences int `json:"sentences,omitempty"`
+	// MinWords and MaxWords bound the words per sentence (default 5-15).
+	MinWords int `json:"min_words,omitempty"`
+	MaxWords int `json:"max_words,omitempty"`
+	// Languages are assigned to documents in turn (default en); see
+	// Languages for the supported ones.
+	Languages    []string `json:"languages,omitempty"`
+	HeadingStyle string   `json:"heading_style,omitempty"` // default markdown
+	Noise        Noise    `json:"noise,omitempty"`
+}
+
+// Noise adds the imperfections of converted documents. Each field is the
+// probability, from 0 to 1, of one kind of noise at each opportunity.
+// Noise never changes the expected segmentation other than by moving
+// offsets.
+type Noise struct {
+	// PageMarkers inserts a "Page N" line between paragraphs.
+	PageMarkers float64 `json:"page_markers,omitempty"`
+	// Whitespace doubles the space between two words, or adds trailing
+	// spaces to a line.
+	Whitespace float64 `json:"whitespace,omitempty"`
+	// Hyphenation breaks a word across two lines with a hyphen, as text
+	// extracted from justified layouts does. Languages written without
+	// spaces are not hyphenated.
+	Hyphenation float64 `json:"hyphenation,omitempty"`
+}
+
+// Span is a byte range of a document's text.
+type Span struct {
+	Start int `json:"start"`
+	End   int `json:"end"`
+}
+
+// Heading is an expected heading. Line counts from 0; Text is the title
+// without heading markup such as "#".
+type Heading struct {
+	Line  int    `json:"line"`
+	Level int    `json:"level"`
+	Text  string `json:"text"`
+	Span
+}
+
+// Section is the expected extent of a heading's section: from the
+// heading to the next heading of the same or a higher level.
+type Section struct {
+	Heading int `json:"heading"` // index into Document.Headings
+	Level   int `json:"level"`
+	Span
+}
+
+// Document is a generated document and its expected segmentation.
+// Paragraph and sentence spans exclude noise lines and surrounding
+// whitespace.
+type Document struct {
+	Name       string    `json:"name"`
+	Language   string    `json:"language"`
+	Text       string    `json:"text,omitempty"`
+	Headings   []Heading `json:"headings,omitempty"`
+	Sections   []Section `json:"sections,omitempty"`
+	Paragraphs []Span    `json:"paragraphs"`
+	Sentences  []Span    `json:"sentences"`
+}
+
+// LoadSpec reads a Spec from a JSON or YAML file.
+func LoadSpec(path string) (Spec, error) {
+	data, err := yamljson.ReadFile(path)
+	if err != nil {
+		return Spec{}, err
+	}
+	var s Spec
+	if err := json.Unmarshal(data, &s); err != nil {
+		return Spec{}, fmt.Errorf("invalid corpus spec: %w", err)
+	}
+	return s, s.Validate()
+}
+
+// Validate checks the spec's ranges and languages.
+func (s Spec) Validate() error {
+	if s.Documents < 0 || s.Sections < 0 || s.Paragraphs < 0 || s.Sentences < 0 || s.MinWords < 0 || s.MaxWords < 0 {
+		return errors.New("corpus spec counts must be >= 0")
+	}
+	if s.Depth != nil && (*s.Depth < 0 || *s.Depth > 6) {
+		return fmt.Errorf("depth must be between 0 and 6, got %d", *s.Depth)
+	}
+	if s.MinWords > 0 && s.MaxWords > 0 && s.MinWords > s.MaxWords {
+		return errors.New("min_words must not exceed max_words")
+	}
+	for _, lang := range s.Languages {
+		if _, ok := vocabularies[lang]; !ok {
+			return fmt.Errorf("unsupported corpus language %q; use one of %s", lang, strings.Join(Languages(), ", "))
+		}
+	}
+	switch s.HeadingStyle {
+	case "", StyleMarkdown, StyleNative:
+	default:
+		return fmt.Errorf("unknown heading style %q", s.HeadingStyle)
+	}
+	for _, p := range []float64{s.Noise.PageMarkers, s.Noise.Whitespace, s.Noise.Hyphenation} {
+		if p < 0 || p > 1 {
+			return errors.New("noise probabilities must be between 0 and 1")
+		}
+	}
+	return nil
+}
+
+// withDefaults fills in the defaults of unset fields.
+func (s Spec) withDefaults() Spec {
+	if s.Documents == 0 {
+		s.Documents = 1
+	}
+	if s.Depth == nil {
+		depth := 2
+		s.Depth = &depth
+	}
+	if s.Sections == 0 {
+		s.Sections = 2
+	}
+	if s.Paragraphs == 0 {
+		s.Paragraphs = 2
+	}
+	if s.Sentences == 0 {
+		s.Sentences = 3
+	}
+	if s.MinWords == 0 {
+		s.MinWords = min(5, max(s.MaxWords, 1))
+	}
+	if s.MaxWords == 0 {
+		s.MaxWords = max(15, s.MinWords)
+	}
+	if len(s.Languages) == 0 {
+		s.Languages = []string{"en"}
+	}
+	if s.HeadingStyle == "" {
+		s.HeadingStyle = StyleMarkdown
+	}
+	return s
+}
+
+// Generate returns the documents spec declares.
+func Generate(spec Spec) ([]Document, error) {
+	if err := spec.Validate(); err != nil {
+		return nil, err
+	}
+	spec = spec.withDefaults()
+	docs := make([]Document, spec.Documents)
+	for i := range docs {
+		lang := spec.Languages[i%len(spec.Languages)]
+		g := &generator{
+			spec:  spec,
+			vocab: vocabularies[lang],
+			rand:  rand.New(rand.NewSource(spec.Seed + int64(i))),
+			doc:   Document{Name: fmt.Sprintf("doc-%03d", i), Language: lang},
+		}
+		g.generate()
+		docs[i] = g.doc
+	}
+	return docs, nil
+}
+
+// generator builds one document, block by block. Blocks are separated by
+// a blank line.
+type generator struct {
+	spec  Spec
+	vocab vocabulary
+	rand  *rand.Rand
+	doc   Document
+	text  strings.Builder
+	lines int
+	pages int
+}
+
+func (g *generator) generate() {
+	if *g.spec.Depth == 0 {
+		g.paragraphs()
+	} else {
+		g.sections(1, nil)
+	}
+	g.doc.Text = g.text.String()
+	// Close each section at the next heading of its level or above.
+	for i, h := range g.doc.Headings {
+		end := len(g.doc.Text)
+		for _, next := range g.doc.Headings[i+1:] {
+			if next.Level <= h.Level {
+				end = next.Start
+				break
+			}
+		}
+		g.doc.Sections = append(g.doc.Sections, Section{Heading: i, Level: h.Level, Span: Span{h.Start, end}})
+	}
+}
+
+// sections writes the sections of a level, numbered below number.
+func (g *generator) sections(level int, number []int) {
+	for i := 1; i <= g.spec.Sections; i++ {
+		n := append(append([]int(nil), number...), i)
+		g.heading(level, n)
+		g.paragraphs()
+		if level < *g.spec.Depth {
+			g.sections(level+1, n)
+		}
+	}
+}
+
+func (g *generator) heading(level int, number []int) {
+	title := g.title()
+	line := strings.Repeat("#", level) + " " + title
+	if g.spec.HeadingStyle == StyleNative && level <= len(g.vocab.native) {
+		title = fmt.Sprintf(g.vocab.native[level-1], number[len(number)-1]) + g.vocab.space + title
+		line = title
+	}
+	start := g.block(line)
+	g.doc.Headings = append(g.doc.Headings, Heading{
+		Line:  g.lines,
+		Level: level,
+		Text:  title,
+		Span:  Span{start, start + len(line)},
+	})
+	g.lines++
+}
+
+func (g *generator) paragraphs() {
+	for i := 0; i < g.spec.Paragraphs; i++ {
+		if i > 0 && g.chance(g.spec.Noise.PageMarkers) {
+			g.pages++
+			g.block("Page " + strconv.Itoa(g.pages))
+			g.lines++
+		}
+		g.paragraph()
+	}
+}
+
+// paragraph writes one paragraph on a single line, or several when
+// hyphenation breaks it.
+func (g *generator) paragraph() {
+	var b strings.Builder
+	var sentences []Span
+	for i := 0; i < g.spec.Sentences; i++ {
+		if i > 0 {
+			b.WriteString(g.vocab.space)
+		}
+		start := b.Len()
+		words := g.spec.MinWords + g.rand.Intn(g.spec.MaxWords-g.spec.MinWords+1)
+		for w := 0; w < words; w++ {
+			word := g.word()
+			if w == 0 {
+				word = capitalize(word)
+			}
+			if w > 0 {
+				b.WriteString(g.vocab.space)
+				if g.vocab.space != "" && g.chance(g.spec.Noise.Whitespace) {
+					b.WriteString(" ")
+				}
+			}
+			if r := []rune(word); g.vocab.space != "" && len(r) >= 6 && g.chance(g.spec.Noise.Hyphenation) {
+				cut := len(r) / 2
+				b.WriteString(string(r[:cut]) + "-\n" + string(r[cut:]))
+				g.lines++
+				continue
+			}
+			b.WriteString(word)
+		}
+		b.WriteString(g.vocab.stop)
+		sentences = append(sentences, Span{start, b.Len()})
+	}
+	text := b.String()
+	if g.vocab.space != "" && g.chance(g.spec.Noise.Whitespace) {
+		text += "  "
+	}
+	start := g.block(text)
+	for _, s := range sentences {
+		g.doc.Sentences = append(g.doc.Sentences, Span{start + s.Start, start + s.End})
+	}
+	g.doc.Paragraphs = append(g.doc.Paragraphs, Span{start, start + b.Len()})
+	g.lines++
+}
+
+// block appends a block, preceded by a blank line unless it is the
+// first, and returns its offset. Every block ends with a newline.
+func (g *generator) block(text string) int {
+	if g.text.Len() > 0 {
+		g.text.WriteString("\n")
+		g.lines++
+	}
+	start := g.text.Len()
+	g.text.WriteString(text)
+	g.text.WriteString("\n")
+	return start
+}
+
+func (g *generator) title() string {
+	words := make([]string, 2+g.rand.Intn(3))
+	for i := range words {
+		words[i] = g.word()
+	}
+	words[0] = capitalize(words[0])
+	return strings.Join(words, g.vocab.space)
+}
+
+func (g *generator) word() string {
+	return g.vocab.words[g.rand.Intn(len(g.vocab.words))]
+}
+
+func (g *generator) chance(p float64) bool {
+	return p > 0 && g.rand.Float64() < p
+}
+
+func capitalize(word string) string {
+	r := []rune(word)
+	r[0] = []rune(strings.ToUpper(string(r[0])))[0]
+	return string(r)
+}
@@ -0,0 +1,72 @@
+package corpusgen
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestGenerate(t *testing.T) {
+	spec := Spec{Seed: 7, Documents: 4, Languages: Languages(), HeadingStyle: StyleNative,
+		Noise: Noise{PageMarkers: 0.5, Whitespace: 0.3, Hyphenation: 0.2}}
+	docs, err := Generate(spec)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	again, _ := Generate(spec)
+	if !reflect.DeepEqual(docs, again) {
+		t.Fatal("the same spec should generate the same corpus")
+	}
+
+	for _, doc := range docs {
+		// Depth 2 with 2 sections per level: 2 + 4 headings, 6 sections
+		// of 2 paragraphs of 3 sentences.
+		if len(doc.Headings) != 6 || len(doc.Sections) != 6 || len(doc.Paragraphs) != 12 || len(doc.Sentences) != 36 {
+			t.Fatalf("%s: unexpected structure: %d headings, %d sections, %d paragraphs, %d sentences",
+				doc.Name, len(doc.Headings), len(doc.Sections), len(doc.Paragraphs), len(doc.Sentences))
+		}
+		lines := strings.Split(doc.Text, "\n")
+		for _, h := range doc.Headings {
+			if lines[h.Line] != doc.Text[h.Start:h.End] || !strings.HasSuffix(lines[h.Line], h.Text) {
+				t.Fatalf("%s: heading %+v does not match line %q", doc.Name, h, lines[h.Line])
+			}
+		}
+		if s := doc.Sections[0]; s.Start != 0 || s.End != doc.Sections[3].Start || doc.Sections[5].End != len(doc.Text) {
+			t.Fatalf("%s: unexpected section spans %+v", doc.Name, doc.Sections)
+		}
+		stop := vocabularies[doc.Language].stop
+		for _, s := range doc.Sentences {
+			if !strings.HasSuffix(doc.Text[s.Start:s.End], stop) {
+				t.Fatalf("%s: sentence %q does not end a sentence", doc.Name, doc.Text[s.Start:s.End])
+			}
+		}
+	}
+
+	flat, _ := Generate(Spec{Depth: new(int), Paragraphs: 3, MinWords: 2, MaxWords: 2})
+	if len(flat[0].Headings) != 0 || len(flat[0].Paragraphs) != 3 {
+		t.Fatalf("depth 0 should generate only paragraphs: %+v", flat[0])
+	}
+	if first := flat[0].Sentences[0]; len(strings.Fields(flat[0].Text[first.Start:first.End])) != 2 {
+		t.Fatalf("expected two-word sentences: %q", flat[0].Text)
+	}
+}
+
+func TestLoadSpec(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "spec.yaml")
+	os.WriteFile(path, []byte("seed: 3\ndepth: 0\nlanguages: [de, ja]\nnoise:\n  hyphenation: 0.5\n"), 0o644)
+	spec, err := LoadSpec(path)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if spec.Seed != 3 || *spec.Depth != 0 || !reflect.DeepEqual(spec.Languages, []string{"de", "ja"}) || spec.Noise.Hyphenation != 0.5 {
+		t.Fatalf("unexpected spec %+v", spec)
+	}
+
+	for _, bad := range []Spec{{Languages: []string{"xx"}}, {HeadingStyle: "rst"}, {MinWords: 9, MaxWords: 3}, {Noise: Noise{Whitespace: 2}}} {
+		if _, err := Generate(bad); err == nil {
+			t.Errorf("expected %+v to be rejected", bad)
+		}
+	}
+}
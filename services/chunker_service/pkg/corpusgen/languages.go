@@ -0,0 +1,78 @@
+package corpusgen
+
+import "sort"
+
+// vocabulary is the material documents of one language are made of.
+// Words avoid abbreviations, numerals and anything else the segmenters
+// could mistake for a sentence end or a heading.
+type vocabulary struct {
+	words []string
+	// space separates words and sentences; it is empty for languages
+	// written without spaces.
+	space string
+	// stop ends a sentence.
+	stop string
+	// native are the fmt formats of the language's numbered headings,
+	// by level, for StyleNative.
+	native []string
+}
+
+var vocabularies = map[string]vocabulary{
+	"en": {
+		words: []string{"the", "system", "stores", "every", "record", "before", "users", "request",
+			"updates", "network", "quickly", "returns", "results", "from", "storage", "which",
+			"contains", "several", "important", "documents", "about", "policy", "changes", "and",
+			"their", "review", "process", "within", "each", "department", "carefully"},
+		space: " ",
+		stop:  ".",
+	},
+	"de": {
+		words: []string{"der", "bericht", "beschreibt", "jede", "anfrage", "bevor", "nutzer", "daten",
+			"speichern", "das", "netzwerk", "liefert", "schnell", "ergebnisse", "aus", "einem",
+			"archiv", "mit", "wichtigen", "dokumenten", "über", "änderungen", "und", "deren",
+			"prüfung", "innerhalb", "jeder", "abteilung", "sorgfältig"},
+		space: " ",
+		stop:  ".",
+	},
+	"fr": {
+		words: []string{"le", "système", "conserve", "chaque", "dossier", "avant", "que", "les",
+			"utilisateurs", "demandent", "une", "mise", "réseau", "renvoie", "rapidement",
+			"résultats", "depuis", "archive", "contient", "plusieurs", "documents", "importants",
+			"sur", "politique", "changements", "leur", "examen", "dans", "service"},
+		space: " ",
+		stop:  ".",
+	},
+	"ru": {
+		words: []string{"система", "хранит", "каждую", "запись", "перед", "тем", "как", "пользователи",
+			"запрашивают", "обновления", "сеть", "быстро", "возвращает", "результаты", "из",
+			"архива", "который", "содержит", "несколько", "важных", "документов", "об",
+			"изменениях", "политики", "их", "проверке", "внутри", "отдела", "тщательно"},
+		space:  " ",
+		stop:   ".",
+		native: []string{"Глава %d", "Статья %d"},
+	},
+	"zh": {
+		words: []string{"系统", "保存", "每条", "记录", "用户", "请求", "更新", "网络", "快速", "返回",
+			"结果", "档案", "包含", "重要", "文件", "关于", "政策", "变化", "以及", "审查", "过程",
+			"部门", "仔细", "数据", "服务"},
+		stop:   "。",
+		native: []string{"第%d章", "第%d节", "第%d条"},
+	},
+	"ja": {
+		words: []string{"システム", "は", "すべて", "の", "記録", "を", "保存", "し", "利用者", "が",
+			"更新", "要求", "ネットワーク", "から", "結果", "返す", "文書", "について", "方針",
+			"変更", "と", "審査", "部署", "内", "慎重"},
+		stop:   "。",
+		native: []string{"第%d章", "第%d節", "第%d条"},
+	},
+}
+
+// Languages returns the languages Spec.Languages may name.
+func Languages() []string {
+	names := make([]string, 0, len(vocabularies))
+	for name := range vocabularies {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
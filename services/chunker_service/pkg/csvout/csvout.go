@@ -0,0 +1,96 @@
+// Package csvout renders chunks as CSV with configurable columns, so
+// chunking results can be inspected in a spreadsheet.
+package csvout
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"io"
+	"strconv"
+	"strings"
+
+	"chunker-service/pkg/chunking"
+)
+
+// DefaultColumns identify each chunk, locate it in its file and show its
+// text.
+var DefaultColumns = []string{
+	"id", "file_path", "chunk_index",
+	"start_index", "end_index", "byte_start", "byte_end", "text",
+}
+
+// Offsets is the column shorthand "offsets" expands to.
+var Offsets = []string{"start_index", "end_index", "byte_start", "byte_end"}
+
+// ParseColumns splits a comma-separated column list. Columns are chunk
+// fields named as in the chunk's JSON ("id", "text", "file_name", ...);
+// "extra.<key>" selects a single Extra entry and "offsets" expands to
+// Offsets. An empty list yields DefaultColumns.
+func ParseColumns(s string) []string {
+	var cols []string
+	for _, c := range strings.Split(s, ",") {
+		switch c = strings.TrimSpace(c); c {
+		case "":
+		case "offsets":
+			cols = append(cols, Offsets...)
+		default:
+			cols = append(cols, c)
+		}
+	}
+	if len(cols) == 0 {
+		return DefaultColumns
+	}
+	return cols
+}
+
+// Write writes a header row of column names and one row per chunk.
+// Missing fields are empty cells; objects and lists are written as JSON.
+func Write(w io.Writer, chunks []chunking.Chunk, columns []string) error {
+	if len(columns) == 0 {
+		columns = DefaultColumns
+	}
+	cw := csv.NewWriter(w)
+	if err := cw.Write(columns); err != nil {
+		return err
+	}
+	row := make([]string, len(columns))
+	for _, ch := range chunks {
+		data, err := json.Marshal(ch)
+		if err != nil {
+			return err
+		}
+		var fields map[string]interface{}
+		if err := json.Unmarshal(data, &fields); err != nil {
+			return err
+		}
+		for i, c := range columns {
+			v := fields[c]
+			if key, ok := strings.CutPrefix(c, "extra."); ok {
+				extra, _ := fields["extra"].(map[string]interface{})
+				v = extra[key]
+			}
+			row[i] = cell(v)
+		}
+		if err := cw.Write(row); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+func cell(v interface{}) string {
+	switch v := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case bool:
+		return strconv.FormatBool(v)
+	default:
+		data, _ := json.Marshal(v)
+		return string(data)
+	}
+}
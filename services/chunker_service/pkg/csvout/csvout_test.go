@@ -0,0 +1,33 @@
+package csvout
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"chunker-service/pkg/chunking"
+)
+
+func TestWrite(t *testing.T) {
+	chunks := []chunking.Chunk{
+		{ID: "d#0", Text: "say \"hi\",\nthen go", FileName: "a.md", StartIndex: 0, EndIndex: 17, Extra: map[string]interface{}{"team": "docs", "links": []interface{}{"x"}}},
+		{ID: "d#1", Text: "two", FileName: "a.md", ChunkIndex: 1, StartIndex: 17, EndIndex: 20},
+	}
+	var b strings.Builder
+	cols := ParseColumns("id, file_name,offsets,text,extra.team,extra.links")
+	if err := Write(&b, chunks, cols); err != nil {
+		t.Fatalf("write failed: %v", err)
+	}
+	want := "id,file_name,start_index,end_index,byte_start,byte_end,text,extra.team,extra.links\n" +
+		"d#0,a.md,0,17,0,0,\"say \"\"hi\"\",\nthen go\",docs,\"[\"\"x\"\"]\"\n" +
+		"d#1,a.md,17,20,0,0,two,,\n"
+	if b.String() != want {
+		t.Fatalf("unexpected CSV:\n%s\nwant:\n%s", b.String(), want)
+	}
+}
+
+func TestParseColumns(t *testing.T) {
+	if got := ParseColumns(" "); !reflect.DeepEqual(got, DefaultColumns) {
+		t.Errorf("expected the default columns, got %v", got)
+	}
+}
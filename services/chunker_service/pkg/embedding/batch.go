@@ -0,0 +1,92 @@
+package embedding
+
+import (
+	"errors"
+	"sort"
+)
+
+// Limits describes the request limits of an embedding model. A zero
+// value for any field means the model imposes no such limit.
+type Limits struct {
+	MaxTokensPerRequest int `json:"max_tokens_per_request"`
+	MaxItemsPerRequest  int `json:"max_items_per_request"`
+	MaxTokensPerItem    int `json:"max_tokens_per_item,omitempty"`
+}
+
+// Batch is a group of chunks that can be sent to the embedding model in a
+// single request. Indices refer to positions in the token count slice
+// passed to PlanBatches and are kept in ascending order.
+type Batch struct {
+	Indices []int `json:"indices"`
+	Tokens  int   `json:"tokens"`
+}
+
+// BatchPlan is the result of grouping chunks into embedding requests.
+// Oversized lists the indices of chunks that exceed a per-item or
+// per-request token limit on their own and therefore cannot be embedded
+// without being split first.
+type BatchPlan struct {
+	Batches   []Batch `json:"batches"`
+	Oversized []int   `json:"oversized,omitempty"`
+}
+
+// PlanBatches groups chunks into as few embedding requests as possible
+// while respecting the model limits. It uses first-fit decreasing bin
+// packing, which is deterministic and stays close to the optimal number
+// of requests for the chunk size distributions we see in practice.
+func PlanBatches(tokenCounts []int, limits Limits) (BatchPlan, error) {
+	if limits.MaxTokensPerRequest < 0 || limits.MaxItemsPerRequest < 0 || limits.MaxTokensPerItem < 0 {
+		return BatchPlan{}, errors.New("embedding limits must be >= 0")
+	}
+
+	plan := BatchPlan{}
+	order := make([]int, 0, len(tokenCounts))
+	for i, n := range tokenCounts {
+		if n < 0 {
+			return BatchPlan{}, errors.New("token counts must be >= 0")
+		}
+		if limits.MaxTokensPerItem > 0 && n > limits.MaxTokensPerItem ||
+			limits.MaxTokensPerRequest > 0 && n > limits.MaxTokensPerRequest {
+			plan.Oversized = append(plan.Oversized, i)
+			continue
+		}
+		order = append(order, i)
+	}
+
+	// Largest chunks first; ties keep document order so plans are stable.
+	sort.SliceStable(order, func(a, b int) bool {
+		return tokenCounts[order[a]] > tokenCounts[order[b]]
+	})
+
+	for _, idx := range order {
+		n := tokenCounts[idx]
+		placed := false
+		for b := range plan.Batches {
+			batch := &plan.Batches[b]
+			if limits.MaxItemsPerRequest > 0 && len(batch.Indices) >= limits.MaxItemsPerRequest {
+				continue
+			}
+			if limits.MaxTokensPerRequest > 0 && batch.Tokens+n > limits.MaxTokensPerRequest {
+				continue
+			}
+			batch.Indices = append(batch.Indices, idx)
+			batch.Tokens += n
+			placed = true
+			break
+		}
+		if !placed {
+			plan.Batches = append(plan.Batches, Batch{Indices: []int{idx}, Tokens: n})
+		}
+	}
+
+	for b := range plan.Batches {
+		sort.Ints(plan.Batches[b].Indices)
+	}
+	// Order batches by their first chunk so requests roughly follow the
+	// document.
+	sort.SliceStable(plan.Batches, func(a, b int) bool {
+		return plan.Batches[a].Indices[0] < plan.Batches[b].Indices[0]
+	})
+
+	return plan, nil
+}
@@ -0,0 +1,66 @@
+package embedding
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestPlanBatchesRespectsLimits(t *testing.T) {
+	counts := []int{50, 30, 80, 20, 60, 10}
+	limits := Limits{MaxTokensPerRequest: 100, MaxItemsPerRequest: 3}
+
+	plan, err := PlanBatches(counts, limits)
+	if err != nil {
+		t.Fatalf("planning failed: %v", err)
+	}
+
+	seen := map[int]bool{}
+	for i, b := range plan.Batches {
+		if b.Tokens > limits.MaxTokensPerRequest {
+			t.Errorf("batch %d has %d tokens, limit %d", i, b.Tokens, limits.MaxTokensPerRequest)
+		}
+		if len(b.Indices) > limits.MaxItemsPerRequest {
+			t.Errorf("batch %d has %d items, limit %d", i, len(b.Indices), limits.MaxItemsPerRequest)
+		}
+		sum := 0
+		for _, idx := range b.Indices {
+			if seen[idx] {
+				t.Errorf("chunk %d assigned twice", idx)
+			}
+			seen[idx] = true
+			sum += counts[idx]
+		}
+		if sum != b.Tokens {
+			t.Errorf("batch %d tokens = %d, want %d", i, b.Tokens, sum)
+		}
+	}
+	if len(seen) != len(counts) {
+		t.Fatalf("expected all %d chunks to be batched, got %d", len(counts), len(seen))
+	}
+	// 250 tokens at 100 per request needs at least 3 requests.
+	if got := len(plan.Batches); got != 3 {
+		t.Fatalf("expected 3 batches, got %d: %+v", got, plan.Batches)
+	}
+}
+
+func TestPlanBatchesOversized(t *testing.T) {
+	plan, err := PlanBatches([]int{10, 600, 20}, Limits{MaxTokensPerRequest: 1000, MaxTokensPerItem: 512})
+	if err != nil {
+		t.Fatalf("planning failed: %v", err)
+	}
+	if !reflect.DeepEqual(plan.Oversized, []int{1}) {
+		t.Fatalf("oversized = %v, want [1]", plan.Oversized)
+	}
+	if len(plan.Batches) != 1 || !reflect.DeepEqual(plan.Batches[0].Indices, []int{0, 2}) {
+		t.Fatalf("unexpected batches: %+v", plan.Batches)
+	}
+}
+
+func TestPlanBatchesValidation(t *testing.T) {
+	if _, err := PlanBatches([]int{1}, Limits{MaxItemsPerRequest: -1}); err == nil {
+		t.Fatalf("expected error for negative limit")
+	}
+	if _, err := PlanBatches([]int{-1}, Limits{}); err == nil {
+		t.Fatalf("expected error for negative token count")
+	}
+}
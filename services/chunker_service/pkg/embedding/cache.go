@@ -0,0 +1,87 @@
+package embedding
+
+import (
+	"container/list"
+	"crypto/sha256"
+	"encoding/hex"
+	"sync"
+)
+
+// Cache stores vectors by CacheKey so texts that were embedded before,
+// such as the unchanged chunks of a re-ingested document, are not sent to
+// the embedding service again. Implementations must be safe for
+// concurrent use; a Redis-backed Cache can be shared by several servers.
+type Cache interface {
+	Get(key string) ([]float64, bool)
+	Put(key string, vector []float64)
+}
+
+// CacheKey is the cache key of text embedded by model: a hash of both, so
+// changing the model or a single character of the text misses. An empty
+// model is the service's default model.
+func CacheKey(model, text string) string {
+	h := sha256.New()
+	h.Write([]byte(model))
+	h.Write([]byte{0})
+	h.Write([]byte(text))
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// MemoryCache is a Cache held in memory that evicts the least recently
+// used vectors beyond its size.
+type MemoryCache struct {
+	mu      sync.Mutex
+	size    int
+	order   *list.List
+	entries map[string]*list.Element
+}
+
+type cacheEntry struct {
+	key    string
+	vector []float64
+}
+
+// NewMemoryCache returns a MemoryCache holding up to size vectors.
+func NewMemoryCache(size int) *MemoryCache {
+	return &MemoryCache{size: size, order: list.New(), entries: map[string]*list.Element{}}
+}
+
+// Get returns the vector stored under key.
+func (c *MemoryCache) Get(key string) ([]float64, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	e, ok := c.entries[key]
+	if !ok {
+		return nil, false
+	}
+	c.order.MoveToFront(e)
+	return e.Value.(*cacheEntry).vector, true
+}
+
+// Put stores vector under key, evicting the least recently used vector
+// when the cache is full.
+func (c *MemoryCache) Put(key string, vector []float64) {
+	if c.size <= 0 {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if e, ok := c.entries[key]; ok {
+		e.Value.(*cacheEntry).vector = vector
+		c.order.MoveToFront(e)
+		return
+	}
+	c.entries[key] = c.order.PushFront(&cacheEntry{key, vector})
+	if c.order.Len() > c.size {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.entries, oldest.Value.(*cacheEntry).key)
+	}
+}
+
+// Len returns the number of cached vectors.
+func (c *MemoryCache) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.order.Len()
+}
@@ -0,0 +1,105 @@
+package embedding
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+func TestMemoryCacheEvicts(t *testing.T) {
+	c := NewMemoryCache(2)
+	c.Put("a", []float64{1})
+	c.Put("b", []float64{2})
+	c.Get("a")
+	c.Put("c", []float64{3})
+	if _, ok := c.Get("b"); ok {
+		t.Error("expected the least recently used vector to be evicted")
+	}
+	if v, ok := c.Get("a"); !ok || v[0] != 1 || c.Len() != 2 {
+		t.Errorf("unexpected cache state: a=%v len=%d", v, c.Len())
+	}
+	if CacheKey("m1", "text") == CacheKey("m2", "text") || CacheKey("", "ab") == CacheKey("a", "b") {
+		t.Error("expected keys to differ by model and text")
+	}
+}
+
+func TestClientCache(t *testing.T) {
+	var sent [][]string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req embedRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Fatalf("bad body: %v", err)
+		}
+		sent = append(sent, req.Texts)
+		var resp embedResponse
+		for _, text := range req.Texts {
+			resp.Vectors = append(resp.Vectors, []float64{float64(len(text))})
+		}
+		_ = json.NewEncoder(w).Encode(resp)
+	}))
+	defer srv.Close()
+
+	c := &Client{URL: srv.URL, Model: "m", Cache: NewMemoryCache(10)}
+	if _, err := c.Embed(context.Background(), []string{"a", "bb", "a"}); err != nil {
+		t.Fatalf("embed failed: %v", err)
+	}
+	vectors, err := c.Embed(context.Background(), []string{"bb", "ccc", "a"})
+	if err != nil {
+		t.Fatalf("embed failed: %v", err)
+	}
+	if want := [][]float64{{2}, {3}, {1}}; !reflect.DeepEqual(vectors, want) {
+		t.Errorf("expected %v, got %v", want, vectors)
+	}
+	if want := [][]string{{"a", "bb"}, {"ccc"}}; !reflect.DeepEqual(sent, want) {
+		t.Errorf("expected only uncached texts to be sent, got %v", sent)
+	}
+
+	c.Model = "other"
+	if _, err := c.Embed(context.Background(), []string{"a"}); err != nil || len(sent) != 3 {
+		t.Errorf("expected another model to miss the cache, got %v after %d requests", err, len(sent))
+	}
+}
+
+type mapStore struct {
+	values map[string][]byte
+	err    error
+}
+
+func (m *mapStore) Load(ctx context.Context, key string) ([]byte, error) {
+	return m.values[key], m.err
+}
+
+func (m *mapStore) Store(ctx context.Context, key string, value []byte) error {
+	if m.err != nil {
+		return m.err
+	}
+	m.values[key] = value
+	return nil
+}
+
+func TestRedisCache(t *testing.T) {
+	store := &mapStore{values: map[string][]byte{}}
+	c := NewRedisCache(store)
+	var failures int
+	c.OnError = func(error) { failures++ }
+	if _, ok := c.Get("k"); ok {
+		t.Fatal("expected a miss on an empty store")
+	}
+	want := []float64{0.5, -1.25, 3}
+	c.Put("k", want)
+	if got, ok := c.Get("k"); !ok || !reflect.DeepEqual(got, want) {
+		t.Fatalf("got %v, %v, want %v", got, ok, want)
+	}
+	store.err = errors.New("connection refused")
+	if _, ok := c.Get("k"); ok {
+		t.Error("a failed lookup should miss")
+	}
+	c.Put("k2", want)
+	if failures != 2 {
+		t.Errorf("expected both failures reported, got %d", failures)
+	}
+}
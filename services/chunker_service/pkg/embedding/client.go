@@ -0,0 +1,293 @@
+package embedding
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"chunker-service/pkg/retry"
+)
+
+// DefaultBatchSize matches the embedding service's default
+// EMBEDDING_MAX_BATCH.
+const DefaultBatchSize = 64
+
+// maxThrottleRetries is how many times a Client with a Limiter retries a
+// batch the provider answered with 429.
+const maxThrottleRetries = 5
+
+// ErrTextTooLong is returned for texts that exceed Client.Limits on
+// their own.
+var ErrTextTooLong = errors.New("embedding client: text exceeds the model's token limit")
+
+// ErrThrottled is returned when the embedding service keeps answering
+// 429 Too Many Requests.
+var ErrThrottled = errors.New("embedding service throttled the request")
+
+// Client calls the embedding service's POST /embed endpoint. Texts are
+// packed into as few requests as Limits allows, with PlanBatches, and
+// the vectors are returned in input order.
+type Client struct {
+	// URL is the base URL of the embedding service, e.g.
+	// "http://embedding-service:8080".
+	URL string
+	// Model optionally overrides the service's default model.
+	Model string
+	// Token is sent as a bearer token when set.
+	Token string
+	// BatchSize caps the texts per request when Limits does not;
+	// DefaultBatchSize when zero.
+	BatchSize int
+	// Limits are the model's request limits, with tokens counted by
+	// CountTokens. A text over a token limit on its own fails the call
+	// with ErrTextTooLong instead of being sent.
+	Limits Limits
+	// HTTPClient defaults to http.DefaultClient.
+	HTTPClient *http.Client
+	// Limiter, when set, keeps requests within the provider's quota,
+	// counting tokens with CountTokens, and retries batches answered with
+	// 429 after the pause it sets. Share one Limiter between all clients
+	// of the same provider.
+	Limiter *Limiter
+	// Retrier retries requests that fail with a network error or a 5xx;
+	// other 4xx answers fail at once. Defaults to the shared "embedding"
+	// Retrier with retry.DefaultPolicy.
+	Retrier *retry.Retrier
+	// Cache, when set, holds the vectors of texts already embedded with
+	// Model; only the texts it misses are sent to the service. Cached
+	// vectors are shared, so callers must not modify them.
+	Cache Cache
+}
+
+type embedRequest struct {
+	Texts []string `json:"texts"`
+	Model string   `json:"model,omitempty"`
+}
+
+type embedResponse struct {
+	Vectors [][]float64 `json:"vectors"`
+}
+
+// Embed returns one vector per text.
+func (c *Client) Embed(ctx context.Context, texts []string) ([][]float64, error) {
+	if c.URL == "" {
+		return nil, errors.New("embedding client: url is required")
+	}
+	if c.Cache == nil {
+		return c.embed(ctx, texts)
+	}
+	vectors := make([][]float64, len(texts))
+	keys := make([]string, len(texts))
+	// missing maps each text to embed, once however often it repeats, to
+	// the indexes it fills.
+	missing := map[string][]int{}
+	var misses []string
+	for i, text := range texts {
+		keys[i] = CacheKey(c.Model, text)
+		if v, ok := c.Cache.Get(keys[i]); ok {
+			vectors[i] = v
+			continue
+		}
+		if _, ok := missing[text]; !ok {
+			misses = append(misses, text)
+		}
+		missing[text] = append(missing[text], i)
+	}
+	if len(misses) == 0 {
+		return vectors, nil
+	}
+	embedded, err := c.embed(ctx, misses)
+	if err != nil {
+		return nil, err
+	}
+	for j, text := range misses {
+		idx := missing[text]
+		c.Cache.Put(keys[idx[0]], embedded[j])
+		for _, i := range idx {
+			vectors[i] = embedded[j]
+		}
+	}
+	return vectors, nil
+}
+
+// embed sends texts to the service in the batches PlanBatches packs
+// them into under c.Limits.
+func (c *Client) embed(ctx context.Context, texts []string) ([][]float64, error) {
+	limits := c.Limits
+	if limits.MaxItemsPerRequest == 0 {
+		limits.MaxItemsPerRequest = c.BatchSize
+		if limits.MaxItemsPerRequest <= 0 {
+			limits.MaxItemsPerRequest = DefaultBatchSize
+		}
+	}
+	counts := make([]int, len(texts))
+	for i, text := range texts {
+		counts[i] = CountTokens(text)
+	}
+	plan, err := PlanBatches(counts, limits)
+	if err != nil {
+		return nil, fmt.Errorf("embedding client: %w", err)
+	}
+	if len(plan.Oversized) > 0 {
+		i := plan.Oversized[0]
+		return nil, fmt.Errorf("%w: text %d has %d tokens (%d texts too long)", ErrTextTooLong, i, counts[i], len(plan.Oversized))
+	}
+	vectors := make([][]float64, len(texts))
+	for _, b := range plan.Batches {
+		batch := make([]string, len(b.Indices))
+		for j, i := range b.Indices {
+			batch[j] = texts[i]
+		}
+		embedded, err := c.post(ctx, batch)
+		if err != nil {
+			return nil, err
+		}
+		if len(embedded) != len(batch) {
+			return nil, fmt.Errorf("embedding client: got %d vectors for %d texts", len(embedded), len(batch))
+		}
+		for j, i := range b.Indices {
+			vectors[i] = embedded[j]
+		}
+	}
+	return vectors, nil
+}
+
+// post embeds one batch, waiting for c.Limiter and retrying while the
+// service answers 429.
+func (c *Client) post(ctx context.Context, texts []string) ([][]float64, error) {
+	body, err := json.Marshal(embedRequest{Texts: texts, Model: c.Model})
+	if err != nil {
+		return nil, err
+	}
+	if c.Limiter == nil {
+		vectors, _, err := c.sendRetried(ctx, body)
+		return vectors, err
+	}
+	tokens := 0
+	for _, text := range texts {
+		tokens += CountTokens(text)
+	}
+	for attempt := 0; ; attempt++ {
+		if err := c.Limiter.Wait(ctx, tokens); err != nil {
+			return nil, err
+		}
+		vectors, retryAfter, err := c.sendRetried(ctx, body)
+		switch {
+		case err == nil:
+			c.Limiter.Succeeded()
+			return vectors, nil
+		case !errors.Is(err, ErrThrottled) || attempt == maxThrottleRetries:
+			return nil, err
+		}
+		c.Limiter.Throttled(retryAfter)
+	}
+}
+
+// sendRetried sends a batch through c.Retrier. A 429 is returned as is
+// when there is a Limiter to handle it.
+func (c *Client) sendRetried(ctx context.Context, body []byte) ([][]float64, time.Duration, error) {
+	r := c.Retrier
+	if r == nil {
+		r = retry.New("embedding", retry.DefaultPolicy)
+	}
+	var vectors [][]float64
+	var after time.Duration
+	var throttled error
+	_, err := r.Do(ctx, func(ctx context.Context) error {
+		var err error
+		vectors, after, err = c.send(ctx, body)
+		if c.Limiter != nil && errors.Is(err, ErrThrottled) {
+			// Not a failure of the service: the Limiter slows down and
+			// retries, and the breaker stays closed.
+			throttled = err
+			return nil
+		}
+		return err
+	})
+	if throttled != nil {
+		return nil, after, throttled
+	}
+	return vectors, after, err
+}
+
+// send makes one /embed request. On 429 it returns ErrThrottled and the
+// response's Retry-After, if any; other 4xx answers are permanent.
+func (c *Client) send(ctx context.Context, body []byte) ([][]float64, time.Duration, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, strings.TrimRight(c.URL, "/")+"/embed", bytes.NewReader(body))
+	if err != nil {
+		return nil, 0, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if c.Token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.Token)
+	}
+	hc := c.HTTPClient
+	if hc == nil {
+		hc = http.DefaultClient
+	}
+	resp, err := hc.Do(req)
+	if err != nil {
+		return nil, 0, fmt.Errorf("embedding client: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusTooManyRequests {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return nil, retryAfter(resp.Header.Get("Retry-After"), time.Now()), fmt.Errorf("embedding client: %w: %s", ErrThrottled, strings.TrimSpace(string(msg)))
+	}
+	if resp.StatusCode != http.StatusOK {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		err := fmt.Errorf("embedding client: %s: %s", resp.Status, strings.TrimSpace(string(msg)))
+		if resp.StatusCode >= 400 && resp.StatusCode < 500 {
+			err = retry.Permanent(err)
+		}
+		return nil, 0, err
+	}
+	var out embedResponse
+	if err := json.NewDecoder(resp.Body).Decode(&out); err != nil {
+		return nil, 0, fmt.Errorf("embedding client: invalid response: %w", err)
+	}
+	return out.Vectors, 0, nil
+}
+
+// retryAfter parses a Retry-After header, in seconds or as an HTTP date,
+// returning 0 when it is missing or invalid.
+func retryAfter(header string, now time.Time) time.Duration {
+	if secs, err := strconv.Atoi(header); err == nil && secs > 0 {
+		return time.Duration(secs) * time.Second
+	}
+	if t, err := http.ParseTime(header); err == nil && t.After(now) {
+		return t.Sub(now)
+	}
+	return 0
+}
+
+// Health checks that the service answers GET /healthz with 200 OK.
+func (c *Client) Health(ctx context.Context) error {
+	if c.URL == "" {
+		return errors.New("embedding client: url is required")
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, strings.TrimRight(c.URL, "/")+"/healthz", nil)
+	if err != nil {
+		return err
+	}
+	hc := c.HTTPClient
+	if hc == nil {
+		hc = http.DefaultClient
+	}
+	resp, err := hc.Do(req)
+	if err != nil {
+		return fmt.Errorf("embedding client: %w", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("embedding client: health check returned %s", resp.Status)
+	}
+	return nil
+}
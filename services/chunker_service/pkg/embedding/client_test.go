@@ -0,0 +1,147 @@
+package embedding
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"chunker-service/pkg/retry"
+)
+
+func TestClientEmbedBatches(t *testing.T) {
+	var batches []int
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/embed" || r.Header.Get("Authorization") != "Bearer secret" {
+			t.Errorf("unexpected request %s auth=%q", r.URL.Path, r.Header.Get("Authorization"))
+		}
+		var req embedRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Fatalf("bad body: %v", err)
+		}
+		batches = append(batches, len(req.Texts))
+		var resp embedResponse
+		for _, text := range req.Texts {
+			resp.Vectors = append(resp.Vectors, []float64{float64(len(text))})
+		}
+		_ = json.NewEncoder(w).Encode(resp)
+	}))
+	defer srv.Close()
+
+	c := &Client{URL: srv.URL + "/", Token: "secret", BatchSize: 2}
+	vectors, err := c.Embed(context.Background(), []string{"a", "bb", "ccc", "dddd", "eeeee"})
+	if err != nil {
+		t.Fatalf("embed failed: %v", err)
+	}
+	if len(batches) != 3 || batches[2] != 1 {
+		t.Errorf("expected batches of 2, 2, 1, got %v", batches)
+	}
+	for i, v := range vectors {
+		if v[0] != float64(i+1) {
+			t.Errorf("vector %d out of order: %v", i, v)
+		}
+	}
+}
+
+func TestClientEmbedLimits(t *testing.T) {
+	var batches [][]string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req embedRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Fatalf("bad body: %v", err)
+		}
+		batches = append(batches, req.Texts)
+		var resp embedResponse
+		for _, text := range req.Texts {
+			resp.Vectors = append(resp.Vectors, []float64{float64(len(text))})
+		}
+		_ = json.NewEncoder(w).Encode(resp)
+	}))
+	defer srv.Close()
+
+	c := &Client{URL: srv.URL, Limits: Limits{MaxTokensPerRequest: 5, MaxTokensPerItem: 4}}
+	texts := []string{"a b c", "d e f", "g h", "i j"}
+	vectors, err := c.Embed(context.Background(), texts)
+	if err != nil {
+		t.Fatalf("embed failed: %v", err)
+	}
+	// 3+3 words exceed 5, so each 3-word text shares a request with a
+	// 2-word one.
+	if len(batches) != 2 || len(batches[0]) != 2 || len(batches[1]) != 2 {
+		t.Errorf("expected two requests of two texts, got %q", batches)
+	}
+	for i, v := range vectors {
+		if v[0] != float64(len(texts[i])) {
+			t.Errorf("vector %d out of order: %v", i, v)
+		}
+	}
+
+	batches = nil
+	if _, err := c.Embed(context.Background(), []string{"a", "one two three four five"}); !errors.Is(err, ErrTextTooLong) {
+		t.Fatalf("expected ErrTextTooLong, got %v", err)
+	}
+	if len(batches) != 0 {
+		t.Errorf("nothing should be sent when a text is too long, sent %q", batches)
+	}
+}
+
+func TestClientEmbedError(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "model unavailable", http.StatusServiceUnavailable)
+	}))
+	defer srv.Close()
+
+	c := &Client{URL: srv.URL, Retrier: retry.New(t.Name(), retry.Policy{})}
+	if _, err := c.Embed(context.Background(), []string{"a"}); err == nil {
+		t.Fatalf("expected error for 503 response")
+	}
+}
+
+func TestClientRetries(t *testing.T) {
+	statuses := []int{http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusOK}
+	calls := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		status := statuses[min(calls, len(statuses)-1)]
+		calls++
+		if status != http.StatusOK {
+			http.Error(w, "try again", status)
+			return
+		}
+		_ = json.NewEncoder(w).Encode(embedResponse{Vectors: [][]float64{{1}}})
+	}))
+	defer srv.Close()
+
+	c := &Client{URL: srv.URL, Retrier: retry.New(t.Name(), retry.Policy{MaxAttempts: 3})}
+	if _, err := c.Embed(context.Background(), []string{"a"}); err != nil || calls != 3 {
+		t.Fatalf("expected success on the third attempt, got %v after %d calls", err, calls)
+	}
+
+	statuses, calls = []int{http.StatusBadRequest, http.StatusOK}, 0
+	if _, err := c.Embed(context.Background(), []string{"a"}); err == nil || calls != 1 {
+		t.Fatalf("expected a 400 to fail without retrying, got %v after %d calls", err, calls)
+	}
+}
+
+func TestClientHealth(t *testing.T) {
+	healthy := true
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/healthz" || r.Method != http.MethodGet {
+			t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+		}
+		if !healthy {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+	}))
+	defer srv.Close()
+
+	c := &Client{URL: srv.URL}
+	if err := c.Health(context.Background()); err != nil {
+		t.Fatalf("expected a healthy service, got %v", err)
+	}
+	healthy = false
+	if err := c.Health(context.Background()); err == nil {
+		t.Fatal("expected an error for a 503")
+	}
+}
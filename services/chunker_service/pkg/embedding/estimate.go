@@ -0,0 +1,61 @@
+package embedding
+
+import (
+	"errors"
+	"strings"
+)
+
+// Price is the cost of embedding with a single model.
+type Price struct {
+	PerMillionTokens float64 `json:"per_million_tokens"`
+	Currency         string  `json:"currency,omitempty"`
+}
+
+// PricingTable maps model names to their embedding price.
+type PricingTable map[string]Price
+
+// Estimate summarizes the projected cost of embedding a set of chunks.
+type Estimate struct {
+	Model     string  `json:"model"`
+	Chunks    int     `json:"chunks"`
+	Tokens    int     `json:"tokens"`
+	Requests  int     `json:"requests"`
+	Oversized int     `json:"oversized,omitempty"`
+	Cost      float64 `json:"cost"`
+	Currency  string  `json:"currency"`
+}
+
+// CountTokens approximates the number of model tokens in text using
+// whitespace-delimited words, matching the chunker's tokens mode.
+func CountTokens(text string) int {
+	return len(strings.Fields(text))
+}
+
+// EstimateCost projects the token count, request count and cost of
+// embedding chunks with the given per-chunk token counts.
+func EstimateCost(tokenCounts []int, model string, pricing PricingTable, limits Limits) (Estimate, error) {
+	price, ok := pricing[model]
+	if !ok {
+		return Estimate{}, errors.New("no pricing for model " + model)
+	}
+	plan, err := PlanBatches(tokenCounts, limits)
+	if err != nil {
+		return Estimate{}, err
+	}
+
+	est := Estimate{
+		Model:     model,
+		Chunks:    len(tokenCounts),
+		Requests:  len(plan.Batches),
+		Oversized: len(plan.Oversized),
+		Currency:  price.Currency,
+	}
+	if est.Currency == "" {
+		est.Currency = "USD"
+	}
+	for _, n := range tokenCounts {
+		est.Tokens += n
+	}
+	est.Cost = float64(est.Tokens) / 1e6 * price.PerMillionTokens
+	return est, nil
+}
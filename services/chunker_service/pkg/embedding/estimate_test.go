@@ -0,0 +1,21 @@
+package embedding
+
+import "testing"
+
+func TestEstimateCost(t *testing.T) {
+	pricing := PricingTable{"small": {PerMillionTokens: 0.02}}
+	est, err := EstimateCost([]int{400000, 600000}, "small", pricing, Limits{MaxTokensPerRequest: 800000})
+	if err != nil {
+		t.Fatalf("estimate failed: %v", err)
+	}
+	if est.Tokens != 1000000 || est.Requests != 2 || est.Chunks != 2 {
+		t.Fatalf("unexpected estimate: %+v", est)
+	}
+	if est.Cost != 0.02 || est.Currency != "USD" {
+		t.Fatalf("cost = %v %s, want 0.02 USD", est.Cost, est.Currency)
+	}
+
+	if _, err := EstimateCost([]int{1}, "missing", pricing, Limits{}); err == nil {
+		t.Fatalf("expected error for unknown model")
+	}
+}
@@ -0,0 +1,44 @@
+package embedding
+
+import (
+	"context"
+	"hash/fnv"
+	"strings"
+	"unicode"
+)
+
+// Hashing is a deterministic, dependency-free embedder that hashes
+// lowercased words into Dim signed buckets. It captures word overlap, not
+// meaning, and is meant for tests and demos where no embedding service is
+// available.
+type Hashing struct {
+	// Dim defaults to 256.
+	Dim int
+}
+
+// Embed returns one vector per text.
+func (h Hashing) Embed(_ context.Context, texts []string) ([][]float64, error) {
+	dim := h.Dim
+	if dim <= 0 {
+		dim = 256
+	}
+	out := make([][]float64, len(texts))
+	for i, text := range texts {
+		v := make([]float64, dim)
+		words := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+			return !unicode.IsLetter(r) && !unicode.IsNumber(r)
+		})
+		for _, w := range words {
+			f := fnv.New64a()
+			f.Write([]byte(w))
+			sum := f.Sum64()
+			sign := 1.0
+			if sum>>63 == 1 {
+				sign = -1
+			}
+			v[sum%uint64(dim)] += sign
+		}
+		out[i] = v
+	}
+	return out, nil
+}
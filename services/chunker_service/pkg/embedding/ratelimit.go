@@ -0,0 +1,152 @@
+package embedding
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+)
+
+// RateLimit is an embedding provider's quota. A zero value for either
+// field means the provider imposes no such limit.
+type RateLimit struct {
+	RequestsPerMinute int `json:"requests_per_minute,omitempty"`
+	TokensPerMinute   int `json:"tokens_per_minute,omitempty"`
+}
+
+// Validate rejects negative limits.
+func (r RateLimit) Validate() error {
+	if r.RequestsPerMinute < 0 || r.TokensPerMinute < 0 {
+		return errors.New("embedding rate limits must be >= 0")
+	}
+	return nil
+}
+
+// Throttle backoff after a 429 without Retry-After: it starts at
+// minThrottleBackoff, doubles with every 429 in a row and resets after a
+// request succeeds.
+const (
+	minThrottleBackoff = time.Second
+	maxThrottleBackoff = time.Minute
+)
+
+// Limiter keeps embedding requests within a RateLimit. One Limiter is
+// shared by every goroutine calling the provider, so concurrent workers
+// draw on a single quota. Each quota is a bucket holding up to one
+// minute's worth, refilled continuously. When the provider still answers
+// 429, Throttled pauses every caller until it may be retried.
+type Limiter struct {
+	limit RateLimit
+
+	mu          sync.Mutex
+	requests    float64
+	tokens      float64
+	last        time.Time
+	pausedUntil time.Time
+	backoff     time.Duration
+
+	now   func() time.Time
+	sleep func(ctx context.Context, d time.Duration) error
+}
+
+// NewLimiter returns a Limiter for limit, starting with full buckets.
+func NewLimiter(limit RateLimit) *Limiter {
+	return &Limiter{
+		limit:    limit,
+		requests: float64(limit.RequestsPerMinute),
+		tokens:   float64(limit.TokensPerMinute),
+		now:      time.Now,
+		sleep:    sleepContext,
+	}
+}
+
+// Wait blocks until a request of the given tokens fits the limits and
+// any pause after a 429 is over, then takes it from the buckets. A
+// request larger than a whole minute's tokens waits for a full bucket.
+func (l *Limiter) Wait(ctx context.Context, tokens int) error {
+	for {
+		d := l.reserve(tokens)
+		if d == 0 {
+			return nil
+		}
+		if err := l.sleep(ctx, d); err != nil {
+			return err
+		}
+	}
+}
+
+// reserve takes a request of tokens from the buckets and returns 0, or
+// returns how long to wait before trying again.
+func (l *Limiter) reserve(tokens int) time.Duration {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	now := l.now()
+	if now.Before(l.pausedUntil) {
+		return l.pausedUntil.Sub(now)
+	}
+	if !l.last.IsZero() {
+		elapsed := now.Sub(l.last).Minutes()
+		l.requests = min(l.requests+elapsed*float64(l.limit.RequestsPerMinute), float64(l.limit.RequestsPerMinute))
+		l.tokens = min(l.tokens+elapsed*float64(l.limit.TokensPerMinute), float64(l.limit.TokensPerMinute))
+	}
+	l.last = now
+
+	need := min(float64(tokens), float64(l.limit.TokensPerMinute))
+	var wait float64 // minutes
+	if rpm := l.limit.RequestsPerMinute; rpm > 0 && l.requests < 1 {
+		wait = max(wait, (1-l.requests)/float64(rpm))
+	}
+	if tpm := l.limit.TokensPerMinute; tpm > 0 && l.tokens < need {
+		wait = max(wait, (need-l.tokens)/float64(tpm))
+	}
+	if wait > 0 {
+		// Round up so the bucket is full enough when the caller wakes.
+		return time.Duration(wait*float64(time.Minute)) + time.Millisecond
+	}
+	if l.limit.RequestsPerMinute > 0 {
+		l.requests--
+	}
+	if l.limit.TokensPerMinute > 0 {
+		l.tokens -= need
+	}
+	return 0
+}
+
+// Throttled records a 429 from the provider and pauses every caller for
+// retryAfter, or, when the provider gave none, for a backoff that doubles
+// with each 429 in a row. It returns the pause.
+func (l *Limiter) Throttled(retryAfter time.Duration) time.Duration {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.backoff == 0 {
+		l.backoff = minThrottleBackoff
+	} else {
+		l.backoff = min(2*l.backoff, maxThrottleBackoff)
+	}
+	pause := retryAfter
+	if pause <= 0 {
+		pause = l.backoff
+	}
+	if until := l.now().Add(pause); until.After(l.pausedUntil) {
+		l.pausedUntil = until
+	}
+	return pause
+}
+
+// Succeeded resets the backoff after the provider accepted a request.
+func (l *Limiter) Succeeded() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.backoff = 0
+}
+
+func sleepContext(ctx context.Context, d time.Duration) error {
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-t.C:
+		return nil
+	}
+}
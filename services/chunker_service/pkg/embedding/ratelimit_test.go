@@ -0,0 +1,140 @@
+package embedding
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeClock makes l sleep on a virtual clock and returns the sleeps.
+func fakeClock(l *Limiter) *[]time.Duration {
+	var mu sync.Mutex
+	now := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	var slept []time.Duration
+	l.now = func() time.Time {
+		mu.Lock()
+		defer mu.Unlock()
+		return now
+	}
+	l.sleep = func(ctx context.Context, d time.Duration) error {
+		mu.Lock()
+		defer mu.Unlock()
+		now = now.Add(d)
+		slept = append(slept, d)
+		return nil
+	}
+	return &slept
+}
+
+func TestLimiterWait(t *testing.T) {
+	l := NewLimiter(RateLimit{RequestsPerMinute: 2, TokensPerMinute: 600})
+	slept := fakeClock(l)
+	ctx := context.Background()
+	for i := 0; i < 2; i++ {
+		if err := l.Wait(ctx, 100); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if len(*slept) != 0 {
+		t.Fatalf("a full bucket should not wait, slept %v", *slept)
+	}
+	// The third request waits for half a minute's request refill.
+	if err := l.Wait(ctx, 100); err != nil {
+		t.Fatal(err)
+	}
+	if len(*slept) != 1 || (*slept)[0] != 30*time.Second+time.Millisecond {
+		t.Fatalf("expected one 30s wait, got %v", *slept)
+	}
+	// 300 tokens are left of 600 plus the refill; 900 is capped at 600.
+	*slept = nil
+	if err := l.Wait(ctx, 900); err != nil {
+		t.Fatal(err)
+	}
+	if len(*slept) != 1 || (*slept)[0] < 29*time.Second || (*slept)[0] > 31*time.Second {
+		t.Fatalf("expected about 30s waiting for tokens, got %v", *slept)
+	}
+
+	unlimited := NewLimiter(RateLimit{})
+	fakeClock(unlimited)
+	for i := 0; i < 100; i++ {
+		if err := unlimited.Wait(ctx, 1000); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestLimiterThrottled(t *testing.T) {
+	l := NewLimiter(RateLimit{})
+	slept := fakeClock(l)
+	if d := l.Throttled(0); d != time.Second {
+		t.Fatalf("expected a 1s backoff, got %v", d)
+	}
+	if d := l.Throttled(0); d != 2*time.Second {
+		t.Fatalf("expected the backoff to double, got %v", d)
+	}
+	if d := l.Throttled(5 * time.Second); d != 5*time.Second {
+		t.Fatalf("expected Retry-After to win, got %v", d)
+	}
+	if err := l.Wait(context.Background(), 1); err != nil {
+		t.Fatal(err)
+	}
+	if len(*slept) != 1 || (*slept)[0] != 5*time.Second {
+		t.Fatalf("expected callers to wait out the pause, got %v", *slept)
+	}
+	l.Succeeded()
+	if d := l.Throttled(0); d != time.Second {
+		t.Fatalf("expected success to reset the backoff, got %v", d)
+	}
+}
+
+func TestClientRetriesThrottled(t *testing.T) {
+	calls := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		if calls <= 2 {
+			w.Header().Set("Retry-After", "3")
+			http.Error(w, "slow down", http.StatusTooManyRequests)
+			return
+		}
+		_ = json.NewEncoder(w).Encode(embedResponse{Vectors: [][]float64{{1}}})
+	}))
+	defer srv.Close()
+
+	l := NewLimiter(RateLimit{RequestsPerMinute: 60})
+	slept := fakeClock(l)
+	c := &Client{URL: srv.URL, Limiter: l}
+	if _, err := c.Embed(context.Background(), []string{"a"}); err != nil {
+		t.Fatalf("expected the throttled batch to be retried: %v", err)
+	}
+	if calls != 3 || len(*slept) != 2 || (*slept)[0] != 3*time.Second {
+		t.Fatalf("expected 3 calls after two 3s pauses, got %d calls, slept %v", calls, *slept)
+	}
+
+	calls = -100
+	if _, err := c.Embed(context.Background(), []string{"a"}); !errors.Is(err, ErrThrottled) {
+		t.Fatalf("expected ErrThrottled once retries run out, got %v", err)
+	}
+	if calls != -100+maxThrottleRetries+1 {
+		t.Fatalf("expected %d attempts, got %d", maxThrottleRetries+1, calls+100)
+	}
+}
+
+func TestRetryAfter(t *testing.T) {
+	now := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	cases := map[string]time.Duration{
+		"":                              0,
+		"7":                             7 * time.Second,
+		"soon":                          0,
+		"Tue, 02 Jan 2024 03:04:15 GMT": 10 * time.Second,
+	}
+	for header, want := range cases {
+		if got := retryAfter(header, now); got != want {
+			t.Errorf("retryAfter(%q) = %v, want %v", header, got, want)
+		}
+	}
+}
@@ -0,0 +1,75 @@
+package embedding
+
+import (
+	"context"
+	"encoding/binary"
+	"math"
+	"time"
+)
+
+// KeyValue stores byte values by key. *jobs.Redis satisfies it, so the
+// job store's Redis client can back a RedisCache too.
+type KeyValue interface {
+	// Load returns the value of key, or nil if there is none.
+	Load(ctx context.Context, key string) ([]byte, error)
+	// Store sets key to value.
+	Store(ctx context.Context, key string, value []byte) error
+}
+
+// RedisCache is a Cache kept in Redis, so replicas share their vectors
+// and keep them across restarts. The cache is an optimisation: a Redis
+// error counts as a miss, or a dropped Put, rather than failing the
+// embedding.
+type RedisCache struct {
+	// Timeout bounds each lookup and write.
+	Timeout time.Duration
+	// OnError, if set, is told of each failed lookup or write.
+	OnError func(error)
+
+	store KeyValue
+}
+
+// NewRedisCache returns a RedisCache over store; with *jobs.Redis, give
+// it a Prefix of its own, such as "chunker:embed:", and the TTL the
+// vectors should live.
+func NewRedisCache(store KeyValue) *RedisCache {
+	return &RedisCache{Timeout: time.Second, store: store}
+}
+
+// Get returns the vector stored under key.
+func (c *RedisCache) Get(key string) ([]float64, bool) {
+	ctx, cancel := context.WithTimeout(context.Background(), c.Timeout)
+	defer cancel()
+	data, err := c.store.Load(ctx, key)
+	if err != nil {
+		c.fail(err)
+		return nil, false
+	}
+	if data == nil || len(data)%8 != 0 {
+		return nil, false
+	}
+	vector := make([]float64, len(data)/8)
+	for i := range vector {
+		vector[i] = math.Float64frombits(binary.LittleEndian.Uint64(data[i*8:]))
+	}
+	return vector, true
+}
+
+// Put stores vector under key as little-endian float64s.
+func (c *RedisCache) Put(key string, vector []float64) {
+	data := make([]byte, 8*len(vector))
+	for i, v := range vector {
+		binary.LittleEndian.PutUint64(data[i*8:], math.Float64bits(v))
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), c.Timeout)
+	defer cancel()
+	if err := c.store.Store(ctx, key, data); err != nil {
+		c.fail(err)
+	}
+}
+
+func (c *RedisCache) fail(err error) {
+	if c.OnError != nil {
+		c.OnError(err)
+	}
+}
@@ -0,0 +1,74 @@
+package fieldcrypt
+
+import (
+	"bytes"
+	"encoding/base64"
+	"strings"
+	"testing"
+
+	"chunker-service/pkg/chunking"
+)
+
+var testKey = bytes.Repeat([]byte{7}, 32)
+
+func TestEncryptRoundTrip(t *testing.T) {
+	enc, err := NewWithKey("k1", testKey)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	chunks := []chunking.Chunk{{ID: "d#0", Text: "secret", OverlapText: "sec", Extra: map[string]interface{}{"a": 1}}}
+
+	out, err := enc.EncryptChunks(chunks)
+	if err != nil {
+		t.Fatalf("encrypt failed: %v", err)
+	}
+	if chunks[0].Text != "secret" || len(chunks[0].Extra) != 1 {
+		t.Fatalf("input chunks must not be modified: %+v", chunks[0])
+	}
+	got := out[0]
+	if !strings.HasPrefix(got.Text, prefix) || strings.Contains(got.Text, "secret") {
+		t.Fatalf("text not encrypted: %q", got.Text)
+	}
+	info, _ := got.Extra["encryption"].(map[string]interface{})
+	if info["key_id"] != "k1" || info["alg"] != Algorithm {
+		t.Fatalf("unexpected encryption metadata: %v", got.Extra)
+	}
+
+	if err := enc.DecryptChunk(&got); err != nil {
+		t.Fatalf("decrypt failed: %v", err)
+	}
+	if got.Text != "secret" || got.OverlapText != "sec" {
+		t.Fatalf("unexpected plaintext: %+v", got)
+	}
+}
+
+func TestDecryptRejectsTampering(t *testing.T) {
+	enc, _ := NewWithKey("k1", testKey)
+	out, _ := enc.EncryptChunks([]chunking.Chunk{{ID: "d#0", Text: "secret"}})
+
+	moved := out[0]
+	moved.ID = "d#1"
+	if err := enc.DecryptChunk(&moved); err == nil {
+		t.Errorf("ciphertext moved to another chunk should not decrypt")
+	}
+
+	other, _ := NewWithKey("k2", bytes.Repeat([]byte{9}, 32))
+	copied := out[0]
+	if err := other.DecryptChunk(&copied); err == nil {
+		t.Errorf("expected key mismatch error")
+	}
+}
+
+func TestNewFromEnv(t *testing.T) {
+	t.Setenv("CHUNK_KEY", base64.StdEncoding.EncodeToString(testKey))
+	if _, err := New(Config{KeyID: "k1", KeyEnv: "CHUNK_KEY"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	t.Setenv("SHORT_KEY", base64.StdEncoding.EncodeToString([]byte("short")))
+	if _, err := New(Config{KeyID: "k1", KeyEnv: "SHORT_KEY"}); err == nil {
+		t.Errorf("expected error for short key")
+	}
+	if _, err := New(Config{KeyEnv: "CHUNK_KEY"}); err == nil {
+		t.Errorf("expected error without key_id")
+	}
+}
@@ -0,0 +1,159 @@
+// Package fieldzip compresses chunk payloads before they are written to a
+// sink. Stored chunk text is highly redundant, not least because of
+// overlap, so Text and OverlapText are compressed with zstd and
+// Extra["compression"] records the algorithm so readers know to expand
+// them again.
+package fieldzip
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/klauspost/compress/zstd"
+
+	"chunker-service/pkg/chunking"
+)
+
+// Algorithm is recorded with every compressed chunk.
+const Algorithm = "zstd"
+
+// prefix marks compressed field values.
+const prefix = "zstd:v1:"
+
+// maxDecodedSize bounds the size of one decompressed value.
+const maxDecodedSize = 64 << 20
+
+// Config selects the compression level, from 1 (fastest) to 22 (smallest)
+// as in the zstd command line; 0 means the zstd default of 3.
+type Config struct {
+	Level int `json:"level,omitempty"`
+}
+
+// Validate rejects levels outside 0-22.
+func (c Config) Validate() error {
+	if c.Level < 0 || c.Level > 22 {
+		return fmt.Errorf("compression: level must be between 1 and 22, got %d", c.Level)
+	}
+	return nil
+}
+
+// Compressor compresses chunk payloads. It is safe for concurrent use.
+type Compressor struct {
+	enc *zstd.Encoder
+}
+
+// New returns a Compressor for cfg.
+func New(cfg Config) (*Compressor, error) {
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	level := zstd.SpeedDefault
+	if cfg.Level > 0 {
+		level = zstd.EncoderLevelFromZstd(cfg.Level)
+	}
+	enc, err := zstd.NewWriter(nil, zstd.WithEncoderLevel(level), zstd.WithEncoderConcurrency(1))
+	if err != nil {
+		return nil, err
+	}
+	return &Compressor{enc: enc}, nil
+}
+
+// CompressChunks returns copies of chunks with Text and OverlapText
+// compressed. A field is left as is when compressing would not shrink it,
+// as for most short texts once base64 encoded. Only chunks with at least
+// one compressed field are marked. Encrypted chunks are rejected: their
+// ciphertext does not compress, so compression must come first. The input
+// is not modified.
+func (c *Compressor) CompressChunks(chunks []chunking.Chunk) ([]chunking.Chunk, error) {
+	out := make([]chunking.Chunk, len(chunks))
+	for i, ch := range chunks {
+		if ch.Extra["encryption"] != nil {
+			return nil, fmt.Errorf("chunk %s is encrypted and cannot be compressed", ch.ID)
+		}
+		if ch.Extra["compression"] != nil {
+			out[i] = ch
+			continue
+		}
+		text, textOK := c.compress(ch.Text)
+		overlap, overlapOK := c.compress(ch.OverlapText)
+		if textOK || overlapOK {
+			ch.Text, ch.OverlapText = text, overlap
+			extra := make(map[string]interface{}, len(ch.Extra)+1)
+			for k, v := range ch.Extra {
+				extra[k] = v
+			}
+			extra["compression"] = Algorithm
+			ch.Extra = extra
+		}
+		out[i] = ch
+	}
+	return out, nil
+}
+
+// compress returns the encoded compressed value, or value and false when
+// that would not be shorter. Values that look compressed already are
+// always compressed, so reading them back is unambiguous.
+func (c *Compressor) compress(value string) (string, bool) {
+	if value == "" {
+		return value, false
+	}
+	compressed := prefix + base64.StdEncoding.EncodeToString(c.enc.EncodeAll([]byte(value), nil))
+	if len(compressed) >= len(value) && !strings.HasPrefix(value, prefix) {
+		return value, false
+	}
+	return compressed, true
+}
+
+// decoder is shared by every reader; DecodeAll is safe for concurrent use.
+var decoder, _ = zstd.NewReader(nil, zstd.WithDecoderConcurrency(0), zstd.WithDecoderMaxMemory(maxDecodedSize))
+
+// Compressed reports whether ch was written by CompressChunks.
+func Compressed(ch chunking.Chunk) bool {
+	return ch.Extra["compression"] != nil
+}
+
+// DecompressChunk restores the text of a chunk written by CompressChunks.
+// Chunks that are not compressed are left unchanged, so readers can call
+// it on every chunk of a sink that was compressed only for part of its
+// life. Chunks that are also encrypted are rejected: sinks compress
+// before they encrypt, so they must be decrypted first.
+func DecompressChunk(ch *chunking.Chunk) error {
+	if !Compressed(*ch) {
+		return nil
+	}
+	if ch.Extra["encryption"] != nil {
+		return fmt.Errorf("chunk %s is encrypted; decrypt it before decompressing", ch.ID)
+	}
+	if alg, _ := ch.Extra["compression"].(string); alg != Algorithm {
+		return fmt.Errorf("chunk %s: unsupported compression %v", ch.ID, ch.Extra["compression"])
+	}
+	var err error
+	if ch.Text, err = decompress(ch.Text); err != nil {
+		return fmt.Errorf("chunk %s: %w", ch.ID, err)
+	}
+	if ch.OverlapText, err = decompress(ch.OverlapText); err != nil {
+		return fmt.Errorf("chunk %s: %w", ch.ID, err)
+	}
+	delete(ch.Extra, "compression")
+	return nil
+}
+
+// decompress expands a compressed value; other values are returned as is,
+// since CompressChunks leaves fields that would not shrink.
+func decompress(value string) (string, error) {
+	encoded, ok := strings.CutPrefix(value, prefix)
+	if !ok {
+		return value, nil
+	}
+	data, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return "", errors.New("compressed value is not base64")
+	}
+	plain, err := decoder.DecodeAll(data, nil)
+	if err != nil {
+		return "", fmt.Errorf("decompress: %w", err)
+	}
+	return string(plain), nil
+}
@@ -0,0 +1,108 @@
+package fieldzip
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"chunker-service/pkg/chunking"
+	"chunker-service/pkg/fieldcrypt"
+)
+
+func TestCompressRoundTrip(t *testing.T) {
+	c, err := New(Config{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	text := strings.Repeat("the quick brown fox jumps over the lazy dog. ", 40)
+	chunks := []chunking.Chunk{
+		{ID: "d#0", Text: text, OverlapText: text[:90], Extra: map[string]interface{}{"a": 1}},
+		{ID: "d#1", Text: "short"},
+	}
+
+	out, err := c.CompressChunks(chunks)
+	if err != nil {
+		t.Fatalf("compress failed: %v", err)
+	}
+	if chunks[0].Text != text || len(chunks[0].Extra) != 1 {
+		t.Fatalf("input chunks must not be modified: %+v", chunks[0])
+	}
+	got := out[0]
+	if !strings.HasPrefix(got.Text, prefix) || len(got.Text) >= len(text)/4 {
+		t.Fatalf("text not compressed: %d bytes", len(got.Text))
+	}
+	if got.Extra["compression"] != Algorithm || got.Extra["a"] != 1 {
+		t.Fatalf("unexpected compression metadata: %v", got.Extra)
+	}
+	if out[1].Text != "short" || Compressed(out[1]) {
+		t.Fatalf("text that does not shrink should be left alone: %+v", out[1])
+	}
+
+	if err := DecompressChunk(&got); err != nil {
+		t.Fatalf("decompress failed: %v", err)
+	}
+	if got.Text != text || got.OverlapText != text[:90] || Compressed(got) {
+		t.Fatalf("unexpected text after decompressing: %+v", got)
+	}
+	plain := out[1]
+	if err := DecompressChunk(&plain); err != nil || plain.Text != "short" {
+		t.Fatalf("uncompressed chunks should pass through: %v %+v", err, plain)
+	}
+}
+
+func TestCompressEdgeCases(t *testing.T) {
+	c, _ := New(Config{Level: 19})
+	out, err := c.CompressChunks([]chunking.Chunk{{ID: "d#0", Text: prefix + "x"}})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	got := out[0]
+	if !Compressed(got) {
+		t.Fatalf("text that looks compressed must be compressed: %+v", got)
+	}
+	if err := DecompressChunk(&got); err != nil || got.Text != prefix+"x" {
+		t.Fatalf("unexpected round trip: %v %+v", err, got)
+	}
+
+	encrypted := chunking.Chunk{ID: "d#0", Text: "enc:v1:abc", Extra: map[string]interface{}{"encryption": map[string]interface{}{}}}
+	if _, err := c.CompressChunks([]chunking.Chunk{encrypted}); err == nil {
+		t.Errorf("expected encrypted chunks to be rejected")
+	}
+	corrupt := chunking.Chunk{ID: "d#0", Text: prefix + "AAAA", Extra: map[string]interface{}{"compression": Algorithm}}
+	if err := DecompressChunk(&corrupt); err == nil {
+		t.Errorf("expected corrupt data to fail")
+	}
+	if _, err := New(Config{Level: 23}); err == nil {
+		t.Errorf("expected level 23 to be rejected")
+	}
+}
+
+func TestDecompressEncrypted(t *testing.T) {
+	c, _ := New(Config{})
+	enc, err := fieldcrypt.NewWithKey("k1", bytes.Repeat([]byte{7}, 32))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	text := strings.Repeat("the quick brown fox jumps over the lazy dog. ", 40)
+	out, err := c.CompressChunks([]chunking.Chunk{{ID: "d#0", Text: text}})
+	if err != nil {
+		t.Fatalf("compress failed: %v", err)
+	}
+	if out, err = enc.EncryptChunks(out); err != nil {
+		t.Fatalf("encrypt failed: %v", err)
+	}
+
+	got := out[0]
+	if err := DecompressChunk(&got); err == nil {
+		t.Fatal("expected an encrypted chunk to be rejected")
+	}
+	if !Compressed(got) || got.Text != out[0].Text {
+		t.Fatalf("a rejected chunk must keep its compression marker: %+v", got)
+	}
+	if err := enc.DecryptChunk(&got); err != nil {
+		t.Fatalf("decrypt failed: %v", err)
+	}
+	if err := DecompressChunk(&got); err != nil || got.Text != text {
+		t.Fatalf("expected decrypt then decompress to restore the text: %v", err)
+	}
+}
@@ -0,0 +1,180 @@
+// Package htmlview renders documents as HTML pages with their chunk
+// boundaries, headings and overlaps marked, so content owners can review
+// how their documents were segmented without reading JSON.
+package htmlview
+
+import (
+	"bufio"
+	"fmt"
+	"html"
+	"io"
+	"sort"
+	"strings"
+	"unicode/utf8"
+
+	"chunker-service/pkg/chunking"
+)
+
+// Document is one document to render: its original text, the chunks cut
+// from it and, optionally, its detected headings.
+type Document struct {
+	Name     string
+	Text     string
+	Chunks   []chunking.Chunk
+	Headings []chunking.HeadingDecision
+}
+
+const style = `body{font-family:sans-serif;margin:2em;color:#222}
+table{border-collapse:collapse;margin:1em 0}td,th{border:1px solid #ccc;padding:2px 6px;text-align:left;font-size:90%}
+.text{white-space:pre-wrap;font-family:monospace;line-height:1.6;border:1px solid #ccc;padding:1em}
+.c0{background:#dbeafe}.c1{background:#dcfce7}.c2{background:#fef3c7}.c3{background:#fce7f3}
+.overlap{background:repeating-linear-gradient(45deg,#fde68a,#fde68a 4px,#fca5a5 4px,#fca5a5 8px)}
+.gap{color:#999}.heading{font-weight:bold;text-decoration:underline}
+.start{font-family:sans-serif;font-size:75%;color:#fff;background:#1e3a8a;border-radius:3px;padding:0 4px;margin-right:2px}
+.legend span{padding:0 6px;margin-right:6px}`
+
+// Write renders docs as a single HTML page. Text is shaded by chunk,
+// striped where consecutive chunks overlap and grey where no chunk covers
+// it; each chunk starts with a label carrying its index and ID, which is
+// also the label's anchor. Headings are underlined. Child and image
+// chunks are listed in a chunk's row but not drawn, as they repeat their
+// parent's text.
+func Write(w io.Writer, docs []Document) error {
+	bw := bufio.NewWriter(w)
+	fmt.Fprintf(bw, "<!DOCTYPE html>\n<html><head><meta charset=\"utf-8\"><title>Chunk boundaries</title>\n<style>%s</style></head><body>\n", style)
+	fmt.Fprint(bw, `<p class="legend"><span class="c0">chunk</span><span class="c1">next chunk</span><span class="overlap">overlap</span><span class="gap">not in any chunk</span><span class="heading">heading</span></p>`+"\n")
+	for _, doc := range docs {
+		writeDocument(bw, doc)
+	}
+	fmt.Fprint(bw, "</body></html>\n")
+	return bw.Flush()
+}
+
+// drawn reports whether ch is drawn over the text: top-level text chunks
+// only.
+func drawn(ch chunking.Chunk) bool {
+	return ch.ParentID == "" && ch.Extra["kind"] != "image_context"
+}
+
+func writeDocument(w *bufio.Writer, doc Document) {
+	text := doc.Text
+	var top []chunking.Chunk
+	children := map[string]int{}
+	for _, ch := range doc.Chunks {
+		if drawn(ch) {
+			top = append(top, ch)
+		} else if ch.ParentID != "" {
+			children[ch.ParentID]++
+		}
+	}
+
+	fmt.Fprintf(w, "<h2>%s</h2>\n<p>%d chunks, %d bytes</p>\n", html.EscapeString(doc.Name), len(doc.Chunks), len(text))
+	fmt.Fprint(w, "<table><tr><th>#</th><th>ID</th><th>Bytes</th><th>Section</th><th>Title</th><th>Children</th></tr>\n")
+	for i, ch := range top {
+		fmt.Fprintf(w, "<tr><td>%d</td><td><a href=\"#%s\">%s</a></td><td>%d-%d</td><td>%s</td><td>%s</td><td>%d</td></tr>\n",
+			i, anchor(doc.Name, ch.ID), html.EscapeString(ch.ID), ch.ByteStart, ch.ByteEnd,
+			html.EscapeString(ch.Section), html.EscapeString(ch.ChunkTitle), children[ch.ID])
+	}
+	fmt.Fprint(w, "</table>\n<div class=\"text\">")
+
+	headings := headingSpans(text, doc.Headings)
+	points := []int{0, len(text)}
+	for _, ch := range top {
+		points = append(points, clamp(text, ch.ByteStart), clamp(text, ch.ByteEnd))
+	}
+	for _, h := range headings {
+		points = append(points, h[0], h[1])
+	}
+	sort.Ints(points)
+
+	// Sweep the segments between boundaries, keeping the chunks covering
+	// the current one in active.
+	var active []int
+	next, heading := 0, 0
+	for p := 0; p+1 < len(points); p++ {
+		a, b := points[p], points[p+1]
+		if a == b {
+			continue
+		}
+		kept := active[:0]
+		for _, i := range active {
+			if clamp(text, top[i].ByteEnd) > a {
+				kept = append(kept, i)
+			}
+		}
+		active = kept
+		for next < len(top) && clamp(text, top[next].ByteStart) <= a {
+			if clamp(text, top[next].ByteEnd) > a {
+				fmt.Fprintf(w, `<span class="start" id="%s">#%d %s</span>`, anchor(doc.Name, top[next].ID), next, html.EscapeString(top[next].ID))
+				active = append(active, next)
+			}
+			next++
+		}
+		for heading < len(headings) && headings[heading][1] <= a {
+			heading++
+		}
+
+		var class string
+		var ids []string
+		switch len(active) {
+		case 0:
+			class = "gap"
+		case 1:
+			class = fmt.Sprintf("c%d", active[0]%4)
+		default:
+			class = "overlap"
+		}
+		for _, i := range active {
+			ids = append(ids, top[i].ID)
+		}
+		if heading < len(headings) && headings[heading][0] <= a {
+			class += " heading"
+		}
+		fmt.Fprintf(w, `<span class="%s" title="%s">%s</span>`, class, html.EscapeString(strings.Join(ids, ", ")), html.EscapeString(text[a:b]))
+	}
+	fmt.Fprint(w, "</div>\n")
+}
+
+// headingSpans returns the byte range of each heading line, in order.
+func headingSpans(text string, headings []chunking.HeadingDecision) [][2]int {
+	lines := map[int]bool{}
+	for _, h := range headings {
+		lines[h.Line] = true
+	}
+	var spans [][2]int
+	start := 0
+	for i := 0; start <= len(text); i++ {
+		end := strings.IndexByte(text[start:], '\n')
+		if end < 0 {
+			end = len(text)
+		} else {
+			end += start
+		}
+		if lines[i] {
+			spans = append(spans, [2]int{start, end})
+		}
+		start = end + 1
+	}
+	return spans
+}
+
+// clamp moves a byte offset within text and back to the start of a
+// character, since bytes mode windows may split one.
+func clamp(text string, i int) int {
+	if i < 0 {
+		return 0
+	}
+	if i >= len(text) {
+		return len(text)
+	}
+	for i > 0 && !utf8.RuneStart(text[i]) {
+		i--
+	}
+	return i
+}
+
+// anchor is the element ID of a chunk's label, unique across documents
+// that share chunk IDs.
+func anchor(doc, id string) string {
+	return html.EscapeString(doc + "/" + id)
+}
@@ -0,0 +1,41 @@
+package htmlview
+
+import (
+	"strings"
+	"testing"
+
+	"chunker-service/pkg/chunking"
+)
+
+func TestWrite(t *testing.T) {
+	text := "# Intro\nalpha <b> beta\ngamma\n"
+	chunks := []chunking.Chunk{
+		{ID: "d#0", ByteStart: 0, ByteEnd: 23, Section: "Intro"},
+		{ID: "d#1", ByteStart: 18, ByteEnd: 28},
+		{ID: "d#1.0", ParentID: "d#1", ByteStart: 18, ByteEnd: 23},
+	}
+	headings, err := chunking.DetectHeadings(text, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var b strings.Builder
+	if err := Write(&b, []Document{{Name: "a.md", Text: text, Chunks: chunks, Headings: headings}}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	out := b.String()
+	for _, want := range []string{
+		`<span class="start" id="a.md/d#0">#0 d#0</span><span class="c0 heading" title="d#0"># Intro</span>`,
+		`<span class="c0" title="d#0">` + "\nalpha &lt;b&gt; </span>",
+		`<span class="start" id="a.md/d#1">#1 d#1</span><span class="overlap" title="d#0, d#1">beta
+</span>`,
+		`<span class="c1" title="d#1">gamma</span><span class="gap" title="">` + "\n</span>",
+		`<td>1</td><td><a href="#a.md/d#1">d#1</a></td><td>18-28</td><td></td><td></td><td>1</td>`,
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("expected %q in:\n%s", want, out)
+		}
+	}
+	if strings.Contains(out, `id="a.md/d#1.0"`) {
+		t.Errorf("child chunks should not be drawn:\n%s", out)
+	}
+}
@@ -0,0 +1,96 @@
+package jobs
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Callback delivery states.
+const (
+	CallbackPending   = "pending"
+	CallbackDelivered = "delivered"
+	CallbackFailed    = "failed"
+)
+
+// Headers of a callback request. The signature is "sha256=" followed by
+// the hex HMAC-SHA256 of the timestamp, a dot and the body.
+const (
+	SignatureHeader = "X-Chunker-Signature"
+	TimestampHeader = "X-Chunker-Timestamp"
+)
+
+// Callback is a URL notified once when its job finishes, and how that
+// delivery went.
+type Callback struct {
+	URL string `json:"url"`
+	// IncludeResult puts the chunks in the notification; otherwise it
+	// only points at the job.
+	IncludeResult bool   `json:"include_result,omitempty"`
+	Status        string `json:"status,omitempty"`
+	Attempts      int    `json:"attempts,omitempty"`
+	Error         string `json:"error,omitempty"`
+}
+
+// Event is the body of a callback request.
+type Event struct {
+	JobID      string          `json:"job_id"`
+	Status     string          `json:"status"`
+	FinishedAt *time.Time      `json:"finished_at,omitempty"`
+	Error      string          `json:"error,omitempty"`
+	Code       string          `json:"code,omitempty"`
+	ResultURL  string          `json:"result_url"`
+	Result     json.RawMessage `json:"result,omitempty"`
+}
+
+// NewEvent describes a finished job. resultURL is where the job can be
+// fetched.
+func NewEvent(job Job, resultURL string) Event {
+	ev := Event{
+		JobID:      job.ID,
+		Status:     job.Status,
+		FinishedAt: job.FinishedAt,
+		Error:      job.Error,
+		Code:       job.Code,
+		ResultURL:  resultURL,
+	}
+	if job.Callback != nil && job.Callback.IncludeResult {
+		ev.Result = job.Result
+	}
+	return ev
+}
+
+// Sign returns the signature header value for body sent at timestamp.
+func Sign(secret []byte, timestamp time.Time, body []byte) string {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(strconv.FormatInt(timestamp.Unix(), 10)))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// ErrBadSignature is returned by Verify for forged, altered or stale
+// callbacks.
+var ErrBadSignature = errors.New("invalid callback signature")
+
+// Verify checks a received callback's signature and timestamp headers,
+// rejecting timestamps more than maxAge away from now to limit replays.
+func Verify(secret []byte, signature, timestamp string, body []byte, now time.Time, maxAge time.Duration) error {
+	unix, err := strconv.ParseInt(strings.TrimSpace(timestamp), 10, 64)
+	if err != nil {
+		return ErrBadSignature
+	}
+	sent := time.Unix(unix, 0)
+	if d := now.Sub(sent); d > maxAge || d < -maxAge {
+		return ErrBadSignature
+	}
+	if !hmac.Equal([]byte(signature), []byte(Sign(secret, sent, body))) {
+		return ErrBadSignature
+	}
+	return nil
+}
@@ -0,0 +1,44 @@
+package jobs
+
+import (
+	"encoding/json"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestSignAndVerify(t *testing.T) {
+	secret := []byte("s3cret")
+	sent := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	body := []byte(`{"job_id":"abc","status":"succeeded"}`)
+	sig := Sign(secret, sent, body)
+	ts := strconv.FormatInt(sent.Unix(), 10)
+
+	if err := Verify(secret, sig, ts, body, sent.Add(time.Minute), 5*time.Minute); err != nil {
+		t.Fatalf("expected valid signature, got %v", err)
+	}
+	checks := map[string]error{
+		"tampered body": Verify(secret, sig, ts, []byte(`{"job_id":"abc","status":"failed"}`), sent, 5*time.Minute),
+		"wrong secret":  Verify([]byte("other"), sig, ts, body, sent, 5*time.Minute),
+		"moved time":    Verify(secret, sig, strconv.FormatInt(sent.Unix()+1, 10), body, sent, 5*time.Minute),
+		"stale":         Verify(secret, sig, ts, body, sent.Add(time.Hour), 5*time.Minute),
+		"bad timestamp": Verify(secret, sig, "yesterday", body, sent, 5*time.Minute),
+	}
+	for name, err := range checks {
+		if err != ErrBadSignature {
+			t.Errorf("%s: expected ErrBadSignature, got %v", name, err)
+		}
+	}
+}
+
+func TestNewEventIncludesResultOnRequest(t *testing.T) {
+	job := Job{ID: "abc", Status: StatusSucceeded, Result: json.RawMessage(`[{"text":"hi"}]`),
+		Callback: &Callback{URL: "https://example.com/hook"}}
+	if ev := NewEvent(job, "/jobs/abc"); ev.Result != nil || ev.ResultURL != "/jobs/abc" {
+		t.Errorf("expected a pointer-only event, got %+v", ev)
+	}
+	job.Callback.IncludeResult = true
+	if ev := NewEvent(job, "/jobs/abc"); string(ev.Result) != `[{"text":"hi"}]` {
+		t.Errorf("expected the result in the event, got %s", ev.Result)
+	}
+}
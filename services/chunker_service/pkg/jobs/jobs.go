@@ -0,0 +1,69 @@
+// Package jobs stores the state of asynchronous chunking jobs so callers
+// can submit a large document and poll for the result instead of holding
+// a connection open while it is chunked.
+package jobs
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"time"
+)
+
+// Job states. A job moves from queued to running to exactly one of
+// succeeded or failed.
+const (
+	StatusQueued    = "queued"
+	StatusRunning   = "running"
+	StatusSucceeded = "succeeded"
+	StatusFailed    = "failed"
+)
+
+// ErrNotFound is returned for unknown and expired jobs.
+var ErrNotFound = errors.New("job not found")
+
+// Job is the state of one submitted job. Result holds the job's output,
+// already encoded, once it has succeeded.
+type Job struct {
+	ID         string          `json:"id"`
+	Status     string          `json:"status"`
+	CreatedAt  time.Time       `json:"created_at"`
+	StartedAt  *time.Time      `json:"started_at,omitempty"`
+	FinishedAt *time.Time      `json:"finished_at,omitempty"`
+	Error      string          `json:"error,omitempty"`
+	Code       string          `json:"code,omitempty"`
+	Result     json.RawMessage `json:"result,omitempty"`
+	Callback   *Callback       `json:"callback,omitempty"`
+	// Owner is the subject of the bearer token that submitted the job,
+	// empty when the server runs without auth. Only the owner may read
+	// the job.
+	Owner string `json:"owner,omitempty"`
+	// Tenant is the tenant the job is scheduled under; see Scheduler.
+	Tenant string `json:"tenant,omitempty"`
+}
+
+// Done reports whether the job has finished, successfully or not.
+func (j Job) Done() bool {
+	return j.Status == StatusSucceeded || j.Status == StatusFailed
+}
+
+// Store keeps jobs for a limited time after they were last written.
+// Implementations must be safe for concurrent use.
+type Store interface {
+	// Put creates or replaces a job.
+	Put(ctx context.Context, job Job) error
+	// Get returns a job, or ErrNotFound.
+	Get(ctx context.Context, id string) (Job, error)
+}
+
+// NewID returns a random job ID. IDs are the only handle on a job's
+// result, so they must not be guessable.
+func NewID() string {
+	var b [16]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		panic(err)
+	}
+	return hex.EncodeToString(b[:])
+}
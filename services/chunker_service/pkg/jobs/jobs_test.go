@@ -0,0 +1,176 @@
+package jobs
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestMemoryExpiresJobs(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	m := NewMemory(time.Hour)
+	m.Now = func() time.Time { return now }
+
+	job := Job{ID: NewID(), Status: StatusQueued, CreatedAt: now}
+	if err := m.Put(ctx, job); err != nil {
+		t.Fatalf("put failed: %v", err)
+	}
+	now = now.Add(50 * time.Minute)
+	job.Status = StatusSucceeded
+	m.Put(ctx, job)
+
+	// The TTL restarts on every write.
+	now = now.Add(50 * time.Minute)
+	got, err := m.Get(ctx, job.ID)
+	if err != nil || got.Status != StatusSucceeded || !got.Done() {
+		t.Fatalf("expected the succeeded job, got %+v, %v", got, err)
+	}
+	now = now.Add(10 * time.Minute)
+	if _, err := m.Get(ctx, job.ID); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("expected ErrNotFound after the ttl, got %v", err)
+	}
+	m.Put(ctx, Job{ID: "other"})
+	if m.Len() != 1 {
+		t.Errorf("expired jobs should be swept on put, have %d", m.Len())
+	}
+}
+
+// fakeRedis serves AUTH, SELECT, PING, SET (with PX) and GET, enough to
+// exercise the client.
+type fakeRedis struct {
+	mu       sync.Mutex
+	data     map[string]string
+	ttls     map[string]string
+	password string
+	db       string
+}
+
+func (f *fakeRedis) serve(ln net.Listener) {
+	for {
+		conn, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		go func() {
+			defer conn.Close()
+			rd := bufio.NewReader(conn)
+			authed := f.password == ""
+			for {
+				reply, err := readReply(rd)
+				if err != nil {
+					return
+				}
+				var args []string
+				for _, a := range reply.([]interface{}) {
+					args = append(args, string(a.([]byte)))
+				}
+				fmt.Fprint(conn, f.handle(args, &authed))
+			}
+		}()
+	}
+}
+
+func (f *fakeRedis) handle(args []string, authed *bool) string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	switch {
+	case args[0] == "AUTH":
+		if args[len(args)-1] != f.password {
+			return "-WRONGPASS invalid password\r\n"
+		}
+		*authed = true
+		return "+OK\r\n"
+	case !*authed:
+		return "-NOAUTH Authentication required.\r\n"
+	case args[0] == "SELECT":
+		f.db = args[1]
+		return "+OK\r\n"
+	case args[0] == "PING":
+		return "+PONG\r\n"
+	case args[0] == "SET":
+		f.data[args[1]] = args[2]
+		if len(args) == 5 {
+			f.ttls[args[1]] = args[4]
+		}
+		return "+OK\r\n"
+	case args[0] == "GET":
+		v, ok := f.data[args[1]]
+		if !ok {
+			return "$-1\r\n"
+		}
+		return fmt.Sprintf("$%d\r\n%s\r\n", len(v), v)
+	}
+	return "-ERR unknown command\r\n"
+}
+
+func TestRedisStore(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+	fake := &fakeRedis{data: map[string]string{}, ttls: map[string]string{}, password: "s3cret"}
+	go fake.serve(ln)
+
+	ctx := context.Background()
+	r, err := NewRedis("redis://:s3cret@"+ln.Addr().String()+"/2", 90*time.Second)
+	if err != nil {
+		t.Fatalf("NewRedis failed: %v", err)
+	}
+	defer r.Close()
+	if err := r.Ping(ctx); err != nil {
+		t.Fatalf("ping failed: %v", err)
+	}
+
+	job := Job{ID: NewID(), Status: StatusSucceeded, Result: []byte(`[{"id":"a#0","text":"line\r\nbreak"}]`)}
+	if err := r.Put(ctx, job); err != nil {
+		t.Fatalf("put failed: %v", err)
+	}
+	got, err := r.Get(ctx, job.ID)
+	if err != nil || string(got.Result) != string(job.Result) {
+		t.Fatalf("expected the stored job, got %+v, %v", got, err)
+	}
+	if _, err := r.Get(ctx, "missing"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("expected ErrNotFound, got %v", err)
+	}
+	// Other values share the client under a prefix of their own.
+	r.Prefix = "chunker:embed:"
+	if err := r.Store(ctx, "v", []byte{0, 1}); err != nil {
+		t.Fatalf("store failed: %v", err)
+	}
+	if v, err := r.Load(ctx, "v"); err != nil || string(v) != "\x00\x01" || fake.data["chunker:embed:v"] != "\x00\x01" {
+		t.Errorf("expected the stored value under its prefix, got %q, %v", v, err)
+	}
+	if v, err := r.Load(ctx, "missing"); v != nil || err != nil {
+		t.Errorf("expected no value, got %q, %v", v, err)
+	}
+	r.Prefix = "chunker:job:"
+	key := "chunker:job:" + job.ID
+	if fake.ttls[key] != "90000" || fake.db != "2" {
+		t.Errorf("expected PX 90000 in db 2, got ttl %q db %q", fake.ttls[key], fake.db)
+	}
+
+	bad, _ := NewRedis("redis://:wrong@"+ln.Addr().String(), 0)
+	var rerr redisError
+	if err := bad.Ping(ctx); !errors.As(err, &rerr) {
+		t.Errorf("expected an auth error, got %v", err)
+	}
+}
+
+func TestNewRedisRejectsBadURLs(t *testing.T) {
+	for _, u := range []string{"http://localhost", "redis://localhost/x", "redis://localhost/-1"} {
+		if _, err := NewRedis(u, 0); err == nil {
+			t.Errorf("expected an error for %q", u)
+		}
+	}
+	r, err := NewRedis("rediss://cache.internal", 0)
+	if err != nil || r.addr != "cache.internal:6379" || r.tls == nil {
+		t.Errorf("expected default port and tls, got %+v, %v", r, err)
+	}
+}
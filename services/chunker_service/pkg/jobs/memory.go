@@ -0,0 +1,71 @@
+package jobs
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// Memory is a Store for a single replica. Jobs are lost on restart.
+type Memory struct {
+	ttl time.Duration
+	// Now returns the current time; tests override it.
+	Now func() time.Time
+
+	mu   sync.Mutex
+	jobs map[string]memoryEntry
+}
+
+type memoryEntry struct {
+	job     Job
+	expires time.Time
+}
+
+// NewMemory returns a store that forgets jobs ttl after they were last
+// written; ttl <= 0 keeps them until restart.
+func NewMemory(ttl time.Duration) *Memory {
+	return &Memory{ttl: ttl, Now: time.Now, jobs: make(map[string]memoryEntry)}
+}
+
+func (m *Memory) Put(_ context.Context, job Job) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	now := m.Now()
+	m.sweep(now)
+	var expires time.Time
+	if m.ttl > 0 {
+		expires = now.Add(m.ttl)
+	}
+	m.jobs[job.ID] = memoryEntry{job: job, expires: expires}
+	return nil
+}
+
+func (m *Memory) Get(_ context.Context, id string) (Job, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	e, ok := m.jobs[id]
+	if !ok || e.expired(m.Now()) {
+		return Job{}, ErrNotFound
+	}
+	return e.job, nil
+}
+
+// Len returns the number of stored jobs, expired ones included until the
+// next Put sweeps them.
+func (m *Memory) Len() int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return len(m.jobs)
+}
+
+func (m *Memory) sweep(now time.Time) {
+	for id, e := range m.jobs {
+		if e.expired(now) {
+			delete(m.jobs, id)
+		}
+	}
+}
+
+func (e memoryEntry) expired(now time.Time) bool {
+	return !e.expires.IsZero() && !now.Before(e.expires)
+}
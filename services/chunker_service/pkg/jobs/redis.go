@@ -0,0 +1,275 @@
+package jobs
+
+import (
+	"bufio"
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Redis is a Store shared by every replica, so a job can be polled from
+// any of them. Each job is one key holding its JSON, expiring ttl after
+// it was last written.
+//
+// It speaks just enough of the Redis protocol (RESP2) for SET and GET.
+type Redis struct {
+	// Prefix is prepended to job IDs to form keys.
+	Prefix string
+	// Timeout bounds each command when the context has no deadline.
+	Timeout time.Duration
+
+	addr     string
+	tls      *tls.Config
+	username string
+	password string
+	db       int
+	ttl      time.Duration
+	idle     chan *redisConn
+}
+
+// NewRedis returns a store for redis://[user:password@]host:port[/db],
+// or rediss:// for TLS. It does not connect until first used; call Ping
+// to check the server.
+func NewRedis(rawURL string, ttl time.Duration) (*Redis, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid redis url: %w", err)
+	}
+	r := &Redis{
+		Prefix:  "chunker:job:",
+		Timeout: 5 * time.Second,
+		addr:    u.Host,
+		ttl:     ttl,
+		idle:    make(chan *redisConn, 8),
+	}
+	switch u.Scheme {
+	case "redis":
+	case "rediss":
+		r.tls = &tls.Config{ServerName: u.Hostname(), MinVersion: tls.VersionTLS12}
+	default:
+		return nil, fmt.Errorf("redis url scheme must be redis or rediss, got %q", u.Scheme)
+	}
+	if u.Port() == "" {
+		r.addr = net.JoinHostPort(u.Hostname(), "6379")
+	}
+	if u.User != nil {
+		r.username = u.User.Username()
+		r.password, _ = u.User.Password()
+	}
+	if db := strings.TrimPrefix(u.Path, "/"); db != "" {
+		if r.db, err = strconv.Atoi(db); err != nil || r.db < 0 {
+			return nil, fmt.Errorf("invalid redis database %q", db)
+		}
+	}
+	return r, nil
+}
+
+func (r *Redis) Put(ctx context.Context, job Job) error {
+	data, err := json.Marshal(job)
+	if err != nil {
+		return err
+	}
+	return r.Store(ctx, job.ID, data)
+}
+
+func (r *Redis) Get(ctx context.Context, id string) (Job, error) {
+	data, err := r.Load(ctx, id)
+	if err != nil {
+		return Job{}, err
+	}
+	if data == nil {
+		return Job{}, ErrNotFound
+	}
+	var job Job
+	if err := json.Unmarshal(data, &job); err != nil {
+		return Job{}, fmt.Errorf("invalid job %s in redis: %w", id, err)
+	}
+	return job, nil
+}
+
+// Store sets Prefix+key to value, expiring ttl later. It lets other
+// packages keep their own values, under their own Prefix, on the same
+// client.
+func (r *Redis) Store(ctx context.Context, key string, value []byte) error {
+	args := []string{"SET", r.Prefix + key, string(value)}
+	if r.ttl > 0 {
+		args = append(args, "PX", strconv.FormatInt(r.ttl.Milliseconds(), 10))
+	}
+	_, err := r.do(ctx, args...)
+	return err
+}
+
+// Load returns the value of Prefix+key, or nil if there is none.
+func (r *Redis) Load(ctx context.Context, key string) ([]byte, error) {
+	reply, err := r.do(ctx, "GET", r.Prefix+key)
+	if err != nil {
+		return nil, err
+	}
+	data, _ := reply.([]byte)
+	return data, nil
+}
+
+// Ping checks that the server is reachable and accepts the credentials.
+func (r *Redis) Ping(ctx context.Context) error {
+	_, err := r.do(ctx, "PING")
+	return err
+}
+
+// Close closes idle connections.
+func (r *Redis) Close() error {
+	for {
+		select {
+		case c := <-r.idle:
+			c.conn.Close()
+		default:
+			return nil
+		}
+	}
+}
+
+// redisError is an error reply from the server.
+type redisError string
+
+func (e redisError) Error() string { return "redis: " + string(e) }
+
+type redisConn struct {
+	conn net.Conn
+	rd   *bufio.Reader
+}
+
+// do runs one command. Connections are reused unless the command failed
+// on the wire, which leaves the stream in an unknown state.
+func (r *Redis) do(ctx context.Context, args ...string) (interface{}, error) {
+	c, err := r.get(ctx)
+	if err != nil {
+		return nil, err
+	}
+	reply, err := c.run(ctx, r.Timeout, args)
+	var rerr redisError
+	if err != nil && !errors.As(err, &rerr) {
+		c.conn.Close()
+		return nil, err
+	}
+	select {
+	case r.idle <- c:
+	default:
+		c.conn.Close()
+	}
+	return reply, err
+}
+
+func (r *Redis) get(ctx context.Context) (*redisConn, error) {
+	select {
+	case c := <-r.idle:
+		return c, nil
+	default:
+	}
+	d := net.Dialer{Timeout: r.Timeout}
+	conn, err := d.DialContext(ctx, "tcp", r.addr)
+	if err != nil {
+		return nil, err
+	}
+	if r.tls != nil {
+		tc := tls.Client(conn, r.tls)
+		if err := tc.HandshakeContext(ctx); err != nil {
+			conn.Close()
+			return nil, err
+		}
+		conn = tc
+	}
+	c := &redisConn{conn: conn, rd: bufio.NewReader(conn)}
+	if r.password != "" {
+		auth := []string{"AUTH", r.password}
+		if r.username != "" {
+			auth = []string{"AUTH", r.username, r.password}
+		}
+		if _, err := c.run(ctx, r.Timeout, auth); err != nil {
+			conn.Close()
+			return nil, err
+		}
+	}
+	if r.db != 0 {
+		if _, err := c.run(ctx, r.Timeout, []string{"SELECT", strconv.Itoa(r.db)}); err != nil {
+			conn.Close()
+			return nil, err
+		}
+	}
+	return c, nil
+}
+
+func (c *redisConn) run(ctx context.Context, timeout time.Duration, args []string) (interface{}, error) {
+	deadline, ok := ctx.Deadline()
+	if !ok {
+		deadline = time.Now().Add(timeout)
+	}
+	if err := c.conn.SetDeadline(deadline); err != nil {
+		return nil, err
+	}
+	var b strings.Builder
+	fmt.Fprintf(&b, "*%d\r\n", len(args))
+	for _, a := range args {
+		fmt.Fprintf(&b, "$%d\r\n%s\r\n", len(a), a)
+	}
+	if _, err := io.WriteString(c.conn, b.String()); err != nil {
+		return nil, err
+	}
+	return readReply(c.rd)
+}
+
+// readReply decodes one reply: a string, int64, []byte, nil or
+// []interface{}, or a redisError.
+func readReply(rd *bufio.Reader) (interface{}, error) {
+	line, err := rd.ReadString('\n')
+	if err != nil {
+		return nil, err
+	}
+	if len(line) < 3 || line[len(line)-2] != '\r' {
+		return nil, fmt.Errorf("redis: malformed reply %q", line)
+	}
+	kind, body := line[0], line[1:len(line)-2]
+	switch kind {
+	case '+':
+		return body, nil
+	case '-':
+		return nil, redisError(body)
+	case ':':
+		return strconv.ParseInt(body, 10, 64)
+	case '$':
+		n, err := strconv.Atoi(body)
+		if err != nil || n < -1 {
+			return nil, fmt.Errorf("redis: malformed bulk length %q", body)
+		}
+		if n == -1 {
+			return nil, nil
+		}
+		buf := make([]byte, n+2)
+		if _, err := io.ReadFull(rd, buf); err != nil {
+			return nil, err
+		}
+		return buf[:n], nil
+	case '*':
+		n, err := strconv.Atoi(body)
+		if err != nil || n < -1 {
+			return nil, fmt.Errorf("redis: malformed array length %q", body)
+		}
+		if n == -1 {
+			return nil, nil
+		}
+		items := make([]interface{}, n)
+		for i := range items {
+			if items[i], err = readReply(rd); err != nil {
+				return nil, err
+			}
+		}
+		return items, nil
+	}
+	return nil, fmt.Errorf("redis: unknown reply type %q", kind)
+}
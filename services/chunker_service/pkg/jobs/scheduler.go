@@ -0,0 +1,142 @@
+package jobs
+
+import (
+	"context"
+	"sync"
+)
+
+// Scheduler hands a fixed number of worker slots to waiting jobs, fairly
+// across tenants, so one tenant's backfill of thousands of jobs cannot
+// starve the others. Each tenant may run at most PerTenant jobs at once,
+// and free slots go to the waiting tenant that has been served least
+// relative to its weight (stride scheduling). Jobs of one tenant start in
+// the order they were submitted.
+type Scheduler struct {
+	mu        sync.Mutex
+	workers   int
+	free      int
+	perTenant int
+	weights   map[string]int
+	tenants   map[string]*tenantQueue
+	// pass is the virtual time: the pass of the tenant served last. A
+	// tenant whose queue was empty catches up to it, so idling builds up
+	// no credit to burst with later.
+	pass float64
+}
+
+type tenantQueue struct {
+	waiting []*waiter
+	running int
+	pass    float64
+	stride  float64
+}
+
+type waiter struct {
+	ready   chan struct{}
+	granted bool
+}
+
+// NewScheduler returns a Scheduler with workers slots. perTenant <= 0
+// lets a tenant use every slot; weights, by tenant, default to 1, and a
+// tenant of weight 2 gets twice the slots of one of weight 1 while both
+// have jobs waiting.
+func NewScheduler(workers, perTenant int, weights map[string]int) *Scheduler {
+	return &Scheduler{workers: workers, free: workers, perTenant: perTenant, weights: weights, tenants: map[string]*tenantQueue{}}
+}
+
+// Acquire waits for a slot for one of tenant's jobs and returns the
+// function that gives it back. It returns ctx's error, holding no slot,
+// if ctx ends first.
+func (s *Scheduler) Acquire(ctx context.Context, tenant string) (release func(), err error) {
+	w := &waiter{ready: make(chan struct{})}
+	s.mu.Lock()
+	t := s.tenants[tenant]
+	if t == nil {
+		weight := s.weights[tenant]
+		if weight < 1 {
+			weight = 1
+		}
+		t = &tenantQueue{stride: 1 / float64(weight)}
+		s.tenants[tenant] = t
+	}
+	if len(t.waiting) == 0 && t.pass < s.pass {
+		t.pass = s.pass
+	}
+	t.waiting = append(t.waiting, w)
+	s.dispatch()
+	s.mu.Unlock()
+
+	release = func() { s.release(tenant) }
+	select {
+	case <-w.ready:
+		return release, nil
+	case <-ctx.Done():
+	}
+	s.mu.Lock()
+	if w.granted {
+		s.mu.Unlock()
+		release()
+		return nil, ctx.Err()
+	}
+	for i, other := range t.waiting {
+		if other == w {
+			t.waiting = append(t.waiting[:i], t.waiting[i+1:]...)
+			break
+		}
+	}
+	s.forget(tenant, t)
+	s.mu.Unlock()
+	return nil, ctx.Err()
+}
+
+// Running returns the number of jobs holding a slot.
+func (s *Scheduler) Running() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.workers - s.free
+}
+
+func (s *Scheduler) release(tenant string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	t := s.tenants[tenant]
+	t.running--
+	s.free++
+	s.forget(tenant, t)
+	s.dispatch()
+}
+
+// forget drops a tenant with nothing running or waiting.
+func (s *Scheduler) forget(name string, t *tenantQueue) {
+	if t.running == 0 && len(t.waiting) == 0 {
+		delete(s.tenants, name)
+	}
+}
+
+// dispatch hands free slots to waiting jobs, each time to the eligible
+// tenant with the lowest pass, ties going to the lexically first tenant.
+func (s *Scheduler) dispatch() {
+	for s.free > 0 {
+		var next string
+		var best *tenantQueue
+		for name, t := range s.tenants {
+			if len(t.waiting) == 0 || (s.perTenant > 0 && t.running >= s.perTenant) {
+				continue
+			}
+			if best == nil || t.pass < best.pass || (t.pass == best.pass && name < next) {
+				next, best = name, t
+			}
+		}
+		if best == nil {
+			return
+		}
+		w := best.waiting[0]
+		best.waiting = best.waiting[1:]
+		best.running++
+		s.free--
+		s.pass = best.pass
+		best.pass += best.stride
+		w.granted = true
+		close(w.ready)
+	}
+}
@@ -0,0 +1,109 @@
+package jobs
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+type grant struct {
+	tenant  string
+	release func()
+}
+
+// serve queues jobs[i] jobs for tenants[i] on a single-slot scheduler,
+// all of them before the slot frees up, and returns the tenants in the
+// order they were served.
+func serve(t *testing.T, s *Scheduler, tenants []string, jobs []int) string {
+	t.Helper()
+	ctx := context.Background()
+	hold, err := s.Acquire(ctx, "hold")
+	if err != nil {
+		t.Fatalf("acquire failed: %v", err)
+	}
+	granted := make(chan grant)
+	total := 0
+	for i, tenant := range tenants {
+		for n := 0; n < jobs[i]; n++ {
+			total++
+			go func(tenant string) {
+				release, err := s.Acquire(ctx, tenant)
+				if err != nil {
+					t.Errorf("acquire failed: %v", err)
+				}
+				granted <- grant{tenant, release}
+			}(tenant)
+		}
+		waitQueued(t, s, tenant, jobs[i])
+	}
+	hold()
+	var order []string
+	for i := 0; i < total; i++ {
+		g := <-granted
+		order = append(order, g.tenant)
+		g.release()
+	}
+	return strings.Join(order, " ")
+}
+
+func waitQueued(t *testing.T, s *Scheduler, tenant string, n int) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		s.mu.Lock()
+		q := s.tenants[tenant]
+		queued := q != nil && len(q.waiting) == n
+		s.mu.Unlock()
+		if queued {
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("%d jobs of %s never queued", n, tenant)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestSchedulerInterleavesTenants(t *testing.T) {
+	// The backfill queued first does not hold up the interactive tenant
+	// until it has drained.
+	got := serve(t, NewScheduler(1, 0, nil), []string{"backfill", "ui"}, []int{4, 2})
+	if want := "backfill ui backfill ui backfill backfill"; got != want {
+		t.Errorf("served %q, want %q", got, want)
+	}
+}
+
+func TestSchedulerWeights(t *testing.T) {
+	got := serve(t, NewScheduler(1, 0, map[string]int{"gold": 2}), []string{"gold", "std"}, []int{4, 4})
+	if want := "gold std gold gold std gold std std"; got != want {
+		t.Errorf("served %q, want %q", got, want)
+	}
+}
+
+func TestSchedulerPerTenantLimit(t *testing.T) {
+	ctx := context.Background()
+	s := NewScheduler(4, 1, nil)
+	release, err := s.Acquire(ctx, "a")
+	if err != nil {
+		t.Fatalf("acquire failed: %v", err)
+	}
+	short, cancel := context.WithTimeout(ctx, 20*time.Millisecond)
+	defer cancel()
+	if _, err := s.Acquire(short, "a"); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("a second job of the same tenant should wait, got %v", err)
+	}
+	if s.tenants["a"].running != 1 || len(s.tenants["a"].waiting) != 0 {
+		t.Fatalf("a cancelled wait must leave no trace, have %+v", s.tenants["a"])
+	}
+	other, err := s.Acquire(ctx, "b")
+	if err != nil {
+		t.Fatalf("another tenant should get a free slot: %v", err)
+	}
+	other()
+	release()
+	if len(s.tenants) != 0 || s.free != 4 {
+		t.Errorf("all slots should be free again, have %d free and tenants %v", s.free, s.tenants)
+	}
+}
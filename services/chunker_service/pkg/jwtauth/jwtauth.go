@@ -0,0 +1,307 @@
+// Package jwtauth verifies JWT bearer tokens issued by an OpenID Connect
+// provider against the provider's JWKS, and exposes the token's scopes for
+// authorization. RS256/384/512, PS256/384/512 and ES256/384/512 signatures
+// are accepted; unsigned and HMAC tokens are not.
+package jwtauth
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/rsa"
+	_ "crypto/sha256"
+	_ "crypto/sha512"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ErrInvalidToken is returned, wrapped, for tokens that fail verification.
+var ErrInvalidToken = errors.New("invalid token")
+
+// Defaults for the Verifier's zero fields.
+const (
+	DefaultCacheTTL   = time.Hour
+	DefaultMinRefresh = time.Minute
+	DefaultLeeway     = time.Minute
+)
+
+// Verifier checks bearer tokens. Keys are fetched on first use, cached
+// for CacheTTL and refetched early, at most once per MinRefresh, when a
+// token names a key id the cache does not hold, so provider key rotation
+// needs no restart. A Verifier is safe for concurrent use.
+type Verifier struct {
+	// JWKSURL is the provider's key set. When empty it is discovered from
+	// Issuer's /.well-known/openid-configuration.
+	JWKSURL string
+	// Issuer, when set, must equal the token's iss claim.
+	Issuer string
+	// Audience, when set, must be one of the token's aud values.
+	Audience string
+	// Leeway allows for clock skew in exp and nbf; DefaultLeeway when zero.
+	Leeway time.Duration
+	// CacheTTL and MinRefresh default to DefaultCacheTTL and
+	// DefaultMinRefresh.
+	CacheTTL   time.Duration
+	MinRefresh time.Duration
+	// HTTPClient defaults to http.DefaultClient.
+	HTTPClient *http.Client
+	// Now defaults to time.Now.
+	Now func() time.Time
+
+	mu         sync.Mutex
+	keys       []key
+	fetched    time.Time
+	discovered string
+}
+
+// Claims are the verified claims of a token.
+type Claims struct {
+	Subject   string
+	Issuer    string
+	Audience  []string
+	ExpiresAt time.Time
+	// Scopes come from the space-separated scope claim and the scp claim
+	// (a string or a list), whichever the provider uses.
+	Scopes []string
+	// Raw holds every claim as decoded from the token.
+	Raw map[string]interface{}
+}
+
+// HasScope reports whether the token grants scope.
+func (c *Claims) HasScope(scope string) bool {
+	for _, s := range c.Scopes {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
+
+type header struct {
+	Alg string `json:"alg"`
+	Kid string `json:"kid"`
+	Typ string `json:"typ"`
+}
+
+// Verify checks token's signature, expiry, issuer and audience and
+// returns its claims. Verification failures wrap ErrInvalidToken; other
+// errors mean the key set could not be fetched.
+func (v *Verifier) Verify(ctx context.Context, token string) (*Claims, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, invalid("malformed token")
+	}
+	var h header
+	if err := decodeSegment(parts[0], &h); err != nil {
+		return nil, invalid("malformed header")
+	}
+	hash, family, err := algorithm(h.Alg)
+	if err != nil {
+		return nil, err
+	}
+	sig, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return nil, invalid("malformed signature")
+	}
+	keys, err := v.keysFor(ctx, h.Kid)
+	if err != nil {
+		return nil, err
+	}
+	hasher := hash.New()
+	hasher.Write([]byte(parts[0] + "." + parts[1]))
+	digest := hasher.Sum(nil)
+	verified := false
+	for _, k := range keys {
+		if k.alg != "" && k.alg != h.Alg {
+			continue
+		}
+		if verifySignature(family, hash, k.pub, digest, sig) {
+			verified = true
+			break
+		}
+	}
+	if !verified {
+		return nil, invalid("signature does not verify")
+	}
+
+	var raw map[string]interface{}
+	if err := decodeSegment(parts[1], &raw); err != nil {
+		return nil, invalid("malformed claims")
+	}
+	claims := parseClaims(raw)
+	if err := v.checkClaims(claims, raw); err != nil {
+		return nil, err
+	}
+	return claims, nil
+}
+
+func invalid(msg string) error {
+	return fmt.Errorf("%w: %s", ErrInvalidToken, msg)
+}
+
+func decodeSegment(seg string, v interface{}) error {
+	data, err := base64.RawURLEncoding.DecodeString(seg)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, v)
+}
+
+func algorithm(alg string) (crypto.Hash, string, error) {
+	if len(alg) != 5 {
+		return 0, "", invalid(fmt.Sprintf("unsupported alg %q", alg))
+	}
+	family := alg[:2]
+	if family != "RS" && family != "PS" && family != "ES" {
+		return 0, "", invalid(fmt.Sprintf("unsupported alg %q", alg))
+	}
+	switch alg[2:] {
+	case "256":
+		return crypto.SHA256, family, nil
+	case "384":
+		return crypto.SHA384, family, nil
+	case "512":
+		return crypto.SHA512, family, nil
+	}
+	return 0, "", invalid(fmt.Sprintf("unsupported alg %q", alg))
+}
+
+func verifySignature(family string, hash crypto.Hash, pub crypto.PublicKey, digest, sig []byte) bool {
+	switch pub := pub.(type) {
+	case *rsa.PublicKey:
+		switch family {
+		case "RS":
+			return rsa.VerifyPKCS1v15(pub, hash, digest, sig) == nil
+		case "PS":
+			return rsa.VerifyPSS(pub, hash, digest, sig, nil) == nil
+		}
+	case *ecdsa.PublicKey:
+		// JWS encodes ECDSA signatures as fixed-size r || s.
+		size := (pub.Curve.Params().BitSize + 7) / 8
+		if family != "ES" || len(sig) != 2*size {
+			return false
+		}
+		r := new(big.Int).SetBytes(sig[:size])
+		s := new(big.Int).SetBytes(sig[size:])
+		return ecdsa.Verify(pub, digest, r, s)
+	}
+	return false
+}
+
+// keysFor returns the cached keys that may have signed a token with key
+// id kid, refreshing the cache when it is stale or lacks kid.
+func (v *Verifier) keysFor(ctx context.Context, kid string) ([]key, error) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	now := v.now()
+	ttl, minRefresh := v.CacheTTL, v.MinRefresh
+	if ttl <= 0 {
+		ttl = DefaultCacheTTL
+	}
+	if minRefresh <= 0 {
+		minRefresh = DefaultMinRefresh
+	}
+	stale := v.fetched.IsZero() || now.Sub(v.fetched) >= ttl
+	if !stale && kid != "" && matching(v.keys, kid) == nil && now.Sub(v.fetched) >= minRefresh {
+		stale = true
+	}
+	if stale {
+		if err := v.refresh(ctx); err != nil && v.keys == nil {
+			return nil, err
+		}
+	}
+	if kid == "" {
+		return v.keys, nil
+	}
+	keys := matching(v.keys, kid)
+	if keys == nil {
+		return nil, invalid(fmt.Sprintf("unknown key id %q", kid))
+	}
+	return keys, nil
+}
+
+func matching(keys []key, kid string) []key {
+	var out []key
+	for _, k := range keys {
+		if k.id == kid {
+			out = append(out, k)
+		}
+	}
+	return out
+}
+
+func (v *Verifier) now() time.Time {
+	if v.Now != nil {
+		return v.Now()
+	}
+	return time.Now()
+}
+
+func parseClaims(raw map[string]interface{}) *Claims {
+	c := &Claims{Raw: raw}
+	c.Subject, _ = raw["sub"].(string)
+	c.Issuer, _ = raw["iss"].(string)
+	c.Audience = stringList(raw["aud"], false)
+	if exp, ok := raw["exp"].(float64); ok {
+		c.ExpiresAt = time.Unix(int64(exp), 0)
+	}
+	c.Scopes = append(stringList(raw["scope"], true), stringList(raw["scp"], true)...)
+	return c
+}
+
+// stringList reads a claim holding a string or a list of strings,
+// splitting a single string on spaces when split is set.
+func stringList(v interface{}, split bool) []string {
+	switch v := v.(type) {
+	case string:
+		if split {
+			return strings.Fields(v)
+		}
+		return []string{v}
+	case []interface{}:
+		var out []string
+		for _, e := range v {
+			if s, ok := e.(string); ok {
+				out = append(out, s)
+			}
+		}
+		return out
+	}
+	return nil
+}
+
+func (v *Verifier) checkClaims(c *Claims, raw map[string]interface{}) error {
+	leeway := v.Leeway
+	if leeway <= 0 {
+		leeway = DefaultLeeway
+	}
+	now := v.now()
+	if c.ExpiresAt.IsZero() {
+		return invalid("missing exp")
+	}
+	if now.After(c.ExpiresAt.Add(leeway)) {
+		return invalid("token expired")
+	}
+	if nbf, ok := raw["nbf"].(float64); ok && now.Add(leeway).Before(time.Unix(int64(nbf), 0)) {
+		return invalid("token not valid yet")
+	}
+	if v.Issuer != "" && c.Issuer != v.Issuer {
+		return invalid(fmt.Sprintf("unexpected issuer %q", c.Issuer))
+	}
+	if v.Audience != "" {
+		for _, a := range c.Audience {
+			if a == v.Audience {
+				return nil
+			}
+		}
+		return invalid("audience mismatch")
+	}
+	return nil
+}
@@ -0,0 +1,151 @@
+package jwtauth
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+var b64 = base64.RawURLEncoding
+
+func rsaJWK(kid string, k *rsa.PrivateKey) map[string]string {
+	return map[string]string{"kty": "RSA", "kid": kid, "use": "sig", "alg": "RS256",
+		"n": b64.EncodeToString(k.N.Bytes()), "e": b64.EncodeToString(big.NewInt(int64(k.E)).Bytes())}
+}
+
+func ecJWK(kid string, k *ecdsa.PrivateKey) map[string]string {
+	return map[string]string{"kty": "EC", "kid": kid, "crv": "P-256",
+		"x": b64.EncodeToString(k.X.FillBytes(make([]byte, 32))), "y": b64.EncodeToString(k.Y.FillBytes(make([]byte, 32)))}
+}
+
+func sign(t *testing.T, alg, kid string, key crypto.Signer, claims map[string]interface{}) string {
+	t.Helper()
+	h, _ := json.Marshal(map[string]string{"alg": alg, "kid": kid, "typ": "JWT"})
+	c, _ := json.Marshal(claims)
+	input := b64.EncodeToString(h) + "." + b64.EncodeToString(c)
+	digest := sha256.Sum256([]byte(input))
+	var sig []byte
+	switch k := key.(type) {
+	case *rsa.PrivateKey:
+		var err error
+		if sig, err = rsa.SignPKCS1v15(rand.Reader, k, crypto.SHA256, digest[:]); err != nil {
+			t.Fatalf("sign: %v", err)
+		}
+	case *ecdsa.PrivateKey:
+		r, s, err := ecdsa.Sign(rand.Reader, k, digest[:])
+		if err != nil {
+			t.Fatalf("sign: %v", err)
+		}
+		sig = append(r.FillBytes(make([]byte, 32)), s.FillBytes(make([]byte, 32))...)
+	}
+	return input + "." + b64.EncodeToString(sig)
+}
+
+type provider struct {
+	keys    atomic.Value // []map[string]string
+	fetches atomic.Int32
+	srv     *httptest.Server
+}
+
+func newProvider(t *testing.T, keys ...map[string]string) *provider {
+	p := &provider{}
+	p.keys.Store(keys)
+	mux := http.NewServeMux()
+	mux.HandleFunc("/keys", func(w http.ResponseWriter, r *http.Request) {
+		p.fetches.Add(1)
+		json.NewEncoder(w).Encode(map[string]interface{}{"keys": p.keys.Load()})
+	})
+	mux.HandleFunc("/.well-known/openid-configuration", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]string{"issuer": p.srv.URL, "jwks_uri": p.srv.URL + "/keys"})
+	})
+	p.srv = httptest.NewServer(mux)
+	t.Cleanup(p.srv.Close)
+	return p
+}
+
+func TestVerify(t *testing.T) {
+	rsaKey, _ := rsa.GenerateKey(rand.Reader, 2048)
+	ecKey, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	p := newProvider(t, rsaJWK("r1", rsaKey), ecJWK("e1", ecKey))
+	now := time.Unix(1_700_000_000, 0)
+	v := &Verifier{Issuer: p.srv.URL, Audience: "chunker", Now: func() time.Time { return now }}
+	claims := func(extra map[string]interface{}) map[string]interface{} {
+		c := map[string]interface{}{"iss": p.srv.URL, "aud": []string{"chunker", "other"}, "sub": "alice",
+			"exp": now.Add(time.Hour).Unix(), "scope": "chunk:write chunk:read"}
+		for k, v := range extra {
+			c[k] = v
+		}
+		return c
+	}
+
+	got, err := v.Verify(context.Background(), sign(t, "RS256", "r1", rsaKey, claims(nil)))
+	if err != nil {
+		t.Fatalf("verify: %v", err)
+	}
+	if got.Subject != "alice" || !got.HasScope("chunk:write") || got.HasScope("admin") {
+		t.Errorf("claims = %+v", got)
+	}
+	got, err = v.Verify(context.Background(), sign(t, "ES256", "e1", ecKey, claims(map[string]interface{}{"scope": nil, "scp": []string{"chunk:read"}})))
+	if err != nil {
+		t.Fatalf("verify es256: %v", err)
+	}
+	if !got.HasScope("chunk:read") || got.HasScope("chunk:write") {
+		t.Errorf("scp scopes = %v", got.Scopes)
+	}
+
+	otherKey, _ := rsa.GenerateKey(rand.Reader, 2048)
+	bad := map[string]string{
+		"expired":       sign(t, "RS256", "r1", rsaKey, claims(map[string]interface{}{"exp": now.Add(-time.Hour).Unix()})),
+		"not yet valid": sign(t, "RS256", "r1", rsaKey, claims(map[string]interface{}{"nbf": now.Add(time.Hour).Unix()})),
+		"no exp":        sign(t, "RS256", "r1", rsaKey, claims(map[string]interface{}{"exp": nil})),
+		"audience":      sign(t, "RS256", "r1", rsaKey, claims(map[string]interface{}{"aud": "someone-else"})),
+		"issuer":        sign(t, "RS256", "r1", rsaKey, claims(map[string]interface{}{"iss": "https://evil"})),
+		"wrong key":     sign(t, "RS256", "r1", otherKey, claims(nil)),
+		"alg mismatch":  sign(t, "ES256", "r1", ecKey, claims(nil)),
+		"alg none":      b64.EncodeToString([]byte(`{"alg":"none"}`)) + "." + b64.EncodeToString([]byte(`{"sub":"x"}`)) + ".",
+		"malformed":     "not-a-token",
+	}
+	for name, token := range bad {
+		if _, err := v.Verify(context.Background(), token); !errors.Is(err, ErrInvalidToken) {
+			t.Errorf("%s: err = %v, want ErrInvalidToken", name, err)
+		}
+	}
+}
+
+func TestVerifyRefreshesOnKeyRotation(t *testing.T) {
+	oldKey, _ := rsa.GenerateKey(rand.Reader, 2048)
+	newKey, _ := rsa.GenerateKey(rand.Reader, 2048)
+	p := newProvider(t, rsaJWK("old", oldKey))
+	now := time.Unix(1_700_000_000, 0)
+	v := &Verifier{JWKSURL: p.srv.URL + "/keys", Now: func() time.Time { return now }}
+	claims := map[string]interface{}{"exp": now.Add(time.Hour).Unix()}
+
+	if _, err := v.Verify(context.Background(), sign(t, "RS256", "old", oldKey, claims)); err != nil {
+		t.Fatalf("verify: %v", err)
+	}
+	p.keys.Store([]map[string]string{rsaJWK("old", oldKey), rsaJWK("new", newKey)})
+	rotated := sign(t, "RS256", "new", newKey, claims)
+	if _, err := v.Verify(context.Background(), rotated); !errors.Is(err, ErrInvalidToken) {
+		t.Fatalf("refetched within MinRefresh: err = %v", err)
+	}
+	now = now.Add(2 * DefaultMinRefresh)
+	if _, err := v.Verify(context.Background(), rotated); err != nil {
+		t.Fatalf("verify after rotation: %v", err)
+	}
+	if n := p.fetches.Load(); n != 2 {
+		t.Errorf("fetched keys %d times, want 2", n)
+	}
+}
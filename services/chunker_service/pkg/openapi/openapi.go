@@ -0,0 +1,207 @@
+// Package openapi generates an OpenAPI 3 document from the Go types used
+// by HTTP handlers, so the published spec cannot drift from the structs
+// the server actually encodes and decodes.
+package openapi
+
+import (
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Version is the OpenAPI version of generated documents.
+const Version = "3.0.3"
+
+// Operation describes one endpoint. Request and Response are zero values
+// of the Go types decoded from the request body and encoded in a
+// successful JSON response; nil means there is no body.
+type Operation struct {
+	Method   string
+	Path     string
+	Summary  string
+	Request  interface{}
+	Response interface{}
+	// Error is the type of error response bodies, if any.
+	Error interface{}
+	// Status is the code of the successful response; 0 means 200.
+	Status int
+	// ResponseTypes lists additional content types of the successful
+	// response, e.g. an Arrow stream, whose schema is not described.
+	ResponseTypes []string
+}
+
+// Generate builds the OpenAPI document for ops. Struct types become
+// component schemas named after the Go type; fields use their JSON names.
+// Path segments such as {id} become required string parameters.
+func Generate(title, version string, ops []Operation) map[string]interface{} {
+	g := &generator{schemas: map[string]interface{}{}}
+	paths := map[string]interface{}{}
+	for _, op := range ops {
+		item, _ := paths[op.Path].(map[string]interface{})
+		if item == nil {
+			item = map[string]interface{}{}
+			paths[op.Path] = item
+		}
+		o := map[string]interface{}{"summary": op.Summary}
+		if params := pathParams(op.Path); len(params) > 0 {
+			o["parameters"] = params
+		}
+		if op.Request != nil {
+			o["requestBody"] = map[string]interface{}{
+				"required": true,
+				"content":  jsonContent(g.schema(reflect.TypeOf(op.Request))),
+			}
+		}
+		ok := map[string]interface{}{"description": "OK"}
+		content := map[string]interface{}{}
+		if op.Response != nil {
+			content = jsonContent(g.schema(reflect.TypeOf(op.Response)))
+		}
+		for _, ct := range op.ResponseTypes {
+			content[ct] = map[string]interface{}{}
+		}
+		if len(content) > 0 {
+			ok["content"] = content
+		}
+		status := op.Status
+		if status == 0 {
+			status = 200
+		}
+		responses := map[string]interface{}{strconv.Itoa(status): ok}
+		if op.Error != nil {
+			responses["default"] = map[string]interface{}{
+				"description": "Error",
+				"content":     jsonContent(g.schema(reflect.TypeOf(op.Error))),
+			}
+		}
+		o["responses"] = responses
+		item[strings.ToLower(op.Method)] = o
+	}
+	doc := map[string]interface{}{
+		"openapi": Version,
+		"info":    map[string]interface{}{"title": title, "version": version},
+		"paths":   paths,
+	}
+	if len(g.schemas) > 0 {
+		doc["components"] = map[string]interface{}{"schemas": g.schemas}
+	}
+	return doc
+}
+
+func pathParams(path string) []interface{} {
+	var params []interface{}
+	for _, seg := range strings.Split(path, "/") {
+		if strings.HasPrefix(seg, "{") && strings.HasSuffix(seg, "}") {
+			params = append(params, map[string]interface{}{
+				"name":     strings.Trim(seg, "{}"),
+				"in":       "path",
+				"required": true,
+				"schema":   map[string]interface{}{"type": "string"},
+			})
+		}
+	}
+	return params
+}
+
+func jsonContent(schema map[string]interface{}) map[string]interface{} {
+	return map[string]interface{}{"application/json": map[string]interface{}{"schema": schema}}
+}
+
+type generator struct {
+	schemas map[string]interface{}
+}
+
+var timeType = reflect.TypeOf(time.Time{})
+
+// schema returns the schema of t, registering struct types as components.
+func (g *generator) schema(t reflect.Type) map[string]interface{} {
+	switch {
+	case t == timeType:
+		return map[string]interface{}{"type": "string", "format": "date-time"}
+	case t.Kind() == reflect.Pointer:
+		s := g.schema(t.Elem())
+		if _, isRef := s["$ref"]; isRef {
+			// Siblings of $ref are ignored in OpenAPI 3.0.
+			return map[string]interface{}{"allOf": []interface{}{s}, "nullable": true}
+		}
+		s["nullable"] = true
+		return s
+	}
+	switch t.Kind() {
+	case reflect.String:
+		return map[string]interface{}{"type": "string"}
+	case reflect.Bool:
+		return map[string]interface{}{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32:
+		return map[string]interface{}{"type": "integer"}
+	case reflect.Int64, reflect.Uint64:
+		return map[string]interface{}{"type": "integer", "format": "int64"}
+	case reflect.Float32, reflect.Float64:
+		return map[string]interface{}{"type": "number"}
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return map[string]interface{}{"type": "string", "format": "byte"}
+		}
+		return map[string]interface{}{"type": "array", "items": g.schema(t.Elem())}
+	case reflect.Map:
+		return map[string]interface{}{"type": "object", "additionalProperties": g.schema(t.Elem())}
+	case reflect.Struct:
+		return g.component(t)
+	}
+	// interface{} and anything else: any JSON value.
+	return map[string]interface{}{}
+}
+
+// component registers a named struct type and returns a reference to it.
+// Names are capitalized so unexported handler types read well in
+// generated SDKs; anonymous structs are inlined.
+func (g *generator) component(t reflect.Type) map[string]interface{} {
+	name := t.Name()
+	if name == "" {
+		return g.object(t)
+	}
+	name = strings.ToUpper(name[:1]) + name[1:]
+	ref := map[string]interface{}{"$ref": "#/components/schemas/" + name}
+	if _, ok := g.schemas[name]; !ok {
+		g.schemas[name] = nil // placeholder for recursive types
+		g.schemas[name] = g.object(t)
+	}
+	return ref
+}
+
+func (g *generator) object(t reflect.Type) map[string]interface{} {
+	props := map[string]interface{}{}
+	g.fields(t, props)
+	return map[string]interface{}{"type": "object", "properties": props}
+}
+
+// fields adds the JSON-visible fields of t to props, flattening embedded
+// structs the way encoding/json does.
+func (g *generator) fields(t reflect.Type, props map[string]interface{}) {
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		tag := f.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name, _, _ := strings.Cut(tag, ",")
+		if f.Anonymous && name == "" {
+			ft := f.Type
+			if ft.Kind() == reflect.Pointer {
+				ft = ft.Elem()
+			}
+			if ft.Kind() == reflect.Struct {
+				g.fields(ft, props)
+				continue
+			}
+		}
+		if !f.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = f.Name
+		}
+		props[name] = g.schema(f.Type)
+	}
+}
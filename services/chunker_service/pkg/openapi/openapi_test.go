@@ -0,0 +1,85 @@
+package openapi
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+type node struct {
+	Name     string            `json:"name"`
+	Children []node            `json:"children,omitempty"`
+	Parent   *node             `json:"parent,omitempty"`
+	Labels   map[string]string `json:"labels"`
+	Seen     time.Time         `json:"seen"`
+	Data     interface{}       `json:"data"`
+	Skipped  string            `json:"-"`
+	private  int
+	embedded
+}
+
+type embedded struct {
+	Score float64 `json:"score"`
+}
+
+type failure struct {
+	Error string `json:"error"`
+}
+
+func TestGenerate(t *testing.T) {
+	doc := Generate("Test", "1", []Operation{
+		{Method: "POST", Path: "/nodes", Summary: "Create", Request: node{}, Response: []node{}, Error: failure{},
+			ResponseTypes: []string{"application/x-test"}},
+		{Method: "GET", Path: "/healthz", Response: map[string]string{}},
+		{Method: "GET", Path: "/nodes/{name}", Response: node{}, Status: 202},
+	})
+	data, err := json.Marshal(doc)
+	if err != nil {
+		t.Fatalf("marshal: %v", err)
+	}
+	var got struct {
+		Paths      map[string]map[string]json.RawMessage `json:"paths"`
+		Components struct {
+			Schemas map[string]struct {
+				Properties map[string]map[string]interface{} `json:"properties"`
+			} `json:"schemas"`
+		} `json:"components"`
+	}
+	if err := json.Unmarshal(data, &got); err != nil {
+		t.Fatalf("unmarshal: %v", err)
+	}
+	if _, ok := got.Paths["/nodes"]["post"]; !ok {
+		t.Fatalf("missing POST /nodes in %s", data)
+	}
+	var byName struct {
+		Parameters []map[string]interface{} `json:"parameters"`
+		Responses  map[string]interface{}   `json:"responses"`
+	}
+	json.Unmarshal(got.Paths["/nodes/{name}"]["get"], &byName)
+	if len(byName.Parameters) != 1 || byName.Parameters[0]["name"] != "name" || byName.Parameters[0]["in"] != "path" {
+		t.Errorf("expected a path parameter \"name\", got %v", byName.Parameters)
+	}
+	if _, ok := byName.Responses["202"]; !ok {
+		t.Errorf("expected a 202 response, got %v", byName.Responses)
+	}
+	props := got.Components.Schemas["Node"].Properties
+	for _, name := range []string{"name", "children", "parent", "labels", "seen", "data", "score"} {
+		if _, ok := props[name]; !ok {
+			t.Errorf("Node schema lacks %q: %v", name, props)
+		}
+	}
+	for _, name := range []string{"Skipped", "private", "embedded"} {
+		if _, ok := props[name]; ok {
+			t.Errorf("Node schema should not have %q", name)
+		}
+	}
+	if props["seen"]["format"] != "date-time" {
+		t.Errorf("time fields should be date-time strings: %v", props["seen"])
+	}
+	if props["parent"]["nullable"] != true {
+		t.Errorf("pointer fields should be nullable: %v", props["parent"])
+	}
+	if _, ok := got.Components.Schemas["Failure"]; !ok {
+		t.Errorf("error type should be a component")
+	}
+}
@@ -0,0 +1,278 @@
+// Package packer assembles retrieved chunks into the context section of a
+// prompt. Given chunks in rank order and the model's token budget, it drops
+// duplicates, merges chunks that are neighbours in their document, groups
+// passages by document section and greedily fills the context budget.
+package packer
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"chunker-service/pkg/chunking"
+	"chunker-service/pkg/tokenizer"
+)
+
+// Budget divides a model's context window. Context is what remains for
+// retrieved text unless set explicitly.
+type Budget struct {
+	Total   int `json:"total,omitempty"`
+	System  int `json:"system,omitempty"`
+	Answer  int `json:"answer,omitempty"`
+	Context int `json:"context,omitempty"`
+}
+
+// ContextTokens returns the tokens available for packed chunks.
+func (b Budget) ContextTokens() (int, error) {
+	if b.System < 0 || b.Answer < 0 || b.Context < 0 || b.Total < 0 {
+		return 0, errors.New("budget values must be >= 0")
+	}
+	n := b.Context
+	if n == 0 {
+		n = b.Total - b.System - b.Answer
+	}
+	if n <= 0 {
+		return 0, fmt.Errorf("no context budget left: total %d, system %d, answer %d", b.Total, b.System, b.Answer)
+	}
+	if b.Total > 0 && b.System+b.Answer+n > b.Total {
+		return 0, fmt.Errorf("system %d + answer %d + context %d exceeds total %d", b.System, b.Answer, n, b.Total)
+	}
+	return n, nil
+}
+
+// Passage is a run of included chunks from one document, merged into a
+// single text.
+type Passage struct {
+	ChunkIDs  []string `json:"chunk_ids"`
+	Source    string   `json:"source,omitempty"`
+	Section   string   `json:"section,omitempty"`
+	ByteStart int      `json:"byte_start"`
+	ByteEnd   int      `json:"byte_end"`
+}
+
+// Dropped is a chunk left out of the context.
+type Dropped struct {
+	ID string `json:"id"`
+	// Reason is "duplicate" for chunks whose text or document span is
+	// already covered, "budget" for chunks that did not fit.
+	Reason string `json:"reason"`
+}
+
+// Result is a packed context.
+type Result struct {
+	Context  string    `json:"context"`
+	Tokens   int       `json:"tokens"`
+	Budget   int       `json:"budget"`
+	Included []string  `json:"included"`
+	Passages []Passage `json:"passages"`
+	Dropped  []Dropped `json:"dropped,omitempty"`
+}
+
+// Pack selects and orders chunks, given best first, to fill the budget's
+// context tokens as counted by tok. Chunks are considered in rank order
+// and each is kept if the rendered context still fits, so a large chunk
+// that does not fit does not stop smaller lower-ranked ones from filling
+// the remainder. Sections appear in order of their best-ranked chunk.
+func Pack(chunks []chunking.Chunk, budget Budget, tok tokenizer.Tokenizer) (Result, error) {
+	limit, err := budget.ContextTokens()
+	if err != nil {
+		return Result{}, err
+	}
+	res := Result{Budget: limit, Included: []string{}, Passages: []Passage{}}
+	var selected []ranked
+	for i, c := range chunks {
+		if duplicateOf(c, selected) {
+			res.Dropped = append(res.Dropped, Dropped{ID: c.ID, Reason: "duplicate"})
+			continue
+		}
+		trial := append(selected[:len(selected):len(selected)], ranked{Chunk: c, rank: i})
+		text, passages := render(trial)
+		n := len(tok.Tokenize(text))
+		if n > limit {
+			res.Dropped = append(res.Dropped, Dropped{ID: c.ID, Reason: "budget"})
+			continue
+		}
+		selected = trial
+		res.Context, res.Passages, res.Tokens = text, passages, n
+		res.Included = append(res.Included, c.ID)
+	}
+	return res, nil
+}
+
+type ranked struct {
+	chunking.Chunk
+	rank int
+}
+
+// duplicateOf reports whether c repeats a selected chunk: the same ID, the
+// same text, or a document span inside a selected chunk's span.
+func duplicateOf(c chunking.Chunk, selected []ranked) bool {
+	text := normalize(c.Text)
+	for _, s := range selected {
+		switch {
+		case c.ID != "" && s.ID == c.ID,
+			normalize(s.Text) == text,
+			docKey(s.Chunk) != "" && docKey(s.Chunk) == docKey(c) && s.ByteStart <= c.ByteStart && c.ByteEnd <= s.ByteEnd:
+			return true
+		}
+	}
+	return false
+}
+
+func normalize(s string) string {
+	return strings.Join(strings.Fields(s), " ")
+}
+
+// docKey identifies a chunk's source document; chunks without one are
+// never merged.
+func docKey(c chunking.Chunk) string {
+	switch {
+	case c.DocID != "":
+		return c.DocID
+	case c.FilePath != "":
+		return c.FilePath
+	default:
+		return c.FileName
+	}
+}
+
+func source(c chunking.Chunk) string {
+	for _, s := range []string{c.Title, c.FileName, c.DocID, c.URL} {
+		if s != "" {
+			return s
+		}
+	}
+	return ""
+}
+
+func section(c chunking.Chunk) string {
+	if c.Section != "" {
+		return c.Section
+	}
+	s, _ := c.Extra["heading"].(string)
+	return s
+}
+
+type passage struct {
+	Passage
+	text string
+	rank int
+	last chunking.Chunk
+}
+
+// render merges neighbouring chunks into passages and lays them out
+// grouped by source and section, each group under a header line.
+func render(chunks []ranked) (string, []Passage) {
+	sorted := append([]ranked(nil), chunks...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		ki, kj := docKey(sorted[i].Chunk), docKey(sorted[j].Chunk)
+		if ki != kj {
+			return ki < kj
+		}
+		return sorted[i].ByteStart < sorted[j].ByteStart
+	})
+	var passages []*passage
+	for _, c := range sorted {
+		if n := len(passages); n > 0 && adjacent(passages[n-1], c.Chunk) {
+			passages[n-1].extend(c)
+			continue
+		}
+		passages = append(passages, &passage{
+			Passage: Passage{ChunkIDs: []string{c.ID}, Source: source(c.Chunk), Section: section(c.Chunk),
+				ByteStart: c.ByteStart, ByteEnd: c.ByteEnd},
+			text: c.Text,
+			rank: c.rank,
+			last: c.Chunk,
+		})
+	}
+
+	// Group passages of the same source and section; groups come in
+	// order of their best rank, passages within a group in document order.
+	type group struct {
+		source, section string
+		rank            int
+		passages        []*passage
+	}
+	var groups []*group
+	index := map[[2]string]*group{}
+	for _, p := range passages {
+		key := [2]string{p.Source, p.Section}
+		g, ok := index[key]
+		if !ok {
+			g = &group{source: p.Source, section: p.Section, rank: p.rank}
+			index[key] = g
+			groups = append(groups, g)
+		}
+		if p.rank < g.rank {
+			g.rank = p.rank
+		}
+		g.passages = append(g.passages, p)
+	}
+	sort.SliceStable(groups, func(i, j int) bool { return groups[i].rank < groups[j].rank })
+
+	var b strings.Builder
+	out := make([]Passage, 0, len(passages))
+	for i, g := range groups {
+		if i > 0 {
+			b.WriteString("\n\n")
+		}
+		if header := header(g.source, g.section); header != "" {
+			b.WriteString(header)
+			b.WriteString("\n")
+		}
+		for j, p := range g.passages {
+			if j > 0 {
+				b.WriteString("\n\n")
+			}
+			b.WriteString(p.text)
+			out = append(out, p.Passage)
+		}
+	}
+	return b.String(), out
+}
+
+func header(source, section string) string {
+	switch {
+	case source != "" && section != "":
+		return fmt.Sprintf("### %s > %s", source, section)
+	case source != "":
+		return "### " + source
+	case section != "":
+		return "### " + section
+	}
+	return ""
+}
+
+// adjacent reports whether c continues p in the same document: its span
+// overlaps or touches p's, or the chunker linked them as neighbours.
+func adjacent(p *passage, c chunking.Chunk) bool {
+	if docKey(c) == "" || docKey(c) != docKey(p.last) || section(c) != p.Section {
+		return false
+	}
+	return c.ByteStart <= p.ByteEnd || (p.last.NextID != "" && p.last.NextID == c.ID)
+}
+
+// extend appends c to p without repeating the text the two share.
+func (p *passage) extend(c ranked) {
+	p.ChunkIDs = append(p.ChunkIDs, c.ID)
+	if c.rank < p.rank {
+		p.rank = c.rank
+	}
+	if c.ByteEnd <= p.ByteEnd {
+		return
+	}
+	p.last = c.Chunk
+	overlap := p.ByteEnd - c.ByteStart
+	switch {
+	case overlap <= 0:
+		p.text += "\n" + c.Text
+	case overlap <= len(c.Text) && strings.HasSuffix(p.text, c.Text[:overlap]):
+		p.text += c.Text[overlap:]
+	default:
+		// The texts do not line up with the byte spans, for example
+		// because headings were prepended; keep both rather than guess.
+		p.text += "\n" + c.Text
+	}
+	p.ByteEnd = c.ByteEnd
+}
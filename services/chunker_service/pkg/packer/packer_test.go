@@ -0,0 +1,72 @@
+package packer
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"chunker-service/pkg/chunking"
+	"chunker-service/pkg/tokenizer"
+)
+
+func docChunks(t *testing.T) []chunking.Chunk {
+	t.Helper()
+	text := "one two three four five six seven eight nine ten eleven twelve"
+	chunks, err := chunking.NewSlidingWindowChunker().Chunk(text,
+		chunking.ChunkingPlan{WindowSize: 4, Overlap: 1, Mode: chunking.ModeTokens},
+		map[string]interface{}{"doc_id": "d", "title": "Guide"})
+	if err != nil {
+		t.Fatalf("chunking failed: %v", err)
+	}
+	for i := range chunks {
+		chunks[i].Section = "Intro"
+	}
+	return chunks
+}
+
+func TestPackMergesNeighboursAndDropsDuplicates(t *testing.T) {
+	chunks := docChunks(t) // d#0 "one..four", d#1 "four..seven", d#2 "seven..ten", d#3 "ten..twelve"
+	other := chunking.Chunk{ID: "x#0", DocID: "x", Title: "FAQ", Text: "unrelated answer text"}
+	ranked := []chunking.Chunk{chunks[1], other, chunks[0], chunks[1], chunks[3]}
+
+	res, err := Pack(ranked, Budget{Total: 100, System: 20, Answer: 40}, tokenizer.Whitespace{})
+	if err != nil {
+		t.Fatalf("pack failed: %v", err)
+	}
+	if !reflect.DeepEqual(res.Included, []string{"d#1", "x#0", "d#0", "d#3"}) {
+		t.Errorf("included = %v", res.Included)
+	}
+	if len(res.Dropped) != 1 || res.Dropped[0] != (Dropped{ID: "d#1", Reason: "duplicate"}) {
+		t.Errorf("dropped = %+v", res.Dropped)
+	}
+	want := "### Guide > Intro\none two three four five six seven\n\nten eleven twelve\n\n### FAQ\nunrelated answer text"
+	if res.Context != want {
+		t.Errorf("context =\n%s\nwant\n%s", res.Context, want)
+	}
+	if len(res.Passages) != 3 || !reflect.DeepEqual(res.Passages[0].ChunkIDs, []string{"d#0", "d#1"}) {
+		t.Errorf("passages = %+v", res.Passages)
+	}
+	if res.Budget != 40 || res.Tokens != len(strings.Fields(want)) {
+		t.Errorf("tokens = %d of %d", res.Tokens, res.Budget)
+	}
+}
+
+func TestPackFillsBudgetPastLargeChunks(t *testing.T) {
+	big := chunking.Chunk{ID: "big", Text: strings.Repeat("word ", 50)}
+	small := chunking.Chunk{ID: "small", Text: "short fact"}
+	res, err := Pack([]chunking.Chunk{big, small}, Budget{Context: 10}, tokenizer.Whitespace{})
+	if err != nil {
+		t.Fatalf("pack failed: %v", err)
+	}
+	if !reflect.DeepEqual(res.Included, []string{"small"}) || res.Dropped[0] != (Dropped{ID: "big", Reason: "budget"}) {
+		t.Errorf("included %v, dropped %+v", res.Included, res.Dropped)
+	}
+}
+
+func TestBudgetValidation(t *testing.T) {
+	for _, b := range []Budget{{}, {Total: 100, System: 60, Answer: 40}, {Total: 100, Context: 90, Answer: 20}, {Context: -1}} {
+		if _, err := b.ContextTokens(); err == nil {
+			t.Errorf("expected error for %+v", b)
+		}
+	}
+}
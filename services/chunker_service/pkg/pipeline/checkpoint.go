@@ -0,0 +1,162 @@
+package pipeline
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+)
+
+// Checkpoint records which documents a run has fully processed so an
+// interrupted run can resume where it left off. Entries are appended to a
+// JSON Lines progress file as each document completes; a document is
+// considered done only if both its path and content hash match, so edited
+// files are processed again. A document that only some sinks took is
+// recorded for each of them, so a resumed run writes it to the others
+// only.
+type Checkpoint struct {
+	mu   sync.Mutex
+	f    *os.File
+	done map[string]string
+	// sinks holds the content hash each sink has taken, by path and sink
+	// name, for documents not done yet.
+	sinks map[string]map[string]string
+}
+
+type checkpointEntry struct {
+	Path   string `json:"path"`
+	SHA256 string `json:"sha256"`
+	// Sink is set on entries recording that one sink took the document.
+	Sink string `json:"sink,omitempty"`
+}
+
+// OpenCheckpoint loads the progress file at path, creating it if needed.
+func OpenCheckpoint(path string) (*Checkpoint, error) {
+	c := &Checkpoint{done: map[string]string{}, sinks: map[string]map[string]string{}}
+
+	data, err := os.ReadFile(path)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, err
+	}
+	// valid is the length of the prefix made of complete entries.
+	valid := 0
+	lines := bytes.SplitAfter(data, []byte("\n"))
+	for i, line := range lines {
+		if len(bytes.TrimSpace(line)) > 0 {
+			var e checkpointEntry
+			if err := json.Unmarshal(line, &e); err != nil {
+				// A torn final line from an interrupted write is expected
+				// and dropped; anything else means the file is not a
+				// checkpoint.
+				if i == len(lines)-1 {
+					break
+				}
+				return nil, fmt.Errorf("checkpoint %s line %d: %w", path, i+1, err)
+			}
+			c.set(e)
+		}
+		valid += len(line)
+	}
+
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o644)
+	if err != nil {
+		return nil, err
+	}
+	if valid < len(data) {
+		err = f.Truncate(int64(valid))
+	} else if len(data) > 0 && data[len(data)-1] != '\n' {
+		_, err = f.Write([]byte("\n"))
+	}
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+	c.f = f
+	return c, nil
+}
+
+// set applies an entry to the in-memory state.
+func (c *Checkpoint) set(e checkpointEntry) {
+	if e.Sink == "" {
+		c.done[e.Path] = e.SHA256
+		delete(c.sinks, e.Path)
+		return
+	}
+	if c.sinks[e.Path] == nil {
+		c.sinks[e.Path] = map[string]string{}
+	}
+	c.sinks[e.Path][e.Sink] = e.SHA256
+}
+
+// Len returns the number of documents recorded as done or taken by some
+// sinks.
+func (c *Checkpoint) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return len(c.done) + len(c.sinks)
+}
+
+// Done reports whether the document at path with the given content hash has
+// already been processed.
+func (c *Checkpoint) Done(path, hash string) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	h, ok := c.done[path]
+	return ok && h == hash
+}
+
+// SinkDone reports whether the named sink has already taken the document
+// at path with the given content hash.
+func (c *Checkpoint) SinkDone(path, hash, sink string) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if h, ok := c.done[path]; ok && h == hash {
+		return true
+	}
+	h, ok := c.sinks[path][sink]
+	return ok && h == hash
+}
+
+// Record marks a document as processed and syncs the progress file so the
+// entry survives a crash.
+func (c *Checkpoint) Record(path, hash string) error {
+	return c.record(checkpointEntry{Path: path, SHA256: hash})
+}
+
+// RecordSink marks a document as taken by the named sink, for runs in
+// which other sinks failed it.
+func (c *Checkpoint) RecordSink(path, hash, sink string) error {
+	return c.record(checkpointEntry{Path: path, SHA256: hash, Sink: sink})
+}
+
+func (c *Checkpoint) record(e checkpointEntry) error {
+	line, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if _, err := c.f.Write(append(line, '\n')); err != nil {
+		return err
+	}
+	if err := c.f.Sync(); err != nil {
+		return err
+	}
+	c.set(e)
+	return nil
+}
+
+// Close closes the progress file.
+func (c *Checkpoint) Close() error {
+	return c.f.Close()
+}
+
+// ContentHash returns the hex-encoded SHA-256 of data.
+func ContentHash(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,149 @@
+package pipeline
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"chunker-service/pkg/chunking"
+)
+
+func TestRunResumesFromCheckpoint(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, "a.txt"), "a b")
+	writeFile(t, filepath.Join(dir, "b.txt"), "c d")
+	writeFile(t, filepath.Join(dir, "m.json"), `{
+		"plan": {"window_size": 2, "overlap": 0, "mode": "tokens"},
+		"sources": [{"path": "*.txt"}],
+		"sinks": [{"type": "jsonl", "path": "out.jsonl"}],
+		"checkpoint": "progress.jsonl"
+	}`)
+
+	m, err := LoadManifest(filepath.Join(dir, "m.json"))
+	if err != nil {
+		t.Fatalf("load manifest: %v", err)
+	}
+	run := func() Report {
+		t.Helper()
+		cp, err := OpenCheckpoint(m.CheckpointPath())
+		if err != nil {
+			t.Fatalf("open checkpoint: %v", err)
+		}
+		defer cp.Close()
+		runner := NewRunner()
+		runner.Checkpoint = cp
+		report, err := runner.Run(m)
+		if err != nil {
+			t.Fatalf("run failed: %v", err)
+		}
+		return report
+	}
+
+	if report := run(); report.Documents != 2 || report.Skipped != 0 {
+		t.Fatalf("first run report = %+v", report)
+	}
+
+	writeFile(t, filepath.Join(dir, "b.txt"), "c d e f")
+	if report := run(); report.Documents != 1 || report.Skipped != 1 {
+		t.Fatalf("second run report = %+v, want only the changed file processed", report)
+	}
+
+	chunks := readChunks(t, filepath.Join(dir, "out.jsonl"))
+	if len(chunks) != 4 {
+		t.Fatalf("expected resumed run to append to output, got %d chunks", len(chunks))
+	}
+}
+
+func TestOpenCheckpointTornLine(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "progress.jsonl")
+	writeFile(t, path, "{\"path\":\"a\",\"sha256\":\"1\"}\n{\"path\":\"b\",\"sha")
+
+	cp, err := OpenCheckpoint(path)
+	if err != nil {
+		t.Fatalf("open checkpoint: %v", err)
+	}
+	if !cp.Done("a", "1") || cp.Len() != 1 {
+		t.Fatalf("expected only the complete entry to be loaded")
+	}
+	if err := cp.Record("b", "2"); err != nil {
+		t.Fatalf("record: %v", err)
+	}
+	cp.Close()
+
+	cp, err = OpenCheckpoint(path)
+	if err != nil {
+		data, _ := os.ReadFile(path)
+		t.Fatalf("reopen checkpoint: %v\n%s", err, data)
+	}
+	defer cp.Close()
+	if !cp.Done("b", "2") {
+		t.Fatalf("entry recorded after torn line was lost")
+	}
+}
+
+// toggleSink fails the document at path b.txt while down and counts the
+// documents it takes.
+type toggleSink struct {
+	down bool
+	docs int
+}
+
+func (s *toggleSink) Write(doc Document, _ []chunking.Chunk) error {
+	if s.down && filepath.Base(doc.Path) == "b.txt" {
+		return errors.New("sink unavailable")
+	}
+	s.docs++
+	return nil
+}
+
+func (s *toggleSink) Close() error { return nil }
+
+func TestRunResumesOnlyFailedSinks(t *testing.T) {
+	toggle := &toggleSink{down: true}
+	sinkFactories["toggle"] = func(SinkConfig) (Sink, error) { return toggle, nil }
+	defer delete(sinkFactories, "toggle")
+
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, "a.txt"), "a b")
+	writeFile(t, filepath.Join(dir, "b.txt"), "c d")
+	m := &Manifest{
+		Plan:       &chunking.ChunkingPlan{WindowSize: 2, Mode: chunking.ModeTokens},
+		Sources:    []Source{{Path: "*.txt"}},
+		Sinks:      []SinkConfig{{Type: "jsonl", Path: "out.jsonl"}, {Type: "toggle"}},
+		Checkpoint: "progress.jsonl",
+		// Write each document in a batch of its own.
+		Queue:   &QueueConfig{MaxBatchChunks: 1},
+		baseDir: dir,
+	}
+	run := func() Report {
+		t.Helper()
+		cp, err := OpenCheckpoint(m.CheckpointPath())
+		if err != nil {
+			t.Fatalf("open checkpoint: %v", err)
+		}
+		defer cp.Close()
+		runner := NewRunner()
+		runner.Checkpoint = cp
+		runner.DeadLetter = &memoryDeadLetter{}
+		report, err := runner.Run(m)
+		if err != nil {
+			t.Fatalf("run failed: %v", err)
+		}
+		return report
+	}
+
+	if report := run(); report.Documents != 1 || report.Failed != 1 || toggle.docs != 1 {
+		t.Fatalf("first run report = %+v", report)
+	}
+	toggle.down = false
+	if report := run(); report.Documents != 1 || report.Skipped != 1 || toggle.docs != 2 {
+		t.Fatalf("second run report = %+v, toggle took %d documents", report, toggle.docs)
+	}
+	if chunks := readChunks(t, filepath.Join(dir, "out.jsonl")); len(chunks) != 2 {
+		t.Fatalf("expected the sink that took b.txt to be skipped on resume, got %d chunks", len(chunks))
+	}
+	if report := run(); report.Skipped != 2 || toggle.docs != 2 {
+		t.Fatalf("third run report = %+v, want both documents skipped", report)
+	}
+}
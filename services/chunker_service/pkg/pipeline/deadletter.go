@@ -0,0 +1,79 @@
+package pipeline
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"chunker-service/pkg/chunking"
+)
+
+// Pipeline stages reported in dead-letter records.
+const (
+	StageRead       = "read"
+	StageChunk      = "chunk"
+	StageSink       = "sink"
+	StageCheckpoint = "checkpoint"
+)
+
+// DeadLetterRecord describes a document that failed processing. It carries
+// the document text, plan and metadata so it can be inspected and replayed
+// even if the source file has since changed.
+type DeadLetterRecord struct {
+	Path     string                 `json:"path"`
+	Stage    string                 `json:"stage"`
+	Error    string                 `json:"error"`
+	Attempts int                    `json:"attempts"`
+	FailedAt time.Time              `json:"failed_at"`
+	Plan     chunking.ChunkingPlan  `json:"plan"`
+	Meta     map[string]interface{} `json:"meta,omitempty"`
+	Text     string                 `json:"text,omitempty"`
+}
+
+// DeadLetter receives documents that failed processing.
+type DeadLetter interface {
+	Put(rec DeadLetterRecord) error
+}
+
+// DirDeadLetter writes each failed document as a JSON file in a directory.
+// Files are named after the document path, so repeated failures of the
+// same document overwrite the previous record.
+type DirDeadLetter struct {
+	dir string
+}
+
+// NewDirDeadLetter creates the directory if needed and returns a
+// DirDeadLetter writing into it.
+func NewDirDeadLetter(dir string) (*DirDeadLetter, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+	return &DirDeadLetter{dir: dir}, nil
+}
+
+// Put writes rec to the dead-letter directory.
+func (d *DirDeadLetter) Put(rec DeadLetterRecord) error {
+	data, err := json.MarshalIndent(rec, "", "  ")
+	if err != nil {
+		return err
+	}
+	name := fmt.Sprintf("%s-%s.json", filepath.Base(rec.Path), ContentHash([]byte(rec.Path))[:12])
+	tmp := filepath.Join(d.dir, "."+name+".tmp")
+	if err := os.WriteFile(tmp, data, 0o644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, filepath.Join(d.dir, name))
+}
+
+// stageError tags an error with the pipeline stage it occurred in and the
+// number of attempts made when the stage is retried.
+type stageError struct {
+	stage    string
+	err      error
+	attempts int
+}
+
+func (e *stageError) Error() string { return e.stage + ": " + e.err.Error() }
+func (e *stageError) Unwrap() error { return e.err }
@@ -0,0 +1,117 @@
+package pipeline
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"chunker-service/pkg/chunking"
+	"chunker-service/pkg/retry"
+)
+
+func TestRunDeadLettersFailedDocuments(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, "good.txt"), "a b")
+	writeFile(t, filepath.Join(dir, "bad.txt"), "c d")
+	writeFile(t, filepath.Join(dir, "m.json"), `{
+		"plan": {"window_size": 2, "overlap": 0, "mode": "tokens"},
+		"sources": [
+			{"path": "good.txt"},
+			{"path": "bad.txt", "plan": {"window_size": 2, "mode": "paragraphs"}}
+		],
+		"sinks": [{"type": "jsonl", "path": "out.jsonl"}],
+		"dead_letter": "dlq"
+	}`)
+
+	m, err := LoadManifest(filepath.Join(dir, "m.json"))
+	if err != nil {
+		t.Fatalf("load manifest: %v", err)
+	}
+	dl, err := NewDirDeadLetter(m.DeadLetterPath())
+	if err != nil {
+		t.Fatalf("dead letter: %v", err)
+	}
+	runner := NewRunner()
+	runner.DeadLetter = dl
+
+	report, err := runner.Run(m)
+	if err != nil {
+		t.Fatalf("run should continue past failures: %v", err)
+	}
+	if report.Documents != 1 || report.Failed != 1 {
+		t.Fatalf("unexpected report: %+v", report)
+	}
+
+	entries, err := os.ReadDir(filepath.Join(dir, "dlq"))
+	if err != nil || len(entries) != 1 {
+		t.Fatalf("expected one dead-letter record, got %v (%v)", entries, err)
+	}
+	data, err := os.ReadFile(filepath.Join(dir, "dlq", entries[0].Name()))
+	if err != nil {
+		t.Fatalf("read record: %v", err)
+	}
+	var rec DeadLetterRecord
+	if err := json.Unmarshal(data, &rec); err != nil {
+		t.Fatalf("decode record: %v", err)
+	}
+	if rec.Stage != StageChunk || rec.Attempts != 1 || rec.Text != "c d" || rec.Error == "" {
+		t.Fatalf("unexpected record: %+v", rec)
+	}
+	if filepath.Base(rec.Path) != "bad.txt" {
+		t.Fatalf("record path = %q", rec.Path)
+	}
+}
+
+func TestRunStopsWithoutDeadLetter(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, "bad.txt"), "c d")
+	m := &Manifest{
+		Sources: []Source{{Path: "bad.txt", Plan: &chunking.ChunkingPlan{WindowSize: 2, Mode: "paragraphs"}}},
+		Sinks:   []SinkConfig{{Type: "jsonl", Path: "out.jsonl"}},
+		baseDir: dir,
+	}
+	if _, err := NewRunner().Run(m); err == nil {
+		t.Fatalf("expected run to fail without a dead-letter location")
+	}
+}
+
+type failingSink struct{}
+
+func (failingSink) Write(Document, []chunking.Chunk) error { return errors.New("sink unavailable") }
+func (failingSink) Close() error                           { return nil }
+
+func TestRunDeadLetterRecordsSinkAttempts(t *testing.T) {
+	sinkFactories["failing"] = func(SinkConfig) (Sink, error) { return failingSink{}, nil }
+	defer delete(sinkFactories, "failing")
+
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, "doc.txt"), "a b")
+	m := &Manifest{
+		Plan:    &chunking.ChunkingPlan{WindowSize: 2, Mode: chunking.ModeTokens},
+		Sources: []Source{{Path: "doc.txt"}},
+		Sinks:   []SinkConfig{{Type: "failing", Path: t.Name(), Retry: &retry.Policy{MaxAttempts: 3}}},
+		baseDir: dir,
+	}
+	dl := &memoryDeadLetter{}
+	runner := NewRunner()
+	runner.DeadLetter = dl
+
+	if _, err := runner.Run(m); err != nil {
+		t.Fatalf("run failed: %v", err)
+	}
+	if len(dl.records) != 1 {
+		t.Fatalf("expected one dead-letter record, got %d", len(dl.records))
+	}
+	if rec := dl.records[0]; rec.Stage != StageSink || rec.Attempts != 3 {
+		t.Fatalf("unexpected record: %+v", rec)
+	}
+}
+
+type memoryDeadLetter struct{ records []DeadLetterRecord }
+
+func (d *memoryDeadLetter) Put(rec DeadLetterRecord) error {
+	d.records = append(d.records, rec)
+	return nil
+}
@@ -0,0 +1,64 @@
+package pipeline
+
+import (
+	"mime"
+	"path/filepath"
+	"strings"
+
+	"chunker-service/pkg/chunking"
+)
+
+// Document is a single input to a pipeline run.
+type Document struct {
+	Path string
+	Text string
+	Plan chunking.ChunkingPlan
+	Meta map[string]interface{}
+}
+
+// textMimeTypes covers common document extensions that the system MIME
+// tables either miss or disagree on across platforms.
+var textMimeTypes = map[string]string{
+	".md":       "text/markdown",
+	".markdown": "text/markdown",
+	".txt":      "text/plain",
+	".log":      "text/plain",
+	".rst":      "text/x-rst",
+	".csv":      "text/csv",
+}
+
+// MimeType derives a MIME type from the file extension of path, defaulting
+// to text/plain.
+func MimeType(path string) string {
+	ext := strings.ToLower(filepath.Ext(path))
+	if t, ok := textMimeTypes[ext]; ok {
+		return t
+	}
+	if t := mime.TypeByExtension(ext); t != "" {
+		return t
+	}
+	return "text/plain"
+}
+
+// FileMeta returns the well-known file metadata the chunker promotes into
+// typed Chunk fields.
+func FileMeta(path string) map[string]interface{} {
+	mimeType := MimeType(path)
+	return map[string]interface{}{
+		"file_name": filepath.Base(path),
+		"file_path": path,
+		"mime_type": mimeType,
+	}
+}
+
+// mergeMeta returns a new map containing the keys of each layer, with later
+// layers taking precedence.
+func mergeMeta(layers ...map[string]interface{}) map[string]interface{} {
+	out := map[string]interface{}{}
+	for _, layer := range layers {
+		for k, v := range layer {
+			out[k] = v
+		}
+	}
+	return out
+}
@@ -0,0 +1,151 @@
+package pipeline
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"time"
+
+	"chunker-service/pkg/chunking"
+	"chunker-service/pkg/yamljson"
+)
+
+// Manifest describes a reproducible corpus build: which sources to chunk,
+// with which plans and metadata, and where the resulting chunks go. It is
+// meant to be committed to version control next to the corpus.
+type Manifest struct {
+	Name    string                 `json:"name"`
+	Plan    *chunking.ChunkingPlan `json:"plan,omitempty"`
+	Meta    map[string]interface{} `json:"meta,omitempty"`
+	Sources []Source               `json:"sources"`
+	Sinks   []SinkConfig           `json:"sinks"`
+
+	// Checkpoint is an optional progress file used to resume interrupted
+	// runs.
+	Checkpoint string `json:"checkpoint,omitempty"`
+
+	// DeadLetter is an optional directory that receives failed documents
+	// instead of aborting the run.
+	DeadLetter string `json:"dead_letter,omitempty"`
+
+	// CreatedAt is an optional ingestion timestamp given to every chunk
+	// of the run, so rebuilding the corpus reproduces identical output.
+	CreatedAt *time.Time `json:"created_at,omitempty"`
+
+	// Routing optionally overlays plan presets by MIME type or extension
+	// on documents whose plan names no strategy.
+	Routing *chunking.Routing `json:"routing,omitempty"`
+
+	// Sample optionally restricts a run to a subset of the documents,
+	// for trying a plan on a large corpus; the report extrapolates the
+	// results to the whole corpus.
+	Sample *Sample `json:"sample,omitempty"`
+
+	// Queue optionally bounds the chunked documents waiting for the
+	// sinks and the size of sink write batches.
+	Queue *QueueConfig `json:"queue,omitempty"`
+
+	// baseDir is the directory relative source and sink paths are
+	// resolved against (the manifest's own directory when loaded from a
+	// file).
+	baseDir string
+}
+
+// Source is a single manifest entry. Path may be a glob. Plan replaces the
+// manifest default plan when set; Meta is merged over the manifest meta.
+type Source struct {
+	Path string                 `json:"path"`
+	Plan *chunking.ChunkingPlan `json:"plan,omitempty"`
+	Meta map[string]interface{} `json:"meta,omitempty"`
+}
+
+// LoadManifest reads a JSON or YAML manifest from path. YAML is detected by
+// the .yaml/.yml extension and uses the same field names as the JSON form.
+func LoadManifest(path string) (*Manifest, error) {
+	data, err := yamljson.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	m := &Manifest{}
+	if err := json.Unmarshal(data, m); err != nil {
+		return nil, fmt.Errorf("invalid manifest: %w", err)
+	}
+	m.baseDir = filepath.Dir(path)
+	if err := m.Validate(); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// Validate checks that every source has a usable plan and that at least
+// one sink is configured.
+func (m *Manifest) Validate() error {
+	if len(m.Sources) == 0 {
+		return errors.New("manifest has no sources")
+	}
+	if len(m.Sinks) == 0 {
+		return errors.New("manifest has no sinks")
+	}
+	for i, src := range m.Sources {
+		if src.Path == "" {
+			return fmt.Errorf("source %d: path is required", i)
+		}
+		if m.planFor(src) == nil {
+			return fmt.Errorf("source %d: no plan and no manifest default plan", i)
+		}
+	}
+	if m.Routing != nil {
+		if err := m.Routing.Validate(); err != nil {
+			return fmt.Errorf("routing: %w", err)
+		}
+	}
+	if m.Sample != nil {
+		if err := m.Sample.Validate(); err != nil {
+			return fmt.Errorf("sample: %w", err)
+		}
+	}
+	if m.Queue != nil {
+		if err := m.Queue.Validate(); err != nil {
+			return fmt.Errorf("queue: %w", err)
+		}
+	}
+	for i, sc := range m.Sinks {
+		if _, ok := sinkFactories[sc.Type]; !ok {
+			return fmt.Errorf("sink %d: unknown type %q", i, sc.Type)
+		}
+		if sc.Redaction != nil {
+			if err := sc.Redaction.Validate(); err != nil {
+				return fmt.Errorf("sink %d: %w", i, err)
+			}
+		}
+	}
+	return nil
+}
+
+func (m *Manifest) planFor(src Source) *chunking.ChunkingPlan {
+	if src.Plan != nil {
+		return src.Plan
+	}
+	return m.Plan
+}
+
+// CheckpointPath returns the manifest's checkpoint file resolved against
+// the manifest directory, or "" when none is configured.
+func (m *Manifest) CheckpointPath() string {
+	return m.resolve(m.Checkpoint)
+}
+
+// DeadLetterPath returns the manifest's dead-letter directory resolved
+// against the manifest directory, or "" when none is configured.
+func (m *Manifest) DeadLetterPath() string {
+	return m.resolve(m.DeadLetter)
+}
+
+func (m *Manifest) resolve(path string) string {
+	if path == "" || path == "-" || filepath.IsAbs(path) {
+		return path
+	}
+	return filepath.Join(m.baseDir, path)
+}
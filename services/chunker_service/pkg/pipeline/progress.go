@@ -0,0 +1,72 @@
+package pipeline
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+// Progress counts the documents of a run as they finish, so a long run
+// can report how far it has got. It is safe for concurrent use, and a nil
+// Progress ignores updates.
+type Progress struct {
+	mu     sync.Mutex
+	start  time.Time
+	total  int
+	docs   int
+	chunks int
+	bytes  int64
+}
+
+// NewProgress starts counting a run of total documents at start; a
+// Runner replaces total once it knows how many documents it will chunk.
+func NewProgress(total int, start time.Time) *Progress {
+	return &Progress{total: total, start: start}
+}
+
+// SetTotal sets how many documents the run covers.
+func (p *Progress) SetTotal(total int) {
+	if p == nil {
+		return
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.total = total
+}
+
+// Add counts a finished document of the given size that produced chunks.
+// Skipped and failed documents count too, with their chunks as zero.
+func (p *Progress) Add(bytes, chunks int) {
+	if p == nil {
+		return
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.docs++
+	p.chunks += chunks
+	p.bytes += int64(bytes)
+}
+
+// Fraction is the share of the documents done, in [0, 1].
+func (p *Progress) Fraction() float64 {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.total == 0 {
+		return 1
+	}
+	return min(float64(p.docs)/float64(p.total), 1)
+}
+
+// Line describes the progress at now: documents done of the total,
+// chunks produced and throughput since the start.
+func (p *Progress) Line(now time.Time) string {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	secs := now.Sub(p.start).Seconds()
+	var docRate, mbRate float64
+	if secs > 0 {
+		docRate = float64(p.docs) / secs
+		mbRate = float64(p.bytes) / 1e6 / secs
+	}
+	return fmt.Sprintf("%d/%d files, %d chunks, %.1f files/s, %.2f MB/s", p.docs, p.total, p.chunks, docRate, mbRate)
+}
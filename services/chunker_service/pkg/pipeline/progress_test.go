@@ -0,0 +1,29 @@
+package pipeline
+
+import (
+	"testing"
+	"time"
+)
+
+func TestProgress(t *testing.T) {
+	start := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	p := NewProgress(4, start)
+	if got := p.Line(start); got != "0/4 files, 0 chunks, 0.0 files/s, 0.00 MB/s" {
+		t.Fatalf("unexpected initial line %q", got)
+	}
+	p.Add(3_000_000, 5)
+	p.Add(1_000_000, 0)
+	if got := p.Fraction(); got != 0.5 {
+		t.Fatalf("expected half done, got %v", got)
+	}
+	if got, want := p.Line(start.Add(4*time.Second)), "2/4 files, 5 chunks, 0.5 files/s, 1.00 MB/s"; got != want {
+		t.Fatalf("expected %q, got %q", want, got)
+	}
+	if got := NewProgress(0, start).Fraction(); got != 1 {
+		t.Fatalf("an empty run should be done, got %v", got)
+	}
+
+	var none *Progress
+	none.SetTotal(1)
+	none.Add(1, 1)
+}
@@ -0,0 +1,90 @@
+package pipeline
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+)
+
+// Expirer is implemented by sinks that can report which of their chunks
+// are past their retention date.
+type Expirer interface {
+	Expired(now time.Time) ([]string, error)
+}
+
+// Deleter is implemented by sinks that can remove chunks by ID.
+type Deleter interface {
+	Delete(ids []string) error
+}
+
+// PurgeReport summarizes a purge. Each sink report counts the chunks the
+// sink was asked to delete.
+type PurgeReport struct {
+	Expired int          `json:"expired"`
+	Sinks   []SinkReport `json:"sinks"`
+}
+
+// Purge deletes chunks whose retention date has passed. The expired chunk
+// IDs are collected from every sink implementing Expirer, so a sink that
+// missed a write still learns about the deletion, and then sent to every
+// sink implementing Deleter. Sinks that can do neither are left untouched.
+func (r *Runner) Purge(m *Manifest) (report PurgeReport, err error) {
+	sinks, err := m.openSinks(true)
+	if err != nil {
+		return report, err
+	}
+
+	now := r.Clock.Now()
+	seen := map[string]bool{}
+	var ids []string
+	scanned := 0
+	for _, s := range sinks {
+		expirer, ok := s.Sink.(Expirer)
+		if !ok {
+			continue
+		}
+		scanned++
+		expired, err := expirer.Expired(now)
+		if err != nil {
+			closeSinks(sinks)
+			return report, fmt.Errorf("sink %s: %w", s.report.Name, err)
+		}
+		for _, id := range expired {
+			if !seen[id] {
+				seen[id] = true
+				ids = append(ids, id)
+			}
+		}
+	}
+	if scanned == 0 {
+		closeSinks(sinks)
+		return report, fmt.Errorf("purge needs a sink that can list expired chunks")
+	}
+	sort.Strings(ids)
+	report.Expired = len(ids)
+
+	var firstErr error
+	for _, s := range sinks {
+		deleter, ok := s.Sink.(Deleter)
+		if !ok || len(ids) == 0 {
+			continue
+		}
+		_, err := s.retrier.Do(context.Background(), func(context.Context) error {
+			return deleter.Delete(ids)
+		})
+		if err != nil {
+			s.report.Failed++
+			if firstErr == nil {
+				firstErr = fmt.Errorf("sink %s: %w", s.report.Name, err)
+			}
+			continue
+		}
+		s.report.Chunks += len(ids)
+	}
+	report.Sinks = sinkReports(sinks)
+	if err := closeSinks(sinks); firstErr == nil {
+		firstErr = err
+	}
+	return report, firstErr
+}
@@ -0,0 +1,63 @@
+package pipeline
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"chunker-service/pkg/chunking"
+)
+
+func TestPurgeDeletesExpiredChunks(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, "short.txt"), "a b")
+	writeFile(t, filepath.Join(dir, "long.txt"), "c d")
+	writeFile(t, filepath.Join(dir, "m.json"), `{
+		"created_at": "2026-01-01T00:00:00Z",
+		"plan": {"window_size": 1, "mode": "tokens", "retention": "1y"},
+		"sources": [
+			{"path": "short.txt", "plan": {"window_size": 1, "mode": "tokens", "retention": "30d"}},
+			{"path": "long.txt"}
+		],
+		"sinks": [
+			{"name": "store", "type": "jsonl", "path": "chunks.jsonl"},
+			{"name": "db", "type": "sql", "path": "chunks.sql"}
+		]
+	}`)
+	m, err := LoadManifest(filepath.Join(dir, "m.json"))
+	if err != nil {
+		t.Fatalf("load manifest: %v", err)
+	}
+	if _, err := NewRunner().Run(m); err != nil {
+		t.Fatalf("run failed: %v", err)
+	}
+
+	runner := NewRunner()
+	runner.Clock = chunking.FixedClock(time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC))
+	report, err := runner.Purge(m)
+	if err != nil {
+		t.Fatalf("purge failed: %v", err)
+	}
+	if report.Expired != 2 || report.Sinks[0].Chunks != 2 || report.Sinks[1].Chunks != 2 {
+		t.Fatalf("unexpected purge report: %+v", report)
+	}
+
+	chunks := readChunks(t, filepath.Join(dir, "chunks.jsonl"))
+	if len(chunks) != 2 {
+		t.Fatalf("expected 2 chunks left, got %d", len(chunks))
+	}
+	for _, ch := range chunks {
+		if !strings.HasSuffix(ch.FilePath, "long.txt") {
+			t.Errorf("chunk of %s should have been purged", ch.FilePath)
+		}
+	}
+	sql, err := os.ReadFile(filepath.Join(dir, "chunks.sql"))
+	if err != nil {
+		t.Fatalf("read sql output: %v", err)
+	}
+	if !strings.Contains(string(sql), `DELETE FROM "chunks" WHERE "id" IN (`) {
+		t.Errorf("sql sink should receive a DELETE, got:\n%s", sql)
+	}
+}
@@ -0,0 +1,82 @@
+package pipeline
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"chunker-service/pkg/chunking"
+)
+
+// Repair reconciles divergence between fan-out sinks. Every sink that
+// implements Lister reports the documents it holds; documents present in
+// some of those sinks but missing from others are re-chunked and appended
+// to the sinks that lack them. Documents missing from every sink are left
+// for a normal run. The returned report counts repaired documents per sink.
+func (r *Runner) Repair(m *Manifest) (report Report, err error) {
+	docs, err := m.documents()
+	if err != nil {
+		return report, err
+	}
+	sinks, err := m.openSinks(true)
+	if err != nil {
+		return report, err
+	}
+	defer func() { report.Sinks = sinkReports(sinks) }()
+
+	var listed []*managedSink
+	var held []map[string]bool
+	for _, s := range sinks {
+		lister, ok := s.Sink.(Lister)
+		if !ok {
+			continue
+		}
+		have, err := lister.Documents()
+		if err != nil {
+			closeSinks(sinks)
+			return report, fmt.Errorf("sink %s: %w", s.report.Name, err)
+		}
+		listed = append(listed, s)
+		held = append(held, have)
+	}
+	if len(listed) < 2 {
+		closeSinks(sinks)
+		return report, fmt.Errorf("repair needs at least two listable sinks, have %d", len(listed))
+	}
+
+	clock := r.clockFor(m)
+	for _, doc := range docs {
+		var missing []*managedSink
+		for i, s := range listed {
+			if !held[i][doc.Path] {
+				missing = append(missing, s)
+			}
+		}
+		if len(missing) == 0 || len(missing) == len(listed) {
+			report.Skipped++
+			continue
+		}
+
+		data, err := os.ReadFile(doc.Path)
+		if err != nil {
+			closeSinks(sinks)
+			return report, fmt.Errorf("%s: %w", doc.Path, err)
+		}
+		doc.Text = string(data)
+		chunks, err := r.Chunker.Chunk(doc.Text, doc.Plan, doc.Meta)
+		if err != nil {
+			closeSinks(sinks)
+			return report, fmt.Errorf("%s: %w", doc.Path, err)
+		}
+		chunking.Stamp(chunks, clock)
+		for _, s := range missing {
+			if _, err := s.write(context.Background(), doc, chunks); err != nil {
+				closeSinks(sinks)
+				return report, fmt.Errorf("%s: %w", doc.Path, err)
+			}
+		}
+		report.Documents++
+		report.Chunks += len(chunks)
+	}
+	return report, closeSinks(sinks)
+}
@@ -0,0 +1,63 @@
+package pipeline
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestRunFanOutAndRepair(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, "a.txt"), "a b")
+	writeFile(t, filepath.Join(dir, "b.txt"), "c d")
+	writeFile(t, filepath.Join(dir, "m.json"), `{
+		"plan": {"window_size": 2, "overlap": 0, "mode": "tokens"},
+		"sources": [{"path": "*.txt"}],
+		"sinks": [
+			{"name": "lexical", "type": "jsonl", "path": "lexical.jsonl"},
+			{"name": "vectors", "type": "jsonl", "path": "vectors.jsonl"}
+		]
+	}`)
+	m, err := LoadManifest(filepath.Join(dir, "m.json"))
+	if err != nil {
+		t.Fatalf("load manifest: %v", err)
+	}
+
+	report, err := NewRunner().Run(m)
+	if err != nil {
+		t.Fatalf("run failed: %v", err)
+	}
+	if len(report.Sinks) != 2 {
+		t.Fatalf("expected a report per sink, got %+v", report.Sinks)
+	}
+	for _, sr := range report.Sinks {
+		if sr.Documents != 2 || sr.Chunks != 2 || sr.Failed != 0 {
+			t.Fatalf("unexpected sink report: %+v", sr)
+		}
+	}
+
+	// Simulate divergence: the vectors sink lost b.txt.
+	writeFile(t, filepath.Join(dir, "vectors.jsonl"), firstLine(t, filepath.Join(dir, "lexical.jsonl")))
+
+	report, err = NewRunner().Repair(m)
+	if err != nil {
+		t.Fatalf("repair failed: %v", err)
+	}
+	if report.Documents != 1 || report.Sinks[0].Documents != 0 || report.Sinks[1].Documents != 1 {
+		t.Fatalf("unexpected repair report: %+v", report)
+	}
+	if got := len(readChunks(t, filepath.Join(dir, "vectors.jsonl"))); got != 2 {
+		t.Fatalf("vectors sink has %d chunks after repair, want 2", got)
+	}
+	if got := len(readChunks(t, filepath.Join(dir, "lexical.jsonl"))); got != 2 {
+		t.Fatalf("lexical sink has %d chunks after repair, want 2", got)
+	}
+}
+
+func firstLine(t *testing.T, path string) string {
+	t.Helper()
+	chunks := readChunks(t, path)
+	if len(chunks) == 0 {
+		t.Fatalf("%s is empty", path)
+	}
+	return mustJSON(t, chunks[0]) + "\n"
+}
@@ -0,0 +1,247 @@
+package pipeline
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"sort"
+
+	"chunker-service/pkg/chunking"
+	"chunker-service/pkg/fieldcrypt"
+	"chunker-service/pkg/fieldzip"
+	"chunker-service/pkg/redact"
+)
+
+// Report summarizes a completed pipeline run.
+type Report struct {
+	Documents int          `json:"documents"`
+	Skipped   int          `json:"skipped,omitempty"`
+	Failed    int          `json:"failed,omitempty"`
+	Chunks    int          `json:"chunks"`
+	Sinks     []SinkReport `json:"sinks"`
+
+	// Sample is set for sampled runs.
+	Sample *SampleReport `json:"sample,omitempty"`
+
+	// Queue is set for runs and shows whether the sinks kept up.
+	Queue *QueueReport `json:"queue,omitempty"`
+}
+
+// Runner executes manifests end-to-end: it expands sources, chunks every
+// document and writes the results to all configured sinks, chunking ahead
+// of the sinks through the bounded queue configured by Manifest.Queue.
+// Documents are written and reported in order. When Checkpoint
+// is set, documents it already records are skipped and sinks append to
+// their existing output instead of truncating it. When DeadLetter is set,
+// failing documents are recorded there and the run continues; otherwise the
+// run stops at the first failure. When Progress is set, every document
+// is counted there as it settles.
+type Runner struct {
+	Chunker    chunking.Chunker
+	Clock      chunking.Clock
+	Checkpoint *Checkpoint
+	DeadLetter DeadLetter
+	Progress   *Progress
+}
+
+// NewRunner constructs a Runner using the sliding window chunker.
+func NewRunner() *Runner {
+	return &Runner{
+		Chunker: chunking.NewSlidingWindowChunker(),
+		Clock:   chunking.SystemClock{},
+	}
+}
+
+// Run executes the manifest, or only its sample when it has one.
+func (r *Runner) Run(m *Manifest) (report Report, err error) {
+	docs, err := m.documents()
+	if err != nil {
+		return report, err
+	}
+	if m.Sample != nil {
+		if docs, report.Sample, err = sampleDocuments(docs, *m.Sample); err != nil {
+			return report, err
+		}
+		defer func() {
+			report.Sample.Chunks = report.Chunks
+			report.Sample.Extrapolate()
+		}()
+	}
+
+	r.Progress.SetTotal(len(docs))
+
+	resuming := r.Checkpoint != nil && r.Checkpoint.Len() > 0
+	sinks, err := m.openSinks(resuming)
+	if err != nil {
+		return report, err
+	}
+	defer func() { report.Sinks = sinkReports(sinks) }()
+
+	clock := r.clockFor(m)
+	queue, err := r.stream(docs, sinks, clock, m.Queue.withDefaults(), func(it queued) error {
+		r.Progress.Add(len(it.doc.Text), len(it.chunks))
+		switch {
+		case it.err != nil && r.DeadLetter != nil:
+			if dlErr := r.deadLetter(it.doc, it.err); dlErr != nil {
+				return fmt.Errorf("%s: dead-letter failed: %w (original error: %v)", it.doc.Path, dlErr, it.err)
+			}
+			report.Failed++
+		case it.err != nil:
+			return fmt.Errorf("%s: %w", it.doc.Path, it.err)
+		case it.skipped:
+			report.Skipped++
+		default:
+			report.Documents++
+			report.Chunks += len(it.chunks)
+		}
+		return nil
+	})
+	report.Queue = &queue
+	if err != nil {
+		closeSinks(sinks)
+		return report, err
+	}
+	return report, closeSinks(sinks)
+}
+
+// clockFor returns the clock stamping m's chunks: a fixed clock when the
+// manifest pins CreatedAt, the runner's clock otherwise.
+func (r *Runner) clockFor(m *Manifest) chunking.Clock {
+	if m.CreatedAt != nil {
+		return chunking.FixedClock(m.CreatedAt.UTC())
+	}
+	return r.Clock
+}
+
+func (r *Runner) deadLetter(doc Document, err error) error {
+	rec := DeadLetterRecord{
+		Path:     doc.Path,
+		Error:    err.Error(),
+		Attempts: 1,
+		FailedAt: r.Clock.Now(),
+		Plan:     doc.Plan,
+		Meta:     doc.Meta,
+		Text:     doc.Text,
+	}
+	var se *stageError
+	if errors.As(err, &se) {
+		rec.Stage = se.stage
+		rec.Error = se.err.Error()
+		if se.attempts > 0 {
+			rec.Attempts = se.attempts
+		}
+	}
+	return r.DeadLetter.Put(rec)
+}
+
+// openSinks constructs the manifest's sinks, appending to existing output
+// when appendAll is set.
+func (m *Manifest) openSinks(appendAll bool) ([]*managedSink, error) {
+	sinks := make([]*managedSink, 0, len(m.Sinks))
+	for _, sc := range m.Sinks {
+		sc.Path = m.resolve(sc.Path)
+		sc.Append = sc.Append || appendAll
+		var enc *fieldcrypt.Encryptor
+		if sc.Encryption != nil {
+			cfg := *sc.Encryption
+			if cfg.KeyFile != "" {
+				cfg.KeyFile = m.resolve(cfg.KeyFile)
+			}
+			var err error
+			if enc, err = fieldcrypt.New(cfg); err != nil {
+				closeSinks(sinks)
+				return nil, err
+			}
+		}
+		var zip *fieldzip.Compressor
+		if sc.Compression != nil {
+			var err error
+			if zip, err = fieldzip.New(*sc.Compression); err != nil {
+				closeSinks(sinks)
+				return nil, err
+			}
+		}
+		var red *redact.Redactor
+		if sc.Redaction != nil {
+			cfg := *sc.Redaction
+			if cfg.Mapping != nil {
+				mapping := *cfg.Mapping
+				mapping.Path = m.resolve(mapping.Path)
+				if mapping.Encryption.KeyFile != "" {
+					mapping.Encryption.KeyFile = m.resolve(mapping.Encryption.KeyFile)
+				}
+				cfg.Mapping = &mapping
+			}
+			var err error
+			if red, err = redact.New(cfg, sc.Append); err != nil {
+				closeSinks(sinks)
+				return nil, err
+			}
+		}
+		s, err := NewSink(sc)
+		if err != nil {
+			if red != nil {
+				red.Close()
+			}
+			closeSinks(sinks)
+			return nil, err
+		}
+		ms := newManagedSink(s, sc)
+		ms.redactor = red
+		ms.compressor = zip
+		ms.encryptor = enc
+		sinks = append(sinks, ms)
+	}
+	return sinks, nil
+}
+
+func sinkReports(sinks []*managedSink) []SinkReport {
+	out := make([]SinkReport, len(sinks))
+	for i, s := range sinks {
+		out[i] = s.report
+	}
+	return out
+}
+
+// closeSinks closes every sink and returns the first error encountered.
+func closeSinks(sinks []*managedSink) error {
+	var first error
+	for _, s := range sinks {
+		if err := s.Close(); err != nil && first == nil {
+			first = err
+		}
+	}
+	return first
+}
+
+// documents expands manifest sources into documents in a deterministic
+// order (manifest order, then lexical order within a glob).
+func (m *Manifest) documents() ([]Document, error) {
+	var docs []Document
+	for _, src := range m.Sources {
+		pattern := m.resolve(src.Path)
+		matches, err := filepath.Glob(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("source %q: %w", src.Path, err)
+		}
+		if len(matches) == 0 {
+			return nil, fmt.Errorf("source %q matched no files", src.Path)
+		}
+		sort.Strings(matches)
+		plan := m.planFor(src)
+		for _, path := range matches {
+			doc := Document{
+				Path: path,
+				Plan: *plan,
+				Meta: mergeMeta(FileMeta(path), m.Meta, src.Meta),
+			}
+			if m.Routing != nil && doc.Plan.Strategy == "" {
+				if _, err := m.Routing.Apply(&doc.Plan, doc.Meta); err != nil {
+					return nil, fmt.Errorf("source %q: %w", src.Path, err)
+				}
+			}
+			docs = append(docs, doc)
+		}
+	}
+	return docs, nil
+}
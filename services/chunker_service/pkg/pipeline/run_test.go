@@ -0,0 +1,286 @@
+package pipeline
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"chunker-service/pkg/chunking"
+	"chunker-service/pkg/fieldcrypt"
+	"chunker-service/pkg/redact"
+)
+
+func writeFile(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		t.Fatalf("mkdir: %v", err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatalf("write %s: %v", path, err)
+	}
+}
+
+func readChunks(t *testing.T, path string) []chunking.Chunk {
+	t.Helper()
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatalf("open output: %v", err)
+	}
+	defer f.Close()
+	var chunks []chunking.Chunk
+	sc := bufio.NewScanner(f)
+	for sc.Scan() {
+		var ch chunking.Chunk
+		if err := json.Unmarshal(sc.Bytes(), &ch); err != nil {
+			t.Fatalf("decode chunk: %v", err)
+		}
+		chunks = append(chunks, ch)
+	}
+	return chunks
+}
+
+func TestRunYAMLManifest(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, "docs", "a.md"), "a b c d")
+	writeFile(t, filepath.Join(dir, "docs", "b.md"), "e f")
+	writeFile(t, filepath.Join(dir, "notes.txt"), "L1\nL2\nL3")
+	writeFile(t, filepath.Join(dir, "corpus.yaml"), `
+name: test-corpus
+plan:
+  window_size: 2
+  overlap: 0
+  mode: tokens
+meta:
+  corpus: test
+sources:
+  - path: docs/*.md
+  - path: notes.txt
+    plan:
+      window_size: 3
+      overlap: 0
+      mode: lines
+    meta:
+      kind: notes
+sinks:
+  - type: jsonl
+    path: out/chunks.jsonl
+`)
+
+	m, err := LoadManifest(filepath.Join(dir, "corpus.yaml"))
+	if err != nil {
+		t.Fatalf("load manifest: %v", err)
+	}
+	runner := NewRunner()
+	fixed := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	runner.Clock = chunking.FixedClock(fixed)
+	runner.Progress = NewProgress(0, fixed)
+
+	report, err := runner.Run(m)
+	if err != nil {
+		t.Fatalf("run failed: %v", err)
+	}
+	if report.Documents != 3 || report.Chunks != 4 {
+		t.Fatalf("unexpected report: %+v", report)
+	}
+	if got, want := runner.Progress.Line(fixed.Add(2*time.Second)), "3/3 files, 4 chunks, 1.5 files/s, 0.00 MB/s"; got != want {
+		t.Fatalf("progress = %q, want %q", got, want)
+	}
+
+	chunks := readChunks(t, filepath.Join(dir, "out", "chunks.jsonl"))
+	if len(chunks) != 4 {
+		t.Fatalf("expected 4 chunks in output, got %d", len(chunks))
+	}
+	wantTexts := []string{"a b", "c d", "e f", "L1\nL2\nL3"}
+	for i, ch := range chunks {
+		if ch.Text != wantTexts[i] {
+			t.Errorf("chunk %d text = %q, want %q", i, ch.Text, wantTexts[i])
+		}
+		if ch.Extra["corpus"] != "test" {
+			t.Errorf("chunk %d missing manifest meta: %+v", i, ch.Extra)
+		}
+		if !ch.CreatedAt.Equal(fixed) {
+			t.Errorf("chunk %d created_at = %v, want %v", i, ch.CreatedAt, fixed)
+		}
+	}
+	if chunks[0].FileName != "a.md" || chunks[0].MimeType != "text/markdown" {
+		t.Errorf("file metadata not populated: %+v", chunks[0])
+	}
+	if chunks[3].Extra["kind"] != "notes" {
+		t.Errorf("source meta not applied: %+v", chunks[3].Extra)
+	}
+}
+
+func TestRunManifestRedaction(t *testing.T) {
+	key := bytes.Repeat([]byte{7}, 32)
+	t.Setenv("MAPPING_KEY", base64.StdEncoding.EncodeToString(key))
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, "a.txt"), "write to ana@example.com\nor bo@example.com")
+	writeFile(t, filepath.Join(dir, "corpus.yaml"), `
+plan:
+  window_size: 1
+  overlap: 0
+  mode: lines
+sources:
+  - path: a.txt
+sinks:
+  - type: jsonl
+    path: out/chunks.jsonl
+    redaction:
+      detectors: [email]
+      mapping:
+        path: secure/pii.jsonl
+        encryption:
+          key_id: pii-1
+          key_env: MAPPING_KEY
+    compression: {}
+  - type: jsonl
+    path: out/plain.jsonl
+`)
+	m, err := LoadManifest(filepath.Join(dir, "corpus.yaml"))
+	if err != nil {
+		t.Fatalf("load manifest: %v", err)
+	}
+	if _, err := NewRunner().Run(m); err != nil {
+		t.Fatalf("run failed: %v", err)
+	}
+
+	// Only the sink configured for it is redacted, ahead of compression.
+	if plain := readChunks(t, filepath.Join(dir, "out", "plain.jsonl")); plain[0].Text != "write to ana@example.com" {
+		t.Errorf("the other sink was redacted: %q", plain[0].Text)
+	}
+	data, _ := os.ReadFile(filepath.Join(dir, "out", "chunks.jsonl"))
+	if strings.Contains(string(data), "example.com") {
+		t.Fatalf("redacted sink holds the addresses: %s", data)
+	}
+	mapping, err := redact.LoadMapping(filepath.Join(dir, "secure", "pii.jsonl"))
+	if err != nil {
+		t.Fatalf("load mapping: %v", err)
+	}
+	enc, _ := fieldcrypt.NewWithKey("pii-1", key)
+	redacted := []string{"write to [EMAIL_1]", "or [EMAIL_1]"}
+	want := []string{"write to ana@example.com", "or bo@example.com"}
+	for i, ch := range readChunks(t, filepath.Join(dir, "out", "chunks.jsonl")) {
+		if ch.Text != redacted[i] {
+			t.Errorf("chunk %d text = %q, want %q", i, ch.Text, redacted[i])
+		}
+		if err := mapping.Restore(&ch, enc); err != nil || ch.Text != want[i] {
+			t.Errorf("chunk %d restored to %q, %v", i, ch.Text, err)
+		}
+	}
+}
+
+func TestRunManifestCreatedAt(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, "a.txt"), "a b c d")
+	writeFile(t, filepath.Join(dir, "corpus.yaml"), `
+name: pinned
+created_at: 2024-05-06T07:08:09Z
+plan:
+  window_size: 2
+  overlap: 0
+  mode: tokens
+sources:
+  - path: a.txt
+sinks:
+  - type: jsonl
+    path: out.jsonl
+`)
+
+	m, err := LoadManifest(filepath.Join(dir, "corpus.yaml"))
+	if err != nil {
+		t.Fatalf("load manifest: %v", err)
+	}
+	runner := NewRunner()
+	runner.Clock = chunking.FixedClock(time.Date(2030, 1, 1, 0, 0, 0, 0, time.UTC))
+	if _, err := runner.Run(m); err != nil {
+		t.Fatalf("run failed: %v", err)
+	}
+
+	want := time.Date(2024, 5, 6, 7, 8, 9, 0, time.UTC)
+	chunks := readChunks(t, filepath.Join(dir, "out.jsonl"))
+	if len(chunks) != 2 {
+		t.Fatalf("expected 2 chunks, got %d", len(chunks))
+	}
+	for i, ch := range chunks {
+		if !ch.CreatedAt.Equal(want) {
+			t.Errorf("chunk %d: expected created_at %v, got %v", i, want, ch.CreatedAt)
+		}
+	}
+}
+
+func TestRunManifestRouting(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, "docs", "app.log"), "L1\nL2\nL3\nL4")
+	writeFile(t, filepath.Join(dir, "docs", "notes.txt"), "a b c d")
+	writeFile(t, filepath.Join(dir, "corpus.yaml"), `
+name: routed
+plan:
+  window_size: 2
+  overlap: 0
+  mode: tokens
+routing:
+  presets:
+    log:
+      mode: lines
+      window_size: 4
+  routes:
+    .log: log
+sources:
+  - path: docs/*
+sinks:
+  - type: jsonl
+    path: out/chunks.jsonl
+`)
+
+	m, err := LoadManifest(filepath.Join(dir, "corpus.yaml"))
+	if err != nil {
+		t.Fatalf("load manifest: %v", err)
+	}
+	if _, err := NewRunner().Run(m); err != nil {
+		t.Fatalf("run failed: %v", err)
+	}
+	chunks := readChunks(t, filepath.Join(dir, "out", "chunks.jsonl"))
+	var texts []string
+	for _, ch := range chunks {
+		texts = append(texts, ch.Text)
+	}
+	want := []string{"L1\nL2\nL3\nL4", "a b", "c d"}
+	if mustJSON(t, texts) != mustJSON(t, want) {
+		t.Errorf("expected the log preset for app.log only, got %q", texts)
+	}
+}
+
+func TestLoadManifestValidation(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "m.json")
+
+	writeFile(t, path, `{"sources":[{"path":"x.txt"}],"sinks":[{"type":"jsonl"}]}`)
+	if _, err := LoadManifest(path); err == nil {
+		t.Fatalf("expected error for source without plan")
+	}
+
+	writeFile(t, path, `{"plan":{"window_size":2},"sources":[{"path":"x.txt"}],"sinks":[{"type":"kafka"}]}`)
+	if _, err := LoadManifest(path); err == nil {
+		t.Fatalf("expected error for unknown sink type")
+	}
+
+	writeFile(t, path, `{"plan":{"window_size":2},"routing":{"routes":{".md":"docs"}},"sources":[{"path":"x.txt"}],"sinks":[{"type":"jsonl"}]}`)
+	if _, err := LoadManifest(path); err == nil {
+		t.Fatalf("expected error for a route to an unknown preset")
+	}
+}
+
+func mustJSON(t *testing.T, v interface{}) string {
+	t.Helper()
+	data, err := json.Marshal(v)
+	if err != nil {
+		t.Fatalf("marshal: %v", err)
+	}
+	return string(data)
+}
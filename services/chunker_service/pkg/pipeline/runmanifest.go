@@ -0,0 +1,167 @@
+package pipeline
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"runtime/debug"
+	"sort"
+	"time"
+
+	"chunker-service/pkg/chunking"
+)
+
+// RunManifestVersion is the version of the RunManifest format.
+const RunManifestVersion = 1
+
+// RunManifest records a chunking run precisely enough to repeat it and
+// check that the repeat produced the same output. Unlike a corpus
+// Manifest, which says what to chunk, it is written by the run: the
+// resolved plan, the hash of every input and of the output produced for
+// it, and the code and tokenizer versions that produced it.
+//
+// Runs have no random seed: sampling picks documents by a hash of their
+// path, so Sample alone repeats it, and CreatedAt is the timestamp every
+// chunk was stamped with.
+type RunManifest struct {
+	Version     int                   `json:"version"`
+	CodeVersion string                `json:"code_version"`
+	CreatedAt   time.Time             `json:"created_at"`
+	Plan        chunking.ChunkingPlan `json:"plan"`
+	PlanHash    string                `json:"plan_hash"`
+	Routing     *chunking.Routing     `json:"routing,omitempty"`
+	Sample      *Sample               `json:"sample,omitempty"`
+
+	// Tokenizers maps every registered tokenizer to its digest (see
+	// tokenizer.Digest). TokenizerDir is where they were loaded from.
+	Tokenizers   map[string]string `json:"tokenizers"`
+	TokenizerDir string            `json:"tokenizer_dir,omitempty"`
+
+	// Format is the output format the output hashes were taken in, and
+	// FormatOptions its settings, such as the SQL table.
+	Format        string            `json:"format"`
+	FormatOptions map[string]string `json:"format_options,omitempty"`
+
+	Inputs []RunInput `json:"inputs"`
+}
+
+// RunInput is one document of a run. Path is empty for stdin. Hash is
+// the ContentHash of the document and OutputHash that of its chunks
+// written in the run's format. PlanHash is set when routing gave the
+// document a plan other than the run's.
+type RunInput struct {
+	Path       string                 `json:"path"`
+	Hash       string                 `json:"hash"`
+	Meta       map[string]interface{} `json:"meta,omitempty"`
+	PlanHash   string                 `json:"plan_hash,omitempty"`
+	Chunks     int                    `json:"chunks"`
+	OutputHash string                 `json:"output_hash"`
+}
+
+// PlanHash returns the ContentHash of plan's JSON form.
+func PlanHash(plan chunking.ChunkingPlan) string {
+	data, err := json.Marshal(plan)
+	if err != nil {
+		return ""
+	}
+	return ContentHash(data)
+}
+
+// CodeVersion identifies the running binary: its module version and,
+// for builds from a checkout, the commit it was built from, marked
+// "-dirty" when the checkout had local changes.
+func CodeVersion() string {
+	info, ok := debug.ReadBuildInfo()
+	if !ok {
+		return "unknown"
+	}
+	version := info.Main.Path + "@" + info.Main.Version
+	var revision, modified string
+	for _, s := range info.Settings {
+		switch s.Key {
+		case "vcs.revision":
+			revision = s.Value
+		case "vcs.modified":
+			modified = s.Value
+		}
+	}
+	if revision != "" {
+		version += "+" + revision
+		if modified == "true" {
+			version += "-dirty"
+		}
+	}
+	return version
+}
+
+// LoadRunManifest reads a run manifest and checks that its plan still
+// matches its plan hash, so an edited manifest is not mistaken for the
+// recorded run.
+func LoadRunManifest(path string) (*RunManifest, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var m RunManifest
+	if err := json.Unmarshal(data, &m); err != nil {
+		return nil, fmt.Errorf("invalid run manifest: %w", err)
+	}
+	if m.Version != RunManifestVersion {
+		return nil, fmt.Errorf("unsupported run manifest version %d", m.Version)
+	}
+	if got := PlanHash(m.Plan); got != m.PlanHash {
+		return nil, fmt.Errorf("plan does not match plan_hash %s (got %s)", m.PlanHash, got)
+	}
+	return &m, nil
+}
+
+// Save writes m as indented JSON.
+func (m *RunManifest) Save(path string) error {
+	data, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, append(data, '\n'), 0o644)
+}
+
+// Compare checks got, a repeat of m, against it. Differences are inputs
+// that changed or produced different output, and mean the run was not
+// reproduced. Warnings are differences in code or tokenizer versions;
+// they explain differences but do not cause any when the output matches.
+func (m *RunManifest) Compare(got *RunManifest) (warnings, differences []string) {
+	if got.CodeVersion != m.CodeVersion {
+		warnings = append(warnings, fmt.Sprintf("code version %s, recorded %s", got.CodeVersion, m.CodeVersion))
+	}
+	names := make([]string, 0, len(m.Tokenizers))
+	for name := range m.Tokenizers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if want, have := m.Tokenizers[name], got.Tokenizers[name]; have != want {
+			warnings = append(warnings, fmt.Sprintf("tokenizer %s has digest %q, recorded %q", name, have, want))
+		}
+	}
+
+	if len(got.Inputs) != len(m.Inputs) {
+		return warnings, append(differences, fmt.Sprintf("%d inputs, recorded %d", len(got.Inputs), len(m.Inputs)))
+	}
+	for i, want := range m.Inputs {
+		have := got.Inputs[i]
+		name := want.Path
+		if name == "" {
+			name = "stdin"
+		}
+		switch {
+		case have.Path != want.Path:
+			differences = append(differences, fmt.Sprintf("input %d is %s, recorded %s", i, have.Path, want.Path))
+		case have.Hash != want.Hash:
+			differences = append(differences, fmt.Sprintf("%s: content changed", name))
+		case have.Chunks != want.Chunks:
+			differences = append(differences, fmt.Sprintf("%s: %d chunks, recorded %d", name, have.Chunks, want.Chunks))
+		case have.OutputHash != want.OutputHash:
+			differences = append(differences, fmt.Sprintf("%s: output differs", name))
+		}
+	}
+	return warnings, differences
+}
@@ -0,0 +1,80 @@
+package pipeline
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"chunker-service/pkg/chunking"
+)
+
+func TestRunManifestRoundTrip(t *testing.T) {
+	plan := chunking.ChunkingPlan{WindowSize: 3, Mode: chunking.ModeLines}
+	m := &RunManifest{
+		Version:     RunManifestVersion,
+		CodeVersion: CodeVersion(),
+		Plan:        plan,
+		PlanHash:    PlanHash(plan),
+		Tokenizers:  map[string]string{"whitespace": "builtin"},
+		Format:      "jsonl",
+		Inputs:      []RunInput{{Path: "a.md", Hash: "1", Chunks: 2, OutputHash: "2"}},
+	}
+	path := filepath.Join(t.TempDir(), "run.json")
+	if err := m.Save(path); err != nil {
+		t.Fatal(err)
+	}
+	got, err := LoadRunManifest(path)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !reflect.DeepEqual(got, m) {
+		t.Fatalf("expected %+v, got %+v", m, got)
+	}
+
+	// An edited plan no longer matches its hash.
+	m.Plan.WindowSize = 4
+	data, _ := json.Marshal(m)
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := LoadRunManifest(path); err == nil || !strings.Contains(err.Error(), "plan_hash") {
+		t.Fatalf("expected a plan hash mismatch, got %v", err)
+	}
+}
+
+func TestRunManifestCompare(t *testing.T) {
+	want := &RunManifest{
+		CodeVersion: "v1",
+		Tokenizers:  map[string]string{"whitespace": "builtin", "cl100k_base": "abc"},
+		Inputs: []RunInput{
+			{Path: "a.md", Hash: "a", Chunks: 2, OutputHash: "oa"},
+			{Path: "b.md", Hash: "b", Chunks: 1, OutputHash: "ob"},
+			{Path: "", Hash: "s", Chunks: 1, OutputHash: "os"},
+		},
+	}
+	same := *want
+	same.CodeVersion = "v2"
+	same.Tokenizers = map[string]string{"whitespace": "builtin", "cl100k_base": "def"}
+	warnings, differences := want.Compare(&same)
+	if len(differences) != 0 {
+		t.Fatalf("expected no differences, got %v", differences)
+	}
+	if len(warnings) != 2 {
+		t.Fatalf("expected code version and tokenizer warnings, got %v", warnings)
+	}
+
+	changed := *want
+	changed.Inputs = []RunInput{
+		{Path: "a.md", Hash: "a2", Chunks: 2, OutputHash: "oa"},
+		{Path: "b.md", Hash: "b", Chunks: 1, OutputHash: "ob2"},
+		{Path: "", Hash: "s", Chunks: 3, OutputHash: "os"},
+	}
+	_, differences = want.Compare(&changed)
+	expected := []string{"a.md: content changed", "b.md: output differs", "stdin: 3 chunks, recorded 1"}
+	if !reflect.DeepEqual(differences, expected) {
+		t.Fatalf("expected %q, got %q", expected, differences)
+	}
+}
@@ -0,0 +1,106 @@
+package pipeline
+
+import (
+	"errors"
+	"hash/fnv"
+	"math"
+	"os"
+)
+
+// Sample selects a subset of a corpus's documents for exploratory runs,
+// so a plan can be tried on a huge corpus without chunking all of it.
+// Rate keeps each document with that probability, decided by a hash of
+// its path so that reruns pick the same documents. First keeps the first
+// documents and Every every Every-th document after them. Rate cannot be
+// combined with First or Every.
+type Sample struct {
+	Rate  float64 `json:"rate,omitempty"`
+	First int     `json:"first,omitempty"`
+	Every int     `json:"every,omitempty"`
+}
+
+// Validate rejects rates outside (0, 1], negative counts, samples that
+// keep nothing and a rate combined with first or every.
+func (s Sample) Validate() error {
+	switch {
+	case s.Rate < 0 || s.Rate > 1 || math.IsNaN(s.Rate):
+		return errors.New("rate must be in (0, 1]")
+	case s.First < 0 || s.Every < 0:
+		return errors.New("first and every must be >= 0")
+	case s.Rate > 0 && (s.First > 0 || s.Every > 0):
+		return errors.New("rate cannot be combined with first or every")
+	case s.Rate == 0 && s.First == 0 && s.Every == 0:
+		return errors.New("set rate, or first and/or every")
+	}
+	return nil
+}
+
+// Keep reports whether the i-th document (counting from zero), at path,
+// is in the sample.
+func (s Sample) Keep(i int, path string) bool {
+	if s.Rate > 0 {
+		h := fnv.New64a()
+		h.Write([]byte(path))
+		return float64(h.Sum64())/float64(math.MaxUint64) < s.Rate
+	}
+	if i < s.First {
+		return true
+	}
+	return s.Every > 0 && (i-s.First)%s.Every == s.Every-1
+}
+
+// SampleReport extrapolates a sampled run to the whole corpus. Chunks
+// are estimated in proportion to input bytes, which are known for every
+// document without chunking it, so a sample of mostly small documents
+// does not underestimate a corpus with a few large ones.
+type SampleReport struct {
+	Documents       int   `json:"documents"`
+	Sampled         int   `json:"sampled"`
+	InputBytes      int64 `json:"input_bytes"`
+	SampledBytes    int64 `json:"sampled_bytes"`
+	Chunks          int   `json:"chunks"`
+	EstimatedChunks int   `json:"estimated_chunks"`
+}
+
+// Add counts a corpus document of size bytes, which was sampled when
+// sampled is set.
+func (r *SampleReport) Add(size int64, sampled bool) {
+	r.Documents++
+	r.InputBytes += size
+	if sampled {
+		r.Sampled++
+		r.SampledBytes += size
+	}
+}
+
+// Extrapolate sets EstimatedChunks from Chunks, the chunks the sample
+// produced.
+func (r *SampleReport) Extrapolate() {
+	switch {
+	case r.SampledBytes > 0:
+		r.EstimatedChunks = int(math.Round(float64(r.Chunks) * float64(r.InputBytes) / float64(r.SampledBytes)))
+	case r.Sampled > 0:
+		r.EstimatedChunks = int(math.Round(float64(r.Chunks) * float64(r.Documents) / float64(r.Sampled)))
+	default:
+		r.EstimatedChunks = 0
+	}
+}
+
+// sampleDocuments returns the documents in s and a report counting the
+// whole corpus.
+func sampleDocuments(docs []Document, s Sample) ([]Document, *SampleReport, error) {
+	report := &SampleReport{}
+	var kept []Document
+	for i, doc := range docs {
+		info, err := os.Stat(doc.Path)
+		if err != nil {
+			return nil, nil, err
+		}
+		keep := s.Keep(i, doc.Path)
+		report.Add(info.Size(), keep)
+		if keep {
+			kept = append(kept, doc)
+		}
+	}
+	return kept, report, nil
+}
@@ -0,0 +1,82 @@
+package pipeline
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestSampleKeep(t *testing.T) {
+	s := Sample{First: 2, Every: 3}
+	var kept []int
+	for i := 0; i < 10; i++ {
+		if s.Keep(i, "") {
+			kept = append(kept, i)
+		}
+	}
+	if got, want := fmt.Sprint(kept), "[0 1 4 7]"; got != want {
+		t.Errorf("expected %s, got %s", want, got)
+	}
+
+	rate := Sample{Rate: 0.25}
+	n := 0
+	for i := 0; i < 4000; i++ {
+		path := fmt.Sprintf("docs/%d.md", i)
+		if rate.Keep(i, path) != rate.Keep(0, path) {
+			t.Fatalf("%s: rate sampling should depend only on the path", path)
+		}
+		if rate.Keep(i, path) {
+			n++
+		}
+	}
+	if n < 900 || n > 1100 {
+		t.Errorf("expected about 1000 of 4000 documents at rate 0.25, got %d", n)
+	}
+
+	for _, bad := range []Sample{{}, {Rate: 1.5}, {Rate: 0.5, First: 1}, {Every: -1}} {
+		if err := bad.Validate(); err == nil {
+			t.Errorf("%+v: expected a validation error", bad)
+		}
+	}
+}
+
+func TestRunManifestSample(t *testing.T) {
+	dir := t.TempDir()
+	for i := 0; i < 6; i++ {
+		// Document i has i+1 lines.
+		writeFile(t, filepath.Join(dir, "docs", fmt.Sprintf("%d.txt", i)), strings.Repeat("line line\n", i)+"line line")
+	}
+	writeFile(t, filepath.Join(dir, "corpus.yaml"), `
+name: sampled
+plan:
+  window_size: 1
+  overlap: 0
+  mode: lines
+sample:
+  first: 1
+  every: 2
+sources:
+  - path: docs/*.txt
+sinks:
+  - type: jsonl
+    path: out/chunks.jsonl
+`)
+
+	m, err := LoadManifest(filepath.Join(dir, "corpus.yaml"))
+	if err != nil {
+		t.Fatalf("load manifest: %v", err)
+	}
+	report, err := NewRunner().Run(m)
+	if err != nil {
+		t.Fatalf("run failed: %v", err)
+	}
+	// Documents 0, 2 and 4 are sampled: 1+3+5 lines of the corpus's 21.
+	want := SampleReport{Documents: 6, Sampled: 3, InputBytes: 204, SampledBytes: 87, Chunks: 9, EstimatedChunks: 21}
+	if report.Sample == nil || *report.Sample != want {
+		t.Fatalf("expected sample report %+v, got %+v", want, report.Sample)
+	}
+	if report.Documents != 3 || len(readChunks(t, filepath.Join(dir, "out", "chunks.jsonl"))) != 9 {
+		t.Errorf("expected only the sampled documents written, got %+v", report)
+	}
+}
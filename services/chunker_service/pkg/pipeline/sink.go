@@ -0,0 +1,417 @@
+package pipeline
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+
+	"chunker-service/pkg/chunking"
+	"chunker-service/pkg/fieldcrypt"
+	"chunker-service/pkg/fieldzip"
+	"chunker-service/pkg/redact"
+	"chunker-service/pkg/retry"
+	"chunker-service/pkg/sqlout"
+)
+
+// Sink receives the chunks produced for each document of a run.
+type Sink interface {
+	Write(doc Document, chunks []chunking.Chunk) error
+	Close() error
+}
+
+// SinkConfig selects and configures a sink in a manifest.
+type SinkConfig struct {
+	// Name identifies the sink in reports; it defaults to type:path.
+	Name   string        `json:"name,omitempty"`
+	Type   string        `json:"type"`
+	Path   string        `json:"path,omitempty"`
+	Append bool          `json:"append,omitempty"`
+	Retry  *retry.Policy `json:"retry,omitempty"`
+
+	// Table configures the "sql" sink.
+	Table *sqlout.Table `json:"table,omitempty"`
+
+	// Encryption, when set, encrypts chunk text before it reaches the
+	// sink and records the key ID in Extra["encryption"].
+	Encryption *fieldcrypt.Config `json:"encryption,omitempty"`
+
+	// Compression, when set, compresses chunk text before it reaches the
+	// sink, ahead of any encryption, and records the algorithm in
+	// Extra["compression"].
+	Compression *fieldzip.Config `json:"compression,omitempty"`
+
+	// Redaction, when set, masks PII and secrets in chunk text ahead of
+	// compression and encryption, and records the matches per detector
+	// in Extra["redaction"].
+	Redaction *redact.Config `json:"redaction,omitempty"`
+}
+
+var sinkFactories = map[string]func(cfg SinkConfig) (Sink, error){
+	"jsonl": newJSONLSink,
+	"sql":   newSQLSink,
+}
+
+// NewSink constructs the sink described by cfg.
+func NewSink(cfg SinkConfig) (Sink, error) {
+	factory, ok := sinkFactories[cfg.Type]
+	if !ok {
+		return nil, fmt.Errorf("unknown sink type %q", cfg.Type)
+	}
+	return factory(cfg)
+}
+
+// Lister is implemented by sinks that can report which documents they
+// already hold, keyed by file path. It is used to detect and repair
+// divergence between fan-out sinks.
+type Lister interface {
+	Documents() (map[string]bool, error)
+}
+
+// DocumentChunks is a document and the chunks produced for it.
+type DocumentChunks struct {
+	Doc    Document
+	Chunks []chunking.Chunk
+}
+
+// BatchWriter is implemented by sinks that write several documents at
+// once more efficiently than one at a time, such as the sql sink, which
+// renders a batch as a single statement. Runs use it to write batches;
+// other sinks receive one Write per document of the batch.
+type BatchWriter interface {
+	WriteBatch(batch []DocumentChunks) error
+}
+
+// SinkReport counts what a single sink received during a run.
+type SinkReport struct {
+	Name      string `json:"name"`
+	Documents int    `json:"documents"`
+	Chunks    int    `json:"chunks"`
+	Failed    int    `json:"failed,omitempty"`
+}
+
+// managedSink wraps a configured sink with its retry policy, optional
+// payload redaction, compression and encryption and per-sink counters.
+type managedSink struct {
+	Sink
+	retrier    *retry.Retrier
+	redactor   *redact.Redactor
+	compressor *fieldzip.Compressor
+	encryptor  *fieldcrypt.Encryptor
+	report     SinkReport
+}
+
+func newManagedSink(s Sink, cfg SinkConfig) *managedSink {
+	policy := retry.Policy{}
+	if cfg.Retry != nil {
+		policy = *cfg.Retry
+	}
+	name := cfg.Name
+	if name == "" {
+		name = cfg.Type
+		if cfg.Path != "" {
+			name += ":" + cfg.Path
+		}
+	}
+	return &managedSink{
+		Sink:    s,
+		retrier: retry.New("sink/"+name, policy),
+		report:  SinkReport{Name: name},
+	}
+}
+
+// write writes chunks and returns the number of attempts made.
+func (s *managedSink) write(ctx context.Context, doc Document, chunks []chunking.Chunk) (int, error) {
+	return s.writeBatch(ctx, []DocumentChunks{{Doc: doc, Chunks: chunks}})
+}
+
+// writeBatch writes a batch of documents, retried as a whole, and returns
+// the number of attempts made. A failure counts against every document
+// of the batch.
+func (s *managedSink) writeBatch(ctx context.Context, batch []DocumentChunks) (int, error) {
+	if s.redactor != nil {
+		redacted := make([]DocumentChunks, len(batch))
+		for i, dc := range batch {
+			chunks, err := s.redactor.RedactChunks(dc.Chunks)
+			if err != nil {
+				s.report.Failed += len(batch)
+				return 0, fmt.Errorf("sink %s: %w", s.report.Name, err)
+			}
+			redacted[i] = DocumentChunks{Doc: dc.Doc, Chunks: chunks}
+		}
+		batch = redacted
+	}
+	if s.compressor != nil {
+		compressed := make([]DocumentChunks, len(batch))
+		for i, dc := range batch {
+			chunks, err := s.compressor.CompressChunks(dc.Chunks)
+			if err != nil {
+				s.report.Failed += len(batch)
+				return 0, fmt.Errorf("sink %s: %w", s.report.Name, err)
+			}
+			compressed[i] = DocumentChunks{Doc: dc.Doc, Chunks: chunks}
+		}
+		batch = compressed
+	}
+	if s.encryptor != nil {
+		encrypted := make([]DocumentChunks, len(batch))
+		for i, dc := range batch {
+			chunks, err := s.encryptor.EncryptChunks(dc.Chunks)
+			if err != nil {
+				s.report.Failed += len(batch)
+				return 0, fmt.Errorf("sink %s: %w", s.report.Name, err)
+			}
+			encrypted[i] = DocumentChunks{Doc: dc.Doc, Chunks: chunks}
+		}
+		batch = encrypted
+	}
+	attempts, err := s.retrier.Do(ctx, func(context.Context) error {
+		if bw, ok := s.Sink.(BatchWriter); ok {
+			return bw.WriteBatch(batch)
+		}
+		for _, dc := range batch {
+			if err := s.Sink.Write(dc.Doc, dc.Chunks); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		s.report.Failed += len(batch)
+		return attempts, fmt.Errorf("sink %s: %w", s.report.Name, err)
+	}
+	for _, dc := range batch {
+		s.report.Documents++
+		s.report.Chunks += len(dc.Chunks)
+	}
+	return attempts, nil
+}
+
+// Close closes the sink and then the redaction mapping, if any.
+func (s *managedSink) Close() error {
+	err := s.Sink.Close()
+	if s.redactor != nil {
+		if rerr := s.redactor.Close(); err == nil {
+			err = rerr
+		}
+	}
+	return err
+}
+
+// jsonlSink writes one JSON-encoded chunk per line to a file, or to stdout
+// when the path is empty or "-".
+type jsonlSink struct {
+	path string
+	w    io.Writer
+	f    *os.File
+	enc  *json.Encoder
+}
+
+func newJSONLSink(cfg SinkConfig) (Sink, error) {
+	w, f, err := openOutput(cfg)
+	if err != nil {
+		return nil, err
+	}
+	s := &jsonlSink{w: w, f: f, enc: json.NewEncoder(w)}
+	if f != nil {
+		s.path = cfg.Path
+	}
+	return s, nil
+}
+
+// openOutput opens the sink's file, truncating it unless cfg.Append is
+// set, or returns stdout when the path is empty or "-".
+func openOutput(cfg SinkConfig) (io.Writer, *os.File, error) {
+	if cfg.Path == "" || cfg.Path == "-" {
+		return os.Stdout, nil, nil
+	}
+	if err := os.MkdirAll(filepath.Dir(cfg.Path), 0o755); err != nil {
+		return nil, nil, err
+	}
+	flags := os.O_CREATE | os.O_WRONLY | os.O_TRUNC
+	if cfg.Append {
+		flags = os.O_CREATE | os.O_WRONLY | os.O_APPEND
+	}
+	f, err := os.OpenFile(cfg.Path, flags, 0o644)
+	if err != nil {
+		return nil, nil, err
+	}
+	return f, f, nil
+}
+
+func (s *jsonlSink) Write(doc Document, chunks []chunking.Chunk) error {
+	for _, ch := range chunks {
+		if err := s.enc.Encode(ch); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// WriteBatch writes the chunks of the whole batch with a single write.
+func (s *jsonlSink) WriteBatch(batch []DocumentChunks) error {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	for _, dc := range batch {
+		for _, ch := range dc.Chunks {
+			if err := enc.Encode(ch); err != nil {
+				return err
+			}
+		}
+	}
+	_, err := s.w.Write(buf.Bytes())
+	return err
+}
+
+func (s *jsonlSink) Close() error {
+	if s.f != nil {
+		return s.f.Close()
+	}
+	return nil
+}
+
+// Documents returns the file paths of the documents already written to the
+// sink's file. Sinks writing to stdout cannot be listed.
+func (s *jsonlSink) Documents() (map[string]bool, error) {
+	docs := map[string]bool{}
+	err := s.scan(func(ch chunking.Chunk) { docs[ch.FilePath] = true })
+	if err != nil {
+		return nil, err
+	}
+	return docs, nil
+}
+
+// Expired returns the IDs of chunks in the sink's file whose retention
+// ended before now.
+func (s *jsonlSink) Expired(now time.Time) ([]string, error) {
+	var ids []string
+	err := s.scan(func(ch chunking.Chunk) {
+		if ch.Expired(now) {
+			ids = append(ids, ch.ID)
+		}
+	})
+	return ids, err
+}
+
+// Delete rewrites the sink's file without the given chunks.
+func (s *jsonlSink) Delete(ids []string) error {
+	if s.path == "" {
+		return errors.New("stdout sink cannot delete chunks")
+	}
+	drop := make(map[string]bool, len(ids))
+	for _, id := range ids {
+		drop[id] = true
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(s.path), filepath.Base(s.path)+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	enc := json.NewEncoder(tmp)
+	var encErr error
+	err = tmp.Chmod(0o644)
+	if err == nil {
+		err = s.scan(func(ch chunking.Chunk) {
+			if !drop[ch.ID] && encErr == nil {
+				encErr = enc.Encode(ch)
+			}
+		})
+	}
+	if err == nil {
+		err = encErr
+	}
+	if cerr := tmp.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return err
+	}
+	if err := os.Rename(tmp.Name(), s.path); err != nil {
+		return err
+	}
+	// Keep appending to the rewritten file rather than the replaced one.
+	if s.f != nil {
+		s.f.Close()
+	}
+	f, err := os.OpenFile(s.path, os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return err
+	}
+	s.f, s.w, s.enc = f, f, json.NewEncoder(f)
+	return nil
+}
+
+// scan decodes every chunk in the sink's file.
+func (s *jsonlSink) scan(fn func(chunking.Chunk)) error {
+	if s.path == "" {
+		return errors.New("stdout sink cannot be listed")
+	}
+	f, err := os.Open(s.path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	dec := json.NewDecoder(f)
+	for {
+		var ch chunking.Chunk
+		if err := dec.Decode(&ch); err == io.EOF {
+			return nil
+		} else if err != nil {
+			return fmt.Errorf("%s: %w", s.path, err)
+		}
+		fn(ch)
+	}
+}
+
+// sqlSink writes each document's chunks as SQL statements for psql.
+type sqlSink struct {
+	table sqlout.Table
+	w     io.Writer
+	f     *os.File
+}
+
+func newSQLSink(cfg SinkConfig) (Sink, error) {
+	var table sqlout.Table
+	if cfg.Table != nil {
+		table = *cfg.Table
+	}
+	if err := table.Validate(); err != nil {
+		return nil, err
+	}
+	w, f, err := openOutput(cfg)
+	if err != nil {
+		return nil, err
+	}
+	return &sqlSink{table: table, w: w, f: f}, nil
+}
+
+func (s *sqlSink) Write(doc Document, chunks []chunking.Chunk) error {
+	return s.table.Write(s.w, chunks)
+}
+
+// WriteBatch writes the chunks of the whole batch as one statement.
+func (s *sqlSink) WriteBatch(batch []DocumentChunks) error {
+	var chunks []chunking.Chunk
+	for _, dc := range batch {
+		chunks = append(chunks, dc.Chunks...)
+	}
+	return s.table.Write(s.w, chunks)
+}
+
+// Delete emits a DELETE statement for the given chunks.
+func (s *sqlSink) Delete(ids []string) error {
+	return s.table.WriteDelete(s.w, ids)
+}
+
+func (s *sqlSink) Close() error {
+	if s.f != nil {
+		return s.f.Close()
+	}
+	return nil
+}
@@ -0,0 +1,277 @@
+package pipeline
+
+import (
+	"context"
+	"errors"
+	"math"
+	"os"
+	"sync"
+	"time"
+
+	"chunker-service/pkg/chunking"
+)
+
+// Queue defaults.
+const (
+	DefaultQueueSize      = 16
+	DefaultMaxBatchChunks = 1000
+)
+
+// QueueConfig bounds the queue between chunking and sink writing. Chunking
+// runs ahead of the sinks until Size chunked documents are waiting, then
+// blocks, so a slow sink slows the run down instead of letting chunks pile
+// up in memory. The sinks are written in batches of whatever is queued,
+// up to MaxBatchChunks chunks: one document at a time while they keep up,
+// larger batches as they fall behind.
+type QueueConfig struct {
+	Size           int `json:"size,omitempty"`
+	MaxBatchChunks int `json:"max_batch_chunks,omitempty"`
+}
+
+// Validate rejects negative sizes.
+func (q QueueConfig) Validate() error {
+	if q.Size < 0 || q.MaxBatchChunks < 0 {
+		return errors.New("size and max_batch_chunks must be >= 0")
+	}
+	return nil
+}
+
+func (q *QueueConfig) withDefaults() QueueConfig {
+	out := QueueConfig{Size: DefaultQueueSize, MaxBatchChunks: DefaultMaxBatchChunks}
+	if q != nil && q.Size > 0 {
+		out.Size = q.Size
+	}
+	if q != nil && q.MaxBatchChunks > 0 {
+		out.MaxBatchChunks = q.MaxBatchChunks
+	}
+	return out
+}
+
+// QueueReport shows how the sinks kept up with chunking. Depths are
+// sampled each time a document is queued. A queue that is often full,
+// with large batches and chunking blocked for much of the run, means the
+// sinks are the bottleneck.
+type QueueReport struct {
+	Capacity       int     `json:"capacity"`
+	MaxDepth       int     `json:"max_depth"`
+	AvgDepth       float64 `json:"avg_depth"`
+	Batches        int     `json:"batches"`
+	AvgBatch       float64 `json:"avg_batch"`
+	BlockedSeconds float64 `json:"blocked_seconds"`
+
+	queued, depthSum, batchSum int
+}
+
+func (q *QueueReport) finish() {
+	if q.queued > 0 {
+		q.AvgDepth = round2(float64(q.depthSum) / float64(q.queued))
+	}
+	if q.Batches > 0 {
+		q.AvgBatch = round2(float64(q.batchSum) / float64(q.Batches))
+	}
+	q.BlockedSeconds = round2(q.BlockedSeconds)
+}
+
+func round2(f float64) float64 {
+	return math.Round(f*100) / 100
+}
+
+// queued is a document ready for the sinks, or the reason it is not.
+type queued struct {
+	doc     Document
+	hash    string
+	chunks  []chunking.Chunk
+	skipped bool
+	err     error
+}
+
+func (it queued) writable() bool { return it.err == nil && !it.skipped }
+
+// produce reads and chunks docs in order into queue until done is closed,
+// then closes queue. It records queue depths and the time it waits for
+// room in stats.
+func (r *Runner) produce(docs []Document, clock chunking.Clock, queue chan<- queued, done <-chan struct{}, stats *QueueReport) {
+	defer close(queue)
+	for _, doc := range docs {
+		select {
+		case <-done:
+			return
+		default:
+		}
+		it := r.prepare(doc, clock)
+		select {
+		case queue <- it:
+		default:
+			start := time.Now()
+			select {
+			case queue <- it:
+			case <-done:
+				return
+			}
+			stats.BlockedSeconds += time.Since(start).Seconds()
+		}
+		depth := len(queue)
+		stats.queued++
+		stats.depthSum += depth
+		if depth > stats.MaxDepth {
+			stats.MaxDepth = depth
+		}
+	}
+}
+
+// prepare reads and chunks a single document, skipping it when the
+// checkpoint already holds it. Errors are tagged with the stage that
+// failed.
+func (r *Runner) prepare(doc Document, clock chunking.Clock) queued {
+	data, err := os.ReadFile(doc.Path)
+	if err != nil {
+		return queued{doc: doc, err: &stageError{stage: StageRead, err: err}}
+	}
+	it := queued{doc: doc, hash: ContentHash(data)}
+	if r.Checkpoint != nil && r.Checkpoint.Done(doc.Path, it.hash) {
+		it.skipped = true
+		return it
+	}
+	it.doc.Text = string(data)
+	if it.chunks, err = r.Chunker.Chunk(it.doc.Text, it.doc.Plan, it.doc.Meta); err != nil {
+		it.err = &stageError{stage: StageChunk, err: err}
+		return it
+	}
+	chunking.Stamp(it.chunks, clock)
+	return it
+}
+
+// stream chunks docs in the background and writes them to sinks from the
+// queue, calling settle with every document's outcome in order. It stops
+// at the first error settle returns.
+func (r *Runner) stream(docs []Document, sinks []*managedSink, clock chunking.Clock, cfg QueueConfig, settle func(queued) error) (QueueReport, error) {
+	stats := QueueReport{Capacity: cfg.Size}
+	queue := make(chan queued, cfg.Size)
+	done := make(chan struct{})
+	var wg sync.WaitGroup
+	var produced QueueReport
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		r.produce(docs, clock, queue, done, &produced)
+	}()
+	stop := func() {
+		close(done)
+		wg.Wait()
+		stats.MaxDepth, stats.BlockedSeconds = produced.MaxDepth, produced.BlockedSeconds
+		stats.queued, stats.depthSum = produced.queued, produced.depthSum
+		stats.finish()
+	}
+
+	for it := range queue {
+		batch := []queued{it}
+		size := len(it.chunks)
+		// Take whatever else is already queued, up to the batch limit.
+	fill:
+		for size < cfg.MaxBatchChunks {
+			select {
+			case next, ok := <-queue:
+				if !ok {
+					break fill
+				}
+				batch = append(batch, next)
+				size += len(next.chunks)
+			default:
+				break fill
+			}
+		}
+		stats.Batches++
+		stats.batchSum += len(batch)
+		if err := r.writeQueued(batch, sinks, settle); err != nil {
+			stop()
+			return stats, err
+		}
+	}
+	stop()
+	return stats, nil
+}
+
+// writeQueued writes each run of writable documents in batch to every
+// sink in one call per sink and settles the documents in order. A failed
+// batch write fails all of its documents. With a checkpoint, the sinks
+// that took a failed document are recorded so a resumed run does not
+// write it to them again.
+func (r *Runner) writeQueued(batch []queued, sinks []*managedSink, settle func(queued) error) error {
+	for len(batch) > 0 {
+		n := 0
+		for n < len(batch) && batch[n].writable() {
+			n++
+		}
+		if n == 0 {
+			if err := settle(batch[0]); err != nil {
+				return err
+			}
+			batch = batch[1:]
+			continue
+		}
+		run := make([]DocumentChunks, n)
+		for i, it := range batch[:n] {
+			run[i] = DocumentChunks{Doc: it.doc, Chunks: it.chunks}
+		}
+		// Fan out to every sink even if one fails so the per-sink report
+		// shows exactly which sinks are missing the documents.
+		var sinkErrs []error
+		maxAttempts := 0
+		// took holds, for each sink, which documents of the run it was
+		// given and took.
+		took := make([][]bool, len(sinks))
+		for i, s := range sinks {
+			took[i] = make([]bool, n)
+			var pending []DocumentChunks
+			for j, it := range batch[:n] {
+				if r.Checkpoint == nil || !r.Checkpoint.SinkDone(it.doc.Path, it.hash, s.report.Name) {
+					pending = append(pending, run[j])
+					took[i][j] = true
+				}
+			}
+			if len(pending) == 0 {
+				continue
+			}
+			attempts, err := s.writeBatch(context.Background(), pending)
+			if err != nil {
+				sinkErrs = append(sinkErrs, err)
+				took[i] = nil
+			}
+			if attempts > maxAttempts {
+				maxAttempts = attempts
+			}
+		}
+		for j, it := range batch[:n] {
+			if len(sinkErrs) > 0 {
+				it.err = &stageError{stage: StageSink, err: errors.Join(sinkErrs...), attempts: maxAttempts}
+			}
+			if r.Checkpoint != nil {
+				if err := r.recordSinks(it, sinks, took, j, len(sinkErrs) > 0); err != nil {
+					it.err = &stageError{stage: StageCheckpoint, err: err}
+				}
+			}
+			if err := settle(it); err != nil {
+				return err
+			}
+		}
+		batch = batch[n:]
+	}
+	return nil
+}
+
+// recordSinks records the j-th document of a run in the checkpoint: as
+// done when every sink took it, otherwise for each sink that just took
+// it.
+func (r *Runner) recordSinks(it queued, sinks []*managedSink, took [][]bool, j int, failed bool) error {
+	if !failed {
+		return r.Checkpoint.Record(it.doc.Path, it.hash)
+	}
+	for i, s := range sinks {
+		if took[i] != nil && took[i][j] {
+			if err := r.Checkpoint.RecordSink(it.doc.Path, it.hash, s.report.Name); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,96 @@
+package pipeline
+
+import (
+	"fmt"
+	"path/filepath"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	"chunker-service/pkg/chunking"
+)
+
+// slowSink takes a while per write, like a remote vector store, and
+// records the documents of each batch.
+type slowSink struct {
+	mu      sync.Mutex
+	batches [][]string
+}
+
+func (s *slowSink) Write(doc Document, chunks []chunking.Chunk) error {
+	return s.WriteBatch([]DocumentChunks{{Doc: doc, Chunks: chunks}})
+}
+
+func (s *slowSink) WriteBatch(batch []DocumentChunks) error {
+	time.Sleep(20 * time.Millisecond)
+	var names []string
+	for _, dc := range batch {
+		names = append(names, filepath.Base(dc.Doc.Path))
+	}
+	s.mu.Lock()
+	s.batches = append(s.batches, names)
+	s.mu.Unlock()
+	return nil
+}
+
+func (s *slowSink) Close() error { return nil }
+
+func TestRunQueueBackpressure(t *testing.T) {
+	sink := &slowSink{}
+	sinkFactories["slow"] = func(SinkConfig) (Sink, error) { return sink, nil }
+	defer delete(sinkFactories, "slow")
+
+	dir := t.TempDir()
+	var want []string
+	for i := 0; i < 12; i++ {
+		name := fmt.Sprintf("%02d.txt", i)
+		writeFile(t, filepath.Join(dir, name), "a b c d")
+		want = append(want, name)
+	}
+	m := &Manifest{
+		Plan:    &chunking.ChunkingPlan{WindowSize: 2, Mode: chunking.ModeTokens},
+		Sources: []Source{{Path: "*.txt"}},
+		Sinks:   []SinkConfig{{Type: "slow"}},
+		Queue:   &QueueConfig{Size: 3, MaxBatchChunks: 6},
+		baseDir: dir,
+	}
+	report, err := NewRunner().Run(m)
+	if err != nil {
+		t.Fatalf("run failed: %v", err)
+	}
+	if report.Documents != 12 || report.Chunks != 24 {
+		t.Fatalf("unexpected report: %+v", report)
+	}
+
+	var written []string
+	for _, b := range sink.batches {
+		// Two chunks per document: at most three documents per batch.
+		if len(b) > 3 {
+			t.Errorf("batch %v exceeds max_batch_chunks", b)
+		}
+		written = append(written, b...)
+	}
+	if !reflect.DeepEqual(written, want) {
+		t.Fatalf("expected documents written in order %v, got %v", want, written)
+	}
+
+	q := report.Queue
+	if q == nil || q.Capacity != 3 || q.MaxDepth > 3 {
+		t.Fatalf("unexpected queue report: %+v", q)
+	}
+	// The sink is the bottleneck, so chunking fills the queue and waits,
+	// and the sink catches up in batches.
+	if q.MaxDepth != 3 || q.BlockedSeconds == 0 || q.Batches >= 12 || q.AvgBatch <= 1 {
+		t.Errorf("expected backpressure and batching, got %+v", q)
+	}
+}
+
+func TestQueueConfigValidate(t *testing.T) {
+	if err := (QueueConfig{Size: -1}).Validate(); err == nil {
+		t.Error("expected an error for a negative size")
+	}
+	if got := (*QueueConfig)(nil).withDefaults(); got.Size != DefaultQueueSize || got.MaxBatchChunks != DefaultMaxBatchChunks {
+		t.Errorf("unexpected defaults %+v", got)
+	}
+}
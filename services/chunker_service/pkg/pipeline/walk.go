@@ -0,0 +1,139 @@
+package pipeline
+
+import (
+	"fmt"
+	"io"
+	"io/fs"
+	"mime"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"unicode/utf8"
+)
+
+// sniffLen is how much of a file WalkDir reads to detect its MIME type
+// and whether it is binary.
+const sniffLen = 8192
+
+// WalkOptions filters the files WalkDir returns. Include and Exclude hold
+// filepath.Match patterns, matched against the slash-separated path
+// relative to the root when they contain a slash and against the base
+// name otherwise. A file is kept when it matches no Exclude pattern and,
+// if Include is not empty, some Include pattern. A directory matching an
+// Exclude pattern is not entered.
+type WalkOptions struct {
+	Include []string
+	Exclude []string
+}
+
+// DirFile is a text file found by WalkDir.
+type DirFile struct {
+	// Path is the file's path, beginning with the walked root.
+	Path string
+	// RelPath is the slash-separated path relative to the root.
+	RelPath  string
+	MimeType string
+}
+
+// WalkDir returns the text files under root matching opts, in lexical
+// order, and the relative paths of the binary files it skipped. Hidden
+// files and directories, such as .git, are skipped too.
+func WalkDir(root string, opts WalkOptions) (files []DirFile, binary []string, err error) {
+	for _, p := range append(append([]string{}, opts.Include...), opts.Exclude...) {
+		if _, err := filepath.Match(p, ""); err != nil {
+			return nil, nil, fmt.Errorf("invalid pattern %q: %w", p, err)
+		}
+	}
+	err = filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if path == root {
+			return nil
+		}
+		rel, err := filepath.Rel(root, path)
+		if err != nil {
+			return err
+		}
+		rel = filepath.ToSlash(rel)
+		if strings.HasPrefix(d.Name(), ".") || matchAny(opts.Exclude, rel) {
+			if d.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if d.IsDir() || !d.Type().IsRegular() && d.Type()&fs.ModeSymlink == 0 {
+			return nil
+		}
+		if len(opts.Include) > 0 && !matchAny(opts.Include, rel) {
+			return nil
+		}
+		head, err := readHead(path)
+		if err != nil {
+			return err
+		}
+		if looksBinary(head) {
+			binary = append(binary, rel)
+			return nil
+		}
+		files = append(files, DirFile{Path: path, RelPath: rel, MimeType: DetectMimeType(path, head)})
+		return nil
+	})
+	return files, binary, err
+}
+
+// matchAny reports whether rel, or its base name for patterns without a
+// slash, matches one of patterns.
+func matchAny(patterns []string, rel string) bool {
+	for _, p := range patterns {
+		name := rel
+		if !strings.Contains(p, "/") {
+			name = filepath.Base(filepath.FromSlash(rel))
+		}
+		// Patterns were checked by WalkDir.
+		if ok, _ := filepath.Match(p, name); ok {
+			return true
+		}
+	}
+	return false
+}
+
+func readHead(path string) ([]byte, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	head := make([]byte, sniffLen)
+	n, err := io.ReadFull(f, head)
+	if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
+		return nil, err
+	}
+	return head[:n], nil
+}
+
+// looksBinary reports whether head, a file's first bytes, holds a NUL
+// byte or is not UTF-8, allowing for a rune cut off at the end.
+func looksBinary(head []byte) bool {
+	if strings.IndexByte(string(head), 0) >= 0 {
+		return true
+	}
+	if len(head) == sniffLen {
+		for i := 0; i < utf8.UTFMax-1 && len(head) > 0 && !utf8.Valid(head); i++ {
+			head = head[:len(head)-1]
+		}
+	}
+	return !utf8.Valid(head)
+}
+
+// DetectMimeType returns the MIME type of path from its extension or,
+// when the extension is unknown, from head, the file's first bytes.
+func DetectMimeType(path string, head []byte) string {
+	ext := strings.ToLower(filepath.Ext(path))
+	if _, ok := textMimeTypes[ext]; ok || mime.TypeByExtension(ext) != "" {
+		return MimeType(path)
+	}
+	t, _, _ := strings.Cut(http.DetectContentType(head), ";")
+	return t
+}
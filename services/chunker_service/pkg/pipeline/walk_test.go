@@ -0,0 +1,67 @@
+package pipeline
+
+import (
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestWalkDir(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, "a.md"), "# A")
+	writeFile(t, filepath.Join(dir, "docs", "b.txt"), "b")
+	writeFile(t, filepath.Join(dir, "docs", "page"), "<!DOCTYPE html><html><body>c</body></html>")
+	writeFile(t, filepath.Join(dir, "docs", "logo.png"), "\x89PNG\r\n\x1a\n\x00\x00")
+	writeFile(t, filepath.Join(dir, "docs", "latin1.txt"), "caf\xe9")
+	writeFile(t, filepath.Join(dir, "vendor", "d.md"), "d")
+	writeFile(t, filepath.Join(dir, ".git", "config"), "[core]")
+	writeFile(t, filepath.Join(dir, "notes.tmp"), "tmp")
+	// A multi-byte rune straddling the sniffed prefix is still text.
+	writeFile(t, filepath.Join(dir, "long.txt"), strings.Repeat("a", sniffLen-1)+"é")
+
+	files, binary, err := WalkDir(dir, WalkOptions{Exclude: []string{"vendor", "*.tmp"}})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	got := map[string]string{}
+	var order []string
+	for _, f := range files {
+		got[f.RelPath] = f.MimeType
+		order = append(order, f.RelPath)
+		if f.Path != filepath.Join(dir, filepath.FromSlash(f.RelPath)) {
+			t.Errorf("%s: unexpected path %q", f.RelPath, f.Path)
+		}
+	}
+	want := map[string]string{
+		"a.md":       "text/markdown",
+		"docs/b.txt": "text/plain",
+		"docs/page":  "text/html",
+		"long.txt":   "text/plain",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("expected %v, got %v", want, got)
+	}
+	if wantOrder := []string{"a.md", "docs/b.txt", "docs/page", "long.txt"}; !reflect.DeepEqual(order, wantOrder) {
+		t.Fatalf("expected order %v, got %v", wantOrder, order)
+	}
+	if wantBinary := []string{"docs/latin1.txt", "docs/logo.png"}; !reflect.DeepEqual(binary, wantBinary) {
+		t.Fatalf("expected binary %v, got %v", wantBinary, binary)
+	}
+
+	files, _, err = WalkDir(dir, WalkOptions{Include: []string{"*.md", "docs/*.txt"}, Exclude: []string{"vendor/*"}})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	order = nil
+	for _, f := range files {
+		order = append(order, f.RelPath)
+	}
+	if wantOrder := []string{"a.md", "docs/b.txt"}; !reflect.DeepEqual(order, wantOrder) {
+		t.Fatalf("expected %v, got %v", wantOrder, order)
+	}
+
+	if _, _, err := WalkDir(dir, WalkOptions{Include: []string{"["}}); err == nil {
+		t.Fatal("expected an error for a malformed pattern")
+	}
+}
@@ -0,0 +1,337 @@
+// Package redact removes PII and secrets from chunk payloads before they
+// are written to a sink, so the index never holds them. Each match in Text
+// and OverlapText is replaced by a placeholder token such as "[EMAIL]".
+// With a mapping configured, masking is reversible: placeholders are
+// numbered per chunk and the original values are written, encrypted, to a
+// separate mapping file that only authorized readers can open.
+package redact
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"chunker-service/pkg/chunking"
+	"chunker-service/pkg/fieldcrypt"
+)
+
+// DefaultPlaceholder is used for detectors without a placeholder of their
+// own. {type} expands to the detector name in upper case and {n} to the
+// number of the match within its chunk, counting from 1.
+const DefaultPlaceholder = "[{type}]"
+
+// DefaultReversiblePlaceholder is the default when a mapping is
+// configured, as reversible placeholders must be unique within a chunk.
+const DefaultReversiblePlaceholder = "[{type}_{n}]"
+
+// detectors are the built-in patterns, by name.
+var detectors = map[string]*regexp.Regexp{
+	"email":          regexp.MustCompile(`\b[A-Za-z0-9._%+-]+@[A-Za-z0-9-]+(?:\.[A-Za-z0-9-]+)*\.[A-Za-z]{2,}\b`),
+	"phone":          regexp.MustCompile(`(?:\+\d{1,3}[ .-]?)?(?:\(\d{3}\)|\b\d{3})[ .-]?\d{3}[ .-]?\d{4}\b`),
+	"ipv4":           regexp.MustCompile(`\b(?:(?:25[0-5]|2[0-4]\d|1\d\d|[1-9]?\d)\.){3}(?:25[0-5]|2[0-4]\d|1\d\d|[1-9]?\d)\b`),
+	"credit_card":    regexp.MustCompile(`\b\d(?:[ -]?\d){12,18}\b`),
+	"aws_access_key": regexp.MustCompile(`\b(?:AKIA|ASIA)[0-9A-Z]{16}\b`),
+	"jwt":            regexp.MustCompile(`\beyJ[A-Za-z0-9_-]+\.eyJ[A-Za-z0-9_-]+\.[A-Za-z0-9_-]+`),
+	"private_key":    regexp.MustCompile(`-----BEGIN [A-Z ]*PRIVATE KEY-----[\s\S]*?-----END [A-Z ]*PRIVATE KEY-----`),
+}
+
+// Detectors returns the names of the built-in detectors, sorted.
+func Detectors() []string {
+	names := make([]string, 0, len(detectors))
+	for name := range detectors {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Config selects what is redacted and how it is replaced.
+type Config struct {
+	// Detectors names the built-in detectors to run; empty runs all.
+	Detectors []string `json:"detectors,omitempty"`
+
+	// Patterns adds custom detectors, regular expressions by name.
+	Patterns map[string]string `json:"patterns,omitempty"`
+
+	// Placeholder is the token template for every detector without an
+	// entry in Placeholders.
+	Placeholder  string            `json:"placeholder,omitempty"`
+	Placeholders map[string]string `json:"placeholders,omitempty"`
+
+	// Mapping, when set, makes masking reversible.
+	Mapping *MappingConfig `json:"mapping,omitempty"`
+}
+
+// MappingConfig locates the mapping file and the key its values are
+// encrypted with. Keep both away from the index: anyone holding them can
+// recover the redacted values.
+type MappingConfig struct {
+	Path       string            `json:"path"`
+	Encryption fieldcrypt.Config `json:"encryption"`
+}
+
+// Validate checks detector names, patterns and placeholders.
+func (c Config) Validate() error {
+	_, err := c.compile()
+	return err
+}
+
+type detector struct {
+	name        string
+	re          *regexp.Regexp
+	placeholder string
+}
+
+// compile returns the configured detectors, sorted by name so matches
+// are resolved the same way on every run.
+func (c Config) compile() ([]detector, error) {
+	names := c.Detectors
+	if len(names) == 0 && len(c.Patterns) == 0 {
+		names = Detectors()
+	}
+	var out []detector
+	for _, name := range names {
+		re, ok := detectors[name]
+		if !ok {
+			return nil, fmt.Errorf("redaction: unknown detector %q", name)
+		}
+		out = append(out, detector{name: name, re: re})
+	}
+	for name, pattern := range c.Patterns {
+		if _, ok := detectors[name]; ok || name == "" {
+			return nil, fmt.Errorf("redaction: pattern name %q is empty or taken by a built-in detector", name)
+		}
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("redaction: pattern %s: %w", name, err)
+		}
+		out = append(out, detector{name: name, re: re})
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].name < out[j].name })
+
+	def := c.Placeholder
+	if def == "" {
+		def = DefaultPlaceholder
+		if c.Mapping != nil {
+			def = DefaultReversiblePlaceholder
+		}
+	}
+	for i := range out {
+		out[i].placeholder = def
+		if p, ok := c.Placeholders[out[i].name]; ok {
+			out[i].placeholder = p
+		}
+		if out[i].placeholder == "" {
+			return nil, fmt.Errorf("redaction: placeholder for %s is empty", out[i].name)
+		}
+		if c.Mapping != nil && !strings.Contains(out[i].placeholder, "{n}") {
+			return nil, fmt.Errorf("redaction: placeholder for %s must contain {n} when a mapping is set", out[i].name)
+		}
+	}
+	for name := range c.Placeholders {
+		if !contains(out, name) {
+			return nil, fmt.Errorf("redaction: placeholder for %s, which is not a configured detector", name)
+		}
+	}
+	if c.Mapping != nil && c.Mapping.Path == "" {
+		return nil, errors.New("redaction: mapping path is required")
+	}
+	return out, nil
+}
+
+func contains(ds []detector, name string) bool {
+	for _, d := range ds {
+		if d.name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// Entry records one reversibly masked value: the placeholder that replaced
+// it in the chunk and the original, sealed with the chunk ID and
+// placeholder as additional data.
+type Entry struct {
+	ChunkID     string `json:"chunk_id"`
+	Placeholder string `json:"placeholder"`
+	Type        string `json:"type"`
+	KeyID       string `json:"key_id"`
+	Value       string `json:"value"`
+}
+
+// Redactor masks chunk payloads. It is safe for concurrent use.
+type Redactor struct {
+	detectors []detector
+	encryptor *fieldcrypt.Encryptor
+
+	mu      sync.Mutex
+	mapping *os.File
+	enc     *json.Encoder
+}
+
+// New returns a Redactor for cfg, opening its mapping file, which is
+// truncated unless appendMapping is set.
+func New(cfg Config, appendMapping bool) (*Redactor, error) {
+	ds, err := cfg.compile()
+	if err != nil {
+		return nil, err
+	}
+	r := &Redactor{detectors: ds}
+	if cfg.Mapping == nil {
+		return r, nil
+	}
+	if r.encryptor, err = fieldcrypt.New(cfg.Mapping.Encryption); err != nil {
+		return nil, fmt.Errorf("redaction mapping: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(cfg.Mapping.Path), 0o755); err != nil {
+		return nil, err
+	}
+	flags := os.O_CREATE | os.O_WRONLY | os.O_TRUNC
+	if appendMapping {
+		flags = os.O_CREATE | os.O_WRONLY | os.O_APPEND
+	}
+	f, err := os.OpenFile(cfg.Mapping.Path, flags, 0o600)
+	if err != nil {
+		return nil, err
+	}
+	r.mapping, r.enc = f, json.NewEncoder(f)
+	return r, nil
+}
+
+// RedactChunks returns copies of chunks with every match replaced by its
+// placeholder and the number of matches per detector recorded in
+// Extra["redaction"]. Chunks without matches are returned unchanged.
+// Compressed or encrypted chunks are rejected, as their text cannot be
+// searched. The input is not modified.
+func (r *Redactor) RedactChunks(chunks []chunking.Chunk) ([]chunking.Chunk, error) {
+	out := make([]chunking.Chunk, len(chunks))
+	var entries []Entry
+	for i, ch := range chunks {
+		if ch.Extra["compression"] != nil || ch.Extra["encryption"] != nil {
+			return nil, fmt.Errorf("chunk %s is compressed or encrypted and cannot be redacted", ch.ID)
+		}
+		counts := map[string]int{}
+		n := 0
+		var err error
+		if ch.Text, err = r.redact(ch.ID, ch.Text, &n, counts, &entries); err != nil {
+			return nil, err
+		}
+		if ch.OverlapText, err = r.redact(ch.ID, ch.OverlapText, &n, counts, &entries); err != nil {
+			return nil, err
+		}
+		if n > 0 {
+			extra := make(map[string]interface{}, len(ch.Extra)+1)
+			for k, v := range ch.Extra {
+				extra[k] = v
+			}
+			extra["redaction"] = counts
+			ch.Extra = extra
+		}
+		out[i] = ch
+	}
+	if len(entries) > 0 {
+		r.mu.Lock()
+		defer r.mu.Unlock()
+		for _, e := range entries {
+			if err := r.enc.Encode(e); err != nil {
+				return nil, fmt.Errorf("redaction mapping: %w", err)
+			}
+		}
+	}
+	return out, nil
+}
+
+type match struct {
+	start, end int
+	d          *detector
+}
+
+// redact replaces the matches in text, numbering them from *n on.
+func (r *Redactor) redact(id, text string, n *int, counts map[string]int, entries *[]Entry) (string, error) {
+	if text == "" {
+		return text, nil
+	}
+	var matches []match
+	for i := range r.detectors {
+		d := &r.detectors[i]
+		for _, loc := range d.re.FindAllStringIndex(text, -1) {
+			if loc[0] == loc[1] || (d.name == "credit_card" && !luhn(text[loc[0]:loc[1]])) {
+				continue
+			}
+			matches = append(matches, match{loc[0], loc[1], d})
+		}
+	}
+	if len(matches) == 0 {
+		return text, nil
+	}
+	// Where matches overlap, the earliest wins, then the longest.
+	sort.SliceStable(matches, func(i, j int) bool {
+		if matches[i].start != matches[j].start {
+			return matches[i].start < matches[j].start
+		}
+		return matches[i].end > matches[j].end
+	})
+	var b strings.Builder
+	last := 0
+	for _, m := range matches {
+		if m.start < last {
+			continue
+		}
+		*n++
+		counts[m.d.name]++
+		token := strings.NewReplacer("{type}", strings.ToUpper(m.d.name), "{n}", strconv.Itoa(*n)).Replace(m.d.placeholder)
+		if r.encryptor != nil {
+			sealed, err := r.encryptor.Seal(text[m.start:m.end], entryID(id, token))
+			if err != nil {
+				return "", err
+			}
+			*entries = append(*entries, Entry{ChunkID: id, Placeholder: token, Type: m.d.name, KeyID: r.encryptor.KeyID(), Value: sealed})
+		}
+		b.WriteString(text[last:m.start])
+		b.WriteString(token)
+		last = m.end
+	}
+	b.WriteString(text[last:])
+	return b.String(), nil
+}
+
+// Close closes the mapping file, if any.
+func (r *Redactor) Close() error {
+	if r.mapping == nil {
+		return nil
+	}
+	return r.mapping.Close()
+}
+
+// entryID is the additional data a mapping value is sealed with.
+func entryID(chunkID, placeholder string) string {
+	return chunkID + " " + placeholder
+}
+
+// luhn reports whether the digits of s pass the Luhn checksum, which
+// tells card numbers from other long digit runs.
+func luhn(s string) bool {
+	sum, double := 0, false
+	for i := len(s) - 1; i >= 0; i-- {
+		c := s[i]
+		if c < '0' || c > '9' {
+			continue
+		}
+		d := int(c - '0')
+		if double {
+			if d *= 2; d > 9 {
+				d -= 9
+			}
+		}
+		sum += d
+		double = !double
+	}
+	return sum%10 == 0
+}
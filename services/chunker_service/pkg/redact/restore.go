@@ -0,0 +1,83 @@
+package redact
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+
+	"chunker-service/pkg/chunking"
+	"chunker-service/pkg/fieldcrypt"
+)
+
+// Mapping holds the entries of a mapping file by chunk ID.
+type Mapping map[string][]Entry
+
+// LoadMapping reads a mapping file. A placeholder recorded twice for the
+// same chunk, as when a failed sink write is retried by a resumed run,
+// keeps its last entry.
+func LoadMapping(path string) (Mapping, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	m := Mapping{}
+	sc := bufio.NewScanner(f)
+	sc.Buffer(make([]byte, 0, 64*1024), 16<<20)
+	for line := 1; sc.Scan(); line++ {
+		if len(strings.TrimSpace(sc.Text())) == 0 {
+			continue
+		}
+		var e Entry
+		if err := json.Unmarshal(sc.Bytes(), &e); err != nil {
+			return nil, fmt.Errorf("%s:%d: %w", path, line, err)
+		}
+		m.add(e)
+	}
+	return m, sc.Err()
+}
+
+func (m Mapping) add(e Entry) {
+	entries := m[e.ChunkID]
+	for i := range entries {
+		if entries[i].Placeholder == e.Placeholder {
+			entries[i] = e
+			return
+		}
+	}
+	m[e.ChunkID] = append(entries, e)
+}
+
+// Restore puts the original values back into a chunk written by
+// RedactChunks with a mapping, decrypting them with enc. Chunks without
+// redactions are left unchanged. Compressed or encrypted chunks must be
+// expanded first, as sinks redact before they compress and encrypt.
+func (m Mapping) Restore(ch *chunking.Chunk, enc *fieldcrypt.Encryptor) error {
+	if ch.Extra["redaction"] == nil {
+		return nil
+	}
+	if ch.Extra["compression"] != nil || ch.Extra["encryption"] != nil {
+		return fmt.Errorf("chunk %s is compressed or encrypted; expand it before restoring", ch.ID)
+	}
+	for _, e := range m[ch.ID] {
+		if e.KeyID != enc.KeyID() {
+			return fmt.Errorf("chunk %s: mapping was encrypted with key %q, not %q", ch.ID, e.KeyID, enc.KeyID())
+		}
+		value, err := enc.Open(e.Value, entryID(ch.ID, e.Placeholder))
+		if err != nil {
+			return err
+		}
+		switch {
+		case strings.Contains(ch.Text, e.Placeholder):
+			ch.Text = strings.Replace(ch.Text, e.Placeholder, value, 1)
+		case strings.Contains(ch.OverlapText, e.Placeholder):
+			ch.OverlapText = strings.Replace(ch.OverlapText, e.Placeholder, value, 1)
+		default:
+			return fmt.Errorf("chunk %s: placeholder %s not found", ch.ID, e.Placeholder)
+		}
+	}
+	delete(ch.Extra, "redaction")
+	return nil
+}
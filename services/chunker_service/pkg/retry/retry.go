@@ -0,0 +1,246 @@
+// Package retry provides the retry, backoff and circuit-breaker layer used
+// for every outbound integration (sinks, embedders, planners). Each
+// integration gets a named Retrier with its own Policy and counters so a
+// flaky dependency is retried in isolation and, once it keeps failing, is
+// short-circuited instead of cascading failures into the whole pipeline.
+package retry
+
+import (
+	"context"
+	"errors"
+	"math/rand/v2"
+	"sync"
+	"time"
+)
+
+// ErrCircuitOpen is returned without calling the integration while its
+// circuit breaker is open.
+var ErrCircuitOpen = errors.New("circuit breaker open")
+
+// Policy configures retries and circuit breaking for one integration.
+// Durations are in milliseconds so policies read naturally in JSON and
+// YAML configuration.
+type Policy struct {
+	// MaxAttempts is the total number of attempts, including the first.
+	// Values <= 1 disable retries.
+	MaxAttempts int `json:"max_attempts,omitempty"`
+	// InitialBackoffMS is the delay before the first retry; subsequent
+	// delays grow by Multiplier up to MaxBackoffMS.
+	InitialBackoffMS int     `json:"initial_backoff_ms,omitempty"`
+	MaxBackoffMS     int     `json:"max_backoff_ms,omitempty"`
+	Multiplier       float64 `json:"multiplier,omitempty"`
+	// BreakerThreshold is the number of consecutive failed calls after
+	// which the breaker opens for BreakerCooldownMS. Zero disables it.
+	BreakerThreshold  int `json:"breaker_threshold,omitempty"`
+	BreakerCooldownMS int `json:"breaker_cooldown_ms,omitempty"`
+}
+
+// DefaultPolicy is a conservative policy for network integrations.
+var DefaultPolicy = Policy{
+	MaxAttempts:       3,
+	InitialBackoffMS:  200,
+	MaxBackoffMS:      5000,
+	Multiplier:        2,
+	BreakerThreshold:  5,
+	BreakerCooldownMS: 30000,
+}
+
+// Stats are the counters kept for each integration.
+type Stats struct {
+	Calls        int64 `json:"calls"`
+	Attempts     int64 `json:"attempts"`
+	Retries      int64 `json:"retries"`
+	Failures     int64 `json:"failures"`
+	Rejected     int64 `json:"rejected"`
+	BreakerOpens int64 `json:"breaker_opens"`
+}
+
+// Retrier applies a Policy to calls against a single integration.
+type Retrier struct {
+	name   string
+	policy Policy
+
+	mu        sync.Mutex
+	failures  int
+	openUntil time.Time
+	stats     Stats
+
+	now   func() time.Time
+	sleep func(ctx context.Context, d time.Duration) error
+}
+
+type permanentError struct{ err error }
+
+func (e *permanentError) Error() string { return e.err.Error() }
+func (e *permanentError) Unwrap() error { return e.err }
+
+// Permanent marks err as not worth retrying (e.g. a 4xx response).
+func Permanent(err error) error {
+	if err == nil {
+		return nil
+	}
+	return &permanentError{err}
+}
+
+// registryKey identifies a Retrier by integration and policy.
+type registryKey struct {
+	name   string
+	policy Policy
+}
+
+var (
+	registryMu sync.Mutex
+	registry   = map[registryKey]*Retrier{}
+)
+
+// New returns the Retrier registered under name and policy, creating it
+// on first use. Later calls with the same name and policy return the
+// existing Retrier so breaker state and counters are shared per
+// integration; a different policy under the same name, such as that of
+// another manifest's sink, gets a Retrier of its own.
+func New(name string, policy Policy) *Retrier {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+	key := registryKey{name, policy}
+	if r, ok := registry[key]; ok {
+		return r
+	}
+	r := &Retrier{
+		name:   name,
+		policy: policy,
+		now:    time.Now,
+		sleep:  sleepContext,
+	}
+	registry[key] = r
+	return r
+}
+
+// Snapshot returns the counters of every registered integration, summed
+// over the policies registered under its name.
+func Snapshot() map[string]Stats {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+	out := make(map[string]Stats, len(registry))
+	for key, r := range registry {
+		s, t := out[key.name], r.Stats()
+		s.Calls += t.Calls
+		s.Attempts += t.Attempts
+		s.Retries += t.Retries
+		s.Failures += t.Failures
+		s.Rejected += t.Rejected
+		s.BreakerOpens += t.BreakerOpens
+		out[key.name] = s
+	}
+	return out
+}
+
+// Name returns the integration name.
+func (r *Retrier) Name() string { return r.name }
+
+// Stats returns a copy of the integration's counters.
+func (r *Retrier) Stats() Stats {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.stats
+}
+
+// Do calls fn until it succeeds, returns a Permanent error, the context is
+// done, or the policy's attempts are exhausted. It returns the number of
+// attempts made alongside the last error.
+func (r *Retrier) Do(ctx context.Context, fn func(ctx context.Context) error) (int, error) {
+	r.mu.Lock()
+	r.stats.Calls++
+	if r.now().Before(r.openUntil) {
+		r.stats.Rejected++
+		r.mu.Unlock()
+		return 0, ErrCircuitOpen
+	}
+	r.mu.Unlock()
+
+	maxAttempts := r.policy.MaxAttempts
+	if maxAttempts < 1 {
+		maxAttempts = 1
+	}
+
+	var err error
+	attempt := 0
+	for attempt < maxAttempts {
+		if attempt > 0 {
+			r.count(func(s *Stats) { s.Retries++ })
+			if serr := r.sleep(ctx, r.backoff(attempt)); serr != nil {
+				break
+			}
+		}
+		attempt++
+		r.count(func(s *Stats) { s.Attempts++ })
+		if err = fn(ctx); err == nil {
+			r.record(true)
+			return attempt, nil
+		}
+		var perm *permanentError
+		if errors.As(err, &perm) {
+			err = perm.err
+			break
+		}
+		if ctx.Err() != nil {
+			break
+		}
+	}
+	r.record(false)
+	return attempt, err
+}
+
+func (r *Retrier) count(f func(*Stats)) {
+	r.mu.Lock()
+	f(&r.stats)
+	r.mu.Unlock()
+}
+
+// record updates the breaker after a call completes.
+func (r *Retrier) record(ok bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if ok {
+		r.failures = 0
+		return
+	}
+	r.stats.Failures++
+	r.failures++
+	if r.policy.BreakerThreshold > 0 && r.failures >= r.policy.BreakerThreshold {
+		r.openUntil = r.now().Add(time.Duration(r.policy.BreakerCooldownMS) * time.Millisecond)
+		r.failures = 0
+		r.stats.BreakerOpens++
+	}
+}
+
+// backoff returns the delay before the given retry (1-based), using
+// exponential growth with equal jitter.
+func (r *Retrier) backoff(retry int) time.Duration {
+	d := float64(r.policy.InitialBackoffMS)
+	mult := r.policy.Multiplier
+	if mult < 1 {
+		mult = 1
+	}
+	for i := 1; i < retry; i++ {
+		d *= mult
+	}
+	if r.policy.MaxBackoffMS > 0 && d > float64(r.policy.MaxBackoffMS) {
+		d = float64(r.policy.MaxBackoffMS)
+	}
+	half := d / 2
+	return time.Duration((half + rand.Float64()*half) * float64(time.Millisecond))
+}
+
+func sleepContext(ctx context.Context, d time.Duration) error {
+	if d <= 0 {
+		return ctx.Err()
+	}
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-t.C:
+		return nil
+	}
+}
@@ -0,0 +1,81 @@
+package retry
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func newTestRetrier(name string, p Policy) *Retrier {
+	r := New(name, p)
+	r.sleep = func(context.Context, time.Duration) error { return nil }
+	return r
+}
+
+func TestDoRetriesUntilSuccess(t *testing.T) {
+	r := newTestRetrier(t.Name(), Policy{MaxAttempts: 3})
+	calls := 0
+	attempts, err := r.Do(context.Background(), func(context.Context) error {
+		calls++
+		if calls < 3 {
+			return errors.New("flaky")
+		}
+		return nil
+	})
+	if err != nil || attempts != 3 {
+		t.Fatalf("Do = (%d, %v), want (3, nil)", attempts, err)
+	}
+	if s := r.Stats(); s.Retries != 2 || s.Attempts != 3 || s.Failures != 0 {
+		t.Fatalf("unexpected stats: %+v", s)
+	}
+}
+
+func TestDoStopsOnPermanentError(t *testing.T) {
+	r := newTestRetrier(t.Name(), Policy{MaxAttempts: 5})
+	bad := errors.New("bad request")
+	attempts, err := r.Do(context.Background(), func(context.Context) error {
+		return Permanent(bad)
+	})
+	if attempts != 1 || !errors.Is(err, bad) {
+		t.Fatalf("Do = (%d, %v), want (1, bad request)", attempts, err)
+	}
+}
+
+func TestBreakerOpensAndRecovers(t *testing.T) {
+	r := newTestRetrier(t.Name(), Policy{MaxAttempts: 1, BreakerThreshold: 2, BreakerCooldownMS: 1000})
+	now := time.Unix(0, 0)
+	r.now = func() time.Time { return now }
+	fail := func(context.Context) error { return errors.New("down") }
+
+	r.Do(context.Background(), fail)
+	r.Do(context.Background(), fail)
+	if _, err := r.Do(context.Background(), fail); !errors.Is(err, ErrCircuitOpen) {
+		t.Fatalf("expected open breaker, got %v", err)
+	}
+
+	now = now.Add(2 * time.Second)
+	if _, err := r.Do(context.Background(), func(context.Context) error { return nil }); err != nil {
+		t.Fatalf("expected breaker to close after cooldown, got %v", err)
+	}
+	if s := Snapshot()[t.Name()]; s.BreakerOpens != 1 || s.Rejected != 1 {
+		t.Fatalf("unexpected stats: %+v", s)
+	}
+}
+
+func TestNewKeysByPolicy(t *testing.T) {
+	fast, slow := Policy{MaxAttempts: 1}, Policy{MaxAttempts: 4}
+	a, b := New(t.Name(), fast), New(t.Name(), slow)
+	if a == b || a.policy != fast || b.policy != slow {
+		t.Fatalf("expected a Retrier per policy, got %+v and %+v", a.policy, b.policy)
+	}
+	if New(t.Name(), fast) != a {
+		t.Fatal("expected the same policy to share its Retrier")
+	}
+
+	a.Do(context.Background(), func(context.Context) error { return nil })
+	b.Do(context.Background(), func(context.Context) error { return nil })
+	if s := Snapshot()[t.Name()]; s.Calls != 2 {
+		t.Fatalf("expected the snapshot to sum both policies, got %+v", s)
+	}
+}
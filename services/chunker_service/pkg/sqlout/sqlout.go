@@ -0,0 +1,248 @@
+// Package sqlout renders chunks as PostgreSQL INSERT or COPY statements
+// for a configurable table, so small deployments can load chunks with
+// psql instead of an intermediate tool.
+package sqlout
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"chunker-service/pkg/chunking"
+)
+
+// Modes of Table.Mode.
+const (
+	ModeInsert = "insert"
+	ModeCopy   = "copy"
+)
+
+// Column maps a table column to a chunk field, named as in the chunk's
+// JSON ("id", "text", "doc_id", ...). "extra.<key>" selects a single Extra
+// entry. Objects and lists are written as JSON text, suitable for jsonb
+// columns.
+type Column struct {
+	Name  string `json:"name"`
+	Field string `json:"field,omitempty"`
+}
+
+// Table describes the target table.
+type Table struct {
+	// Name may be schema-qualified; it defaults to "chunks".
+	Name string `json:"name,omitempty"`
+	// Columns defaults to DefaultColumns.
+	Columns []Column `json:"columns,omitempty"`
+	// Mode is "insert" (default) or "copy".
+	Mode string `json:"mode,omitempty"`
+	// OnConflict, when set, names the key columns of an
+	// "ON CONFLICT (...) DO NOTHING" clause on inserts, making reloads
+	// idempotent.
+	OnConflict []string `json:"on_conflict,omitempty"`
+}
+
+// DefaultColumns covers the commonly queried chunk fields.
+var DefaultColumns = []Column{
+	{Name: "id"}, {Name: "doc_id"}, {Name: "chunk_index"}, {Name: "parent_id"},
+	{Name: "text"}, {Name: "start_index"}, {Name: "end_index"},
+	{Name: "byte_start"}, {Name: "byte_end"}, {Name: "section"},
+	{Name: "file_name"}, {Name: "file_path"}, {Name: "mime_type"},
+	{Name: "title"}, {Name: "url"}, {Name: "author"}, {Name: "tags"},
+	{Name: "tenant"}, {Name: "license"}, {Name: "retention"},
+	{Name: "created_at"}, {Name: "expires_at"}, {Name: "extra"},
+}
+
+// Validate checks the table configuration.
+func (t Table) Validate() error {
+	switch t.Mode {
+	case "", ModeInsert, ModeCopy:
+	default:
+		return fmt.Errorf("unknown sql mode %q", t.Mode)
+	}
+	if t.Mode == ModeCopy && len(t.OnConflict) > 0 {
+		return fmt.Errorf("on_conflict is not supported with copy")
+	}
+	for _, c := range t.Columns {
+		if c.Name == "" {
+			return fmt.Errorf("sql column without a name")
+		}
+	}
+	return nil
+}
+
+func (t Table) columns() []Column {
+	if len(t.Columns) == 0 {
+		return DefaultColumns
+	}
+	return t.Columns
+}
+
+// Write renders chunks as one INSERT statement or one COPY block.
+func (t Table) Write(w io.Writer, chunks []chunking.Chunk) error {
+	if len(chunks) == 0 {
+		return nil
+	}
+	cols := t.columns()
+	names := make([]string, len(cols))
+	for i, c := range cols {
+		names[i] = quoteIdent(c.Name)
+	}
+	name := t.Name
+	if name == "" {
+		name = "chunks"
+	}
+	target := quoteQualified(name) + " (" + strings.Join(names, ", ") + ")"
+
+	rows := make([][]interface{}, len(chunks))
+	for i, ch := range chunks {
+		row, err := values(ch, cols)
+		if err != nil {
+			return err
+		}
+		rows[i] = row
+	}
+
+	var b strings.Builder
+	if t.Mode == ModeCopy {
+		fmt.Fprintf(&b, "COPY %s FROM stdin;\n", target)
+		for _, row := range rows {
+			for i, v := range row {
+				if i > 0 {
+					b.WriteByte('\t')
+				}
+				b.WriteString(copyValue(v))
+			}
+			b.WriteByte('\n')
+		}
+		b.WriteString("\\.\n")
+	} else {
+		fmt.Fprintf(&b, "INSERT INTO %s VALUES\n", target)
+		for r, row := range rows {
+			b.WriteString("  (")
+			for i, v := range row {
+				if i > 0 {
+					b.WriteString(", ")
+				}
+				b.WriteString(literal(v))
+			}
+			b.WriteByte(')')
+			if r < len(rows)-1 {
+				b.WriteString(",\n")
+			}
+		}
+		if len(t.OnConflict) > 0 {
+			keys := make([]string, len(t.OnConflict))
+			for i, k := range t.OnConflict {
+				keys[i] = quoteIdent(k)
+			}
+			fmt.Fprintf(&b, "\nON CONFLICT (%s) DO NOTHING", strings.Join(keys, ", "))
+		}
+		b.WriteString(";\n")
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// WriteDelete renders a DELETE statement removing the chunks with the
+// given IDs. The table must have a column for the chunk "id" field.
+func (t Table) WriteDelete(w io.Writer, ids []string) error {
+	if len(ids) == 0 {
+		return nil
+	}
+	idCol := ""
+	for _, c := range t.columns() {
+		if c.Field == "id" || (c.Field == "" && c.Name == "id") {
+			idCol = c.Name
+			break
+		}
+	}
+	if idCol == "" {
+		return fmt.Errorf("table has no column for the chunk id")
+	}
+	name := t.Name
+	if name == "" {
+		name = "chunks"
+	}
+	quoted := make([]string, len(ids))
+	for i, id := range ids {
+		quoted[i] = literal(id)
+	}
+	_, err := fmt.Fprintf(w, "DELETE FROM %s WHERE %s IN (%s);\n",
+		quoteQualified(name), quoteIdent(idCol), strings.Join(quoted, ", "))
+	return err
+}
+
+// values extracts the configured fields from the chunk's JSON form, so
+// column fields use the same names as every other output.
+func values(ch chunking.Chunk, cols []Column) ([]interface{}, error) {
+	data, err := json.Marshal(ch)
+	if err != nil {
+		return nil, err
+	}
+	var fields map[string]interface{}
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return nil, err
+	}
+	row := make([]interface{}, len(cols))
+	for i, c := range cols {
+		field := c.Field
+		if field == "" {
+			field = c.Name
+		}
+		if key, ok := strings.CutPrefix(field, "extra."); ok {
+			extra, _ := fields["extra"].(map[string]interface{})
+			row[i] = extra[key]
+			continue
+		}
+		row[i] = fields[field]
+	}
+	return row, nil
+}
+
+// text renders a non-NULL value as text: strings as-is, objects and lists
+// as JSON.
+func text(v interface{}) string {
+	switch v := v.(type) {
+	case string:
+		return v
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case bool:
+		return strconv.FormatBool(v)
+	default:
+		data, _ := json.Marshal(v)
+		return string(data)
+	}
+}
+
+func literal(v interface{}) string {
+	switch v.(type) {
+	case nil:
+		return "NULL"
+	case float64, bool:
+		return text(v)
+	}
+	return "'" + strings.ReplaceAll(text(v), "'", "''") + "'"
+}
+
+var copyEscaper = strings.NewReplacer(`\`, `\\`, "\t", `\t`, "\n", `\n`, "\r", `\r`)
+
+func copyValue(v interface{}) string {
+	if v == nil {
+		return `\N`
+	}
+	return copyEscaper.Replace(text(v))
+}
+
+func quoteIdent(s string) string {
+	return `"` + strings.ReplaceAll(s, `"`, `""`) + `"`
+}
+
+func quoteQualified(name string) string {
+	parts := strings.Split(name, ".")
+	for i, p := range parts {
+		parts[i] = quoteIdent(p)
+	}
+	return strings.Join(parts, ".")
+}
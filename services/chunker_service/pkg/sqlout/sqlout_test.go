@@ -0,0 +1,55 @@
+package sqlout
+
+import (
+	"strings"
+	"testing"
+
+	"chunker-service/pkg/chunking"
+)
+
+func TestInsertStatement(t *testing.T) {
+	table := Table{
+		Name:       "rag.chunks",
+		Columns:    []Column{{Name: "chunk_id", Field: "id"}, {Name: "body", Field: "text"}, {Name: "idx", Field: "chunk_index"}, {Name: "team", Field: "extra.team"}, {Name: "doc_id"}},
+		OnConflict: []string{"chunk_id"},
+	}
+	chunks := []chunking.Chunk{
+		{ID: "d#0", Text: "it's", Extra: map[string]interface{}{"team": "docs"}},
+		{ID: "d#1", Text: "two", ChunkIndex: 1},
+	}
+	var b strings.Builder
+	if err := table.Write(&b, chunks); err != nil {
+		t.Fatalf("write failed: %v", err)
+	}
+	want := `INSERT INTO "rag"."chunks" ("chunk_id", "body", "idx", "team", "doc_id") VALUES
+  ('d#0', 'it''s', 0, 'docs', NULL),
+  ('d#1', 'two', 1, NULL, NULL)
+ON CONFLICT ("chunk_id") DO NOTHING;
+`
+	if b.String() != want {
+		t.Fatalf("unexpected SQL:\n%s\nwant:\n%s", b.String(), want)
+	}
+}
+
+func TestCopyBlock(t *testing.T) {
+	table := Table{Mode: ModeCopy, Columns: []Column{{Name: "text"}, {Name: "tags"}, {Name: "doc_id"}}}
+	chunks := []chunking.Chunk{{Text: "a\tb\\c\nd", Tags: []string{"x"}}}
+	var b strings.Builder
+	if err := table.Write(&b, chunks); err != nil {
+		t.Fatalf("write failed: %v", err)
+	}
+	want := "COPY \"chunks\" (\"text\", \"tags\", \"doc_id\") FROM stdin;\n" +
+		"a\\tb\\\\c\\nd\t[\"x\"]\t\\N\n\\.\n"
+	if b.String() != want {
+		t.Fatalf("unexpected COPY block:\n%q\nwant:\n%q", b.String(), want)
+	}
+}
+
+func TestValidate(t *testing.T) {
+	if err := (Table{Mode: "merge"}).Validate(); err == nil {
+		t.Errorf("expected error for unknown mode")
+	}
+	if err := (Table{Mode: ModeCopy, OnConflict: []string{"id"}}).Validate(); err == nil {
+		t.Errorf("expected error for on_conflict with copy")
+	}
+}
@@ -0,0 +1,132 @@
+package tokenizer
+
+import (
+	"math"
+	"regexp"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Pre-tokenizer patterns. Go's regexp has no lookahead, so the
+// `\s+(?!\S)` alternative of the reference patterns is emulated by
+// splitPieces instead.
+var (
+	// cl100kPattern approximates the tiktoken cl100k_base/o200k_base
+	// pre-tokenizer.
+	cl100kPattern = regexp.MustCompile(`(?i:'s|'t|'re|'ve|'m|'ll|'d)|[^\r\n\p{L}\p{N}]?\p{L}+|\p{N}{1,3}| ?[^\s\p{L}\p{N}]+[\r\n]*|\s*[\r\n]+|\s+`)
+	// gpt2Pattern is the GPT-2 style pre-tokenizer used by byte-level BPE
+	// vocabularies from HuggingFace.
+	gpt2Pattern = regexp.MustCompile(`'s|'t|'re|'ve|'m|'ll|'d| ?\p{L}+| ?\p{N}+| ?[^\s\p{L}\p{N}]+|\s+`)
+)
+
+// BPE is a byte-level byte-pair-encoding tokenizer. Ranks maps byte
+// sequences to their merge priority (lower merges first); single bytes are
+// always valid tokens.
+type BPE struct {
+	ranks   map[string]int
+	pattern *regexp.Regexp
+	// leadingSpace reports whether a pre-token piece may absorb the last
+	// character of a preceding whitespace run.
+	leadingSpace func(r rune, next string) bool
+}
+
+// Tokenize splits text into BPE tokens. Tokens that would split a
+// multi-byte UTF-8 character are merged with their neighbors so every
+// piece, and therefore every chunk, is valid UTF-8; such characters count
+// as a single unit.
+func (b *BPE) Tokenize(text string) []string {
+	var tokens []string
+	pending := ""
+	for _, piece := range splitPieces(b.pattern, text, b.leadingSpace) {
+		for _, tok := range b.encodePiece(piece) {
+			pending += tok
+			if utf8.ValidString(pending) {
+				tokens = append(tokens, pending)
+				pending = ""
+			}
+		}
+	}
+	if pending != "" {
+		tokens = append(tokens, pending)
+	}
+	return tokens
+}
+
+// Join concatenates tokens; byte-level BPE is lossless.
+func (b *BPE) Join(tokens []string) string { return strings.Join(tokens, "") }
+
+// encodePiece applies byte-pair merges to a single pre-token piece.
+func (b *BPE) encodePiece(piece string) []string {
+	if _, ok := b.ranks[piece]; ok {
+		return []string{piece}
+	}
+	// parts holds the start offsets of the current tokens plus len(piece).
+	parts := make([]int, len(piece)+1)
+	for i := range parts {
+		parts[i] = i
+	}
+	for len(parts) > 2 {
+		best, bestRank := -1, math.MaxInt
+		for i := 0; i+2 < len(parts); i++ {
+			if rank, ok := b.ranks[piece[parts[i]:parts[i+2]]]; ok && rank < bestRank {
+				best, bestRank = i, rank
+			}
+		}
+		if best < 0 {
+			break
+		}
+		parts = append(parts[:best+1], parts[best+2:]...)
+	}
+	out := make([]string, 0, len(parts)-1)
+	for i := 0; i+1 < len(parts); i++ {
+		out = append(out, piece[parts[i]:parts[i+1]])
+	}
+	return out
+}
+
+// splitPieces runs the pre-tokenizer pattern and then emulates
+// `\s+(?!\S)`: a whitespace run followed by a non-space piece gives up its
+// last character, which joins that piece if the pattern would have let it
+// lead the piece, and otherwise stands alone.
+func splitPieces(re *regexp.Regexp, text string, leadingSpace func(r rune, next string) bool) []string {
+	matches := re.FindAllString(text, -1)
+	out := make([]string, 0, len(matches))
+	for i := 0; i < len(matches); i++ {
+		m := matches[i]
+		if i+1 < len(matches) && strings.TrimSpace(m) == "" {
+			next := matches[i+1]
+			r, size := utf8.DecodeLastRuneInString(m)
+			first, _ := utf8.DecodeRuneInString(next)
+			if !unicode.IsSpace(first) && r != '\n' && r != '\r' {
+				if rest := m[:len(m)-size]; rest != "" {
+					out = append(out, rest)
+				}
+				if leadingSpace(r, next) {
+					matches[i+1] = m[len(m)-size:] + next
+				} else {
+					out = append(out, m[len(m)-size:])
+				}
+				continue
+			}
+		}
+		out = append(out, m)
+	}
+	return out
+}
+
+// cl100kLeadingSpace mirrors `[^\r\n\p{L}\p{N}]?\p{L}+` and
+// ` ?[^\s\p{L}\p{N}]+`.
+func cl100kLeadingSpace(r rune, next string) bool {
+	first, _ := utf8.DecodeRuneInString(next)
+	if unicode.IsLetter(first) {
+		return true
+	}
+	return r == ' ' && !unicode.IsDigit(first) && !unicode.IsNumber(first)
+}
+
+// gpt2LeadingSpace mirrors the optional leading space of every GPT-2
+// word, number and punctuation alternative.
+func gpt2LeadingSpace(r rune, next string) bool {
+	return r == ' ' && !strings.HasPrefix(next, "'")
+}
@@ -0,0 +1,168 @@
+package tokenizer
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// LoadTiktoken reads a tiktoken rank file (one "<base64 token> <rank>"
+// pair per line, as published for cl100k_base and o200k_base).
+func LoadTiktoken(r io.Reader) (*BPE, error) {
+	ranks := map[string]int{}
+	sc := bufio.NewScanner(r)
+	sc.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for line := 1; sc.Scan(); line++ {
+		fields := strings.Fields(sc.Text())
+		if len(fields) == 0 {
+			continue
+		}
+		if len(fields) != 2 {
+			return nil, fmt.Errorf("line %d: expected token and rank", line)
+		}
+		tok, err := base64.StdEncoding.DecodeString(fields[0])
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+		rank, err := strconv.Atoi(fields[1])
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+		ranks[string(tok)] = rank
+	}
+	if err := sc.Err(); err != nil {
+		return nil, err
+	}
+	return &BPE{ranks: ranks, pattern: cl100kPattern, leadingSpace: cl100kLeadingSpace}, nil
+}
+
+// LoadHuggingFaceBPE reads a byte-level BPE vocabulary in the HuggingFace
+// vocab.json/merges.txt format (GPT-2, RoBERTa and similar). Merge
+// priority is taken from the order of merges.txt; merges whose result is
+// missing from the vocabulary are ignored.
+func LoadHuggingFaceBPE(vocab, merges io.Reader) (*BPE, error) {
+	var v map[string]int
+	if err := json.NewDecoder(vocab).Decode(&v); err != nil {
+		return nil, fmt.Errorf("vocab: %w", err)
+	}
+	decode := byteDecoder()
+	toBytes := func(s string) (string, bool) {
+		var b strings.Builder
+		for _, r := range s {
+			c, ok := decode[r]
+			if !ok {
+				return "", false
+			}
+			b.WriteByte(c)
+		}
+		return b.String(), true
+	}
+
+	known := make(map[string]bool, len(v))
+	for tok := range v {
+		if b, ok := toBytes(tok); ok {
+			known[b] = true
+		}
+	}
+
+	ranks := map[string]int{}
+	sc := bufio.NewScanner(merges)
+	for rank := 0; sc.Scan(); {
+		line := sc.Text()
+		if strings.HasPrefix(line, "#version") || strings.TrimSpace(line) == "" {
+			continue
+		}
+		pair := strings.Fields(line)
+		if len(pair) != 2 {
+			return nil, fmt.Errorf("merges: malformed line %q", line)
+		}
+		merged, ok := toBytes(pair[0] + pair[1])
+		if !ok {
+			return nil, fmt.Errorf("merges: token %q is not byte-level", line)
+		}
+		if _, exists := ranks[merged]; !exists && known[merged] {
+			ranks[merged] = rank
+		}
+		rank++
+	}
+	if err := sc.Err(); err != nil {
+		return nil, err
+	}
+	return &BPE{ranks: ranks, pattern: gpt2Pattern, leadingSpace: gpt2LeadingSpace}, nil
+}
+
+// byteDecoder inverts GPT-2's reversible byte-to-unicode mapping.
+func byteDecoder() map[rune]byte {
+	dec := map[rune]byte{}
+	n := 0
+	for b := 0; b < 256; b++ {
+		printable := (b >= '!' && b <= '~') || (b >= 0xA1 && b <= 0xAC) || (b >= 0xAE && b <= 0xFF)
+		if printable {
+			dec[rune(b)] = byte(b)
+		} else {
+			dec[rune(256+n)] = byte(b)
+			n++
+		}
+	}
+	return dec
+}
+
+// LoadDir registers every tokenizer found in dir: "<name>.tiktoken" rank
+// files, and "<name>/" subdirectories containing vocab.json and
+// merges.txt. It returns the names it registered. Symlinks are followed
+// and hidden entries skipped, so dir may be a mounted Kubernetes
+// ConfigMap, whose "..data" links point at the current version.
+func LoadDir(dir string) ([]string, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	var names []string
+	for _, e := range entries {
+		if strings.HasPrefix(e.Name(), ".") {
+			continue
+		}
+		path := filepath.Join(dir, e.Name())
+		info, err := os.Stat(path)
+		if err != nil {
+			return names, err
+		}
+		switch {
+		case !info.IsDir() && strings.HasSuffix(e.Name(), ".tiktoken"):
+			name := strings.TrimSuffix(e.Name(), ".tiktoken")
+			data, err := os.ReadFile(path)
+			if err != nil {
+				return names, err
+			}
+			tok, err := LoadTiktoken(bytes.NewReader(data))
+			if err != nil {
+				return names, fmt.Errorf("%s: %w", path, err)
+			}
+			registerFiles(name, tok, data)
+			names = append(names, name)
+		case info.IsDir():
+			vocab, err := os.ReadFile(filepath.Join(path, "vocab.json"))
+			if err != nil {
+				continue
+			}
+			merges, err := os.ReadFile(filepath.Join(path, "merges.txt"))
+			if err != nil {
+				continue
+			}
+			tok, err := LoadHuggingFaceBPE(bytes.NewReader(vocab), bytes.NewReader(merges))
+			if err != nil {
+				return names, fmt.Errorf("%s: %w", path, err)
+			}
+			registerFiles(e.Name(), tok, vocab, merges)
+			names = append(names, e.Name())
+		}
+	}
+	return names, nil
+}
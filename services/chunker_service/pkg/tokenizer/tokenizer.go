@@ -0,0 +1,95 @@
+// Package tokenizer provides the pluggable tokenizers behind the chunker's
+// tokens mode, so window_size can be expressed in the tokens of the
+// embedding model that will consume the chunks.
+package tokenizer
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Tokenizer splits text into the units counted by the tokens mode.
+type Tokenizer interface {
+	// Tokenize splits text into token pieces.
+	Tokenize(text string) []string
+	// Join reassembles a run of token pieces into text.
+	Join(tokens []string) string
+}
+
+// Default is the name of the tokenizer used when a plan names none. It
+// splits on whitespace, matching the chunker's historical behavior.
+const Default = "whitespace"
+
+var (
+	mu       sync.RWMutex
+	registry = map[string]Tokenizer{Default: Whitespace{}}
+	// digests identify the files LoadDir loaded each tokenizer from.
+	digests = map[string]string{Default: "builtin"}
+)
+
+// Register adds or replaces a named tokenizer.
+func Register(name string, t Tokenizer) {
+	mu.Lock()
+	defer mu.Unlock()
+	registry[name] = t
+	delete(digests, name)
+}
+
+// Digest identifies the version of a tokenizer: the SHA-256 of the files
+// LoadDir loaded it from, "builtin" for the default tokenizer, or "" for
+// tokenizers registered directly, whose source is unknown.
+func Digest(name string) string {
+	mu.RLock()
+	defer mu.RUnlock()
+	return digests[name]
+}
+
+func registerFiles(name string, t Tokenizer, data ...[]byte) {
+	h := sha256.New()
+	for _, d := range data {
+		h.Write(d)
+	}
+	Register(name, t)
+	mu.Lock()
+	defer mu.Unlock()
+	digests[name] = hex.EncodeToString(h.Sum(nil))
+}
+
+// Get returns the named tokenizer, or the default one for "".
+func Get(name string) (Tokenizer, error) {
+	if name == "" {
+		name = Default
+	}
+	mu.RLock()
+	defer mu.RUnlock()
+	t, ok := registry[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown tokenizer %q", name)
+	}
+	return t, nil
+}
+
+// Names returns the registered tokenizer names.
+func Names() []string {
+	mu.RLock()
+	defer mu.RUnlock()
+	names := make([]string, 0, len(registry))
+	for name := range registry {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Whitespace treats every whitespace-delimited word as one token.
+type Whitespace struct{}
+
+// Tokenize splits text on whitespace.
+func (Whitespace) Tokenize(text string) []string { return strings.Fields(text) }
+
+// Join joins tokens with single spaces.
+func (Whitespace) Join(tokens []string) string { return strings.Join(tokens, " ") }
@@ -0,0 +1,163 @@
+package tokenizer
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// rankFile builds a tiktoken rank file containing every single byte
+// followed by the given merged tokens in priority order.
+func rankFile(merged ...string) string {
+	var b strings.Builder
+	rank := 0
+	for i := 0; i < 256; i++ {
+		fmt.Fprintf(&b, "%s %d\n", base64.StdEncoding.EncodeToString([]byte{byte(i)}), rank)
+		rank++
+	}
+	for _, tok := range merged {
+		fmt.Fprintf(&b, "%s %d\n", base64.StdEncoding.EncodeToString([]byte(tok)), rank)
+		rank++
+	}
+	return b.String()
+}
+
+func TestWhitespaceIsDefault(t *testing.T) {
+	tok, err := Get("")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	got := tok.Tokenize("one  two\nthree")
+	if want := []string{"one", "two", "three"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("expected %v, got %v", want, got)
+	}
+	if _, err := Get("nope"); err == nil {
+		t.Fatalf("expected error for unknown tokenizer")
+	}
+}
+
+func TestTiktokenMerges(t *testing.T) {
+	tok, err := LoadTiktoken(strings.NewReader(rankFile("he", "ll", "hell", "hello", " w", " wo")))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	got := tok.Tokenize("hello world")
+	want := []string{"hello", " wo", "r", "l", "d"}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("expected %q, got %q", want, got)
+	}
+	if joined := tok.Join(got); joined != "hello world" {
+		t.Fatalf("expected lossless join, got %q", joined)
+	}
+}
+
+func TestPreTokenizerWhitespaceRuns(t *testing.T) {
+	got := splitPieces(cl100kPattern, "a   b  12", cl100kLeadingSpace)
+	want := []string{"a", "  ", " b", " ", " ", "12"}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("expected %q, got %q", want, got)
+	}
+}
+
+func TestTokensStayValidUTF8(t *testing.T) {
+	tok, err := LoadTiktoken(strings.NewReader(rankFile()))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	got := tok.Tokenize("héllo")
+	want := []string{"h", "é", "l", "l", "o"}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("expected %q, got %q", want, got)
+	}
+}
+
+func TestLoadDir(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "tiny.tiktoken"), []byte(rankFile("ab")), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	hf := filepath.Join(dir, "tiny-hf")
+	if err := os.Mkdir(hf, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	// "Ġ" is GPT-2's byte-level encoding of a space.
+	vocab := `{"a": 0, "b": 1, "Ġ": 2, "Ġa": 3, "Ġab": 4}`
+	merges := "#version: 0.2\nĠ a\nĠa b\n"
+	if err := os.WriteFile(filepath.Join(hf, "vocab.json"), []byte(vocab), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(hf, "merges.txt"), []byte(merges), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	names, err := LoadDir(dir)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if want := []string{"tiny-hf", "tiny"}; !reflect.DeepEqual(names, want) {
+		t.Fatalf("expected %v, got %v", want, names)
+	}
+
+	tok, err := Get("tiny-hf")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got, want := tok.Tokenize("ab ab"), []string{"a", "b", " ab"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("expected %q, got %q", want, got)
+	}
+
+	sum := sha256.Sum256([]byte(rankFile("ab")))
+	if got := Digest("tiny"); got != hex.EncodeToString(sum[:]) {
+		t.Errorf("expected the rank file's SHA-256 as digest, got %q", got)
+	}
+	if Digest(Default) != "builtin" {
+		t.Errorf("expected the default tokenizer to be builtin, got %q", Digest(Default))
+	}
+	Register("tiny", tok)
+	if got := Digest("tiny"); got != "" {
+		t.Errorf("expected no digest after Register, got %q", got)
+	}
+}
+
+func TestLoadDirConfigMap(t *testing.T) {
+	// A mounted ConfigMap: keys are symlinks through "..data" to a hidden,
+	// timestamped directory holding the current version.
+	dir := t.TempDir()
+	version := filepath.Join(dir, "..2024_01_01_00_00_00.1")
+	if err := os.MkdirAll(filepath.Join(version, "cm-hf"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	files := map[string]string{
+		"cm.tiktoken":       rankFile("ab"),
+		"cm-hf/vocab.json":  `{"a": 0, "b": 1}`,
+		"cm-hf/merges.txt":  "#version: 0.2\n",
+		"unlinked.tiktoken": "not a rank file",
+	}
+	for name, data := range files {
+		if err := os.WriteFile(filepath.Join(version, name), []byte(data), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.Symlink(filepath.Base(version), filepath.Join(dir, "..data")); err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"cm.tiktoken", "cm-hf"} {
+		if err := os.Symlink(filepath.Join("..data", name), filepath.Join(dir, name)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	names, err := LoadDir(dir)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if want := []string{"cm-hf", "cm"}; !reflect.DeepEqual(names, want) {
+		t.Fatalf("expected %v, got %v", want, names)
+	}
+}
@@ -0,0 +1,324 @@
+// Package vectorstore defines the storage interface for embedded chunks
+// and a small in-memory implementation for tests, demos and laptop
+// deployments that should not depend on Milvus.
+package vectorstore
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"sync"
+	"time"
+
+	"chunker-service/pkg/chunking"
+)
+
+// Record is an embedded chunk.
+type Record struct {
+	Chunk  chunking.Chunk
+	Vector []float64
+}
+
+// Match is a search hit; higher scores are more similar.
+type Match struct {
+	Chunk chunking.Chunk `json:"chunk"`
+	Score float64        `json:"score"`
+}
+
+// Filter restricts a search to chunks matching every non-empty field.
+// Tags match when the chunk carries any of them; Language matches the
+// chunk's "language" metadata; Section matches its Section or, failing
+// that, the heading the chunker recorded in Extra["heading"]. The creation
+// and effective date ranges are half-open: [CreatedAfter, CreatedBefore).
+// Chunks without an effective date never match an effective date range.
+type Filter struct {
+	Tenant        string     `json:"tenant,omitempty"`
+	DocID         string     `json:"doc_id,omitempty"`
+	Language      string     `json:"language,omitempty"`
+	Section       string     `json:"section,omitempty"`
+	Tags          []string   `json:"tags,omitempty"`
+	CreatedAfter  *time.Time `json:"created_after,omitempty"`
+	CreatedBefore *time.Time `json:"created_before,omitempty"`
+	EffectiveFrom *time.Time `json:"effective_from,omitempty"`
+	EffectiveTo   *time.Time `json:"effective_to,omitempty"`
+}
+
+func (f Filter) match(c *chunking.Chunk) bool {
+	switch {
+	case f.Tenant != "" && f.Tenant != c.Tenant,
+		f.DocID != "" && f.DocID != c.DocID,
+		f.Section != "" && f.Section != section(c),
+		f.CreatedAfter != nil && c.CreatedAt.Before(*f.CreatedAfter),
+		f.CreatedBefore != nil && !c.CreatedAt.Before(*f.CreatedBefore),
+		(f.EffectiveFrom != nil || f.EffectiveTo != nil) && c.EffectiveAt == nil,
+		f.EffectiveFrom != nil && c.EffectiveAt.Before(*f.EffectiveFrom),
+		f.EffectiveTo != nil && !c.EffectiveAt.Before(*f.EffectiveTo):
+		return false
+	}
+	if f.Language != "" {
+		if lang, _ := c.Extra["language"].(string); lang != f.Language {
+			return false
+		}
+	}
+	if len(f.Tags) == 0 {
+		return true
+	}
+	for _, want := range f.Tags {
+		for _, tag := range c.Tags {
+			if tag == want {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func section(c *chunking.Chunk) string {
+	if c.Section != "" {
+		return c.Section
+	}
+	s, _ := c.Extra["heading"].(string)
+	return s
+}
+
+// VectorStore stores embedded chunks and retrieves the nearest ones to a
+// query vector.
+type VectorStore interface {
+	// Upsert inserts records, replacing existing records with the same
+	// chunk ID.
+	Upsert(ctx context.Context, records []Record) error
+	// Search returns up to k matches ordered by descending score.
+	Search(ctx context.Context, query []float64, k int, filter Filter) ([]Match, error)
+	// Delete removes the chunks with the given IDs; unknown IDs are
+	// ignored.
+	Delete(ctx context.Context, ids []string) error
+	// DeleteByDocument removes every chunk whose DocID is docID, so a
+	// re-ingested or removed document leaves no stale chunks behind, and
+	// returns the number removed.
+	DeleteByDocument(ctx context.Context, docID string) (int, error)
+}
+
+// Memory is a flat (exact, brute-force) cosine-similarity index held in
+// memory. Search is linear in the number of records, which is fine for
+// tens of thousands of chunks. It is safe for concurrent use.
+//
+// Chunks past their expires_at are left out of search results, so a
+// time-sensitive corpus ages out without a purge; DeleteExpired frees
+// their memory.
+type Memory struct {
+	// Now returns the current time; tests override it.
+	Now func() time.Time
+
+	mu      sync.RWMutex
+	dim     int
+	records []Record
+	norms   []float64
+	index   map[string]int
+}
+
+// NewMemory returns an empty in-memory store. The vector dimension is
+// fixed by the first upsert.
+func NewMemory() *Memory {
+	return &Memory{Now: time.Now, index: map[string]int{}}
+}
+
+// Len returns the number of stored records.
+func (m *Memory) Len() int {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return len(m.records)
+}
+
+// Chunks returns the stored chunks, without their vectors, in the order
+// they were first stored.
+func (m *Memory) Chunks() []chunking.Chunk {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	chunks := make([]chunking.Chunk, len(m.records))
+	for i, r := range m.records {
+		chunks[i] = r.Chunk
+	}
+	return chunks
+}
+
+// Upsert implements VectorStore. Every record is checked before any is
+// stored, so a bad record leaves the store unchanged.
+func (m *Memory) Upsert(_ context.Context, records []Record) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	dim := m.dim
+	for _, r := range records {
+		if r.Chunk.ID == "" {
+			return errors.New("vectorstore: record without chunk id")
+		}
+		if dim == 0 {
+			dim = len(r.Vector)
+		}
+		if len(r.Vector) != dim || dim == 0 {
+			return fmt.Errorf("vectorstore: chunk %s has dimension %d, store has %d", r.Chunk.ID, len(r.Vector), dim)
+		}
+	}
+	m.dim = dim
+	for _, r := range records {
+		r.Vector = append([]float64(nil), r.Vector...)
+		if i, ok := m.index[r.Chunk.ID]; ok {
+			m.records[i], m.norms[i] = r, norm(r.Vector)
+			continue
+		}
+		m.index[r.Chunk.ID] = len(m.records)
+		m.records = append(m.records, r)
+		m.norms = append(m.norms, norm(r.Vector))
+	}
+	return nil
+}
+
+// Search implements VectorStore. Ties are broken by chunk ID so results
+// are deterministic.
+func (m *Memory) Search(_ context.Context, query []float64, k int, filter Filter) ([]Match, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	if k <= 0 || len(m.records) == 0 {
+		return nil, nil
+	}
+	if len(query) != m.dim {
+		return nil, fmt.Errorf("vectorstore: query has dimension %d, store has %d", len(query), m.dim)
+	}
+	qn := norm(query)
+	now := m.Now()
+	var matches []Match
+	for i := range m.records {
+		r := &m.records[i]
+		if r.Chunk.Expired(now) || !filter.match(&r.Chunk) {
+			continue
+		}
+		score := 0.0
+		if qn > 0 && m.norms[i] > 0 {
+			for j, v := range r.Vector {
+				score += v * query[j]
+			}
+			score /= qn * m.norms[i]
+		}
+		matches = append(matches, Match{Chunk: r.Chunk, Score: score})
+	}
+	sort.Slice(matches, func(a, b int) bool {
+		if matches[a].Score != matches[b].Score {
+			return matches[a].Score > matches[b].Score
+		}
+		return matches[a].Chunk.ID < matches[b].Chunk.ID
+	})
+	if len(matches) > k {
+		matches = matches[:k]
+	}
+	return matches, nil
+}
+
+// Delete implements VectorStore.
+func (m *Memory) Delete(_ context.Context, ids []string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.delete(ids)
+	return nil
+}
+
+// DeleteByDocument implements VectorStore.
+func (m *Memory) DeleteByDocument(_ context.Context, docID string) (int, error) {
+	if docID == "" {
+		return 0, errors.New("vectorstore: document id is required")
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var ids []string
+	for _, r := range m.records {
+		if r.Chunk.DocID == docID {
+			ids = append(ids, r.Chunk.ID)
+		}
+	}
+	m.delete(ids)
+	return len(ids), nil
+}
+
+func (m *Memory) delete(ids []string) {
+	for _, id := range ids {
+		i, ok := m.index[id]
+		if !ok {
+			continue
+		}
+		last := len(m.records) - 1
+		m.records[i], m.norms[i] = m.records[last], m.norms[last]
+		m.index[m.records[i].Chunk.ID] = i
+		m.records, m.norms = m.records[:last], m.norms[:last]
+		delete(m.index, id)
+	}
+}
+
+// DeleteExpired removes the chunks past their expires_at and returns
+// their IDs, so the deletion can be passed on to other stores.
+func (m *Memory) DeleteExpired(_ context.Context) ([]string, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	now := m.Now()
+	var ids []string
+	for _, r := range m.records {
+		if r.Chunk.Expired(now) {
+			ids = append(ids, r.Chunk.ID)
+		}
+	}
+	sort.Strings(ids)
+	m.delete(ids)
+	return ids, nil
+}
+
+func norm(v []float64) float64 {
+	var s float64
+	for _, x := range v {
+		s += x * x
+	}
+	return math.Sqrt(s)
+}
+
+// Index embeds the chunks' text and upserts them into store. Embedding
+// errors wrap chunking.ErrEmbeddingFailed, unless ctx ended.
+func Index(ctx context.Context, store VectorStore, embedder chunking.Embedder, chunks []chunking.Chunk) error {
+	texts := make([]string, len(chunks))
+	for i, ch := range chunks {
+		texts[i] = ch.Text
+	}
+	vectors, err := embedder.Embed(ctx, texts)
+	if ctxErr := ctx.Err(); ctxErr != nil {
+		return ctxErr
+	}
+	if err != nil {
+		return fmt.Errorf("%w: %v", chunking.ErrEmbeddingFailed, err)
+	}
+	if len(vectors) != len(chunks) {
+		return fmt.Errorf("%w: got %d vectors for %d chunks", chunking.ErrEmbeddingFailed, len(vectors), len(chunks))
+	}
+	records := make([]Record, len(chunks))
+	for i := range chunks {
+		records[i] = Record{Chunk: chunks[i], Vector: vectors[i]}
+	}
+	return store.Upsert(ctx, records)
+}
+
+// Reembed embeds chunks again, batchSize at a time, and upserts them with
+// their new vectors into dst, so an embedding model can be replaced
+// without chunking the documents again. dst is normally a new, empty
+// store: vectors of different models must not be mixed. It returns the
+// number of chunks upserted, which on error is the number before the
+// failed batch.
+func Reembed(ctx context.Context, dst VectorStore, embedder chunking.Embedder, chunks []chunking.Chunk, batchSize int) (int, error) {
+	if batchSize <= 0 {
+		batchSize = len(chunks)
+	}
+	done := 0
+	for done < len(chunks) {
+		end := min(done+batchSize, len(chunks))
+		if err := Index(ctx, dst, embedder, chunks[done:end]); err != nil {
+			return done, err
+		}
+		done = end
+	}
+	return done, nil
+}
@@ -0,0 +1,237 @@
+package vectorstore
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"chunker-service/pkg/chunking"
+	"chunker-service/pkg/embedding"
+)
+
+func TestChunkEmbedRetrieve(t *testing.T) {
+	ctx := context.Background()
+	text := "Cats purr and sleep all day.\nRockets burn fuel to reach orbit.\nTaxes are due in April."
+	chunks, err := chunking.NewSlidingWindowChunker().Chunk(text,
+		chunking.ChunkingPlan{WindowSize: 1, Mode: chunking.ModeLines},
+		map[string]interface{}{"doc_id": "d", "tenant": "acme"})
+	if err != nil {
+		t.Fatalf("chunking failed: %v", err)
+	}
+
+	store := NewMemory()
+	embedder := embedding.Hashing{}
+	if err := Index(ctx, store, embedder, chunks); err != nil {
+		t.Fatalf("index failed: %v", err)
+	}
+	query, _ := embedder.Embed(ctx, []string{"when do rockets reach orbit"})
+	matches, err := store.Search(ctx, query[0], 2, Filter{Tenant: "acme"})
+	if err != nil {
+		t.Fatalf("search failed: %v", err)
+	}
+	if len(matches) != 2 || matches[0].Chunk.ID != "d#1" {
+		t.Fatalf("expected the rockets chunk first, got %+v", matches)
+	}
+	if matches[0].Score <= matches[1].Score {
+		t.Errorf("matches should be ordered by score: %+v", matches)
+	}
+
+	if matches, _ := store.Search(ctx, query[0], 2, Filter{Tenant: "other"}); len(matches) != 0 {
+		t.Errorf("filter should exclude other tenants, got %+v", matches)
+	}
+
+	if err := store.Delete(ctx, []string{"d#1"}); err != nil {
+		t.Fatalf("delete failed: %v", err)
+	}
+	matches, _ = store.Search(ctx, query[0], 5, Filter{})
+	if store.Len() != 2 || len(matches) != 2 || matches[0].Chunk.ID == "d#1" {
+		t.Errorf("deleted chunk still returned: %+v", matches)
+	}
+
+	other := Record{Chunk: chunking.Chunk{ID: "e#0", DocID: "e"}, Vector: query[0]}
+	if err := store.Upsert(ctx, []Record{other}); err != nil {
+		t.Fatalf("upsert failed: %v", err)
+	}
+	if n, err := store.DeleteByDocument(ctx, "d"); err != nil || n != 2 {
+		t.Fatalf("expected both remaining chunks of d to be deleted, got %d (%v)", n, err)
+	}
+	if matches, _ := store.Search(ctx, query[0], 5, Filter{}); store.Len() != 1 || matches[0].Chunk.ID != "e#0" {
+		t.Errorf("expected only the other document to remain, got %+v", matches)
+	}
+	if n, _ := store.DeleteByDocument(ctx, "d"); n != 0 {
+		t.Errorf("deleting a removed document should remove nothing, got %d", n)
+	}
+}
+
+func TestMemoryUpsertReplacesAndChecksDimension(t *testing.T) {
+	ctx := context.Background()
+	store := NewMemory()
+	rec := Record{Chunk: chunking.Chunk{ID: "a", Text: "old"}, Vector: []float64{1, 0}}
+	if err := store.Upsert(ctx, []Record{rec}); err != nil {
+		t.Fatalf("upsert failed: %v", err)
+	}
+	rec.Chunk.Text = "new"
+	if err := store.Upsert(ctx, []Record{rec}); err != nil {
+		t.Fatalf("upsert failed: %v", err)
+	}
+	matches, _ := store.Search(ctx, []float64{1, 0}, 1, Filter{})
+	if store.Len() != 1 || matches[0].Chunk.Text != "new" {
+		t.Errorf("upsert should replace by id: %+v", matches)
+	}
+	if err := store.Upsert(ctx, []Record{{Chunk: chunking.Chunk{ID: "b"}, Vector: []float64{1}}}); err == nil {
+		t.Errorf("expected dimension mismatch error")
+	}
+
+	batch := []Record{
+		{Chunk: chunking.Chunk{ID: "c"}, Vector: []float64{0, 1}},
+		{Chunk: chunking.Chunk{ID: "d"}, Vector: []float64{0, 1, 0}},
+	}
+	if err := store.Upsert(ctx, batch); err == nil || store.Len() != 1 {
+		t.Errorf("a bad record must leave the store unchanged: %v, %d records", err, store.Len())
+	}
+	empty := NewMemory()
+	if err := empty.Upsert(ctx, []Record{batch[1], {Chunk: chunking.Chunk{ID: "e"}, Vector: []float64{1}}}); err == nil || empty.Len() != 0 {
+		t.Errorf("expected a failed first batch to store nothing: %v", err)
+	}
+	if err := empty.Upsert(ctx, []Record{{Chunk: chunking.Chunk{ID: "e"}, Vector: []float64{1}}}); err != nil {
+		t.Errorf("a failed batch must not fix the dimension: %v", err)
+	}
+}
+
+func TestFilterFacets(t *testing.T) {
+	ctx := context.Background()
+	day := func(d int) time.Time { return time.Date(2024, 1, d, 0, 0, 0, 0, time.UTC) }
+	effective := day(3)
+	store := NewMemory()
+	records := []Record{
+		{Chunk: chunking.Chunk{ID: "a", Section: "Intro", Tags: []string{"faq"}, CreatedAt: day(1),
+			Extra: map[string]interface{}{"language": "en"}}, Vector: []float64{1, 0}},
+		{Chunk: chunking.Chunk{ID: "b", Section: "Usage", Tags: []string{"howto", "cli"}, CreatedAt: day(5), EffectiveAt: &effective,
+			Extra: map[string]interface{}{"language": "de"}}, Vector: []float64{0, 1}},
+	}
+	if err := store.Upsert(ctx, records); err != nil {
+		t.Fatalf("upsert failed: %v", err)
+	}
+	after, before := day(5), day(5)
+	cases := []struct {
+		name   string
+		filter Filter
+		want   string
+	}{
+		{"language", Filter{Language: "de"}, "b"},
+		{"section", Filter{Section: "Intro"}, "a"},
+		{"any tag", Filter{Tags: []string{"nope", "cli"}}, "b"},
+		{"created after is inclusive", Filter{CreatedAfter: &after}, "b"},
+		{"created before is exclusive", Filter{CreatedBefore: &before}, "a"},
+		{"effective range", Filter{EffectiveFrom: &effective, EffectiveTo: &before}, "b"},
+		{"effective range excludes undated", Filter{EffectiveTo: &after}, "b"},
+		{"effective to is exclusive", Filter{EffectiveTo: &effective}, ""},
+		{"no match", Filter{Language: "en", Section: "Usage"}, ""},
+	}
+	for _, tc := range cases {
+		matches, err := store.Search(ctx, []float64{1, 1}, 5, tc.filter)
+		if err != nil {
+			t.Fatalf("%s: search failed: %v", tc.name, err)
+		}
+		got := ""
+		for _, m := range matches {
+			got += m.Chunk.ID
+		}
+		if got != tc.want {
+			t.Errorf("%s: got %q, want %q", tc.name, got, tc.want)
+		}
+	}
+}
+
+func TestMemoryExpiry(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	past, future := now.Add(-time.Hour), now.Add(time.Hour)
+	store := NewMemory()
+	store.Now = func() time.Time { return now }
+	err := store.Upsert(ctx, []Record{
+		{Chunk: chunking.Chunk{ID: "expired", ExpiresAt: &past}, Vector: []float64{1, 0}},
+		{Chunk: chunking.Chunk{ID: "current", ExpiresAt: &future}, Vector: []float64{1, 0}},
+		{Chunk: chunking.Chunk{ID: "forever"}, Vector: []float64{1, 0}},
+	})
+	if err != nil {
+		t.Fatalf("upsert failed: %v", err)
+	}
+	matches, _ := store.Search(ctx, []float64{1, 0}, 5, Filter{})
+	if len(matches) != 2 || matches[0].Chunk.ID != "current" || matches[1].Chunk.ID != "forever" {
+		t.Fatalf("expired chunks should not be returned: %+v", matches)
+	}
+
+	ids, err := store.DeleteExpired(ctx)
+	if err != nil {
+		t.Fatalf("delete expired failed: %v", err)
+	}
+	if len(ids) != 1 || ids[0] != "expired" || store.Len() != 2 {
+		t.Errorf("expected only the expired chunk deleted, got %v with %d left", ids, store.Len())
+	}
+}
+
+func TestFilterSectionFromHeadings(t *testing.T) {
+	ctx := context.Background()
+	text := "# Install\nRun the installer.\n# Usage\nRun the binary.\n"
+	chunks, err := chunking.NewSlidingWindowChunker().Chunk(text,
+		chunking.ChunkingPlan{WindowSize: 5, Mode: chunking.ModeLines, BreakOnHeadings: true}, nil)
+	if err != nil {
+		t.Fatalf("chunking failed: %v", err)
+	}
+	store := NewMemory()
+	if err := Index(ctx, store, embedding.Hashing{}, chunks); err != nil {
+		t.Fatalf("index failed: %v", err)
+	}
+	query, _ := embedding.Hashing{}.Embed(ctx, []string{"run"})
+	matches, err := store.Search(ctx, query[0], 5, Filter{Section: "Usage"})
+	if err != nil {
+		t.Fatalf("search failed: %v", err)
+	}
+	if len(matches) != 1 || matches[0].Chunk.ChunkIndex != 1 {
+		t.Fatalf("section filter over chunker output returned %+v", matches)
+	}
+}
+
+// pairEmbedder embeds every text as a two-dimensional vector.
+type pairEmbedder struct{ calls int }
+
+func (p *pairEmbedder) Embed(_ context.Context, texts []string) ([][]float64, error) {
+	p.calls++
+	vectors := make([][]float64, len(texts))
+	for i, text := range texts {
+		vectors[i] = []float64{float64(len(text)), 1}
+	}
+	return vectors, nil
+}
+
+func TestReembed(t *testing.T) {
+	ctx := context.Background()
+	chunks, err := chunking.NewSlidingWindowChunker().Chunk("a\nbb\nccc\ndddd\neeeee",
+		chunking.ChunkingPlan{WindowSize: 1, Mode: chunking.ModeLines}, map[string]interface{}{"doc_id": "d"})
+	if err != nil {
+		t.Fatalf("chunking failed: %v", err)
+	}
+	blue := NewMemory()
+	if err := Index(ctx, blue, embedding.Hashing{}, chunks); err != nil {
+		t.Fatalf("index failed: %v", err)
+	}
+
+	green := NewMemory()
+	embedder := &pairEmbedder{}
+	n, err := Reembed(ctx, green, embedder, blue.Chunks(), 2)
+	if err != nil || n != len(chunks) {
+		t.Fatalf("reembed returned %d, %v; want %d chunks", n, err, len(chunks))
+	}
+	if embedder.calls != 3 {
+		t.Errorf("5 chunks in batches of 2 should take 3 calls, took %d", embedder.calls)
+	}
+	// The new store holds the same chunks under the new model's vectors.
+	matches, err := green.Search(ctx, []float64{4, 1}, 1, Filter{})
+	if err != nil || len(matches) != 1 || matches[0].Chunk.Text != "dddd" {
+		t.Fatalf("search of the re-embedded store returned %+v, %v", matches, err)
+	}
+	if blue.Len() != len(chunks) {
+		t.Errorf("the source store must be left alone, has %d chunks", blue.Len())
+	}
+}
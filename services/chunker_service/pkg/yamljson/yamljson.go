@@ -0,0 +1,42 @@
+// Package yamljson reads configuration files written in JSON or YAML.
+// YAML is converted to JSON before decoding, so the json struct tags stay
+// the single source of truth for field names in both forms.
+package yamljson
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// IsYAML reports whether path has a .yaml or .yml extension.
+func IsYAML(path string) bool {
+	ext := strings.ToLower(filepath.Ext(path))
+	return ext == ".yaml" || ext == ".yml"
+}
+
+// ToJSON converts a YAML document to JSON.
+func ToJSON(data []byte) ([]byte, error) {
+	var raw interface{}
+	if err := yaml.Unmarshal(data, &raw); err != nil {
+		return nil, err
+	}
+	return json.Marshal(raw)
+}
+
+// ReadFile reads the file at path as JSON, converting it first when IsYAML
+// says it is YAML. Errors reading the file are returned unchanged.
+func ReadFile(path string) ([]byte, error) {
+	data, err := os.ReadFile(path)
+	if err != nil || !IsYAML(path) {
+		return data, err
+	}
+	if data, err = ToJSON(data); err != nil {
+		return nil, fmt.Errorf("invalid yaml in %s: %w", filepath.Base(path), err)
+	}
+	return data, nil
+}
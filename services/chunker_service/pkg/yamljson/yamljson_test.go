@@ -0,0 +1,42 @@
+package yamljson
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestReadFile(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"plan.yaml": "window_size: 5\nlanguages: [en, de]\n",
+		"plan.YML":  "window_size: 5\n",
+		"plan.json": `{"window_size": 5}`,
+		"bad.yaml":  "window_size: [5\n",
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	want := map[string]string{
+		"plan.yaml": `{"languages":["en","de"],"window_size":5}`,
+		"plan.YML":  `{"window_size":5}`,
+		"plan.json": `{"window_size": 5}`,
+	}
+	for name, w := range want {
+		got, err := ReadFile(filepath.Join(dir, name))
+		if err != nil || string(got) != w {
+			t.Errorf("ReadFile(%s) = %s, %v; want %s", name, got, err, w)
+		}
+	}
+	if _, err := ReadFile(filepath.Join(dir, "bad.yaml")); err == nil {
+		t.Error("expected an error for invalid yaml")
+	}
+	if _, err := ReadFile(filepath.Join(dir, "missing.yaml")); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("expected a not-exist error, got %v", err)
+	}
+}
@@ -0,0 +1,260 @@
+"""A/B experiments over chunking plans.
+
+For each plan, the runner chunks the corpus with the chunker service,
+indexes the chunks into a collection of their own through the vector
+gateway, runs every question of an evaluation set against that collection
+and compares the plans' retrieval metrics.
+
+Usage:
+    python -m lib.experiment --corpus docs/ --plans plans.json --evalset evalset.jsonl \\
+        --chunker-url http://localhost:8080 --gateway-url http://localhost:8005 --output report.json
+
+plans.json is a list of {"name": ..., "plan": {...}}. The evaluation set is
+the JSONL written by lib.synth_qa. Chunk IDs differ between plans, so a hit
+counts as relevant when it contains the pair's evidence quote rather than
+when its ID matches.
+"""
+from __future__ import annotations
+
+import argparse
+import json
+import logging
+import os
+import re
+import statistics
+import sys
+import time
+from dataclasses import asdict, dataclass, field
+from typing import Any, Dict, List, Optional
+
+import httpx
+
+logger = logging.getLogger("evaluator_service.experiment")
+
+TEXT_SUFFIXES = (".txt", ".md", ".markdown", ".rst", ".html", ".htm")
+UPSERT_BATCH = 64
+
+# A hit that contains this share of the evidence's words also counts, for
+# evidence that a plan split across two chunks.
+EVIDENCE_COVERAGE = 0.8
+
+
+@dataclass
+class PlanResult:
+    name: str
+    plan: Dict[str, Any]
+    collection: str
+    documents: int = 0
+    chunks: int = 0
+    mean_chunk_chars: float = 0.0
+    questions: int = 0
+    recall_at_k: float = 0.0
+    mrr: float = 0.0
+    index_seconds: float = 0.0
+    mean_search_ms: float = 0.0
+    errors: List[str] = field(default_factory=list)
+
+
+def load_corpus(path: str) -> List[Dict[str, str]]:
+    """Read documents from a directory of text files or a JSONL file of
+    {"doc_id", "text"} objects."""
+    if os.path.isdir(path):
+        docs = []
+        for root, _, files in os.walk(path):
+            for name in sorted(files):
+                if name.lower().endswith(TEXT_SUFFIXES):
+                    full = os.path.join(root, name)
+                    with open(full, "r", errors="replace") as f:
+                        docs.append({"doc_id": os.path.relpath(full, path), "text": f.read()})
+        return docs
+    with open(path, "r") as f:
+        return [json.loads(line) for line in f if line.strip()]
+
+
+def load_jsonl(path: str) -> List[Dict[str, Any]]:
+    with open(path, "r") as f:
+        return [json.loads(line) for line in f if line.strip()]
+
+
+def _words(text: str) -> List[str]:
+    return re.findall(r"\w+", text.lower())
+
+
+def relevant(evidence: str, text: str) -> bool:
+    """Whether a hit's text supports the evidence quote."""
+    quote, hit = _words(evidence), _words(text)
+    if not quote:
+        return False
+    if " ".join(quote) in " ".join(hit):
+        return True
+    hit_words = set(hit)
+    return sum(1 for w in quote if w in hit_words) / len(quote) >= EVIDENCE_COVERAGE
+
+
+def collection_name(prefix: str, plan_name: str) -> str:
+    """Milvus collection names allow letters, digits and underscores."""
+    return re.sub(r"\W", "_", f"{prefix}_{plan_name}")[:255]
+
+
+class Runner:
+    def __init__(
+        self,
+        chunker_url: str,
+        gateway_url: str,
+        chunker_token: Optional[str] = None,
+        gateway_token: Optional[str] = None,
+        top_k: int = 5,
+        timeout: float = 300.0,
+    ) -> None:
+        self.chunker = httpx.Client(base_url=chunker_url.rstrip("/"), timeout=timeout, headers=_auth(chunker_token))
+        self.gateway = httpx.Client(base_url=gateway_url.rstrip("/"), timeout=timeout, headers=_auth(gateway_token))
+        self.top_k = top_k
+
+    def check_gateway(self) -> None:
+        health = self.gateway.get("/healthz").json()
+        if health.get("backend") != "milvus":
+            raise RuntimeError(
+                f"gateway backend is {health.get('backend')!r}; experiments need milvus, "
+                "as the memory backend shares one store across collections"
+            )
+
+    def run_plan(self, name: str, plan: Dict[str, Any], corpus: List[Dict[str, str]],
+                 evalset: List[Dict[str, Any]], prefix: str) -> PlanResult:
+        result = PlanResult(name=name, plan=plan, collection=collection_name(prefix, name))
+        start = time.time()
+        sizes: List[int] = []
+        batch: List[Dict[str, Any]] = []
+        for doc in corpus:
+            resp = self.chunker.post("/v1/chunk", json={
+                "text": doc["text"], "plan": plan,
+                "meta": {"doc_id": doc["doc_id"], "file_name": doc["doc_id"]},
+            })
+            if resp.status_code != 200:
+                result.errors.append(f"chunk {doc['doc_id']}: {resp.status_code} {resp.text[:200]}")
+                continue
+            result.documents += 1
+            for chunk in resp.json():
+                sizes.append(len(chunk["text"]))
+                batch.append({
+                    "doc_id": chunk["id"],
+                    "text": chunk["text"],
+                    "metadata": {
+                        "file_name": chunk.get("file_name") or doc["doc_id"],
+                        "section": chunk.get("section", ""),
+                        "chunk_index": chunk.get("chunk_index", 0),
+                    },
+                })
+                if len(batch) == UPSERT_BATCH:
+                    self._upsert(result, batch)
+                    batch = []
+        if batch:
+            self._upsert(result, batch)
+        result.chunks = len(sizes)
+        result.mean_chunk_chars = statistics.mean(sizes) if sizes else 0.0
+        result.index_seconds = round(time.time() - start, 2)
+
+        hits_found = 0
+        reciprocal_ranks: List[float] = []
+        latencies: List[int] = []
+        for pair in evalset:
+            resp = self.gateway.post("/search", json={
+                "query": pair["question"], "collection": result.collection, "top_k": self.top_k,
+            })
+            if resp.status_code != 200:
+                result.errors.append(f"search {pair.get('id')}: {resp.status_code} {resp.text[:200]}")
+                continue
+            body = resp.json()
+            latencies.append(body.get("latency_ms", 0))
+            rank = next(
+                (i + 1 for i, hit in enumerate(body.get("hits", [])) if relevant(pair["evidence"], hit["text"])),
+                None,
+            )
+            if rank:
+                hits_found += 1
+            reciprocal_ranks.append(1.0 / rank if rank else 0.0)
+        result.questions = len(reciprocal_ranks)
+        if reciprocal_ranks:
+            result.recall_at_k = round(hits_found / len(reciprocal_ranks), 4)
+            result.mrr = round(statistics.mean(reciprocal_ranks), 4)
+            result.mean_search_ms = round(statistics.mean(latencies), 1)
+        return result
+
+    def _upsert(self, result: PlanResult, documents: List[Dict[str, Any]]) -> None:
+        resp = self.gateway.post("/upsert", json={"documents": documents, "collection": result.collection})
+        if resp.status_code != 200:
+            result.errors.append(f"upsert: {resp.status_code} {resp.text[:200]}")
+
+
+def _auth(token: Optional[str]) -> Dict[str, str]:
+    return {"Authorization": f"Bearer {token}"} if token else {}
+
+
+def report(results: List[PlanResult], top_k: int) -> Dict[str, Any]:
+    """Rank plans by recall@k, then MRR, then fewer chunks."""
+    ranked = sorted(results, key=lambda r: (-r.recall_at_k, -r.mrr, r.chunks))
+    return {
+        "top_k": top_k,
+        "best": ranked[0].name if ranked else None,
+        "plans": [asdict(r) for r in ranked],
+    }
+
+
+def markdown(rep: Dict[str, Any]) -> str:
+    k = rep["top_k"]
+    lines = [
+        f"| Plan | Recall@{k} | MRR | Chunks | Mean chars | Index s | Search ms | Errors |",
+        "|------|-----------|-----|--------|------------|---------|-----------|--------|",
+    ]
+    for p in rep["plans"]:
+        lines.append(
+            f"| {p['name']} | {p['recall_at_k']:.3f} | {p['mrr']:.3f} | {p['chunks']} | "
+            f"{p['mean_chunk_chars']:.0f} | {p['index_seconds']} | {p['mean_search_ms']} | {len(p['errors'])} |"
+        )
+    return "\n".join(lines)
+
+
+def main(argv: Optional[List[str]] = None) -> int:
+    parser = argparse.ArgumentParser(description="Compare chunking plans end to end")
+    parser.add_argument("--corpus", required=True, help="directory of text files, or JSONL of {doc_id, text}")
+    parser.add_argument("--plans", required=True, help='JSON list of {"name", "plan"}')
+    parser.add_argument("--evalset", required=True, help="JSONL evaluation set from lib.synth_qa")
+    parser.add_argument("--chunker-url", default=os.environ.get("CHUNKER_URL", "http://localhost:8080"))
+    parser.add_argument("--gateway-url", default=os.environ.get("GATEWAY_URL", "http://localhost:8005"))
+    parser.add_argument("--chunker-token", default=os.environ.get("CHUNKER_TOKEN"))
+    parser.add_argument("--gateway-token", default=os.environ.get("GATEWAY_TOKEN"))
+    parser.add_argument("--prefix", default=f"exp_{int(time.time())}", help="collection name prefix")
+    parser.add_argument("--top-k", type=int, default=5)
+    parser.add_argument("--output", default=None, help="write the JSON report here")
+    args = parser.parse_args(argv)
+    logging.basicConfig(level=logging.INFO)
+
+    with open(args.plans, "r") as f:
+        plans = json.load(f)
+    names = [p["name"] for p in plans]
+    if len(set(names)) != len(names):
+        parser.error("plan names must be unique")
+    corpus = load_corpus(args.corpus)
+    evalset = [p for p in load_jsonl(args.evalset) if p.get("evidence")]
+    if not corpus or not evalset:
+        parser.error("corpus and evaluation set must not be empty")
+
+    runner = Runner(args.chunker_url, args.gateway_url, args.chunker_token, args.gateway_token, top_k=args.top_k)
+    runner.check_gateway()
+    results = []
+    for p in plans:
+        logger.info("plan %s: indexing %d documents", p["name"], len(corpus))
+        result = runner.run_plan(p["name"], p["plan"], corpus, evalset, args.prefix)
+        logger.info("plan %s: recall@%d=%.3f mrr=%.3f chunks=%d errors=%d",
+                    result.name, args.top_k, result.recall_at_k, result.mrr, result.chunks, len(result.errors))
+        results.append(result)
+
+    rep = report(results, args.top_k)
+    if args.output:
+        with open(args.output, "w") as f:
+            json.dump(rep, f, indent=2)
+    print(markdown(rep))
+    return 0
+
+
+if __name__ == "__main__":
+    sys.exit(main())
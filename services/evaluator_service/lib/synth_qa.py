@@ -0,0 +1,208 @@
+"""Synthetic question/answer generation for retrieval evaluation.
+
+Samples chunks, asks the evaluation model for questions each chunk answers,
+and keeps only pairs whose supporting quote appears in the chunk, so every
+question comes with the chunk ID a retriever should return for it.
+
+Usage:
+    python -m lib.synth_qa --input chunks.json --output evalset.jsonl --samples 200
+
+The input is the chunker's JSON array output or JSONL, one chunk per line.
+"""
+from __future__ import annotations
+
+import argparse
+import hashlib
+import json
+import logging
+import random
+import re
+import sys
+import time
+from collections import defaultdict
+from typing import Any, Dict, Iterable, List, Optional
+
+from .config import get_eval_model, get_openai_client
+
+logger = logging.getLogger("evaluator_service.synth_qa")
+
+
+def load_chunks(path: str) -> List[Dict[str, Any]]:
+    """Read chunks from a JSON array or a JSONL file."""
+    with open(path, "r") as f:
+        data = f.read()
+    stripped = data.lstrip()
+    if stripped.startswith("["):
+        return list(json.loads(stripped))
+    return [json.loads(line) for line in data.splitlines() if line.strip()]
+
+
+def _document(chunk: Dict[str, Any]) -> str:
+    return str(chunk.get("doc_id") or chunk.get("file_path") or chunk.get("file_name") or "")
+
+
+def sample_chunks(
+    chunks: Iterable[Dict[str, Any]],
+    n: int,
+    seed: int = 0,
+    min_chars: int = 200,
+    per_document: int = 0,
+) -> List[Dict[str, Any]]:
+    """Pick up to n chunks spread across documents.
+
+    Chunks shorter than min_chars rarely hold a self-contained fact and are
+    skipped. Documents are visited round-robin in random order so large
+    documents do not crowd out small ones; per_document caps the chunks
+    taken from any one document (0 = no cap).
+    """
+    rng = random.Random(seed)
+    by_doc: Dict[str, List[Dict[str, Any]]] = defaultdict(list)
+    for chunk in chunks:
+        if chunk.get("id") and len((chunk.get("text") or "").strip()) >= min_chars:
+            by_doc[_document(chunk)].append(chunk)
+    for group in by_doc.values():
+        rng.shuffle(group)
+    docs = sorted(by_doc)
+    rng.shuffle(docs)
+
+    picked: List[Dict[str, Any]] = []
+    depth = 0
+    while len(picked) < n:
+        if per_document and depth >= per_document:
+            break
+        progressed = False
+        for doc in docs:
+            if depth < len(by_doc[doc]):
+                picked.append(by_doc[doc][depth])
+                progressed = True
+                if len(picked) == n:
+                    break
+        if not progressed:
+            break
+        depth += 1
+    return picked
+
+
+PROMPT = """You are writing a retrieval evaluation set. Read the passage and write {n} question(s) that a user of this corpus might ask and that the passage alone fully answers.
+- Questions must make sense without seeing the passage: name the subject instead of saying "the passage" or "this document".
+- Prefer specific facts (values, steps, conditions, definitions) over vague summaries.
+- The answer must be short and supported by the passage.
+- evidence must be copied verbatim from the passage: the shortest span that supports the answer.
+Return ONLY valid JSON (no prose):
+{{"pairs": [{{"question": "<question>", "answer": "<answer>", "evidence": "<verbatim quote>"}}]}}
+
+Passage (from {source}):
+\"\"\"
+{text}
+\"\"\"
+"""
+
+
+def _normalize(text: str) -> str:
+    return re.sub(r"\W+", " ", text).strip().lower()
+
+
+def _grounded(evidence: str, text: str) -> bool:
+    """Whether the quoted evidence appears in the chunk, ignoring case,
+    punctuation and whitespace."""
+    quote = _normalize(evidence)
+    return bool(quote) and quote in _normalize(text)
+
+
+def generate_pairs(
+    chunk: Dict[str, Any], per_chunk: int = 1, client: Any = None, model: Optional[str] = None
+) -> List[Dict[str, Any]]:
+    """Ask the model for question/answer pairs grounded in one chunk.
+
+    Pairs without a question or answer, or whose evidence is not found in
+    the chunk, are dropped.
+    """
+    client = client or get_openai_client()
+    model = model or get_eval_model()
+    source = chunk.get("title") or chunk.get("file_name") or _document(chunk) or "unknown"
+    if chunk.get("section"):
+        source = f"{source}, section {chunk['section']}"
+    prompt = PROMPT.format(n=per_chunk, source=source, text=chunk["text"])
+    kwargs: Dict[str, Any] = {
+        "model": model,
+        "messages": [{"role": "user", "content": prompt}],
+        "response_format": {"type": "json_object"},
+    }
+    try:
+        kwargs["temperature"] = 0.2
+        resp = client.chat.completions.create(**kwargs)
+    except Exception:
+        kwargs.pop("temperature", None)
+        resp = client.chat.completions.create(**kwargs)
+
+    raw = resp.choices[0].message.content
+    try:
+        pairs = json.loads(raw).get("pairs") or []
+    except (ValueError, AttributeError):
+        logger.warning("could not parse model output for chunk %s", chunk.get("id"))
+        return []
+
+    out = []
+    for pair in pairs[:per_chunk]:
+        if not isinstance(pair, dict):
+            continue
+        question = str(pair.get("question") or "").strip()
+        answer = str(pair.get("answer") or "").strip()
+        evidence = str(pair.get("evidence") or "").strip()
+        if not question or not answer or not _grounded(evidence, chunk["text"]):
+            logger.info("dropped ungrounded pair for chunk %s: %r", chunk.get("id"), question)
+            continue
+        out.append({
+            "id": hashlib.sha1(f"{chunk['id']}\n{question}".encode("utf-8")).hexdigest()[:16],
+            "question": question,
+            "answer": answer,
+            "evidence": evidence,
+            "chunk_id": chunk["id"],
+            "doc_id": _document(chunk),
+            "section": chunk.get("section", ""),
+            "model": model,
+            "created_at": int(time.time()),
+        })
+    return out
+
+
+def main(argv: Optional[List[str]] = None) -> int:
+    parser = argparse.ArgumentParser(description="Generate a synthetic QA evaluation set from chunks")
+    parser.add_argument("--input", required=True, help="chunks as a JSON array or JSONL")
+    parser.add_argument("--output", default="-", help="JSONL evaluation set (default: stdout)")
+    parser.add_argument("--samples", type=int, default=100, help="chunks to sample")
+    parser.add_argument("--per-chunk", type=int, default=1, help="questions per chunk")
+    parser.add_argument("--per-document", type=int, default=0, help="max chunks per document (0 = no cap)")
+    parser.add_argument("--min-chars", type=int, default=200, help="skip chunks shorter than this")
+    parser.add_argument("--seed", type=int, default=0, help="sampling seed, for reproducible sets")
+    parser.add_argument("--model", default=None, help="model (default: OPENAI_EVAL_MODEL)")
+    args = parser.parse_args(argv)
+    logging.basicConfig(level=logging.INFO)
+
+    chunks = sample_chunks(
+        load_chunks(args.input), args.samples, seed=args.seed, min_chars=args.min_chars, per_document=args.per_document
+    )
+    client = get_openai_client()
+    out = sys.stdout if args.output == "-" else open(args.output, "w")
+    written = failed = 0
+    try:
+        for chunk in chunks:
+            try:
+                pairs = generate_pairs(chunk, per_chunk=args.per_chunk, client=client, model=args.model)
+            except Exception as exc:
+                failed += 1
+                logger.warning("generation failed for chunk %s: %s", chunk.get("id"), exc)
+                continue
+            for pair in pairs:
+                out.write(json.dumps(pair) + "\n")
+                written += 1
+            out.flush()
+    finally:
+        if out is not sys.stdout:
+            out.close()
+    logger.info("sampled %d chunks, wrote %d pairs, %d chunks failed", len(chunks), written, failed)
+    return 0 if written else 1
+
+
+if __name__ == "__main__":
+    sys.exit(main())
@@ -4,18 +4,22 @@ import fnmatch
 import json
 import logging
 import os
+import re
 import time
 from dataclasses import dataclass
-from typing import Any, Dict, List, Optional, Protocol
+from datetime import datetime, timezone
+from typing import Any, AsyncIterator, Dict, Iterator, List, Optional, Protocol
 
 import httpx
-from fastapi import Depends, FastAPI, Header, HTTPException
+from fastapi import Depends, FastAPI, Header, HTTPException, Query, Request
+from fastapi.concurrency import run_in_threadpool
+from fastapi.responses import StreamingResponse
 from pydantic import BaseModel, Field, validator
 
 # Import embed_texts from rag_core shared library
 from rag_core import embed_texts
 # Keep milvus_io from local lib (vector store specific)
-from lib import milvus_io
+from lib import corpus_health, feedback, grounding, milvus_io, sessions
 
 
 AUTH_TOKEN = os.environ.get("AUTH_TOKEN")
@@ -24,6 +28,10 @@ DEFAULT_BACKEND = os.environ.get("GATEWAY_BACKEND", "milvus").lower()
 DEFAULT_COLLECTION = os.environ.get("MILVUS_COLLECTION", "rag_gateway")
 REQUIRE_BACKEND = os.environ.get("GATEWAY_REQUIRE_BACKEND", "0").lower() in {"1", "true", "yes"}
 CONFIG_PATH = os.environ.get("GATEWAY_CONFIG")
+MMR_LAMBDA = os.environ.get("GATEWAY_MMR_LAMBDA")
+SESSION_TTL_SECONDS = int(os.environ.get("GATEWAY_SESSION_TTL_SECONDS", "3600"))
+SESSION_MAX = int(os.environ.get("GATEWAY_SESSION_MAX", "10000"))
+FEEDBACK_LOG = os.environ.get("GATEWAY_FEEDBACK_LOG")
 RERANK_SERVICE_URL = os.environ.get("RERANK_SERVICE_URL", "http://rerank-service.advanced-rag.svc.cluster.local:8003")
 logging.basicConfig(level=logging.INFO)
 logger = logging.getLogger("vector_gateway")
@@ -44,12 +52,17 @@ class Backend(Protocol):
     def upsert(self, docs: List[StoredDoc]) -> int:
         ...
 
-    def search(self, query_vector: List[float], query_text: str, top_k: int) -> List[StoredDoc]:
+    def search(
+        self, query_vector: List[float], query_text: str, top_k: int, filters: Optional[SearchFilters] = None
+    ) -> List[StoredDoc]:
         ...
 
     def count(self) -> int:
         ...
 
+    def get(self, doc_ids: List[str]) -> List[StoredDoc]:
+        ...
+
 
 class MemoryBackend:
     name = "memory"
@@ -62,15 +75,27 @@ class MemoryBackend:
     def upsert(self, docs: List[StoredDoc]) -> int:
         if len(self.store) + len(docs) > self.max_docs:
             raise RuntimeError("store limit reached")
+        # Hold metadata to the Milvus schema too, so switching backends
+        # does not change which documents are accepted.
+        dim = len(self.store[0].vector) if self.store else None
+        milvus_io.validate_chunks(
+            [{**doc.metadata, "chunk_id": doc.doc_id, "text": doc.text} for doc in docs],
+            [doc.vector for doc in docs],
+            dim,
+        )
         self.store.extend(docs)
         return len(docs)
 
-    def search(self, query_vector: List[float], query_text: str, top_k: int) -> List[StoredDoc]:
+    def search(
+        self, query_vector: List[float], query_text: str, top_k: int, filters: Optional[SearchFilters] = None
+    ) -> List[StoredDoc]:
         # Memory backend ignores query_text (no BM25 support)
         scored = []
         for doc in self.store:
             if len(doc.vector) != len(query_vector):
                 continue
+            if filters and not _matches_filters(doc.metadata, filters):
+                continue
             sim = _cosine_similarity(query_vector, doc.vector)
             # Convert cosine similarity (-1..1) to 0..1.
             norm = _normalize_score((sim + 1.0) / 2.0)
@@ -81,6 +106,10 @@ class MemoryBackend:
     def count(self) -> int:
         return len(self.store)
 
+    def get(self, doc_ids: List[str]) -> List[StoredDoc]:
+        wanted = set(doc_ids)
+        return [doc for doc in self.store if doc.doc_id in wanted]
+
 
 class MilvusBackend:
     name = "milvus"
@@ -121,14 +150,20 @@ class MilvusBackend:
                     "mime_type": doc.metadata.get("mime_type", ""),
                     "created_at_ts": doc.metadata.get("created_at_ts", now_ts),
                     "chunk_index": doc.metadata.get("chunk_index", idx),
+                    "tenant": doc.metadata.get("tenant", ""),
+                    "language": doc.metadata.get("language", ""),
+                    "tags": doc.metadata.get("tags", []),
                     "text": doc.text,
                 }
             )
             vectors.append(doc.vector)
+        self.milvus_io.validate_chunks(chunks, vectors, self.milvus_io.collection_dim(self.collection_name))
         self.milvus_io.insert_chunks(self.collection, chunks, vectors, sparse_vectors=None)
         return len(docs)
 
-    def search(self, query_vector: List[float], query_text: str, top_k: int) -> List[StoredDoc]:
+    def search(
+        self, query_vector: List[float], query_text: str, top_k: int, filters: Optional[SearchFilters] = None
+    ) -> List[StoredDoc]:
         results = self.milvus_io.hybrid_search(
             collection=self.collection_name,
             query_vector=query_vector,
@@ -136,6 +171,7 @@ class MilvusBackend:
             top_k=top_k,
             overfetch=max(20, top_k * 3),
             rrf_k=60,
+            filter_expr=_milvus_filter_expr(filters),
         )
         docs: List[StoredDoc] = []
         for hit in results[0] if results else []:
@@ -159,6 +195,13 @@ class MilvusBackend:
         # Milvus IO lacks count helper; return -1 to indicate unknown.
         return -1
 
+    def get(self, doc_ids: List[str]) -> List[StoredDoc]:
+        rows = self.milvus_io.get_chunks(self.collection_name, doc_ids)
+        return [
+            StoredDoc(doc_id=str(r.get("chunk_id", "")), text=r.get("text", ""), metadata=r, vector=[])
+            for r in rows
+        ]
+
 
 class UpsertDocument(BaseModel):
     doc_id: Optional[str] = Field(default=None, description="Caller-provided ID (optional)")
@@ -196,7 +239,14 @@ class SearchFilters(BaseModel):
     """Metadata filters for search."""
     file_name: Optional[str] = Field(default=None, description="Filter by exact file name")
     file_pattern: Optional[str] = Field(default=None, description="Filter by glob pattern (e.g., 'DMC-BRAKE*')")
+    file_path_prefix: Optional[str] = Field(default=None, description="Filter by file path prefix (e.g., 'manuals/brakes/')")
     mime_type: Optional[str] = Field(default=None, description="Filter by MIME type")
+    tenant: Optional[str] = Field(default=None, description="Filter by tenant")
+    language: Optional[str] = Field(default=None, description="Filter by document language (e.g., 'en')")
+    tags: Optional[List[str]] = Field(default=None, description="Match chunks carrying any of these tags")
+    section: Optional[str] = Field(default=None, description="Filter by exact section title")
+    created_after: Optional[datetime] = Field(default=None, description="Only chunks created at or after this time")
+    created_before: Optional[datetime] = Field(default=None, description="Only chunks created before this time")
 
 
 class SearchRequest(BaseModel):
@@ -206,6 +256,22 @@ class SearchRequest(BaseModel):
     context_window: int = Field(default=0, ge=0, le=10, description="Number of surrounding chunks to include (0=disabled)")
     filters: Optional[SearchFilters] = Field(default=None, description="Metadata filters")
     model: Optional[str] = Field(default=None, description="Embedding model override")
+    highlight: bool = Field(default=False, description="Return per-hit spans matching the query for UI highlighting")
+    mmr_lambda: Optional[float] = Field(
+        default=None, ge=0.0, le=1.0,
+        description="Re-select hits with Maximal Marginal Relevance: 1 = pure relevance, 0 = pure diversity "
+                    "(defaults to env GATEWAY_MMR_LAMBDA; unset disables)",
+    )
+    session_id: Optional[str] = Field(default=None, description="Conversation the search belongs to; hits are recorded to it")
+    session_policy: str = Field(
+        default="none",
+        description="How to treat chunks already shown in the session: none, boost, demote or exclude",
+    )
+    session_weight: float = Field(
+        default=0.25, ge=0.0, le=1.0,
+        description="For boost/demote, the fraction of the candidate list a shown chunk moves up or down",
+    )
+    record_session: bool = Field(default=True, description="Record the returned hits to the session")
 
     @validator("top_k")
     def _validate_top_k(cls, v: int) -> int:
@@ -213,6 +279,12 @@ class SearchRequest(BaseModel):
             raise ValueError("top_k must be > 0")
         return v
 
+    @validator("session_policy")
+    def _validate_session_policy(cls, v: str) -> str:
+        if v not in {"none", "boost", "demote", "exclude"}:
+            raise ValueError("session_policy must be none, boost, demote or exclude")
+        return v
+
 
 class SurroundingChunk(BaseModel):
     """A chunk surrounding the main search hit."""
@@ -221,12 +293,28 @@ class SurroundingChunk(BaseModel):
     page: int = -1
 
 
+class HighlightSpan(BaseModel):
+    """A span of a hit's text that matched the query.
+
+    kind is "term" for a query term found in the text (the BM25 side of a
+    search) or "sentence" for the sentence most similar to the query vector.
+    Offsets are character offsets into the hit text.
+    """
+    start: int
+    end: int
+    text: str
+    kind: str
+    score: Optional[float] = None
+
+
 class SearchHit(BaseModel):
     doc_id: str
     text: str
     score: float
     metadata: Dict[str, Any]
     surrounding_chunks: List[SurroundingChunk] = Field(default_factory=list)
+    highlights: List[HighlightSpan] = Field(default_factory=list)
+    previously_shown: int = Field(default=0, description="Times this chunk was returned earlier in the session")
 
 
 class SearchResponse(BaseModel):
@@ -236,6 +324,7 @@ class SearchResponse(BaseModel):
     backend: str
     collection: str
     reranked: bool = False
+    diversified: bool = False
 
 
 def _auth_dependency(authorization: str = Header(None), x_api_key: str = Header(None)) -> None:
@@ -285,6 +374,8 @@ def _init_backend() -> Backend:
 
 
 BACKEND = _init_backend()
+SESSIONS = sessions.SessionStore(ttl_seconds=SESSION_TTL_SECONDS, max_sessions=SESSION_MAX)
+FEEDBACK = feedback.FeedbackStore(FEEDBACK_LOG)
 
 
 @app.get("/healthz")
@@ -292,6 +383,38 @@ def healthz() -> dict[str, Any]:
     return {"status": "ok", "backend": BACKEND.name, "count": BACKEND.count()}
 
 
+def _write_milvus(collection: str, docs: List[StoredDoc]) -> int:
+    """Insert embedded documents into a Milvus collection, creating it if needed.
+
+    Every document is checked before any is written; a new collection takes
+    the dimension of the vectors.
+    """
+    chunks = []
+    now_ts = int(time.time())
+    for idx, doc in enumerate(docs):
+        chunks.append({
+            "chunk_id": doc.doc_id,
+            "file_name": doc.metadata.get("file_name", ""),
+            "file_path": doc.metadata.get("file_path", ""),
+            "page": doc.metadata.get("page", -1),
+            "section": doc.metadata.get("section", ""),
+            "mime_type": doc.metadata.get("mime_type", ""),
+            "created_at_ts": doc.metadata.get("created_at_ts", now_ts),
+            "chunk_index": doc.metadata.get("chunk_index", idx),
+            "tenant": doc.metadata.get("tenant", ""),
+            "language": doc.metadata.get("language", ""),
+            "tags": doc.metadata.get("tags", []),
+            "text": doc.text,
+        })
+    vectors = [doc.vector for doc in docs]
+    dim = milvus_io.collection_dim(collection)
+    milvus_io.validate_chunks(chunks, vectors, dim)
+    # Get or create collection (never drops existing data)
+    handle = milvus_io.get_or_create_collection(collection, dim=dim or len(vectors[0]))
+    milvus_io.insert_chunks(handle, chunks, vectors, sparse_vectors=None)
+    return len(chunks)
+
+
 @app.post("/upsert", response_model=UpsertResponse)
 def upsert(request: UpsertRequest, _: None = Depends(_auth_dependency)) -> UpsertResponse:
     collection = request.collection or DEFAULT_COLLECTION
@@ -305,44 +428,31 @@ def upsert(request: UpsertRequest, _: None = Depends(_auth_dependency)) -> Upser
 
     # For Milvus backend, use collection-specific upsert
     if BACKEND.name == "milvus":
+        now_ts = int(time.time())
+        stored = [
+            StoredDoc(doc_id=doc.doc_id or f"doc-{idx}-{now_ts}", text=doc.text, metadata=doc.metadata, vector=vec)
+            for idx, (doc, vec) in enumerate(zip(request.documents, vectors))
+        ]
         try:
-            # Get or create collection (never drops existing data)
-            handle = milvus_io.get_or_create_collection(collection)
-
-            # Build chunks for insertion
-            chunks = []
-            now_ts = int(time.time())
-            for idx, doc in enumerate(request.documents):
-                doc_id = doc.doc_id or f"doc-{idx}-{now_ts}"
-                chunks.append({
-                    "chunk_id": doc_id,
-                    "file_name": doc.metadata.get("file_name", ""),
-                    "file_path": doc.metadata.get("file_path", ""),
-                    "page": doc.metadata.get("page", -1),
-                    "section": doc.metadata.get("section", ""),
-                    "mime_type": doc.metadata.get("mime_type", ""),
-                    "created_at_ts": doc.metadata.get("created_at_ts", now_ts),
-                    "chunk_index": doc.metadata.get("chunk_index", idx),
-                    "text": doc.text,
-                })
-
-            # Insert into collection
-            milvus_io.insert_chunks(handle, chunks, vectors, sparse_vectors=None)
-            inserted = len(chunks)
+            inserted = _write_milvus(collection, stored)
             logger.info("upserted %d docs to collection=%s backend=%s", inserted, collection, BACKEND.name)
             return UpsertResponse(inserted=inserted, total=-1, backend=BACKEND.name, collection=collection)
 
+        except milvus_io.SchemaError as exc:
+            raise HTTPException(status_code=422, detail=f"documents do not match collection {collection}: {exc}")
         except Exception as exc:
             logger.error("upsert to collection=%s failed: %s", collection, exc)
             raise HTTPException(status_code=500, detail=f"Upsert failed: {exc}")
 
     # Memory backend fallback (doesn't support collections)
-    inserted = 0
-    for doc, vec in zip(request.documents, vectors):
-        doc_id = doc.doc_id or f"doc-{BACKEND.count()+1}"
-        stored = StoredDoc(doc_id=doc_id, text=doc.text, metadata=doc.metadata, vector=vec)
-        BACKEND.upsert([stored])
-        inserted += 1
+    stored = [
+        StoredDoc(doc_id=doc.doc_id or f"doc-{BACKEND.count()+idx+1}", text=doc.text, metadata=doc.metadata, vector=vec)
+        for idx, (doc, vec) in enumerate(zip(request.documents, vectors))
+    ]
+    try:
+        inserted = BACKEND.upsert(stored)
+    except milvus_io.SchemaError as exc:
+        raise HTTPException(status_code=422, detail=f"documents do not match the store: {exc}")
     logger.info("upserted %d docs backend=%s", inserted, BACKEND.name)
     return UpsertResponse(inserted=inserted, total=BACKEND.count(), backend=BACKEND.name, collection=collection)
 
@@ -387,34 +497,162 @@ def _rerank_documents(query: str, documents: List[str], top_k: Optional[int] = N
         return list(range(len(documents))), False
 
 
+def _mmr_lambda(request: SearchRequest) -> Optional[float]:
+    """Return the MMR lambda for a request, or None when MMR is disabled."""
+    if request.mmr_lambda is not None:
+        return request.mmr_lambda
+    if MMR_LAMBDA:
+        try:
+            return max(0.0, min(1.0, float(MMR_LAMBDA)))
+        except ValueError:
+            logger.warning("ignoring invalid GATEWAY_MMR_LAMBDA=%r", MMR_LAMBDA)
+    return None
+
+
+def _mmr_select(
+    query_vector: List[float], vectors: List[List[float]], k: int, lam: float, bonus: Optional[List[float]] = None
+) -> List[int]:
+    """Greedily pick k candidates by Maximal Marginal Relevance.
+
+    Each step takes the candidate maximising
+    lam * sim(query, doc) - (1 - lam) * max sim(doc, already selected),
+    so near-duplicates of earlier picks lose out to other relevant chunks.
+    bonus, if given, is added to each candidate's relevance. Returns
+    candidate indices in selection order.
+    """
+    relevance = [_cosine_similarity(query_vector, v) for v in vectors]
+    if bonus:
+        relevance = [r + b for r, b in zip(relevance, bonus)]
+    selected: List[int] = []
+    # redundancy[i] is candidate i's highest similarity to any selected one.
+    redundancy = [0.0] * len(vectors)
+    remaining = list(range(len(vectors)))
+    while remaining and len(selected) < k:
+        best = max(remaining, key=lambda i: lam * relevance[i] - (1 - lam) * redundancy[i])
+        selected.append(best)
+        remaining.remove(best)
+        for i in remaining:
+            redundancy[i] = max(redundancy[i], _cosine_similarity(vectors[i], vectors[best]))
+    return selected
+
+
+def _session_deltas(ids: List[str], shown: Dict[str, int], request: SearchRequest) -> List[Optional[float]]:
+    """Ranking adjustment per candidate for chunks already shown in the
+    session: +weight to boost, -weight to demote, None to exclude."""
+    policy, weight = request.session_policy, request.session_weight
+    deltas: List[Optional[float]] = []
+    for doc_id in ids:
+        if doc_id not in shown or policy == "none":
+            deltas.append(0.0)
+        elif policy == "exclude":
+            deltas.append(None)
+        else:
+            deltas.append(weight if policy == "boost" else -weight)
+    return deltas
+
+
+def _session_order(deltas: List[Optional[float]]) -> List[int]:
+    """Reorder candidates, given best first, by rank score plus session delta.
+
+    The rank score falls linearly from 1 to 0 down the list, so a weight of
+    0.25 moves a shown chunk a quarter of the candidate list. Rerankers
+    return an order rather than comparable scores, so ranks are used on
+    both backends. Excluded candidates are dropped.
+    """
+    n = len(deltas)
+    keep = [i for i, d in enumerate(deltas) if d is not None]
+    return sorted(keep, key=lambda i: (1 - i / n) + deltas[i], reverse=True)
+
+
+def _record_session(request: SearchRequest, hits: List[SearchHit]) -> None:
+    if request.session_id and request.record_session:
+        SESSIONS.record(request.session_id, [h.doc_id for h in hits], query=request.query)
+
+
+def _timestamp(value: Any) -> Optional[float]:
+    """Convert a unix timestamp or ISO-8601 string to seconds since the epoch."""
+    if isinstance(value, (int, float)):
+        return float(value)
+    if isinstance(value, str) and value:
+        try:
+            return datetime.fromisoformat(value.replace("Z", "+00:00")).timestamp()
+        except ValueError:
+            return None
+    return None
+
+
+def _matches_filters(metadata: Dict[str, Any], filters: SearchFilters) -> bool:
+    """Report whether a document's metadata satisfies every set filter."""
+    # The entity data may be nested under metadata.entity (from Milvus) or directly in metadata
+    entity = metadata.get("entity", metadata)
+
+    def field(name: str) -> Any:
+        return entity.get(name) or metadata.get(name)
+
+    # exact matches
+    for name in ("file_name", "mime_type", "tenant", "language", "section"):
+        want = getattr(filters, name)
+        if want and (field(name) or "") != want:
+            return False
+
+    # file_pattern glob match
+    if filters.file_pattern and not fnmatch.fnmatch(field("file_name") or "", filters.file_pattern):
+        return False
+
+    if filters.file_path_prefix and not (field("file_path") or "").startswith(filters.file_path_prefix):
+        return False
+
+    # tags match if any requested tag is present
+    if filters.tags and not set(filters.tags) & set(field("tags") or []):
+        return False
+
+    # created_at range, half-open
+    if filters.created_after or filters.created_before:
+        created = _timestamp(field("created_at_ts") or field("created_at"))
+        if created is None:
+            return False
+        if filters.created_after and created < filters.created_after.timestamp():
+            return False
+        if filters.created_before and created >= filters.created_before.timestamp():
+            return False
+    return True
+
+
 def _apply_filters(hits: List[Dict[str, Any]], filters: Optional[SearchFilters]) -> List[Dict[str, Any]]:
     """Apply metadata filters to search hits."""
     if not filters:
         return hits
+    return [hit for hit in hits if _matches_filters(hit.get("metadata", {}), filters)]
 
-    filtered = []
-    for hit in hits:
-        metadata = hit.get("metadata", {})
-        # The entity data may be nested under metadata.entity (from Milvus) or directly in metadata
-        entity = metadata.get("entity", metadata)
-        file_name = entity.get("file_name", "") or metadata.get("file_name", "")
-        mime_type = entity.get("mime_type", "") or metadata.get("mime_type", "")
 
-        # file_name exact match
-        if filters.file_name and file_name != filters.file_name:
-            continue
-
-        # file_pattern glob match
-        if filters.file_pattern and not fnmatch.fnmatch(file_name, filters.file_pattern):
-            continue
+def _like_prefix(prefix: str) -> str:
+    """Return a `like` pattern matching values that start with prefix literally."""
+    escaped = prefix.replace("\\", "\\\\").replace("%", "\\%").replace("_", "\\_")
+    return escaped + "%"
 
-        # mime_type exact match
-        if filters.mime_type and mime_type != filters.mime_type:
-            continue
 
-        filtered.append(hit)
+def _milvus_filter_expr(filters: Optional[SearchFilters]) -> str:
+    """Translate filters to a Milvus boolean expression.
 
-    return filtered
+    file_pattern accepts full glob syntax, which Milvus `like` cannot express,
+    so it is left to _apply_filters after the search.
+    """
+    if not filters:
+        return ""
+    clauses = []
+    for name in ("file_name", "mime_type", "tenant", "language", "section"):
+        want = getattr(filters, name)
+        if want:
+            clauses.append(f"{name} == {json.dumps(want)}")
+    if filters.file_path_prefix:
+        clauses.append(f"file_path like {json.dumps(_like_prefix(filters.file_path_prefix))}")
+    if filters.tags:
+        clauses.append(f"array_contains_any(tags, {json.dumps(filters.tags)})")
+    if filters.created_after:
+        clauses.append(f"created_at >= {int(filters.created_after.timestamp())}")
+    if filters.created_before:
+        clauses.append(f"created_at < {int(filters.created_before.timestamp())}")
+    return " && ".join(clauses)
 
 
 def _get_surrounding_chunks(
@@ -439,6 +677,74 @@ def _get_surrounding_chunks(
         return []
 
 
+_WORD_RE = re.compile(r"\w+")
+_HIGHLIGHT_STOPWORDS = {
+    "a", "an", "and", "are", "as", "at", "be", "by", "for", "from", "how", "in", "is", "it",
+    "of", "on", "or", "that", "the", "to", "was", "what", "when", "where", "which", "who", "why", "with",
+}
+
+
+def _term_spans(query: str, text: str) -> List[HighlightSpan]:
+    """Find whole-word, case-insensitive occurrences of the query terms in text."""
+    terms = {t.lower() for t in _WORD_RE.findall(query)} - _HIGHLIGHT_STOPWORDS
+    if not terms:
+        return []
+    return [
+        HighlightSpan(start=m.start(), end=m.end(), text=m.group(), kind="term")
+        for m in _WORD_RE.finditer(text)
+        if m.group().lower() in terms
+    ]
+
+
+def _best_sentences(
+    query_vector: List[float], texts: List[str], model: Optional[str]
+) -> List[Optional[HighlightSpan]]:
+    """Pick the sentence of each text most similar to the query vector.
+
+    All sentences are embedded in a single call. Returns None for texts
+    without sentences or when embedding fails.
+    """
+    per_text = [grounding.split_sentences(t) for t in texts]
+    sentences = [texts[i][s:e] for i, spans in enumerate(per_text) for s, e in spans]
+    if not sentences:
+        return [None] * len(texts)
+    try:
+        vectors = embed_texts(sentences, model=model, prefer_service=True)
+    except Exception as exc:
+        logger.warning("sentence embedding for highlights failed: %s", exc)
+        return [None] * len(texts)
+    if len(vectors) != len(sentences):
+        return [None] * len(texts)
+
+    best: List[Optional[HighlightSpan]] = []
+    pos = 0
+    for text, spans in zip(texts, per_text):
+        top: Optional[HighlightSpan] = None
+        for start, end in spans:
+            sim = _cosine_similarity(query_vector, vectors[pos])
+            pos += 1
+            if top is None or sim > (top.score or 0.0):
+                top = HighlightSpan(start=start, end=end, text=text[start:end], kind="sentence", score=sim)
+        best.append(top)
+    return best
+
+
+def _add_highlights(
+    hits: List[SearchHit], query: str, query_vector: List[float], model: Optional[str], lexical: bool
+) -> None:
+    """Attach highlight spans to hits in place.
+
+    Every hit gets its highest-similarity sentence; lexical (BM25/hybrid)
+    searches also get the query-term matches.
+    """
+    sentences = _best_sentences(query_vector, [h.text for h in hits], model)
+    for hit, sentence in zip(hits, sentences):
+        spans = _term_spans(query, hit.text) if lexical else []
+        if sentence is not None:
+            spans.append(sentence)
+        hit.highlights = sorted(spans, key=lambda s: (s.start, s.end))
+
+
 @app.post("/search", response_model=SearchResponse)
 def search(request: SearchRequest, _: None = Depends(_auth_dependency)) -> SearchResponse:
     start = time.time()
@@ -453,18 +759,34 @@ def search(request: SearchRequest, _: None = Depends(_auth_dependency)) -> Searc
                 collection=collection, reranked=False
             )
         qvec = embed_texts([request.query], model=request.model, prefer_service=True)[0]
-        docs = BACKEND.search(qvec, query_text=request.query, top_k=request.top_k)
+        lam = _mmr_lambda(request)
+        shown = SESSIONS.shown(request.session_id) if request.session_id else {}
+        adjust = bool(shown) and request.session_policy != "none"
+        fetch = request.top_k * 4 if lam is not None or adjust else request.top_k
+        docs = BACKEND.search(qvec, query_text=request.query, top_k=fetch, filters=request.filters)
+        deltas: Optional[List[float]] = None
+        if adjust:
+            all_deltas = _session_deltas([d.doc_id for d in docs], shown, request)
+            order = _session_order(all_deltas)
+            docs, deltas = [docs[i] for i in order], [all_deltas[i] for i in order]
+        if lam is not None:
+            docs = [docs[i] for i in _mmr_select(qvec, [d.vector for d in docs], request.top_k, lam, bonus=deltas)]
+        docs = docs[:request.top_k]
         scored_hits: List[SearchHit] = []
         for doc in docs:
             score = _cosine_similarity(qvec, doc.vector) if doc.vector else doc.metadata.get("score", 0.0)
             score = _normalize_score(score)
             scored_hits.append(SearchHit(
-                doc_id=doc.doc_id, text=doc.text, metadata=doc.metadata, score=score
+                doc_id=doc.doc_id, text=doc.text, metadata=doc.metadata, score=score,
+                previously_shown=shown.get(doc.doc_id, 0),
             ))
+        if request.highlight:
+            _add_highlights(scored_hits, request.query, qvec, request.model, lexical=False)
+        _record_session(request, scored_hits)
         latency_ms = int((time.time() - start) * 1000)
         return SearchResponse(
             hits=scored_hits, count=len(scored_hits), latency_ms=latency_ms,
-            backend=BACKEND.name, collection=collection, reranked=False
+            backend=BACKEND.name, collection=collection, reranked=False, diversified=lam is not None
         )
 
     # Milvus backend - use enhanced search
@@ -472,8 +794,12 @@ def search(request: SearchRequest, _: None = Depends(_auth_dependency)) -> Searc
         # Embed the query
         qvec = embed_texts([request.query], model=request.model, prefer_service=True)[0]
 
-        # Overfetch for filtering and reranking
-        overfetch = max(request.top_k * 4, 50) if request.filters else request.top_k * 2
+        # Overfetch for filtering, reranking, session adjustment and MMR
+        lam = _mmr_lambda(request)
+        shown = SESSIONS.shown(request.session_id) if request.session_id else {}
+        adjust = bool(shown) and request.session_policy != "none"
+        widen = lam is not None or adjust
+        overfetch = max(request.top_k * 4, 50) if request.filters or widen else request.top_k * 2
 
         # Hybrid search
         results = milvus_io.hybrid_search(
@@ -483,6 +809,7 @@ def search(request: SearchRequest, _: None = Depends(_auth_dependency)) -> Searc
             top_k=overfetch,
             overfetch=overfetch,
             rrf_k=60,
+            filter_expr=_milvus_filter_expr(request.filters),
         )
 
         # Convert to hit dicts
@@ -501,15 +828,36 @@ def search(request: SearchRequest, _: None = Depends(_auth_dependency)) -> Searc
         # Apply filters
         filtered_hits = _apply_filters(raw_hits, request.filters)
 
-        # Rerank (always on, graceful fallback)
+        # Rerank (always on, graceful fallback); keep a candidate pool for MMR
         reranked = False
+        pool = request.top_k * 4 if widen else request.top_k
         if filtered_hits:
             texts = [h["text"] for h in filtered_hits]
-            rerank_indices, rerank_success = _rerank_documents(request.query, texts, top_k=request.top_k)
+            rerank_indices, rerank_success = _rerank_documents(request.query, texts, top_k=pool)
             reranked = rerank_success
             if rerank_success:
                 filtered_hits = [filtered_hits[i] for i in rerank_indices if i < len(filtered_hits)]
 
+        # Boost, demote or drop chunks already shown in the session
+        filtered_hits = filtered_hits[:pool]
+        deltas: Optional[List[float]] = None
+        if adjust:
+            all_deltas = _session_deltas([h["doc_id"] for h in filtered_hits], shown, request)
+            order = _session_order(all_deltas)
+            filtered_hits, deltas = [filtered_hits[i] for i in order], [all_deltas[i] for i in order]
+
+        # Diversify with MMR (Milvus does not return vectors, so re-embed the pool)
+        diversified = False
+        if lam is not None and len(filtered_hits) > request.top_k:
+            try:
+                vectors = embed_texts([h["text"] for h in filtered_hits], model=request.model, prefer_service=True)
+                if len(vectors) == len(filtered_hits):
+                    selected = _mmr_select(qvec, vectors, request.top_k, lam, bonus=deltas)
+                    filtered_hits = [filtered_hits[i] for i in selected]
+                    diversified = True
+            except Exception as exc:
+                logger.warning("mmr embedding failed, keeping ranked order: %s", exc)
+
         # Limit to top_k
         filtered_hits = filtered_hits[:request.top_k]
 
@@ -533,13 +881,17 @@ def search(request: SearchRequest, _: None = Depends(_auth_dependency)) -> Searc
                 score=hit["score"],
                 metadata=hit["metadata"],
                 surrounding_chunks=surrounding,
+                previously_shown=shown.get(hit["doc_id"], 0),
             ))
+        if request.highlight:
+            _add_highlights(scored_hits, request.query, qvec, request.model, lexical=True)
+        _record_session(request, scored_hits)
 
         latency_ms = int((time.time() - start) * 1000)
         logger.info(
-            "search collection=%s query=%r top_k=%d filters=%s context_window=%d reranked=%s hits=%d latency_ms=%d",
+            "search collection=%s query=%r top_k=%d filters=%s context_window=%d reranked=%s diversified=%s hits=%d latency_ms=%d",
             collection, request.query[:50], request.top_k, bool(request.filters),
-            request.context_window, reranked, len(scored_hits), latency_ms
+            request.context_window, reranked, diversified, len(scored_hits), latency_ms
         )
 
         return SearchResponse(
@@ -549,6 +901,7 @@ def search(request: SearchRequest, _: None = Depends(_auth_dependency)) -> Searc
             backend="milvus",
             collection=collection,
             reranked=reranked,
+            diversified=diversified,
         )
 
     except Exception as exc:
@@ -556,6 +909,253 @@ def search(request: SearchRequest, _: None = Depends(_auth_dependency)) -> Searc
         raise HTTPException(status_code=500, detail=f"Search failed: {exc}")
 
 
+# ============================================================================
+# Answer Grounding
+# ============================================================================
+
+
+class GroundRequest(BaseModel):
+    answer: str = Field(description="Generated answer to verify")
+    chunk_ids: List[str] = Field(description="IDs of the chunks the answer was generated from")
+    collection: Optional[str] = Field(default=None, description="Collection holding the chunks (defaults to env MILVUS_COLLECTION)")
+    min_score: float = Field(default=0.6, ge=0.0, le=1.0, description="Minimum fuzzy match score for a span to support a sentence")
+
+    @validator("chunk_ids")
+    def _validate_chunk_ids(cls, v: List[str]) -> List[str]:
+        if not v:
+            raise ValueError("chunk_ids must be non-empty")
+        return v
+
+
+class GroundingSpan(BaseModel):
+    """A chunk span supporting an answer sentence; offsets index the chunk text."""
+    chunk_id: str
+    start: int
+    end: int
+    text: str
+    score: float
+    match: str = Field(description="exact or fuzzy")
+
+
+class GroundedSentence(BaseModel):
+    """An answer sentence with the spans supporting it; offsets index the answer."""
+    text: str
+    start: int
+    end: int
+    supported: bool
+    best_score: float
+    spans: List[GroundingSpan] = Field(default_factory=list)
+
+
+class GroundResponse(BaseModel):
+    sentences: List[GroundedSentence]
+    supported: int
+    total: int
+    support_ratio: float
+    missing_chunk_ids: List[str] = Field(default_factory=list)
+    latency_ms: int
+
+
+@app.post("/ground", response_model=GroundResponse)
+def ground(request: GroundRequest, _: None = Depends(_auth_dependency)) -> GroundResponse:
+    """Report which answer sentences are supported by spans of the cited chunks.
+
+    Unsupported sentences are candidate hallucinations. Matching is lexical
+    (exact, then fuzzy), so faithful paraphrases can score below min_score.
+    """
+    start = time.time()
+    collection = request.collection or DEFAULT_COLLECTION
+    try:
+        if BACKEND.name == "milvus":
+            rows = milvus_io.get_chunks(collection, request.chunk_ids)
+            chunks = {str(r.get("chunk_id", "")): r.get("text", "") for r in rows}
+        else:
+            chunks = {doc.doc_id: doc.text for doc in BACKEND.get(request.chunk_ids)}
+    except Exception as exc:
+        logger.error("ground chunk lookup failed: %s", exc)
+        raise HTTPException(status_code=500, detail=f"Chunk lookup failed: {exc}")
+
+    report = grounding.ground_answer(request.answer, chunks, min_score=request.min_score)
+    sentences = [
+        GroundedSentence(
+            text=s.text, start=s.start, end=s.end, supported=s.supported, best_score=s.best_score,
+            spans=[GroundingSpan(**vars(m)) for m in s.spans],
+        )
+        for s in report
+    ]
+    supported = sum(1 for s in sentences if s.supported)
+    missing = [c for c in request.chunk_ids if c not in chunks]
+    latency_ms = int((time.time() - start) * 1000)
+    logger.info(
+        "ground collection=%s chunks=%d missing=%d sentences=%d supported=%d latency_ms=%d",
+        collection, len(chunks), len(missing), len(sentences), supported, latency_ms
+    )
+    return GroundResponse(
+        sentences=sentences,
+        supported=supported,
+        total=len(sentences),
+        support_ratio=supported / len(sentences) if sentences else 1.0,
+        missing_chunk_ids=missing,
+        latency_ms=latency_ms,
+    )
+
+
+# ============================================================================
+# Session Memory
+# ============================================================================
+
+
+class RecordSessionRequest(BaseModel):
+    chunk_ids: List[str] = Field(description="Chunks shown to the user, e.g. the ones cited in an answer")
+    query: str = Field(default="", description="Query or question the chunks were retrieved for")
+
+
+class SessionRetrieval(BaseModel):
+    query: str
+    chunk_ids: List[str]
+    at: float
+
+
+class SessionResponse(BaseModel):
+    session_id: str
+    retrievals: List[SessionRetrieval] = Field(description="Recorded retrievals, oldest first")
+    shown: Dict[str, int] = Field(description="Times each chunk was shown in the session")
+    expires_at: Optional[float] = Field(default=None, description="Unix time the session expires unless used again")
+
+
+def _session_response(session_id: str) -> SessionResponse:
+    session = SESSIONS.get(session_id)
+    if session is None:
+        raise HTTPException(status_code=404, detail=f"Session '{session_id}' not found")
+    return SessionResponse(
+        session_id=session.session_id,
+        retrievals=[SessionRetrieval(**vars(r)) for r in session.retrievals],
+        shown=session.shown,
+        expires_at=session.updated_at + SESSION_TTL_SECONDS if SESSION_TTL_SECONDS > 0 else None,
+    )
+
+
+@app.post("/sessions/{session_id}/record", response_model=SessionResponse)
+def record_session(
+    session_id: str, request: RecordSessionRequest, _: None = Depends(_auth_dependency)
+) -> SessionResponse:
+    """Record chunks shown outside /search, such as those a client reranked or cited."""
+    SESSIONS.record(session_id, request.chunk_ids, query=request.query)
+    return _session_response(session_id)
+
+
+@app.get("/sessions/{session_id}", response_model=SessionResponse)
+def get_session(session_id: str, _: None = Depends(_auth_dependency)) -> SessionResponse:
+    return _session_response(session_id)
+
+
+@app.delete("/sessions/{session_id}")
+def delete_session(session_id: str, _: None = Depends(_auth_dependency)) -> dict[str, Any]:
+    if not SESSIONS.delete(session_id):
+        raise HTTPException(status_code=404, detail=f"Session '{session_id}' not found")
+    return {"deleted": session_id}
+
+
+# ============================================================================
+# Relevance Feedback
+# ============================================================================
+
+
+class FeedbackItem(BaseModel):
+    chunk_id: str
+    signal: str = Field(description="up, down or click")
+    query: str = Field(default="", description="Query the chunk was retrieved for")
+    session_id: str = Field(default="", description="Conversation the feedback came from")
+
+    @validator("signal")
+    def _validate_signal(cls, v: str) -> str:
+        if v not in feedback.SIGNALS:
+            raise ValueError("signal must be up, down or click")
+        return v
+
+
+class FeedbackRequest(BaseModel):
+    events: List[FeedbackItem] = Field(min_items=1, max_items=1000)
+    collection: Optional[str] = Field(default=None, description="Collection of the chunks (defaults to env MILVUS_COLLECTION)")
+
+
+class FeedbackResponse(BaseModel):
+    recorded: int
+    collection: str
+
+
+class ChunkFeedbackStats(BaseModel):
+    chunk_id: str
+    collection: str
+    up: int
+    down: int
+    clicks: int
+    votes: int
+    score: float = Field(description="(up - down) / (votes + 2): net approval in [-1, 1], shrunk towards 0 for few votes")
+    first_at: float
+    last_at: float
+    recent_queries: List[str]
+
+
+class FeedbackStatsResponse(BaseModel):
+    collection: str
+    chunks: List[ChunkFeedbackStats]
+    total: int = Field(description="Chunks matching min_votes before limit was applied")
+
+
+def _feedback_stats(stats: feedback.ChunkFeedback) -> ChunkFeedbackStats:
+    return ChunkFeedbackStats(**vars(stats), votes=stats.votes, score=round(stats.score, 4))
+
+
+@app.post("/feedback", response_model=FeedbackResponse)
+def record_feedback(request: FeedbackRequest, _: None = Depends(_auth_dependency)) -> FeedbackResponse:
+    """Record thumbs up/down and clicks on retrieved chunks."""
+    collection = request.collection or DEFAULT_COLLECTION
+    events = [
+        feedback.FeedbackEvent(
+            chunk_id=e.chunk_id, signal=e.signal, collection=collection, query=e.query, session_id=e.session_id
+        )
+        for e in request.events
+    ]
+    try:
+        FEEDBACK.record(events)
+    except OSError as exc:
+        logger.error("feedback log write failed: %s", exc)
+        raise HTTPException(status_code=500, detail=f"Feedback write failed: {exc}")
+    logger.info("feedback collection=%s events=%d", collection, len(events))
+    return FeedbackResponse(recorded=len(events), collection=collection)
+
+
+@app.get("/feedback/stats", response_model=FeedbackStatsResponse)
+def feedback_stats(
+    collection: Optional[str] = None,
+    order: str = "worst",
+    min_votes: int = 0,
+    limit: int = 100,
+    _: None = Depends(_auth_dependency),
+) -> FeedbackStatsResponse:
+    """Per-chunk feedback; order=worst lists pruning candidates, best lists boost candidates."""
+    collection = collection or DEFAULT_COLLECTION
+    try:
+        rows = FEEDBACK.stats(collection, order=order, min_votes=min_votes)
+    except ValueError as exc:
+        raise HTTPException(status_code=400, detail=str(exc))
+    return FeedbackStatsResponse(
+        collection=collection, chunks=[_feedback_stats(r) for r in rows[:max(limit, 0)]], total=len(rows)
+    )
+
+
+@app.get("/feedback/chunks/{chunk_id}", response_model=ChunkFeedbackStats)
+def chunk_feedback(
+    chunk_id: str, collection: Optional[str] = None, _: None = Depends(_auth_dependency)
+) -> ChunkFeedbackStats:
+    collection = collection or DEFAULT_COLLECTION
+    stats = FEEDBACK.get(collection, chunk_id)
+    if stats is None:
+        raise HTTPException(status_code=404, detail=f"No feedback for chunk '{chunk_id}'")
+    return _feedback_stats(stats)
+
+
 # ============================================================================
 # Collection Discovery Endpoints
 # ============================================================================
@@ -632,6 +1232,416 @@ def get_collection_stats(
         raise HTTPException(status_code=500, detail=f"Failed to get collection stats: {exc}")
 
 
+class ChunkRecord(BaseModel):
+    chunk_id: str
+    text: str
+    metadata: Dict[str, Any]
+
+
+class ChunkListResponse(BaseModel):
+    """Response for GET /chunks."""
+    chunks: List[ChunkRecord]
+    count: int
+    offset: int
+    next_offset: Optional[int] = Field(description="Offset of the next page; null on the last page")
+    backend: str
+    collection: str
+
+
+@app.get("/chunks", response_model=ChunkListResponse)
+def list_chunks(
+    collection: Optional[str] = None,
+    file_name: Optional[str] = Query(default=None, description="Document to list, by exact file name"),
+    file_path_prefix: Optional[str] = None,
+    section: Optional[str] = None,
+    language: Optional[str] = None,
+    tenant: Optional[str] = None,
+    tag: Optional[List[str]] = Query(default=None, description="Match chunks carrying any of these tags; repeatable"),
+    created_after: Optional[datetime] = None,
+    created_before: Optional[datetime] = None,
+    offset: int = Query(default=0, ge=0),
+    limit: int = Query(default=100, ge=1, le=1000),
+    _: None = Depends(_auth_dependency),
+) -> ChunkListResponse:
+    """List stored chunks matching the filters, a page at a time.
+
+    For inspecting what was ingested without raw database access. The
+    filters are those of /search, applied inside Milvus.
+    """
+    collection = collection or DEFAULT_COLLECTION
+    filters = SearchFilters(
+        file_name=file_name,
+        file_path_prefix=file_path_prefix,
+        section=section,
+        language=language,
+        tenant=tenant,
+        tags=tag,
+        created_after=created_after,
+        created_before=created_before,
+    )
+    # Fetch one extra row to learn whether another page follows.
+    if BACKEND.name == "milvus":
+        if offset + limit + 1 > milvus_io.MAX_QUERY_WINDOW:
+            raise HTTPException(
+                status_code=400,
+                detail=f"offset + limit must stay below {milvus_io.MAX_QUERY_WINDOW}; narrow the filters instead",
+            )
+        try:
+            rows = milvus_io.query_chunks(collection, _milvus_filter_expr(filters), offset, limit + 1)
+        except KeyError as exc:
+            raise HTTPException(status_code=404, detail=str(exc.args[0]))
+        except Exception as exc:
+            logger.error("list_chunks collection=%s failed: %s", collection, exc)
+            raise HTTPException(status_code=500, detail=f"Failed to list chunks: {exc}")
+        records = [
+            ChunkRecord(
+                chunk_id=str(r.get("chunk_id", "")),
+                text=r.get("text", ""),
+                metadata={k: v for k, v in r.items() if k not in {"chunk_id", "text"}},
+            )
+            for r in rows
+        ]
+    else:
+        # The memory backend has a single store and ignores collection.
+        matching = [doc for doc in getattr(BACKEND, "store", []) if _matches_filters(doc.metadata, filters)]
+        records = [
+            ChunkRecord(chunk_id=doc.doc_id, text=doc.text, metadata=doc.metadata)
+            for doc in matching[offset:offset + limit + 1]
+        ]
+
+    next_offset = offset + limit if len(records) > limit else None
+    records = records[:limit]
+    logger.info("list_chunks collection=%s offset=%d returned=%d", collection, offset, len(records))
+    return ChunkListResponse(
+        chunks=records,
+        count=len(records),
+        offset=offset,
+        next_offset=next_offset,
+        backend=BACKEND.name,
+        collection=collection,
+    )
+
+
+# Snapshots are NDJSON: a manifest line, one line per chunk with its
+# vector, and an end line with the chunk count and the cursor for the
+# next differential export. A missing end line marks a truncated export.
+SNAPSHOT_FORMAT = "vector-gateway-snapshot"
+SNAPSHOT_VERSION = 1
+SNAPSHOT_BATCH = 500
+
+
+def _snapshot_rows(collection: str, filters: SearchFilters) -> Iterator[Dict[str, Any]]:
+    """Yield the chunks of a collection matching filters, vectors included."""
+    if BACKEND.name == "milvus":
+        rows = milvus_io.iter_chunks(collection, milvus_io.ALL_FIELDS, filter_expr=_milvus_filter_expr(filters))
+        for row in rows:
+            yield {
+                "chunk_id": str(row.get("chunk_id", "")),
+                "text": row.get("text", ""),
+                "metadata": {k: v for k, v in row.items() if k not in {"chunk_id", "text", "vector"}},
+                "vector": [float(x) for x in row.get("vector") or []],
+            }
+    else:
+        # The memory backend has a single store and ignores collection.
+        for doc in list(getattr(BACKEND, "store", [])):
+            if _matches_filters(doc.metadata, filters):
+                yield {"chunk_id": doc.doc_id, "text": doc.text, "metadata": doc.metadata, "vector": doc.vector}
+
+
+@app.get("/collections/{collection_name}/snapshot")
+def export_snapshot(
+    collection_name: str,
+    tenant: Optional[str] = None,
+    file_path_prefix: Optional[str] = None,
+    created_after: Optional[datetime] = Query(
+        default=None, description="Only chunks written at or after this time; pass the previous export's cursor"
+    ),
+    _: None = Depends(_auth_dependency),
+) -> StreamingResponse:
+    """Stream the chunks of a tenant or corpus, vectors included, as an NDJSON snapshot.
+
+    POST the snapshot to /collections/{name}/snapshot of another gateway,
+    e.g. to promote an index from staging to production without
+    re-embedding. The end line's cursor, passed back as created_after,
+    makes the next export differential: it holds only chunks written
+    since.
+    """
+    if BACKEND.name == "milvus" and milvus_io.collection_dim(collection_name) is None:
+        raise HTTPException(status_code=404, detail=f"Collection {collection_name} not found")
+    filters = SearchFilters(tenant=tenant, file_path_prefix=file_path_prefix, created_after=created_after)
+    manifest = {
+        "type": "manifest",
+        "format": SNAPSHOT_FORMAT,
+        "version": SNAPSHOT_VERSION,
+        "collection": collection_name,
+        "backend": BACKEND.name,
+        "exported_at": datetime.now(timezone.utc).isoformat(),
+        "filters": json.loads(filters.json(exclude_none=True)),
+    }
+
+    def lines() -> Iterator[str]:
+        yield json.dumps(manifest) + "\n"
+        count, dim = 0, None
+        newest = created_after.timestamp() if created_after else None
+        try:
+            for row in _snapshot_rows(collection_name, filters):
+                count += 1
+                dim = dim or len(row["vector"])
+                meta = row["metadata"]
+                written = _timestamp(meta.get("created_at_ts", meta.get("created_at")))
+                if written is not None and (newest is None or written > newest):
+                    newest = written
+                yield json.dumps({"type": "chunk", **row}) + "\n"
+        except Exception as exc:
+            # The response has started; leaving out the end line tells the
+            # importer the snapshot is incomplete.
+            logger.error("export_snapshot collection=%s failed after %d chunks: %s", collection_name, count, exc)
+            return
+        # created_at has whole seconds, so the cursor is inclusive: chunks
+        # written in its second after this export are not missed, and
+        # those already exported may come again.
+        cursor = datetime.fromtimestamp(newest, timezone.utc).isoformat() if newest is not None else None
+        yield json.dumps({"type": "end", "count": count, "dim": dim, "cursor": cursor}) + "\n"
+        logger.info("export_snapshot collection=%s chunks=%d", collection_name, count)
+
+    return StreamingResponse(
+        lines(),
+        media_type="application/x-ndjson",
+        headers={"Content-Disposition": f'attachment; filename="{collection_name}.ndjson"'},
+    )
+
+
+class SnapshotImportResponse(BaseModel):
+    imported: int
+    backend: str
+    collection: str
+    source_collection: str
+
+
+async def _ndjson_records(request: Request) -> AsyncIterator[Dict[str, Any]]:
+    """Yield the JSON objects of an NDJSON request body as it arrives."""
+    buf = b""
+    line_no = 0
+    async for part in request.stream():
+        buf += part
+        *lines, buf = buf.split(b"\n")
+        for line in lines:
+            line_no += 1
+            if line.strip():
+                yield _snapshot_record(line, line_no)
+    if buf.strip():
+        yield _snapshot_record(buf, line_no + 1)
+
+
+def _snapshot_record(line: bytes, line_no: int) -> Dict[str, Any]:
+    try:
+        record = json.loads(line)
+    except ValueError as exc:
+        raise HTTPException(status_code=400, detail=f"snapshot line {line_no}: invalid JSON: {exc}")
+    if not isinstance(record, dict):
+        raise HTTPException(status_code=400, detail=f"snapshot line {line_no}: expected an object")
+    record["_line"] = line_no
+    return record
+
+
+def _snapshot_doc(record: Dict[str, Any]) -> StoredDoc:
+    """Convert a snapshot chunk line back to a document."""
+    chunk_id, text = record.get("chunk_id"), record.get("text")
+    metadata, vector = record.get("metadata") or {}, record.get("vector")
+    if not isinstance(chunk_id, str) or not isinstance(text, str) or not isinstance(metadata, dict):
+        raise HTTPException(
+            status_code=400, detail=f"snapshot line {record['_line']}: chunk_id, text and metadata are required"
+        )
+    if not isinstance(vector, list) or not vector:
+        raise HTTPException(status_code=400, detail=f"snapshot line {record['_line']}: chunk {chunk_id} has no vector")
+    # Milvus returns created_at as the timestamp the chunk was written with.
+    if "created_at" in metadata and "created_at_ts" not in metadata:
+        metadata = {**metadata, "created_at_ts": metadata["created_at"]}
+    return StoredDoc(doc_id=chunk_id, text=text, metadata=metadata, vector=vector)
+
+
+def _import_batch(collection: str, docs: List[StoredDoc]) -> int:
+    if BACKEND.name == "milvus":
+        return _write_milvus(collection, docs)
+    # The memory backend has a single store and ignores collection.
+    return BACKEND.upsert(docs)
+
+
+@app.post("/collections/{collection_name}/snapshot", response_model=SnapshotImportResponse)
+async def import_snapshot(
+    collection_name: str, request: Request, _: None = Depends(_auth_dependency)
+) -> SnapshotImportResponse:
+    """Load an NDJSON snapshot from GET /collections/{name}/snapshot into a collection.
+
+    Chunks keep their IDs, metadata and vectors, so nothing is embedded
+    again. The body is streamed and written SNAPSHOT_BATCH chunks at a
+    time: a snapshot that turns out to be truncated or invalid part way
+    through is rejected, but batches before the error stay written.
+    """
+    manifest: Optional[Dict[str, Any]] = None
+    end: Optional[Dict[str, Any]] = None
+    batch: List[StoredDoc] = []
+    seen = imported = 0
+    try:
+        async for record in _ndjson_records(request):
+            kind = record.get("type")
+            if manifest is None:
+                if kind != "manifest" or record.get("format") != SNAPSHOT_FORMAT:
+                    raise HTTPException(status_code=400, detail="not a snapshot: the first line must be its manifest")
+                if record.get("version") != SNAPSHOT_VERSION:
+                    raise HTTPException(
+                        status_code=400, detail=f"unsupported snapshot version {record.get('version')}"
+                    )
+                manifest = record
+            elif end is not None:
+                raise HTTPException(status_code=400, detail=f"snapshot line {record['_line']}: data after the end line")
+            elif kind == "chunk":
+                batch.append(_snapshot_doc(record))
+                seen += 1
+                if len(batch) >= SNAPSHOT_BATCH:
+                    imported += await run_in_threadpool(_import_batch, collection_name, batch)
+                    batch = []
+            elif kind == "end":
+                end = record
+            else:
+                raise HTTPException(status_code=400, detail=f"snapshot line {record['_line']}: unknown type {kind!r}")
+        if manifest is None:
+            raise HTTPException(status_code=400, detail="snapshot is empty")
+        if end is None or end.get("count") != seen:
+            raise HTTPException(
+                status_code=400,
+                detail=f"snapshot is truncated: read {seen} chunks, {imported} of them imported",
+            )
+        if batch:
+            imported += await run_in_threadpool(_import_batch, collection_name, batch)
+    except milvus_io.SchemaError as exc:
+        raise HTTPException(
+            status_code=422,
+            detail=f"snapshot does not match collection {collection_name} ({imported} chunks imported): {exc}",
+        )
+    except HTTPException:
+        raise
+    except Exception as exc:
+        logger.error("import_snapshot collection=%s failed after %d chunks: %s", collection_name, imported, exc)
+        raise HTTPException(status_code=500, detail=f"Snapshot import failed after {imported} chunks: {exc}")
+
+    logger.info(
+        "import_snapshot collection=%s source=%s chunks=%d",
+        collection_name, manifest.get("collection"), imported
+    )
+    return SnapshotImportResponse(
+        imported=imported,
+        backend=BACKEND.name,
+        collection=collection_name,
+        source_collection=str(manifest.get("collection", "")),
+    )
+
+
+class HistogramBucket(BaseModel):
+    """Values in [min, max); max is null for the open-ended last bucket."""
+    label: str
+    min: int
+    max: Optional[int]
+    count: int
+
+
+class DocumentChunks(BaseModel):
+    document: str
+    chunks: int
+
+
+class ChunksPerDocument(BaseModel):
+    min: int
+    median: float
+    max: int
+    histogram: List[HistogramBucket]
+    largest: List[DocumentChunks]
+
+
+class ChunkSizes(BaseModel):
+    median: float
+    histogram: List[HistogramBucket]
+    empty: int = Field(description="Chunks with no text besides whitespace")
+
+
+class DuplicateGroup(BaseModel):
+    count: int
+    chunk_ids: List[str] = Field(description="Up to five of the chunks sharing the text")
+
+
+class Duplicates(BaseModel):
+    duplicate_chunks: int = Field(description="Chunks whose text repeats an earlier chunk's")
+    ratio: float
+    groups: int
+    largest_groups: List[DuplicateGroup]
+
+
+class Staleness(BaseModel):
+    stale_days: int
+    stale_chunks: int
+    stale_ratio: float = Field(description="Share of dated chunks older than stale_days")
+    oldest: Optional[float]
+    newest: Optional[float]
+    undated: int
+    histogram: List[HistogramBucket]
+
+
+class CorpusHealthResponse(BaseModel):
+    """Response for GET /collections/{name}/health."""
+    collection: str
+    backend: str
+    total_chunks: int
+    documents: int
+    chunks_per_document: ChunksPerDocument
+    by_language: Dict[str, int]
+    by_section_depth: Dict[str, int]
+    size_chars: ChunkSizes
+    duplicates: Duplicates
+    staleness: Staleness
+    latency_ms: int
+
+
+@app.get("/collections/{collection_name}/health", response_model=CorpusHealthResponse)
+def get_corpus_health(
+    collection_name: str, stale_days: int = 180, top: int = 10, _: None = Depends(_auth_dependency)
+) -> CorpusHealthResponse:
+    """Aggregate corpus statistics for an operations dashboard.
+
+    Scans the whole collection, so poll it every few minutes rather than
+    on every dashboard refresh.
+    """
+    start = time.time()
+    health = corpus_health.CorpusHealth(now=start, stale_days=stale_days, top=max(top, 0))
+    try:
+        if BACKEND.name == "milvus":
+            fields = ["chunk_id", "file_name", "file_path", "language", "section", "created_at", "text"]
+            for row in milvus_io.iter_chunks(collection_name, fields):
+                health.add(row)
+        else:
+            # The memory backend has a single store and ignores collection_name.
+            for doc in getattr(BACKEND, "store", []):
+                meta = doc.metadata
+                health.add({
+                    **meta,
+                    "chunk_id": doc.doc_id,
+                    "text": doc.text,
+                    "created_at": _timestamp(meta.get("created_at_ts", meta.get("created_at"))),
+                })
+    except KeyError as exc:
+        raise HTTPException(status_code=404, detail=str(exc.args[0]))
+    except Exception as exc:
+        logger.error("corpus health for collection=%s failed: %s", collection_name, exc)
+        raise HTTPException(status_code=500, detail=f"Failed to compute corpus health: {exc}")
+
+    report = health.report()
+    latency_ms = int((time.time() - start) * 1000)
+    logger.info(
+        "corpus_health collection=%s chunks=%d documents=%d latency_ms=%d",
+        collection_name, report["total_chunks"], report["documents"], latency_ms
+    )
+    return CorpusHealthResponse(collection=collection_name, backend=BACKEND.name, latency_ms=latency_ms, **report)
+
+
 if __name__ == "__main__":
     import uvicorn
 
@@ -0,0 +1,166 @@
+"""Corpus health statistics for an operations dashboard.
+
+CorpusHealth consumes chunk rows one at a time, so a collection can be
+streamed through it without holding the chunks in memory; only a digest
+per distinct text and a counter per document are kept.
+"""
+
+from __future__ import annotations
+
+import hashlib
+import re
+import statistics
+from collections import Counter
+from typing import Any, Dict, List, Optional, Tuple
+
+DAY = 86400
+
+# (label, lower bound inclusive, upper bound exclusive or None)
+SIZE_BUCKETS: List[Tuple[str, int, Optional[int]]] = [
+    ("<100", 0, 100),
+    ("100-499", 100, 500),
+    ("500-999", 500, 1000),
+    ("1000-1999", 1000, 2000),
+    ("2000-3999", 2000, 4000),
+    (">=4000", 4000, None),
+]
+PER_DOCUMENT_BUCKETS: List[Tuple[str, int, Optional[int]]] = [
+    ("1", 1, 2),
+    ("2-5", 2, 6),
+    ("6-20", 6, 21),
+    ("21-100", 21, 101),
+    (">100", 101, None),
+]
+AGE_BUCKETS: List[Tuple[str, int, Optional[int]]] = [
+    ("<7d", 0, 7),
+    ("7-30d", 7, 30),
+    ("30-90d", 30, 90),
+    ("90-365d", 90, 365),
+    (">=365d", 365, None),
+]
+
+_SECTION_PATH_RE = re.compile(r"\s*(?:>|/|»)\s*")
+_SECTION_NUMBER_RE = re.compile(r"^(\d+(?:\.\d+)*)\.?\s")
+
+
+def section_depth(section: str) -> int:
+    """Nesting depth of a section title: the number of parts of a heading
+    path such as "Guide > Install > Linux", the number of levels of a
+    numbered heading such as "3.2.1 Ports", otherwise 1; 0 when empty."""
+    section = section.strip()
+    if not section:
+        return 0
+    parts = [p for p in _SECTION_PATH_RE.split(section) if p]
+    if len(parts) > 1:
+        return len(parts)
+    m = _SECTION_NUMBER_RE.match(section)
+    if m:
+        return len(m.group(1).split("."))
+    return 1
+
+
+def _bucket(value: float, buckets: List[Tuple[str, int, Optional[int]]]) -> str:
+    for label, low, high in buckets:
+        if value >= low and (high is None or value < high):
+            return label
+    return buckets[0][0]
+
+
+def _histogram(counts: Counter, buckets: List[Tuple[str, int, Optional[int]]]) -> List[Dict[str, Any]]:
+    return [{"label": label, "min": low, "max": high, "count": counts.get(label, 0)} for label, low, high in buckets]
+
+
+def _digest(text: str) -> bytes:
+    normalized = " ".join(text.split()).lower()
+    return hashlib.blake2b(normalized.encode("utf-8"), digest_size=16).digest()
+
+
+class CorpusHealth:
+    def __init__(self, now: float, stale_days: int = 180, top: int = 10) -> None:
+        self.now = now
+        self.stale_days = stale_days
+        self.top = top
+        self.total = 0
+        self.per_document: Counter = Counter()
+        self.languages: Counter = Counter()
+        self.depths: Counter = Counter()
+        self.sizes: Counter = Counter()
+        self.ages: Counter = Counter()
+        self.lengths: List[int] = []
+        self.empty = 0
+        self.stale = 0
+        self.oldest: Optional[float] = None
+        self.newest: Optional[float] = None
+        # digest -> (count, first few chunk ids)
+        self.texts: Dict[bytes, Tuple[int, List[str]]] = {}
+
+    def add(self, row: Dict[str, Any]) -> None:
+        """Count one chunk row with chunk_id, text and optional file_name,
+        file_path, language, section and created_at (unix seconds)."""
+        self.total += 1
+        doc = row.get("file_path") or row.get("file_name") or "(unknown)"
+        self.per_document[doc] += 1
+        self.languages[row.get("language") or "unknown"] += 1
+        self.depths[section_depth(row.get("section") or "")] += 1
+
+        text = row.get("text") or ""
+        self.lengths.append(len(text))
+        self.sizes[_bucket(len(text), SIZE_BUCKETS)] += 1
+        if not text.strip():
+            self.empty += 1
+        else:
+            digest = _digest(text)
+            count, ids = self.texts.get(digest, (0, []))
+            if len(ids) < 5:
+                ids.append(str(row.get("chunk_id", "")))
+            self.texts[digest] = (count + 1, ids)
+
+        created = row.get("created_at")
+        if isinstance(created, (int, float)) and created > 0:
+            self.oldest = created if self.oldest is None else min(self.oldest, created)
+            self.newest = created if self.newest is None else max(self.newest, created)
+            age_days = max(0.0, (self.now - created) / DAY)
+            self.ages[_bucket(age_days, AGE_BUCKETS)] += 1
+            if age_days >= self.stale_days:
+                self.stale += 1
+        else:
+            self.ages["unknown"] += 1
+
+    def report(self) -> Dict[str, Any]:
+        duplicates = sum(count - 1 for count, _ in self.texts.values())
+        groups = sorted((g for g in self.texts.values() if g[0] > 1), key=lambda g: g[0], reverse=True)
+        doc_sizes = list(self.per_document.values())
+        dated = self.total - self.ages.get("unknown", 0)
+        return {
+            "total_chunks": self.total,
+            "documents": len(self.per_document),
+            "chunks_per_document": {
+                "min": min(doc_sizes, default=0),
+                "median": statistics.median(doc_sizes) if doc_sizes else 0,
+                "max": max(doc_sizes, default=0),
+                "histogram": _histogram(Counter(_bucket(n, PER_DOCUMENT_BUCKETS) for n in doc_sizes), PER_DOCUMENT_BUCKETS),
+                "largest": [{"document": d, "chunks": n} for d, n in self.per_document.most_common(self.top)],
+            },
+            "by_language": dict(self.languages.most_common()),
+            "by_section_depth": {str(d): n for d, n in sorted(self.depths.items())},
+            "size_chars": {
+                "median": statistics.median(self.lengths) if self.lengths else 0,
+                "histogram": _histogram(self.sizes, SIZE_BUCKETS),
+                "empty": self.empty,
+            },
+            "duplicates": {
+                "duplicate_chunks": duplicates,
+                "ratio": duplicates / self.total if self.total else 0.0,
+                "groups": len(groups),
+                "largest_groups": [{"count": count, "chunk_ids": ids} for count, ids in groups[:self.top]],
+            },
+            "staleness": {
+                "stale_days": self.stale_days,
+                "stale_chunks": self.stale,
+                "stale_ratio": self.stale / dated if dated else 0.0,
+                "oldest": self.oldest,
+                "newest": self.newest,
+                "undated": self.ages.get("unknown", 0),
+                "histogram": _histogram(self.ages, AGE_BUCKETS),
+            },
+        }
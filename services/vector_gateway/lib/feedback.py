@@ -0,0 +1,139 @@
+"""Relevance feedback on retrieved chunks, aggregated per chunk.
+
+Events (thumbs up, thumbs down, click) are appended to an optional JSONL
+log and folded into per-chunk counters. The log is replayed on startup, so
+the counters survive restarts without a database; without a log they live
+in memory only.
+"""
+
+from __future__ import annotations
+
+import json
+import logging
+import os
+import threading
+import time
+from dataclasses import asdict, dataclass, field
+from typing import Dict, List, Optional, Tuple
+
+logger = logging.getLogger("vector_gateway.feedback")
+
+SIGNALS = ("up", "down", "click")
+
+# PRIOR pseudo-votes pull the score of rarely rated chunks towards 0, so one
+# thumbs-down does not outweigh a hundred mixed votes elsewhere.
+PRIOR = 2.0
+
+RECENT_QUERIES = 5
+
+
+@dataclass
+class FeedbackEvent:
+    chunk_id: str
+    signal: str
+    collection: str
+    query: str = ""
+    session_id: str = ""
+    at: float = 0.0
+
+
+@dataclass
+class ChunkFeedback:
+    chunk_id: str
+    collection: str
+    up: int = 0
+    down: int = 0
+    clicks: int = 0
+    first_at: float = 0.0
+    last_at: float = 0.0
+    recent_queries: List[str] = field(default_factory=list)
+
+    @property
+    def votes(self) -> int:
+        return self.up + self.down
+
+    @property
+    def score(self) -> float:
+        """Net approval in [-1, 1], shrunk towards 0 for few votes."""
+        return (self.up - self.down) / (self.votes + PRIOR)
+
+    def add(self, event: FeedbackEvent) -> None:
+        if event.signal == "up":
+            self.up += 1
+        elif event.signal == "down":
+            self.down += 1
+        else:
+            self.clicks += 1
+        if not self.first_at or event.at < self.first_at:
+            self.first_at = event.at
+        self.last_at = max(self.last_at, event.at)
+        if event.query:
+            if event.query in self.recent_queries:
+                self.recent_queries.remove(event.query)
+            self.recent_queries.append(event.query)
+            del self.recent_queries[:-RECENT_QUERIES]
+
+
+class FeedbackStore:
+    def __init__(self, path: Optional[str] = None) -> None:
+        self.path = path
+        self._stats: Dict[Tuple[str, str], ChunkFeedback] = {}
+        self._lock = threading.Lock()
+        if path and os.path.exists(path):
+            self._replay(path)
+
+    def _replay(self, path: str) -> None:
+        skipped = 0
+        with open(path, "r") as f:
+            for line in f:
+                try:
+                    self._add(FeedbackEvent(**json.loads(line)))
+                except (ValueError, TypeError):
+                    skipped += 1
+        if skipped:
+            logger.warning("skipped %d malformed feedback lines in %s", skipped, path)
+
+    def _add(self, event: FeedbackEvent) -> None:
+        key = (event.collection, event.chunk_id)
+        stats = self._stats.get(key)
+        if stats is None:
+            stats = self._stats[key] = ChunkFeedback(chunk_id=event.chunk_id, collection=event.collection)
+        stats.add(event)
+
+    def record(self, events: List[FeedbackEvent]) -> None:
+        """Log and aggregate events, stamping those without a time."""
+        now = time.time()
+        for event in events:
+            if event.signal not in SIGNALS:
+                raise ValueError(f"unknown signal {event.signal!r}")
+            if not event.at:
+                event.at = now
+        with self._lock:
+            if self.path:
+                with open(self.path, "a") as f:
+                    for event in events:
+                        f.write(json.dumps(asdict(event)) + "\n")
+            for event in events:
+                self._add(event)
+
+    def get(self, collection: str, chunk_id: str) -> Optional[ChunkFeedback]:
+        with self._lock:
+            return self._stats.get((collection, chunk_id))
+
+    def stats(self, collection: str, order: str = "worst", min_votes: int = 0) -> List[ChunkFeedback]:
+        """Per-chunk stats of a collection.
+
+        order is "worst" (lowest score first, for pruning), "best" (for
+        boosting), "clicks" or "recent".
+        """
+        with self._lock:
+            rows = [s for (c, _), s in self._stats.items() if c == collection and s.votes >= min_votes]
+        keys = {
+            "worst": lambda s: (s.score, -s.votes),
+            "best": lambda s: (-s.score, -s.votes),
+            "clicks": lambda s: -s.clicks,
+            "recent": lambda s: -s.last_at,
+        }
+        if order not in keys:
+            raise ValueError(f"unknown order {order!r}")
+        return sorted(rows, key=keys[order])
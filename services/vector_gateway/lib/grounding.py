@@ -0,0 +1,128 @@
+"""Answer grounding: match the sentences of a generated answer to the chunk
+spans that support them.
+
+A sentence is supported by a span when it appears in the chunk verbatim
+(ignoring case, punctuation and whitespace) or when a chunk sentence, or a
+pair of adjacent chunk sentences, is a close enough fuzzy match.
+"""
+
+from __future__ import annotations
+
+import difflib
+import re
+from dataclasses import dataclass, field
+from typing import Dict, List, Tuple
+
+WORD_RE = re.compile(r"\w+")
+SENTENCE_RE = re.compile(r"[^.!?\n]+(?:[.!?]+|$)", re.MULTILINE)
+
+# Function words carry no evidence, so they are ignored when measuring how
+# much of a sentence a span covers.
+STOPWORDS = {
+    "a", "an", "and", "are", "as", "at", "be", "been", "by", "can", "for", "from", "has", "have", "in",
+    "is", "it", "its", "of", "on", "or", "that", "the", "this", "to", "was", "were", "which", "will", "with",
+}
+
+
+@dataclass
+class SpanMatch:
+    chunk_id: str
+    start: int
+    end: int
+    text: str
+    score: float
+    match: str  # "exact" or "fuzzy"
+
+
+@dataclass
+class SentenceGrounding:
+    text: str
+    start: int
+    end: int
+    supported: bool
+    best_score: float
+    spans: List[SpanMatch] = field(default_factory=list)
+
+
+def split_sentences(text: str) -> List[Tuple[int, int]]:
+    """Split text into (start, end) sentence offsets with surrounding whitespace trimmed."""
+    spans = []
+    for m in SENTENCE_RE.finditer(text):
+        raw = m.group()
+        stripped = raw.strip()
+        if not stripped:
+            continue
+        start = m.start() + (len(raw) - len(raw.lstrip()))
+        spans.append((start, start + len(stripped)))
+    return spans
+
+
+def _words(text: str) -> List[str]:
+    return [w.lower() for w in WORD_RE.findall(text)]
+
+
+def _exact_span(words: List[str], chunk: str) -> Tuple[int, int] | None:
+    """Find the words in order in chunk, separated only by non-word characters."""
+    pattern = r"\W+".join(re.escape(w) for w in words)
+    m = re.search(r"\b" + pattern + r"\b", chunk, re.IGNORECASE)
+    return (m.start(), m.end()) if m else None
+
+
+def _fuzzy_score(words: List[str], candidate: str) -> float:
+    """Average the character-level similarity of the two word sequences with
+    the fraction of the sentence's content words found in the candidate."""
+    cand_words = _words(candidate)
+    ratio = difflib.SequenceMatcher(None, " ".join(words), " ".join(cand_words)).ratio()
+    content = {w for w in words if w not in STOPWORDS}
+    if not content:
+        return ratio
+    recall = len(content & set(cand_words)) / len(content)
+    return (ratio + recall) / 2
+
+
+def _candidates(chunk: str) -> List[Tuple[int, int]]:
+    """Chunk sentences and pairs of adjacent sentences, as answers often merge two."""
+    sentences = split_sentences(chunk)
+    pairs = [(a[0], b[1]) for a, b in zip(sentences, sentences[1:])]
+    return sentences + pairs
+
+
+def ground_answer(
+    answer: str, chunks: Dict[str, str], min_score: float = 0.6, max_spans: int = 3
+) -> List[SentenceGrounding]:
+    """Ground each answer sentence against the chunks.
+
+    Returns one entry per answer sentence containing at least one word. Spans
+    scoring at least min_score are listed best first, at most max_spans of
+    them; exact matches score 1.0.
+    """
+    candidates = {chunk_id: _candidates(text) for chunk_id, text in chunks.items()}
+    report = []
+    for start, end in split_sentences(answer):
+        sentence = answer[start:end]
+        words = _words(sentence)
+        if not words:
+            continue
+        matches: List[SpanMatch] = []
+        best = 0.0
+        for chunk_id, text in chunks.items():
+            exact = _exact_span(words, text)
+            if exact:
+                matches.append(SpanMatch(chunk_id, exact[0], exact[1], text[exact[0]:exact[1]], 1.0, "exact"))
+                best = 1.0
+                continue
+            top: SpanMatch | None = None
+            for s, e in candidates[chunk_id]:
+                score = _fuzzy_score(words, text[s:e])
+                if top is None or score > top.score:
+                    top = SpanMatch(chunk_id, s, e, text[s:e], score, "fuzzy")
+            if top is not None:
+                best = max(best, top.score)
+                if top.score >= min_score:
+                    matches.append(top)
+        matches.sort(key=lambda m: m.score, reverse=True)
+        report.append(SentenceGrounding(
+            text=sentence, start=start, end=end, supported=bool(matches),
+            best_score=round(best, 4), spans=matches[:max_spans],
+        ))
+    return report
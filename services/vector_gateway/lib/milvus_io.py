@@ -4,9 +4,10 @@ Supports Milvus 2.5+ with BM25 sparse vectors and hybrid search.
 """
 from __future__ import annotations
 
+import json
 import os
 from datetime import datetime
-from typing import Any, Dict, List
+from typing import Any, Dict, Iterator, List
 from uuid import uuid4
 
 from pymilvus import (
@@ -19,6 +20,116 @@ from pymilvus import (
 )
 
 
+# Scalar fields of the collection schema, in order, with their Milvus
+# parameters. ensure_collection and get_or_create_collection create
+# collections from it and validate_chunks derives its limits from it, so
+# rows can be checked before an insert instead of failing part way
+# through it.
+SCALAR_FIELDS = (
+    ("chunk_id", DataType.VARCHAR, {"is_primary": True, "max_length": 64}),
+    ("file_name", DataType.VARCHAR, {"max_length": 256}),
+    ("file_path", DataType.VARCHAR, {"max_length": 512}),
+    ("page", DataType.INT64, {}),
+    ("section", DataType.VARCHAR, {"max_length": 256}),
+    ("mime_type", DataType.VARCHAR, {"max_length": 64}),
+    ("created_at", DataType.INT64, {}),
+    ("chunk_index", DataType.INT64, {}),
+    ("tenant", DataType.VARCHAR, {"max_length": 64}),
+    ("language", DataType.VARCHAR, {"max_length": 16}),
+    ("tags", DataType.ARRAY, {"element_type": DataType.VARCHAR, "max_capacity": 64, "max_length": 128}),
+    ("text", DataType.VARCHAR, {"max_length": 32768, "enable_analyzer": True}),
+)
+_PARAMS = {name: params for name, _, params in SCALAR_FIELDS}
+
+# Metadata VARCHAR fields and their lengths. chunk_id and text are checked
+# on their own; created_at is converted to a timestamp by insert_chunks.
+STRING_FIELDS = {
+    name: params["max_length"]
+    for name, dtype, params in SCALAR_FIELDS
+    if dtype == DataType.VARCHAR and name not in {"chunk_id", "text"}
+}
+INT_FIELDS = tuple(name for name, dtype, _ in SCALAR_FIELDS if dtype == DataType.INT64 and name != "created_at")
+MAX_ID_LENGTH = _PARAMS["chunk_id"]["max_length"]
+MAX_TAGS = _PARAMS["tags"]["max_capacity"]
+MAX_TAG_LENGTH = _PARAMS["tags"]["max_length"]
+MAX_TEXT_LENGTH = _PARAMS["text"]["max_length"]
+# Every stored field except the BM25 sparse vector, which Milvus derives
+# from text.
+ALL_FIELDS = [name for name, _, _ in SCALAR_FIELDS] + ["vector"]
+
+
+class SchemaError(ValueError):
+    """Rows that do not fit the collection's vector dimension or schema."""
+
+    def __init__(self, problems: List[str]) -> None:
+        super().__init__("; ".join(problems))
+        self.problems = problems
+
+
+def validate_chunks(chunks: List[Dict[str, Any]], vectors: List[List[float]], dim: int | None) -> None:
+    """Check every row against the collection schema before anything is written.
+
+    Args:
+        chunks: Rows as passed to insert_chunks, identified by chunk_id
+        vectors: Dense vectors, one per row
+        dim: Dimension of the collection's vector field; None accepts any
+            dimension shared by all vectors
+
+    Raises:
+        SchemaError: listing every problem found, one per row and field
+    """
+    problems: List[str] = []
+    if len(chunks) != len(vectors):
+        raise SchemaError([f"got {len(vectors)} vectors for {len(chunks)} documents"])
+    if dim is None and vectors:
+        dim = len(vectors[0])
+    for idx, (chunk, vec) in enumerate(zip(chunks, vectors)):
+        name = f"document {idx}"
+        chunk_id = chunk.get("chunk_id")
+        if chunk_id is not None:
+            if not isinstance(chunk_id, str) or not chunk_id:
+                problems.append(f"{name}: doc_id must be a non-empty string")
+            elif len(chunk_id) > MAX_ID_LENGTH:
+                problems.append(f"{name}: doc_id is {len(chunk_id)} characters, the limit is {MAX_ID_LENGTH}")
+            else:
+                name = chunk_id
+        if len(vec) != dim:
+            problems.append(f"{name}: vector has dimension {len(vec)}, collection expects {dim}")
+        for field, limit in STRING_FIELDS.items():
+            value = chunk.get(field)
+            if value is None:
+                continue
+            if not isinstance(value, str):
+                problems.append(f"{name}: metadata.{field} must be a string, got {type(value).__name__}")
+            elif len(value) > limit:
+                problems.append(f"{name}: metadata.{field} is {len(value)} characters, the limit is {limit}")
+        for field in INT_FIELDS:
+            value = chunk.get(field)
+            if value is not None and (isinstance(value, bool) or not isinstance(value, int)):
+                problems.append(f"{name}: metadata.{field} must be an integer, got {type(value).__name__}")
+        tags = chunk.get("tags")
+        if tags is not None:
+            if not isinstance(tags, list) or not all(isinstance(t, str) for t in tags):
+                problems.append(f"{name}: metadata.tags must be a list of strings")
+            elif len(tags) > MAX_TAGS or any(len(t) > MAX_TAG_LENGTH for t in tags):
+                problems.append(f"{name}: metadata.tags allows {MAX_TAGS} tags of up to {MAX_TAG_LENGTH} characters")
+        if len(chunk.get("text") or "") > MAX_TEXT_LENGTH:
+            problems.append(f"{name}: text is longer than {MAX_TEXT_LENGTH} characters")
+    if problems:
+        raise SchemaError(problems)
+
+
+def collection_dim(collection: str) -> int | None:
+    """Return the dimension of a collection's vector field, or None if it does not exist."""
+    client = get_client()
+    if not client.has_collection(collection):
+        return None
+    for field in client.describe_collection(collection).get("fields", []):
+        if field.get("name") == "vector":
+            return int(field.get("params", {}).get("dim", 0)) or None
+    return None
+
+
 def get_client() -> MilvusClient:
     """Return a MilvusClient configured from env."""
     uri = os.environ.get("MILVUS_URI")
@@ -51,16 +162,15 @@ def ensure_collection(name: str, dim: int, sparse_dim: int | None = None) -> Dic
     if client.has_collection(name):
         return {"client": client, "collection": name}
 
+    _create_collection(client, name, dim)
+    return {"client": client, "collection": name}
+
+
+def _create_collection(client: MilvusClient, name: str, dim: int) -> None:
+    """Create a collection with the SCALAR_FIELDS schema, a dense vector and BM25."""
     schema = client.create_schema(auto_id=False, enable_dynamic_field=False)
-    schema.add_field("chunk_id", DataType.VARCHAR, is_primary=True, max_length=64)
-    schema.add_field("file_name", DataType.VARCHAR, max_length=256)
-    schema.add_field("file_path", DataType.VARCHAR, max_length=512)
-    schema.add_field("page", DataType.INT64)
-    schema.add_field("section", DataType.VARCHAR, max_length=256)
-    schema.add_field("mime_type", DataType.VARCHAR, max_length=64)
-    schema.add_field("created_at", DataType.INT64)
-    schema.add_field("chunk_index", DataType.INT64)
-    schema.add_field("text", DataType.VARCHAR, max_length=32768, enable_analyzer=True)
+    for field, dtype, params in SCALAR_FIELDS:
+        schema.add_field(field, dtype, **params)
     schema.add_field("vector", DataType.FLOAT_VECTOR, dim=dim)
     schema.add_field("sparse_vector", DataType.SPARSE_FLOAT_VECTOR)
 
@@ -82,7 +192,6 @@ def ensure_collection(name: str, dim: int, sparse_dim: int | None = None) -> Dic
         schema=schema,
         index_params=index_params,
     )
-    return {"client": client, "collection": name}
 
 
 def insert_chunks(
@@ -91,7 +200,7 @@ def insert_chunks(
     vectors: List[List[float]],
     sparse_vectors: List[Dict[int, float]] | None = None,
 ) -> None:
-    """Insert chunks with dense vectors. Sparse vectors auto-generated by BM25 function."""
+    """Insert or replace chunks with dense vectors. Sparse vectors auto-generated by BM25 function."""
     if len(chunks) != len(vectors):
         raise ValueError("chunks and vectors must have same length")
     if not chunks:
@@ -118,7 +227,7 @@ def insert_chunks(
     rows = []
     for idx, chunk in enumerate(chunks):
         chunk_index = int(chunk.get("chunk_index", idx))
-        base_id = chunk.get("id")
+        base_id = chunk.get("chunk_id") or chunk.get("id")
         if not base_id:
             base = chunk.get("file_name", "chunk")[:40]
             base_id = f"{base}-{chunk_index}-{uuid4().hex[:8]}"
@@ -134,13 +243,18 @@ def insert_chunks(
             "mime_type": chunk.get("mime_type", ""),
             "created_at": _to_timestamp(chunk.get("created_at_ts", chunk.get("created_at"))),
             "chunk_index": chunk_index,
+            "tenant": chunk.get("tenant") or "",
+            "language": chunk.get("language") or "",
+            "tags": [str(t) for t in chunk.get("tags") or []],
             "text": chunk.get("text", ""),
             "vector": vectors[idx],
         }
         # Note: sparse_vector is auto-generated by BM25 function from text field
         rows.append(row)
 
-    client.insert(collection_name=collection_name, data=rows)
+    # Upsert, so a chunk written again, e.g. by a re-sent differential
+    # snapshot, replaces the row with its chunk_id instead of duplicating it.
+    client.upsert(collection_name=collection_name, data=rows)
     # Flush to ensure data is immediately visible in stats and queries
     client.flush(collection_name=collection_name)
 
@@ -153,6 +267,7 @@ def hybrid_search(
     overfetch: int | None = None,
     rrf_k: int = 60,
     nprobe: int = 10,
+    filter_expr: str = "",
 ) -> List[Any]:
     """Execute Milvus hybrid search (dense + BM25) with RRF fusion.
 
@@ -164,6 +279,7 @@ def hybrid_search(
         overfetch: Number of candidates to fetch before fusion (default: max(top_k*4, 20))
         rrf_k: RRF parameter (higher = more weight to lower ranks)
         nprobe: Number of clusters to search for dense vectors
+        filter_expr: Milvus boolean expression applied to both searches before fusion
 
     Returns:
         List of search results with entity data and distances
@@ -181,6 +297,7 @@ def hybrid_search(
         anns_field="vector",
         param={"metric_type": "COSINE", "params": {"nprobe": nprobe}},
         limit=pool,
+        expr=filter_expr or None,
     )
 
     # Sparse vector search (BM25 keyword matching)
@@ -189,6 +306,7 @@ def hybrid_search(
         anns_field="sparse_vector",
         param={"metric_type": "BM25"},
         limit=pool,
+        expr=filter_expr or None,
     )
 
     # RRF fusion of dense and sparse results
@@ -208,6 +326,9 @@ def hybrid_search(
             "mime_type",
             "created_at",
             "chunk_index",
+            "tenant",
+            "language",
+            "tags",
             "text",
         ],
     )
@@ -218,6 +339,7 @@ def search(
     query_vector: List[float],
     top_k: int = 5,
     nprobe: int = 10,
+    filter_expr: str = "",
 ) -> List[Any]:
     """Execute dense-only vector search (fallback for when query_text not available)."""
     client = get_client()
@@ -232,6 +354,7 @@ def search(
         anns_field="vector",
         search_params={"metric_type": "COSINE", "params": {"nprobe": nprobe}},
         limit=top_k,
+        filter=filter_expr,
         output_fields=[
             "chunk_id",
             "file_name",
@@ -241,6 +364,9 @@ def search(
             "mime_type",
             "created_at",
             "chunk_index",
+            "tenant",
+            "language",
+            "tags",
             "text",
         ],
     )
@@ -295,6 +421,79 @@ def get_context_chunks(
     return sorted(results, key=lambda x: x.get("chunk_index", 0))
 
 
+def get_chunks(collection: str, chunk_ids: List[str]) -> List[Dict[str, Any]]:
+    """Fetch chunks by chunk_id; unknown IDs are skipped."""
+    if not chunk_ids:
+        return []
+    client = get_client()
+    if not client.has_collection(collection):
+        raise RuntimeError(f"Milvus collection {collection} not found")
+
+    client.load_collection(collection)
+    ids = ", ".join(json.dumps(str(c)) for c in chunk_ids)
+    return client.query(
+        collection_name=collection,
+        filter=f"chunk_id in [{ids}]",
+        output_fields=["chunk_id", "file_name", "section", "chunk_index", "text"],
+    )
+
+
+def iter_chunks(
+    collection: str, output_fields: List[str], batch_size: int = 1000, filter_expr: str = ""
+) -> Iterator[Dict[str, Any]]:
+    """Yield every chunk of a collection matching filter_expr, batch_size rows per query."""
+    client = get_client()
+    if not client.has_collection(collection):
+        raise KeyError(f"Collection {collection} not found")
+    client.load_collection(collection)
+    iterator = client.query_iterator(
+        collection_name=collection,
+        batch_size=batch_size,
+        filter=filter_expr,
+        output_fields=output_fields,
+    )
+    try:
+        while True:
+            batch = iterator.next()
+            if not batch:
+                break
+            yield from batch
+    finally:
+        iterator.close()
+
+
+# Milvus rejects queries whose offset plus limit exceeds this.
+MAX_QUERY_WINDOW = 16384
+
+
+def query_chunks(collection: str, filter_expr: str, offset: int, limit: int) -> List[Dict[str, Any]]:
+    """Return one page of the chunks matching filter_expr, without vectors."""
+    client = get_client()
+    if not client.has_collection(collection):
+        raise KeyError(f"Collection {collection} not found")
+    client.load_collection(collection)
+    return client.query(
+        collection_name=collection,
+        filter=filter_expr,
+        output_fields=[
+            "chunk_id",
+            "file_name",
+            "file_path",
+            "page",
+            "section",
+            "mime_type",
+            "created_at",
+            "chunk_index",
+            "tenant",
+            "language",
+            "tags",
+            "text",
+        ],
+        offset=offset,
+        limit=limit,
+    )
+
+
 def list_collections() -> List[str]:
     """List all available collections."""
     client = get_client()
@@ -318,37 +517,7 @@ def get_or_create_collection(name: str, dim: int = 1536) -> Dict[str, Any]:
         return {"client": client, "collection": name}
 
     # Create new collection with standard schema
-    schema = client.create_schema(auto_id=False, enable_dynamic_field=False)
-    schema.add_field("chunk_id", DataType.VARCHAR, is_primary=True, max_length=64)
-    schema.add_field("file_name", DataType.VARCHAR, max_length=256)
-    schema.add_field("file_path", DataType.VARCHAR, max_length=512)
-    schema.add_field("page", DataType.INT64)
-    schema.add_field("section", DataType.VARCHAR, max_length=256)
-    schema.add_field("mime_type", DataType.VARCHAR, max_length=64)
-    schema.add_field("created_at", DataType.INT64)
-    schema.add_field("chunk_index", DataType.INT64)
-    schema.add_field("text", DataType.VARCHAR, max_length=32768, enable_analyzer=True)
-    schema.add_field("vector", DataType.FLOAT_VECTOR, dim=dim)
-    schema.add_field("sparse_vector", DataType.SPARSE_FLOAT_VECTOR)
-
-    # BM25 function auto-generates sparse vectors from text field
-    bm25_function = Function(
-        name="text_bm25_emb",
-        input_field_names=["text"],
-        output_field_names=["sparse_vector"],
-        function_type=FunctionType.BM25,
-    )
-    schema.add_function(bm25_function)
-
-    index_params = client.prepare_index_params()
-    index_params.add_index(field_name="vector", index_type="AUTOINDEX", metric_type="COSINE")
-    index_params.add_index(field_name="sparse_vector", index_type="SPARSE_INVERTED_INDEX", metric_type="BM25")
-
-    client.create_collection(
-        collection_name=name,
-        schema=schema,
-        index_params=index_params,
-    )
+    _create_collection(client, name, dim)
     return {"client": client, "collection": name}
 
 
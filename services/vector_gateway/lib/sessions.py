@@ -0,0 +1,95 @@
+"""Session-scoped retrieval memory: which chunks each conversation has
+already been shown, so follow-up searches can boost or avoid them.
+
+Sessions live in process memory. They expire ttl_seconds after their last
+use, and the least recently used session is evicted once max_sessions is
+reached, so memory stays bounded without a cleanup job.
+"""
+
+from __future__ import annotations
+
+import threading
+import time
+from collections import OrderedDict
+from dataclasses import dataclass, field
+from typing import Callable, Dict, List, Optional
+
+
+@dataclass
+class Retrieval:
+    query: str
+    chunk_ids: List[str]
+    at: float
+
+
+@dataclass
+class Session:
+    session_id: str
+    updated_at: float
+    retrievals: List[Retrieval] = field(default_factory=list)
+    # shown counts how often each chunk was returned in this session.
+    shown: Dict[str, int] = field(default_factory=dict)
+
+
+class SessionStore:
+    def __init__(
+        self,
+        ttl_seconds: float = 3600,
+        max_sessions: int = 10000,
+        max_retrievals: int = 100,
+        clock: Callable[[], float] = time.time,
+    ) -> None:
+        self.ttl_seconds = ttl_seconds
+        self.max_sessions = max_sessions
+        self.max_retrievals = max_retrievals
+        self._clock = clock
+        self._sessions: "OrderedDict[str, Session]" = OrderedDict()
+        self._lock = threading.Lock()
+
+    def _live(self, session_id: str, now: float) -> Optional[Session]:
+        session = self._sessions.get(session_id)
+        if session is None:
+            return None
+        if self.ttl_seconds > 0 and now - session.updated_at > self.ttl_seconds:
+            del self._sessions[session_id]
+            return None
+        return session
+
+    def record(self, session_id: str, chunk_ids: List[str], query: str = "") -> None:
+        """Add a retrieval to a session, creating the session if needed."""
+        with self._lock:
+            now = self._clock()
+            session = self._live(session_id, now)
+            if session is None:
+                session = Session(session_id=session_id, updated_at=now)
+                self._sessions[session_id] = session
+                while len(self._sessions) > self.max_sessions:
+                    self._sessions.popitem(last=False)
+            session.updated_at = now
+            self._sessions.move_to_end(session_id)
+            session.retrievals.append(Retrieval(query=query, chunk_ids=list(chunk_ids), at=now))
+            # Only the history is capped; shown keeps counting every chunk.
+            del session.retrievals[:-self.max_retrievals]
+            for chunk_id in chunk_ids:
+                session.shown[chunk_id] = session.shown.get(chunk_id, 0) + 1
+
+    def get(self, session_id: str) -> Optional[Session]:
+        """Return a copy of a session, or None if it does not exist or has expired."""
+        with self._lock:
+            session = self._live(session_id, self._clock())
+            if session is None:
+                return None
+            return Session(session.session_id, session.updated_at, list(session.retrievals), dict(session.shown))
+
+    def shown(self, session_id: str) -> Dict[str, int]:
+        """Return how often each chunk was shown in a session."""
+        with self._lock:
+            session = self._live(session_id, self._clock())
+            return dict(session.shown) if session else {}
+
+    def delete(self, session_id: str) -> bool:
+        with self._lock:
+            return self._sessions.pop(session_id, None) is not None
+
+    def __len__(self) -> int:
+        return len(self._sessions)